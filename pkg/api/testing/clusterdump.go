@@ -0,0 +1,126 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kubernetes/pkg/api/legacyscheme"
+)
+
+// LoadClusterDump reads a cluster dump: a JSON document containing either a bare array of
+// objects or a List-style object with an "items" member. Items of kinds that are not
+// registered in the legacy scheme (custom resources, for example) are skipped so that
+// dumps taken from real clusters load without modification.
+func LoadClusterDump(path string) ([]runtime.Object, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		var list struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("%s: expected a JSON array of objects or a list with an items member: %v", path, err)
+		}
+		items = list.Items
+	}
+
+	decoder := legacyscheme.Codecs.UniversalDeserializer()
+	objs := make([]runtime.Object, 0, len(items))
+	for i, item := range items {
+		obj, _, err := decoder.Decode(item, nil, nil)
+		if runtime.IsNotRegisteredError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: item %d: %v", path, i, err)
+		}
+		objs = append(objs, obj)
+	}
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("%s: no objects of registered kinds in dump", path)
+	}
+	return objs, nil
+}
+
+// SanitizeClusterDump redacts the values that commonly carry sensitive payloads — secret
+// and config data, container environment variables, and annotations — in place. Each
+// value is replaced by filler of the same length so that the size distribution of the
+// dump, which is the property serialization benchmarks care about, is preserved.
+func SanitizeClusterDump(objs []runtime.Object) {
+	for _, obj := range objs {
+		switch t := obj.(type) {
+		case *v1.Secret:
+			for k, v := range t.Data {
+				t.Data[k] = filler(len(v))
+			}
+			for k, v := range t.StringData {
+				t.StringData[k] = string(filler(len(v)))
+			}
+		case *v1.ConfigMap:
+			for k, v := range t.Data {
+				t.Data[k] = string(filler(len(v)))
+			}
+			for k, v := range t.BinaryData {
+				t.BinaryData[k] = filler(len(v))
+			}
+		case *v1.Pod:
+			sanitizePodSpec(&t.Spec)
+		}
+
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			continue
+		}
+		annotations := accessor.GetAnnotations()
+		for k, v := range annotations {
+			annotations[k] = string(filler(len(v)))
+		}
+	}
+}
+
+func sanitizePodSpec(spec *v1.PodSpec) {
+	containers := make([]*v1.Container, 0, len(spec.InitContainers)+len(spec.Containers))
+	for i := range spec.InitContainers {
+		containers = append(containers, &spec.InitContainers[i])
+	}
+	for i := range spec.Containers {
+		containers = append(containers, &spec.Containers[i])
+	}
+	for _, container := range containers {
+		for i, env := range container.Env {
+			container.Env[i].Value = string(filler(len(env.Value)))
+		}
+		for i, arg := range container.Args {
+			container.Args[i] = string(filler(len(arg)))
+		}
+	}
+}
+
+func filler(n int) []byte {
+	return bytes.Repeat([]byte{'x'}, n)
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	cborserializer "k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+	jsonserializer "k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	"k8s.io/kubernetes/pkg/api/legacyscheme"
+)
+
+// clusterDumpEnv names a sanitized cluster dump to replay in place of synthetic objects.
+const clusterDumpEnv = "KUBE_BENCHMARK_CLUSTER_DUMP"
+
+const testDump = `{
+	"kind": "List",
+	"apiVersion": "v1",
+	"items": [
+		{
+			"kind": "Pod",
+			"apiVersion": "v1",
+			"metadata": {
+				"name": "pod",
+				"annotations": {"owner": "someone@example.com"}
+			},
+			"spec": {
+				"containers": [
+					{
+						"name": "app",
+						"image": "registry.example.com/app:v1",
+						"args": ["--token=hunter2"],
+						"env": [{"name": "PASSWORD", "value": "hunter2"}]
+					}
+				]
+			}
+		},
+		{
+			"kind": "Secret",
+			"apiVersion": "v1",
+			"metadata": {"name": "credentials"},
+			"data": {"password": "aHVudGVyMg=="}
+		},
+		{
+			"kind": "Widget",
+			"apiVersion": "example.com/v1",
+			"metadata": {"name": "custom-resource"}
+		}
+	]
+}`
+
+func writeTestDump(t testing.TB, contents string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "clusterdump")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "dump.json")
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return path
+}
+
+func TestLoadClusterDump(t *testing.T) {
+	objs, err := LoadClusterDump(writeTestDump(t, testDump))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The unregistered Widget kind is skipped, not an error.
+	if len(objs) != 2 {
+		t.Fatalf("expected 2 objects, got %d", len(objs))
+	}
+	if _, ok := objs[0].(*v1.Pod); !ok {
+		t.Errorf("expected a Pod, got %T", objs[0])
+	}
+	if _, ok := objs[1].(*v1.Secret); !ok {
+		t.Errorf("expected a Secret, got %T", objs[1])
+	}
+
+	// A bare array of objects is accepted too.
+	objs, err = LoadClusterDump(writeTestDump(t, `[{"kind": "Pod", "apiVersion": "v1", "metadata": {"name": "pod"}}]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(objs) != 1 {
+		t.Fatalf("expected 1 object, got %d", len(objs))
+	}
+
+	if _, err := LoadClusterDump(writeTestDump(t, `"not a list"`)); err == nil {
+		t.Error("expected an error for a document that is not a list")
+	}
+	if _, err := LoadClusterDump(writeTestDump(t, `{"items": []}`)); err == nil {
+		t.Error("expected an error for a dump with no recognized objects")
+	}
+}
+
+func TestSanitizeClusterDump(t *testing.T) {
+	objs, err := LoadClusterDump(writeTestDump(t, testDump))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	SanitizeClusterDump(objs)
+
+	pod := objs[0].(*v1.Pod)
+	if got := pod.Annotations["owner"]; got != "xxxxxxxxxxxxxxxxxxx" {
+		t.Errorf("expected annotation value to be redacted preserving length, got %q", got)
+	}
+	container := pod.Spec.Containers[0]
+	if got := container.Env[0].Value; got != "xxxxxxx" {
+		t.Errorf("expected env value to be redacted preserving length, got %q", got)
+	}
+	if got := container.Args[0]; got != "xxxxxxxxxxxxxxx" {
+		t.Errorf("expected arg to be redacted preserving length, got %q", got)
+	}
+	if container.Env[0].Name != "PASSWORD" || container.Image != "registry.example.com/app:v1" {
+		t.Errorf("expected structural fields to be preserved: %#v", container)
+	}
+
+	secret := objs[1].(*v1.Secret)
+	if got := secret.Data["password"]; string(got) != "xxxxxxx" {
+		t.Errorf("expected secret data to be redacted preserving length, got %q", got)
+	}
+}
+
+// clusterDumpObjects returns the objects replayed by BenchmarkClusterDumpReplay: the
+// sanitized contents of the dump named by KUBE_BENCHMARK_CLUSTER_DUMP when set, or the
+// same fuzzed pods used by the other serialization benchmarks otherwise.
+func clusterDumpObjects(b *testing.B) []runtime.Object {
+	if path := os.Getenv(clusterDumpEnv); len(path) > 0 {
+		objs, err := LoadClusterDump(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		SanitizeClusterDump(objs)
+		return objs
+	}
+	items := benchmarkItems(b)
+	objs := make([]runtime.Object, len(items))
+	for i := range items {
+		objs[i] = &items[i]
+	}
+	return objs
+}
+
+// BenchmarkClusterDumpReplay replays an encode and decode workload over every object in a
+// cluster dump for each serializer, preserving the dump's distribution of object kinds
+// and sizes instead of benchmarking synthetic pods alone.
+func BenchmarkClusterDumpReplay(b *testing.B) {
+	objs := clusterDumpObjects(b)
+	for i := range objs {
+		// The plain serializers encode the object's own type metadata, so populate it
+		// the way a codec would.
+		kinds, _, err := legacyscheme.Scheme.ObjectKinds(objs[i])
+		if err != nil {
+			b.Fatal(err)
+		}
+		objs[i].GetObjectKind().SetGroupVersionKind(kinds[0])
+	}
+
+	serializers := []struct {
+		name       string
+		serializer runtime.Serializer
+	}{
+		{name: "json", serializer: jsonserializer.NewSerializer(jsonserializer.DefaultMetaFactory, legacyscheme.Scheme, legacyscheme.Scheme, false)},
+		{name: "protobuf", serializer: protobuf.NewSerializer(legacyscheme.Scheme, legacyscheme.Scheme)},
+		{name: "cbor", serializer: cborserializer.NewSerializer(cborserializer.DefaultMetaFactory, legacyscheme.Scheme, legacyscheme.Scheme)},
+	}
+
+	for _, s := range serializers {
+		b.Run("encode/"+s.name, func(b *testing.B) {
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := runtime.Encode(s.serializer, objs[i%len(objs)]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run("decode/"+s.name, func(b *testing.B) {
+			encoded := make([][]byte, len(objs))
+			for i := range objs {
+				data, err := runtime.Encode(s.serializer, objs[i])
+				if err != nil {
+					b.Fatal(err)
+				}
+				encoded[i] = data
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := runtime.Decode(s.serializer, encoded[i%len(encoded)]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -574,6 +574,21 @@ const (
 	//
 	// Enables usage of any object for volume data source in PVCs
 	AnyVolumeDataSource featuregate.Feature = "AnyVolumeDataSource"
+
+	// owner: @benluddy
+	// alpha: v1.19
+	//
+	// Writes component checkpoint files in a format-tagged envelope with a CBOR
+	// payload. Checkpoints already on disk in the legacy formats remain readable.
+	CBORCheckpointFormat featuregate.Feature = "CBORCheckpointFormat"
+
+	// owner: @benluddy
+	// alpha: v1.19
+	//
+	// Allows a single create request to the events resource to carry an EventList,
+	// storing each event in the list as if it had arrived in its own request. When
+	// disabled, such requests are rejected as they were before the feature existed.
+	BatchEventCreation featuregate.Feature = "BatchEventCreation"
 )
 
 func init() {
@@ -663,6 +678,8 @@ var defaultKubernetesFeatureGates = map[featuregate.Feature]featuregate.FeatureS
 	HugePageStorageMediumSize:                      {Default: false, PreRelease: featuregate.Alpha},
 	ExternalPolicyForExternalIP:                    {Default: true, PreRelease: featuregate.GA}, // remove in 1.20
 	AnyVolumeDataSource:                            {Default: false, PreRelease: featuregate.Alpha},
+	CBORCheckpointFormat:                           {Default: false, PreRelease: featuregate.Alpha},
+	BatchEventCreation:                             {Default: false, PreRelease: featuregate.Alpha},
 
 	// inherited features from generic apiserver, relisted here to get a conflict if it is changed
 	// unintentionally on either side:
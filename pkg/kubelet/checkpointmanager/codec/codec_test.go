@@ -0,0 +1,117 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"k8s.io/kubernetes/pkg/kubelet/checkpointmanager/errors"
+)
+
+type testPayload struct {
+	Name    string
+	Entries map[string][]string
+	Blob    []byte
+}
+
+func testData() testPayload {
+	return testPayload{
+		Name:    "x",
+		Entries: map[string][]string{"a": {"1", "2"}},
+		Blob:    []byte{0x01, 0x02},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatCBOR} {
+		t.Run(string(format), func(t *testing.T) {
+			original := testData()
+			blob, err := Marshal(format, original)
+			if err != nil {
+				t.Fatalf("unexpected marshal error: %v", err)
+			}
+			if !IsEnvelope(blob) {
+				t.Error("expected marshalled checkpoint to be recognized as an envelope")
+			}
+			var decoded testPayload
+			got, err := Unmarshal(blob, &decoded)
+			if err != nil {
+				t.Fatalf("unexpected unmarshal error: %v", err)
+			}
+			if got != format {
+				t.Errorf("expected format %q, got %q", format, got)
+			}
+			if !reflect.DeepEqual(original, decoded) {
+				t.Errorf("expected %#v, got %#v", original, decoded)
+			}
+		})
+	}
+}
+
+func TestCorruptPayload(t *testing.T) {
+	blob, err := Marshal(FormatCBOR, testData())
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	var env map[string]interface{}
+	if err := json.Unmarshal(blob, &env); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	env["payload"] = []byte("tampered")
+	blob, err = json.Marshal(env)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded testPayload
+	if _, err := Unmarshal(blob, &decoded); err != errors.ErrCorruptCheckpoint {
+		t.Errorf("expected ErrCorruptCheckpoint, got %v", err)
+	}
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	if _, err := Marshal(Format("xml"), testData()); err == nil {
+		t.Error("expected marshal error for unsupported format")
+	}
+	blob, err := Marshal(FormatJSON, testData())
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	blob = bytes.Replace(blob, []byte(`"json"`), []byte(`"xml"`), 1)
+	var decoded testPayload
+	if _, err := Unmarshal(blob, &decoded); err == nil {
+		t.Error("expected unmarshal error for unsupported format")
+	}
+}
+
+func TestIsEnvelopeRejectsLegacyCheckpoints(t *testing.T) {
+	legacy, err := json.Marshal(struct {
+		Data     testPayload
+		Checksum uint64
+	}{Data: testData(), Checksum: 7})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if IsEnvelope(legacy) {
+		t.Error("expected legacy checkpoint to not be recognized as an envelope")
+	}
+	if IsEnvelope([]byte("not json")) {
+		t.Error("expected non-JSON data to not be recognized as an envelope")
+	}
+}
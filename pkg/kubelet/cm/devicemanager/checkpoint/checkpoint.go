@@ -19,8 +19,11 @@ package checkpoint
 import (
 	"encoding/json"
 
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/kubernetes/pkg/features"
 	"k8s.io/kubernetes/pkg/kubelet/checkpointmanager"
 	"k8s.io/kubernetes/pkg/kubelet/checkpointmanager/checksum"
+	"k8s.io/kubernetes/pkg/kubelet/checkpointmanager/codec"
 )
 
 // DeviceManagerCheckpoint defines the operations to retrieve pod devices
@@ -66,11 +69,24 @@ func New(devEntries []PodDevicesEntry,
 // MarshalCheckpoint returns marshalled data
 func (cp *Data) MarshalCheckpoint() ([]byte, error) {
 	cp.Checksum = checksum.New(cp.Data)
+	if utilfeature.DefaultFeatureGate.Enabled(features.CBORCheckpointFormat) {
+		return codec.Marshal(codec.FormatCBOR, cp.Data)
+	}
 	return json.Marshal(*cp)
 }
 
 // UnmarshalCheckpoint returns unmarshalled data
 func (cp *Data) UnmarshalCheckpoint(blob []byte) error {
+	if codec.IsEnvelope(blob) {
+		if _, err := codec.Unmarshal(blob, &cp.Data); err != nil {
+			return err
+		}
+		// The envelope's checksum covers the payload bytes, so integrity has
+		// already been verified; recompute over the decoded data so that
+		// VerifyChecksum agrees.
+		cp.Checksum = checksum.New(cp.Data)
+		return nil
+	}
 	return json.Unmarshal(blob, cp)
 }
 
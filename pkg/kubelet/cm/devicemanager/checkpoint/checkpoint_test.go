@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checkpoint
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
+	"k8s.io/kubernetes/pkg/features"
+)
+
+func testCheckpoint() DeviceManagerCheckpoint {
+	return New(
+		[]PodDevicesEntry{{
+			PodUID:        "uid",
+			ContainerName: "main",
+			ResourceName:  "example.com/dev",
+			DeviceIDs:     []string{"dev0", "dev1"},
+			AllocResp:     []byte{0x01},
+		}},
+		map[string][]string{"example.com/dev": {"dev0", "dev1", "dev2"}},
+	)
+}
+
+func assertRoundTrip(t *testing.T, blob []byte) {
+	t.Helper()
+	restored := New(nil, nil)
+	if err := restored.UnmarshalCheckpoint(blob); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	if err := restored.VerifyChecksum(); err != nil {
+		t.Fatalf("unexpected checksum error: %v", err)
+	}
+	wantEntries, wantDevices := testCheckpoint().GetData()
+	gotEntries, gotDevices := restored.GetData()
+	if !reflect.DeepEqual(wantEntries, gotEntries) || !reflect.DeepEqual(wantDevices, gotDevices) {
+		t.Errorf("expected %#v %#v, got %#v %#v", wantEntries, wantDevices, gotEntries, gotDevices)
+	}
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.CBORCheckpointFormat, enabled)()
+		blob, err := testCheckpoint().MarshalCheckpoint()
+		if err != nil {
+			t.Fatalf("unexpected marshal error: %v", err)
+		}
+		if enveloped := bytes.Contains(blob, []byte(`"format":"cbor"`)); enveloped != enabled {
+			t.Errorf("feature enabled %v, but blob enveloped %v: %s", enabled, enveloped, blob)
+		}
+		assertRoundTrip(t, blob)
+	}
+}
+
+// TestCheckpointFormatMigration covers the upgrade and rollback paths: files written in
+// either format must stay readable whether or not the gate is currently enabled.
+func TestCheckpointFormatMigration(t *testing.T) {
+	var blobs [][]byte
+	for _, enabled := range []bool{false, true} {
+		func() {
+			defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.CBORCheckpointFormat, enabled)()
+			blob, err := testCheckpoint().MarshalCheckpoint()
+			if err != nil {
+				t.Fatalf("unexpected marshal error: %v", err)
+			}
+			blobs = append(blobs, blob)
+		}()
+	}
+	for _, enabled := range []bool{false, true} {
+		func() {
+			defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.CBORCheckpointFormat, enabled)()
+			for _, blob := range blobs {
+				assertRoundTrip(t, blob)
+			}
+		}()
+	}
+}
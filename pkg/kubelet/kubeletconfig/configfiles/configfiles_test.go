@@ -25,6 +25,7 @@ import (
 
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/runtime"
+	cbordirect "k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 	kubeletconfigv1beta1 "k8s.io/kubelet/config/v1beta1"
 	kubeletconfig "k8s.io/kubernetes/pkg/kubelet/apis/config"
 	kubeletscheme "k8s.io/kubernetes/pkg/kubelet/apis/config/scheme"
@@ -67,6 +68,11 @@ func TestLoad(t *testing.T) {
 			file: newString(`{*`),
 			err:  "failed to decode",
 		},
+		{
+			desc: "invalid cbor",
+			file: newString("\xd9\xd9\xf7"),
+			err:  "failed to decode",
+		},
 		// invalid object
 		{
 			desc: "missing kind",
@@ -101,6 +107,14 @@ apiVersion: kubelet.config.k8s.io/v1beta1`),
 			file:   newString(`{"kind":"KubeletConfiguration","apiVersion":"kubelet.config.k8s.io/v1beta1"}`),
 			expect: newConfig(t),
 		},
+		{
+			desc: "default from cbor",
+			file: newString(cborString(t, map[string]interface{}{
+				"kind":       "KubeletConfiguration",
+				"apiVersion": "kubelet.config.k8s.io/v1beta1",
+			})),
+			expect: newConfig(t),
+		},
 
 		// relative path
 		{
@@ -123,6 +137,19 @@ staticPodPath: %s`, relativePath)),
 				return kc
 			}(),
 		},
+		{
+			desc: "cbor, relative path is resolved",
+			file: newString(cborString(t, map[string]interface{}{
+				"kind":          "KubeletConfiguration",
+				"apiVersion":    "kubelet.config.k8s.io/v1beta1",
+				"staticPodPath": relativePath,
+			})),
+			expect: func() *kubeletconfig.KubeletConfiguration {
+				kc := newConfig(t)
+				kc.StaticPodPath = filepath.Join(configDir, relativePath)
+				return kc
+			}(),
+		},
 		{
 			// This should fail from v1beta2+
 			desc: "duplicate field",
@@ -210,6 +237,16 @@ func newString(s string) *string {
 	return &s
 }
 
+// cborString encodes obj as a self-described CBOR document, the form in which
+// CBOR config files are recognized by the loader.
+func cborString(t *testing.T, obj interface{}) string {
+	data, err := cbordirect.Marshal(obj)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return string(append([]byte{0xd9, 0xd9, 0xf7}, data...))
+}
+
 func addFile(fs utilfs.Filesystem, path string, file string) error {
 	if err := utilfiles.EnsureDir(fs, filepath.Dir(path)); err != nil {
 		return err
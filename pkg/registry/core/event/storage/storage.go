@@ -17,11 +17,17 @@ limitations under the License.
 package storage
 
 import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apiserver/pkg/registry/generic"
 	genericregistry "k8s.io/apiserver/pkg/registry/generic/registry"
 	"k8s.io/apiserver/pkg/registry/rest"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	api "k8s.io/kubernetes/pkg/apis/core"
+	"k8s.io/kubernetes/pkg/features"
 	"k8s.io/kubernetes/pkg/printers"
 	printersinternal "k8s.io/kubernetes/pkg/printers/internalversion"
 	printerstorage "k8s.io/kubernetes/pkg/printers/storage"
@@ -63,6 +69,33 @@ func NewREST(optsGetter generic.RESTOptionsGetter, ttl uint64) (*REST, error) {
 	return &REST{store}, nil
 }
 
+// Create creates one event or, when obj is an EventList and the BatchEventCreation
+// feature is enabled, every event in the list in a single request. Event traffic is
+// high in volume and tolerant of latency, so clients that buffer events may submit a
+// batch to cut per-request overhead. Each event in a batch is validated and stored
+// individually with the same checks a separate request would receive. Batches are not
+// transactional: the first failure is returned as-is, so its status is preserved, and
+// events stored before it remain.
+func (r *REST) Create(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	list, ok := obj.(*api.EventList)
+	if !ok {
+		return r.Store.Create(ctx, obj, createValidation, options)
+	}
+	if !utilfeature.DefaultFeatureGate.Enabled(features.BatchEventCreation) {
+		// Preserve the rejection clients observed before batches were supported.
+		return nil, apierrors.NewBadRequest("batch event creation is disabled by the BatchEventCreation feature gate")
+	}
+	created := &api.EventList{}
+	for i := range list.Items {
+		obj, err := r.Store.Create(ctx, &list.Items[i], createValidation, options)
+		if err != nil {
+			return nil, err
+		}
+		created.Items = append(created.Items, *obj.(*api.Event))
+	}
+	return created, nil
+}
+
 // Implement ShortNamesProvider
 var _ rest.ShortNamesProvider = &REST{}
 
@@ -19,12 +19,18 @@ package storage
 import (
 	"testing"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/generic"
 	genericregistrytest "k8s.io/apiserver/pkg/registry/generic/testing"
+	"k8s.io/apiserver/pkg/registry/rest"
 	etcd3testing "k8s.io/apiserver/pkg/storage/etcd3/testing"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
 	api "k8s.io/kubernetes/pkg/apis/core"
+	"k8s.io/kubernetes/pkg/features"
 	"k8s.io/kubernetes/pkg/registry/registrytest"
 )
 
@@ -74,6 +80,62 @@ func TestCreate(t *testing.T) {
 	)
 }
 
+func TestCreateBatch(t *testing.T) {
+	defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.BatchEventCreation, true)()
+	storage, server := newStorage(t)
+	defer server.Terminate(t)
+	defer storage.Store.DestroyFunc()
+	ctx := genericapirequest.NewDefaultContext()
+
+	first := validNewEvent(metav1.NamespaceDefault)
+	second := validNewEvent(metav1.NamespaceDefault)
+	second.Name = "foo2"
+	obj, err := storage.Create(ctx, &api.EventList{Items: []api.Event{*first, *second}}, rest.ValidateAllObjectFunc, &metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	created, ok := obj.(*api.EventList)
+	if !ok {
+		t.Fatalf("expected an EventList, got %T", obj)
+	}
+	if len(created.Items) != 2 {
+		t.Fatalf("expected 2 created events, got %d", len(created.Items))
+	}
+	for i := range created.Items {
+		if created.Items[i].ResourceVersion == "" {
+			t.Errorf("created event %q has no resource version", created.Items[i].Name)
+		}
+	}
+
+	// Batches are not transactional: an invalid event fails the request, but events
+	// before it in the list remain.
+	third := validNewEvent(metav1.NamespaceDefault)
+	third.Name = "foo3"
+	if _, err := storage.Create(ctx, &api.EventList{Items: []api.Event{*third, {}}}, rest.ValidateAllObjectFunc, &metav1.CreateOptions{}); err == nil {
+		t.Fatal("expected error creating a batch containing an invalid event")
+	}
+	if _, err := storage.Get(ctx, third.Name, &metav1.GetOptions{}); err != nil {
+		t.Errorf("expected event created before the invalid one to remain, got: %v", err)
+	}
+}
+
+func TestCreateBatchFeatureDisabled(t *testing.T) {
+	defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.BatchEventCreation, false)()
+	storage, server := newStorage(t)
+	defer server.Terminate(t)
+	defer storage.Store.DestroyFunc()
+	ctx := genericapirequest.NewDefaultContext()
+
+	event := validNewEvent(metav1.NamespaceDefault)
+	_, err := storage.Create(ctx, &api.EventList{Items: []api.Event{*event}}, rest.ValidateAllObjectFunc, &metav1.CreateOptions{})
+	if !apierrors.IsBadRequest(err) {
+		t.Fatalf("expected a bad request error with the feature gate disabled, got: %v", err)
+	}
+	if _, err := storage.Get(ctx, event.Name, &metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected no event to be created, got: %v", err)
+	}
+}
+
 func TestUpdate(t *testing.T) {
 	storage, server := newStorage(t)
 	defer server.Terminate(t)
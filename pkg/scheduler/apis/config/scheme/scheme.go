@@ -19,6 +19,7 @@ package scheme
 import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	cborserializer "k8s.io/apimachinery/pkg/runtime/serializer/cbor"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	kubeschedulerconfig "k8s.io/kubernetes/pkg/scheduler/apis/config"
 	kubeschedulerconfigv1 "k8s.io/kubernetes/pkg/scheduler/apis/config/v1"
@@ -29,8 +30,10 @@ var (
 	// Scheme is the runtime.Scheme to which all kubescheduler api types are registered.
 	Scheme = runtime.NewScheme()
 
-	// Codecs provides access to encoding and decoding for the scheme.
-	Codecs = serializer.NewCodecFactory(Scheme, serializer.EnableStrict)
+	// Codecs provides access to encoding and decoding for the scheme. CBOR documents
+	// are accepted in addition to the default formats, recognized by the
+	// self-described CBOR prefix.
+	Codecs = serializer.NewCodecFactory(Scheme, serializer.EnableStrict, serializer.WithSerializer(cborserializer.NewSerializerInfo))
 )
 
 func init() {
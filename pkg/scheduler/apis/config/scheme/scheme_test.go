@@ -24,6 +24,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	cbordirect "k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 	"k8s.io/kube-scheduler/config/v1alpha2"
 	"k8s.io/kubernetes/pkg/scheduler/apis/config"
 	"k8s.io/utils/pointer"
@@ -294,12 +295,12 @@ profiles:
 			obj, gvk, err := decoder.Decode(tt.data, nil, nil)
 			if err != nil {
 				if tt.wantErr != err.Error() {
-					t.Fatalf("got err %w, want %w", err, tt.wantErr)
+					t.Fatalf("got err %v, want %q", err, tt.wantErr)
 				}
 				return
 			}
 			if len(tt.wantErr) != 0 {
-				t.Fatal("no error produced, wanted %w", tt.wantErr)
+				t.Fatalf("no error produced, wanted %q", tt.wantErr)
 			}
 			got, ok := obj.(*config.KubeSchedulerConfiguration)
 			if !ok {
@@ -520,3 +521,23 @@ profiles:
 		})
 	}
 }
+
+func TestCodecsDecodeCBOR(t *testing.T) {
+	doc := map[string]interface{}{
+		"apiVersion": "kubescheduler.config.k8s.io/v1alpha2",
+		"kind":       "KubeSchedulerConfiguration",
+	}
+	encoded, err := cbordirect.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The universal decoder recognizes CBOR documents by the self-described prefix.
+	data := append([]byte{0xd9, 0xd9, 0xf7}, encoded...)
+	obj, gvk, err := Codecs.UniversalDecoder().Decode(data, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := obj.(*config.KubeSchedulerConfiguration); !ok {
+		t.Fatalf("decoded into %s, want %s", gvk, config.SchemeGroupVersion.WithKind("KubeSchedulerConfiguration"))
+	}
+}
@@ -95,11 +95,30 @@ func (a *Plugin) Validate(ctx context.Context, attr admission.Attributes, o admi
 		return nil
 	}
 
+	// A batched create carries an EventList. It is limited as if each of its events
+	// had arrived in its own request, so batching cannot be used to outpace clients
+	// that submit events one at a time.
+	var events []*api.Event
+	switch object := attr.GetObject().(type) {
+	case *api.EventList:
+		events = make([]*api.Event, 0, len(object.Items))
+		for i := range object.Items {
+			events = append(events, &object.Items[i])
+		}
+	case *api.Event:
+		events = []*api.Event{object}
+	default:
+		events = []*api.Event{nil}
+	}
+
 	var errors []error
 	// give each limit enforcer a chance to reject the event
 	for _, enforcer := range a.limitEnforcers {
-		if err := enforcer.accept(attr); err != nil {
-			errors = append(errors, err)
+		for _, event := range events {
+			if err := enforcer.accept(attr, event); err != nil {
+				errors = append(errors, err)
+				break
+			}
 		}
 	}
 
@@ -24,6 +24,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apiserver/pkg/admission"
@@ -40,8 +41,12 @@ const (
 
 // attributesForRequest generates the admission.Attributes that for the specified request
 func attributesForRequest(rq request) admission.Attributes {
+	var object runtime.Object = rq.event
+	if rq.eventList != nil {
+		object = rq.eventList
+	}
 	return admission.NewAttributesRecord(
-		rq.event,
+		object,
 		nil,
 		api.Kind(rq.kind).WithVersion("version"),
 		rq.namespace,
@@ -59,6 +64,7 @@ type request struct {
 	namespace string
 	username  string
 	event     *api.Event
+	eventList *api.EventList
 	delay     time.Duration
 	accepted  bool
 	dryRun    bool
@@ -89,6 +95,11 @@ func (r request) withEvent(event *api.Event) request {
 	return r
 }
 
+func (r request) withEventList(events ...api.Event) request {
+	r.eventList = &api.EventList{Items: events}
+	return r
+}
+
 func (r request) withEventComponent(component string) request {
 	return r.withEvent(&api.Event{
 		Source: api.EventSource{
@@ -210,6 +221,21 @@ func TestEventRateLimiting(t *testing.T) {
 				newEventRequest().withDelay(1),
 			},
 		},
+		{
+			name:        "each event in a batch counts against limit",
+			serverBurst: 3,
+			requests: []request{
+				newEventRequest().withEventList(api.Event{}, api.Event{}, api.Event{}),
+				newEventRequest().blocked(),
+			},
+		},
+		{
+			name:        "batch blocked when it exhausts tokens",
+			serverBurst: 3,
+			requests: []request{
+				newEventRequest().withEventList(api.Event{}, api.Event{}, api.Event{}, api.Event{}).blocked(),
+			},
+		},
 		{
 			name:               "event blocked by namespace limits",
 			serverBurst:        100,
@@ -304,6 +330,22 @@ func TestEventRateLimiting(t *testing.T) {
 				newEventRequest().withEventComponent("A").blocked(),
 			},
 		},
+		{
+			name:                     "batched events limited by source+object individually",
+			serverBurst:              100,
+			sourceAndObjectBurst:     1,
+			sourceAndObjectCacheSize: 10,
+			requests: []request{
+				newEventRequest().withEventList(
+					api.Event{Source: api.EventSource{Component: "A"}},
+					api.Event{Source: api.EventSource{Component: "B"}},
+				),
+				newEventRequest().withEventList(
+					api.Event{Source: api.EventSource{Component: "C"}},
+					api.Event{Source: api.EventSource{Component: "C"}},
+				).blocked(),
+			},
+		},
 		{
 			name:                     "event from other source+object not blocked",
 			serverBurst:              100,
@@ -40,7 +40,7 @@ type limitEnforcer struct {
 	// cache for holding the rate limiters
 	cache cache
 	// a keyFunc which is responsible for computing a single key based on input
-	keyFunc func(admission.Attributes) string
+	keyFunc func(admission.Attributes, *api.Event) string
 }
 
 func newLimitEnforcer(config eventratelimitapi.Limit, clock flowcontrol.Clock) (*limitEnforcer, error) {
@@ -71,7 +71,7 @@ func newLimitEnforcer(config eventratelimitapi.Limit, clock flowcontrol.Clock) (
 		cache:              underlyingCache,
 	}
 
-	var keyFunc func(admission.Attributes) string
+	var keyFunc func(admission.Attributes, *api.Event) string
 	switch t := config.Type; t {
 	case eventratelimitapi.NamespaceLimitType:
 		keyFunc = getNamespaceKey
@@ -90,8 +90,10 @@ func newLimitEnforcer(config eventratelimitapi.Limit, clock flowcontrol.Clock) (
 	}, nil
 }
 
-func (enforcer *limitEnforcer) accept(attr admission.Attributes) error {
-	key := enforcer.keyFunc(attr)
+// accept charges one token for a single event. For a batched request, it is called
+// once per event in the batch.
+func (enforcer *limitEnforcer) accept(attr admission.Attributes, event *api.Event) error {
+	key := enforcer.keyFunc(attr, event)
 	rateLimiter := enforcer.cache.get(key)
 
 	// ensure we have available rate
@@ -104,17 +106,17 @@ func (enforcer *limitEnforcer) accept(attr admission.Attributes) error {
 	return nil
 }
 
-func getServerKey(attr admission.Attributes) string {
+func getServerKey(attr admission.Attributes, event *api.Event) string {
 	return ""
 }
 
 // getNamespaceKey returns a cache key that is based on the namespace of the event request
-func getNamespaceKey(attr admission.Attributes) string {
+func getNamespaceKey(attr admission.Attributes, event *api.Event) string {
 	return attr.GetNamespace()
 }
 
 // getUserKey returns a cache key that is based on the user of the event request
-func getUserKey(attr admission.Attributes) string {
+func getUserKey(attr admission.Attributes, event *api.Event) string {
 	userInfo := attr.GetUserInfo()
 	if userInfo == nil {
 		return ""
@@ -123,13 +125,8 @@ func getUserKey(attr admission.Attributes) string {
 }
 
 // getSourceAndObjectKey returns a cache key that is based on the source+object of the event
-func getSourceAndObjectKey(attr admission.Attributes) string {
-	object := attr.GetObject()
-	if object == nil {
-		return ""
-	}
-	event, ok := object.(*api.Event)
-	if !ok {
+func getSourceAndObjectKey(attr admission.Attributes, event *api.Event) string {
+	if event == nil {
 		return ""
 	}
 	return strings.Join([]string{
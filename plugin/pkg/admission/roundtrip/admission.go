@@ -0,0 +1,161 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package roundtrip contains an admission plugin that re-encodes every admitted object
+// with each serializer enabled for the server and checks that each encoding decodes back
+// to an equal object. A divergence means two clients could read different objects
+// depending on the wire format they negotiate, so the plugin is a canary for serializer
+// parity bugs. It is intended for test clusters only and is never enabled by default.
+package roundtrip
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/api/legacyscheme"
+)
+
+// PluginName indicates name of admission plugin.
+const PluginName = "CodecRoundTrip"
+
+// defaultMaxObjectBytes bounds the size of the objects the plugin will compare. Larger
+// encodings are skipped so that the plugin cannot multiply the cost of admitting an
+// unusually large object by the number of enabled serializers.
+const defaultMaxObjectBytes = 1 << 20
+
+// pluginConfig holds config data for the CodecRoundTrip plugin. The config file may be
+// json or yaml.
+type pluginConfig struct {
+	// FailOnDivergence rejects a request whose object does not round-trip cleanly
+	// through every serializer instead of only logging the divergence.
+	FailOnDivergence bool `json:"failOnDivergence"`
+	// SampleEvery checks only every Nth eligible request when greater than one, to
+	// bound the admission overhead on busy test clusters. Defaults to 1, checking
+	// every request.
+	SampleEvery int64 `json:"sampleEvery"`
+	// MaxObjectBytes skips comparison of any encoding larger than this many bytes.
+	// Defaults to 1Mi.
+	MaxObjectBytes int64 `json:"maxObjectBytes"`
+}
+
+// Register registers a plugin
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName, func(config io.Reader) (admission.Interface, error) {
+		cfg, err := readConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		return newPlugin(legacyscheme.Codecs, cfg), nil
+	})
+}
+
+// Plugin is an implementation of admission.ValidationInterface that re-encodes each
+// admitted object with every enabled serializer and verifies the encodings agree.
+type Plugin struct {
+	*admission.Handler
+	codecs serializer.CodecFactory
+	config pluginConfig
+
+	requests int64
+}
+
+var _ admission.ValidationInterface = &Plugin{}
+
+// newPlugin allows tests to substitute a codec factory with a known-divergent serializer.
+func newPlugin(codecs serializer.CodecFactory, config pluginConfig) *Plugin {
+	return &Plugin{
+		Handler: admission.NewHandler(admission.Create, admission.Update),
+		codecs:  codecs,
+		config:  config,
+	}
+}
+
+func readConfig(config io.Reader) (pluginConfig, error) {
+	cfg := pluginConfig{SampleEvery: 1, MaxObjectBytes: defaultMaxObjectBytes}
+	if config == nil {
+		return cfg, nil
+	}
+	d := yaml.NewYAMLOrJSONDecoder(config, 4096)
+	if err := d.Decode(&cfg); err != nil && err != io.EOF {
+		return cfg, fmt.Errorf("failed to read %s admission plugin config: %v", PluginName, err)
+	}
+	if cfg.SampleEvery < 1 {
+		cfg.SampleEvery = 1
+	}
+	if cfg.MaxObjectBytes < 1 {
+		cfg.MaxObjectBytes = defaultMaxObjectBytes
+	}
+	return cfg, nil
+}
+
+// Validate re-encodes the admitted object with every enabled serializer and verifies that
+// each encoding decodes to an object equal to the admitted one. It never mutates anything.
+func (p *Plugin) Validate(ctx context.Context, a admission.Attributes, o admission.ObjectInterfaces) error {
+	obj := a.GetObject()
+	if obj == nil {
+		return nil
+	}
+	if n := atomic.AddInt64(&p.requests, 1); (n-1)%p.config.SampleEvery != 0 {
+		return nil
+	}
+
+	gv := a.GetKind().GroupVersion()
+	for _, info := range p.codecs.SupportedMediaTypes() {
+		codec := p.codecs.CodecForVersions(info.Serializer, info.Serializer, gv, runtime.InternalGroupVersioner)
+		data, err := runtime.Encode(codec, obj)
+		if err != nil {
+			// Not every serializer can encode every object; that is a property of
+			// the object, not a round-trip failure.
+			klog.V(6).Infof("%s: skipping %s for %s: %v", PluginName, info.MediaType, a.GetKind(), err)
+			continue
+		}
+		if int64(len(data)) > p.config.MaxObjectBytes {
+			klog.V(4).Infof("%s: skipping %s for %s: encoding exceeds %d bytes", PluginName, info.MediaType, a.GetKind(), p.config.MaxObjectBytes)
+			continue
+		}
+		decoded, err := runtime.Decode(codec, data)
+		if err != nil {
+			if err := p.report(a, fmt.Errorf("%s encoding of %s failed to decode: %v", info.MediaType, a.GetKind(), err)); err != nil {
+				return err
+			}
+			continue
+		}
+		if !apiequality.Semantic.DeepEqual(obj, decoded) {
+			if err := p.report(a, fmt.Errorf("%s does not round-trip cleanly through %s", a.GetKind(), info.MediaType)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// report surfaces a divergence, either by failing the request or by logging, as
+// configured.
+func (p *Plugin) report(a admission.Attributes, err error) error {
+	if p.config.FailOnDivergence {
+		return admission.NewForbidden(a, err)
+	}
+	klog.Errorf("%s: %v", PluginName, err)
+	return nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package roundtrip
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/kubernetes/pkg/api/legacyscheme"
+	api "k8s.io/kubernetes/pkg/apis/core"
+	_ "k8s.io/kubernetes/pkg/apis/core/install"
+)
+
+// admittedPod returns an internal pod in the form admission would see it: decoded from
+// its v1 encoding, with defaults applied.
+func admittedPod(t *testing.T) *api.Pod {
+	pod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod",
+			Namespace: "test",
+		},
+		Spec: api.PodSpec{
+			Containers: []api.Container{{Name: "ctr", Image: "image"}},
+		},
+	}
+	codec := legacyscheme.Codecs.LegacyCodec(corev1.SchemeGroupVersion)
+	data, err := runtime.Encode(codec, pod)
+	if err != nil {
+		t.Fatalf("unexpected error encoding pod: %v", err)
+	}
+	obj, err := runtime.Decode(codec, data)
+	if err != nil {
+		t.Fatalf("unexpected error decoding pod: %v", err)
+	}
+	return obj.(*api.Pod)
+}
+
+func podAttributes(pod *api.Pod) admission.Attributes {
+	return admission.NewAttributesRecord(pod, nil,
+		schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		pod.Namespace, pod.Name,
+		schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		"", admission.Create, &metav1.CreateOptions{}, false, nil)
+}
+
+// lossySerializer decodes correctly except that it drops the object's name, standing in
+// for a serializer with a parity bug.
+type lossySerializer struct {
+	runtime.Serializer
+}
+
+func (s lossySerializer) Decode(data []byte, gvk *schema.GroupVersionKind, into runtime.Object) (runtime.Object, *schema.GroupVersionKind, error) {
+	obj, actual, err := s.Serializer.Decode(data, gvk, into)
+	if obj != nil {
+		if accessor, err := meta.Accessor(obj); err == nil {
+			accessor.SetName("")
+		}
+	}
+	return obj, actual, err
+}
+
+// lossyCodecs returns the usual codec factory with one known-divergent serializer added.
+func lossyCodecs() serializer.CodecFactory {
+	return serializer.NewCodecFactory(legacyscheme.Scheme, serializer.WithSerializer(func(creater runtime.ObjectCreater, typer runtime.ObjectTyper) runtime.SerializerInfo {
+		return runtime.SerializerInfo{
+			MediaType:  "application/vnd.test.lossy",
+			Serializer: lossySerializer{json.NewSerializer(json.DefaultMetaFactory, creater, typer, false)},
+		}
+	}))
+}
+
+func TestValidateRoundTrip(t *testing.T) {
+	p := newPlugin(legacyscheme.Codecs, pluginConfig{FailOnDivergence: true, SampleEvery: 1, MaxObjectBytes: defaultMaxObjectBytes})
+	if err := p.Validate(context.TODO(), podAttributes(admittedPod(t)), nil); err != nil {
+		t.Errorf("unexpected error from round-trippable object: %v", err)
+	}
+}
+
+func TestValidateDivergence(t *testing.T) {
+	pod := admittedPod(t)
+
+	p := newPlugin(lossyCodecs(), pluginConfig{FailOnDivergence: true, SampleEvery: 1, MaxObjectBytes: defaultMaxObjectBytes})
+	if err := p.Validate(context.TODO(), podAttributes(pod), nil); err == nil {
+		t.Error("expected divergence to fail the request")
+	}
+
+	p = newPlugin(lossyCodecs(), pluginConfig{SampleEvery: 1, MaxObjectBytes: defaultMaxObjectBytes})
+	if err := p.Validate(context.TODO(), podAttributes(pod), nil); err != nil {
+		t.Errorf("expected divergence to be logged, not returned: %v", err)
+	}
+}
+
+func TestValidateSampling(t *testing.T) {
+	pod := admittedPod(t)
+	p := newPlugin(lossyCodecs(), pluginConfig{FailOnDivergence: true, SampleEvery: 2, MaxObjectBytes: defaultMaxObjectBytes})
+	for i, expectChecked := range []bool{true, false, true, false} {
+		err := p.Validate(context.TODO(), podAttributes(pod), nil)
+		if checked := err != nil; checked != expectChecked {
+			t.Errorf("request %d: expected checked=%t, got error %v", i, expectChecked, err)
+		}
+	}
+}
+
+func TestValidateSkipsOversizedObjects(t *testing.T) {
+	p := newPlugin(lossyCodecs(), pluginConfig{FailOnDivergence: true, SampleEvery: 1, MaxObjectBytes: 1})
+	if err := p.Validate(context.TODO(), podAttributes(admittedPod(t)), nil); err != nil {
+		t.Errorf("expected oversized encodings to be skipped, got: %v", err)
+	}
+}
+
+func TestReadConfig(t *testing.T) {
+	cfg, err := readConfig(nil)
+	if err != nil {
+		t.Fatalf("unexpected error reading nil config: %v", err)
+	}
+	if cfg.FailOnDivergence || cfg.SampleEvery != 1 || cfg.MaxObjectBytes != defaultMaxObjectBytes {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+
+	cfg, err = readConfig(bytes.NewBufferString(`{"failOnDivergence": true, "sampleEvery": 10}`))
+	if err != nil {
+		t.Fatalf("unexpected error reading config: %v", err)
+	}
+	if !cfg.FailOnDivergence || cfg.SampleEvery != 10 || cfg.MaxObjectBytes != defaultMaxObjectBytes {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	if _, err := readConfig(bytes.NewBufferString(`{`)); err == nil {
+		t.Error("expected error reading malformed config")
+	}
+}
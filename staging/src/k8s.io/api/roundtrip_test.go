@@ -124,6 +124,18 @@ func TestRoundTripExternalTypes(t *testing.T) {
 	roundtrip.RoundTripExternalTypes(t, scheme, codecs, fuzzer, nil)
 }
 
+func TestRoundTripExternalTypesCBOR(t *testing.T) {
+	scheme := runtime.NewScheme()
+	codecs := serializer.NewCodecFactory(scheme)
+	for _, builder := range groups {
+		require.NoError(t, builder.AddToScheme(scheme))
+	}
+	seed := rand.Int63()
+	fuzzer := fuzzer.FuzzerFor(genericfuzzer.Funcs, rand.NewSource(seed), codecs)
+
+	roundtrip.RoundTripCBORExternalTypes(t, scheme, fuzzer, nil)
+}
+
 func TestCompatibility(t *testing.T) {
 	scheme := runtime.NewScheme()
 	for _, builder := range groups {
@@ -26,4 +26,5 @@ import (
 func TestRoundTrip(t *testing.T) {
 	roundtrip.RoundTripTestForAPIGroup(t, Install, apiextensionsfuzzer.Funcs)
 	roundtrip.RoundTripProtobufTestForAPIGroup(t, Install, apiextensionsfuzzer.Funcs)
+	roundtrip.RoundTripCBORTestForAPIGroup(t, Install, apiextensionsfuzzer.Funcs)
 }
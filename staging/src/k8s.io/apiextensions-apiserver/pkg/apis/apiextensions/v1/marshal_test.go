@@ -20,6 +20,8 @@ import (
 	"encoding/json"
 	"reflect"
 	"testing"
+
+	cbor "k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 )
 
 type JSONSchemaPropsOrBoolHolder struct {
@@ -148,3 +150,51 @@ func TestJSONSchemaPropsOrArrayMarshalJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestUnionTypesCBORRoundTrip(t *testing.T) {
+	// Each union type must decode from the CBOR equivalent of the documents its JSON
+	// unmarshaler accepts, and encode back to the same document.
+	type holder struct {
+		JSPoB  JSONSchemaPropsOrBool        `json:"b,omitempty"`
+		JSPoA  JSONSchemaPropsOrArray       `json:"a,omitempty"`
+		JSPoSA JSONSchemaPropsOrStringArray `json:"sa,omitempty"`
+		J      JSON                         `json:"j,omitempty"`
+	}
+	cases := []string{
+		`{"b":true,"a":{"type":"string"},"sa":["x","y"],"j":{"nested":[1,2]}}`,
+		`{"b":{"type":"object"},"a":[{"type":"string"},{"type":"number"}],"sa":{"type":"integer"},"j":"scalar"}`,
+		`{"b":false,"a":null,"sa":null,"j":null}`,
+	}
+	for _, tc := range cases {
+		t.Run(tc, func(t *testing.T) {
+			var fromJSON holder
+			if err := json.Unmarshal([]byte(tc), &fromJSON); err != nil {
+				t.Fatalf("unexpected json unmarshal error: %v", err)
+			}
+
+			data, err := transcodeJSONToCBOR([]byte(tc))
+			if err != nil {
+				t.Fatalf("unexpected transcode error: %v", err)
+			}
+			var fromCBOR holder
+			if err := cbor.Unmarshal(data, &fromCBOR); err != nil {
+				t.Fatalf("unexpected cbor unmarshal error: %v", err)
+			}
+			if !reflect.DeepEqual(fromCBOR, fromJSON) {
+				t.Fatalf("cbor and json decode disagree:\ncbor: %#v\njson: %#v", fromCBOR, fromJSON)
+			}
+
+			reencoded, err := cbor.Marshal(fromCBOR)
+			if err != nil {
+				t.Fatalf("unexpected cbor marshal error: %v", err)
+			}
+			var roundTripped holder
+			if err := cbor.Unmarshal(reencoded, &roundTripped); err != nil {
+				t.Fatalf("unexpected cbor unmarshal error: %v", err)
+			}
+			if !reflect.DeepEqual(roundTripped, fromJSON) {
+				t.Fatalf("value did not survive cbor round trip:\ngot:  %#v\nwant: %#v", roundTripped, fromJSON)
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ package v1beta1
 import (
 	"errors"
 
+	cbor "k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 	"k8s.io/apimachinery/pkg/util/json"
 )
 
@@ -133,3 +134,89 @@ func (s *JSON) UnmarshalJSON(data []byte) error {
 	}
 	return nil
 }
+
+// transcodeCBORToJSON converts a single CBOR document to the equivalent JSON document,
+// for reuse of the JSON unmarshaling behavior of types whose raw form is defined to be
+// JSON.
+func transcodeCBORToJSON(data []byte) ([]byte, error) {
+	var u interface{}
+	if err := cbor.Unmarshal(data, &u); err != nil {
+		return nil, err
+	}
+	return json.Marshal(u)
+}
+
+// transcodeJSONToCBOR converts a single JSON document to the equivalent CBOR document.
+func transcodeJSONToCBOR(data []byte) ([]byte, error) {
+	var u interface{}
+	if err := json.Unmarshal(data, &u); err != nil {
+		return nil, err
+	}
+	return cbor.Marshal(u)
+}
+
+func (s JSONSchemaPropsOrBool) MarshalCBOR() ([]byte, error) {
+	data, err := s.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return transcodeJSONToCBOR(data)
+}
+
+func (s *JSONSchemaPropsOrBool) UnmarshalCBOR(data []byte) error {
+	j, err := transcodeCBORToJSON(data)
+	if err != nil {
+		return err
+	}
+	return s.UnmarshalJSON(j)
+}
+
+func (s JSONSchemaPropsOrStringArray) MarshalCBOR() ([]byte, error) {
+	data, err := s.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return transcodeJSONToCBOR(data)
+}
+
+func (s *JSONSchemaPropsOrStringArray) UnmarshalCBOR(data []byte) error {
+	j, err := transcodeCBORToJSON(data)
+	if err != nil {
+		return err
+	}
+	return s.UnmarshalJSON(j)
+}
+
+func (s JSONSchemaPropsOrArray) MarshalCBOR() ([]byte, error) {
+	data, err := s.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return transcodeJSONToCBOR(data)
+}
+
+func (s *JSONSchemaPropsOrArray) UnmarshalCBOR(data []byte) error {
+	j, err := transcodeCBORToJSON(data)
+	if err != nil {
+		return err
+	}
+	return s.UnmarshalJSON(j)
+}
+
+func (s JSON) MarshalCBOR() ([]byte, error) {
+	if len(s.Raw) == 0 {
+		return cbor.Marshal(nil)
+	}
+	return transcodeJSONToCBOR(s.Raw)
+}
+
+func (s *JSON) UnmarshalCBOR(data []byte) error {
+	j, err := transcodeCBORToJSON(data)
+	if err != nil {
+		return err
+	}
+	if string(j) != "null" {
+		s.Raw = j
+	}
+	return nil
+}
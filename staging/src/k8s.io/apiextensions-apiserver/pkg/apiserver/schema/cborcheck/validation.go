@@ -0,0 +1,471 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cborcheck validates raw CBOR documents against structural schemas by walking
+// the token stream, without materializing the document as Go values. It implements the
+// checks that can be decided from token heads alone — value types, required properties,
+// and array length bounds — so that obviously invalid bodies can be rejected before
+// paying for a full decode. A clean result is not a substitute for full validation:
+// checks that need materialized values (string lengths in code points, numeric bounds,
+// enums, formats, patterns, and list-type uniqueness) still run after decode.
+package cborcheck
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+)
+
+// maxNestedLevels limits the depth of nesting the scanner will follow, pinned to the
+// same limit the decode modes enforce so that the pre-check never recurses deeper than a
+// document the serializer would accept.
+const maxNestedLevels = 32
+
+// selfDescribedCBOR is the encoded form of tag 55799. The serializer strips exactly one
+// leading instance before decoding, and this package does the same.
+var selfDescribedCBOR = []byte{0xd9, 0xd9, 0xf7}
+
+// Check scans the CBOR document in data against the structural schema s, returning the
+// violations it can detect from the token stream and an error if the document is not
+// well-formed or uses a construct the decode modes reject (tags, reserved encodings).
+// Properties not declared by the schema are skipped; pruning decides their fate after
+// decode. Required properties with a declared default are not reported as missing,
+// because defaulting runs before full validation and would supply them.
+func Check(fldPath *field.Path, s *schema.Structural, data []byte) (field.ErrorList, error) {
+	if len(data) >= len(selfDescribedCBOR) && string(data[:len(selfDescribedCBOR)]) == string(selfDescribedCBOR) {
+		data = data[len(selfDescribedCBOR):]
+	}
+	sc := &scanner{data: data}
+	errs, err := sc.checkItem(fldPath, s, 0)
+	if err != nil {
+		return nil, err
+	}
+	if sc.off != len(sc.data) {
+		return nil, fmt.Errorf("%d bytes of trailing data after the document", len(sc.data)-sc.off)
+	}
+	return errs, nil
+}
+
+// scanner reads CBOR items sequentially from a byte slice. It understands just enough of
+// RFC 8949 framing to locate item boundaries: heads, definite and indefinite lengths,
+// and the break code.
+type scanner struct {
+	data []byte
+	off  int
+}
+
+const (
+	majorUnsigned   = 0
+	majorNegative   = 1
+	majorByteString = 2
+	majorTextString = 3
+	majorArray      = 4
+	majorMap        = 5
+	majorTag        = 6
+	majorSimple     = 7
+)
+
+const (
+	infoIndefinite = 31
+
+	simpleFalse     = 20
+	simpleTrue      = 21
+	simpleNull      = 22
+	simpleUndefined = 23
+)
+
+// readHead reads the initial byte of an item and its argument. For indefinite-length
+// items and the break code, info is infoIndefinite and value is meaningless.
+func (sc *scanner) readHead() (major, info byte, value uint64, err error) {
+	if sc.off >= len(sc.data) {
+		return 0, 0, 0, fmt.Errorf("unexpected end of data at offset %d", sc.off)
+	}
+	initial := sc.data[sc.off]
+	sc.off++
+	major = initial >> 5
+	info = initial & 0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info == 24, info == 25, info == 26, info == 27:
+		n := 1 << (info - 24)
+		if sc.off+n > len(sc.data) {
+			return 0, 0, 0, fmt.Errorf("unexpected end of data at offset %d", sc.off)
+		}
+		for i := 0; i < n; i++ {
+			value = value<<8 | uint64(sc.data[sc.off+i])
+		}
+		sc.off += n
+		return major, info, value, nil
+	case info == infoIndefinite:
+		return major, info, 0, nil
+	default:
+		return 0, 0, 0, fmt.Errorf("reserved additional information %d at offset %d", info, sc.off-1)
+	}
+}
+
+// atBreak consumes a break code if one is next, reporting whether it did.
+func (sc *scanner) atBreak() bool {
+	if sc.off < len(sc.data) && sc.data[sc.off] == 0xff {
+		sc.off++
+		return true
+	}
+	return false
+}
+
+// skipItem skips one complete item, including all nested content.
+func (sc *scanner) skipItem(depth int) error {
+	major, info, value, err := sc.readHead()
+	if err != nil {
+		return err
+	}
+	return sc.skipBody(major, info, value, depth)
+}
+
+// skipBody skips the content of an item whose head has already been read.
+func (sc *scanner) skipBody(major, info byte, value uint64, depth int) error {
+	if depth > maxNestedLevels {
+		return fmt.Errorf("exceeded max depth of %d", maxNestedLevels)
+	}
+	switch major {
+	case majorUnsigned, majorNegative:
+		return nil
+	case majorByteString, majorTextString:
+		if info == infoIndefinite {
+			return sc.skipStringChunks(major)
+		}
+		return sc.skipBytes(value)
+	case majorArray:
+		if info == infoIndefinite {
+			for !sc.atBreak() {
+				if err := sc.skipItem(depth + 1); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for i := uint64(0); i < value; i++ {
+			if err := sc.skipItem(depth + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case majorMap:
+		if info == infoIndefinite {
+			for !sc.atBreak() {
+				if err := sc.skipItem(depth + 1); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for i := uint64(0); i < 2*value; i++ {
+			if err := sc.skipItem(depth + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case majorTag:
+		// The decode modes forbid all tags, so a document containing one can never
+		// decode successfully and there is no point scanning further.
+		return fmt.Errorf("tag %d is not permitted", value)
+	default: // majorSimple
+		if info == infoIndefinite {
+			return fmt.Errorf("unexpected break code at offset %d", sc.off-1)
+		}
+		return nil
+	}
+}
+
+// skipStringChunks skips the chunks of an indefinite-length string. Chunks must be
+// definite-length strings of the same major type.
+func (sc *scanner) skipStringChunks(major byte) error {
+	for !sc.atBreak() {
+		chunkMajor, chunkInfo, chunkLen, err := sc.readHead()
+		if err != nil {
+			return err
+		}
+		if chunkMajor != major || chunkInfo == infoIndefinite {
+			return fmt.Errorf("invalid chunk inside indefinite-length string at offset %d", sc.off)
+		}
+		if err := sc.skipBytes(chunkLen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sc *scanner) skipBytes(n uint64) error {
+	if n > uint64(len(sc.data)-sc.off) {
+		return fmt.Errorf("unexpected end of data at offset %d", sc.off)
+	}
+	sc.off += int(n)
+	return nil
+}
+
+// readStringBody returns the content of a string whose head has already been read.
+// Definite-length strings are returned without copying.
+func (sc *scanner) readStringBody(major, info byte, value uint64) (string, error) {
+	if info != infoIndefinite {
+		start := sc.off
+		if err := sc.skipBytes(value); err != nil {
+			return "", err
+		}
+		return string(sc.data[start:sc.off]), nil
+	}
+	var content []byte
+	for !sc.atBreak() {
+		chunkMajor, chunkInfo, chunkLen, err := sc.readHead()
+		if err != nil {
+			return "", err
+		}
+		if chunkMajor != major || chunkInfo == infoIndefinite {
+			return "", fmt.Errorf("invalid chunk inside indefinite-length string at offset %d", sc.off)
+		}
+		start := sc.off
+		if err := sc.skipBytes(chunkLen); err != nil {
+			return "", err
+		}
+		content = append(content, sc.data[start:sc.off]...)
+	}
+	return string(content), nil
+}
+
+// checkItem validates one item against s, leaving the scanner positioned after the item.
+func (sc *scanner) checkItem(fldPath *field.Path, s *schema.Structural, depth int) (field.ErrorList, error) {
+	if depth > maxNestedLevels {
+		return nil, fmt.Errorf("exceeded max depth of %d", maxNestedLevels)
+	}
+
+	major, info, value, err := sc.readHead()
+	if err != nil {
+		return nil, err
+	}
+
+	// Schemas without a declared type, including those that preserve unknown fields,
+	// constrain nothing that this pass can check.
+	if s == nil || s.Type == "" {
+		return nil, sc.skipBody(major, info, value, depth)
+	}
+
+	if major == majorSimple && info == simpleNull {
+		if s.Nullable {
+			return nil, nil
+		}
+		return field.ErrorList{field.Invalid(fldPath, nil, fmt.Sprintf("must be of type %s", s.Type))}, nil
+	}
+
+	if s.XIntOrString {
+		if major == majorUnsigned || major == majorNegative || major == majorByteString || major == majorTextString {
+			return nil, sc.skipBody(major, info, value, depth)
+		}
+		return field.ErrorList{field.Invalid(fldPath, describeItem(major, info), "must be an integer or a string")}, sc.skipBody(major, info, value, depth)
+	}
+
+	switch s.Type {
+	case "object":
+		if major != majorMap {
+			return typeMismatch(fldPath, s, major, info), sc.skipBody(major, info, value, depth)
+		}
+		return sc.checkObject(fldPath, s, info, value, depth)
+	case "array":
+		if major != majorArray {
+			return typeMismatch(fldPath, s, major, info), sc.skipBody(major, info, value, depth)
+		}
+		return sc.checkArray(fldPath, s, info, value, depth)
+	case "string":
+		// Byte strings are accepted where strings are expected because decoding
+		// replaces them with their base64 encoding, which is a string.
+		if major != majorByteString && major != majorTextString {
+			return typeMismatch(fldPath, s, major, info), sc.skipBody(major, info, value, depth)
+		}
+		return nil, sc.skipBody(major, info, value, depth)
+	case "integer":
+		// Floats are accepted where integers are expected because full validation
+		// accepts floats with integral values; deciding integrality requires the
+		// value, so it is left to full validation.
+		if major != majorUnsigned && major != majorNegative && !isFloat(major, info) {
+			return typeMismatch(fldPath, s, major, info), sc.skipBody(major, info, value, depth)
+		}
+		return nil, nil
+	case "number":
+		if major != majorUnsigned && major != majorNegative && !isFloat(major, info) {
+			return typeMismatch(fldPath, s, major, info), sc.skipBody(major, info, value, depth)
+		}
+		return nil, nil
+	case "boolean":
+		if major != majorSimple || (info != simpleFalse && info != simpleTrue) {
+			return typeMismatch(fldPath, s, major, info), sc.skipBody(major, info, value, depth)
+		}
+		return nil, nil
+	default:
+		return nil, sc.skipBody(major, info, value, depth)
+	}
+}
+
+// checkObject validates the entries of a map whose head has already been read.
+func (sc *scanner) checkObject(fldPath *field.Path, s *schema.Structural, info byte, value uint64, depth int) (field.ErrorList, error) {
+	var errs field.ErrorList
+
+	var required map[string]bool
+	if s.ValueValidation != nil && len(s.ValueValidation.Required) > 0 {
+		required = make(map[string]bool, len(s.ValueValidation.Required))
+		for _, name := range s.ValueValidation.Required {
+			required[name] = true
+		}
+	}
+
+	checkEntry := func() error {
+		keyMajor, keyInfo, keyValue, err := sc.readHead()
+		if err != nil {
+			return err
+		}
+		if keyMajor != majorTextString {
+			// The decode modes permit only text string keys.
+			return fmt.Errorf("map key of non-string type at offset %d", sc.off)
+		}
+		key, err := sc.readStringBody(keyMajor, keyInfo, keyValue)
+		if err != nil {
+			return err
+		}
+		delete(required, key)
+		if prop, ok := s.Properties[key]; ok {
+			propErrs, err := sc.checkItem(fldPath.Child(key), &prop, depth+1)
+			errs = append(errs, propErrs...)
+			return err
+		}
+		if s.AdditionalProperties != nil && s.AdditionalProperties.Structural != nil {
+			propErrs, err := sc.checkItem(fldPath.Key(key), s.AdditionalProperties.Structural, depth+1)
+			errs = append(errs, propErrs...)
+			return err
+		}
+		// Undeclared properties are pruning's concern, not a validation failure.
+		return sc.skipItem(depth + 1)
+	}
+
+	if info == infoIndefinite {
+		for !sc.atBreak() {
+			if err := checkEntry(); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		for i := uint64(0); i < value; i++ {
+			if err := checkEntry(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, name := range sortedNames(required) {
+		if prop, ok := s.Properties[name]; ok && prop.Default.Object != nil {
+			// Defaulting runs before full validation and will supply this property.
+			continue
+		}
+		errs = append(errs, field.Required(fldPath.Child(name), ""))
+	}
+	return errs, nil
+}
+
+// checkArray validates the elements of an array whose head has already been read.
+func (sc *scanner) checkArray(fldPath *field.Path, s *schema.Structural, info byte, value uint64, depth int) (field.ErrorList, error) {
+	var errs field.ErrorList
+	count := uint64(0)
+
+	checkElement := func() error {
+		elementErrs, err := sc.checkItem(fldPath.Index(int(count)), s.Items, depth+1)
+		errs = append(errs, elementErrs...)
+		count++
+		return err
+	}
+
+	if info == infoIndefinite {
+		for !sc.atBreak() {
+			if err := checkElement(); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		for i := uint64(0); i < value; i++ {
+			if err := checkElement(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if s.ValueValidation != nil {
+		if max := s.ValueValidation.MaxItems; max != nil && count > uint64(*max) {
+			errs = append(errs, field.TooMany(fldPath, int(count), int(*max)))
+		}
+		if min := s.ValueValidation.MinItems; min != nil && count < uint64(*min) {
+			errs = append(errs, field.Invalid(fldPath, int(count), fmt.Sprintf("must have at least %d items", *min)))
+		}
+	}
+	return errs, nil
+}
+
+func typeMismatch(fldPath *field.Path, s *schema.Structural, major, info byte) field.ErrorList {
+	return field.ErrorList{field.Invalid(fldPath, describeItem(major, info), fmt.Sprintf("must be of type %s", s.Type))}
+}
+
+// describeItem names the kind of item a head introduces, for use in error messages in
+// place of a value that was never materialized.
+func describeItem(major, info byte) string {
+	switch major {
+	case majorUnsigned, majorNegative:
+		return "integer"
+	case majorByteString:
+		return "byte string"
+	case majorTextString:
+		return "string"
+	case majorArray:
+		return "array"
+	case majorMap:
+		return "object"
+	case majorTag:
+		return "tag"
+	default:
+		switch {
+		case info == simpleFalse, info == simpleTrue:
+			return "boolean"
+		case info == simpleNull:
+			return "null"
+		case isFloat(major, info):
+			return "number"
+		default:
+			return "simple value"
+		}
+	}
+}
+
+func isFloat(major, info byte) bool {
+	return major == majorSimple && (info == 25 || info == 26 || info == 27)
+}
+
+func sortedNames(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
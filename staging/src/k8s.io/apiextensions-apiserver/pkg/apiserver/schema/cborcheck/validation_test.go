@@ -0,0 +1,351 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cborcheck
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+)
+
+func int64ptr(i int64) *int64 { return &i }
+
+// testSchema declares an object with one property of each scalar type, a bounded array,
+// a map through additionalProperties, and a required string.
+func testSchema() *schema.Structural {
+	return &schema.Structural{
+		Generic: schema.Generic{Type: "object"},
+		Properties: map[string]schema.Structural{
+			"spec": {
+				Generic: schema.Generic{Type: "object"},
+				Properties: map[string]schema.Structural{
+					"name":     {Generic: schema.Generic{Type: "string"}},
+					"replicas": {Generic: schema.Generic{Type: "integer"}},
+					"weight":   {Generic: schema.Generic{Type: "number"}},
+					"paused":   {Generic: schema.Generic{Type: "boolean"}},
+					"note":     {Generic: schema.Generic{Type: "string", Nullable: true}},
+					"port": {
+						Generic:    schema.Generic{Type: "integer"},
+						Extensions: schema.Extensions{XIntOrString: true},
+					},
+					"mode": {
+						Generic: schema.Generic{Type: "string", Default: schema.JSON{Object: "auto"}},
+					},
+					"ports": {
+						Generic: schema.Generic{Type: "array"},
+						Items:   &schema.Structural{Generic: schema.Generic{Type: "integer"}},
+						ValueValidation: &schema.ValueValidation{
+							MaxItems: int64ptr(2),
+							MinItems: int64ptr(1),
+						},
+					},
+					"labels": {
+						Generic: schema.Generic{
+							Type: "object",
+							AdditionalProperties: &schema.StructuralOrBool{
+								Structural: &schema.Structural{Generic: schema.Generic{Type: "string"}},
+							},
+						},
+					},
+				},
+				ValueValidation: &schema.ValueValidation{Required: []string{"name", "mode"}},
+			},
+		},
+	}
+}
+
+func marshal(t testing.TB, v interface{}) []byte {
+	t.Helper()
+	data, err := direct.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestCheck(t *testing.T) {
+	validSpec := map[string]interface{}{
+		"name":    "x",
+		"ports":   []interface{}{int64(1), int64(2)},
+		"paused":  true,
+		"weight":  0.5,
+		"note":    nil,
+		"labels":  map[string]interface{}{"a": "b"},
+		"unknown": map[string]interface{}{"pruned": "later"},
+	}
+
+	for _, tc := range []struct {
+		name      string
+		doc       interface{} // marshaled unless raw is set
+		raw       []byte
+		errors    []string // "<type>:<path>"
+		wantError string   // substring of the scan error
+	}{
+		{
+			name: "valid",
+			doc:  map[string]interface{}{"spec": validSpec},
+		},
+		{
+			name: "self-describe prefix",
+			raw:  append([]byte{0xd9, 0xd9, 0xf7}, marshal(t, map[string]interface{}{"spec": validSpec})...),
+		},
+		{
+			name:   "root type mismatch",
+			doc:    []interface{}{"not", "an", "object"},
+			errors: []string{"Invalid value:root"},
+		},
+		{
+			name:   "wrong property type",
+			doc:    map[string]interface{}{"spec": map[string]interface{}{"name": "x", "replicas": "many"}},
+			errors: []string{"Invalid value:root.spec.replicas"},
+		},
+		{
+			name:   "missing required",
+			doc:    map[string]interface{}{"spec": map[string]interface{}{"ports": []interface{}{int64(1)}}},
+			errors: []string{"Required value:root.spec.name"},
+		},
+		{
+			name: "required with default is not reported",
+			doc:  map[string]interface{}{"spec": map[string]interface{}{"name": "x"}},
+		},
+		{
+			name:   "too many items",
+			doc:    map[string]interface{}{"spec": map[string]interface{}{"name": "x", "ports": []interface{}{int64(1), int64(2), int64(3)}}},
+			errors: []string{"Too many:root.spec.ports"},
+		},
+		{
+			name:   "too few items",
+			doc:    map[string]interface{}{"spec": map[string]interface{}{"name": "x", "ports": []interface{}{}}},
+			errors: []string{"Invalid value:root.spec.ports"},
+		},
+		{
+			name:   "wrong element type",
+			doc:    map[string]interface{}{"spec": map[string]interface{}{"name": "x", "ports": []interface{}{int64(1), "eighty"}}},
+			errors: []string{"Invalid value:root.spec.ports[1]"},
+		},
+		{
+			name:   "null for non-nullable",
+			doc:    map[string]interface{}{"spec": map[string]interface{}{"name": nil}},
+			errors: []string{"Invalid value:root.spec.name"},
+		},
+		{
+			name: "byte string where string expected",
+			doc:  map[string]interface{}{"spec": map[string]interface{}{"name": []byte("x")}},
+		},
+		{
+			name: "float where integer expected",
+			doc:  map[string]interface{}{"spec": map[string]interface{}{"name": "x", "replicas": 3.0}},
+		},
+		{
+			name: "int-or-string accepts string",
+			doc:  map[string]interface{}{"spec": map[string]interface{}{"name": "x", "port": "http"}},
+		},
+		{
+			name:   "int-or-string rejects boolean",
+			doc:    map[string]interface{}{"spec": map[string]interface{}{"name": "x", "port": true}},
+			errors: []string{"Invalid value:root.spec.port"},
+		},
+		{
+			name:   "wrong additional property type",
+			doc:    map[string]interface{}{"spec": map[string]interface{}{"name": "x", "labels": map[string]interface{}{"a": int64(1)}}},
+			errors: []string{"Invalid value:root.spec.labels[a]"},
+		},
+		{
+			name:   "boolean type mismatch",
+			doc:    map[string]interface{}{"spec": map[string]interface{}{"name": "x", "paused": "yes"}},
+			errors: []string{"Invalid value:root.spec.paused"},
+		},
+		{
+			name: "indefinite-length containers",
+			// {_ "spec": {_ "name": (_ "x"), "ports": [_ 1]}}
+			raw: []byte{
+				0xbf, 0x64, 's', 'p', 'e', 'c',
+				0xbf,
+				0x64, 'n', 'a', 'm', 'e', 0x7f, 0x61, 'x', 0xff,
+				0x65, 'p', 'o', 'r', 't', 's', 0x9f, 0x01, 0xff,
+				0x64, 'm', 'o', 'd', 'e', 0x61, 'a',
+				0xff,
+				0xff,
+			},
+		},
+		{
+			name:      "tag in document",
+			raw:       []byte{0xa1, 0x64, 's', 'p', 'e', 'c', 0xc0, 0x61, 'x'},
+			wantError: "tag 0 is not permitted",
+		},
+		{
+			name:      "non-string map key",
+			raw:       []byte{0xa1, 0x01, 0x02},
+			wantError: "map key of non-string type",
+		},
+		{
+			name:      "truncated document",
+			raw:       []byte{0xa2, 0x64, 's', 'p', 'e', 'c'},
+			wantError: "unexpected end of data",
+		},
+		{
+			name:      "trailing data",
+			raw:       append(marshal(t, map[string]interface{}{"spec": validSpec}), 0x00),
+			wantError: "trailing data",
+		},
+		{
+			name:      "reserved additional information",
+			raw:       []byte{0xfc},
+			wantError: "reserved additional information",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data := tc.raw
+			if data == nil {
+				data = marshal(t, tc.doc)
+			}
+			errs, err := Check(field.NewPath("root"), testSchema(), data)
+			if tc.wantError != "" {
+				if err == nil || !contains(err.Error(), tc.wantError) {
+					t.Fatalf("expected scan error containing %q, got %v", tc.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected scan error: %v", err)
+			}
+			var got []string
+			for _, e := range errs {
+				got = append(got, fmt.Sprintf("%s:%s", e.Type, e.Field))
+			}
+			if len(got) != len(tc.errors) {
+				t.Fatalf("expected errors %v, got %v", tc.errors, errs)
+			}
+			for i := range got {
+				if got[i] != tc.errors[i] {
+					t.Errorf("expected error %q at index %d, got %q", tc.errors[i], i, got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckDepthLimit(t *testing.T) {
+	deep := []byte{0xa1, 0x61, 'a'}
+	nested := &schema.Structural{Generic: schema.Generic{Type: "string"}}
+	for i := 0; i < 2*maxNestedLevels; i++ {
+		deep = append([]byte{0xa1, 0x61, 'a'}, deep...)
+		nested = &schema.Structural{
+			Generic:    schema.Generic{Type: "object"},
+			Properties: map[string]schema.Structural{"a": *nested},
+		}
+	}
+	deep = append(deep, 0x61, 'x')
+	if _, err := Check(field.NewPath("root"), nested, deep); err == nil || !contains(err.Error(), "max depth") {
+		t.Fatalf("expected max depth error, got %v", err)
+	}
+}
+
+func contains(s, substr string) bool {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if s[i:i+len(substr)] == substr {
+			return true
+		}
+	}
+	return false
+}
+
+func benchmarkDocument(b *testing.B, items int) []byte {
+	elements := make([]interface{}, 0, items)
+	for i := 0; i < items; i++ {
+		elements = append(elements, map[string]interface{}{
+			"name":    fmt.Sprintf("item-%d", i),
+			"weight":  float64(i) / 2,
+			"paused":  i%2 == 0,
+			"labels":  map[string]interface{}{"index": fmt.Sprintf("%d", i)},
+			"unknown": []interface{}{int64(i), "padding", nil},
+		})
+	}
+	return marshal(b, map[string]interface{}{"spec": map[string]interface{}{
+		"name":  "bench",
+		"mode":  "manual",
+		"items": elements,
+	}})
+}
+
+func benchmarkSchema() *schema.Structural {
+	s := testSchema()
+	spec := s.Properties["spec"]
+	spec.Properties["items"] = schema.Structural{
+		Generic: schema.Generic{Type: "array"},
+		Items: &schema.Structural{
+			Generic: schema.Generic{Type: "object"},
+			Properties: map[string]schema.Structural{
+				"name":   {Generic: schema.Generic{Type: "string"}},
+				"weight": {Generic: schema.Generic{Type: "number"}},
+				"paused": {Generic: schema.Generic{Type: "boolean"}},
+				"labels": {
+					Generic: schema.Generic{
+						Type: "object",
+						AdditionalProperties: &schema.StructuralOrBool{
+							Structural: &schema.Structural{Generic: schema.Generic{Type: "string"}},
+						},
+					},
+				},
+			},
+			ValueValidation: &schema.ValueValidation{Required: []string{"name"}},
+		},
+	}
+	s.Properties["spec"] = spec
+	return s
+}
+
+func BenchmarkCheck(b *testing.B) {
+	s := benchmarkSchema()
+	data := benchmarkDocument(b, 100)
+	fldPath := field.NewPath("root")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		errs, err := Check(fldPath, s, data)
+		if err != nil || len(errs) > 0 {
+			b.Fatalf("unexpected failure: %v %v", errs, err)
+		}
+	}
+}
+
+func BenchmarkCheckVersusDecode(b *testing.B) {
+	data := benchmarkDocument(b, 100)
+	b.Run("check", func(b *testing.B) {
+		s := benchmarkSchema()
+		fldPath := field.NewPath("root")
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Check(fldPath, s, data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("decode", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var decoded map[string]interface{}
+			if err := direct.Unmarshal(data, &decoded); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
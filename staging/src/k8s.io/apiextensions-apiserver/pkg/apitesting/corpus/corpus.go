@@ -0,0 +1,433 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package corpus generates schema-aware random CBOR documents from structural schemas.
+// Valid documents satisfy every constraint of the schema and invalid documents violate
+// exactly one constraint at its boundary, so that fuzz targets and validation
+// differential tests can exercise both sides of each constraint without hand-writing
+// fixtures per schema.
+//
+// Pattern, format, and multipleOf constraints are not synthesized; schemas that use them
+// must also declare an enum, from which values are drawn verbatim.
+package corpus
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+)
+
+// selfDescribedCBOR is the prefix the serializer places before every document it
+// produces, and by which CBOR documents are recognized.
+var selfDescribedCBOR = []byte{0xd9, 0xd9, 0xf7}
+
+// Generator produces random CBOR documents from a structural schema. A Generator with a
+// given seed produces the same sequence of documents on every run.
+type Generator struct {
+	rand *rand.Rand
+
+	// MaxLength bounds the sizes of generated strings, arrays, and maps beyond any
+	// minimum the schema imposes, when the schema itself does not bound them.
+	MaxLength int
+}
+
+// NewGenerator returns a Generator producing a deterministic sequence of documents for
+// the given seed.
+func NewGenerator(seed int64) *Generator {
+	return &Generator{rand: rand.New(rand.NewSource(seed)), MaxLength: 4}
+}
+
+// Valid returns a CBOR document for the given group-version-kind whose content is
+// randomly generated within the constraints of the structural schema. The document
+// carries the self-describe prefix, as serializer output does.
+func (g *Generator) Valid(gvk schema.GroupVersionKind, s *structuralschema.Structural) ([]byte, error) {
+	doc, err := g.document(gvk, s)
+	if err != nil {
+		return nil, err
+	}
+	return encode(doc)
+}
+
+// Invalid returns a CBOR document for the given group-version-kind that violates exactly
+// one constraint of the structural schema at its boundary, along with a description of
+// the violation for test output. It fails if the schema has no constraint to violate.
+func (g *Generator) Invalid(gvk schema.GroupVersionKind, s *structuralschema.Structural) ([]byte, string, error) {
+	doc, err := g.document(gvk, s)
+	if err != nil {
+		return nil, "", err
+	}
+	var violations []violation
+	collectViolations(s, nil, &violations)
+	if len(violations) == 0 {
+		return nil, "", fmt.Errorf("schema for %s has no constraints to violate", gvk)
+	}
+	v := violations[g.rand.Intn(len(violations))]
+	if err := v.apply(g, doc); err != nil {
+		return nil, "", err
+	}
+	data, err := encode(doc)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, v.desc, nil
+}
+
+func (g *Generator) document(gvk schema.GroupVersionKind, s *structuralschema.Structural) (map[string]interface{}, error) {
+	value, err := g.value(s)
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("schema for %s must describe an object at the root", gvk)
+	}
+	doc["apiVersion"] = gvk.GroupVersion().String()
+	doc["kind"] = gvk.Kind
+	return doc, nil
+}
+
+func encode(doc map[string]interface{}) ([]byte, error) {
+	data, err := direct.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, selfDescribedCBOR...), data...), nil
+}
+
+// value generates a random value satisfying the schema. Every declared property is
+// populated so that constraint violations can later be applied at any path.
+func (g *Generator) value(s *structuralschema.Structural) (interface{}, error) {
+	if s == nil {
+		return g.anyScalar(), nil
+	}
+	if vv := s.ValueValidation; vv != nil {
+		if len(vv.Enum) > 0 {
+			return vv.Enum[g.rand.Intn(len(vv.Enum))].DeepCopy().Object, nil
+		}
+		if vv.Pattern != "" || vv.Format != "" || vv.MultipleOf != nil {
+			return nil, fmt.Errorf("cannot generate values for pattern, format, or multipleOf constraints without enum")
+		}
+	}
+	if s.XIntOrString {
+		if g.rand.Intn(2) == 0 {
+			return int64(g.rand.Intn(100)), nil
+		}
+		return g.randString(1, int64(g.MaxLength)), nil
+	}
+	switch s.Type {
+	case "boolean":
+		return g.rand.Intn(2) == 0, nil
+	case "integer":
+		lo, hi := g.numberBounds(s)
+		ilo, ihi := int64(math.Ceil(lo)), int64(math.Floor(hi))
+		if ihi < ilo {
+			return nil, fmt.Errorf("no integer satisfies minimum %v and maximum %v", lo, hi)
+		}
+		return ilo + g.rand.Int63n(ihi-ilo+1), nil
+	case "number":
+		lo, hi := g.numberBounds(s)
+		return lo + g.rand.Float64()*(hi-lo), nil
+	case "string":
+		min, max := g.lengthBounds(s)
+		return g.randString(min, max), nil
+	case "array":
+		min, max := g.itemBounds(s)
+		if max < min {
+			max = min
+		}
+		n := min + g.rand.Int63n(max-min+1)
+		items := make([]interface{}, 0, n)
+		for i := int64(0); i < n; i++ {
+			item, err := g.value(s.Items)
+			if err != nil {
+				return nil, err
+			}
+			if s.ValueValidation != nil && s.ValueValidation.UniqueItems {
+				item = fmt.Sprintf("unique-%d", i)
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case "object":
+		object := map[string]interface{}{}
+		// Iterate in sorted order so that generators with equal seeds make draws in
+		// the same sequence and produce identical documents.
+		for _, name := range sortedPropertyNames(s.Properties) {
+			property := s.Properties[name]
+			value, err := g.value(&property)
+			if err != nil {
+				return nil, err
+			}
+			object[name] = value
+		}
+		if len(s.Properties) == 0 && s.AdditionalProperties != nil && s.AdditionalProperties.Structural != nil {
+			for i := 0; i < g.rand.Intn(g.MaxLength+1); i++ {
+				value, err := g.value(s.AdditionalProperties.Structural)
+				if err != nil {
+					return nil, err
+				}
+				object[fmt.Sprintf("key%d", i)] = value
+			}
+		}
+		return object, nil
+	default:
+		// Empty type with x-kubernetes-preserve-unknown-fields admits anything.
+		return g.anyScalar(), nil
+	}
+}
+
+func (g *Generator) anyScalar() interface{} {
+	switch g.rand.Intn(4) {
+	case 0:
+		return g.rand.Intn(2) == 0
+	case 1:
+		return int64(g.rand.Intn(1000))
+	case 2:
+		return g.rand.Float64()
+	default:
+		return g.randString(0, int64(g.MaxLength))
+	}
+}
+
+func (g *Generator) randString(min, max int64) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	if max < min {
+		max = min
+	}
+	n := min + g.rand.Int63n(max-min+1)
+	var b strings.Builder
+	for i := int64(0); i < n; i++ {
+		b.WriteByte(alphabet[g.rand.Intn(len(alphabet))])
+	}
+	return b.String()
+}
+
+func (g *Generator) numberBounds(s *structuralschema.Structural) (lo, hi float64) {
+	lo, hi = -1000, 1000
+	if vv := s.ValueValidation; vv != nil {
+		if vv.Minimum != nil {
+			lo = *vv.Minimum
+			if vv.ExclusiveMinimum {
+				lo++
+			}
+		}
+		if vv.Maximum != nil {
+			hi = *vv.Maximum
+			if vv.ExclusiveMaximum {
+				hi--
+			}
+		}
+	}
+	return lo, hi
+}
+
+func (g *Generator) lengthBounds(s *structuralschema.Structural) (min, max int64) {
+	min, max = 0, int64(g.MaxLength)
+	if vv := s.ValueValidation; vv != nil {
+		if vv.MinLength != nil {
+			min = *vv.MinLength
+			max = min + int64(g.MaxLength)
+		}
+		if vv.MaxLength != nil {
+			max = *vv.MaxLength
+		}
+	}
+	return min, max
+}
+
+func (g *Generator) itemBounds(s *structuralschema.Structural) (min, max int64) {
+	min, max = 0, int64(g.MaxLength)
+	if vv := s.ValueValidation; vv != nil {
+		if vv.MinItems != nil {
+			min = *vv.MinItems
+			max = min + int64(g.MaxLength)
+		}
+		if vv.MaxItems != nil {
+			max = *vv.MaxItems
+		}
+	}
+	return min, max
+}
+
+// violation describes one constraint of a schema and how to break it at its boundary. It
+// addresses the object property to mutate by the property names leading to its parent
+// object from the document root.
+type violation struct {
+	parent []string
+	field  string
+	// remove deletes the field from its parent instead of replacing its value, for
+	// required-property violations.
+	remove  bool
+	desc    string
+	invalid func(g *Generator, s *structuralschema.Structural) (interface{}, error)
+	schema  *structuralschema.Structural
+}
+
+func (v violation) apply(g *Generator, doc map[string]interface{}) error {
+	object := doc
+	for _, name := range v.parent {
+		next, ok := object[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("document has no object at %q", strings.Join(v.parent, "."))
+		}
+		object = next
+	}
+	if v.remove {
+		delete(object, v.field)
+		return nil
+	}
+	value, err := v.invalid(g, v.schema)
+	if err != nil {
+		return err
+	}
+	object[v.field] = value
+	return nil
+}
+
+// collectViolations walks the schema gathering one candidate violation per constraint
+// reachable through object properties. Array items and map values are not descended
+// into, since their paths depend on generated content.
+func collectViolations(s *structuralschema.Structural, path []string, out *[]violation) {
+	if s == nil || s.Type != "object" {
+		return
+	}
+	if vv := s.ValueValidation; vv != nil {
+		for _, name := range vv.Required {
+			if _, ok := s.Properties[name]; !ok {
+				continue
+			}
+			*out = append(*out, violation{
+				parent: path,
+				field:  name,
+				remove: true,
+				desc:   fmt.Sprintf("%s: missing required property", join(path, name)),
+			})
+		}
+	}
+	for _, name := range sortedPropertyNames(s.Properties) {
+		property := s.Properties[name]
+		collectPropertyViolations(&property, path, name, out)
+		collectViolations(&property, append(path[:len(path):len(path)], name), out)
+	}
+}
+
+func collectPropertyViolations(s *structuralschema.Structural, parent []string, field string, out *[]violation) {
+	add := func(desc string, invalid func(g *Generator, s *structuralschema.Structural) (interface{}, error)) {
+		*out = append(*out, violation{parent: parent, field: field, desc: fmt.Sprintf("%s: %s", join(parent, field), desc), invalid: invalid, schema: s})
+	}
+	if s.Type != "" {
+		add(fmt.Sprintf("value of wrong type for %s", s.Type), func(g *Generator, s *structuralschema.Structural) (interface{}, error) {
+			if s.Type == "string" {
+				return false, nil
+			}
+			return "wrong type", nil
+		})
+	}
+	vv := s.ValueValidation
+	if vv == nil {
+		return
+	}
+	if len(vv.Enum) > 0 {
+		add("value not in enum", func(g *Generator, s *structuralschema.Structural) (interface{}, error) {
+			return "zz-not-in-enum", nil
+		})
+	}
+	if vv.MaxLength != nil {
+		add("string exceeding maxLength by one", func(g *Generator, s *structuralschema.Structural) (interface{}, error) {
+			return g.randString(*s.ValueValidation.MaxLength+1, *s.ValueValidation.MaxLength+1), nil
+		})
+	}
+	if vv.MinLength != nil && *vv.MinLength > 0 {
+		add("string below minLength by one", func(g *Generator, s *structuralschema.Structural) (interface{}, error) {
+			return g.randString(*s.ValueValidation.MinLength-1, *s.ValueValidation.MinLength-1), nil
+		})
+	}
+	if vv.Maximum != nil {
+		add("number just above maximum", func(g *Generator, s *structuralschema.Structural) (interface{}, error) {
+			above := *s.ValueValidation.Maximum + 1
+			if s.ValueValidation.ExclusiveMaximum {
+				above = *s.ValueValidation.Maximum
+			}
+			if s.Type == "integer" {
+				return int64(above), nil
+			}
+			return above, nil
+		})
+	}
+	if vv.Minimum != nil {
+		add("number just below minimum", func(g *Generator, s *structuralschema.Structural) (interface{}, error) {
+			below := *s.ValueValidation.Minimum - 1
+			if s.ValueValidation.ExclusiveMinimum {
+				below = *s.ValueValidation.Minimum
+			}
+			if s.Type == "integer" {
+				return int64(below), nil
+			}
+			return below, nil
+		})
+	}
+	if vv.MaxItems != nil {
+		add("array exceeding maxItems by one", func(g *Generator, s *structuralschema.Structural) (interface{}, error) {
+			items := make([]interface{}, 0, *s.ValueValidation.MaxItems+1)
+			for i := int64(0); i <= *s.ValueValidation.MaxItems; i++ {
+				item, err := g.value(s.Items)
+				if err != nil {
+					return nil, err
+				}
+				if s.ValueValidation.UniqueItems {
+					item = fmt.Sprintf("unique-%d", i)
+				}
+				items = append(items, item)
+			}
+			return items, nil
+		})
+	}
+	if vv.MinItems != nil && *vv.MinItems > 0 {
+		add("array below minItems by one", func(g *Generator, s *structuralschema.Structural) (interface{}, error) {
+			items := make([]interface{}, 0, *s.ValueValidation.MinItems-1)
+			for i := int64(0); i < *s.ValueValidation.MinItems-1; i++ {
+				item, err := g.value(s.Items)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+			}
+			return items, nil
+		})
+	}
+}
+
+func sortedPropertyNames(properties map[string]structuralschema.Structural) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func join(path []string, field string) string {
+	if len(path) == 0 {
+		return field
+	}
+	return strings.Join(path, ".") + "." + field
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package corpus
+
+import (
+	"bytes"
+	"testing"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+var testGVK = schema.GroupVersionKind{Group: "tests.example.com", Version: "v1", Kind: "Widget"}
+
+// testSchema covers object, array, string, integer, number, boolean, and enum
+// constraints with boundaries on each.
+func testSchema() *apiextensions.JSONSchemaProps {
+	minReplicas := 1.0
+	maxReplicas := 10.0
+	maxNameLength := int64(8)
+	minNameLength := int64(2)
+	maxPorts := int64(3)
+	return &apiextensions.JSONSchemaProps{
+		Type:     "object",
+		Required: []string{"spec"},
+		Properties: map[string]apiextensions.JSONSchemaProps{
+			"spec": {
+				Type:     "object",
+				Required: []string{"name", "replicas"},
+				Properties: map[string]apiextensions.JSONSchemaProps{
+					"name": {
+						Type:      "string",
+						MinLength: &minNameLength,
+						MaxLength: &maxNameLength,
+					},
+					"replicas": {
+						Type:    "integer",
+						Minimum: &minReplicas,
+						Maximum: &maxReplicas,
+					},
+					"weight": {
+						Type:    "number",
+						Maximum: &maxReplicas,
+					},
+					"paused": {
+						Type: "boolean",
+					},
+					"protocol": {
+						Type: "string",
+						Enum: []apiextensions.JSON{"tcp", "udp"},
+					},
+					"ports": {
+						Type:     "array",
+						MaxItems: &maxPorts,
+						Items: &apiextensions.JSONSchemaPropsOrArray{
+							Schema: &apiextensions.JSONSchemaProps{Type: "integer", Minimum: &minReplicas},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestGeneratedDocumentsAgainstValidator(t *testing.T) {
+	props := testSchema()
+	structural, err := structuralschema.NewStructural(props)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validator, _, err := validation.NewSchemaValidator(&apiextensions.CustomResourceValidation{OpenAPIV3Schema: props})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewGenerator(1643)
+	for i := 0; i < 100; i++ {
+		data, err := g.Valid(testGVK, structural)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(data, selfDescribedCBOR) {
+			t.Fatalf("document %d does not begin with the self-describe prefix", i)
+		}
+		var doc interface{}
+		if err := direct.Unmarshal(bytes.TrimPrefix(data, selfDescribedCBOR), &doc); err != nil {
+			t.Fatalf("document %d is not valid CBOR: %v", i, err)
+		}
+		if errs := validation.ValidateCustomResource(field.NewPath(""), doc, validator); len(errs) > 0 {
+			t.Errorf("document %d should satisfy the schema: %v\n%#v", i, errs, doc)
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		data, desc, err := g.Invalid(testGVK, structural)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc interface{}
+		if err := direct.Unmarshal(bytes.TrimPrefix(data, selfDescribedCBOR), &doc); err != nil {
+			t.Fatalf("document %d is not valid CBOR: %v", i, err)
+		}
+		if errs := validation.ValidateCustomResource(field.NewPath(""), doc, validator); len(errs) == 0 {
+			t.Errorf("document %d with violation %q should not satisfy the schema:\n%#v", i, desc, doc)
+		}
+	}
+}
+
+func TestGeneratorDeterminism(t *testing.T) {
+	props := testSchema()
+	structural, err := structuralschema.NewStructural(props)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewGenerator(7).Valid(testGVK, structural)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewGenerator(7).Valid(testGVK, structural)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Error("generators with the same seed should produce identical documents")
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package reviews provides golden CBOR encodings of AdmissionReview and ConversionReview
+// documents as produced by the API server's serializer, together with validation helpers
+// for the documents a webhook sends back. Webhook authors can replay the fixtures against
+// their servers and validate their responses without depending on a running API server,
+// so that webhooks keep working when API servers begin sending CBOR review bodies.
+package reviews
+
+import (
+	"errors"
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/apitesting/vectors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+var scheme = runtime.NewScheme()
+
+// serializer decodes strictly so that validation rejects response documents carrying
+// fields that an API server would not recognize.
+var reviewSerializer = cbor.NewSerializerWithOptions(cbor.DefaultMetaFactory, scheme, scheme, cbor.SerializerOptions{Strict: true})
+
+func init() {
+	utilruntime.Must(admissionv1.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
+}
+
+// Fixture pairs a review document with its encoding as produced by the API server's CBOR
+// serializer, including the self-describe tag prefix that the serializer places before
+// every document.
+type Fixture struct {
+	// Name identifies the fixture in test output.
+	Name string
+	// Object is the review document.
+	Object runtime.Object
+	// CBOR is the serializer's encoding of Object.
+	CBOR []byte
+}
+
+// AdmissionReviewFixtures returns admission.k8s.io/v1 AdmissionReview documents and their
+// CBOR encodings. The request fixtures are representative of what an API server sends to
+// an admission webhook, and the response fixtures of what a webhook sends back. Note that
+// []byte fields like the response patch are encoded as CBOR byte strings rather than the
+// base64 text used in JSON.
+func AdmissionReviewFixtures() []Fixture {
+	patchType := admissionv1.PatchTypeJSONPatch
+	return []Fixture{
+		{
+			Name: "request to admit a create",
+			Object: &admissionv1.AdmissionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+				Request: &admissionv1.AdmissionRequest{
+					UID:       "705ab4f5-6393-11e8-b7cc-42010a800002",
+					Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+					Resource:  metav1.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+					Name:      "example",
+					Namespace: "default",
+					Operation: admissionv1.Create,
+					UserInfo:  authenticationv1.UserInfo{Username: "system:serviceaccount:default:example"},
+					Object:    runtime.RawExtension{Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"example","namespace":"default"},"data":{"key":"value"}}`)},
+				},
+			},
+			CBOR: vectors.MustHexDecode("d9d9f7a3646b696e646f41646d697373696f6e5265766965776772657175657374aa63756964782437303561623466352d363339332d313165382d623763632d343230313061383030303032646b696e64a3646b696e6469436f6e6669674d61706567726f7570606776657273696f6e627631646e616d65676578616d706c65666f626a656374a46464617461a1636b65796576616c7565646b696e6469436f6e6669674d6170686d65746164617461a2646e616d65676578616d706c65696e616d6573706163656764656661756c746a61706956657273696f6e627631676f7074696f6e73f6687265736f75726365a36567726f7570606776657273696f6e627631687265736f757263656a636f6e6669676d6170736875736572496e666fa168757365726e616d65782573797374656d3a736572766963656163636f756e743a64656661756c743a6578616d706c65696e616d6573706163656764656661756c74696f6c644f626a656374f6696f7065726174696f6e664352454154456a61706956657273696f6e7361646d697373696f6e2e6b38732e696f2f7631"),
+		},
+		{
+			Name: "response allowing a request",
+			Object: &admissionv1.AdmissionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+				Response: &admissionv1.AdmissionResponse{
+					UID:     "705ab4f5-6393-11e8-b7cc-42010a800002",
+					Allowed: true,
+				},
+			},
+			CBOR: vectors.MustHexDecode("d9d9f7a3646b696e646f41646d697373696f6e52657669657768726573706f6e7365a263756964782437303561623466352d363339332d313165382d623763632d34323031306138303030303267616c6c6f776564f56a61706956657273696f6e7361646d697373696f6e2e6b38732e696f2f7631"),
+		},
+		{
+			Name: "response patching a request",
+			Object: &admissionv1.AdmissionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+				Response: &admissionv1.AdmissionResponse{
+					UID:       "705ab4f5-6393-11e8-b7cc-42010a800002",
+					Allowed:   true,
+					Patch:     []byte(`[{"op":"add","path":"/metadata/labels/patched","value":"true"}]`),
+					PatchType: &patchType,
+				},
+			},
+			CBOR: vectors.MustHexDecode("d9d9f7a3646b696e646f41646d697373696f6e52657669657768726573706f6e7365a463756964782437303561623466352d363339332d313165382d623763632d343230313061383030303032657061746368583f5b7b226f70223a22616464222c2270617468223a222f6d657461646174612f6c6162656c732f70617463686564222c2276616c7565223a2274727565227d5d67616c6c6f776564f569706174636854797065694a534f4e50617463686a61706956657273696f6e7361646d697373696f6e2e6b38732e696f2f7631"),
+		},
+	}
+}
+
+// ConversionReviewFixtures returns apiextensions.k8s.io/v1 ConversionReview documents and
+// their CBOR encodings. Objects submitted for conversion arrive as single CBOR data items
+// in the objects list, in the same positions that JSON review bodies carry JSON objects.
+func ConversionReviewFixtures() []Fixture {
+	return []Fixture{
+		{
+			Name: "request to convert a custom resource",
+			Object: &apiextensionsv1.ConversionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: apiextensionsv1.SchemeGroupVersion.String(), Kind: "ConversionReview"},
+				Request: &apiextensionsv1.ConversionRequest{
+					UID:               "705ab4f5-6393-11e8-b7cc-42010a800002",
+					DesiredAPIVersion: "example.com/v2",
+					Objects: []runtime.RawExtension{
+						{Raw: []byte(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"example"},"spec":{"replicas":1}}`)},
+					},
+				},
+			},
+			CBOR: vectors.MustHexDecode("d9d9f7a3646b696e6470436f6e76657273696f6e5265766965776772657175657374a363756964782437303561623466352d363339332d313165382d623763632d343230313061383030303032676f626a6563747381a4646b696e64665769646765746473706563a1687265706c69636173f93c00686d65746164617461a1646e616d65676578616d706c656a61706956657273696f6e6e6578616d706c652e636f6d2f7631716465736972656441504956657273696f6e6e6578616d706c652e636f6d2f76326a61706956657273696f6e77617069657874656e73696f6e732e6b38732e696f2f7631"),
+		},
+		{
+			Name: "response with converted objects",
+			Object: &apiextensionsv1.ConversionReview{
+				TypeMeta: metav1.TypeMeta{APIVersion: apiextensionsv1.SchemeGroupVersion.String(), Kind: "ConversionReview"},
+				Response: &apiextensionsv1.ConversionResponse{
+					UID: "705ab4f5-6393-11e8-b7cc-42010a800002",
+					ConvertedObjects: []runtime.RawExtension{
+						{Raw: []byte(`{"apiVersion":"example.com/v2","kind":"Widget","metadata":{"name":"example"},"spec":{"instances":1}}`)},
+					},
+					Result: metav1.Status{Status: metav1.StatusSuccess},
+				},
+			},
+			CBOR: vectors.MustHexDecode("d9d9f7a3646b696e6470436f6e76657273696f6e52657669657768726573706f6e7365a363756964782437303561623466352d363339332d313165382d623763632d34323031306138303030303266726573756c74a2667374617475736753756363657373686d65746164617461a070636f6e7665727465644f626a6563747381a4646b696e64665769646765746473706563a169696e7374616e636573f93c00686d65746164617461a1646e616d65676578616d706c656a61706956657273696f6e6e6578616d706c652e636f6d2f76326a61706956657273696f6e77617069657874656e73696f6e732e6b38732e696f2f7631"),
+		},
+	}
+}
+
+// ValidateAdmissionReviewResponse checks that data is a CBOR-encoded AdmissionReview that
+// an API server would accept as a response from an admission webhook, returning the
+// decoded review on success.
+func ValidateAdmissionReviewResponse(data []byte) (*admissionv1.AdmissionReview, error) {
+	obj, gvk, err := reviewSerializer.Decode(data, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	review, ok := obj.(*admissionv1.AdmissionReview)
+	if !ok {
+		return nil, fmt.Errorf("expected %v, got %v", admissionv1.SchemeGroupVersion.WithKind("AdmissionReview"), gvk)
+	}
+	if review.Response == nil {
+		return nil, errors.New("response is not set")
+	}
+	if review.Response.UID == "" {
+		return nil, errors.New("response uid is not set")
+	}
+	if len(review.Response.Patch) > 0 && (review.Response.PatchType == nil || *review.Response.PatchType != admissionv1.PatchTypeJSONPatch) {
+		return nil, fmt.Errorf("response patchType must be %q when a patch is provided", admissionv1.PatchTypeJSONPatch)
+	}
+	return review, nil
+}
+
+// ValidateConversionReviewResponse checks that data is a CBOR-encoded ConversionReview
+// that an API server would accept as a response from a conversion webhook, returning the
+// decoded review on success.
+func ValidateConversionReviewResponse(data []byte) (*apiextensionsv1.ConversionReview, error) {
+	obj, gvk, err := reviewSerializer.Decode(data, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	review, ok := obj.(*apiextensionsv1.ConversionReview)
+	if !ok {
+		return nil, fmt.Errorf("expected %v, got %v", apiextensionsv1.SchemeGroupVersion.WithKind("ConversionReview"), gvk)
+	}
+	if review.Response == nil {
+		return nil, errors.New("response is not set")
+	}
+	if review.Response.UID == "" {
+		return nil, errors.New("response uid is not set")
+	}
+	if status := review.Response.Result.Status; status != metav1.StatusSuccess && status != metav1.StatusFailure {
+		return nil, fmt.Errorf("response result status must be %q or %q, got %q", metav1.StatusSuccess, metav1.StatusFailure, status)
+	}
+	return review, nil
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reviews
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// TestFixturesMatchEncoder pins the fixture bytes to the serializer's output so that a
+// change to the encoding of review documents is caught here before it reaches webhooks
+// that were tested against the published fixtures.
+func TestFixturesMatchEncoder(t *testing.T) {
+	for _, fixture := range append(AdmissionReviewFixtures(), ConversionReviewFixtures()...) {
+		t.Run(fixture.Name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := reviewSerializer.Encode(fixture.Object, &buf); err != nil {
+				t.Fatalf("unexpected encode error: %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), fixture.CBOR) {
+				t.Errorf("fixture bytes do not match the encoder's output:\nfixture: %x\nencoder: %x", fixture.CBOR, buf.Bytes())
+			}
+		})
+	}
+}
+
+func TestValidateAdmissionReviewResponse(t *testing.T) {
+	mustEncode := func(obj runtime.Object) []byte {
+		var buf bytes.Buffer
+		if err := reviewSerializer.Encode(obj, &buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	patchType := admissionv1.PatchTypeJSONPatch
+	review := func(response *admissionv1.AdmissionResponse) *admissionv1.AdmissionReview {
+		return &admissionv1.AdmissionReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+			Response: response,
+		}
+	}
+
+	for _, tc := range []struct {
+		name          string
+		data          []byte
+		expectedError string
+	}{
+		{
+			name: "valid",
+			data: mustEncode(review(&admissionv1.AdmissionResponse{UID: "uid", Allowed: true})),
+		},
+		{
+			name: "valid with patch",
+			data: mustEncode(review(&admissionv1.AdmissionResponse{UID: "uid", Allowed: true, Patch: []byte(`[]`), PatchType: &patchType})),
+		},
+		{
+			name:          "not cbor",
+			data:          []byte(`{"apiVersion":"admission.k8s.io/v1","kind":"AdmissionReview"}`),
+			expectedError: "cbor parse error",
+		},
+		{
+			name:          "wrong kind",
+			data:          ConversionReviewFixtures()[0].CBOR,
+			expectedError: "expected admission.k8s.io/v1, Kind=AdmissionReview",
+		},
+		{
+			name:          "no response",
+			data:          AdmissionReviewFixtures()[0].CBOR,
+			expectedError: "response is not set",
+		},
+		{
+			name:          "no uid",
+			data:          mustEncode(review(&admissionv1.AdmissionResponse{Allowed: true})),
+			expectedError: "response uid is not set",
+		},
+		{
+			name:          "patch without patch type",
+			data:          mustEncode(review(&admissionv1.AdmissionResponse{UID: "uid", Allowed: true, Patch: []byte(`[]`)})),
+			expectedError: `response patchType must be "JSONPatch"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ValidateAdmissionReviewResponse(tc.data)
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestValidateConversionReviewResponse(t *testing.T) {
+	mustEncode := func(obj runtime.Object) []byte {
+		var buf bytes.Buffer
+		if err := reviewSerializer.Encode(obj, &buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	review := func(response *apiextensionsv1.ConversionResponse) *apiextensionsv1.ConversionReview {
+		return &apiextensionsv1.ConversionReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: apiextensionsv1.SchemeGroupVersion.String(), Kind: "ConversionReview"},
+			Response: response,
+		}
+	}
+
+	for _, tc := range []struct {
+		name          string
+		data          []byte
+		expectedError string
+	}{
+		{
+			name: "valid",
+			data: mustEncode(review(&apiextensionsv1.ConversionResponse{UID: "uid", Result: metav1.Status{Status: metav1.StatusSuccess}})),
+		},
+		{
+			name: "valid failure",
+			data: mustEncode(review(&apiextensionsv1.ConversionResponse{UID: "uid", Result: metav1.Status{Status: metav1.StatusFailure, Message: "conversion failed"}})),
+		},
+		{
+			name:          "wrong kind",
+			data:          AdmissionReviewFixtures()[0].CBOR,
+			expectedError: "expected apiextensions.k8s.io/v1, Kind=ConversionReview",
+		},
+		{
+			name:          "no response",
+			data:          ConversionReviewFixtures()[0].CBOR,
+			expectedError: "response is not set",
+		},
+		{
+			name:          "no uid",
+			data:          mustEncode(review(&apiextensionsv1.ConversionResponse{Result: metav1.Status{Status: metav1.StatusSuccess}})),
+			expectedError: "response uid is not set",
+		},
+		{
+			name:          "no result status",
+			data:          mustEncode(review(&apiextensionsv1.ConversionResponse{UID: "uid"})),
+			expectedError: `response result status must be "Success" or "Failure"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ValidateConversionReviewResponse(tc.data)
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+// reviewFuzzer randomizes review documents while keeping their embedded objects valid,
+// since the raw extensions in a review must hold encoded objects rather than arbitrary
+// bytes.
+func reviewFuzzer() *fuzz.Fuzzer {
+	return fuzz.NewWithSeed(42).NilChance(0.2).NumElements(0, 3).Funcs(
+		func(re *runtime.RawExtension, c fuzz.Continue) {
+			re.Raw = []byte(fmt.Sprintf(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"widget-%d"}}`, c.Intn(1000)))
+		},
+		func(pt **admissionv1.PatchType, c fuzz.Continue) {
+			*pt = nil
+		},
+	)
+}
+
+// TestFuzzedAdmissionResponsesValidate encodes randomized admission responses the way a
+// webhook using this scheme and serializer would and checks that every one of them passes
+// validation, so that the helper does not reject documents our own encoder produces.
+func TestFuzzedAdmissionResponsesValidate(t *testing.T) {
+	fuzzer := reviewFuzzer()
+	patchType := admissionv1.PatchTypeJSONPatch
+	for i := 0; i < 50; i++ {
+		response := &admissionv1.AdmissionResponse{}
+		fuzzer.Fuzz(response)
+		response.UID = "uid"
+		if len(response.Patch) > 0 {
+			response.PatchType = &patchType
+		}
+		review := &admissionv1.AdmissionReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+			Response: response,
+		}
+		var buf bytes.Buffer
+		if err := reviewSerializer.Encode(review, &buf); err != nil {
+			t.Fatalf("unexpected error encoding %#v: %v", review, err)
+		}
+		if _, err := ValidateAdmissionReviewResponse(buf.Bytes()); err != nil {
+			t.Errorf("fuzzed response %#v failed validation: %v", response, err)
+		}
+	}
+}
+
+// TestFuzzedConversionResponsesValidate is the conversion counterpart of
+// TestFuzzedAdmissionResponsesValidate.
+func TestFuzzedConversionResponsesValidate(t *testing.T) {
+	fuzzer := reviewFuzzer()
+	for i := 0; i < 50; i++ {
+		response := &apiextensionsv1.ConversionResponse{}
+		fuzzer.Fuzz(response)
+		response.UID = "uid"
+		if i%2 == 0 {
+			response.Result = metav1.Status{Status: metav1.StatusSuccess}
+		} else {
+			response.Result = metav1.Status{Status: metav1.StatusFailure, Message: "conversion failed"}
+		}
+		review := &apiextensionsv1.ConversionReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: apiextensionsv1.SchemeGroupVersion.String(), Kind: "ConversionReview"},
+			Response: response,
+		}
+		var buf bytes.Buffer
+		if err := reviewSerializer.Encode(review, &buf); err != nil {
+			t.Fatalf("unexpected error encoding %#v: %v", review, err)
+		}
+		if _, err := ValidateConversionReviewResponse(buf.Bytes()); err != nil {
+			t.Errorf("fuzzed response %#v failed validation: %v", response, err)
+		}
+	}
+}
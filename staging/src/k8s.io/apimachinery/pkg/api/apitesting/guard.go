@@ -0,0 +1,54 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apitesting
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// NewGuardedEncoder wraps an encoder so that Encode returns an error if the delegate
+// mutates the object it encodes. Encoders are required to leave objects unmodified (see
+// the runtime.Encoder contract) so that read-only handlers can encode objects shared
+// with serving caches without copying them first; wrapping a serializer under test with
+// the guard turns a violation into a test failure instead of cache corruption. The guard
+// deep-copies every object it encodes, so it is for use in tests only.
+func NewGuardedEncoder(delegate runtime.Encoder) runtime.Encoder {
+	return guardedEncoder{delegate: delegate}
+}
+
+type guardedEncoder struct {
+	delegate runtime.Encoder
+}
+
+func (e guardedEncoder) Encode(obj runtime.Object, w io.Writer) error {
+	original := obj.DeepCopyObject()
+	if err := e.delegate.Encode(obj, w); err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(original, obj) {
+		return fmt.Errorf("encoder %q mutated the object it encoded", e.delegate.Identifier())
+	}
+	return nil
+}
+
+func (e guardedEncoder) Identifier() runtime.Identifier {
+	return e.delegate.Identifier()
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apitesting_test
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/apitesting"
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	"k8s.io/apimachinery/pkg/apis/testapigroup"
+	testapigroupfuzzer "k8s.io/apimachinery/pkg/apis/testapigroup/fuzzer"
+	"k8s.io/apimachinery/pkg/apis/testapigroup/install"
+	testapigroupv1 "k8s.io/apimachinery/pkg/apis/testapigroup/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	cborserializer "k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+	jsonserializer "k8s.io/apimachinery/pkg/runtime/serializer/json"
+	protobufserializer "k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+)
+
+// stampingEncoder writes a placeholder document after setting the object's kind, in
+// violation of the encoder contract.
+type stampingEncoder struct{}
+
+func (stampingEncoder) Encode(obj runtime.Object, w io.Writer) error {
+	obj.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{Version: "v1", Kind: "Stamped"})
+	_, err := w.Write([]byte("{}"))
+	return err
+}
+
+func (stampingEncoder) Identifier() runtime.Identifier {
+	return runtime.Identifier("stamping")
+}
+
+func TestGuardedEncoderDetectsMutation(t *testing.T) {
+	if err := apitesting.NewGuardedEncoder(stampingEncoder{}).Encode(&testapigroupv1.Carp{}, &bytes.Buffer{}); err == nil {
+		t.Error("expected an error encoding with a mutating encoder, got none")
+	}
+}
+
+// TestSerializersEncodeWithoutMutation encodes fuzzed objects with each of the JSON,
+// CBOR, and protobuf serializers under the mutation guard. Read-only handlers encode
+// objects shared with serving caches, so none of the serializers may modify an object
+// while encoding it.
+func TestSerializersEncodeWithoutMutation(t *testing.T) {
+	const seed = 1587
+	scheme := runtime.NewScheme()
+	install.Install(scheme)
+	codecs := runtimeserializer.NewCodecFactory(scheme)
+	f := fuzzer.FuzzerFor(fuzzer.MergeFuzzerFuncs(metafuzzer.Funcs, testapigroupfuzzer.Funcs), rand.NewSource(seed), codecs)
+
+	encoders := map[string]runtime.Encoder{
+		"json":     apitesting.NewGuardedEncoder(jsonserializer.NewSerializerWithOptions(jsonserializer.DefaultMetaFactory, scheme, scheme, jsonserializer.SerializerOptions{})),
+		"cbor":     apitesting.NewGuardedEncoder(cborserializer.NewSerializer(cborserializer.DefaultMetaFactory, scheme, scheme)),
+		"protobuf": apitesting.NewGuardedEncoder(protobufserializer.NewSerializer(scheme, scheme)),
+	}
+
+	for i := 0; i < 50; i++ {
+		internal := &testapigroup.Carp{}
+		f.Fuzz(internal)
+		external, err := scheme.ConvertToVersion(internal, testapigroupv1.SchemeGroupVersion)
+		if err != nil {
+			t.Fatalf("failed to convert fuzzed object to external version: %v", err)
+		}
+		external.GetObjectKind().SetGroupVersionKind(testapigroupv1.SchemeGroupVersion.WithKind("Carp"))
+
+		list := &testapigroupv1.CarpList{Items: []testapigroupv1.Carp{*external.(*testapigroupv1.Carp)}}
+		list.GetObjectKind().SetGroupVersionKind(testapigroupv1.SchemeGroupVersion.WithKind("CarpList"))
+
+		for name, encoder := range encoders {
+			for _, obj := range []runtime.Object{external, list} {
+				if err := encoder.Encode(obj, &bytes.Buffer{}); err != nil {
+					t.Errorf("%s: unexpected error encoding %T: %v", name, obj, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,399 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package roundtrip
+
+import (
+	"bytes"
+	gojson "encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+
+	apitesting "k8s.io/apimachinery/pkg/api/apitesting"
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	"k8s.io/apimachinery/pkg/conversion"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	cborserializer "k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/apimachinery/pkg/util/diff"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// RoundTripCBORTestForAPIGroup is convenient to call from your install package to make
+// sure that every type in your group means the same thing in CBOR as it does in JSON. A
+// type given custom marshaling in one format but not the other fails here.
+func RoundTripCBORTestForAPIGroup(t *testing.T, installFn InstallFunc, fuzzingFuncs fuzzer.FuzzerFuncs) {
+	scheme := runtime.NewScheme()
+	installFn(scheme)
+
+	RoundTripCBORTestForScheme(t, scheme, fuzzingFuncs)
+}
+
+// RoundTripCBORTestForScheme is convenient to call if you already have a scheme and want
+// to make sure that its types have equivalent CBOR and JSON encodings.
+func RoundTripCBORTestForScheme(t *testing.T, scheme *runtime.Scheme, fuzzingFuncs fuzzer.FuzzerFuncs) {
+	codecFactory := runtimeserializer.NewCodecFactory(scheme)
+	f := fuzzer.FuzzerFor(
+		fuzzer.MergeFuzzerFuncs(metafuzzer.Funcs, fuzzingFuncs),
+		rand.NewSource(rand.Int63()),
+		codecFactory,
+	)
+	RoundTripCBORTypes(t, scheme, codecFactory, f, nil)
+}
+
+// RoundTripCBORTypes applies the CBOR round-trip and JSON cross-equality test to all
+// round-trippable Kinds in the scheme. Internal kinds are fuzzed and converted to each
+// of their external versions, exactly as the JSON and protobuf round-trip tests do, so
+// the fuzzer funcs registered for the internal types apply.
+func RoundTripCBORTypes(t *testing.T, scheme *runtime.Scheme, codecFactory runtimeserializer.CodecFactory, fuzzer *fuzz.Fuzzer, nonRoundTrippableTypes map[schema.GroupVersionKind]bool) {
+	cborS := cborserializer.NewSerializer(cborserializer.DefaultMetaFactory, scheme, scheme)
+
+	for _, group := range groupsFromScheme(scheme) {
+		internalVersion := schema.GroupVersion{Group: group, Version: runtime.APIVersionInternal}
+
+		for kind := range scheme.KnownTypes(internalVersion) {
+			if globalNonRoundTrippableTypes.Has(kind) {
+				continue
+			}
+
+			internalGVK := internalVersion.WithKind(kind)
+			if nonRoundTrippableTypes[internalGVK] {
+				continue
+			}
+
+			// Try a few times, since the test uses random values.
+			for i := 0; i < *FuzzIters; i++ {
+				cborRoundTripToAllExternalVersions(t, scheme, codecFactory, cborS, fuzzer, internalGVK, nonRoundTrippableTypes)
+				if t.Failed() {
+					break
+				}
+			}
+		}
+	}
+}
+
+// RoundTripCBORExternalTypes fuzzes instances of every external round-trippable Kind in
+// the scheme directly, with no conversion from an internal version. It is for schemes,
+// like the one assembled from the k8s.io/api group builders, that register no internal
+// types.
+func RoundTripCBORExternalTypes(t *testing.T, scheme *runtime.Scheme, fuzzer *fuzz.Fuzzer, nonRoundTrippableTypes map[schema.GroupVersionKind]bool) {
+	cborS := cborserializer.NewSerializer(cborserializer.DefaultMetaFactory, scheme, scheme)
+	jsonS := json.NewSerializer(json.DefaultMetaFactory, scheme, scheme, false)
+
+	for gvk := range scheme.AllKnownTypes() {
+		if gvk.Version == runtime.APIVersionInternal || globalNonRoundTrippableTypes.Has(gvk.Kind) {
+			continue
+		}
+		if nonRoundTrippableTypes[gvk] {
+			continue
+		}
+		t.Run(gvk.Group+"."+gvk.Version+"."+gvk.Kind, func(t *testing.T) {
+			for i := 0; i < *FuzzIters; i++ {
+				cborRoundTripOfExternalType(t, scheme, cborS, jsonS, fuzzer, gvk)
+				if t.Failed() {
+					break
+				}
+			}
+		})
+	}
+}
+
+func cborRoundTripToAllExternalVersions(t *testing.T, scheme *runtime.Scheme, codecFactory runtimeserializer.CodecFactory, cborS runtime.Serializer, fuzzer *fuzz.Fuzzer, internalGVK schema.GroupVersionKind, nonRoundTrippableTypes map[schema.GroupVersionKind]bool) {
+	object, err := scheme.New(internalGVK)
+	if err != nil {
+		t.Fatalf("Couldn't make a %v? %v", internalGVK, err)
+	}
+	if _, err := apimeta.TypeAccessor(object); err != nil {
+		t.Fatalf("%q is not a TypeMeta and cannot be tested - add it to nonRoundTrippableInternalTypes: %v", internalGVK, err)
+	}
+
+	fuzzInternalObject(t, fuzzer, object)
+
+	for externalGVK := range scheme.AllKnownTypes() {
+		if externalGVK.Version == runtime.APIVersionInternal {
+			continue
+		}
+		if externalGVK.GroupKind() != internalGVK.GroupKind() {
+			continue
+		}
+		if nonRoundTrippableTypes[externalGVK] {
+			continue
+		}
+
+		jsonCodec := apitesting.TestCodec(codecFactory, externalGVK.GroupVersion())
+		cborCodec := codecFactory.CodecForVersions(cborS, cborS, externalGVK.GroupVersion(), nil)
+		cborRoundTrip(t, cborCodec, jsonCodec, object)
+	}
+}
+
+func cborRoundTripOfExternalType(t *testing.T, scheme *runtime.Scheme, cborS runtime.Serializer, jsonS runtime.Serializer, fuzzer *fuzz.Fuzzer, externalGVK schema.GroupVersionKind) {
+	object, err := scheme.New(externalGVK)
+	if err != nil {
+		t.Fatalf("Couldn't make a %v? %v", externalGVK, err)
+	}
+	typeAcc, err := apimeta.TypeAccessor(object)
+	if err != nil {
+		t.Fatalf("%q is not a TypeMeta and cannot be tested: %v", externalGVK, err)
+	}
+
+	fuzzInternalObject(t, fuzzer, object)
+
+	typeAcc.SetKind(externalGVK.Kind)
+	typeAcc.SetAPIVersion(externalGVK.GroupVersion().String())
+
+	cborRoundTrip(t, cborS, jsonS, object)
+}
+
+// cborRoundTrip verifies that the object survives a round trip through its CBOR
+// encoding and that decoding its CBOR and JSON encodings produces equal objects. Types
+// that preserve raw bytes in whichever format they were decoded from are compared by
+// the content of those bytes rather than their exact value.
+func cborRoundTrip(t *testing.T, cborCodec runtime.Codec, jsonCodec runtime.Codec, object runtime.Object) {
+	name := reflect.TypeOf(object).Elem().Name()
+
+	cborData, err := runtime.Encode(cborCodec, object)
+	if err != nil {
+		if runtime.IsNotRegisteredError(err) {
+			t.Logf("%v: not registered: %v", name, err)
+			return
+		}
+		t.Errorf("%v: cbor encode failed: %v", name, err)
+		return
+	}
+
+	// serialization to the wire must be stable to ensure that we don't write twice to
+	// the DB when the object hasn't changed.
+	secondData, err := runtime.Encode(cborCodec, object)
+	if err != nil {
+		t.Errorf("%v: cbor encode failed: %v", name, err)
+		return
+	}
+	if !bytes.Equal(cborData, secondData) {
+		t.Errorf("%v: cbor serialization is not stable", name)
+		return
+	}
+
+	fromCBOR, err := runtime.Decode(cborCodec, cborData)
+	if err != nil {
+		t.Errorf("%v: cbor decode failed: %v\nData: % x", name, err, cborData)
+		return
+	}
+	if !crossFormatEqualities.DeepEqual(object, fromCBOR) {
+		t.Errorf("%v: object changed during cbor round trip, diff: %v", name, diff.ObjectReflectDiff(object, fromCBOR))
+		return
+	}
+
+	jsonData, err := runtime.Encode(jsonCodec, object)
+	if err != nil {
+		t.Errorf("%v: json encode failed: %v", name, err)
+		return
+	}
+	fromJSON, err := runtime.Decode(jsonCodec, jsonData)
+	if err != nil {
+		t.Errorf("%v: json decode failed: %v\nData: %s", name, err, jsonData)
+		return
+	}
+	if !crossFormatEqualities.DeepEqual(fromJSON, fromCBOR) {
+		t.Errorf("%v: decoding the json and cbor encodings disagrees, diff: %v\nJSON: %s", name, diff.ObjectReflectDiff(fromJSON, fromCBOR), jsonData)
+	}
+}
+
+// CBORDecodeParityTestForAPIGroup is convenient to call from your install package to
+// make sure that a document produces the same fully-defaulted internal object whether a
+// client submits it as JSON or as CBOR. The round-trip tests compare encodings produced
+// from the same object; this compares the decode paths themselves, starting from
+// equivalent documents, so it exercises defaulting and conversion behavior that only
+// runs during decoding.
+func CBORDecodeParityTestForAPIGroup(t *testing.T, installFn InstallFunc, fuzzingFuncs fuzzer.FuzzerFuncs) {
+	scheme := runtime.NewScheme()
+	installFn(scheme)
+
+	CBORDecodeParityTestForScheme(t, scheme, fuzzingFuncs)
+}
+
+// CBORDecodeParityTestForScheme is convenient to call if you already have a scheme and
+// want to make sure that equivalent JSON and CBOR documents decode to equal objects.
+func CBORDecodeParityTestForScheme(t *testing.T, scheme *runtime.Scheme, fuzzingFuncs fuzzer.FuzzerFuncs) {
+	codecFactory := runtimeserializer.NewCodecFactory(scheme)
+	f := fuzzer.FuzzerFor(
+		fuzzer.MergeFuzzerFuncs(metafuzzer.Funcs, fuzzingFuncs),
+		rand.NewSource(rand.Int63()),
+		codecFactory,
+	)
+	CBORDecodeParityTypes(t, scheme, codecFactory, f, nil)
+}
+
+// CBORDecodeParityTypes applies the decode parity test to all round-trippable Kinds in
+// the scheme.
+func CBORDecodeParityTypes(t *testing.T, scheme *runtime.Scheme, codecFactory runtimeserializer.CodecFactory, fuzzer *fuzz.Fuzzer, nonRoundTrippableTypes map[schema.GroupVersionKind]bool) {
+	cborS := cborserializer.NewSerializer(cborserializer.DefaultMetaFactory, scheme, scheme)
+
+	for _, group := range groupsFromScheme(scheme) {
+		internalVersion := schema.GroupVersion{Group: group, Version: runtime.APIVersionInternal}
+
+		for kind := range scheme.KnownTypes(internalVersion) {
+			if globalNonRoundTrippableTypes.Has(kind) {
+				continue
+			}
+
+			internalGVK := internalVersion.WithKind(kind)
+			if nonRoundTrippableTypes[internalGVK] {
+				continue
+			}
+
+			// Try a few times, since the test uses random values.
+			for i := 0; i < *FuzzIters; i++ {
+				cborDecodeParityToAllExternalVersions(t, scheme, codecFactory, cborS, fuzzer, internalGVK, nonRoundTrippableTypes)
+				if t.Failed() {
+					break
+				}
+			}
+		}
+	}
+}
+
+func cborDecodeParityToAllExternalVersions(t *testing.T, scheme *runtime.Scheme, codecFactory runtimeserializer.CodecFactory, cborS runtime.Serializer, fuzzer *fuzz.Fuzzer, internalGVK schema.GroupVersionKind, nonRoundTrippableTypes map[schema.GroupVersionKind]bool) {
+	object, err := scheme.New(internalGVK)
+	if err != nil {
+		t.Fatalf("Couldn't make a %v? %v", internalGVK, err)
+	}
+	if _, err := apimeta.TypeAccessor(object); err != nil {
+		t.Fatalf("%q is not a TypeMeta and cannot be tested - add it to nonRoundTrippableInternalTypes: %v", internalGVK, err)
+	}
+
+	fuzzInternalObject(t, fuzzer, object)
+
+	for externalGVK := range scheme.AllKnownTypes() {
+		if externalGVK.Version == runtime.APIVersionInternal {
+			continue
+		}
+		if externalGVK.GroupKind() != internalGVK.GroupKind() {
+			continue
+		}
+		if nonRoundTrippableTypes[externalGVK] {
+			continue
+		}
+
+		jsonCodec := apitesting.TestCodec(codecFactory, externalGVK.GroupVersion())
+		cborCodec := codecFactory.CodecForVersions(cborS, cborS, externalGVK.GroupVersion(), nil)
+		cborDecodeParity(t, jsonCodec, cborCodec, object)
+	}
+}
+
+// cborDecodeParity encodes the object as a JSON document, derives the equivalent CBOR
+// document by transcoding the JSON text — the object itself is never encoded to CBOR —
+// and decodes both documents through their full codec chains, conversion and defaulting
+// included. The two decoded objects must be semantically equal.
+func cborDecodeParity(t *testing.T, jsonCodec runtime.Codec, cborCodec runtime.Codec, object runtime.Object) {
+	name := reflect.TypeOf(object).Elem().Name()
+
+	jsonData, err := runtime.Encode(jsonCodec, object)
+	if err != nil {
+		if runtime.IsNotRegisteredError(err) {
+			t.Logf("%v: not registered: %v", name, err)
+			return
+		}
+		t.Errorf("%v: json encode failed: %v", name, err)
+		return
+	}
+
+	// Integer-valued numbers are transcoded as integers, matching what any CBOR writer
+	// starting from the same document would produce.
+	var decoded interface{}
+	if err := utiljson.Unmarshal(jsonData, &decoded); err != nil {
+		t.Errorf("%v: json document did not decode: %v\nData: %s", name, err, jsonData)
+		return
+	}
+	cborData, err := direct.Marshal(decoded)
+	if err != nil {
+		t.Errorf("%v: transcode to cbor failed: %v\nData: %s", name, err, jsonData)
+		return
+	}
+
+	fromJSON, err := runtime.Decode(jsonCodec, jsonData)
+	if err != nil {
+		t.Errorf("%v: json decode failed: %v\nData: %s", name, err, jsonData)
+		return
+	}
+	fromCBOR, err := runtime.Decode(cborCodec, cborData)
+	if err != nil {
+		t.Errorf("%v: cbor decode failed: %v\nData: % x\nJSON: %s", name, err, cborData, jsonData)
+		return
+	}
+	if !crossFormatEqualities.DeepEqual(fromJSON, fromCBOR) {
+		t.Errorf("%v: the json and cbor decode paths disagree, diff: %v\nJSON: %s", name, diff.ObjectReflectDiff(fromJSON, fromCBOR), jsonData)
+	}
+}
+
+// crossFormatEqualities extends the semantic equalities with comparisons for the types
+// that deliberately preserve raw bytes in whichever wire format they were decoded from,
+// so that an object decoded from CBOR can be compared against one decoded from JSON.
+var crossFormatEqualities = func() conversion.Equalities {
+	e := conversion.EqualitiesOrDie(
+		func(a, b runtime.RawExtension) bool {
+			return rawDocumentsEqual(a.Raw, b.Raw)
+		},
+		func(a, b runtime.Unknown) bool {
+			return a.TypeMeta == b.TypeMeta && a.ContentEncoding == b.ContentEncoding && rawDocumentsEqual(a.Raw, b.Raw)
+		},
+	)
+	for t, f := range apiequality.Semantic.Equalities {
+		if _, ok := e.Equalities[t]; !ok {
+			e.Equalities[t] = f
+		}
+	}
+	return e
+}()
+
+// cborSelfDescribed is the encoded form of tag 55799, which distinguishes raw CBOR bytes
+// from the JSON historically stored in the raw-preserving types.
+var cborSelfDescribed = []byte{0xd9, 0xd9, 0xf7}
+
+// rawDocumentsEqual decodes two raw documents, each of which may be JSON or
+// self-described CBOR, and compares the resulting unstructured values.
+func rawDocumentsEqual(a, b []byte) bool {
+	ua, err := decodeRawDocument(a)
+	if err != nil {
+		return false
+	}
+	ub, err := decodeRawDocument(b)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(ua, ub)
+}
+
+func decodeRawDocument(raw []byte) (interface{}, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var u interface{}
+	if bytes.HasPrefix(raw, cborSelfDescribed) {
+		err := direct.Unmarshal(bytes.TrimPrefix(raw, cborSelfDescribed), &u)
+		return u, err
+	}
+	err := gojson.Unmarshal(raw, &u)
+	return u, err
+}
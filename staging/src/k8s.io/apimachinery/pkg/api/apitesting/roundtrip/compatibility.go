@@ -28,6 +28,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/google/go-cmp/cmp"
 	fuzz "github.com/google/gofuzz"
 
@@ -64,6 +65,7 @@ type CompatibilityTestOptions struct {
 	// * verified to decode without error
 	// * verified to round-trip byte-for-byte when re-encoded
 	// * verified to be semantically equal when decoded into memory
+	// When CBOR is set, `<group>.<version>.<kind>.cbor` files are verified the same way.
 	TestDataDirCurrentVersion string
 
 	// TestDataDirsPreviousVersions is a list of directories containing compatibility test data for previous versions.
@@ -90,6 +92,12 @@ type CompatibilityTestOptions struct {
 	JSON  runtime.Serializer
 	YAML  runtime.Serializer
 	Proto runtime.Serializer
+
+	// CBOR enables verification of `<group>.<version>.<kind>.cbor` fixtures alongside the
+	// json/yaml/pb fixtures when set. It is nil by default, and left nil by Complete(),
+	// because CBOR fixtures are only meaningful for schemes whose types all round-trip
+	// through CBOR; test suites opt in by setting it explicitly.
+	CBOR runtime.Serializer
 }
 
 func NewCompatibilityTestOptions(scheme *runtime.Scheme) *CompatibilityTestOptions {
@@ -287,11 +295,22 @@ func (c *CompatibilityTestOptions) Run(t *testing.T) {
 func (c *CompatibilityTestOptions) runCurrentVersionTest(t *testing.T, gvk schema.GroupVersionKind) {
 	expectedObject := c.FuzzedObjects[gvk]
 	expectedJSON, expectedYAML, expectedProto := c.encode(t, expectedObject)
+	var expectedCBOR []byte
+	if c.CBOR != nil {
+		expectedCBOR = c.encodeCBOR(t, expectedObject)
+	}
 
 	actualJSON, actualYAML, actualProto, err := read(c.TestDataDirCurrentVersion, gvk, "")
 	if err != nil && !os.IsNotExist(err) {
 		t.Fatal(err)
 	}
+	var actualCBOR []byte
+	if c.CBOR != nil && err == nil {
+		actualCBOR, err = readCBOR(c.TestDataDirCurrentVersion, gvk, "")
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+	}
 
 	needsUpdate := false
 	if os.IsNotExist(err) {
@@ -316,6 +335,12 @@ func (c *CompatibilityTestOptions) runCurrentVersionTest(t *testing.T, gvk schem
 			t.Log(cmp.Diff(dumpProto(t, actualProto[4:]), dumpProto(t, expectedProto[4:])))
 			// t.Logf("json (for locating the offending field based on surrounding data): %s", string(expectedJSON))
 		}
+
+		if c.CBOR != nil && !bytes.Equal(expectedCBOR, actualCBOR) {
+			t.Errorf("cbor differs")
+			needsUpdate = true
+			t.Log(cmp.Diff(dumpCBOR(t, actualCBOR), dumpCBOR(t, expectedCBOR)))
+		}
 	}
 
 	if needsUpdate {
@@ -324,6 +349,9 @@ func (c *CompatibilityTestOptions) runCurrentVersionTest(t *testing.T, gvk schem
 			writeFile(t, c.TestDataDirCurrentVersion, gvk, "", "json", expectedJSON)
 			writeFile(t, c.TestDataDirCurrentVersion, gvk, "", "yaml", expectedYAML)
 			writeFile(t, c.TestDataDirCurrentVersion, gvk, "", "pb", expectedProto)
+			if c.CBOR != nil {
+				writeFile(t, c.TestDataDirCurrentVersion, gvk, "", "cbor", expectedCBOR)
+			}
 			t.Logf("wrote expected compatibility data... verify, commit, and rerun tests")
 		} else {
 			t.Logf("if the diff is expected because of a new type or a new field, re-run with %s=true to update the compatibility data", updateEnvVar)
@@ -362,6 +390,15 @@ func (c *CompatibilityTestOptions) runCurrentVersionTest(t *testing.T, gvk schem
 			t.Errorf("expected and decoded proto objects differed:\n%s", cmp.Diff(expectedObject, protoDecoded))
 		}
 	}
+	if c.CBOR != nil {
+		cborDecoded := emptyObj.DeepCopyObject()
+		cborDecoded, _, err = c.CBOR.Decode(actualCBOR, &gvk, cborDecoded)
+		if err != nil {
+			t.Error(err)
+		} else if !apiequality.Semantic.DeepEqual(expectedObject, cborDecoded) {
+			t.Errorf("expected and decoded cbor objects differed:\n%s", cmp.Diff(expectedObject, cborDecoded))
+		}
+	}
 }
 
 func (c *CompatibilityTestOptions) encode(t *testing.T, obj runtime.Object) (json, yaml, proto []byte) {
@@ -380,6 +417,18 @@ func (c *CompatibilityTestOptions) encode(t *testing.T, obj runtime.Object) (jso
 	return jsonBytes.Bytes(), yamlBytes.Bytes(), protoBytes.Bytes()
 }
 
+func (c *CompatibilityTestOptions) encodeCBOR(t *testing.T, obj runtime.Object) []byte {
+	cborBytes := bytes.NewBuffer(nil)
+	if err := c.CBOR.Encode(obj, cborBytes); err != nil {
+		t.Fatalf("error encoding cbor: %v", err)
+	}
+	return cborBytes.Bytes()
+}
+
+func readCBOR(dir string, gvk schema.GroupVersionKind, suffix string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(dir, makeName(gvk)+suffix+".cbor"))
+}
+
 func read(dir string, gvk schema.GroupVersionKind, suffix string) (json, yaml, proto []byte, err error) {
 	actualJSON, jsonErr := ioutil.ReadFile(filepath.Join(dir, makeName(gvk)+suffix+".json"))
 	actualYAML, yamlErr := ioutil.ReadFile(filepath.Join(dir, makeName(gvk)+suffix+".yaml"))
@@ -457,6 +506,30 @@ func (c *CompatibilityTestOptions) runPreviousVersionTest(t *testing.T, gvk sche
 	}
 	protoAfterRoundTrip := protoBytes.Bytes()
 
+	// Previous releases may predate CBOR fixtures, so an absent .cbor file skips the
+	// cbor checks for this directory rather than failing.
+	var cborBeforeRoundTrip, cborAfterRoundTrip []byte
+	if c.CBOR != nil {
+		cborBeforeRoundTrip, err = readCBOR(previousVersionDir, gvk, "")
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+	}
+	if len(cborBeforeRoundTrip) > 0 {
+		cborDecoded := emptyObj.DeepCopyObject()
+		cborDecoded, _, err = c.CBOR.Decode(cborBeforeRoundTrip, &gvk, cborDecoded)
+		if err != nil {
+			t.Fatal(err)
+		} else if !apiequality.Semantic.DeepEqual(jsonDecoded, cborDecoded) {
+			t.Errorf("decoded json and cbor objects differ:\n%s", cmp.Diff(jsonDecoded, cborDecoded))
+		}
+		cborBytes := bytes.NewBuffer(nil)
+		if err := c.CBOR.Encode(cborDecoded, cborBytes); err != nil {
+			t.Fatalf("error encoding cbor: %v", err)
+		}
+		cborAfterRoundTrip = cborBytes.Bytes()
+	}
+
 	expectedJSONAfterRoundTrip, expectedYAMLAfterRoundTrip, expectedProtoAfterRoundTrip, _ := read(previousVersionDir, gvk, ".after_roundtrip")
 	if len(expectedJSONAfterRoundTrip) == 0 {
 		expectedJSONAfterRoundTrip = jsonBeforeRoundTrip
@@ -467,10 +540,18 @@ func (c *CompatibilityTestOptions) runPreviousVersionTest(t *testing.T, gvk sche
 	if len(expectedProtoAfterRoundTrip) == 0 {
 		expectedProtoAfterRoundTrip = protoBeforeRoundTrip
 	}
+	var expectedCBORAfterRoundTrip []byte
+	if len(cborBeforeRoundTrip) > 0 {
+		expectedCBORAfterRoundTrip, _ = readCBOR(previousVersionDir, gvk, ".after_roundtrip")
+		if len(expectedCBORAfterRoundTrip) == 0 {
+			expectedCBORAfterRoundTrip = cborBeforeRoundTrip
+		}
+	}
 
 	jsonNeedsUpdate := false
 	yamlNeedsUpdate := false
 	protoNeedsUpdate := false
+	cborNeedsUpdate := false
 
 	if !bytes.Equal(expectedJSONAfterRoundTrip, jsonAfterRoundTrip) {
 		t.Errorf("json differs")
@@ -491,7 +572,13 @@ func (c *CompatibilityTestOptions) runPreviousVersionTest(t *testing.T, gvk sche
 		// t.Logf("json (for locating the offending field based on surrounding data): %s", string(expectedJSON))
 	}
 
-	if jsonNeedsUpdate || yamlNeedsUpdate || protoNeedsUpdate {
+	if len(cborBeforeRoundTrip) > 0 && !bytes.Equal(expectedCBORAfterRoundTrip, cborAfterRoundTrip) {
+		t.Errorf("cbor differs")
+		cborNeedsUpdate = true
+		t.Log(cmp.Diff(dumpCBOR(t, expectedCBORAfterRoundTrip), dumpCBOR(t, cborAfterRoundTrip)))
+	}
+
+	if jsonNeedsUpdate || yamlNeedsUpdate || protoNeedsUpdate || cborNeedsUpdate {
 		const updateEnvVar = "UPDATE_COMPATIBILITY_FIXTURE_DATA"
 		if os.Getenv(updateEnvVar) == "true" {
 			if jsonNeedsUpdate {
@@ -503,6 +590,9 @@ func (c *CompatibilityTestOptions) runPreviousVersionTest(t *testing.T, gvk sche
 			if protoNeedsUpdate {
 				writeFile(t, previousVersionDir, gvk, ".after_roundtrip", "pb", protoAfterRoundTrip)
 			}
+			if cborNeedsUpdate {
+				writeFile(t, previousVersionDir, gvk, ".after_roundtrip", "cbor", cborAfterRoundTrip)
+			}
 			t.Logf("wrote expected compatibility data... verify, commit, and rerun tests")
 		} else {
 			t.Logf("if the diff is expected because of a new type or a new field, re-run with %s=true to update the compatibility data", updateEnvVar)
@@ -535,3 +625,18 @@ func dumpProto(t *testing.T, data []byte) string {
 	}
 	return string(d)
 }
+
+// dumpCBOR returns the diagnostic notation (RFC 8949 section 8) of a CBOR document for
+// readable fixture diffs.
+func dumpCBOR(t *testing.T, data []byte) string {
+	t.Helper()
+	if len(data) == 0 {
+		return ""
+	}
+	d, err := cbor.Diagnose(data)
+	if err != nil {
+		t.Log(err)
+		return ""
+	}
+	return d
+}
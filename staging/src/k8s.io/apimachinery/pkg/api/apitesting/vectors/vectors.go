@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vectors provides a shared corpus of encoded test vectors for serializer tests.
+// Each vector pairs a semantic value with its encodings in one or more formats, so that
+// tests of the serializers, their internal modes, and their clients exercise the same
+// documents instead of duplicating hex literals. The CBOR vectors include entries
+// adapted from RFC 8949 Appendix A.
+package vectors
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+)
+
+// Vector is a single semantic value together with its encodings.
+type Vector struct {
+	// Name identifies the vector in test output.
+	Name string
+
+	// Diagnostic is the CBOR diagnostic notation (RFC 8949 section 8) of the value.
+	Diagnostic string
+
+	// CBOR is an encoding of the value, without the self-describe tag prefix.
+	CBOR []byte
+
+	// Canonical reports whether CBOR is the encoding that the serializer's encode
+	// mode produces for the value. Vectors that are well-formed but not canonical
+	// exercise decoder tolerance.
+	Canonical bool
+
+	// RejectedByDecode reports that the serializer's decode modes refuse this
+	// document, for example because it carries a tag or because its value does not
+	// fit the decoded representation.
+	RejectedByDecode bool
+
+	// JSON is the equivalent JSON document, or empty when the value has no JSON
+	// equivalent.
+	JSON string
+
+	// Protobuf is the equivalent protobuf encoding for vectors whose value is an API
+	// object with a generated protobuf form; empty otherwise.
+	Protobuf []byte
+
+	// Value is the value as produced by decoding CBOR into an untyped destination
+	// with the serializer's decode modes: int64 for integers, float64 for
+	// floating-point values, and map[string]interface{} for maps. Unset when
+	// RejectedByDecode is true.
+	Value interface{}
+}
+
+// MustHexDecode returns the bytes represented by the provided hex string, panicking if
+// the string is not valid hex. It is intended for use in declaring test vectors.
+func MustHexDecode(s string) []byte {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		panic(fmt.Sprintf("invalid hex string %q: %v", s, err))
+	}
+	return data
+}
+
+// All returns the complete corpus. The returned slice is freshly allocated and may be
+// modified by the caller.
+func All() []Vector {
+	return []Vector{
+		{Name: "zero", Diagnostic: "0", CBOR: MustHexDecode("00"), Canonical: true, JSON: "0", Value: int64(0)},
+		{Name: "one", Diagnostic: "1", CBOR: MustHexDecode("01"), Canonical: true, JSON: "1", Value: int64(1)},
+		{Name: "ten", Diagnostic: "10", CBOR: MustHexDecode("0a"), Canonical: true, JSON: "10", Value: int64(10)},
+		{Name: "max immediate int", Diagnostic: "23", CBOR: MustHexDecode("17"), Canonical: true, JSON: "23", Value: int64(23)},
+		{Name: "one-byte int", Diagnostic: "24", CBOR: MustHexDecode("1818"), Canonical: true, JSON: "24", Value: int64(24)},
+		{Name: "two-byte int", Diagnostic: "1000", CBOR: MustHexDecode("1903e8"), Canonical: true, JSON: "1000", Value: int64(1000)},
+		{Name: "four-byte int", Diagnostic: "1000000", CBOR: MustHexDecode("1a000f4240"), Canonical: true, JSON: "1000000", Value: int64(1000000)},
+		{Name: "max uint64", Diagnostic: "18446744073709551615", CBOR: MustHexDecode("1bffffffffffffffff"), Canonical: true, JSON: "18446744073709551615", RejectedByDecode: true},
+		{Name: "widened int", Diagnostic: "1", CBOR: MustHexDecode("190001"), Canonical: false, JSON: "1", Value: int64(1)},
+		{Name: "negative one", Diagnostic: "-1", CBOR: MustHexDecode("20"), Canonical: true, JSON: "-1", Value: int64(-1)},
+		{Name: "negative hundred", Diagnostic: "-100", CBOR: MustHexDecode("3863"), Canonical: true, JSON: "-100", Value: int64(-100)},
+		{Name: "negative thousand", Diagnostic: "-1000", CBOR: MustHexDecode("3903e7"), Canonical: true, JSON: "-1000", Value: int64(-1000)},
+		{Name: "half-precision float", Diagnostic: "1.0", CBOR: MustHexDecode("f93c00"), Canonical: true, JSON: "1", Value: float64(1)},
+		{Name: "double-precision float", Diagnostic: "1.0", CBOR: MustHexDecode("fb3ff0000000000000"), Canonical: false, JSON: "1", Value: float64(1)},
+		{Name: "fractional float", Diagnostic: "1.1", CBOR: MustHexDecode("fb3ff199999999999a"), Canonical: true, JSON: "1.1", Value: float64(1.1)},
+		{Name: "single-precision float", Diagnostic: "100000.0", CBOR: MustHexDecode("fa47c35000"), Canonical: true, JSON: "100000", Value: float64(100000)},
+		{Name: "positive infinity", Diagnostic: "Infinity", CBOR: MustHexDecode("f97c00"), Canonical: true, Value: math.Inf(1)},
+		{Name: "negative infinity", Diagnostic: "-Infinity", CBOR: MustHexDecode("f9fc00"), Canonical: true, Value: math.Inf(-1)},
+		{Name: "false", Diagnostic: "false", CBOR: MustHexDecode("f4"), Canonical: true, JSON: "false", Value: false},
+		{Name: "true", Diagnostic: "true", CBOR: MustHexDecode("f5"), Canonical: true, JSON: "true", Value: true},
+		{Name: "null", Diagnostic: "null", CBOR: MustHexDecode("f6"), Canonical: true, JSON: "null", Value: nil},
+		{Name: "empty string", Diagnostic: `""`, CBOR: MustHexDecode("60"), Canonical: true, JSON: `""`, Value: ""},
+		{Name: "short string", Diagnostic: `"a"`, CBOR: MustHexDecode("6161"), Canonical: true, JSON: `"a"`, Value: "a"},
+		{Name: "string", Diagnostic: `"IETF"`, CBOR: MustHexDecode("6449455446"), Canonical: true, JSON: `"IETF"`, Value: "IETF"},
+		{Name: "string with escapes", Diagnostic: `"\"\\"`, CBOR: MustHexDecode("62225c"), Canonical: true, JSON: `"\"\\"`, Value: `"\`},
+		{Name: "two-byte utf8", Diagnostic: `"ü"`, CBOR: MustHexDecode("62c3bc"), Canonical: true, JSON: `"ü"`, Value: "ü"},
+		{Name: "three-byte utf8", Diagnostic: `"水"`, CBOR: MustHexDecode("63e6b0b4"), Canonical: true, JSON: `"水"`, Value: "水"},
+		{Name: "byte string", Diagnostic: "h'01020304'", CBOR: MustHexDecode("4401020304"), Canonical: true, Value: []byte{0x01, 0x02, 0x03, 0x04}},
+		{Name: "empty array", Diagnostic: "[]", CBOR: MustHexDecode("80"), Canonical: true, JSON: "[]", Value: []interface{}{}},
+		{Name: "array", Diagnostic: "[1, 2, 3]", CBOR: MustHexDecode("83010203"), Canonical: true, JSON: "[1,2,3]", Value: []interface{}{int64(1), int64(2), int64(3)}},
+		{Name: "nested arrays", Diagnostic: "[1, [2, 3], [4, 5]]", CBOR: MustHexDecode("8301820203820405"), Canonical: true, JSON: "[1,[2,3],[4,5]]", Value: []interface{}{int64(1), []interface{}{int64(2), int64(3)}, []interface{}{int64(4), int64(5)}}},
+		{Name: "empty map", Diagnostic: "{}", CBOR: MustHexDecode("a0"), Canonical: true, JSON: "{}", Value: map[string]interface{}{}},
+		{Name: "map", Diagnostic: `{"a": 1, "b": [2, 3]}`, CBOR: MustHexDecode("a26161016162820203"), Canonical: true, JSON: `{"a":1,"b":[2,3]}`, Value: map[string]interface{}{"a": int64(1), "b": []interface{}{int64(2), int64(3)}}},
+		{Name: "five-entry map", Diagnostic: `{"a": "A", "b": "B", "c": "C", "d": "D", "e": "E"}`, CBOR: MustHexDecode("a56161614161626142616361436164614461656145"), Canonical: true, JSON: `{"a":"A","b":"B","c":"C","d":"D","e":"E"}`, Value: map[string]interface{}{"a": "A", "b": "B", "c": "C", "d": "D", "e": "E"}},
+		{Name: "indefinite-length string", Diagnostic: `(_ "strea", "ming")`, CBOR: MustHexDecode("7f657374726561646d696e67ff"), Canonical: false, JSON: `"streaming"`, Value: "streaming"},
+		{Name: "indefinite-length array", Diagnostic: "[_ 1, 2]", CBOR: MustHexDecode("9f0102ff"), Canonical: false, JSON: "[1,2]", Value: []interface{}{int64(1), int64(2)}},
+		{Name: "tagged time", Diagnostic: `0("2013-03-21T20:04:00Z")`, CBOR: MustHexDecode("c074323031332d30332d32315432303a30343a30305a"), Canonical: false, RejectedByDecode: true},
+		{Name: "tagged bignum", Diagnostic: "2(h'010000000000000000')", CBOR: MustHexDecode("c249010000000000000000"), Canonical: false, RejectedByDecode: true},
+	}
+}
@@ -25,6 +25,8 @@ import (
 	"strings"
 
 	inf "gopkg.in/inf.v0"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 )
 
 // Quantity is a fixed-point representation of a number.
@@ -634,6 +636,12 @@ func (q Quantity) MarshalJSON() ([]byte, error) {
 	return result, nil
 }
 
+// MarshalCBOR encodes the quantity as a text string holding its canonical form, the
+// same string MarshalJSON emits.
+func (q Quantity) MarshalCBOR() ([]byte, error) {
+	return direct.Marshal(q.String())
+}
+
 // ToUnstructured implements the value.UnstructuredConverter interface.
 func (q Quantity) ToUnstructured() interface{} {
 	return q.String()
@@ -662,6 +670,27 @@ func (q *Quantity) UnmarshalJSON(value []byte) error {
 	return nil
 }
 
+// UnmarshalCBOR decodes a quantity from a CBOR text string, or resets it on null.
+func (q *Quantity) UnmarshalCBOR(value []byte) error {
+	var str *string
+	if err := direct.Unmarshal(value, &str); err != nil {
+		return err
+	}
+	if str == nil {
+		q.d.Dec = nil
+		q.i = int64Amount{}
+		return nil
+	}
+
+	parsed, err := ParseQuantity(strings.TrimSpace(*str))
+	if err != nil {
+		return err
+	}
+
+	*q = parsed
+	return nil
+}
+
 // NewQuantity returns a new Quantity representing the given
 // value in the given format.
 func NewQuantity(value int64, format Format) *Quantity {
@@ -96,6 +96,14 @@ func genericFuzzerFuncs(codecs runtimeserializer.CodecFactory) []interface{} {
 			// Set the bytes field on the RawExtension
 			r.Raw = bytes
 		},
+		func(u *runtime.Unknown, c fuzz.Continue) {
+			// Unknown fields hold a complete serialized document, as they would
+			// after passing through a codec, never arbitrary bytes.
+			u.TypeMeta = runtime.TypeMeta{}
+			u.Raw = []byte(`{"apiVersion":"unknown.group/unknown","kind":"Something","someKey":"someValue"}`)
+			u.ContentEncoding = ""
+			u.ContentType = runtime.ContentTypeJSON
+		},
 	}
 }
 
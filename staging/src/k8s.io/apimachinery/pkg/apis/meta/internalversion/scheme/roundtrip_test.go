@@ -25,4 +25,5 @@ import (
 
 func TestRoundTrip(t *testing.T) {
 	roundtrip.RoundTripTestForScheme(t, scheme, fuzzer.Funcs)
+	roundtrip.RoundTripCBORTestForScheme(t, scheme, fuzzer.Funcs)
 }
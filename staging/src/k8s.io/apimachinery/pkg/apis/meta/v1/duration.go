@@ -19,6 +19,8 @@ package v1
 import (
 	"encoding/json"
 	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 )
 
 // Duration is a wrapper around time.Duration which supports correct
@@ -49,6 +51,30 @@ func (d Duration) MarshalJSON() ([]byte, error) {
 	return json.Marshal(d.Duration.String())
 }
 
+// UnmarshalCBOR decodes a duration from its string form, as in JSON.
+func (d *Duration) UnmarshalCBOR(b []byte) error {
+	var str *string
+	if err := direct.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	if str == nil {
+		d.Duration = 0
+		return nil
+	}
+
+	pd, err := time.ParseDuration(*str)
+	if err != nil {
+		return err
+	}
+	d.Duration = pd
+	return nil
+}
+
+// MarshalCBOR encodes the duration as a text string, the same form it takes in JSON.
+func (d Duration) MarshalCBOR() ([]byte, error) {
+	return direct.Marshal(d.Duration.String())
+}
+
 // ToUnstructured implements the value.UnstructuredConverter interface.
 func (d Duration) ToUnstructured() interface{} {
 	return d.Duration.String()
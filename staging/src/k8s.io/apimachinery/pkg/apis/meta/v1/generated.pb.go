@@ -1593,6 +1593,33 @@ func (m *APIResource) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.PatchMediaTypes) > 0 {
+		for iNdEx := len(m.PatchMediaTypes) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.PatchMediaTypes[iNdEx])
+			copy(dAtA[i:], m.PatchMediaTypes[iNdEx])
+			i = encodeVarintGenerated(dAtA, i, uint64(len(m.PatchMediaTypes[iNdEx])))
+			i--
+			dAtA[i] = 0x6a
+		}
+	}
+	if len(m.RequestMediaTypes) > 0 {
+		for iNdEx := len(m.RequestMediaTypes) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.RequestMediaTypes[iNdEx])
+			copy(dAtA[i:], m.RequestMediaTypes[iNdEx])
+			i = encodeVarintGenerated(dAtA, i, uint64(len(m.RequestMediaTypes[iNdEx])))
+			i--
+			dAtA[i] = 0x62
+		}
+	}
+	if len(m.ResponseMediaTypes) > 0 {
+		for iNdEx := len(m.ResponseMediaTypes) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.ResponseMediaTypes[iNdEx])
+			copy(dAtA[i:], m.ResponseMediaTypes[iNdEx])
+			i = encodeVarintGenerated(dAtA, i, uint64(len(m.ResponseMediaTypes[iNdEx])))
+			i--
+			dAtA[i] = 0x5a
+		}
+	}
 	i -= len(m.StorageVersionHash)
 	copy(dAtA[i:], m.StorageVersionHash)
 	i = encodeVarintGenerated(dAtA, i, uint64(len(m.StorageVersionHash)))
@@ -3447,6 +3474,24 @@ func (m *APIResource) Size() (n int) {
 	n += 1 + l + sovGenerated(uint64(l))
 	l = len(m.StorageVersionHash)
 	n += 1 + l + sovGenerated(uint64(l))
+	if len(m.ResponseMediaTypes) > 0 {
+		for _, s := range m.ResponseMediaTypes {
+			l = len(s)
+			n += 1 + l + sovGenerated(uint64(l))
+		}
+	}
+	if len(m.RequestMediaTypes) > 0 {
+		for _, s := range m.RequestMediaTypes {
+			l = len(s)
+			n += 1 + l + sovGenerated(uint64(l))
+		}
+	}
+	if len(m.PatchMediaTypes) > 0 {
+		for _, s := range m.PatchMediaTypes {
+			l = len(s)
+			n += 1 + l + sovGenerated(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -4178,6 +4223,9 @@ func (this *APIResource) String() string {
 		`Group:` + fmt.Sprintf("%v", this.Group) + `,`,
 		`Version:` + fmt.Sprintf("%v", this.Version) + `,`,
 		`StorageVersionHash:` + fmt.Sprintf("%v", this.StorageVersionHash) + `,`,
+		`ResponseMediaTypes:` + fmt.Sprintf("%v", this.ResponseMediaTypes) + `,`,
+		`RequestMediaTypes:` + fmt.Sprintf("%v", this.RequestMediaTypes) + `,`,
+		`PatchMediaTypes:` + fmt.Sprintf("%v", this.PatchMediaTypes) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -5236,6 +5284,102 @@ func (m *APIResource) Unmarshal(dAtA []byte) error {
 			}
 			m.StorageVersionHash = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ResponseMediaTypes", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGenerated
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthGenerated
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthGenerated
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ResponseMediaTypes = append(m.ResponseMediaTypes, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RequestMediaTypes", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGenerated
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthGenerated
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthGenerated
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RequestMediaTypes = append(m.RequestMediaTypes, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PatchMediaTypes", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGenerated
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthGenerated
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthGenerated
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.PatchMediaTypes = append(m.PatchMediaTypes, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipGenerated(dAtA[iNdEx:])
@@ -24,6 +24,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -278,5 +279,39 @@ func (f *FieldsV1) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MarshalCBOR transcodes Raw, which always holds JSON, into a single CBOR data item. A
+// nil Raw encodes as null.
+func (f FieldsV1) MarshalCBOR() ([]byte, error) {
+	if f.Raw == nil {
+		return direct.Marshal(nil)
+	}
+	var u interface{}
+	if err := json.Unmarshal(f.Raw, &u); err != nil {
+		return nil, err
+	}
+	return direct.Marshal(u)
+}
+
+// UnmarshalCBOR transcodes a CBOR data item to JSON before storing it, preserving the
+// invariant that Raw holds JSON regardless of the format an object arrived in.
+func (f *FieldsV1) UnmarshalCBOR(b []byte) error {
+	if f == nil {
+		return errors.New("metav1.Fields: UnmarshalCBOR on nil pointer")
+	}
+	var u interface{}
+	if err := direct.Unmarshal(b, &u); err != nil {
+		return err
+	}
+	if u == nil {
+		return nil
+	}
+	j, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	f.Raw = append(f.Raw[0:0], j...)
+	return nil
+}
+
 var _ json.Marshaler = FieldsV1{}
 var _ json.Unmarshaler = &FieldsV1{}
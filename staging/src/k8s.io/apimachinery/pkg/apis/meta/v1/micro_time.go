@@ -21,6 +21,8 @@ import (
 	"time"
 
 	"github.com/google/gofuzz"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 )
 
 const RFC3339Micro = "2006-01-02T15:04:05.000000Z07:00"
@@ -131,6 +133,27 @@ func (t *MicroTime) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// UnmarshalCBOR decodes a microsecond-precision time from a text string in the
+// RFC3339Micro layout, matching the JSON representation, or clears it on null.
+func (t *MicroTime) UnmarshalCBOR(b []byte) error {
+	var str *string
+	if err := direct.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	if str == nil {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	pt, err := time.Parse(RFC3339Micro, *str)
+	if err != nil {
+		return err
+	}
+
+	t.Time = pt.Local()
+	return nil
+}
+
 // UnmarshalQueryParameter converts from a URL query parameter value to an object
 func (t *MicroTime) UnmarshalQueryParameter(str string) error {
 	if len(str) == 0 {
@@ -162,6 +185,15 @@ func (t MicroTime) MarshalJSON() ([]byte, error) {
 	return json.Marshal(t.UTC().Format(RFC3339Micro))
 }
 
+// MarshalCBOR encodes the time as a text string in the RFC3339Micro layout, or as null
+// when unset, the same values MarshalJSON produces.
+func (t MicroTime) MarshalCBOR() ([]byte, error) {
+	if t.IsZero() {
+		return direct.Marshal(nil)
+	}
+	return direct.Marshal(t.UTC().Format(RFC3339Micro))
+}
+
 // OpenAPISchemaType is used by the kube-openapi generator when constructing
 // the OpenAPI spec of this type.
 //
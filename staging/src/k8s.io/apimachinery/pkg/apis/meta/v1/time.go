@@ -21,6 +21,8 @@ import (
 	"time"
 
 	fuzz "github.com/google/gofuzz"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 )
 
 // Time is a wrapper around time.Time which supports correct
@@ -118,6 +120,27 @@ func (t *Time) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// UnmarshalCBOR decodes a time from an RFC 3339 text string, the same representation
+// used in JSON, or clears it when the data item is null.
+func (t *Time) UnmarshalCBOR(b []byte) error {
+	var str *string
+	if err := direct.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	if str == nil {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	pt, err := time.Parse(time.RFC3339, *str)
+	if err != nil {
+		return err
+	}
+
+	t.Time = pt.Local()
+	return nil
+}
+
 // UnmarshalQueryParameter converts from a URL query parameter value to an object
 func (t *Time) UnmarshalQueryParameter(str string) error {
 	if len(str) == 0 {
@@ -153,6 +176,15 @@ func (t Time) MarshalJSON() ([]byte, error) {
 	return buf, nil
 }
 
+// MarshalCBOR encodes the time as an RFC 3339 text string, or as null when unset, so
+// that the CBOR representation carries the same information as the JSON one.
+func (t Time) MarshalCBOR() ([]byte, error) {
+	if t.IsZero() {
+		return direct.Marshal(nil)
+	}
+	return direct.Marshal(t.UTC().Format(time.RFC3339))
+}
+
 // ToUnstructured implements the value.UnstructuredConverter interface.
 func (t Time) ToUnstructured() interface{} {
 	if t.IsZero() {
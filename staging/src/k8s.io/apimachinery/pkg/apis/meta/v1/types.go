@@ -996,6 +996,20 @@ type APIResource struct {
 	// This field will remain optional even if it graduates.
 	// +optional
 	StorageVersionHash string `json:"storageVersionHash,omitempty" protobuf:"bytes,10,opt,name=storageVersionHash"`
+	// responseMediaTypes lists the media types the server supports for responses for this
+	// resource, including streaming media types usable with the watch verb. Empty implies
+	// that only the server defaults are supported.
+	// +optional
+	ResponseMediaTypes []string `json:"responseMediaTypes,omitempty" protobuf:"bytes,11,rep,name=responseMediaTypes"`
+	// requestMediaTypes lists the media types the server accepts in request bodies for this
+	// resource. Empty implies that only the server defaults are supported.
+	// +optional
+	RequestMediaTypes []string `json:"requestMediaTypes,omitempty" protobuf:"bytes,12,rep,name=requestMediaTypes"`
+	// patchMediaTypes lists the patch content types the server accepts for this resource,
+	// also advertised in the Accept-Patch response header. Empty implies the resource does
+	// not support the patch verb or the server predates advertisement.
+	// +optional
+	PatchMediaTypes []string `json:"patchMediaTypes,omitempty" protobuf:"bytes,13,rep,name=patchMediaTypes"`
 }
 
 // Verbs masks the value so protobuf can generate
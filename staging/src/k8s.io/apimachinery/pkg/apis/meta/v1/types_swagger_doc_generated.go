@@ -60,6 +60,9 @@ var map_APIResource = map[string]string{
 	"shortNames":         "shortNames is a list of suggested short names of the resource.",
 	"categories":         "categories is a list of the grouped resources this resource belongs to (e.g. 'all')",
 	"storageVersionHash": "The hash value of the storage version, the version this resource is converted to when written to the data store. Value must be treated as opaque by clients. Only equality comparison on the value is valid. This is an alpha feature and may change or be removed in the future. The field is populated by the apiserver only if the StorageVersionHash feature gate is enabled. This field will remain optional even if it graduates.",
+	"responseMediaTypes": "responseMediaTypes lists the media types the server supports for responses for this resource, including streaming media types usable with the watch verb. Empty implies that only the server defaults are supported.",
+	"requestMediaTypes":  "requestMediaTypes lists the media types the server accepts in request bodies for this resource. Empty implies that only the server defaults are supported.",
+	"patchMediaTypes":    "patchMediaTypes lists the patch content types the server accepts for this resource, also advertised in the Accept-Patch response header. Empty implies the resource does not support the patch verb or the server predates advertisement.",
 }
 
 func (APIResource) SwaggerDoc() map[string]string {
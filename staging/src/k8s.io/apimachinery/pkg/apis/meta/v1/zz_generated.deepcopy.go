@@ -111,6 +111,21 @@ func (in *APIResource) DeepCopyInto(out *APIResource) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ResponseMediaTypes != nil {
+		in, out := &in.ResponseMediaTypes, &out.ResponseMediaTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequestMediaTypes != nil {
+		in, out := &in.RequestMediaTypes, &out.RequestMediaTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PatchMediaTypes != nil {
+		in, out := &in.PatchMediaTypes, &out.PatchMediaTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
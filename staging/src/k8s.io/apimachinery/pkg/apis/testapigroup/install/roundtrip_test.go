@@ -26,4 +26,9 @@ import (
 func TestRoundTrip(t *testing.T) {
 	roundtrip.RoundTripTestForAPIGroup(t, Install, testapigroupfuzzer.Funcs)
 	roundtrip.RoundTripProtobufTestForAPIGroup(t, Install, testapigroupfuzzer.Funcs)
+	roundtrip.RoundTripCBORTestForAPIGroup(t, Install, testapigroupfuzzer.Funcs)
+}
+
+func TestCBORDecodeParity(t *testing.T) {
+	roundtrip.CBORDecodeParityTestForAPIGroup(t, Install, testapigroupfuzzer.Funcs)
 }
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"sync"
+)
+
+// AllocatorPool simply stores Allocator objects to avoid additional memory allocations.
+// By caching allocators we avoid the GC having to deal with lots of tiny allocator objects.
+//
+// The pattern to use it is: retrieve an allocator from the pool, use it,
+// and then put it back when you no longer need it.
+var AllocatorPool = sync.Pool{
+	New: func() interface{} {
+		return &Allocator{}
+	},
+}
+
+// MemoryAllocator is responsible for allocating memory.
+// By encapsulating memory allocation into its own interface, we can reuse the memory
+// across many operations in places we know it can significantly improve the performance.
+type MemoryAllocator interface {
+	// Allocate reserves memory for n bytes.
+	// Note that implementations of this method are not required to zero the returned array.
+	// It is the caller's responsibility to clean the memory if needed.
+	Allocate(n uint64) []byte
+}
+
+var _ MemoryAllocator = &Allocator{}
+
+// Allocator knows how to allocate memory.
+// It exists to make the cost of object serialization cheaper.
+// In some cases, it allows for allocating memory only once and then reusing it.
+// This approach puts less load on the GC and leads to less fragmented memory in general.
+type Allocator struct {
+	buf []byte
+}
+
+// Allocate reserves memory for n bytes only if the underlying array doesn't have enough capacity,
+// otherwise it returns previously allocated block of memory.
+//
+// Note that the returned array is not zeroed, it is the caller's
+// responsibility to clean the memory if needed.
+func (a *Allocator) Allocate(n uint64) []byte {
+	if uint64(cap(a.buf)) >= n {
+		a.buf = a.buf[:n]
+		return a.buf
+	}
+	// grow the buffer
+	size := uint64(2*cap(a.buf)) + n
+	a.buf = make([]byte, size)
+	a.buf = a.buf[:n]
+	return a.buf
+}
+
+var _ MemoryAllocator = &SimpleAllocator{}
+
+// SimpleAllocator a wrapper around make([]byte)
+// conforms to the MemoryAllocator interface.
+type SimpleAllocator struct{}
+
+// Allocate reserves memory for n bytes by delegating to make.
+func (sa *SimpleAllocator) Allocate(n uint64) []byte {
+	return make([]byte, n)
+}
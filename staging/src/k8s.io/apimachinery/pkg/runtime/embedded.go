@@ -17,10 +17,13 @@ limitations under the License.
 package runtime
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 
 	"k8s.io/apimachinery/pkg/conversion"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 )
 
 type encodable struct {
@@ -94,6 +97,47 @@ func (e Unknown) MarshalJSON() ([]byte, error) {
 	return e.Raw, nil
 }
 
+// UnmarshalCBOR stores the raw bytes of a CBOR data item, prefixed with the
+// self-describe tag as the CBOR serializer does for Unknown, and records the content
+// type accordingly.
+func (e *Unknown) UnmarshalCBOR(in []byte) error {
+	if e == nil {
+		return errors.New("runtime.Unknown: UnmarshalCBOR on nil pointer")
+	}
+	raw := in
+	if bytes.HasPrefix(raw, cborSelfDescribed) {
+		raw = raw[len(cborSelfDescribed):]
+	}
+	e.TypeMeta = TypeMeta{}
+	e.Raw = append(e.Raw[0:0], cborSelfDescribed...)
+	e.Raw = append(e.Raw, raw...)
+	e.ContentEncoding = ""
+	e.ContentType = ContentTypeCBOR
+	return nil
+}
+
+// MarshalCBOR emits Raw as a single CBOR data item. CBOR content is passed through with
+// its self-describe prefix removed, since tags may not appear inside encoded documents,
+// and JSON content is transcoded.
+func (e Unknown) MarshalCBOR() ([]byte, error) {
+	if e.Raw == nil {
+		return direct.Marshal(nil)
+	}
+	switch e.ContentType {
+	case ContentTypeCBOR:
+		return bytes.TrimPrefix(e.Raw, cborSelfDescribed), nil
+	// If ContentType is unset, we assume this is JSON.
+	case "", ContentTypeJSON:
+		var u interface{}
+		if err := json.Unmarshal(e.Raw, &u); err != nil {
+			return nil, err
+		}
+		return direct.Marshal(u)
+	default:
+		return nil, errors.New("runtime.Unknown: MarshalCBOR on non-json, non-cbor data")
+	}
+}
+
 func Convert_runtime_Object_To_runtime_RawExtension(in *Object, out *RawExtension, s conversion.Scope) error {
 	if in == nil {
 		out.Raw = []byte("null")
@@ -115,6 +159,28 @@ func Convert_runtime_Object_To_runtime_RawExtension(in *Object, out *RawExtensio
 	return nil
 }
 
+// protobufEnvelopePrefix is the magic number that the protobuf serializer writes before
+// every Unknown envelope. It is duplicated here because the runtime package cannot
+// import the serializer that owns it.
+var protobufEnvelopePrefix = []byte{0x6b, 0x38, 0x73, 0x00}
+
+// contentTypeOfRaw guesses the serialization format of raw bytes from their leading
+// magic numbers. A RawExtension does not record the format of the request it was
+// decoded from, so converting one to an Unknown has to sniff: bytes produced by the
+// protobuf serializer begin with its envelope prefix and bytes produced by the CBOR
+// serializer begin with the self-describe tag. Everything else is assumed to be JSON,
+// historically the only format that reached this conversion.
+func contentTypeOfRaw(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, protobufEnvelopePrefix):
+		return ContentTypeProtobuf
+	case bytes.HasPrefix(data, cborSelfDescribed):
+		return ContentTypeCBOR
+	default:
+		return ContentTypeJSON
+	}
+}
+
 func Convert_runtime_RawExtension_To_runtime_Object(in *RawExtension, out *Object, s conversion.Scope) error {
 	if in.Object != nil {
 		*out = in.Object
@@ -125,12 +191,30 @@ func Convert_runtime_RawExtension_To_runtime_Object(in *RawExtension, out *Objec
 		*out = nil
 		return nil
 	}
-	*out = &Unknown{
+	unk := &Unknown{
 		Raw: data,
-		// TODO: Set ContentEncoding and ContentType appropriately.
-		// Currently we set ContentTypeJSON to make tests passing.
-		ContentType: ContentTypeJSON,
+		// TODO: Set ContentEncoding appropriately.
+		ContentType: contentTypeOfRaw(data),
+	}
+	switch unk.ContentType {
+	case ContentTypeCBOR:
+		// Populate the type of the document so that consumers that only need the
+		// type don't have to decode Raw. The peek costs much less than a decode
+		// and, like the rest of this conversion, leaves problems with the document
+		// itself to be reported when Raw is decoded.
+		if gvk, err := direct.PeekTypeMeta(data); err == nil {
+			unk.GetObjectKind().SetGroupVersionKind(gvk)
+		}
+	case ContentTypeProtobuf:
+		// The bytes following the magic number are an encoded Unknown whose
+		// TypeMeta names the type of the document; decoding it populates the type
+		// without a scheme. Errors are left to surface when Raw is decoded.
+		var envelope Unknown
+		if err := envelope.Unmarshal(data[len(protobufEnvelopePrefix):]); err == nil {
+			unk.TypeMeta = envelope.TypeMeta
+		}
 	}
+	*out = unk
 	return nil
 }
 
@@ -17,6 +17,7 @@ limitations under the License.
 package runtime_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"reflect"
 	"testing"
@@ -25,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
 	"k8s.io/apimachinery/pkg/util/diff"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -67,6 +69,60 @@ func TestDecodeEmptyRawExtensionAsObject(t *testing.T) {
 	}
 }
 
+func TestConvertRawExtensionToObjectDetectsFormat(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "test.group", Version: "v1test", Kind: "ObjectTest"}
+	apiVersion := gvk.GroupVersion().String()
+
+	jsonDoc := []byte(`{"apiVersion":"` + apiVersion + `","kind":"` + gvk.Kind + `"}`)
+
+	cborDoc, err := direct.Marshal(map[string]interface{}{"apiVersion": apiVersion, "kind": gvk.Kind})
+	if err != nil {
+		t.Fatalf("unexpected error encoding cbor document: %v", err)
+	}
+	cborDoc = append([]byte{0xd9, 0xd9, 0xf7}, cborDoc...)
+
+	envelope, err := (&runtime.Unknown{TypeMeta: runtime.TypeMeta{APIVersion: apiVersion, Kind: gvk.Kind}}).Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error encoding protobuf envelope: %v", err)
+	}
+	protoDoc := append([]byte{0x6b, 0x38, 0x73, 0x00}, envelope...)
+
+	testCases := []struct {
+		name        string
+		raw         []byte
+		contentType string
+		// JSON leaves the type empty because historically this conversion never
+		// parsed JSON documents; the other formats expose their type without a
+		// full decode.
+		gvk schema.GroupVersionKind
+	}{
+		{name: "json", raw: jsonDoc, contentType: runtime.ContentTypeJSON},
+		{name: "cbor", raw: cborDoc, contentType: runtime.ContentTypeCBOR, gvk: gvk},
+		{name: "protobuf", raw: protoDoc, contentType: runtime.ContentTypeProtobuf, gvk: gvk},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out runtime.Object
+			if err := runtime.Convert_runtime_RawExtension_To_runtime_Object(&runtime.RawExtension{Raw: tc.raw}, &out, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			unk, ok := out.(*runtime.Unknown)
+			if !ok {
+				t.Fatalf("expected *runtime.Unknown, got %T", out)
+			}
+			if unk.ContentType != tc.contentType {
+				t.Errorf("expected content type %q, got %q", tc.contentType, unk.ContentType)
+			}
+			if got := unk.GetObjectKind().GroupVersionKind(); got != tc.gvk {
+				t.Errorf("expected type %v, got %v", tc.gvk, got)
+			}
+			if !bytes.Equal(unk.Raw, tc.raw) {
+				t.Errorf("expected raw bytes to pass through unmodified, got %q", unk.Raw)
+			}
+		})
+	}
+}
+
 func TestArrayOfRuntimeObject(t *testing.T) {
 	internalGV := schema.GroupVersion{Group: "test.group", Version: runtime.APIVersionInternal}
 	externalGV := schema.GroupVersion{Group: "test.group", Version: "v1test"}
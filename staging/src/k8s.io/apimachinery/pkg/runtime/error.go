@@ -124,8 +124,9 @@ func IsMissingVersion(err error) bool {
 // strictDecodingError is a base error type that is returned by a strict Decoder such
 // as UniversalStrictDecoder.
 type strictDecodingError struct {
-	message string
-	data    string
+	message    string
+	data       string
+	fieldPaths []string
 }
 
 // NewStrictDecodingError creates a new strictDecodingError object.
@@ -136,6 +137,17 @@ func NewStrictDecodingError(message string, data string) error {
 	}
 }
 
+// NewStrictDecodingErrorForFields creates a new strictDecodingError object that records
+// the paths of the fields responsible for the strictness violation, so that callers can
+// report them individually instead of parsing the message.
+func NewStrictDecodingErrorForFields(message string, data string, fieldPaths ...string) error {
+	return &strictDecodingError{
+		message:    message,
+		data:       data,
+		fieldPaths: fieldPaths,
+	}
+}
+
 func (e *strictDecodingError) Error() string {
 	return fmt.Sprintf("strict decoder error for %s: %s", e.data, e.message)
 }
@@ -149,3 +161,12 @@ func IsStrictDecodingError(err error) bool {
 	_, ok := err.(*strictDecodingError)
 	return ok
 }
+
+// StrictDecodingFieldPaths returns the paths of the fields responsible for a strict
+// decoding error, or nil for errors that do not record them.
+func StrictDecodingFieldPaths(err error) []string {
+	if strictErr, ok := err.(*strictDecodingError); ok {
+		return strictErr.fieldPaths
+	}
+	return nil
+}
@@ -20,6 +20,18 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+)
+
+var (
+	// cborNull is the CBOR encoding of null.
+	cborNull = []byte{0xf6}
+	// cborSelfDescribed is the encoded form of tag 55799. Raw bytes beginning with it
+	// are CBOR; the CBOR serializer prefixes documents with it and UnmarshalCBOR
+	// preserves it so that Raw remains distinguishable from the JSON historically
+	// stored there.
+	cborSelfDescribed = []byte{0xd9, 0xd9, 0xf7}
 )
 
 func (re *RawExtension) UnmarshalJSON(in []byte) error {
@@ -49,3 +61,43 @@ func (re RawExtension) MarshalJSON() ([]byte, error) {
 	// TODO: Check whether ContentType is actually JSON before returning it.
 	return re.Raw, nil
 }
+
+// UnmarshalCBOR stores the raw bytes of a CBOR document, prefixed with the self-describe
+// tag so that the content type of Raw can be distinguished from JSON later.
+func (re *RawExtension) UnmarshalCBOR(in []byte) error {
+	if re == nil {
+		return errors.New("runtime.RawExtension: UnmarshalCBOR on nil pointer")
+	}
+	if bytes.Equal(in, cborNull) {
+		return nil
+	}
+	raw := in
+	if bytes.HasPrefix(raw, cborSelfDescribed) {
+		raw = raw[len(cborSelfDescribed):]
+	}
+	re.Raw = re.Raw[0:0]
+	re.Raw = append(re.Raw, cborSelfDescribed...)
+	re.Raw = append(re.Raw, raw...)
+	return nil
+}
+
+// MarshalCBOR emits the contents of Raw as a single CBOR data item. Raw bytes that carry
+// the self-describe tag prefix are emitted as-is, minus the prefix, since tags may not
+// appear inside encoded documents. Raw bytes without the prefix are assumed to be JSON,
+// the only content type stored in Raw before CBOR support, and are transcoded.
+func (re RawExtension) MarshalCBOR() ([]byte, error) {
+	if re.Raw == nil {
+		if re.Object != nil {
+			return direct.Marshal(re.Object)
+		}
+		return direct.Marshal(nil)
+	}
+	if bytes.HasPrefix(re.Raw, cborSelfDescribed) {
+		return re.Raw[len(cborSelfDescribed):], nil
+	}
+	var u interface{}
+	if err := json.Unmarshal(re.Raw, &u); err != nil {
+		return nil, err
+	}
+	return direct.Marshal(u)
+}
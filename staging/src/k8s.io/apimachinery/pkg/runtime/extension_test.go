@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 )
 
 func TestEmbeddedRawExtensionMarshal(t *testing.T) {
@@ -111,3 +112,77 @@ func TestEmbeddedRawExtensionRoundTrip(t *testing.T) {
 		}
 	}
 }
+
+func TestEmbeddedRawExtensionCBORRoundTrip(t *testing.T) {
+	type test struct {
+		Ext runtime.RawExtension `json:"ext"`
+	}
+
+	original := map[string]interface{}{"ext": map[string]interface{}{"foo": "bar"}}
+	data, err := direct.Marshal(original)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	var decoded test
+	if err := direct.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	if !bytes.HasPrefix(decoded.Ext.Raw, []byte{0xd9, 0xd9, 0xf7}) {
+		t.Errorf("expected raw bytes to carry the self-describe tag prefix, got % x", decoded.Ext.Raw)
+	}
+
+	reencoded, err := direct.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	if !bytes.Equal(reencoded, data) {
+		t.Errorf("expected re-encoded document to be byte-identical to the original: got % x, want % x", reencoded, data)
+	}
+}
+
+func TestEmbeddedRawExtensionCBORMarshalJSONRaw(t *testing.T) {
+	// Raw bytes without the self-describe tag prefix hold JSON and are transcoded on
+	// encode so that the enclosing document remains valid CBOR.
+	type test struct {
+		Ext runtime.RawExtension `json:"ext"`
+	}
+
+	data, err := direct.Marshal(test{Ext: runtime.RawExtension{Raw: []byte(`{"foo":"bar"}`)}})
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := direct.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	expected := map[string]interface{}{"ext": map[string]interface{}{"foo": "bar"}}
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("unexpected decoded document: got %#v, want %#v", decoded, expected)
+	}
+}
+
+func TestEmbeddedRawExtensionCBORNull(t *testing.T) {
+	type test struct {
+		Ext runtime.RawExtension `json:"ext"`
+	}
+
+	data, err := direct.Marshal(map[string]interface{}{"ext": nil})
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	var decoded test
+	if err := direct.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	if decoded.Ext.Raw != nil {
+		t.Errorf("expected nil raw bytes after decoding null, got % x", decoded.Ext.Raw)
+	}
+	reencoded, err := direct.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	if !bytes.Equal(reencoded, data) {
+		t.Errorf("expected null to round-trip: got % x, want % x", reencoded, data)
+	}
+}
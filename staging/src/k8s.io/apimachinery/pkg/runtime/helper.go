@@ -242,6 +242,15 @@ func (e WithVersionEncoder) Encode(obj Object, stream io.Writer) error {
 	return err
 }
 
+// EstimateEncodedSize implements EncodedSizeEstimator by delegating to the wrapped
+// encoder, if it provides an estimate.
+func (e WithVersionEncoder) EstimateEncodedSize(obj Object) (int, bool) {
+	if estimator, ok := e.Encoder.(EncodedSizeEstimator); ok {
+		return estimator.EstimateEncodedSize(obj)
+	}
+	return 0, false
+}
+
 // WithoutVersionDecoder clears the group version kind of a deserialized object.
 type WithoutVersionDecoder struct {
 	Decoder
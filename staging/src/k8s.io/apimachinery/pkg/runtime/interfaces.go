@@ -17,6 +17,7 @@ limitations under the License.
 package runtime
 
 import (
+	"context"
 	"io"
 	"net/url"
 
@@ -52,6 +53,10 @@ type Identifier string
 type Encoder interface {
 	// Encode writes an object to a stream. Implementations may return errors if the versions are
 	// incompatible, or if no conversion is defined.
+	//
+	// Encode must not mutate obj. Read-only handlers encode objects shared with serving
+	// caches rather than private copies, so a mutating encoder corrupts the cache for
+	// every subsequent reader.
 	Encode(obj Object, w io.Writer) error
 	// Identifier returns an identifier of the encoder.
 	// Identifiers of two different encoders should be equal if and only if for every input
@@ -69,6 +74,69 @@ type Encoder interface {
 	Identifier() Identifier
 }
 
+// EncoderWithAllocator  serializes objects in a way that allows callers to manage any additional memory allocations.
+type EncoderWithAllocator interface {
+	Encoder
+	// EncodeWithAllocator writes an object to a stream as Encode does.
+	// In addition, it allows for providing a memory allocator for efficient memory usage during object serialization
+	EncodeWithAllocator(obj Object, w io.Writer, memAlloc MemoryAllocator) error
+}
+
+// EncodedSizeEstimator is an optional interface implemented by encoders that can cheaply
+// estimate the encoded size of an object before encoding it, for example from the
+// generated Size method of a protobuf message or from a prior encoding cached on a
+// CacheableObject. Writers may use the estimate to pre-allocate destination buffers; the
+// estimate is a hint and is not required to be exact.
+type EncodedSizeEstimator interface {
+	// EstimateEncodedSize returns an estimate of the number of bytes Encode will write
+	// for obj, and false if no estimate can be produced more cheaply than encoding.
+	EstimateEncodedSize(obj Object) (int, bool)
+}
+
+// ContextEncoder is an optional interface implemented by encoders that can abandon an
+// encode in progress when the provided context is canceled, so that requests that have
+// timed out or been canceled stop consuming CPU on serialization. Implementations check
+// for cancellation at natural boundaries, such as between the items of a list; an encode
+// that has already begun writing may leave a truncated document behind when it aborts.
+type ContextEncoder interface {
+	Encoder
+	// EncodeContext writes an object to a stream as Encode does, returning the
+	// context's error if the context is canceled before or during encoding.
+	EncodeContext(ctx context.Context, obj Object, w io.Writer) error
+}
+
+// ContextDecoder is an optional interface implemented by decoders that can abandon a
+// decode when the provided context is canceled.
+type ContextDecoder interface {
+	Decoder
+	// DecodeContext deserializes data as Decode does, returning the context's error if
+	// the context is canceled before or during decoding.
+	DecodeContext(ctx context.Context, data []byte, defaults *schema.GroupVersionKind, into Object) (Object, *schema.GroupVersionKind, error)
+}
+
+// ListEncoder is an optional interface implemented by encoders that have a specialized
+// entry point for list objects. EncodeList writes exactly the bytes Encode would write
+// for any object, but may produce them more cheaply for lists whose items all share a
+// group, version, and kind — for example by encoding the items concurrently — so callers
+// that know they hold a list should prefer it.
+type ListEncoder interface {
+	Encoder
+	// EncodeList writes a list to a stream. The bytes written are identical to those
+	// Encode would write.
+	EncodeList(list Object, w io.Writer) error
+}
+
+// ListDecoder is an optional interface implemented by decoders that can populate a
+// provided list directly from data, without first scanning the document for its type
+// metadata the way Decode must in order to resolve a destination.
+type ListDecoder interface {
+	Decoder
+	// DecodeList deserializes data into the provided list. Unlike Decode, the result is
+	// always stored in into, and the document's own group, version, and kind are not
+	// examined.
+	DecodeList(data []byte, into Object) error
+}
+
 // Decoder attempts to load an object from data.
 type Decoder interface {
 	// Decode attempts to deserialize the provided data using either the innate typing of the scheme or the
@@ -120,11 +188,18 @@ type SerializerInfo struct {
 	MediaTypeSubType string
 	// EncodesAsText indicates this serializer can be encoded to UTF-8 safely.
 	EncodesAsText bool
+	// EncodesNestedRaw indicates that documents in this encoding carry the raw bytes
+	// of nested RawExtension payloads verbatim, rather than transcoding them from
+	// another encoding.
+	EncodesNestedRaw bool
 	// Serializer is the individual object serializer for this media type.
 	Serializer Serializer
 	// PrettySerializer, if set, can serialize this object in a form biased towards
 	// readability.
 	PrettySerializer Serializer
+	// StrictSerializer, if set, deserializes this object strictly, erring on
+	// unknown or duplicate fields.
+	StrictSerializer Serializer
 	// StreamSerializer, if set, describes the streaming serialization format
 	// for this media type.
 	StreamSerializer *StreamSerializerInfo
@@ -138,6 +213,43 @@ type StreamSerializerInfo struct {
 	Serializer
 	// Framer is the factory for retrieving streams that separate objects on the wire
 	Framer
+	// AlternateFramers, if non-nil, maps values of the "framing" media type parameter
+	// to framing behaviors other than the default. Framer is used when the parameter
+	// is absent.
+	AlternateFramers map[string]Framer
+}
+
+// SerializerCapabilities summarizes what a serializer supports beyond encoding and
+// decoding single objects, so that content negotiation can test for a capability in one
+// place instead of probing individual SerializerInfo fields.
+type SerializerCapabilities struct {
+	// Streaming is true when the encoding has a streaming variant with framing
+	// suitable for watch responses.
+	Streaming bool
+	// Strict is true when a decoding variant that rejects unknown or duplicate
+	// fields is available.
+	Strict bool
+	// Pretty is true when a variant formatted for human viewing is available.
+	Pretty bool
+	// NestedRaw is true when nested RawExtension payloads are carried in this
+	// encoding without transcoding.
+	NestedRaw bool
+	// Binary is true when documents in this encoding are not safe to treat as UTF-8
+	// text.
+	Binary bool
+}
+
+// Capabilities returns the capability descriptor for this serializer. Every capability
+// except NestedRaw is implied by the presence or absence of the corresponding optional
+// field, so the descriptor is accurate for SerializerInfos constructed before it existed.
+func (info SerializerInfo) Capabilities() SerializerCapabilities {
+	return SerializerCapabilities{
+		Streaming: info.StreamSerializer != nil,
+		Strict:    info.StrictSerializer != nil,
+		Pretty:    info.PrettySerializer != nil,
+		NestedRaw: info.EncodesNestedRaw,
+		Binary:    !info.EncodesAsText,
+	}
 }
 
 // NegotiatedSerializer is an interface used for obtaining encoders, decoders, and serializers
@@ -39,6 +39,10 @@ func (e NegotiateError) Error() string {
 type clientNegotiator struct {
 	serializer     NegotiatedSerializer
 	encode, decode GroupVersioner
+
+	// fallbackDecoder, if not nil, is returned by Decoder instead of an error when the
+	// response content type is missing or not among the supported media types.
+	fallbackDecoder Decoder
 }
 
 func (n *clientNegotiator) Encoder(contentType string, params map[string]string) (Encoder, error) {
@@ -59,6 +63,9 @@ func (n *clientNegotiator) Decoder(contentType string, params map[string]string)
 	mediaTypes := n.serializer.SupportedMediaTypes()
 	info, ok := SerializerInfoForMediaType(mediaTypes, contentType)
 	if !ok {
+		if n.fallbackDecoder != nil {
+			return n.serializer.DecoderToVersion(n.fallbackDecoder, n.decode), nil
+		}
 		if len(contentType) != 0 || len(mediaTypes) == 0 {
 			return nil, NegotiateError{ContentType: contentType}
 		}
@@ -79,7 +86,15 @@ func (n *clientNegotiator) StreamDecoder(contentType string, params map[string]s
 	if info.StreamSerializer == nil {
 		return nil, nil, nil, NegotiateError{ContentType: info.MediaType, Stream: true}
 	}
-	return n.serializer.DecoderToVersion(info.Serializer, n.decode), info.StreamSerializer.Serializer, info.StreamSerializer.Framer, nil
+	framer := info.StreamSerializer.Framer
+	if framing, ok := params["framing"]; ok && len(framing) > 0 {
+		alternate, ok := info.StreamSerializer.AlternateFramers[framing]
+		if !ok {
+			return nil, nil, nil, NegotiateError{ContentType: contentType, Stream: true}
+		}
+		framer = alternate
+	}
+	return n.serializer.DecoderToVersion(info.Serializer, n.decode), info.StreamSerializer.Serializer, framer, nil
 }
 
 // NewClientNegotiator will attempt to retrieve the appropriate encoder, decoder, or
@@ -92,6 +107,21 @@ func NewClientNegotiator(serializer NegotiatedSerializer, gv schema.GroupVersion
 	}
 }
 
+// NewClientNegotiatorWithFallbackDecoder behaves as NewClientNegotiator for supported
+// content types, and returns the provided decoder instead of failing when the response
+// content type is missing or unrecognized, as happens when an intermediary proxy
+// rewrites or strips the Content-Type header. The fallback is typically a recognizing
+// decoder chain that sniffs the payload itself — such as the one returned by a codec
+// factory's UniversalDeserializer — routing self-described CBOR and protobuf documents
+// by their prefixes and treating anything else as JSON.
+func NewClientNegotiatorWithFallbackDecoder(serializer NegotiatedSerializer, gv schema.GroupVersion, fallback Decoder) ClientNegotiator {
+	return &clientNegotiator{
+		serializer:      serializer,
+		encode:          gv,
+		fallbackDecoder: fallback,
+	}
+}
+
 // NewInternalClientNegotiator applies the default client rules for connecting to a Kubernetes apiserver
 // where objects are converted to gv prior to sending and decoded to their internal representation prior
 // to retrieval.
@@ -0,0 +1,422 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cbor provides a serializer for the application/cbor format, RFC 8949 Concise
+// Binary Object Representation. Objects are encoded with the same field names and
+// structure as their JSON serialization, so the two formats are interchangeable
+// representations of the same documents.
+package cbor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+	"k8s.io/apimachinery/pkg/runtime/serializer/internal/unknownfields"
+	"k8s.io/apimachinery/pkg/runtime/serializer/recognizer"
+
+	fxcbor "github.com/fxamacker/cbor/v2"
+
+	"k8s.io/klog/v2"
+)
+
+// selfDescribedCBOR is the encoded form of tag 55799, which RFC 8949 section 3.4.6
+// reserves for distinguishing CBOR from other formats. The serializer prefixes every
+// document it encodes with the tag, and strips exactly one leading instance of it on
+// decode. Occurrences of the tag anywhere else, including a second leading instance or
+// nested within a document, fall under the decode modes' general tag policy and are
+// rejected.
+var selfDescribedCBOR = []byte{0xd9, 0xd9, 0xf7}
+
+// NewSerializer creates a CBOR serializer that handles encoding versioned objects into
+// the proper CBOR form. If typer is not nil, the object has the group, version, and kind
+// fields set.
+func NewSerializer(meta MetaFactory, creater runtime.ObjectCreater, typer runtime.ObjectTyper) Serializer {
+	return NewSerializerWithOptions(meta, creater, typer, SerializerOptions{})
+}
+
+// NewSerializerWithOptions creates a CBOR serializer that handles encoding versioned
+// objects into the proper CBOR form. If typer is not nil, the object has the group,
+// version, and kind fields set. Options are copied into the Serializer and are immutable.
+func NewSerializerWithOptions(meta MetaFactory, creater runtime.ObjectCreater, typer runtime.ObjectTyper, options SerializerOptions) Serializer {
+	return &serializer{
+		meta:       meta,
+		creater:    creater,
+		typer:      typer,
+		options:    options,
+		identifier: identifier(options),
+	}
+}
+
+// NewSerializerInfo returns a fully-populated SerializerInfo for the application/cbor
+// media type, including the strict and streaming variants, suitable for registration
+// with a CodecFactory or a NegotiatedSerializer. Its signature is compatible with
+// serializer.WithSerializer.
+func NewSerializerInfo(creater runtime.ObjectCreater, typer runtime.ObjectTyper) runtime.SerializerInfo {
+	s := NewSerializer(DefaultMetaFactory, creater, typer)
+	// EncodesNestedRaw is left false because nested RawExtension payloads are
+	// transcoded between JSON and CBOR rather than carried verbatim.
+	return runtime.SerializerInfo{
+		MediaType:        runtime.ContentTypeCBOR,
+		MediaTypeType:    "application",
+		MediaTypeSubType: "cbor",
+		Serializer:       s,
+		StrictSerializer: NewSerializerWithOptions(DefaultMetaFactory, creater, typer, SerializerOptions{
+			Strict: true,
+		}),
+		StreamSerializer: &runtime.StreamSerializerInfo{
+			Serializer: s,
+			Framer:     Framer,
+			AlternateFramers: map[string]runtime.Framer{
+				LengthDelimitedFramerName: LengthDelimitedFramer,
+			},
+		},
+	}
+}
+
+// identifier computes Identifier of Encoder based on the given options.
+func identifier(options SerializerOptions) runtime.Identifier {
+	result := map[string]string{
+		"name":   "cbor",
+		"strict": strconv.FormatBool(options.Strict),
+	}
+	identifier, err := json.Marshal(result)
+	if err != nil {
+		klog.Fatalf("Failed marshaling identifier for cbor Serializer: %v", err)
+	}
+	return runtime.Identifier(identifier)
+}
+
+// SerializerOptions holds the options which are used to configure a CBOR serializer.
+type SerializerOptions struct {
+	// Strict: configures the Serializer to return a strict decoding error when the data
+	// contains map keys that do not correspond to any field of the destination object.
+	// Note that enabling this option is not as performant as the non-strict variant, and
+	// should not be used in fast paths.
+	Strict bool
+
+	// ParallelListItems: configures the Serializer to encode the items of large lists
+	// concurrently, producing output identical to the sequential path. This trades
+	// additional goroutines and item copies for reduced wall-clock encode time, which
+	// only pays off for lists with very many items.
+	ParallelListItems bool
+}
+
+// Serializer is the interface implemented by the serializers returned from this
+// package's constructors. Programs outside this repository, including aggregated API
+// servers registering application/cbor with their own schemes, should depend on it — or
+// on the narrower package runtime interfaces it is composed of — rather than on the
+// concrete serializer type, which is not exported. Consumers of this interface are
+// insulated from additions to the concrete type; it gains methods only when they are
+// required of every CBOR serializer.
+type Serializer interface {
+	runtime.Serializer
+	runtime.EncoderWithAllocator
+	runtime.ContextEncoder
+	runtime.ContextDecoder
+	runtime.ListEncoder
+	runtime.ListDecoder
+	recognizer.RecognizingDecoder
+}
+
+// serializer implements Serializer for the application/cbor format.
+type serializer struct {
+	meta    MetaFactory
+	creater runtime.ObjectCreater
+	typer   runtime.ObjectTyper
+	options SerializerOptions
+
+	identifier runtime.Identifier
+}
+
+var _ Serializer = &serializer{}
+
+// Decode attempts to parse the provided CBOR data, extract the stored schema kind, apply
+// the provided default gvk, and then load that data into an object matching the desired
+// schema kind or the provided into. A single leading self-describe tag (55799) is
+// stripped from the document before decoding; all other tags are rejected wherever they
+// occur.
+// If into is *runtime.Unknown, the raw data will be extracted and no decoding will be performed.
+// If into is not registered with the typer, then the object will be straight decoded using
+// normal CBOR unmarshalling.
+// If into is provided and the original data is not fully qualified with kind/version/group,
+// the type of the into will be used to alter the returned gvk.
+// If into is nil or data's gvk different from into's gvk, it will generate a new Object with
+// ObjectCreater.New(gvk).
+// On success or most errors, the method will return the calculated schema kind.
+// The gvk calculate priority will be originalData > default gvk > into
+func (s *serializer) Decode(originalData []byte, gvk *schema.GroupVersionKind, into runtime.Object) (runtime.Object, *schema.GroupVersionKind, error) {
+	data := originalData
+	if bytes.HasPrefix(data, selfDescribedCBOR) {
+		// Exactly one leading self-describe tag is accepted as a document prefix; a
+		// repeated prefix is rejected by the decode modes' tag policy below.
+		data = data[len(selfDescribedCBOR):]
+	}
+
+	actual, err := s.meta.Interpret(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if gvk != nil {
+		*actual = gvkWithDefaults(*actual, *gvk)
+	}
+
+	if unk, ok := into.(*runtime.Unknown); ok && unk != nil {
+		unk.Raw = originalData
+		unk.ContentType = runtime.ContentTypeCBOR
+		unk.GetObjectKind().SetGroupVersionKind(*actual)
+		return unk, actual, nil
+	}
+
+	if into != nil {
+		_, isUnstructured := into.(runtime.Unstructured)
+		types, _, err := s.typer.ObjectKinds(into)
+		switch {
+		case runtime.IsNotRegisteredError(err), isUnstructured:
+			if err := s.unmarshal(data, into); err != nil {
+				return nil, actual, err
+			}
+			return into, actual, nil
+		case err != nil:
+			return nil, actual, err
+		default:
+			*actual = gvkWithDefaults(*actual, types[0])
+		}
+	}
+
+	if len(actual.Kind) == 0 {
+		return nil, actual, runtime.NewMissingKindErr(string(originalData))
+	}
+	if len(actual.Version) == 0 {
+		return nil, actual, runtime.NewMissingVersionErr(string(originalData))
+	}
+
+	// use the target if necessary
+	obj, err := runtime.UseOrCreateObject(s.typer, s.creater, *actual, into)
+	if err != nil {
+		return nil, actual, err
+	}
+
+	if obj == into {
+		// A destination being reused across decodes may still hold fields from an
+		// earlier document. Reset it so that fields absent from this document are
+		// observed at their zero values rather than their stale ones.
+		zeroDestination(obj)
+	}
+
+	if err := modes.Decode.Unmarshal(data, obj); err != nil {
+		return nil, actual, err
+	}
+
+	// If the deserializer is non-strict, return successfully here.
+	if !s.options.Strict {
+		return obj, actual, nil
+	}
+
+	// As we know the non-strict decode was successful, the only error that may be
+	// returned by the strict mode is due to the newly-added strictness, and we can
+	// return it as a typed strictDecodingError.
+	strictObj := obj.DeepCopyObject()
+	if err := modes.DecodeStrict.Unmarshal(data, strictObj); err != nil {
+		return nil, actual, strictDecodingError(err, data, strictObj, originalData)
+	}
+	// Always return the same object as the non-strict serializer to avoid any deviations.
+	return obj, actual, nil
+}
+
+// strictDecodingError converts a strict mode decode failure into a strictDecodingError,
+// naming the offending fields by path when it can. The decode mode reports an unknown
+// field by its position within its enclosing map, which is of no use in an error message,
+// so the document is re-examined to recover the complete set of unknown field paths.
+func strictDecodingError(err error, data []byte, into runtime.Object, originalData []byte) error {
+	var unknownField *fxcbor.UnknownFieldError
+	if errors.As(err, &unknownField) {
+		var decoded interface{}
+		if modes.Decode.Unmarshal(data, &decoded) == nil {
+			if paths := unknownfields.Paths(decoded, reflect.TypeOf(into)); len(paths) > 0 {
+				quoted := make([]string, 0, len(paths))
+				for _, path := range paths {
+					quoted = append(quoted, strconv.Quote(path))
+				}
+				message := fmt.Sprintf("unknown field(s): %s", strings.Join(quoted, ", "))
+				return runtime.NewStrictDecodingErrorForFields(message, string(originalData), paths...)
+			}
+		}
+	}
+	return runtime.NewStrictDecodingError(err.Error(), string(originalData))
+}
+
+// unmarshal decodes one CBOR document into the provided destination, transparently
+// handling unstructured destinations.
+func (s *serializer) unmarshal(data []byte, into interface{}) error {
+	if u, ok := into.(runtime.Unstructured); ok {
+		content := map[string]interface{}{}
+		if err := modes.Decode.Unmarshal(data, &content); err != nil {
+			return err
+		}
+		convertByteStrings(content)
+		u.SetUnstructuredContent(content)
+		return nil
+	}
+	zeroDestination(into)
+	return modes.Decode.Unmarshal(data, into)
+}
+
+// zeroDestination resets a pointer destination to the zero value of its element type.
+// The decode modes leave fields that have no corresponding map key untouched, so a
+// destination must be cleared before it can be reused for another document. Unstructured
+// destinations need no clearing because their content is replaced wholesale.
+func zeroDestination(into interface{}) {
+	v := reflect.ValueOf(into)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return
+	}
+	v.Elem().Set(reflect.Zero(v.Type().Elem()))
+}
+
+// convertByteStrings replaces every byte string in decoded unstructured content, at any
+// depth, with a text string containing its standard base64 encoding. CBOR writers outside
+// the project may put genuine byte strings in custom resource fields, and []byte has no
+// representation in JSON, so unstructured content containing one could never be re-encoded
+// as JSON. Base64 text is exactly what the value would have looked like had it been
+// produced by encoding a []byte field to JSON, so a round trip through either format
+// observes the same dynamic value. Map keys need no treatment; the decode modes permit
+// only text string keys.
+func convertByteStrings(content map[string]interface{}) {
+	for k, v := range content {
+		content[k] = convertedByteStrings(v)
+	}
+}
+
+func convertedByteStrings(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case map[string]interface{}:
+		convertByteStrings(v)
+	case []interface{}:
+		for i, e := range v {
+			v[i] = convertedByteStrings(e)
+		}
+	}
+	return v
+}
+
+// Encode serializes the provided object to the given writer, prefixed with the
+// self-describe tag so that the output is recognizable as CBOR.
+func (s *serializer) Encode(obj runtime.Object, w io.Writer) error {
+	if co, ok := obj.(runtime.CacheableObject); ok {
+		return co.CacheEncode(s.Identifier(), s.doEncode, w)
+	}
+	return s.doEncode(obj, w)
+}
+
+func (s *serializer) doEncode(obj runtime.Object, w io.Writer) error {
+	if s.options.ParallelListItems {
+		return s.doEncodeList(obj, w)
+	}
+	if _, err := w.Write(selfDescribedCBOR); err != nil {
+		return err
+	}
+	var v interface{} = obj
+	if u, ok := obj.(runtime.Unstructured); ok {
+		v = u.UnstructuredContent()
+	}
+	return modes.Encode.NewEncoder(w).Encode(v)
+}
+
+// EncodeWithAllocator writes an object to the provided writer as Encode does, staging the
+// document through a buffer backed by memory from the provided allocator so that the
+// entire output reaches the writer in a single write.
+func (s *serializer) EncodeWithAllocator(obj runtime.Object, w io.Writer, memAlloc runtime.MemoryAllocator) error {
+	if co, ok := obj.(runtime.CacheableObject); ok {
+		return co.CacheEncode(s.Identifier(), func(obj runtime.Object, w io.Writer) error { return s.doEncodeWithAllocator(obj, w, memAlloc) }, w)
+	}
+	return s.doEncodeWithAllocator(obj, w, memAlloc)
+}
+
+func (s *serializer) doEncodeWithAllocator(obj runtime.Object, w io.Writer, memAlloc runtime.MemoryAllocator) error {
+	if memAlloc == nil {
+		klog.Error("a mandatory memory allocator wasn't provided, this might have a negative impact on performance, check invocations of EncodeWithAllocator method, falling back on runtime.SimpleAllocator")
+		memAlloc = &runtime.SimpleAllocator{}
+	}
+	buf := bytes.NewBuffer(memAlloc.Allocate(0))
+	if _, err := buf.Write(selfDescribedCBOR); err != nil {
+		return err
+	}
+	if s.options.ParallelListItems {
+		if handled, err := s.encodeListParallel(obj, buf); handled {
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(buf.Bytes())
+			return err
+		}
+	}
+	var v interface{} = obj
+	if u, ok := obj.(runtime.Unstructured); ok {
+		v = u.UnstructuredContent()
+	}
+	if err := modes.Encode.MarshalToBuffer(v, buf); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Identifier implements runtime.Encoder interface.
+func (s *serializer) Identifier() runtime.Identifier {
+	return s.identifier
+}
+
+// RecognizesData implements the RecognizingDecoder interface. Only documents carrying the
+// self-describe tag prefix are recognized, which every document produced by this
+// serializer does.
+func (s *serializer) RecognizesData(peek io.Reader) (ok, unknown bool, err error) {
+	prefix := make([]byte, len(selfDescribedCBOR))
+	if _, err := io.ReadFull(peek, prefix); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	return bytes.Equal(prefix, selfDescribedCBOR), false, nil
+}
+
+// gvkWithDefaults returns group kind and version defaulting from provided default
+func gvkWithDefaults(actual, defaultGVK schema.GroupVersionKind) schema.GroupVersionKind {
+	if len(actual.Kind) == 0 {
+		actual.Kind = defaultGVK.Kind
+	}
+	if len(actual.Version) == 0 && len(actual.Group) == 0 {
+		actual.Group = defaultGVK.Group
+		actual.Version = defaultGVK.Version
+	}
+	if len(actual.Version) == 0 && actual.Group == defaultGVK.Group {
+		actual.Version = defaultGVK.Version
+	}
+	return actual
+}
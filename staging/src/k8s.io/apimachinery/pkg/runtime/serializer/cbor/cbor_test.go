@@ -0,0 +1,485 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	jsonserializer "k8s.io/apimachinery/pkg/runtime/serializer/json"
+	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+var testGV = schema.GroupVersion{Group: "test.group", Version: "v1"}
+
+func newTestScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(testGV.WithKind("Simple"), &runtimetesting.ExternalSimple{})
+	return scheme
+}
+
+// marshalWithTagsAllowed encodes v using a permissive mode so tests can construct
+// documents the serializer itself would refuse to produce.
+func marshalWithTagsAllowed(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	em, err := cbor.EncOptions{}.EncMode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := em.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestRoundTrip(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	original := &runtimetesting.ExternalSimple{
+		TypeMeta:   runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"},
+		TestString: "value",
+	}
+	var buf bytes.Buffer
+	if err := s.Encode(original, &buf); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), selfDescribedCBOR) {
+		t.Errorf("expected encoded document to begin with the self-describe tag, got % x", buf.Bytes())
+	}
+
+	obj, gvk, err := s.Decode(buf.Bytes(), nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if expected := testGV.WithKind("Simple"); *gvk != expected {
+		t.Errorf("expected gvk %v, got %v", expected, gvk)
+	}
+	if !reflect.DeepEqual(original, obj) {
+		t.Errorf("expected %#v, got %#v", original, obj)
+	}
+}
+
+func TestRoundTripUnstructured(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	original := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"spec": map[string]interface{}{
+			"count": int64(3),
+			"name":  "squeak",
+		},
+	}}
+	var buf bytes.Buffer
+	if err := s.Encode(original, &buf); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+
+	into := &unstructured.Unstructured{}
+	obj, _, err := s.Decode(buf.Bytes(), nil, into)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if !reflect.DeepEqual(original, obj) {
+		t.Errorf("expected %#v, got %#v", original, obj)
+	}
+}
+
+func TestRoundTripUnstructuredByteStable(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	original := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata": map[string]interface{}{
+			"name":            "squeak",
+			"namespace":       "default",
+			"resourceVersion": "42",
+		},
+		"spec": map[string]interface{}{
+			"count":   int64(3),
+			"ratio":   float64(0.5),
+			"enabled": true,
+			"tags":    []interface{}{"a", "b"},
+		},
+	}}
+	var buf bytes.Buffer
+	if err := s.Encode(original, &buf); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+
+	// An unchanged object must re-encode to identical bytes no matter how many times
+	// it round trips, so that a no-op update can be detected by comparing encodings.
+	// Map iteration order is randomized, so repeat to catch ordering instability that
+	// a single pass could miss. The deep copy stands in for admission and conversion
+	// passing an object through untouched.
+	data := buf.Bytes()
+	for i := 0; i < 10; i++ {
+		into := &unstructured.Unstructured{}
+		obj, _, err := s.Decode(data, nil, into)
+		if err != nil {
+			t.Fatalf("unexpected decode error on iteration %d: %v", i, err)
+		}
+		var reencoded bytes.Buffer
+		if err := s.Encode(obj.DeepCopyObject(), &reencoded); err != nil {
+			t.Fatalf("unexpected encode error on iteration %d: %v", i, err)
+		}
+		if !bytes.Equal(data, reencoded.Bytes()) {
+			t.Fatalf("round trip %d was not byte-stable:\noriginal: %x\nreencoded: %x", i, data, reencoded.Bytes())
+		}
+	}
+}
+
+func TestDecodeClearsReusedDestination(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	first := marshalWithTagsAllowed(t, map[string]interface{}{
+		"apiVersion": testGV.String(),
+		"kind":       "Simple",
+		"testString": "stale",
+	})
+	second := marshalWithTagsAllowed(t, map[string]interface{}{
+		"apiVersion": testGV.String(),
+		"kind":       "Simple",
+	})
+
+	into := &runtimetesting.ExternalSimple{}
+	obj, _, err := s.Decode(first, nil, into)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if obj != runtime.Object(into) {
+		t.Fatalf("expected the provided destination to be used, got %#v", obj)
+	}
+	if into.TestString != "stale" {
+		t.Fatalf("expected testString to decode as %q, got %q", "stale", into.TestString)
+	}
+
+	if _, _, err := s.Decode(second, nil, into); err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if into.TestString != "" {
+		t.Errorf("expected field absent from the second document to be cleared, got %q", into.TestString)
+	}
+}
+
+func TestDecodeClearsReusedUnregisteredDestination(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	document := marshalWithTagsAllowed(t, map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Unknown",
+	})
+
+	into := &runtimetesting.UnknownType{A: "stale"}
+	obj, _, err := s.Decode(document, nil, into)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if obj != runtime.Object(into) {
+		t.Fatalf("expected the provided destination to be used, got %#v", obj)
+	}
+	if into.A != "" {
+		t.Errorf("expected field absent from the document to be cleared, got %q", into.A)
+	}
+}
+
+func TestSelfDescribedTagPolicy(t *testing.T) {
+	document := marshalWithTagsAllowed(t, map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+	})
+	nestedTag := marshalWithTagsAllowed(t, map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"extra":      cbor.Tag{Number: 55799, Content: "oops"},
+	})
+
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	testCases := []struct {
+		name      string
+		data      []byte
+		expectErr bool
+	}{
+		{
+			name: "no prefix",
+			data: document,
+		},
+		{
+			name: "single prefix",
+			data: append(append([]byte{}, selfDescribedCBOR...), document...),
+		},
+		{
+			name:      "double prefix",
+			data:      append(append(append([]byte{}, selfDescribedCBOR...), selfDescribedCBOR...), document...),
+			expectErr: true,
+		},
+		{
+			name:      "tag nested inside document",
+			data:      nestedTag,
+			expectErr: true,
+		},
+		{
+			name:      "tag nested inside document with prefix",
+			data:      append(append([]byte{}, selfDescribedCBOR...), nestedTag...),
+			expectErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := s.Decode(tc.data, nil, &unstructured.Unstructured{})
+			if tc.expectErr && err == nil {
+				t.Error("expected error, got none")
+			}
+			if !tc.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDecodeUnstructuredByteStrings(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	data := marshalWithTagsAllowed(t, map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"bytes":      []byte("top-level"),
+		"empty":      []byte{},
+		"notUTF8":    []byte{0xff, 0x00},
+		"nested": map[string]interface{}{
+			"bytes": []byte("in a nested map"),
+		},
+		"list": []interface{}{
+			[]byte("in a list"),
+			map[string]interface{}{
+				"bytes": []byte("in a map in a list"),
+			},
+		},
+	})
+
+	into := &unstructured.Unstructured{}
+	if _, _, err := s.Decode(data, nil, into); err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"bytes":      "dG9wLWxldmVs",
+		"empty":      "",
+		"notUTF8":    "/wA=",
+		"nested": map[string]interface{}{
+			"bytes": "aW4gYSBuZXN0ZWQgbWFw",
+		},
+		"list": []interface{}{
+			"aW4gYSBsaXN0",
+			map[string]interface{}{
+				"bytes": "aW4gYSBtYXAgaW4gYSBsaXN0",
+			},
+		},
+	}
+	if !reflect.DeepEqual(expected, into.Object) {
+		t.Errorf("unexpected content: got %#v, want %#v", into.Object, expected)
+	}
+
+	// The converted content must be representable as JSON, unlike the byte strings it
+	// was decoded from.
+	if _, err := json.Marshal(into.Object); err != nil {
+		t.Errorf("unexpected error marshaling decoded content to JSON: %v", err)
+	}
+}
+
+func TestStrictDecode(t *testing.T) {
+	scheme := newTestScheme()
+	strict := NewSerializerWithOptions(DefaultMetaFactory, scheme, scheme, SerializerOptions{Strict: true})
+	lax := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	data := marshalWithTagsAllowed(t, map[string]interface{}{
+		"apiVersion": testGV.String(),
+		"kind":       "Simple",
+		"unknown":    true,
+	})
+
+	if _, _, err := lax.Decode(data, nil, nil); err != nil {
+		t.Errorf("unexpected error from non-strict decode: %v", err)
+	}
+
+	_, _, err := strict.Decode(data, nil, nil)
+	if err == nil {
+		t.Fatal("expected strict decoding error, got none")
+	}
+	if !runtime.IsStrictDecodingError(err) {
+		t.Errorf("expected strict decoding error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "unknown") {
+		t.Errorf("expected error to name the unknown field, got %v", err)
+	}
+	if paths := runtime.StrictDecodingFieldPaths(err); !reflect.DeepEqual(paths, []string{"unknown"}) {
+		t.Errorf("expected field paths [unknown], got %v", paths)
+	}
+}
+
+// TestStrictDecodeFieldPathsMatchJSON decodes equivalent documents with the strict CBOR
+// and JSON serializers and checks that both record the same unknown field paths, so that
+// the causes constructed from them do not depend on the request encoding.
+func TestStrictDecodeFieldPathsMatchJSON(t *testing.T) {
+	scheme := newTestScheme()
+	strictCBOR := NewSerializerWithOptions(DefaultMetaFactory, scheme, scheme, SerializerOptions{Strict: true})
+	strictJSON := jsonserializer.NewSerializerWithOptions(jsonserializer.DefaultMetaFactory, scheme, scheme, jsonserializer.SerializerOptions{Strict: true})
+
+	document := map[string]interface{}{
+		"apiVersion":   testGV.String(),
+		"kind":         "Simple",
+		"testString":   "value",
+		"unknownOne":   true,
+		"unknownOther": []interface{}{int64(1)},
+	}
+
+	cborData := marshalWithTagsAllowed(t, document)
+	jsonData, err := json.Marshal(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, cborErr := strictCBOR.Decode(cborData, nil, nil)
+	if !runtime.IsStrictDecodingError(cborErr) {
+		t.Fatalf("expected strict decoding error from cbor, got %v", cborErr)
+	}
+	_, _, jsonErr := strictJSON.Decode(jsonData, nil, nil)
+	if !runtime.IsStrictDecodingError(jsonErr) {
+		t.Fatalf("expected strict decoding error from json, got %v", jsonErr)
+	}
+
+	expected := []string{"unknownOne", "unknownOther"}
+	if paths := runtime.StrictDecodingFieldPaths(cborErr); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected cbor field paths %v, got %v", expected, paths)
+	}
+	if paths := runtime.StrictDecodingFieldPaths(jsonErr); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected json field paths %v, got %v", expected, paths)
+	}
+}
+
+func TestRecognizesData(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	recognized, _, err := s.RecognizesData(bytes.NewReader(append(append([]byte{}, selfDescribedCBOR...), 0xa0)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !recognized {
+		t.Error("expected prefixed document to be recognized")
+	}
+
+	for _, data := range [][]byte{nil, []byte(`{"apiVersion":"v1"}`), {0xa0}} {
+		recognized, _, err := s.RecognizesData(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if recognized {
+			t.Errorf("expected % x not to be recognized", data)
+		}
+	}
+}
+
+func TestRoundTripEmbeddedRawExtension(t *testing.T) {
+	scheme := runtime.NewScheme()
+	embeddedGVK := testGV.WithKind("EmbeddedTest")
+	scheme.AddKnownTypeWithName(embeddedGVK, &runtimetesting.EmbeddedTestExternal{})
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	inner := map[string]interface{}{"apiVersion": testGV.String(), "kind": "Simple", "testString": "nested"}
+	original := map[string]interface{}{
+		"apiVersion": testGV.String(),
+		"kind":       "EmbeddedTest",
+		"id":         "outer",
+		"object":     inner,
+	}
+	var buf bytes.Buffer
+	if err := s.Encode(&unstructured.Unstructured{Object: original}, &buf); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+
+	// Decoding into the typed destination captures the embedded object as raw bytes,
+	// and re-encoding the typed object must reproduce the original document.
+	obj, _, err := s.Decode(buf.Bytes(), nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	typed, ok := obj.(*runtimetesting.EmbeddedTestExternal)
+	if !ok {
+		t.Fatalf("expected *runtimetesting.EmbeddedTestExternal, got %T", obj)
+	}
+	if typed.Object.Raw == nil {
+		t.Fatal("expected embedded object to be captured as raw bytes")
+	}
+
+	// The typed object always encodes its second extension field, as null, so the
+	// expected document carries it too.
+	expected := map[string]interface{}{
+		"apiVersion":  testGV.String(),
+		"kind":        "EmbeddedTest",
+		"id":          "outer",
+		"object":      inner,
+		"emptyObject": nil,
+	}
+	var expectedBuf bytes.Buffer
+	if err := s.Encode(&unstructured.Unstructured{Object: expected}, &expectedBuf); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+
+	var reencoded bytes.Buffer
+	if err := s.Encode(typed, &reencoded); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+	if !bytes.Equal(reencoded.Bytes(), expectedBuf.Bytes()) {
+		t.Errorf("re-encoded document is not byte-identical to the expected document:\ngot  % x\nwant % x", reencoded.Bytes(), expectedBuf.Bytes())
+	}
+
+	// A typed object whose raw bytes hold JSON, as produced by the JSON serializer,
+	// is transcoded on encode.
+	fromJSON := &runtimetesting.EmbeddedTestExternal{
+		TypeMeta: runtime.TypeMeta{APIVersion: testGV.String(), Kind: "EmbeddedTest"},
+		ID:       "outer",
+		Object:   runtime.RawExtension{Raw: []byte(`{"apiVersion":"test.group/v1","kind":"Simple","testString":"nested"}`)},
+	}
+	var transcoded bytes.Buffer
+	if err := s.Encode(fromJSON, &transcoded); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+	if !bytes.Equal(transcoded.Bytes(), expectedBuf.Bytes()) {
+		t.Errorf("document encoded from JSON raw bytes is not byte-identical to the expected document:\ngot  % x\nwant % x", transcoded.Bytes(), expectedBuf.Bytes())
+	}
+}
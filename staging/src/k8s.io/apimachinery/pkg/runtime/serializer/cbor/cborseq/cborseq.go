@@ -0,0 +1,222 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cborseq reads and writes CBOR Sequences, the RFC 8742 framing of zero or more
+// concatenated CBOR documents with no delimiters between them. The sequence format is
+// shared by streaming watch responses, multi-document manifest files, and log formats,
+// which should split and join streams through this package rather than each carrying its
+// own framing implementation. Splitting is purely syntactic: elements are returned
+// exactly as they appear in the stream, with no content restrictions applied. Those are
+// enforced when an element is decoded.
+package cborseq
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+)
+
+// ErrElementLimit is returned by a Reader that has already returned as many elements as
+// its configured limit allows.
+var ErrElementLimit = errors.New("cbor sequence element limit reached")
+
+// selfDescribedCBOR is the encoded form of tag 55799, which prefixes every document the
+// serializer produces. A reader that has lost the element boundary can resynchronize at
+// the next occurrence of the prefix.
+var selfDescribedCBOR = []byte{0xd9, 0xd9, 0xf7}
+
+// ReaderOptions configures a Reader.
+type ReaderOptions struct {
+	// MaxElements limits the number of elements the Reader will return. Next and Peek
+	// return ErrElementLimit once the limit has been reached. Zero means no limit.
+	MaxElements int
+}
+
+// Reader splits a CBOR Sequence into its elements.
+type Reader struct {
+	source  io.Reader
+	buf     []byte
+	options ReaderOptions
+	count   int
+	// peeked is the length of the element at the head of buf, already located by Peek
+	// but not yet returned by Next, or zero.
+	peeked int
+}
+
+// NewReader returns a Reader that reads sequence elements from r.
+func NewReader(r io.Reader) *Reader {
+	return NewReaderWithOptions(r, ReaderOptions{})
+}
+
+// NewReaderWithOptions returns a Reader that reads sequence elements from r with the
+// provided options.
+func NewReaderWithOptions(r io.Reader, options ReaderOptions) *Reader {
+	return &Reader{source: r, options: options}
+}
+
+// Next returns the next element of the sequence. It returns io.EOF when the source is
+// exhausted, io.ErrUnexpectedEOF if the source ends inside an element, and an error
+// without consuming anything if the next element is not well-formed. The returned slice
+// is a copy and remains valid after subsequent calls.
+func (r *Reader) Next() ([]byte, error) {
+	end, err := r.nextBoundary()
+	if err != nil {
+		return nil, err
+	}
+	element := make([]byte, end)
+	copy(element, r.buf)
+	r.buf = r.buf[end:]
+	r.peeked = 0
+	r.count++
+	return element, nil
+}
+
+// Peek returns the next element of the sequence without consuming it. The errors and the
+// lifetime of the returned slice are as for Next.
+func (r *Reader) Peek() ([]byte, error) {
+	end, err := r.nextBoundary()
+	if err != nil {
+		return nil, err
+	}
+	element := make([]byte, end)
+	copy(element, r.buf)
+	return element, nil
+}
+
+// Count returns the number of elements returned by Next so far.
+func (r *Reader) Count() int {
+	return r.count
+}
+
+// SkipMalformed discards input up to the next occurrence of the self-describe tag
+// prefix, which every document produced by the serializer carries, so that reading can
+// continue after Next reports a malformed element. It returns io.EOF if the source is
+// exhausted before another prefix is found. Resynchronization is a heuristic for tools
+// salvaging damaged streams: the prefix bytes can also occur inside a document, so an
+// element returned after skipping is not guaranteed to start where a writer intended.
+func (r *Reader) SkipMalformed() error {
+	// The head of the buffer begins the malformed element, so a prefix there is part of
+	// the element being skipped rather than a point to resynchronize to.
+	if len(r.buf) > 0 {
+		r.buf = r.buf[1:]
+	}
+	r.peeked = 0
+	for {
+		if i := bytes.Index(r.buf, selfDescribedCBOR); i >= 0 {
+			r.buf = r.buf[i:]
+			return nil
+		}
+		// Retain enough of the tail to recognize a prefix that straddles reads.
+		if n := len(r.buf) - (len(selfDescribedCBOR) - 1); n > 0 {
+			r.buf = r.buf[n:]
+		}
+		if err := r.fill(); err != nil {
+			return err
+		}
+	}
+}
+
+// nextBoundary locates the end of the element at the head of the buffer, reading more
+// from the source as needed.
+func (r *Reader) nextBoundary() (int, error) {
+	if r.options.MaxElements > 0 && r.count >= r.options.MaxElements {
+		return 0, ErrElementLimit
+	}
+	if r.peeked > 0 {
+		return r.peeked, nil
+	}
+	for {
+		if len(r.buf) > 0 {
+			decoder := modes.Split.NewDecoder(bytes.NewReader(r.buf))
+			err := decoder.Skip()
+			if err == nil {
+				r.peeked = decoder.NumBytesRead()
+				return r.peeked, nil
+			}
+			if err != io.EOF && err != io.ErrUnexpectedEOF {
+				return 0, fmt.Errorf("malformed cbor sequence element: %v", err)
+			}
+		}
+		if err := r.fill(); err != nil {
+			if err != io.EOF {
+				return 0, err
+			}
+			if len(r.buf) == 0 {
+				return 0, io.EOF
+			}
+			return 0, io.ErrUnexpectedEOF
+		}
+	}
+}
+
+// fill reads at least one byte from the source into the buffer. The read size grows with
+// the buffer so that locating the boundary of a large element, which rescans the buffer
+// after each fill, does work proportional to the element size overall.
+func (r *Reader) fill() error {
+	chunk := 4096
+	if len(r.buf) > chunk {
+		chunk = len(r.buf)
+	}
+	free := make([]byte, chunk)
+	n, err := r.source.Read(free)
+	r.buf = append(r.buf, free[:n]...)
+	if n > 0 {
+		// Defer the error until no further progress can be made.
+		return nil
+	}
+	if err == nil {
+		return io.ErrNoProgress
+	}
+	return err
+}
+
+// Writer writes the elements of a CBOR Sequence. Elements are written as-is, per RFC
+// 8742, after a check that each is exactly one well-formed document, so that one bad
+// element cannot corrupt every element after it in the stream.
+type Writer struct {
+	w     io.Writer
+	count int
+}
+
+// NewWriter returns a Writer that writes sequence elements to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WriteElement appends one element to the sequence. The data must contain exactly one
+// well-formed CBOR document.
+func (w *Writer) WriteElement(data []byte) error {
+	decoder := modes.Split.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Skip(); err != nil {
+		return fmt.Errorf("refusing to write malformed cbor sequence element: %v", err)
+	}
+	if end := decoder.NumBytesRead(); end != len(data) {
+		return fmt.Errorf("refusing to write cbor sequence element with %d bytes of trailing data", len(data)-end)
+	}
+	if _, err := w.w.Write(data); err != nil {
+		return err
+	}
+	w.count++
+	return nil
+}
+
+// Count returns the number of elements written so far.
+func (w *Writer) Count() int {
+	return w.count
+}
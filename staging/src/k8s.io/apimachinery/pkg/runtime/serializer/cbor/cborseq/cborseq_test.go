@@ -0,0 +1,234 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cborseq_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/cborseq"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+)
+
+// encodeElement returns the encoding of v with the self-describe tag prefix the
+// serializer emits on every document.
+func encodeElement(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	encoded, err := direct.Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+	return append([]byte{0xd9, 0xd9, 0xf7}, encoded...)
+}
+
+func TestReader(t *testing.T) {
+	var stream bytes.Buffer
+	var elements [][]byte
+	for _, v := range []interface{}{
+		map[string]interface{}{"apiVersion": "v1", "kind": "Pod"},
+		[]interface{}{int64(1), int64(2), int64(3)},
+		"third",
+	} {
+		element := encodeElement(t, v)
+		elements = append(elements, element)
+		stream.Write(element)
+	}
+
+	// A one-byte-at-a-time source exercises boundary detection across short reads.
+	reader := cborseq.NewReader(iotest.OneByteReader(&stream))
+	for i, want := range elements {
+		got, err := reader.Next()
+		if err != nil {
+			t.Fatalf("unexpected error reading element %d: %v", i+1, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("element %d does not match its encoding:\nwant: % x\ngot:  % x", i+1, want, got)
+		}
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF at end of sequence, got %v", err)
+	}
+	if got := reader.Count(); got != len(elements) {
+		t.Errorf("expected count %d, got %d", len(elements), got)
+	}
+}
+
+func TestReaderEmpty(t *testing.T) {
+	if _, err := cborseq.NewReader(bytes.NewReader(nil)).Next(); err != io.EOF {
+		t.Errorf("expected io.EOF for empty input, got %v", err)
+	}
+}
+
+func TestReaderTruncated(t *testing.T) {
+	element := encodeElement(t, map[string]interface{}{"kind": "Pod"})
+	reader := cborseq.NewReader(bytes.NewReader(element[:len(element)-1]))
+	if _, err := reader.Next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF for truncated element, got %v", err)
+	}
+}
+
+func TestReaderPeek(t *testing.T) {
+	first := encodeElement(t, "first")
+	second := encodeElement(t, "second")
+	reader := cborseq.NewReader(bytes.NewReader(append(first[:len(first):len(first)], second...)))
+
+	peeked, err := reader.Peek()
+	if err != nil {
+		t.Fatalf("unexpected peek error: %v", err)
+	}
+	if !bytes.Equal(peeked, first) {
+		t.Errorf("peek returned % x, want % x", peeked, first)
+	}
+	if got := reader.Count(); got != 0 {
+		t.Errorf("peek should not count elements, got count %d", got)
+	}
+	got, err := reader.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, first) {
+		t.Errorf("next after peek returned % x, want % x", got, first)
+	}
+	got, err = reader.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, second) {
+		t.Errorf("expected second element % x, got % x", second, got)
+	}
+}
+
+func TestReaderMaxElements(t *testing.T) {
+	var stream bytes.Buffer
+	for i := 0; i < 3; i++ {
+		stream.Write(encodeElement(t, int64(i)))
+	}
+	reader := cborseq.NewReaderWithOptions(&stream, cborseq.ReaderOptions{MaxElements: 2})
+	for i := 0; i < 2; i++ {
+		if _, err := reader.Next(); err != nil {
+			t.Fatalf("unexpected error reading element %d: %v", i+1, err)
+		}
+	}
+	if _, err := reader.Next(); err != cborseq.ErrElementLimit {
+		t.Errorf("expected ErrElementLimit, got %v", err)
+	}
+	if _, err := reader.Peek(); err != cborseq.ErrElementLimit {
+		t.Errorf("expected ErrElementLimit from peek, got %v", err)
+	}
+}
+
+func TestReaderSkipMalformed(t *testing.T) {
+	first := encodeElement(t, "first")
+	second := encodeElement(t, "second")
+
+	var stream bytes.Buffer
+	stream.Write(first)
+	// A break code outside of an indefinite-length item is never well-formed.
+	stream.WriteByte(0xff)
+	stream.Write(second)
+
+	reader := cborseq.NewReader(iotest.OneByteReader(&stream))
+	got, err := reader.Next()
+	if err != nil {
+		t.Fatalf("unexpected error reading first element: %v", err)
+	}
+	if !bytes.Equal(got, first) {
+		t.Errorf("expected first element % x, got % x", first, got)
+	}
+	if _, err := reader.Next(); err == nil {
+		t.Fatal("expected error reading malformed element")
+	}
+	// The malformed element is not consumed, so reading again returns the same error.
+	if _, err := reader.Next(); err == nil {
+		t.Fatal("expected repeated error reading malformed element")
+	}
+	if err := reader.SkipMalformed(); err != nil {
+		t.Fatalf("unexpected resynchronization error: %v", err)
+	}
+	got, err = reader.Next()
+	if err != nil {
+		t.Fatalf("unexpected error reading element after resynchronization: %v", err)
+	}
+	if !bytes.Equal(got, second) {
+		t.Errorf("expected second element % x, got % x", second, got)
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF at end of sequence, got %v", err)
+	}
+}
+
+func TestReaderSkipMalformedAtEOF(t *testing.T) {
+	reader := cborseq.NewReader(bytes.NewReader([]byte{0xff}))
+	if _, err := reader.Next(); err == nil {
+		t.Fatal("expected error reading malformed element")
+	}
+	if err := reader.SkipMalformed(); err != io.EOF {
+		t.Errorf("expected io.EOF when no further element begins, got %v", err)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var stream bytes.Buffer
+	writer := cborseq.NewWriter(&stream)
+
+	elements := [][]byte{
+		encodeElement(t, map[string]interface{}{"kind": "Pod"}),
+		encodeElement(t, "second"),
+	}
+	for i, element := range elements {
+		if err := writer.WriteElement(element); err != nil {
+			t.Fatalf("unexpected error writing element %d: %v", i+1, err)
+		}
+	}
+	if got := writer.Count(); got != len(elements) {
+		t.Errorf("expected count %d, got %d", len(elements), got)
+	}
+
+	reader := cborseq.NewReader(&stream)
+	for i, want := range elements {
+		got, err := reader.Next()
+		if err != nil {
+			t.Fatalf("unexpected error reading element %d: %v", i+1, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("element %d did not round trip:\nwant: % x\ngot:  % x", i+1, want, got)
+		}
+	}
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF at end of sequence, got %v", err)
+	}
+}
+
+func TestWriterRejectsMalformedElements(t *testing.T) {
+	element := encodeElement(t, "element")
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"truncated": element[:len(element)-1],
+		"malformed": {0xff},
+		"trailing":  append(element[:len(element):len(element)], element...),
+	} {
+		var stream bytes.Buffer
+		if err := cborseq.NewWriter(&stream).WriteElement(data); err == nil {
+			t.Errorf("%s: expected error, got nil", name)
+		}
+		if stream.Len() != 0 {
+			t.Errorf("%s: rejected element must not be written, got %d bytes", name, stream.Len())
+		}
+	}
+}
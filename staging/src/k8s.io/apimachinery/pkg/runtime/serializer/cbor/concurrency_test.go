@@ -0,0 +1,155 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
+)
+
+// The only state shared between concurrent serializer calls is the set of package-level
+// encode and decode modes, which are immutable once constructed, and the pool of scratch
+// buffers used by the item-at-a-time list encoders. There is deliberately no mutable
+// package-level codec handle of the kind some CBOR libraries use, because options hung
+// on such a handle by one caller change the behavior observed by every other.
+//
+// TestConcurrentSerializerUse exercises every entry point of a shared serializer from
+// many goroutines at once and checks each result against a reference computed up front.
+// The interleaving assertions are weak on their own; the test earns its keep under the
+// race detector, where any unsynchronized access to the shared state is an error even if
+// the output happens to be correct.
+func TestConcurrentSerializerUse(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+	strict := NewSerializerWithOptions(DefaultMetaFactory, scheme, scheme, SerializerOptions{Strict: true})
+	parallel := NewSerializerWithOptions(DefaultMetaFactory, scheme, scheme, SerializerOptions{ParallelListItems: true})
+
+	single := &runtimetesting.ExternalSimple{
+		TypeMeta:   runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"},
+		TestString: "value",
+	}
+	list := newTestSimpleList(minParallelEncodeItems + 1)
+	copyableList := &testCopyableList{
+		TypeMeta: runtime.TypeMeta{APIVersion: testGV.String(), Kind: "SimpleList"},
+		Metadata: testListMeta{ResourceVersion: "42"},
+		Items:    newTestSimpleList(3).Items,
+	}
+
+	var singleData, listData, copyableListData bytes.Buffer
+	if err := s.Encode(single, &singleData); err != nil {
+		t.Fatalf("unexpected encode error preparing reference document: %v", err)
+	}
+	if err := s.Encode(list, &listData); err != nil {
+		t.Fatalf("unexpected encode error preparing reference document: %v", err)
+	}
+	if err := s.Encode(copyableList, &copyableListData); err != nil {
+		t.Fatalf("unexpected encode error preparing reference document: %v", err)
+	}
+
+	expectBytes := func(want []byte, encode func(w *bytes.Buffer) error) func() error {
+		return func() error {
+			var buf bytes.Buffer
+			if err := encode(&buf); err != nil {
+				return err
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				return fmt.Errorf("encoded bytes differ from reference:\ngot:  % x\nwant: % x", buf.Bytes(), want)
+			}
+			return nil
+		}
+	}
+
+	operations := map[string]func() error{
+		"encode": expectBytes(singleData.Bytes(), func(w *bytes.Buffer) error {
+			return s.Encode(single, w)
+		}),
+		"encode list sequentially": expectBytes(listData.Bytes(), func(w *bytes.Buffer) error {
+			return s.Encode(list, w)
+		}),
+		"encode list with the parallel option": expectBytes(listData.Bytes(), func(w *bytes.Buffer) error {
+			return parallel.Encode(list, w)
+		}),
+		"encode list through the list entry point": expectBytes(listData.Bytes(), func(w *bytes.Buffer) error {
+			return s.EncodeList(list, w)
+		}),
+		"encode list with cancellation checks": expectBytes(listData.Bytes(), func(w *bytes.Buffer) error {
+			return s.EncodeContext(context.Background(), list, w)
+		}),
+		"decode": func() error {
+			obj, _, err := s.Decode(singleData.Bytes(), nil, nil)
+			if err != nil {
+				return err
+			}
+			if got := obj.(*runtimetesting.ExternalSimple).TestString; got != single.TestString {
+				return fmt.Errorf("decoded TestString %q, expected %q", got, single.TestString)
+			}
+			return nil
+		},
+		"decode into": func() error {
+			var into runtimetesting.ExternalSimple
+			if _, _, err := s.Decode(singleData.Bytes(), nil, &into); err != nil {
+				return err
+			}
+			if into.TestString != single.TestString {
+				return fmt.Errorf("decoded TestString %q, expected %q", into.TestString, single.TestString)
+			}
+			return nil
+		},
+		"decode strictly": func() error {
+			_, _, err := strict.Decode(singleData.Bytes(), nil, nil)
+			return err
+		},
+		"decode list through the list entry point": func() error {
+			into := &testCopyableList{}
+			if err := s.DecodeList(copyableListData.Bytes(), into); err != nil {
+				return err
+			}
+			if len(into.Items) != len(copyableList.Items) {
+				return fmt.Errorf("decoded %d items, expected %d", len(into.Items), len(copyableList.Items))
+			}
+			return nil
+		},
+	}
+
+	const (
+		workersPerOperation    = 8
+		iterationsPerGoroutine = 100
+	)
+
+	var wg sync.WaitGroup
+	for name, op := range operations {
+		for i := 0; i < workersPerOperation; i++ {
+			wg.Add(1)
+			go func(name string, op func() error) {
+				defer wg.Done()
+				for j := 0; j < iterationsPerGoroutine; j++ {
+					if err := op(); err != nil {
+						t.Errorf("%s: %v", name, err)
+						return
+					}
+				}
+			}(name, op)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// EncodeContext writes an object to the provided writer as Encode does, aborting with
+// the context's error if ctx is canceled. For lists of a recognized shape, cancellation
+// is checked between items; for other objects it is checked once before encoding begins.
+// The bytes written for an uncanceled encode are identical to those of Encode.
+func (s *serializer) EncodeContext(ctx context.Context, obj runtime.Object, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if co, ok := obj.(runtime.CacheableObject); ok {
+		return co.CacheEncode(s.Identifier(), func(obj runtime.Object, w io.Writer) error { return s.doEncodeContext(ctx, obj, w) }, w)
+	}
+	return s.doEncodeContext(ctx, obj, w)
+}
+
+func (s *serializer) doEncodeContext(ctx context.Context, obj runtime.Object, w io.Writer) error {
+	if _, err := w.Write(selfDescribedCBOR); err != nil {
+		return err
+	}
+	if handled, err := s.encodeListContext(ctx, obj, w); handled {
+		return err
+	}
+	var v interface{} = obj
+	if u, ok := obj.(runtime.Unstructured); ok {
+		v = u.UnstructuredContent()
+	}
+	return modes.Encode.NewEncoder(w).Encode(v)
+}
+
+// DecodeContext decodes as Decode does, returning the context's error instead of
+// beginning the decode if ctx has already been canceled.
+func (s *serializer) DecodeContext(ctx context.Context, data []byte, gvk *schema.GroupVersionKind, into runtime.Object) (runtime.Object, *schema.GroupVersionKind, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, gvk, err
+	}
+	return s.Decode(data, gvk, into)
+}
+
+// encodeListContext encodes obj with cancellation checked between items, if obj is a
+// list of a recognized shape. It returns false if obj was not handled and should be
+// encoded without item boundaries. The bytes written are identical to those of a
+// sequential encode.
+func (s *serializer) encodeListContext(ctx context.Context, obj runtime.Object, w io.Writer) (bool, error) {
+	if u, ok := obj.(runtime.Unstructured); ok {
+		content := u.UnstructuredContent()
+		rawItems, ok := content["items"].([]interface{})
+		if !ok {
+			return false, nil
+		}
+		items, err := encodeItemsContext(ctx, len(rawItems), func(i int) interface{} {
+			return rawItems[i]
+		})
+		if err != nil {
+			return true, err
+		}
+		envelope := make(map[string]interface{}, len(content))
+		for k, v := range content {
+			envelope[k] = v
+		}
+		envelope["items"] = items
+		return true, modes.Encode.NewEncoder(w).Encode(envelope)
+	}
+
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return false, nil
+	}
+	v = v.Elem()
+	typeMeta, listMeta, itemsValue, ok := listShape(v)
+	if !ok {
+		return false, nil
+	}
+
+	items, err := encodeItemsContext(ctx, itemsValue.Len(), func(i int) interface{} {
+		return itemsValue.Index(i).Addr().Interface()
+	})
+	if err != nil {
+		return true, err
+	}
+
+	metadata, err := modes.Encode.Marshal(listMeta.Interface())
+	if err != nil {
+		return true, err
+	}
+	envelope := listEnvelope{
+		APIVersion: typeMeta.FieldByName("APIVersion").String(),
+		Kind:       typeMeta.FieldByName("Kind").String(),
+		Metadata:   metadata,
+		Items:      items,
+	}
+	return true, modes.Encode.NewEncoder(w).Encode(&envelope)
+}
+
+// encodeItemsContext encodes n items in order, checking for cancellation before each
+// item.
+func encodeItemsContext(ctx context.Context, n int, item func(i int) interface{}) ([]cbor.RawMessage, error) {
+	items := make([]cbor.RawMessage, n)
+	buf := encodeBufferPool.Get().(*bytes.Buffer)
+	defer encodeBufferPool.Put(buf)
+	for i := 0; i < n; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		buf.Reset()
+		if err := modes.Encode.NewEncoder(buf).Encode(item(i)); err != nil {
+			return nil, err
+		}
+		items[i] = append(cbor.RawMessage(nil), buf.Bytes()...)
+	}
+	return items, nil
+}
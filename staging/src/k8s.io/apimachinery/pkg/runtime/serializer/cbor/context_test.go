@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
+)
+
+func TestEncodeContextMatchesEncode(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	testCases := []struct {
+		name string
+		obj  runtime.Object
+	}{
+		{
+			name: "typed list",
+			obj:  newTestSimpleList(3),
+		},
+		{
+			name: "unstructured list",
+			obj:  newTestUnstructuredList(3),
+		},
+		{
+			name: "non-list object",
+			obj: &runtimetesting.ExternalSimple{
+				TypeMeta:   runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"},
+				TestString: "value",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var plain, withContext bytes.Buffer
+			if err := s.Encode(tc.obj, &plain); err != nil {
+				t.Fatalf("unexpected encode error: %v", err)
+			}
+			if err := s.EncodeContext(context.Background(), tc.obj, &withContext); err != nil {
+				t.Fatalf("unexpected context encode error: %v", err)
+			}
+			if !bytes.Equal(plain.Bytes(), withContext.Bytes()) {
+				t.Errorf("encodings differ:\nencode:        % x\nencodecontext: % x", plain.Bytes(), withContext.Bytes())
+			}
+		})
+	}
+}
+
+func TestEncodeContextCanceled(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buf bytes.Buffer
+	if err := s.EncodeContext(ctx, newTestSimpleList(3), &buf); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no bytes written for an encode that never started, got % x", buf.Bytes())
+	}
+}
+
+func TestDecodeContextCanceled(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	original := &runtimetesting.ExternalSimple{
+		TypeMeta:   runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"},
+		TestString: "value",
+	}
+	var buf bytes.Buffer
+	if err := s.Encode(original, &buf); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := s.DecodeContext(ctx, buf.Bytes(), nil, nil); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	obj, _, err := s.DecodeContext(context.Background(), buf.Bytes(), nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if !reflect.DeepEqual(original, obj) {
+		t.Errorf("expected %#v, got %#v", original, obj)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	"k8s.io/apimachinery/pkg/apis/testapigroup"
+	testapigroupfuzzer "k8s.io/apimachinery/pkg/apis/testapigroup/fuzzer"
+	"k8s.io/apimachinery/pkg/apis/testapigroup/install"
+	testapigroupv1 "k8s.io/apimachinery/pkg/apis/testapigroup/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+	"k8s.io/apimachinery/pkg/util/diff"
+)
+
+// cborImplementation is one complete CBOR stack under evaluation. The differential test
+// and the benchmarks treat entries as interchangeable so that a candidate library can be
+// compared against the incumbent over the same corpus, and so that the comparison can be
+// rerun after any regression.
+type cborImplementation struct {
+	name      string
+	marshal   func(src interface{}) ([]byte, error)
+	unmarshal func(data []byte, dst interface{}) error
+}
+
+// cborImplementations lists every stack present in this tree. github.com/ugorji/go is
+// not vendored here, so only the fxamacker stack is wired in and the pairwise assertions
+// in TestImplementationDifferential hold vacuously. Adding a second entry is all that is
+// required to reproduce the full comparison.
+var cborImplementations = []cborImplementation{
+	{
+		name: "fxamacker",
+		marshal: func(src interface{}) ([]byte, error) {
+			return modes.Encode.Marshal(src)
+		},
+		unmarshal: func(data []byte, dst interface{}) error {
+			return modes.Decode.Unmarshal(data, dst)
+		},
+	},
+}
+
+// differentialCorpus returns fuzzed external Carp objects. The corpus is generated from a
+// fixed seed so that benchmark numbers are comparable from run to run.
+func differentialCorpus(tb testing.TB, n int) []*testapigroupv1.Carp {
+	const seed = 1587
+	scheme := runtime.NewScheme()
+	install.Install(scheme)
+	codecs := runtimeserializer.NewCodecFactory(scheme)
+	f := fuzzer.FuzzerFor(fuzzer.MergeFuzzerFuncs(metafuzzer.Funcs, testapigroupfuzzer.Funcs), rand.NewSource(seed), codecs)
+
+	corpus := make([]*testapigroupv1.Carp, 0, n)
+	for i := 0; i < n; i++ {
+		internal := &testapigroup.Carp{}
+		f.Fuzz(internal)
+		external, err := scheme.ConvertToVersion(internal, testapigroupv1.SchemeGroupVersion)
+		if err != nil {
+			tb.Fatalf("failed to convert fuzzed object to external version: %v", err)
+		}
+		corpus = append(corpus, external.(*testapigroupv1.Carp))
+	}
+	return corpus
+}
+
+// canonicalize decodes a CBOR document with the reference implementation and re-encodes
+// it, so that documents from implementations with differing but equally valid encoding
+// choices (e.g. map key order) can be compared byte for byte.
+func canonicalize(tb testing.TB, data []byte) []byte {
+	reference := cborImplementations[0]
+	var value interface{}
+	if err := reference.unmarshal(data, &value); err != nil {
+		tb.Fatalf("failed to decode document during canonicalization: %v", err)
+	}
+	canonical, err := reference.marshal(value)
+	if err != nil {
+		tb.Fatalf("failed to re-encode document during canonicalization: %v", err)
+	}
+	return canonical
+}
+
+// TestImplementationDifferential encodes each corpus object with every implementation and
+// requires that all implementations agree on the canonicalized encoded bytes and on the
+// decoded semantics of each other's output.
+func TestImplementationDifferential(t *testing.T) {
+	for _, obj := range differentialCorpus(t, 100) {
+		encodings := make(map[string][]byte, len(cborImplementations))
+		for _, impl := range cborImplementations {
+			data, err := impl.marshal(obj)
+			if err != nil {
+				t.Fatalf("%s failed to encode object: %v", impl.name, err)
+			}
+			encodings[impl.name] = data
+		}
+
+		for i, a := range cborImplementations {
+			for _, b := range cborImplementations[i+1:] {
+				if ca, cb := canonicalize(t, encodings[a.name]), canonicalize(t, encodings[b.name]); !bytes.Equal(ca, cb) {
+					t.Errorf("canonicalized encodings differ between %s and %s:\n% x\n% x", a.name, b.name, ca, cb)
+				}
+			}
+		}
+
+		// Every implementation must assign the same meaning to every
+		// implementation's output.
+		for _, producer := range cborImplementations {
+			var want interface{}
+			if err := producer.unmarshal(encodings[producer.name], &want); err != nil {
+				t.Fatalf("%s failed to decode its own output: %v", producer.name, err)
+			}
+			for _, consumer := range cborImplementations {
+				var got interface{}
+				if err := consumer.unmarshal(encodings[producer.name], &got); err != nil {
+					t.Fatalf("%s failed to decode output of %s: %v", consumer.name, producer.name, err)
+				}
+				if !reflect.DeepEqual(want, got) {
+					t.Errorf("%s and %s decode the same document differently: %s", producer.name, consumer.name, diff.ObjectReflectDiff(want, got))
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkImplementationEncode(b *testing.B) {
+	corpus := differentialCorpus(b, 16)
+	for _, impl := range cborImplementations {
+		b.Run(impl.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := impl.marshal(corpus[i%len(corpus)]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkImplementationDecode(b *testing.B) {
+	corpus := differentialCorpus(b, 16)
+	for _, impl := range cborImplementations {
+		encodings := make([][]byte, 0, len(corpus))
+		for _, obj := range corpus {
+			data, err := impl.marshal(obj)
+			if err != nil {
+				b.Fatal(err)
+			}
+			encodings = append(encodings, data)
+		}
+		b.Run(impl.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var value interface{}
+				if err := impl.unmarshal(encodings[i%len(encodings)], &value); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
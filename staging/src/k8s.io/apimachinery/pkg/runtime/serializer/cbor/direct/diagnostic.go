@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package direct
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Diagnostic returns the extended diagnostic notation (RFC 8949 section 8) of a CBOR
+// document, a human-readable text form suitable for logs and error messages. An error is
+// returned if the input is not a single well-formed document.
+func Diagnostic(data []byte) (string, error) {
+	return cbor.Diagnose(data)
+}
+
+// diagnosticEllipsis stands in for data items omitted by DiagnosticLimited.
+const diagnosticEllipsis = "..."
+
+// DiagnosticLimited returns the diagnostic notation of a CBOR document after truncating
+// it to at most limit data items, so that summarizing a very large document for a log
+// message produces bounded output regardless of the document's encoded size. Truncation
+// operates on decoded data items rather than bytes, since a document cut at an arbitrary
+// byte offset is no longer well-formed and can't be rendered at all. Omitted items are
+// replaced with "...", and map entries are retained in sorted key order so that repeated
+// summaries of the same document are identical. The exact byte-level representation is
+// not preserved; use Diagnostic when fidelity matters and the document is small enough.
+func DiagnosticLimited(data []byte, limit int) (string, error) {
+	var v interface{}
+	if err := Unmarshal(bytes.TrimPrefix(data, selfDescribedCBOR), &v); err != nil {
+		return "", err
+	}
+	truncated, err := Marshal(truncateValue(v, &limit))
+	if err != nil {
+		return "", err
+	}
+	return cbor.Diagnose(truncated)
+}
+
+// truncateValue returns v with nested containers truncated so that the total number of
+// data items retained does not exceed the remaining budget.
+func truncateValue(v interface{}, budget *int) interface{} {
+	if *budget <= 0 {
+		return diagnosticEllipsis
+	}
+	*budget--
+	switch v := v.(type) {
+	case []interface{}:
+		items := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			if *budget <= 0 {
+				items = append(items, diagnosticEllipsis)
+				break
+			}
+			items = append(items, truncateValue(item, budget))
+		}
+		return items
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		entries := make(map[string]interface{}, len(v))
+		for _, key := range keys {
+			if *budget <= 0 {
+				entries[diagnosticEllipsis] = diagnosticEllipsis
+				break
+			}
+			entries[key] = truncateValue(v[key], budget)
+		}
+		return entries
+	default:
+		return v
+	}
+}
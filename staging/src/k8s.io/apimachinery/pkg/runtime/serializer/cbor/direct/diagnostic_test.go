@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package direct
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDiagnosticLimited(t *testing.T) {
+	mustMarshal := func(v interface{}) []byte {
+		data, err := Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	bigArray := make([]interface{}, 100)
+	for i := range bigArray {
+		bigArray[i] = int64(i)
+	}
+
+	testCases := []struct {
+		name     string
+		data     []byte
+		limit    int
+		expected string
+	}{
+		{
+			name:     "scalar",
+			data:     mustMarshal(int64(42)),
+			limit:    1,
+			expected: `42`,
+		},
+		{
+			name:     "small document within limit",
+			data:     mustMarshal(map[string]interface{}{"a": int64(1), "b": int64(2)}),
+			limit:    8,
+			expected: `{"a": 1, "b": 2}`,
+		},
+		{
+			name:     "array truncated",
+			data:     mustMarshal([]interface{}{int64(0), int64(1), int64(2), int64(3)}),
+			limit:    3,
+			expected: `[0, 1, "..."]`,
+		},
+		{
+			name: "map truncated in sorted key order",
+			data: mustMarshal(map[string]interface{}{"c": int64(3), "a": int64(1), "b": int64(2)}),
+			// Entries are retained in sorted key order, but rendered in the canonical
+			// order of the encode mode, which places shorter keys first.
+			limit:    3,
+			expected: `{"a": 1, "b": 2, "...": "..."}`,
+		},
+		{
+			name:     "nested container truncated",
+			data:     mustMarshal(map[string]interface{}{"items": bigArray, "kind": "List"}),
+			limit:    4,
+			expected: `{"...": "...", "items": [0, 1, "..."]}`,
+		},
+		{
+			name:     "self-describe tag prefix tolerated",
+			data:     append([]byte{0xd9, 0xd9, 0xf7}, mustMarshal(int64(42))...),
+			limit:    1,
+			expected: `42`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			diagnostic, err := DiagnosticLimited(tc.data, tc.limit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diagnostic != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, diagnostic)
+			}
+		})
+	}
+
+	t.Run("malformed input", func(t *testing.T) {
+		if _, err := DiagnosticLimited([]byte{0xff}, 8); err == nil {
+			t.Error("expected an error for a document that is not well-formed")
+		}
+	})
+
+	t.Run("output is bounded", func(t *testing.T) {
+		huge := make([]interface{}, 10000)
+		for i := range huge {
+			huge[i] = fmt.Sprintf("item-%d", i)
+		}
+		diagnostic, err := DiagnosticLimited(mustMarshal(huge), 16)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(diagnostic) > 1024 {
+			t.Errorf("expected a bounded summary, got %d characters", len(diagnostic))
+		}
+	})
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package direct provides functions for operating on CBOR data directly, without going
+// through a runtime.Serializer, with behavior that is compatible with that of the CBOR
+// serializer.
+package direct
+
+import (
+	"bytes"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// selfDescribedCBOR is the encoded form of tag 55799, which the serializer emits as a
+// document prefix. Equal accepts documents with or without the prefix.
+var selfDescribedCBOR = []byte{0xd9, 0xd9, 0xf7}
+
+// Marshal serializes a value to CBOR using the same encode mode as the serializer. No
+// self-describe tag prefix is added.
+func Marshal(src interface{}) ([]byte, error) {
+	return modes.Encode.Marshal(src)
+}
+
+// Unmarshal deserializes a single CBOR document into a value using the same decode mode
+// as the serializer.
+func Unmarshal(src []byte, dst interface{}) error {
+	return modes.Decode.Unmarshal(src, dst)
+}
+
+// Equal reports whether two CBOR documents encode semantically equal values. Map entry
+// order and numeric representation width are not significant, so two documents compare
+// equal if and only if decoding both would produce equal values. Containers are compared
+// structurally without first being converted to Go maps and slices; only leaf values are
+// decoded. An error is returned if either input is not a single well-formed document
+// acceptable to the serializer's decode modes.
+func Equal(a, b []byte) (bool, error) {
+	a = bytes.TrimPrefix(a, selfDescribedCBOR)
+	b = bytes.TrimPrefix(b, selfDescribedCBOR)
+
+	if bytes.Equal(a, b) {
+		// Identical bytes decode identically. Well-formedness still needs to be
+		// checked to honor the error contract.
+		var tmp interface{}
+		if err := modes.Decode.Unmarshal(a, &tmp); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	return equal(a, b)
+}
+
+func equal(a, b []byte) (bool, error) {
+	if len(a) == 0 || len(b) == 0 {
+		return false, fmt.Errorf("cbor: cannot compare empty document")
+	}
+
+	const (
+		majorTypeArray = 4
+		majorTypeMap   = 5
+	)
+	aMajor, bMajor := a[0]>>5, b[0]>>5
+
+	switch {
+	case aMajor == majorTypeMap || bMajor == majorTypeMap:
+		if aMajor != bMajor {
+			// A map is never equal to a value of any other type.
+			return false, nil
+		}
+		return equalMaps(a, b)
+	case aMajor == majorTypeArray || bMajor == majorTypeArray:
+		if aMajor != bMajor {
+			return false, nil
+		}
+		return equalArrays(a, b)
+	}
+
+	return equalScalars(a, b)
+}
+
+func equalMaps(a, b []byte) (bool, error) {
+	var am, bm map[string]cbor.RawMessage
+	if err := modes.Decode.Unmarshal(a, &am); err != nil {
+		return false, err
+	}
+	if err := modes.Decode.Unmarshal(b, &bm); err != nil {
+		return false, err
+	}
+	if len(am) != len(bm) {
+		return false, nil
+	}
+	for key, av := range am {
+		bv, ok := bm[key]
+		if !ok {
+			return false, nil
+		}
+		if eq, err := equal(av, bv); err != nil || !eq {
+			return eq, err
+		}
+	}
+	return true, nil
+}
+
+func equalArrays(a, b []byte) (bool, error) {
+	var as, bs []cbor.RawMessage
+	if err := modes.Decode.Unmarshal(a, &as); err != nil {
+		return false, err
+	}
+	if err := modes.Decode.Unmarshal(b, &bs); err != nil {
+		return false, err
+	}
+	if len(as) != len(bs) {
+		return false, nil
+	}
+	for i := range as {
+		if eq, err := equal(as[i], bs[i]); err != nil || !eq {
+			return eq, err
+		}
+	}
+	return true, nil
+}
+
+func equalScalars(a, b []byte) (bool, error) {
+	var av, bv interface{}
+	if err := modes.Decode.Unmarshal(a, &av); err != nil {
+		return false, err
+	}
+	if err := modes.Decode.Unmarshal(b, &bv); err != nil {
+		return false, err
+	}
+
+	// The decode modes produce int64 for all integers and float64 for all
+	// floating-point values, so representation width differences have already been
+	// erased. Integers and floating-point values that represent the same number
+	// compare equal.
+	switch a := av.(type) {
+	case int64:
+		switch b := bv.(type) {
+		case int64:
+			return a == b, nil
+		case float64:
+			return float64(a) == b, nil
+		}
+		return false, nil
+	case float64:
+		switch b := bv.(type) {
+		case int64:
+			return a == float64(b), nil
+		case float64:
+			return a == b, nil
+		}
+		return false, nil
+	case []byte:
+		b, ok := bv.([]byte)
+		return ok && bytes.Equal(a, b), nil
+	}
+
+	return av == bv, nil
+}
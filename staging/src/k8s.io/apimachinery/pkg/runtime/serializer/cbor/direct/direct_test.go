@@ -0,0 +1,142 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package direct
+
+import (
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		a       []byte
+		b       []byte
+		want    bool
+		wantErr bool
+	}{
+		{
+			// Identical inputs that the decode modes reject must error rather
+			// than compare equal.
+			name:    "identical rejected documents",
+			a:       []byte{0xa1, 0x41, 'a', 0x01}, // map with forbidden byte string key
+			b:       []byte{0xa1, 0x41, 'a', 0x01},
+			wantErr: true,
+		},
+		{
+			name: "identical documents",
+			a:    []byte{0xa1, 0x61, 'a', 0x01}, // {"a": 1}
+			b:    []byte{0xa1, 0x61, 'a', 0x01},
+			want: true,
+		},
+		{
+			name: "self-described prefix ignored",
+			a:    []byte{0xd9, 0xd9, 0xf7, 0xa1, 0x61, 'a', 0x01},
+			b:    []byte{0xa1, 0x61, 'a', 0x01},
+			want: true,
+		},
+		{
+			name: "map entry order insignificant",
+			a:    []byte{0xa2, 0x61, 'a', 0x01, 0x61, 'b', 0x02}, // {"a": 1, "b": 2}
+			b:    []byte{0xa2, 0x61, 'b', 0x02, 0x61, 'a', 0x01}, // {"b": 2, "a": 1}
+			want: true,
+		},
+		{
+			name: "integer width insignificant",
+			a:    []byte{0x01},             // 1
+			b:    []byte{0x19, 0x00, 0x01}, // 1 as uint16
+			want: true,
+		},
+		{
+			name: "integer equals equivalent float",
+			a:    []byte{0x01},             // 1
+			b:    []byte{0xf9, 0x3c, 0x00}, // 1.0 as float16
+			want: true,
+		},
+		{
+			name: "unequal integers",
+			a:    []byte{0x01},
+			b:    []byte{0x02},
+			want: false,
+		},
+		{
+			name: "nested container difference",
+			a:    []byte{0xa1, 0x61, 'a', 0x82, 0x01, 0x02}, // {"a": [1, 2]}
+			b:    []byte{0xa1, 0x61, 'a', 0x82, 0x01, 0x03}, // {"a": [1, 3]}
+			want: false,
+		},
+		{
+			name: "array length difference",
+			a:    []byte{0x82, 0x01, 0x02}, // [1, 2]
+			b:    []byte{0x81, 0x01},       // [1]
+			want: false,
+		},
+		{
+			name: "map key difference",
+			a:    []byte{0xa1, 0x61, 'a', 0x01}, // {"a": 1}
+			b:    []byte{0xa1, 0x61, 'b', 0x01}, // {"b": 1}
+			want: false,
+		},
+		{
+			name: "map not equal to array",
+			a:    []byte{0xa0}, // {}
+			b:    []byte{0x80}, // []
+			want: false,
+		},
+		{
+			name: "string not equal to byte string",
+			a:    []byte{0x61, 'a'},
+			b:    []byte{0x41, 'a'},
+			want: false,
+		},
+		{
+			name:    "malformed input",
+			a:       []byte{0xa1, 0x61, 'a'}, // truncated map
+			b:       []byte{0xa0},
+			wantErr: true,
+		},
+		{
+			name:    "trailing data",
+			a:       []byte{0x01, 0x01},
+			b:       []byte{0x01},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Equal(tc.a, tc.b)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && got != tc.want {
+				t.Errorf("Equal() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEqualSymmetric(t *testing.T) {
+	a := []byte{0xa2, 0x61, 'a', 0x01, 0x61, 'b', 0xf9, 0x40, 0x00}                   // {"a": 1, "b": 2.0}
+	b := []byte{0xa2, 0x61, 'b', 0x02, 0x61, 'a', 0xfb, 0x3f, 0xf0, 0, 0, 0, 0, 0, 0} // {"b": 2, "a": 1.0 as float64}
+	for _, pair := range [][2][]byte{{a, b}, {b, a}} {
+		eq, err := Equal(pair[0], pair[1])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !eq {
+			t.Errorf("expected documents to compare equal")
+		}
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package direct
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+)
+
+// Hash returns a 64-bit FNV-1a hash of the canonical CBOR encoding of the provided
+// value. The encoding is streamed directly into the hash function, so no copy of the
+// document is materialized. The encode mode produces exactly one encoding for a given
+// value, so values that would encode identically always produce the same hash. The hash
+// is not hardened against collision attacks and must not be used where an adversary
+// controls the input and a collision has security consequences.
+func Hash(v interface{}) (uint64, error) {
+	// The interface is asserted structurally rather than as runtime.Unstructured so
+	// that this package can be imported from the runtime package without a cycle.
+	if u, ok := v.(interface{ UnstructuredContent() map[string]interface{} }); ok {
+		// Hash the content so that the result does not vary with the concrete type
+		// wrapping it, for parity with how the serializer encodes unstructured
+		// objects.
+		v = u.UnstructuredContent()
+	}
+	h := fnv.New64a()
+	if err := modes.Encode.NewEncoder(h).Encode(v); err != nil {
+		return 0, err
+	}
+	return h.Sum64(), nil
+}
+
+// Fingerprint returns the hex-encoded SHA-256 digest of the canonical CBOR encoding of
+// the provided value. As with Hash, the encoding is streamed into the hash function
+// without materializing a copy of the document, and values that would encode identically
+// always produce the same fingerprint. Unlike Hash, the digest is collision-resistant,
+// so it is suitable for keying caches and deduplicating values that an adversary may
+// influence.
+func Fingerprint(v interface{}) (string, error) {
+	if u, ok := v.(interface{ UnstructuredContent() map[string]interface{} }); ok {
+		v = u.UnstructuredContent()
+	}
+	h := sha256.New()
+	if err := modes.Encode.NewEncoder(h).Encode(v); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package direct_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+)
+
+func TestHash(t *testing.T) {
+	type object struct {
+		A string `json:"a"`
+		B int64  `json:"b"`
+	}
+
+	a, err := direct.Hash(object{A: "x", B: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	same, err := direct.Hash(object{A: "x", B: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a != same {
+		t.Errorf("equal objects produced unequal hashes: %x != %x", a, same)
+	}
+	different, err := direct.Hash(object{A: "x", B: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a == different {
+		t.Errorf("unequal objects produced equal hash: %x", a)
+	}
+}
+
+func TestHashEquivalentRepresentations(t *testing.T) {
+	// The typed object and the equivalent unstructured content encode to the same
+	// document, so they must hash identically whether or not the content is wrapped in
+	// an unstructured object.
+	type object struct {
+		A string `json:"a"`
+		B int64  `json:"b"`
+	}
+	typed, err := direct.Hash(object{A: "x", B: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content := map[string]interface{}{"a": "x", "b": int64(1)}
+	fromMap, err := direct.Hash(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fromUnstructured, err := direct.Hash(&unstructured.Unstructured{Object: content})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if typed != fromMap || fromMap != fromUnstructured {
+		t.Errorf("equivalent representations produced unequal hashes: %x, %x, %x", typed, fromMap, fromUnstructured)
+	}
+}
+
+func TestHashError(t *testing.T) {
+	if _, err := direct.Hash(func() {}); err == nil {
+		t.Error("expected error hashing unsupported type")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	type object struct {
+		A string `json:"a"`
+		B int64  `json:"b"`
+	}
+
+	a, err := direct.Fingerprint(object{A: "x", B: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(a) != 64 {
+		t.Errorf("expected a 64-character hex digest, got %q", a)
+	}
+	same, err := direct.Fingerprint(object{A: "x", B: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a != same {
+		t.Errorf("equal objects produced unequal fingerprints: %s != %s", a, same)
+	}
+	fromMap, err := direct.Fingerprint(map[string]interface{}{"a": "x", "b": int64(1)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fromUnstructured, err := direct.Fingerprint(&unstructured.Unstructured{Object: map[string]interface{}{"a": "x", "b": int64(1)}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a != fromMap || fromMap != fromUnstructured {
+		t.Errorf("equivalent representations produced unequal fingerprints: %s, %s, %s", a, fromMap, fromUnstructured)
+	}
+	different, err := direct.Fingerprint(object{A: "x", B: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a == different {
+		t.Errorf("unequal objects produced equal fingerprint: %s", a)
+	}
+	if _, err := direct.Fingerprint(func() {}); err == nil {
+		t.Error("expected error fingerprinting unsupported type")
+	}
+}
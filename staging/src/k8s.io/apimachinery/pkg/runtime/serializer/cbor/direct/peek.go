@@ -0,0 +1,357 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package direct
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// maxMetaFieldLength bounds the type-identifying strings PeekTypeMeta will read.
+	// The longest apiVersion the API machinery accepts is a 253-character group, a
+	// separator, and a 63-character version, and kinds are far shorter, so a longer
+	// value cannot name a registered type. PeekTypeMeta runs before requests can be
+	// rejected for any reason that depends on their content, so it must not be a
+	// vehicle for outsized allocation.
+	maxMetaFieldLength = 512
+
+	// peekMaxNestedLevels mirrors the nesting limit the decode modes enforce, so that
+	// PeekTypeMeta never follows a document deeper than a subsequent decode would.
+	peekMaxNestedLevels = 32
+)
+
+// MetaFieldLengthError is returned by PeekTypeMeta when the apiVersion or kind field of
+// a document is longer than it will read.
+type MetaFieldLengthError struct {
+	Field string
+	Limit int
+}
+
+func (e *MetaFieldLengthError) Error() string {
+	return fmt.Sprintf("%s exceeds the maximum length of %d bytes", e.Field, e.Limit)
+}
+
+// MetaFieldTypeError is returned by PeekTypeMeta when the apiVersion or kind field of a
+// document is present but is not a text string.
+type MetaFieldTypeError struct {
+	Field  string
+	Actual string
+}
+
+func (e *MetaFieldTypeError) Error() string {
+	return fmt.Sprintf("%s must be a string, not %s", e.Field, e.Actual)
+}
+
+// PeekTypeMeta returns the group, version, and kind declared by the apiVersion and kind
+// entries of the top-level map of a single CBOR-encoded object. Only the heads of the
+// top-level map entries and the two type-identifying strings are examined — no map is
+// materialized — so the cost is bounded by the size of the document rather than by
+// anything an adversarial head claims. Documents may carry the self-describe tag prefix
+// the serializer emits.
+func PeekTypeMeta(data []byte) (schema.GroupVersionKind, error) {
+	apiVersion, kind, err := peekTypeMeta(data)
+	switch {
+	case err == nil:
+	case errors.As(err, new(*MetaFieldLengthError)), errors.As(err, new(*MetaFieldTypeError)):
+		return schema.GroupVersionKind{}, err
+	default:
+		return schema.GroupVersionKind{}, fmt.Errorf("couldn't get version/kind; cbor parse error: %v", err)
+	}
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	return gv.WithKind(kind), nil
+}
+
+// peekTypeMeta scans the top-level map of a document for the "apiVersion" and "kind"
+// entries, skipping over everything else without materializing it.
+func peekTypeMeta(data []byte) (apiVersion, kind string, err error) {
+	if bytes.HasPrefix(data, selfDescribedCBOR) {
+		data = data[len(selfDescribedCBOR):]
+	}
+	sc := &metaScanner{data: data}
+
+	major, info, value, err := sc.readHead()
+	if err != nil {
+		return "", "", err
+	}
+	if major != metaMajorMap {
+		return "", "", errors.New("top-level value is not a map")
+	}
+
+	entry := func() error {
+		keyMajor, keyInfo, keyValue, err := sc.readHead()
+		if err != nil {
+			return err
+		}
+		if keyMajor != metaMajorTextString {
+			return errors.New("map key is not a string")
+		}
+		key, ok, err := sc.readTextString(keyInfo, keyValue, len("apiVersion"))
+		if err != nil {
+			return err
+		}
+		if !ok || (key != "apiVersion" && key != "kind") {
+			return sc.skipItem(0)
+		}
+
+		valueMajor, valueInfo, valueValue, err := sc.readHead()
+		if err != nil {
+			return err
+		}
+		if valueMajor == metaMajorSimple && valueInfo == metaSimpleNull {
+			// A null is interpreted as an absent field, as it would be when
+			// decoding into a string field.
+			return nil
+		}
+		if valueMajor != metaMajorTextString {
+			return &MetaFieldTypeError{Field: key, Actual: describeMetaItem(valueMajor, valueInfo)}
+		}
+		content, ok, err := sc.readTextString(valueInfo, valueValue, maxMetaFieldLength)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return &MetaFieldLengthError{Field: key, Limit: maxMetaFieldLength}
+		}
+		if key == "apiVersion" {
+			apiVersion = content
+		} else {
+			kind = content
+		}
+		return nil
+	}
+
+	if info == metaInfoIndefinite {
+		for !sc.atBreak() {
+			if err := entry(); err != nil {
+				return "", "", err
+			}
+		}
+	} else {
+		for i := uint64(0); i < value; i++ {
+			if err := entry(); err != nil {
+				return "", "", err
+			}
+		}
+	}
+
+	if sc.off != len(sc.data) {
+		return "", "", errors.New("extraneous data after the document")
+	}
+	return apiVersion, kind, nil
+}
+
+const (
+	metaMajorUnsigned   = 0
+	metaMajorNegative   = 1
+	metaMajorByteString = 2
+	metaMajorTextString = 3
+	metaMajorArray      = 4
+	metaMajorMap        = 5
+	metaMajorTag        = 6
+	metaMajorSimple     = 7
+
+	metaInfoIndefinite = 31
+	metaSimpleNull     = 22
+)
+
+// metaScanner reads CBOR items sequentially from a byte slice, understanding just enough
+// of RFC 8949 framing to find item boundaries.
+type metaScanner struct {
+	data []byte
+	off  int
+}
+
+func (sc *metaScanner) readHead() (major, info byte, value uint64, err error) {
+	if sc.off >= len(sc.data) {
+		return 0, 0, 0, fmt.Errorf("unexpected end of data at offset %d", sc.off)
+	}
+	initial := sc.data[sc.off]
+	sc.off++
+	major = initial >> 5
+	info = initial & 0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info >= 24 && info <= 27:
+		n := 1 << (info - 24)
+		if sc.off+n > len(sc.data) {
+			return 0, 0, 0, fmt.Errorf("unexpected end of data at offset %d", sc.off)
+		}
+		for i := 0; i < n; i++ {
+			value = value<<8 | uint64(sc.data[sc.off+i])
+		}
+		sc.off += n
+		return major, info, value, nil
+	case info == metaInfoIndefinite:
+		return major, info, 0, nil
+	default:
+		return 0, 0, 0, fmt.Errorf("reserved additional information %d at offset %d", info, sc.off-1)
+	}
+}
+
+func (sc *metaScanner) atBreak() bool {
+	if sc.off < len(sc.data) && sc.data[sc.off] == 0xff {
+		sc.off++
+		return true
+	}
+	return false
+}
+
+func (sc *metaScanner) skipItem(depth int) error {
+	major, info, value, err := sc.readHead()
+	if err != nil {
+		return err
+	}
+	return sc.skipBody(major, info, value, depth)
+}
+
+func (sc *metaScanner) skipBody(major, info byte, value uint64, depth int) error {
+	if depth > peekMaxNestedLevels {
+		return fmt.Errorf("exceeded max depth of %d", peekMaxNestedLevels)
+	}
+	switch major {
+	case metaMajorUnsigned, metaMajorNegative:
+		return nil
+	case metaMajorByteString, metaMajorTextString:
+		if info == metaInfoIndefinite {
+			for !sc.atBreak() {
+				chunkMajor, chunkInfo, chunkLen, err := sc.readHead()
+				if err != nil {
+					return err
+				}
+				if chunkMajor != major || chunkInfo == metaInfoIndefinite {
+					return fmt.Errorf("invalid chunk inside indefinite-length string at offset %d", sc.off)
+				}
+				if err := sc.skipBytes(chunkLen); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return sc.skipBytes(value)
+	case metaMajorArray, metaMajorMap:
+		items := value
+		if major == metaMajorMap {
+			items *= 2
+		}
+		if info == metaInfoIndefinite {
+			for !sc.atBreak() {
+				if err := sc.skipItem(depth + 1); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for i := uint64(0); i < items; i++ {
+			if err := sc.skipItem(depth + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case metaMajorTag:
+		// The decode modes forbid all tags, so a document containing one can never
+		// decode successfully and there is nothing useful to interpret.
+		return fmt.Errorf("tag %d is not permitted", value)
+	default: // metaMajorSimple
+		if info == metaInfoIndefinite {
+			return fmt.Errorf("unexpected break code at offset %d", sc.off-1)
+		}
+		return nil
+	}
+}
+
+func (sc *metaScanner) skipBytes(n uint64) error {
+	if n > uint64(len(sc.data)-sc.off) {
+		return fmt.Errorf("unexpected end of data at offset %d", sc.off)
+	}
+	sc.off += int(n)
+	return nil
+}
+
+// readTextString returns the content of a text string whose head has already been read,
+// or ok false, with the string skipped but unread, when its length exceeds limit. The
+// limit is enforced before any content is copied, so the caller never allocates more
+// than limit bytes no matter what length a head declares.
+func (sc *metaScanner) readTextString(info byte, value uint64, limit int) (content string, ok bool, err error) {
+	if info != metaInfoIndefinite {
+		if value > uint64(limit) {
+			return "", false, sc.skipBytes(value)
+		}
+		start := sc.off
+		if err := sc.skipBytes(value); err != nil {
+			return "", false, err
+		}
+		return string(sc.data[start:sc.off]), true, nil
+	}
+	var buf []byte
+	exceeded := false
+	for !sc.atBreak() {
+		chunkMajor, chunkInfo, chunkLen, err := sc.readHead()
+		if err != nil {
+			return "", false, err
+		}
+		if chunkMajor != metaMajorTextString || chunkInfo == metaInfoIndefinite {
+			return "", false, fmt.Errorf("invalid chunk inside indefinite-length string at offset %d", sc.off)
+		}
+		start := sc.off
+		if err := sc.skipBytes(chunkLen); err != nil {
+			return "", false, err
+		}
+		if exceeded || uint64(len(buf))+chunkLen > uint64(limit) {
+			exceeded = true
+			continue
+		}
+		buf = append(buf, sc.data[start:sc.off]...)
+	}
+	if exceeded {
+		return "", false, nil
+	}
+	return string(buf), true, nil
+}
+
+// describeMetaItem names the kind of item a head introduces, for use in error messages
+// in place of a value that was never materialized.
+func describeMetaItem(major, info byte) string {
+	switch major {
+	case metaMajorUnsigned, metaMajorNegative:
+		return "an integer"
+	case metaMajorByteString:
+		return "a byte string"
+	case metaMajorArray:
+		return "an array"
+	case metaMajorMap:
+		return "a map"
+	case metaMajorTag:
+		return "a tag"
+	default:
+		switch info {
+		case 20, 21:
+			return "a boolean"
+		case 25, 26, 27:
+			return "a float"
+		default:
+			return "a simple value"
+		}
+	}
+}
@@ -0,0 +1,326 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package direct
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// marshalPermissive encodes documents the canonical encode mode would refuse to produce,
+// for tests that exercise inputs this package must accept or reject but never emits.
+func marshalPermissive(t *testing.T, v interface{}) []byte {
+	em, err := cbor.EncOptions{}.EncMode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := em.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestPeekTypeMeta(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		doc  interface{} // marshaled unless raw is set
+		raw  []byte
+		gvk  schema.GroupVersionKind
+	}{
+		{
+			name: "group, version, and kind",
+			doc: map[string]interface{}{
+				"apiVersion": "test.group/v1",
+				"kind":       "Simple",
+				"metadata":   map[string]interface{}{"name": "x"},
+			},
+			gvk: schema.GroupVersionKind{Group: "test.group", Version: "v1", Kind: "Simple"},
+		},
+		{
+			name: "legacy group",
+			doc:  map[string]interface{}{"apiVersion": "v1", "kind": "Pod"},
+			gvk:  schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		},
+		{
+			name: "absent fields",
+			doc:  map[string]interface{}{"spec": map[string]interface{}{}},
+		},
+		{
+			name: "null fields",
+			doc:  map[string]interface{}{"apiVersion": nil, "kind": nil},
+		},
+		{
+			name: "self-describe prefix",
+			raw: append(append([]byte{}, selfDescribedCBOR...), []byte{
+				0xa1, 0x64, 'k', 'i', 'n', 'd', 0x63, 'P', 'o', 'd'}...), // {"kind": "Pod"}
+			gvk: schema.GroupVersionKind{Kind: "Pod"},
+		},
+		{
+			// {_ "kind": (_ "Po" "d")}
+			name: "indefinite-length encodings",
+			raw:  []byte{0xbf, 0x64, 'k', 'i', 'n', 'd', 0x7f, 0x62, 'P', 'o', 0x61, 'd', 0xff, 0xff},
+			gvk:  schema.GroupVersionKind{Kind: "Pod"},
+		},
+		{
+			name: "unrelated long values are skipped, not read",
+			doc: map[string]interface{}{
+				"kind": "Simple",
+				"data": strings.Repeat("x", 4*maxMetaFieldLength),
+			},
+			gvk: schema.GroupVersionKind{Kind: "Simple"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data := tc.raw
+			if data == nil {
+				data = marshalPermissive(t, tc.doc)
+			}
+			gvk, err := PeekTypeMeta(data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gvk != tc.gvk {
+				t.Errorf("expected gvk %#v, got %#v", tc.gvk, gvk)
+			}
+		})
+	}
+}
+
+func TestPeekTypeMetaRejectsOversizedMetaFields(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		raw  []byte
+	}{
+		{
+			name: "oversized kind",
+			raw: marshalPermissive(t, map[string]interface{}{
+				"kind": strings.Repeat("x", maxMetaFieldLength+1),
+			}),
+		},
+		{
+			// The head claims 2^32 bytes that the document does not carry. The
+			// length check must come before any attempt to read the content.
+			name: "claimed length exceeds document",
+			raw:  []byte{0xa1, 0x64, 'k', 'i', 'n', 'd', 0x7a, 0xff, 0xff, 0xff, 0xff},
+		},
+		{
+			name: "oversized indefinite-length apiVersion",
+			raw: append(append([]byte{
+				0xa1, 0x6a, 'a', 'p', 'i', 'V', 'e', 'r', 's', 'i', 'o', 'n', 0x7f,
+				0x79, 0x02, 0x01}, // one definite chunk of maxMetaFieldLength+1 bytes
+				[]byte(strings.Repeat("x", maxMetaFieldLength+1))...),
+				0xff),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := PeekTypeMeta(tc.raw)
+			lengthErr := new(*MetaFieldLengthError)
+			if errors.As(err, lengthErr) {
+				if (*lengthErr).Limit != maxMetaFieldLength {
+					t.Errorf("expected limit %d in error, got %d", maxMetaFieldLength, (*lengthErr).Limit)
+				}
+				return
+			}
+			// A claimed length the document cannot satisfy is a parse error rather
+			// than a length error, but must never be an allocation.
+			if err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestPeekTypeMetaRejectsNonStringMetaFields(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		doc   interface{}
+		field string
+	}{
+		{
+			name:  "integer kind",
+			doc:   map[string]interface{}{"kind": int64(7)},
+			field: "kind",
+		},
+		{
+			name:  "map apiVersion",
+			doc:   map[string]interface{}{"apiVersion": map[string]interface{}{}},
+			field: "apiVersion",
+		},
+		{
+			name:  "array kind",
+			doc:   map[string]interface{}{"kind": []interface{}{"Pod"}},
+			field: "kind",
+		},
+		{
+			name:  "boolean apiVersion",
+			doc:   map[string]interface{}{"apiVersion": true},
+			field: "apiVersion",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := PeekTypeMeta(marshalPermissive(t, tc.doc))
+			typeErr := new(*MetaFieldTypeError)
+			if !errors.As(err, typeErr) {
+				t.Fatalf("expected MetaFieldTypeError, got %v", err)
+			}
+			if (*typeErr).Field != tc.field {
+				t.Errorf("expected error for field %q, got %q", tc.field, (*typeErr).Field)
+			}
+		})
+	}
+}
+
+func TestPeekTypeMetaMalformedDocuments(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		raw  []byte
+	}{
+		{name: "empty", raw: []byte{}},
+		{name: "not a map", raw: []byte{0x01}},
+		{name: "truncated", raw: []byte{0xa1, 0x64, 'k', 'i', 'n'}},
+		{name: "tagged value", raw: []byte{0xa1, 0x61, 'a', 0xc0, 0x01}},
+		{name: "trailing data", raw: []byte{0xa0, 0x00}},
+		{name: "reserved additional information", raw: []byte{0xfc}},
+		{name: "break outside indefinite item", raw: []byte{0xa1, 0x61, 'a', 0xff}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := PeekTypeMeta(tc.raw); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+// TestPeekTypeMetaAgreesWithDecode feeds randomly-generated documents to PeekTypeMeta and
+// to a reference implementation in terms of a full decode, and requires that both either
+// fail or succeed with the same result. The seed is fixed so that a failure can be
+// reproduced.
+func TestPeekTypeMetaAgreesWithDecode(t *testing.T) {
+	const seed = 1587
+	r := rand.New(rand.NewSource(seed))
+
+	em, err := cbor.EncOptions{}.EncMode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiVersions := []interface{}{
+		nil,
+		"",
+		"v1",
+		"test.group/v1",
+		"a/b/c", // too many slashes to parse as a GroupVersion
+		"испытание/v1",
+		int64(7),
+		true,
+		[]interface{}{"v1"},
+		map[string]interface{}{"group": "test.group"},
+	}
+	kinds := []interface{}{
+		nil,
+		"",
+		"Pod",
+		"Ωmega",
+		int64(-1),
+		false,
+		[]interface{}{},
+	}
+	keys := []string{"apiVersion", "kind", "metadata", "spec", "status", "items", "x"}
+
+	// genValue returns a value for an entry that is not type-identifying. Values never
+	// exceed the nesting limit and string lengths stay far below the field length
+	// limit, since limit behaviors are pinned by the deterministic tests above.
+	var genValue func(depth int) interface{}
+	genValue = func(depth int) interface{} {
+		switch n := r.Intn(8); {
+		case n == 0 && depth < 4:
+			m := map[string]interface{}{}
+			for i := r.Intn(3); i > 0; i-- {
+				m[keys[r.Intn(len(keys))]] = genValue(depth + 1)
+			}
+			return m
+		case n == 1 && depth < 4:
+			var s []interface{}
+			for i := r.Intn(3); i > 0; i-- {
+				s = append(s, genValue(depth+1))
+			}
+			return s
+		case n == 2:
+			return nil
+		case n == 3:
+			return r.Intn(2) == 0
+		case n == 4:
+			return r.Int63n(1 << 40)
+		default:
+			return strings.Repeat("x", r.Intn(64))
+		}
+	}
+
+	for i := 0; i < 1000; i++ {
+		// Every document the serializer produces has a map at the top level, so the
+		// generated documents do too. Non-map top-level items are pinned above.
+		doc := map[string]interface{}{}
+		if r.Intn(4) > 0 {
+			doc["apiVersion"] = apiVersions[r.Intn(len(apiVersions))]
+		}
+		if r.Intn(4) > 0 {
+			doc["kind"] = kinds[r.Intn(len(kinds))]
+		}
+		for n := r.Intn(3); n > 0; n-- {
+			doc[keys[r.Intn(len(keys))]] = genValue(0)
+		}
+
+		data, err := em.Marshal(doc)
+		if err != nil {
+			t.Fatalf("failed to marshal generated document %#v: %v", doc, err)
+		}
+
+		expected, expectedErr := decodeTypeMeta(data)
+		actual, actualErr := PeekTypeMeta(data)
+		if (expectedErr == nil) != (actualErr == nil) {
+			t.Fatalf("document %#v: decode returned (%v, %v), peek returned (%v, %v)", doc, expected, expectedErr, actual, actualErr)
+		}
+		if expectedErr == nil && actual != expected {
+			t.Fatalf("document %#v: decode returned gvk %#v, peek returned %#v", doc, expected, actual)
+		}
+	}
+}
+
+// decodeTypeMeta is a reference implementation of PeekTypeMeta that performs a complete
+// decode of the document.
+func decodeTypeMeta(data []byte) (schema.GroupVersionKind, error) {
+	var tm struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}
+	if err := modes.Decode.Unmarshal(data, &tm); err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	gv, err := schema.ParseGroupVersion(tm.APIVersion)
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	return gv.WithKind(tm.Kind), nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+)
+
+// emptinessFields exercises every combination that matters for the nil-versus-empty
+// policy: plain and omitempty collections of both kinds.
+type emptinessFields struct {
+	Slice          []string          `json:"slice"`
+	Map            map[string]string `json:"map"`
+	OmitEmptySlice []string          `json:"omitEmptySlice,omitempty"`
+	OmitEmptyMap   map[string]string `json:"omitEmptyMap,omitempty"`
+}
+
+// TestNilVersusEmptyCollectionParity enforces the policy that the JSON and CBOR encoders
+// make identical nil-versus-empty decisions: a nil slice or map encodes as null, an empty
+// one encodes as an empty container, and omitempty drops both. Controllers compare
+// serialized forms for change detection, so an object that encodes one way in JSON and
+// another way in CBOR would be reported as changed when nothing changed at all.
+func TestNilVersusEmptyCollectionParity(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		in       emptinessFields
+		wantJSON string
+		// wantCBOR is the expected encoding in CBOR diagnostic-ish terms: the exact
+		// bytes of the document body, which is small enough to spell out. 0xf6 is
+		// null, 0x80 is an empty array, and 0xa0 is an empty map.
+		wantCBOR []byte
+	}{
+		{
+			name:     "nil collections",
+			in:       emptinessFields{},
+			wantJSON: `{"slice":null,"map":null}`,
+			wantCBOR: []byte{
+				0xa2, // map of 2 pairs
+				0x63, 'm', 'a', 'p', 0xf6,
+				0x65, 's', 'l', 'i', 'c', 'e', 0xf6,
+			},
+		},
+		{
+			name: "empty collections",
+			in: emptinessFields{
+				Slice:          []string{},
+				Map:            map[string]string{},
+				OmitEmptySlice: []string{},
+				OmitEmptyMap:   map[string]string{},
+			},
+			wantJSON: `{"slice":[],"map":{}}`,
+			wantCBOR: []byte{
+				0xa2,
+				0x63, 'm', 'a', 'p', 0xa0,
+				0x65, 's', 'l', 'i', 'c', 'e', 0x80,
+			},
+		},
+		{
+			name: "populated omitempty collections",
+			in: emptinessFields{
+				Slice:          []string{"a"},
+				Map:            map[string]string{"k": "v"},
+				OmitEmptySlice: []string{"a"},
+				OmitEmptyMap:   map[string]string{"k": "v"},
+			},
+			wantJSON: `{"slice":["a"],"map":{"k":"v"},"omitEmptySlice":["a"],"omitEmptyMap":{"k":"v"}}`,
+			wantCBOR: []byte{
+				0xa4,
+				0x63, 'm', 'a', 'p', 0xa1, 0x61, 'k', 0x61, 'v',
+				0x65, 's', 'l', 'i', 'c', 'e', 0x81, 0x61, 'a',
+				0x6c, 'o', 'm', 'i', 't', 'E', 'm', 'p', 't', 'y', 'M', 'a', 'p', 0xa1, 0x61, 'k', 0x61, 'v',
+				0x6e, 'o', 'm', 'i', 't', 'E', 'm', 'p', 't', 'y', 'S', 'l', 'i', 'c', 'e', 0x81, 0x61, 'a',
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gotJSON, err := json.Marshal(tc.in)
+			if err != nil {
+				t.Fatalf("unexpected error encoding json: %v", err)
+			}
+			// The JSON expectation is compared semantically since encoding/json
+			// emits fields in declaration order rather than sorted order.
+			var want, got interface{}
+			if err := json.Unmarshal([]byte(tc.wantJSON), &want); err != nil {
+				t.Fatalf("unexpected error decoding expected json: %v", err)
+			}
+			if err := json.Unmarshal(gotJSON, &got); err != nil {
+				t.Fatalf("unexpected error decoding actual json: %v", err)
+			}
+			if !jsonSemanticallyEqual(want, got) {
+				t.Errorf("expected json %s, got %s", tc.wantJSON, gotJSON)
+			}
+
+			gotCBOR, err := modes.Encode.Marshal(tc.in)
+			if err != nil {
+				t.Fatalf("unexpected error encoding cbor: %v", err)
+			}
+			if !bytes.Equal(tc.wantCBOR, gotCBOR) {
+				t.Errorf("expected cbor\n% x\ngot\n% x", tc.wantCBOR, gotCBOR)
+			}
+		})
+	}
+}
+
+// jsonSemanticallyEqual compares decoded JSON values, treating null, absent, and empty as
+// three distinct states as the parity test requires.
+func jsonSemanticallyEqual(a, b interface{}) bool {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, av := range a {
+			bv, present := b[k]
+			if !present || !jsonSemanticallyEqual(av, bv) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !jsonSemanticallyEqual(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"io"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/cborseq"
+	utilframer "k8s.io/apimachinery/pkg/util/framer"
+)
+
+// Framer is the CBOR framing behavior. Encoded CBOR documents are self-delimiting, so
+// frames are written as-is with no delimiter, per the CBOR Sequence format of RFC 8742,
+// and the frame reader splits the stream at document boundaries. Writing a frame adds no
+// per-frame work beyond the write itself, so a pre-encoded document can be fanned out to
+// many streams without being re-encoded.
+var Framer = framer{}
+
+type framer struct{}
+
+func (framer) NewFrameWriter(w io.Writer) io.Writer {
+	return w
+}
+
+func (framer) NewFrameReader(r io.ReadCloser) io.ReadCloser {
+	// The sequence reader recovers each frame byte for byte, including any tags it
+	// skipped over transparently while locating the boundary. Decoding the document is
+	// the consumer's responsibility, including enforcement of this package's tag policy.
+	return &frameReader{r: r, seq: cborseq.NewReader(r)}
+}
+
+type frameReader struct {
+	r         io.ReadCloser
+	seq       *cborseq.Reader
+	remaining []byte
+}
+
+// Read reads the next CBOR document in the stream into data. If data is too small to
+// contain an entire document, io.ErrShortBuffer is returned and subsequent calls return
+// the rest of the document. A frame is complete when err is nil.
+func (r *frameReader) Read(data []byte) (int, error) {
+	if len(r.remaining) == 0 {
+		frame, err := r.seq.Next()
+		if err != nil {
+			return 0, err
+		}
+		r.remaining = frame
+	}
+
+	n := copy(data, r.remaining)
+	r.remaining = r.remaining[n:]
+	if len(r.remaining) > 0 {
+		return n, io.ErrShortBuffer
+	}
+	return n, nil
+}
+
+func (r *frameReader) Close() error {
+	return r.r.Close()
+}
+
+// LengthDelimitedFramerName is the value of the "framing" media type parameter that
+// selects LengthDelimitedFramer instead of the default framing.
+const LengthDelimitedFramerName = "length-delimited"
+
+// LengthDelimitedFramer prefixes each frame with its length as a 4-byte, big-endian
+// unsigned integer, exactly like the protobuf framing behavior. Although CBOR documents
+// are self-delimiting and need no prefix to be split apart, an explicit length lets
+// intermediaries like proxies and replay tools carve up a stream without a CBOR parser.
+var LengthDelimitedFramer = lengthDelimitedFramer{}
+
+type lengthDelimitedFramer struct{}
+
+func (lengthDelimitedFramer) NewFrameWriter(w io.Writer) io.Writer {
+	return utilframer.NewLengthDelimitedFrameWriter(w)
+}
+
+func (lengthDelimitedFramer) NewFrameReader(r io.ReadCloser) io.ReadCloser {
+	return utilframer.NewLengthDelimitedFrameReader(r)
+}
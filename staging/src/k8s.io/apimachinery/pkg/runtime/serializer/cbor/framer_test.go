@@ -0,0 +1,225 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/streaming"
+	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
+)
+
+func TestFramerRoundTrip(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	objects := []runtime.Object{
+		&runtimetesting.ExternalSimple{TypeMeta: runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"}, TestString: "one"},
+		&runtimetesting.ExternalSimple{TypeMeta: runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"}, TestString: "two"},
+		&runtimetesting.ExternalSimple{TypeMeta: runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"}, TestString: "three"},
+	}
+
+	var stream bytes.Buffer
+	w := Framer.NewFrameWriter(&stream)
+	var frames [][]byte
+	for _, obj := range objects {
+		var buf bytes.Buffer
+		if err := s.Encode(obj, &buf); err != nil {
+			t.Fatalf("unexpected encode error: %v", err)
+		}
+		frames = append(frames, buf.Bytes())
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			t.Fatalf("unexpected frame write error: %v", err)
+		}
+	}
+
+	r := Framer.NewFrameReader(ioutil.NopCloser(bytes.NewReader(stream.Bytes())))
+	for i := range frames {
+		frame := make([]byte, len(frames[i]))
+		n, err := r.Read(frame)
+		if err != nil {
+			t.Fatalf("unexpected frame read error: %v", err)
+		}
+		if !bytes.Equal(frame[:n], frames[i]) {
+			t.Errorf("frame %d: got % x, want % x", i, frame[:n], frames[i])
+		}
+	}
+	if _, err := r.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected io.EOF after last frame, got %v", err)
+	}
+}
+
+func TestFramerShortBuffer(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	var buf bytes.Buffer
+	obj := &runtimetesting.ExternalSimple{TypeMeta: runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"}, TestString: "value"}
+	if err := s.Encode(obj, &buf); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+	want := buf.Bytes()
+
+	r := Framer.NewFrameReader(ioutil.NopCloser(bytes.NewReader(want)))
+	var got []byte
+	for {
+		chunk := make([]byte, 3)
+		n, err := r.Read(chunk)
+		got = append(got, chunk[:n]...)
+		if err == io.ErrShortBuffer {
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected frame read error: %v", err)
+		}
+		break
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("reassembled frame does not match original: got % x, want % x", got, want)
+	}
+}
+
+func TestLengthDelimitedFramerRoundTrip(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	objects := []runtime.Object{
+		&runtimetesting.ExternalSimple{TypeMeta: runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"}, TestString: "one"},
+		&runtimetesting.ExternalSimple{TypeMeta: runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"}, TestString: "two"},
+		&runtimetesting.ExternalSimple{TypeMeta: runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"}, TestString: "three"},
+	}
+
+	var stream bytes.Buffer
+	w := LengthDelimitedFramer.NewFrameWriter(&stream)
+	var frames [][]byte
+	total := 0
+	for _, obj := range objects {
+		var buf bytes.Buffer
+		if err := s.Encode(obj, &buf); err != nil {
+			t.Fatalf("unexpected encode error: %v", err)
+		}
+		frames = append(frames, buf.Bytes())
+		total += buf.Len()
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			t.Fatalf("unexpected frame write error: %v", err)
+		}
+	}
+
+	// each frame carries a 4-byte length prefix
+	if want := total + 4*len(frames); stream.Len() != want {
+		t.Errorf("expected stream of %d bytes, got %d", want, stream.Len())
+	}
+
+	r := LengthDelimitedFramer.NewFrameReader(ioutil.NopCloser(bytes.NewReader(stream.Bytes())))
+	for i := range frames {
+		frame := make([]byte, len(frames[i]))
+		n, err := r.Read(frame)
+		if err != nil {
+			t.Fatalf("unexpected frame read error: %v", err)
+		}
+		if !bytes.Equal(frame[:n], frames[i]) {
+			t.Errorf("frame %d: got % x, want % x", i, frame[:n], frames[i])
+		}
+	}
+	if _, err := r.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected io.EOF after last frame, got %v", err)
+	}
+}
+
+// countingCacheableObject implements runtime.CacheableObject with the same contract as
+// the apiserver's cachingObject: the first encode for a given identifier is computed and
+// cached, and subsequent encodes for that identifier write the cached bytes.
+type countingCacheableObject struct {
+	object  runtime.Object
+	cache   map[runtime.Identifier][]byte
+	encodes int
+}
+
+func (o *countingCacheableObject) CacheEncode(id runtime.Identifier, encode func(runtime.Object, io.Writer) error, w io.Writer) error {
+	if cached, ok := o.cache[id]; ok {
+		_, err := w.Write(cached)
+		return err
+	}
+	o.encodes++
+	var buf bytes.Buffer
+	if err := encode(o.object, &buf); err != nil {
+		return err
+	}
+	o.cache[id] = buf.Bytes()
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (o *countingCacheableObject) GetObject() runtime.Object {
+	return o.object.DeepCopyObject()
+}
+
+func (o *countingCacheableObject) GetObjectKind() schema.ObjectKind {
+	return o.object.GetObjectKind()
+}
+
+func (o *countingCacheableObject) DeepCopyObject() runtime.Object {
+	panic("countingCacheableObject is not deep-copied during encoding")
+}
+
+func TestFanOutEncodesOnce(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	obj := &countingCacheableObject{
+		object: &runtimetesting.ExternalSimple{TypeMeta: runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"}, TestString: "value"},
+		cache:  map[runtime.Identifier][]byte{},
+	}
+
+	// Fan the same event out to several watchers negotiating the same media type. The
+	// object must be encoded exactly once; each watcher only pays for framing.
+	const watchers = 5
+	streams := make([]*bytes.Buffer, watchers)
+	for i := range streams {
+		streams[i] = &bytes.Buffer{}
+		encoder := streaming.NewEncoder(Framer.NewFrameWriter(streams[i]), s)
+		if err := encoder.Encode(obj); err != nil {
+			t.Fatalf("unexpected encode error: %v", err)
+		}
+	}
+
+	if obj.encodes != 1 {
+		t.Errorf("expected exactly one encode across %d watchers, got %d", watchers, obj.encodes)
+	}
+	for i := 1; i < watchers; i++ {
+		if !bytes.Equal(streams[i].Bytes(), streams[0].Bytes()) {
+			t.Errorf("stream %d differs from stream 0", i)
+		}
+	}
+
+	// Every stream must still decode to the original object.
+	decoder := streaming.NewDecoder(Framer.NewFrameReader(ioutil.NopCloser(streams[0])), s)
+	defer decoder.Close()
+	decoded, _, err := decoder.Decode(nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, obj.object) {
+		t.Errorf("decoded object does not match original: got %#v", decoded)
+	}
+}
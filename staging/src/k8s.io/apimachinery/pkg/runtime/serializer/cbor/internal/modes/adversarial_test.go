@@ -0,0 +1,83 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modes_test
+
+import (
+	"bytes"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+)
+
+// TestDecodeDeclaredLengths verifies that container headers declaring enormous lengths are
+// rejected instead of being trusted for preallocation. A header alone can claim up to
+// 2^64-1 elements without carrying any of them.
+func TestDecodeDeclaredLengths(t *testing.T) {
+	repeatedItems := func(header []byte, item byte, n int) []byte {
+		return append(header, bytes.Repeat([]byte{item}, n)...)
+	}
+
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{
+			name: "array claiming 2^64-1 elements with no content",
+			data: []byte{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		},
+		{
+			name: "map claiming 2^64-1 pairs with no content",
+			data: []byte{0xbb, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		},
+		{
+			name: "byte string claiming 2^64-1 bytes with no content",
+			data: []byte{0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		},
+		{
+			name: "text string claiming 2^64-1 bytes with no content",
+			data: []byte{0x7b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		},
+		{
+			name: "array exceeding the element limit with all elements present",
+			// 131073 zero elements, one more than MaxArrayElements.
+			data: repeatedItems([]byte{0x9a, 0x00, 0x02, 0x00, 0x01}, 0x00, 131073),
+		},
+		{
+			name: "nesting deeper than the limit",
+			// 64 nested single-element arrays around a zero.
+			data: append(bytes.Repeat([]byte{0x81}, 64), 0x00),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var dst interface{}
+			if err := modes.Decode.Unmarshal(tc.data, &dst); err == nil {
+				t.Errorf("expected error decoding % x...", tc.data[:min(len(tc.data), 16)])
+			}
+			if err := modes.DecodeStrict.Unmarshal(tc.data, &dst); err == nil {
+				t.Errorf("expected error from strict decode of % x...", tc.data[:min(len(tc.data), 16)])
+			}
+		})
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
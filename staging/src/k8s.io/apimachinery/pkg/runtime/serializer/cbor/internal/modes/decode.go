@@ -0,0 +1,123 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modes
+
+import (
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+var decodeOptions = cbor.DecOptions{
+	// Duplicate map keys are rejected outright rather than resolved last-one-wins, so
+	// that a document has exactly one interpretation.
+	DupMapKey: cbor.DupMapKeyEnforcedAPF,
+
+	// All tags are rejected. The self-described CBOR tag is permitted only as a
+	// document prefix, which the serializer strips before decoding; any other
+	// occurrence, nested or repeated, is an error under this policy.
+	TagsMd: cbor.TagsForbidden,
+
+	// Integers decode to int64 so that unstructured content contains the same dynamic
+	// types a JSON decode would produce; unsigned values that overflow int64 are
+	// errors rather than silently becoming uint64.
+	IntDec: cbor.IntDecConvertSignedOrFail,
+
+	// Map keys must be text strings, as they are in JSON.
+	MapKeyByteString: cbor.MapKeyByteStringForbidden,
+
+	// Nested values of unspecified type decode as map[string]interface{}, matching
+	// unstructured JSON decoding.
+	DefaultMapType: reflect.TypeOf(map[string]interface{}(nil)),
+
+	// Text strings carrying invalid UTF-8 are rejected rather than replaced.
+	UTF8: cbor.UTF8RejectInvalid,
+
+	// Field names match struct tags exactly, as the API's JSON serializer does.
+	FieldNameMatching: cbor.FieldNameMatchingCaseSensitive,
+
+	// No option governs null (0xf6); the library decodes a null map value to a present
+	// entry with a nil value, exactly as encoding/json decodes JSON null. Merge patch
+	// semantics distinguish an explicit null (delete the field) from an absent key
+	// (leave it alone), so that behavior must not change if an option controlling it is
+	// ever introduced upstream.
+
+	// An array or map header can declare up to 2^64-1 elements without carrying any of
+	// them, so the number of elements a container claims must never be trusted for
+	// preallocation. The library validates that a document is well-formed, and caps
+	// container sizes to these limits, before allocating for its content; the limits are
+	// pinned here so that an upgrade cannot silently relax them.
+	MaxArrayElements: 131072,
+	MaxMapPairs:      131072,
+
+	// Depth of nesting is limited to keep recursive decoding of hostile documents from
+	// exhausting the stack.
+	MaxNestedLevels: 32,
+}
+
+// Decode is the mode used to decode CBOR documents.
+var Decode cbor.DecMode = func() cbor.DecMode {
+	decode, err := decodeOptions.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return decode
+}()
+
+// DecModeWithLimits returns a mode that behaves as Decode except for the given container
+// and nesting limits. A zero keeps the corresponding serving default; the library
+// rejects values outside its supported ranges. Every other hardening option is pinned to
+// the serving configuration and cannot be relaxed.
+func DecModeWithLimits(maxArrayElements, maxMapPairs, maxNestedLevels int) (cbor.DecMode, error) {
+	options := decodeOptions
+	if maxArrayElements > 0 {
+		options.MaxArrayElements = maxArrayElements
+	}
+	if maxMapPairs > 0 {
+		options.MaxMapPairs = maxMapPairs
+	}
+	if maxNestedLevels > 0 {
+		options.MaxNestedLevels = maxNestedLevels
+	}
+	return options.DecMode()
+}
+
+// Split is the mode used to read concatenated documents from a stream without decoding
+// their content. Unlike Decode, it permits tags, since each document in a stream
+// produced by the serializer carries a self-describe tag prefix; all content
+// restrictions are applied when the individual documents are decoded.
+var Split cbor.DecMode = func() cbor.DecMode {
+	options := decodeOptions
+	options.TagsMd = cbor.TagsAllowed
+	split, err := options.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return split
+}()
+
+// DecodeStrict behaves as Decode except that map keys with no corresponding destination
+// struct field are errors.
+var DecodeStrict cbor.DecMode = func() cbor.DecMode {
+	options := decodeOptions
+	options.ExtraReturnErrors = cbor.ExtraDecErrorUnknownField
+	decode, err := options.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return decode
+}()
@@ -0,0 +1,66 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modes
+
+import (
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Encode is the mode used to produce all CBOR emitted by the serializer. The options are
+// chosen so that a given object has exactly one valid encoding. The user-buffer variant
+// is used so that callers that manage their own buffers can marshal into them.
+var Encode cbor.UserBufferEncMode = func() cbor.UserBufferEncMode {
+	encode, err := cbor.EncOptions{
+		// Map keys are sorted bytewise-lexically per RFC 8949 section 4.2.1 so that
+		// equal objects always produce identical bytes.
+		Sort: cbor.SortBytewiseLexical,
+
+		// Floating-point values are encoded in the shortest width that round-trips.
+		ShortestFloat: cbor.ShortestFloat16,
+		NaNConvert:    cbor.NaNConvert7e00,
+		InfConvert:    cbor.InfConvertFloat16,
+
+		// Indefinite-length items allow multiple encodings of the same value, so they
+		// are never produced.
+		IndefLength: cbor.IndefLengthForbidden,
+
+		// Times are encoded as RFC 3339 text for parity with JSON serialization.
+		Time: cbor.TimeRFC3339Nano,
+
+		// The serializer is responsible for any tags it emits, and programs are
+		// prevented from smuggling arbitrary tags in through cbor.Tag values.
+		TagsMd: cbor.TagsForbidden,
+
+		// Nil slices and maps encode as null rather than as empty containers, matching
+		// encoding/json. Clients compare serialized forms for change detection, so the
+		// nil-versus-empty decision must be the same in every format an object can be
+		// encoded to.
+		NilContainers: cbor.NilContainerAsNull,
+
+		// Strings encode as text strings and []byte as byte strings, preserving the
+		// distinction on the wire.
+		String:    cbor.StringToTextString,
+		FieldName: cbor.FieldNameToTextString,
+
+		// Emptiness is decided on the Go value, as encoding/json does for omitempty.
+		OmitEmpty: cbor.OmitEmptyGoValue,
+	}.UserBufferEncMode()
+	if err != nil {
+		panic(err)
+	}
+	return encode
+}()
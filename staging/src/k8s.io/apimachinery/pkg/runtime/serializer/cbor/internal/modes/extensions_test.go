@@ -0,0 +1,248 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modes_test
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+)
+
+// benchmarkPod approximates the shape and content of a small core API object without
+// importing one, so that the relative cost of the encoder extension points can be
+// measured on representative input.
+type benchmarkPod struct {
+	APIVersion string          `json:"apiVersion"`
+	Kind       string          `json:"kind"`
+	Metadata   benchmarkMeta   `json:"metadata"`
+	Spec       benchmarkSpec   `json:"spec"`
+	Status     benchmarkStatus `json:"status"`
+}
+
+type benchmarkMeta struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace"`
+	UID             string            `json:"uid"`
+	ResourceVersion string            `json:"resourceVersion"`
+	Labels          map[string]string `json:"labels"`
+}
+
+type benchmarkSpec struct {
+	NodeName   string               `json:"nodeName"`
+	Containers []benchmarkContainer `json:"containers"`
+}
+
+type benchmarkContainer struct {
+	Name  string   `json:"name"`
+	Image string   `json:"image"`
+	Args  []string `json:"args"`
+}
+
+type benchmarkStatus struct {
+	Phase        string `json:"phase"`
+	Ready        bool   `json:"ready"`
+	RestartCount int64  `json:"restartCount"`
+}
+
+func samplePod() benchmarkPod {
+	return benchmarkPod{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Metadata: benchmarkMeta{
+			Name:            "benchmark-7d59f6bfc4-tq2x8",
+			Namespace:       "benchmark",
+			UID:             "a47b32c1-4b7e-48d5-a345-d3c085e0d246",
+			ResourceVersion: "429622613",
+			Labels: map[string]string{
+				"app":               "benchmark",
+				"pod-template-hash": "7d59f6bfc4",
+			},
+		},
+		Spec: benchmarkSpec{
+			NodeName: "node-14",
+			Containers: []benchmarkContainer{
+				{Name: "server", Image: "registry.example.com/server:v2.4.1", Args: []string{"--port=8443", "--v=2"}},
+				{Name: "sidecar", Image: "registry.example.com/sidecar:v1.0.3", Args: []string{"--upstream=localhost:8443"}},
+			},
+		},
+		Status: benchmarkStatus{Phase: "Running", Ready: true, RestartCount: 3},
+	}
+}
+
+// marshalerPod delegates its MarshalCBOR implementation back to the reflection-based
+// encoder, isolating the cost of dispatching through the extension point itself. It
+// stands in for custom marshalers written by hand, which typically marshal an
+// intermediate value.
+type marshalerPod benchmarkPod
+
+func (p marshalerPod) MarshalCBOR() ([]byte, error) {
+	return modes.Encode.Marshal(benchmarkPod(p))
+}
+
+// generatedPod implements MarshalCBOR the way a code generator would, appending each
+// field's header and content directly to a buffer without reflection. It represents the
+// upper bound of what generated marshalers could save.
+type generatedPod benchmarkPod
+
+func (p generatedPod) MarshalCBOR() ([]byte, error) {
+	var dst []byte
+	dst = appendCBORHead(dst, cborMajorMap, 5)
+	dst = appendCBORText(dst, "apiVersion")
+	dst = appendCBORText(dst, p.APIVersion)
+	dst = appendCBORText(dst, "kind")
+	dst = appendCBORText(dst, p.Kind)
+	dst = appendCBORText(dst, "metadata")
+	dst = appendCBORHead(dst, cborMajorMap, 5)
+	dst = appendCBORText(dst, "name")
+	dst = appendCBORText(dst, p.Metadata.Name)
+	dst = appendCBORText(dst, "namespace")
+	dst = appendCBORText(dst, p.Metadata.Namespace)
+	dst = appendCBORText(dst, "uid")
+	dst = appendCBORText(dst, p.Metadata.UID)
+	dst = appendCBORText(dst, "resourceVersion")
+	dst = appendCBORText(dst, p.Metadata.ResourceVersion)
+	dst = appendCBORText(dst, "labels")
+	dst = appendCBORHead(dst, cborMajorMap, uint64(len(p.Metadata.Labels)))
+	for k, v := range p.Metadata.Labels {
+		dst = appendCBORText(dst, k)
+		dst = appendCBORText(dst, v)
+	}
+	dst = appendCBORText(dst, "spec")
+	dst = appendCBORHead(dst, cborMajorMap, 2)
+	dst = appendCBORText(dst, "nodeName")
+	dst = appendCBORText(dst, p.Spec.NodeName)
+	dst = appendCBORText(dst, "containers")
+	dst = appendCBORHead(dst, cborMajorArray, uint64(len(p.Spec.Containers)))
+	for _, c := range p.Spec.Containers {
+		dst = appendCBORHead(dst, cborMajorMap, 3)
+		dst = appendCBORText(dst, "name")
+		dst = appendCBORText(dst, c.Name)
+		dst = appendCBORText(dst, "image")
+		dst = appendCBORText(dst, c.Image)
+		dst = appendCBORText(dst, "args")
+		dst = appendCBORHead(dst, cborMajorArray, uint64(len(c.Args)))
+		for _, arg := range c.Args {
+			dst = appendCBORText(dst, arg)
+		}
+	}
+	dst = appendCBORText(dst, "status")
+	dst = appendCBORHead(dst, cborMajorMap, 3)
+	dst = appendCBORText(dst, "phase")
+	dst = appendCBORText(dst, p.Status.Phase)
+	dst = appendCBORText(dst, "ready")
+	if p.Status.Ready {
+		dst = append(dst, 0xf5)
+	} else {
+		dst = append(dst, 0xf4)
+	}
+	dst = appendCBORText(dst, "restartCount")
+	dst = appendCBORHead(dst, cborMajorUnsigned, uint64(p.Status.RestartCount))
+	return dst, nil
+}
+
+const (
+	cborMajorUnsigned = 0
+	cborMajorText     = 3
+	cborMajorArray    = 4
+	cborMajorMap      = 5
+)
+
+func appendCBORHead(dst []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(dst, major<<5|byte(n))
+	case n <= 0xff:
+		return append(dst, major<<5|24, byte(n))
+	case n <= 0xffff:
+		return append(dst, major<<5|25, byte(n>>8), byte(n))
+	case n <= 0xffffffff:
+		return append(dst, major<<5|26, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	default:
+		return append(dst, major<<5|27, byte(n>>56), byte(n>>48), byte(n>>40), byte(n>>32), byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+}
+
+func appendCBORText(dst []byte, s string) []byte {
+	dst = appendCBORHead(dst, cborMajorText, uint64(len(s)))
+	return append(dst, s...)
+}
+
+// requireEquivalentDocuments fails unless the two encoded documents decode to equal
+// values. Byte equality is deliberately not required: generated marshalers are free to
+// emit map entries in a different order than the deterministic reflection encoder.
+func requireEquivalentDocuments(tb testing.TB, want, got []byte) {
+	tb.Helper()
+	var wantDecoded, gotDecoded interface{}
+	if err := modes.Decode.Unmarshal(want, &wantDecoded); err != nil {
+		tb.Fatalf("failed to decode reference document: %v", err)
+	}
+	if err := modes.Decode.Unmarshal(got, &gotDecoded); err != nil {
+		tb.Fatalf("failed to decode document: %v", err)
+	}
+	if !reflect.DeepEqual(wantDecoded, gotDecoded) {
+		tb.Fatalf("documents are not equivalent:\nwant: %#v\ngot:  %#v", wantDecoded, gotDecoded)
+	}
+}
+
+func TestMarshalerEquivalence(t *testing.T) {
+	pod := samplePod()
+	reference, err := modes.Encode.Marshal(pod)
+	if err != nil {
+		t.Fatalf("unexpected error from reflection encode: %v", err)
+	}
+	for _, tc := range []struct {
+		name string
+		obj  interface{}
+	}{
+		{name: "marshaler", obj: marshalerPod(pod)},
+		{name: "generated", obj: generatedPod(pod)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded, err := modes.Encode.Marshal(tc.obj)
+			if err != nil {
+				t.Fatalf("unexpected encode error: %v", err)
+			}
+			requireEquivalentDocuments(t, reference, encoded)
+		})
+	}
+}
+
+// BenchmarkMarshalerExtensions compares the reflection encoder against the two styles of
+// MarshalCBOR implementation on a representative object. The marshaler variant bounds
+// the overhead added by the extension point; the generated variant bounds the savings
+// available to generated code.
+func BenchmarkMarshalerExtensions(b *testing.B) {
+	pod := samplePod()
+	for _, tc := range []struct {
+		name string
+		obj  interface{}
+	}{
+		{name: "reflection", obj: pod},
+		{name: "marshaler", obj: marshalerPod(pod)},
+		{name: "generated", obj: generatedPod(pod)},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := modes.Encode.Marshal(tc.obj); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/apitesting/vectors"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+)
+
+func TestDecodeVectors(t *testing.T) {
+	for _, v := range vectors.All() {
+		t.Run(v.Name, func(t *testing.T) {
+			var decoded interface{}
+			err := modes.Decode.Unmarshal(v.CBOR, &decoded)
+			if v.RejectedByDecode {
+				if err == nil {
+					t.Fatalf("expected decode of %s to be rejected, got %#v", v.Diagnostic, decoded)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected decode error for %s: %v", v.Diagnostic, err)
+			}
+			if !reflect.DeepEqual(decoded, v.Value) {
+				t.Errorf("decoded %s to %#v, want %#v", v.Diagnostic, decoded, v.Value)
+			}
+		})
+	}
+}
+
+func TestEncodeVectors(t *testing.T) {
+	for _, v := range vectors.All() {
+		if !v.Canonical || v.RejectedByDecode {
+			continue
+		}
+		t.Run(v.Name, func(t *testing.T) {
+			encoded, err := modes.Encode.Marshal(v.Value)
+			if err != nil {
+				t.Fatalf("unexpected encode error for %s: %v", v.Diagnostic, err)
+			}
+			if !bytes.Equal(encoded, v.CBOR) {
+				t.Errorf("encoded %s to % x, want % x", v.Diagnostic, encoded, v.CBOR)
+			}
+		})
+	}
+}
+
+func TestJSONVectors(t *testing.T) {
+	// The corpus JSON documents are the JSON serializations of the same semantic
+	// values, so encoding a decoded vector with encoding/json must reproduce them.
+	for _, v := range vectors.All() {
+		if v.RejectedByDecode || v.JSON == "" {
+			continue
+		}
+		t.Run(v.Name, func(t *testing.T) {
+			encoded, err := json.Marshal(v.Value)
+			if err != nil {
+				t.Fatalf("unexpected json encode error for %s: %v", v.Diagnostic, err)
+			}
+			if string(encoded) != v.JSON {
+				t.Errorf("encoded %s to %s, want %s", v.Diagnostic, encoded, v.JSON)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"io"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+)
+
+// EncodeList writes an object to a stream as Encode does, encoding the items of lists of
+// a recognized shape concurrently whether or not the ParallelListItems option is set.
+// Objects that are not lists of a recognized shape are encoded sequentially.
+func (s *serializer) EncodeList(list runtime.Object, w io.Writer) error {
+	if co, ok := list.(runtime.CacheableObject); ok {
+		return co.CacheEncode(s.Identifier(), s.doEncodeList, w)
+	}
+	return s.doEncodeList(list, w)
+}
+
+func (s *serializer) doEncodeList(obj runtime.Object, w io.Writer) error {
+	if _, err := w.Write(selfDescribedCBOR); err != nil {
+		return err
+	}
+	if handled, err := s.encodeListParallel(obj, w); handled {
+		return err
+	}
+	var v interface{} = obj
+	if u, ok := obj.(runtime.Unstructured); ok {
+		v = u.UnstructuredContent()
+	}
+	return modes.Encode.NewEncoder(w).Encode(v)
+}
+
+// DecodeList decodes a document directly into the provided destination, skipping the
+// full-document scan Decode performs to extract the stored type metadata and the type
+// lookup it performs to resolve a destination. The strict variant retains its check for
+// unknown fields.
+func (s *serializer) DecodeList(data []byte, into runtime.Object) error {
+	originalData := data
+	if bytes.HasPrefix(data, selfDescribedCBOR) {
+		data = data[len(selfDescribedCBOR):]
+	}
+	if err := s.unmarshal(data, into); err != nil {
+		return err
+	}
+	if !s.options.Strict {
+		return nil
+	}
+	if _, ok := into.(runtime.Unstructured); ok {
+		// An unstructured destination has no fields for the document to mismatch.
+		return nil
+	}
+	strictObj := into.DeepCopyObject()
+	if err := modes.DecodeStrict.Unmarshal(data, strictObj); err != nil {
+		return strictDecodingError(err, data, strictObj, originalData)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
+)
+
+// testCopyableList is a list destination for DecodeList tests that exercise the strict
+// variant, which needs a working DeepCopyObject.
+type testCopyableList struct {
+	runtime.TypeMeta `json:",inline"`
+	Metadata         testListMeta                    `json:"metadata,omitempty"`
+	Items            []runtimetesting.ExternalSimple `json:"items"`
+}
+
+func (l *testCopyableList) DeepCopyObject() runtime.Object {
+	out := *l
+	out.Items = append([]runtimetesting.ExternalSimple(nil), l.Items...)
+	return &out
+}
+
+func TestEncodeListMatchesEncode(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	testCases := []struct {
+		name string
+		obj  runtime.Object
+	}{
+		{
+			name: "typed list above threshold",
+			obj:  newTestSimpleList(minParallelEncodeItems + 1),
+		},
+		{
+			name: "typed list below threshold",
+			obj:  newTestSimpleList(3),
+		},
+		{
+			name: "unstructured list above threshold",
+			obj:  newTestUnstructuredList(minParallelEncodeItems + 1),
+		},
+		{
+			name: "non-list object",
+			obj: &runtimetesting.ExternalSimple{
+				TypeMeta:   runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"},
+				TestString: "value",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var want, got bytes.Buffer
+			if err := s.Encode(tc.obj, &want); err != nil {
+				t.Fatalf("unexpected Encode error: %v", err)
+			}
+			if err := s.EncodeList(tc.obj, &got); err != nil {
+				t.Fatalf("unexpected EncodeList error: %v", err)
+			}
+			if !bytes.Equal(want.Bytes(), got.Bytes()) {
+				t.Errorf("Encode and EncodeList produced different bytes:\nEncode:     % x\nEncodeList: % x", want.Bytes(), got.Bytes())
+			}
+		})
+	}
+}
+
+func TestDecodeList(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	t.Run("typed", func(t *testing.T) {
+		original := newTestSimpleList(3)
+		data, err := runtime.Encode(s, original)
+		if err != nil {
+			t.Fatalf("unexpected encode error: %v", err)
+		}
+		into := &testSimpleList{}
+		if err := s.DecodeList(data, into); err != nil {
+			t.Fatalf("unexpected decode error: %v", err)
+		}
+		if !reflect.DeepEqual(original, into) {
+			t.Errorf("expected %#v, got %#v", original, into)
+		}
+	})
+
+	t.Run("typed destination is reset", func(t *testing.T) {
+		into := &testSimpleList{}
+		data, err := runtime.Encode(s, newTestSimpleList(3))
+		if err != nil {
+			t.Fatalf("unexpected encode error: %v", err)
+		}
+		if err := s.DecodeList(data, into); err != nil {
+			t.Fatalf("unexpected decode error: %v", err)
+		}
+		second := newTestSimpleList(1)
+		second.Metadata = testListMeta{}
+		data, err = runtime.Encode(s, second)
+		if err != nil {
+			t.Fatalf("unexpected encode error: %v", err)
+		}
+		if err := s.DecodeList(data, into); err != nil {
+			t.Fatalf("unexpected decode error: %v", err)
+		}
+		if !reflect.DeepEqual(second, into) {
+			t.Errorf("expected fields absent from the second document to be zeroed, got %#v", into)
+		}
+	})
+
+	t.Run("unstructured", func(t *testing.T) {
+		original := newTestUnstructuredList(3)
+		data, err := runtime.Encode(s, original)
+		if err != nil {
+			t.Fatalf("unexpected encode error: %v", err)
+		}
+		into := &unstructured.Unstructured{}
+		if err := s.DecodeList(data, into); err != nil {
+			t.Fatalf("unexpected decode error: %v", err)
+		}
+		if !reflect.DeepEqual(original.Object, into.Object) {
+			t.Errorf("expected %#v, got %#v", original.Object, into.Object)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		unknown := newTestUnstructuredList(1)
+		unknown.Object["bogus"] = "value"
+		data, err := runtime.Encode(s, unknown)
+		if err != nil {
+			t.Fatalf("unexpected encode error: %v", err)
+		}
+		if err := s.DecodeList(data, &testCopyableList{}); err != nil {
+			t.Errorf("unexpected non-strict decode error: %v", err)
+		}
+		strict := NewSerializerWithOptions(DefaultMetaFactory, scheme, scheme, SerializerOptions{Strict: true})
+		err = strict.DecodeList(data, &testCopyableList{})
+		if !runtime.IsStrictDecodingError(err) {
+			t.Errorf("expected a strict decoding error for an unknown field, got %v", err)
+		}
+	})
+}
+
+func BenchmarkListDecode(b *testing.B) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+	for _, n := range []int{minParallelEncodeItems, 10 * minParallelEncodeItems} {
+		data, err := runtime.Encode(s, newTestSimpleList(n))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("items=%d/decode", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, _, err := s.Decode(data, nil, &testSimpleList{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("items=%d/decodeList", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := s.DecodeList(data, &testSimpleList{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+)
+
+// MetaFactory is used to store and retrieve the version and kind
+// information for CBOR objects in a serializer.
+type MetaFactory interface {
+	// Interpret should return the version and kind of the wire-format of
+	// the object.
+	Interpret(data []byte) (*schema.GroupVersionKind, error)
+}
+
+// DefaultMetaFactory is a default factory for versioning objects in CBOR. The object
+// in memory and in the default serialization will use the "kind" and "apiVersion"
+// fields.
+var DefaultMetaFactory = SimpleMetaFactory{}
+
+// SimpleMetaFactory provides default methods for retrieving the type and version of objects
+// that are identified with an "apiVersion" and "kind" fields in their CBOR
+// serialization.
+type SimpleMetaFactory struct{}
+
+// MetaFieldLengthError is returned by Interpret when the apiVersion or kind field of a
+// document is longer than the factory will read.
+type MetaFieldLengthError = direct.MetaFieldLengthError
+
+// MetaFieldTypeError is returned by Interpret when the apiVersion or kind field of a
+// document is present but is not a text string.
+type MetaFieldTypeError = direct.MetaFieldTypeError
+
+// Interpret will return the APIVersion and Kind of the CBOR wire-format
+// encoding of an object, or an error. It scans only the heads of the top-level map
+// entries and the two type-identifying strings, so the cost of interpretation is bounded
+// by the size of the document rather than by anything an adversarial head claims.
+func (SimpleMetaFactory) Interpret(data []byte) (*schema.GroupVersionKind, error) {
+	gvk, err := direct.PeekTypeMeta(data)
+	if err != nil {
+		return nil, err
+	}
+	return &gvk, nil
+}
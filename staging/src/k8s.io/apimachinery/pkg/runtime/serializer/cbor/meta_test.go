@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestInterpret(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		doc  interface{} // marshaled unless raw is set
+		raw  []byte
+		gvk  schema.GroupVersionKind
+	}{
+		{
+			name: "group, version, and kind",
+			doc: map[string]interface{}{
+				"apiVersion": "test.group/v1",
+				"kind":       "Simple",
+				"metadata":   map[string]interface{}{"name": "x"},
+			},
+			gvk: schema.GroupVersionKind{Group: "test.group", Version: "v1", Kind: "Simple"},
+		},
+		{
+			name: "legacy group",
+			doc:  map[string]interface{}{"apiVersion": "v1", "kind": "Pod"},
+			gvk:  schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		},
+		{
+			name: "absent fields",
+			doc:  map[string]interface{}{"spec": map[string]interface{}{}},
+		},
+		{
+			name: "null fields",
+			doc:  map[string]interface{}{"apiVersion": nil, "kind": nil},
+		},
+		{
+			name: "self-describe prefix",
+			raw: append(append([]byte{}, selfDescribedCBOR...), marshalWithTagsAllowed(t, map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+			})...),
+			gvk: schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		},
+		{
+			// {_ "kind": (_ "Po" "d")}
+			name: "indefinite-length encodings",
+			raw:  []byte{0xbf, 0x64, 'k', 'i', 'n', 'd', 0x7f, 0x62, 'P', 'o', 0x61, 'd', 0xff, 0xff},
+			gvk:  schema.GroupVersionKind{Kind: "Pod"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data := tc.raw
+			if data == nil {
+				data = marshalWithTagsAllowed(t, tc.doc)
+			}
+			gvk, err := DefaultMetaFactory.Interpret(data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *gvk != tc.gvk {
+				t.Errorf("expected gvk %#v, got %#v", tc.gvk, *gvk)
+			}
+		})
+	}
+}
+
+// TestInterpretErrors checks that the typed errors of the underlying scan surface from
+// Interpret under the names this package has always exported for them. The scan itself
+// is covered in detail alongside its implementation in the direct package.
+func TestInterpretErrors(t *testing.T) {
+	// {"kind": 7}
+	_, err := DefaultMetaFactory.Interpret([]byte{0xa1, 0x64, 'k', 'i', 'n', 'd', 0x07})
+	typeErr := new(*MetaFieldTypeError)
+	if !errors.As(err, typeErr) {
+		t.Fatalf("expected MetaFieldTypeError, got %v", err)
+	}
+	if (*typeErr).Field != "kind" {
+		t.Errorf("expected error for field %q, got %q", "kind", (*typeErr).Field)
+	}
+
+	if _, err := DefaultMetaFactory.Interpret([]byte{0xff}); err == nil {
+		t.Fatal("expected error interpreting a malformed document")
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+
+	jsonpatch "github.com/evanphx/json-patch"
+)
+
+// TestDecodePreservesExplicitNulls decodes equivalent JSON and CBOR documents containing
+// explicit nulls and requires that both produce a present map entry with a nil value.
+// Merge patch semantics distinguish a null field (delete it) from an absent one (leave it
+// alone), so a decode path that dropped null entries would silently turn deletions into
+// no-ops for one format but not the other.
+func TestDecodePreservesExplicitNulls(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		json string
+		cbor []byte
+	}{
+		{
+			name: "top-level null entry",
+			json: `{"apiVersion":"example.com/v1","kind":"Widget","spec":null}`,
+			cbor: mustMarshalCBOR(t, map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "Widget",
+				"spec":       nil,
+			}),
+		},
+		{
+			name: "nested null entry",
+			json: `{"apiVersion":"example.com/v1","kind":"Widget","spec":{"paused":null,"name":"squeak"}}`,
+			cbor: mustMarshalCBOR(t, map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "Widget",
+				"spec":       map[string]interface{}{"paused": nil, "name": "squeak"},
+			}),
+		},
+		{
+			name: "null array element",
+			json: `{"apiVersion":"example.com/v1","kind":"Widget","spec":{"items":["a",null,"b"]}}`,
+			cbor: mustMarshalCBOR(t, map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "Widget",
+				"spec":       map[string]interface{}{"items": []interface{}{"a", nil, "b"}},
+			}),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var fromJSON map[string]interface{}
+			if err := json.Unmarshal([]byte(tc.json), &fromJSON); err != nil {
+				t.Fatalf("unexpected json error: %v", err)
+			}
+
+			fromCBOR, err := UnmarshalToUnstructuredContent(tc.cbor, Limits{})
+			if err != nil {
+				t.Fatalf("unexpected cbor error: %v", err)
+			}
+			if !reflect.DeepEqual(fromJSON, fromCBOR) {
+				t.Errorf("json and cbor decodes disagree: %#v vs %#v", fromJSON, fromCBOR)
+			}
+
+			// The serializer's decode into an unstructured destination takes a
+			// separate path through unmarshal and the byte string conversion walk,
+			// so it is pinned independently.
+			scheme := newTestScheme()
+			s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+			into := &unstructured.Unstructured{}
+			obj, _, err := s.Decode(append(append([]byte(nil), selfDescribedCBOR...), tc.cbor...), nil, into)
+			if err != nil {
+				t.Fatalf("unexpected decode error: %v", err)
+			}
+			if !reflect.DeepEqual(fromJSON, obj.(*unstructured.Unstructured).Object) {
+				t.Errorf("serializer decode disagrees with json: %#v vs %#v", fromJSON, obj.(*unstructured.Unstructured).Object)
+			}
+		})
+	}
+}
+
+// TestMergePatchNullParity submits the same merge patch once as JSON and once as CBOR and
+// requires that both applications produce the same result, including the deletion of the
+// field patched to null.
+func TestMergePatchNullParity(t *testing.T) {
+	base := []byte(`{"apiVersion":"example.com/v1","kind":"Widget","spec":{"paused":"true","name":"squeak"}}`)
+
+	jsonPatch := []byte(`{"spec":{"paused":null}}`)
+	cborPatch := mustMarshalCBOR(t, map[string]interface{}{
+		"spec": map[string]interface{}{"paused": nil},
+	})
+
+	fromJSON, err := jsonpatch.MergePatch(base, jsonPatch)
+	if err != nil {
+		t.Fatalf("unexpected error applying json patch: %v", err)
+	}
+
+	// A CBOR patch is decoded into unstructured content before application, so a decode
+	// that failed to preserve the null entry would degrade the deletion to a no-op.
+	decodedPatch, err := UnmarshalToUnstructuredContent(cborPatch, Limits{})
+	if err != nil {
+		t.Fatalf("unexpected error decoding cbor patch: %v", err)
+	}
+	recodedPatch, err := json.Marshal(decodedPatch)
+	if err != nil {
+		t.Fatalf("unexpected error re-encoding cbor patch: %v", err)
+	}
+	fromCBOR, err := jsonpatch.MergePatch(base, recodedPatch)
+	if err != nil {
+		t.Fatalf("unexpected error applying cbor patch: %v", err)
+	}
+
+	var resultJSON, resultCBOR map[string]interface{}
+	if err := json.Unmarshal(fromJSON, &resultJSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := json.Unmarshal(fromCBOR, &resultCBOR); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(resultJSON, resultCBOR) {
+		t.Errorf("json and cbor patches applied differently: %#v vs %#v", resultJSON, resultCBOR)
+	}
+	if _, present := resultCBOR["spec"].(map[string]interface{})["paused"]; present {
+		t.Error("expected field patched to null to be deleted")
+	}
+}
+
+func mustMarshalCBOR(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := modes.Encode.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
+)
+
+type testSimpleList struct {
+	runtime.TypeMeta `json:",inline"`
+	Metadata         testListMeta                    `json:"metadata,omitempty"`
+	Items            []runtimetesting.ExternalSimple `json:"items"`
+}
+
+type testListMeta struct {
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+func (l *testSimpleList) DeepCopyObject() runtime.Object {
+	panic("testSimpleList does not support DeepCopy")
+}
+
+func newTestSimpleList(n int) *testSimpleList {
+	list := &testSimpleList{
+		TypeMeta: runtime.TypeMeta{APIVersion: testGV.String(), Kind: "SimpleList"},
+		Metadata: testListMeta{ResourceVersion: "42"},
+	}
+	for i := 0; i < n; i++ {
+		list.Items = append(list.Items, runtimetesting.ExternalSimple{TestString: fmt.Sprintf("item-%d", i)})
+	}
+	return list
+}
+
+func newTestUnstructuredList(n int) *unstructured.Unstructured {
+	items := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		items = append(items, map[string]interface{}{"testString": fmt.Sprintf("item-%d", i)})
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "WidgetList",
+		"metadata":   map[string]interface{}{"resourceVersion": "42"},
+		"items":      items,
+	}}
+}
+
+func TestParallelListEncodeMatchesSequential(t *testing.T) {
+	scheme := newTestScheme()
+	sequential := NewSerializer(DefaultMetaFactory, scheme, scheme)
+	parallel := NewSerializerWithOptions(DefaultMetaFactory, scheme, scheme, SerializerOptions{ParallelListItems: true})
+
+	testCases := []struct {
+		name string
+		obj  runtime.Object
+	}{
+		{
+			name: "typed list above threshold",
+			obj:  newTestSimpleList(minParallelEncodeItems + 1),
+		},
+		{
+			name: "typed list below threshold",
+			obj:  newTestSimpleList(3),
+		},
+		{
+			name: "typed list with empty list metadata",
+			obj: &testSimpleList{
+				TypeMeta: runtime.TypeMeta{APIVersion: testGV.String(), Kind: "SimpleList"},
+				Items:    newTestSimpleList(minParallelEncodeItems + 1).Items,
+			},
+		},
+		{
+			name: "unstructured list above threshold",
+			obj:  newTestUnstructuredList(minParallelEncodeItems + 1),
+		},
+		{
+			name: "non-list object",
+			obj: &runtimetesting.ExternalSimple{
+				TypeMeta:   runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"},
+				TestString: "value",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var sequentialBytes, parallelBytes bytes.Buffer
+			if err := sequential.Encode(tc.obj, &sequentialBytes); err != nil {
+				t.Fatalf("unexpected sequential encode error: %v", err)
+			}
+			if err := parallel.Encode(tc.obj, &parallelBytes); err != nil {
+				t.Fatalf("unexpected parallel encode error: %v", err)
+			}
+			if !bytes.Equal(sequentialBytes.Bytes(), parallelBytes.Bytes()) {
+				t.Errorf("sequential and parallel encodings differ:\nsequential: % x\nparallel:   % x", sequentialBytes.Bytes(), parallelBytes.Bytes())
+			}
+		})
+	}
+}
+
+func BenchmarkListEncode(b *testing.B) {
+	scheme := newTestScheme()
+	for _, n := range []int{minParallelEncodeItems, 10 * minParallelEncodeItems} {
+		list := newTestSimpleList(n)
+		for _, tc := range []struct {
+			name    string
+			options SerializerOptions
+		}{
+			{name: "sequential"},
+			{name: "parallel", options: SerializerOptions{ParallelListItems: true}},
+		} {
+			s := NewSerializerWithOptions(DefaultMetaFactory, scheme, scheme, tc.options)
+			b.Run(fmt.Sprintf("items=%d/%s", n, tc.name), func(b *testing.B) {
+				var buf bytes.Buffer
+				for i := 0; i < b.N; i++ {
+					buf.Reset()
+					if err := s.Encode(list, &buf); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
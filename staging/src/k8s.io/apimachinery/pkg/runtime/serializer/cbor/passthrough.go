@@ -0,0 +1,422 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// metaRuntimeInterface implements runtime.Object and metav1.Object interfaces.
+type metaRuntimeInterface interface {
+	runtime.Object
+	metav1.Object
+}
+
+// NewPassthroughCodec wraps a CBOR codec so that objects it decodes remember the stored
+// document they were decoded from. When such an object is encoded again by the same codec
+// without having been modified, the stored document is written back directly — after
+// targeted fix-ups of its apiVersion, kind, and metadata resourceVersion and selfLink —
+// instead of being re-encoded. Metadata writes that the request path is expected to
+// perform (resource version and self link fix-ups, and group version kind rewrites)
+// preserve the passthrough; any other metadata write falls back to a full re-encode.
+func NewPassthroughCodec(delegate runtime.Codec) runtime.Codec {
+	return &passthroughCodec{delegate: delegate}
+}
+
+type passthroughCodec struct {
+	delegate runtime.Codec
+}
+
+// Decode decodes with the delegate codec. If the decoded object carries object metadata,
+// it is returned wrapped together with the original document so that a later encode by
+// the same codec can serve the document directly.
+func (c *passthroughCodec) Decode(data []byte, defaults *schema.GroupVersionKind, into runtime.Object) (runtime.Object, *schema.GroupVersionKind, error) {
+	obj, gvk, err := c.delegate.Decode(data, defaults, into)
+	if err != nil {
+		return obj, gvk, err
+	}
+	mo, ok := obj.(metaRuntimeInterface)
+	if !ok {
+		return obj, gvk, nil
+	}
+	raw := make([]byte, len(data))
+	copy(raw, data)
+	return &passthroughObject{object: mo, raw: raw, id: c.delegate.Identifier()}, gvk, nil
+}
+
+// Encode encodes with the delegate codec. The delegate observes passthrough objects
+// through the runtime.CacheableObject interface, so no special handling is needed here.
+func (c *passthroughCodec) Encode(obj runtime.Object, w io.Writer) error {
+	return c.delegate.Encode(obj, w)
+}
+
+// Identifier implements runtime.Encoder interface.
+func (c *passthroughCodec) Identifier() runtime.Identifier {
+	return c.delegate.Identifier()
+}
+
+var _ runtime.CacheableObject = &passthroughObject{}
+
+// passthroughObject pairs a decoded object with the stored document it was decoded from.
+// Like the caching object used by the watch cache, it intercepts metadata setters;
+// setters other than the fix-ups applied by the read path invalidate the stored document.
+type passthroughObject struct {
+	lock sync.RWMutex
+
+	// object is the decoded form of raw, subsequently subject to metadata fix-ups.
+	object metaRuntimeInterface
+	// raw is the stored document, or nil once an unsupported mutation invalidates it.
+	raw []byte
+	// id identifies the codec whose encoding raw is known to agree with.
+	id runtime.Identifier
+}
+
+// CacheEncode implements runtime.CacheableObject. If the requested encoding is the one
+// the stored document was decoded from and the object has not been modified, the stored
+// document is patched and written directly; otherwise the object is re-encoded.
+func (o *passthroughObject) CacheEncode(id runtime.Identifier, encode func(runtime.Object, io.Writer) error, w io.Writer) error {
+	o.lock.RLock()
+	raw, gvk := o.raw, o.object.GetObjectKind().GroupVersionKind()
+	resourceVersion, selfLink := o.object.GetResourceVersion(), o.object.GetSelfLink()
+	o.lock.RUnlock()
+
+	if raw != nil && id == o.id {
+		patched, err := patchDocument(raw, gvk, resourceVersion, selfLink)
+		if err == nil {
+			_, err = w.Write(patched)
+			return err
+		}
+		// An unpatchable document falls back to a full re-encode below.
+	}
+	return encode(o.GetObject(), w)
+}
+
+// GetObject implements runtime.CacheableObject. It returns a deep copy of the wrapped
+// object to return ownership of it to the caller according to the contract of the
+// interface.
+func (o *passthroughObject) GetObject() runtime.Object {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.DeepCopyObject().(metaRuntimeInterface)
+}
+
+// GetObjectKind implements runtime.Object interface.
+func (o *passthroughObject) GetObjectKind() schema.ObjectKind {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetObjectKind()
+}
+
+// DeepCopyObject implements runtime.Object interface.
+func (o *passthroughObject) DeepCopyObject() runtime.Object {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return &passthroughObject{
+		object: o.object.DeepCopyObject().(metaRuntimeInterface),
+		raw:    o.raw,
+		id:     o.id,
+	}
+}
+
+// patchDocument rewrites the apiVersion, kind, and metadata resourceVersion and selfLink
+// of a stored document without decoding or re-encoding the rest of it. Only the affected
+// top-level and metadata map entries are re-marshalled; canonical key order is preserved
+// by the encode mode.
+func patchDocument(raw []byte, gvk schema.GroupVersionKind, resourceVersion, selfLink string) ([]byte, error) {
+	data := raw
+	if bytes.HasPrefix(data, selfDescribedCBOR) {
+		data = data[len(selfDescribedCBOR):]
+	}
+	var doc map[string]cbor.RawMessage
+	if err := modes.Decode.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	// An empty group version kind means the in-memory object has had its kind cleared,
+	// as decoders do; the document's own apiVersion and kind remain authoritative.
+	if !gvk.Empty() {
+		if err := setEntry(doc, "apiVersion", gvk.GroupVersion().String()); err != nil {
+			return nil, err
+		}
+		if err := setEntry(doc, "kind", gvk.Kind); err != nil {
+			return nil, err
+		}
+	}
+
+	metadata := map[string]cbor.RawMessage{}
+	rawMetadata, hadMetadata := doc["metadata"]
+	if hadMetadata {
+		if err := modes.Decode.Unmarshal(rawMetadata, &metadata); err != nil {
+			return nil, err
+		}
+	}
+	if err := setEntry(metadata, "resourceVersion", resourceVersion); err != nil {
+		return nil, err
+	}
+	if err := setEntry(metadata, "selfLink", selfLink); err != nil {
+		return nil, err
+	}
+	// The shape of the document is preserved: a metadata entry is only introduced when
+	// there is something to record in it.
+	if hadMetadata || len(metadata) > 0 {
+		encodedMetadata, err := modes.Encode.Marshal(metadata)
+		if err != nil {
+			return nil, err
+		}
+		doc["metadata"] = encodedMetadata
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(selfDescribedCBOR) + len(data))
+	out.Write(selfDescribedCBOR)
+	if err := modes.Encode.NewEncoder(&out).Encode(doc); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// setEntry sets key to the encoding of value in doc, removing the entry entirely when
+// value is empty, matching omitempty handling on encode.
+func setEntry(doc map[string]cbor.RawMessage, key, value string) error {
+	if value == "" {
+		delete(doc, key)
+		return nil
+	}
+	encoded, err := modes.Encode.Marshal(value)
+	if err != nil {
+		return err
+	}
+	doc[key] = encoded
+	return nil
+}
+
+// invalidateLocked drops the stored document so that subsequent encodes take the full
+// re-encode path.
+func (o *passthroughObject) invalidateLocked() {
+	o.raw = nil
+}
+
+// The following functions implement the metav1.Object interface:
+// - getters simply delegate to the underlying object
+// - setters for the fields the read path fixes up (resource version and self link)
+//   delegate to the underlying object, since those fields are patched into the stored
+//   document during encode
+// - all other setters check whether the operation is a no-op and if not, invalidate
+//   the stored document and delegate to the underlying object
+
+func (o *passthroughObject) conditionalSet(isNoop func() bool, set func()) {
+	if fastPath := func() bool {
+		o.lock.RLock()
+		defer o.lock.RUnlock()
+		return isNoop()
+	}(); fastPath {
+		return
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if isNoop() {
+		return
+	}
+	o.invalidateLocked()
+	set()
+}
+
+func (o *passthroughObject) GetNamespace() string {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetNamespace()
+}
+func (o *passthroughObject) SetNamespace(namespace string) {
+	o.conditionalSet(
+		func() bool { return o.object.GetNamespace() == namespace },
+		func() { o.object.SetNamespace(namespace) },
+	)
+}
+func (o *passthroughObject) GetName() string {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetName()
+}
+func (o *passthroughObject) SetName(name string) {
+	o.conditionalSet(
+		func() bool { return o.object.GetName() == name },
+		func() { o.object.SetName(name) },
+	)
+}
+func (o *passthroughObject) GetGenerateName() string {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetGenerateName()
+}
+func (o *passthroughObject) SetGenerateName(name string) {
+	o.conditionalSet(
+		func() bool { return o.object.GetGenerateName() == name },
+		func() { o.object.SetGenerateName(name) },
+	)
+}
+func (o *passthroughObject) GetUID() types.UID {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetUID()
+}
+func (o *passthroughObject) SetUID(uid types.UID) {
+	o.conditionalSet(
+		func() bool { return o.object.GetUID() == uid },
+		func() { o.object.SetUID(uid) },
+	)
+}
+func (o *passthroughObject) GetResourceVersion() string {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetResourceVersion()
+}
+func (o *passthroughObject) SetResourceVersion(version string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.object.SetResourceVersion(version)
+}
+func (o *passthroughObject) GetGeneration() int64 {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetGeneration()
+}
+func (o *passthroughObject) SetGeneration(generation int64) {
+	o.conditionalSet(
+		func() bool { return o.object.GetGeneration() == generation },
+		func() { o.object.SetGeneration(generation) },
+	)
+}
+func (o *passthroughObject) GetSelfLink() string {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetSelfLink()
+}
+func (o *passthroughObject) SetSelfLink(selfLink string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.object.SetSelfLink(selfLink)
+}
+func (o *passthroughObject) GetCreationTimestamp() metav1.Time {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetCreationTimestamp()
+}
+func (o *passthroughObject) SetCreationTimestamp(timestamp metav1.Time) {
+	o.conditionalSet(
+		func() bool { return o.object.GetCreationTimestamp() == timestamp },
+		func() { o.object.SetCreationTimestamp(timestamp) },
+	)
+}
+func (o *passthroughObject) GetDeletionTimestamp() *metav1.Time {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetDeletionTimestamp()
+}
+func (o *passthroughObject) SetDeletionTimestamp(timestamp *metav1.Time) {
+	o.conditionalSet(
+		func() bool { return o.object.GetDeletionTimestamp() == timestamp },
+		func() { o.object.SetDeletionTimestamp(timestamp) },
+	)
+}
+func (o *passthroughObject) GetDeletionGracePeriodSeconds() *int64 {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetDeletionGracePeriodSeconds()
+}
+func (o *passthroughObject) SetDeletionGracePeriodSeconds(gracePeriodSeconds *int64) {
+	o.conditionalSet(
+		func() bool { return o.object.GetDeletionGracePeriodSeconds() == gracePeriodSeconds },
+		func() { o.object.SetDeletionGracePeriodSeconds(gracePeriodSeconds) },
+	)
+}
+func (o *passthroughObject) GetLabels() map[string]string {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetLabels()
+}
+func (o *passthroughObject) SetLabels(labels map[string]string) {
+	o.conditionalSet(
+		func() bool { return reflect.DeepEqual(o.object.GetLabels(), labels) },
+		func() { o.object.SetLabels(labels) },
+	)
+}
+func (o *passthroughObject) GetAnnotations() map[string]string {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetAnnotations()
+}
+func (o *passthroughObject) SetAnnotations(annotations map[string]string) {
+	o.conditionalSet(
+		func() bool { return reflect.DeepEqual(o.object.GetAnnotations(), annotations) },
+		func() { o.object.SetAnnotations(annotations) },
+	)
+}
+func (o *passthroughObject) GetFinalizers() []string {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetFinalizers()
+}
+func (o *passthroughObject) SetFinalizers(finalizers []string) {
+	o.conditionalSet(
+		func() bool { return reflect.DeepEqual(o.object.GetFinalizers(), finalizers) },
+		func() { o.object.SetFinalizers(finalizers) },
+	)
+}
+func (o *passthroughObject) GetOwnerReferences() []metav1.OwnerReference {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetOwnerReferences()
+}
+func (o *passthroughObject) SetOwnerReferences(references []metav1.OwnerReference) {
+	o.conditionalSet(
+		func() bool { return reflect.DeepEqual(o.object.GetOwnerReferences(), references) },
+		func() { o.object.SetOwnerReferences(references) },
+	)
+}
+func (o *passthroughObject) GetClusterName() string {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetClusterName()
+}
+func (o *passthroughObject) SetClusterName(clusterName string) {
+	o.conditionalSet(
+		func() bool { return o.object.GetClusterName() == clusterName },
+		func() { o.object.SetClusterName(clusterName) },
+	)
+}
+func (o *passthroughObject) GetManagedFields() []metav1.ManagedFieldsEntry {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.object.GetManagedFields()
+}
+func (o *passthroughObject) SetManagedFields(managedFields []metav1.ManagedFieldsEntry) {
+	o.conditionalSet(
+		func() bool { return reflect.DeepEqual(o.object.GetManagedFields(), managedFields) },
+		func() { o.object.SetManagedFields(managedFields) },
+	)
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
+)
+
+func newPassthroughTestObject() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata": map[string]interface{}{
+			"name":            "squeak",
+			"resourceVersion": "1",
+		},
+		"spec": map[string]interface{}{
+			"count": int64(3),
+		},
+	}}
+}
+
+func TestPassthroughEncode(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+	codec := NewPassthroughCodec(s)
+
+	var stored bytes.Buffer
+	if err := s.Encode(newPassthroughTestObject(), &stored); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name string
+		// mutate is applied to both the decoded passthrough object and the plain
+		// object used to produce the expected bytes.
+		mutate func(obj metav1.Object)
+		// id is the identifier passed to CacheEncode; empty means the delegate's.
+		id runtime.Identifier
+		// expectEncodes is the number of full re-encodes expected.
+		expectEncodes int
+	}{
+		{
+			name:          "unmodified",
+			mutate:        func(metav1.Object) {},
+			expectEncodes: 0,
+		},
+		{
+			name: "resource version and self link fixups",
+			mutate: func(obj metav1.Object) {
+				obj.SetResourceVersion("2")
+				obj.SetSelfLink("/apis/example.com/v1/widgets/squeak")
+			},
+			expectEncodes: 0,
+		},
+		{
+			name: "no-op label write",
+			mutate: func(obj metav1.Object) {
+				obj.SetLabels(nil)
+			},
+			expectEncodes: 0,
+		},
+		{
+			name: "label write invalidates",
+			mutate: func(obj metav1.Object) {
+				obj.SetLabels(map[string]string{"modified": "true"})
+			},
+			expectEncodes: 1,
+		},
+		{
+			name:          "identifier mismatch",
+			mutate:        func(metav1.Object) {},
+			id:            runtime.Identifier("other"),
+			expectEncodes: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj, _, err := codec.Decode(stored.Bytes(), nil, &unstructured.Unstructured{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			co, ok := obj.(runtime.CacheableObject)
+			if !ok {
+				t.Fatalf("expected decoded object to implement runtime.CacheableObject, got %T", obj)
+			}
+			tc.mutate(obj.(metav1.Object))
+
+			expectedObj := newPassthroughTestObject()
+			tc.mutate(expectedObj)
+			var expected bytes.Buffer
+			if err := s.Encode(expectedObj, &expected); err != nil {
+				t.Fatal(err)
+			}
+
+			id := tc.id
+			if id == "" {
+				id = s.Identifier()
+			}
+			encodes := 0
+			var actual bytes.Buffer
+			err = co.CacheEncode(id, func(obj runtime.Object, w io.Writer) error {
+				encodes++
+				return s.Encode(obj, w)
+			}, &actual)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if encodes != tc.expectEncodes {
+				t.Errorf("expected %d full re-encodes, got %d", tc.expectEncodes, encodes)
+			}
+			if !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+				t.Errorf("expected bytes:\n% x\ngot:\n% x", expected.Bytes(), actual.Bytes())
+			}
+		})
+	}
+}
+
+func TestPassthroughEncodeGVKRewrite(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+	codec := NewPassthroughCodec(s)
+
+	var stored bytes.Buffer
+	if err := s.Encode(newPassthroughTestObject(), &stored); err != nil {
+		t.Fatal(err)
+	}
+	obj, _, err := codec.Decode(stored.Bytes(), nil, &unstructured.Unstructured{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{Group: "example.com", Version: "v2", Kind: "Widget"})
+
+	var actual bytes.Buffer
+	err = obj.(runtime.CacheableObject).CacheEncode(s.Identifier(), func(obj runtime.Object, w io.Writer) error {
+		t.Error("unexpected full re-encode")
+		return s.Encode(obj, w)
+	}, &actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := &unstructured.Unstructured{}
+	if _, _, err := s.Decode(actual.Bytes(), nil, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.GetAPIVersion(); got != "example.com/v2" {
+		t.Errorf("expected patched document to have apiVersion example.com/v2, got %q", got)
+	}
+}
+
+func TestPassthroughDecodeWithoutObjectMeta(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+	codec := NewPassthroughCodec(s)
+
+	var stored bytes.Buffer
+	original := &runtimetesting.ExternalSimple{
+		TypeMeta:   runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"},
+		TestString: "value",
+	}
+	if err := s.Encode(original, &stored); err != nil {
+		t.Fatal(err)
+	}
+	obj, _, err := codec.Decode(stored.Bytes(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Objects without object metadata can't have fix-ups tracked, so they are returned
+	// unwrapped.
+	if _, ok := obj.(runtime.CacheableObject); ok {
+		t.Errorf("expected object without object metadata not to be wrapped, got %T", obj)
+	}
+}
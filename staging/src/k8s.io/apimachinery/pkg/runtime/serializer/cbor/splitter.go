@@ -0,0 +1,44 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"io"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/cborseq"
+)
+
+// DocumentSplitter reads a stream of concatenated CBOR documents, such as a file of
+// manifests, returning one document at a time. It is the CBOR analog of the YAML
+// document reader: splitting is purely syntactic, so each returned document retains its
+// self-describe tag prefix and has not had any content restrictions applied to it. Those
+// are enforced when the document is decoded.
+type DocumentSplitter struct {
+	seq *cborseq.Reader
+}
+
+// NewDocumentSplitter returns a DocumentSplitter that reads documents from r.
+func NewDocumentSplitter(r io.Reader) *DocumentSplitter {
+	return &DocumentSplitter{seq: cborseq.NewReader(r)}
+}
+
+// Read returns the next document in the stream. It returns io.EOF when the stream is
+// exhausted, io.ErrUnexpectedEOF if the stream ends inside a document, and an error if
+// the next document is not well-formed.
+func (s *DocumentSplitter) Read() ([]byte, error) {
+	return s.seq.Next()
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
+)
+
+func TestDocumentSplitter(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	objects := []runtime.Object{
+		&runtimetesting.ExternalSimple{
+			TypeMeta:   runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"},
+			TestString: "first",
+		},
+		&runtimetesting.ExternalSimple{
+			TypeMeta:   runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"},
+			TestString: "second",
+		},
+		&runtimetesting.ExternalSimple{
+			TypeMeta:   runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"},
+			TestString: "third",
+		},
+	}
+
+	var stream bytes.Buffer
+	var want [][]byte
+	for _, obj := range objects {
+		var buf bytes.Buffer
+		if err := s.Encode(obj, &buf); err != nil {
+			t.Fatalf("unexpected encode error: %v", err)
+		}
+		want = append(want, buf.Bytes())
+		stream.Write(buf.Bytes())
+	}
+
+	splitter := NewDocumentSplitter(&stream)
+	for i, expected := range want {
+		doc, err := splitter.Read()
+		if err != nil {
+			t.Fatalf("unexpected error reading document %d: %v", i+1, err)
+		}
+		if !bytes.Equal(expected, doc) {
+			t.Errorf("document %d does not match its encoding:\nwant: % x\ngot:  % x", i+1, expected, doc)
+		}
+		// Each document decodes independently, with its self-describe prefix intact.
+		decoded, _, err := s.Decode(doc, nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error decoding document %d: %v", i+1, err)
+		}
+		if decoded.(*runtimetesting.ExternalSimple).TestString != objects[i].(*runtimetesting.ExternalSimple).TestString {
+			t.Errorf("document %d did not round trip", i+1)
+		}
+	}
+	if _, err := splitter.Read(); err != io.EOF {
+		t.Errorf("expected io.EOF at end of stream, got %v", err)
+	}
+}
+
+func TestDocumentSplitterEmpty(t *testing.T) {
+	if _, err := NewDocumentSplitter(bytes.NewReader(nil)).Read(); err != io.EOF {
+		t.Errorf("expected io.EOF for empty input, got %v", err)
+	}
+}
+
+func TestDocumentSplitterTruncated(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	var buf bytes.Buffer
+	if err := s.Encode(&runtimetesting.ExternalSimple{
+		TypeMeta:   runtime.TypeMeta{APIVersion: testGV.String(), Kind: "Simple"},
+		TestString: "value",
+	}, &buf); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+
+	splitter := NewDocumentSplitter(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	if _, err := splitter.Read(); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF for truncated document, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// TranscodeFromUnknown writes the CBOR encoding of the object carried by the provided
+// storage envelope. Objects persisted with the protobuf storage encoding are wrapped in
+// a runtime.Unknown message, and for unstructured-backed resources the wrapped payload
+// is JSON. Serving such an object as CBOR ordinarily decodes the payload into an object
+// and re-encodes it; this path transcodes the payload directly, never constructing an
+// object of the stored kind. Kind and apiVersion from the envelope are applied if the
+// payload omits them, mirroring what a decode of the envelope would produce.
+func TranscodeFromUnknown(w io.Writer, unk *runtime.Unknown) error {
+	switch unk.ContentType {
+	// An unspecified content type means JSON.
+	case runtime.ContentTypeJSON, "":
+	default:
+		return fmt.Errorf("cannot transcode content type %q to CBOR without decoding to an object", unk.ContentType)
+	}
+	if len(unk.ContentEncoding) > 0 {
+		return fmt.Errorf("cannot transcode content encoding %q to CBOR without decoding to an object", unk.ContentEncoding)
+	}
+
+	var content map[string]interface{}
+	if err := utiljson.Unmarshal(unk.Raw, &content); err != nil {
+		return err
+	}
+	if _, ok := content["kind"]; !ok && len(unk.Kind) > 0 {
+		content["kind"] = unk.Kind
+	}
+	if _, ok := content["apiVersion"]; !ok && len(unk.APIVersion) > 0 {
+		content["apiVersion"] = unk.APIVersion
+	}
+
+	if _, err := w.Write(selfDescribedCBOR); err != nil {
+		return err
+	}
+	encoded, err := modes.Encode.Marshal(content)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(encoded)
+	return err
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	jsonserializer "k8s.io/apimachinery/pkg/runtime/serializer/json"
+)
+
+func TestTranscodeFromUnknown(t *testing.T) {
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+
+	t.Run("matches decode and re-encode", func(t *testing.T) {
+		raw := []byte(`{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"x"},"spec":{"names":["a","b"],"replicas":3}}`)
+
+		var transcoded bytes.Buffer
+		if err := TranscodeFromUnknown(&transcoded, &runtime.Unknown{Raw: raw}); err != nil {
+			t.Fatalf("unexpected transcode error: %v", err)
+		}
+
+		js := jsonserializer.NewSerializerWithOptions(jsonserializer.DefaultMetaFactory, scheme, scheme, jsonserializer.SerializerOptions{})
+		obj, _, err := js.Decode(raw, nil, &unstructured.Unstructured{})
+		if err != nil {
+			t.Fatalf("unexpected decode error: %v", err)
+		}
+		var reencoded bytes.Buffer
+		if err := s.Encode(obj, &reencoded); err != nil {
+			t.Fatalf("unexpected encode error: %v", err)
+		}
+
+		if !bytes.Equal(transcoded.Bytes(), reencoded.Bytes()) {
+			t.Errorf("transcoded bytes differ from decode-and-re-encode: got % x, want % x", transcoded.Bytes(), reencoded.Bytes())
+		}
+	})
+
+	t.Run("envelope supplies missing kind and apiVersion", func(t *testing.T) {
+		unk := &runtime.Unknown{
+			TypeMeta: runtime.TypeMeta{APIVersion: "example.com/v1", Kind: "Widget"},
+			Raw:      []byte(`{"metadata":{"name":"x"}}`),
+		}
+
+		var buf bytes.Buffer
+		if err := TranscodeFromUnknown(&buf, unk); err != nil {
+			t.Fatalf("unexpected transcode error: %v", err)
+		}
+
+		into := &unstructured.Unstructured{}
+		if _, _, err := s.Decode(buf.Bytes(), nil, into); err != nil {
+			t.Fatalf("unexpected decode error: %v", err)
+		}
+		expected := map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata":   map[string]interface{}{"name": "x"},
+		}
+		if !reflect.DeepEqual(expected, into.Object) {
+			t.Errorf("unexpected content: got %#v, want %#v", into.Object, expected)
+		}
+	})
+
+	t.Run("payload kind and apiVersion win", func(t *testing.T) {
+		unk := &runtime.Unknown{
+			TypeMeta: runtime.TypeMeta{APIVersion: "example.com/v2", Kind: "Other"},
+			Raw:      []byte(`{"apiVersion":"example.com/v1","kind":"Widget"}`),
+		}
+
+		var buf bytes.Buffer
+		if err := TranscodeFromUnknown(&buf, unk); err != nil {
+			t.Fatalf("unexpected transcode error: %v", err)
+		}
+
+		into := &unstructured.Unstructured{}
+		if _, _, err := s.Decode(buf.Bytes(), nil, into); err != nil {
+			t.Fatalf("unexpected decode error: %v", err)
+		}
+		if into.GetAPIVersion() != "example.com/v1" || into.GetKind() != "Widget" {
+			t.Errorf("unexpected type: %s, %s", into.GetAPIVersion(), into.GetKind())
+		}
+	})
+
+	t.Run("protobuf payload is rejected", func(t *testing.T) {
+		unk := &runtime.Unknown{ContentType: "application/vnd.kubernetes.protobuf", Raw: []byte{0x0a}}
+		if err := TranscodeFromUnknown(ioutil.Discard, unk); err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+
+	t.Run("encoded payload is rejected", func(t *testing.T) {
+		unk := &runtime.Unknown{ContentEncoding: "gzip", Raw: []byte(`{}`)}
+		if err := TranscodeFromUnknown(ioutil.Discard, unk); err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+}
+
+func BenchmarkTranscodeFromUnknown(b *testing.B) {
+	items := make([]interface{}, 1000)
+	for i := range items {
+		items[i] = map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata": map[string]interface{}{
+				"name":      fmt.Sprintf("widget-%d", i),
+				"namespace": "default",
+				"labels":    map[string]interface{}{"index": fmt.Sprintf("%d", i)},
+			},
+			"spec": map[string]interface{}{
+				"replicas": 3,
+				"names":    []interface{}{"a", "b", "c"},
+			},
+		}
+	}
+	raw, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "WidgetList",
+		"metadata":   map[string]interface{}{"resourceVersion": "1"},
+		"items":      items,
+	})
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+	unk := &runtime.Unknown{Raw: raw}
+
+	scheme := newTestScheme()
+	s := NewSerializer(DefaultMetaFactory, scheme, scheme)
+	js := jsonserializer.NewSerializerWithOptions(jsonserializer.DefaultMetaFactory, scheme, scheme, jsonserializer.SerializerOptions{})
+
+	b.Run("Transcode", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := TranscodeFromUnknown(ioutil.Discard, unk); err != nil {
+				b.Fatalf("unexpected transcode error: %v", err)
+			}
+		}
+	})
+
+	b.Run("DecodeEncode", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			obj, _, err := js.Decode(unk.Raw, nil, &unstructured.Unstructured{})
+			if err != nil {
+				b.Fatalf("unexpected decode error: %v", err)
+			}
+			if err := s.Encode(obj, ioutil.Discard); err != nil {
+				b.Fatalf("unexpected encode error: %v", err)
+			}
+		}
+	})
+}
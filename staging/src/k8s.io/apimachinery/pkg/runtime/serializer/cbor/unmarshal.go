@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+)
+
+// Limits bounds resource consumption when decoding a document from an untrusted source.
+// A zero value for any field keeps the limit the serving decoders use. Limits can only
+// be tightened relative to the library's supported ranges, never removed.
+type Limits struct {
+	// MaxArrayElements is the maximum number of elements accepted in a single array.
+	MaxArrayElements int
+
+	// MaxMapPairs is the maximum number of key-value pairs accepted in a single map.
+	MaxMapPairs int
+
+	// MaxNestedLevels is the maximum depth of nested containers.
+	MaxNestedLevels int
+}
+
+// UnmarshalToUnstructuredContent decodes a single CBOR document into unstructured
+// content without requiring a Scheme. The document is subject to exactly the same
+// hardening as the serving decoders apply — duplicate map keys, non-text map keys,
+// invalid UTF-8, tags, and oversized or overly nested containers are all rejected — and
+// the result contains only the dynamic types produced by decoding the equivalent JSON,
+// with byte strings converted to base64 text. One leading self-describe tag prefix is
+// accepted, matching the serializer's output. It is intended for tools that inspect API
+// objects from storage, audit logs, or the wire and have no scheme to decode into.
+func UnmarshalToUnstructuredContent(data []byte, limits Limits) (map[string]interface{}, error) {
+	dec := modes.Decode
+	if limits != (Limits{}) {
+		var err error
+		dec, err = modes.DecModeWithLimits(limits.MaxArrayElements, limits.MaxMapPairs, limits.MaxNestedLevels)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limits: %v", err)
+		}
+	}
+
+	if bytes.HasPrefix(data, selfDescribedCBOR) {
+		data = data[len(selfDescribedCBOR):]
+	}
+
+	var content map[string]interface{}
+	if err := dec.Unmarshal(data, &content); err != nil {
+		return nil, err
+	}
+	convertByteStrings(content)
+	return content, nil
+}
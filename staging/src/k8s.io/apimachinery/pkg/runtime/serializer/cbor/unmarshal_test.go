@@ -0,0 +1,109 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cbor
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/internal/modes"
+)
+
+func TestUnmarshalToUnstructuredContent(t *testing.T) {
+	document := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"binaryData": map[string]interface{}{"key": []byte("value")},
+		"data":       map[string]interface{}{"count": int64(3)},
+	}
+	encoded, err := modes.Encode.Marshal(document)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"binaryData": map[string]interface{}{"key": "dmFsdWU="},
+		"data":       map[string]interface{}{"count": int64(3)},
+	}
+
+	t.Run("bare document", func(t *testing.T) {
+		content, err := UnmarshalToUnstructuredContent(encoded, Limits{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(expected, content) {
+			t.Errorf("unexpected content: got %#v, want %#v", content, expected)
+		}
+	})
+
+	t.Run("self-describe prefix accepted", func(t *testing.T) {
+		content, err := UnmarshalToUnstructuredContent(append(append([]byte(nil), selfDescribedCBOR...), encoded...), Limits{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(expected, content) {
+			t.Errorf("unexpected content: got %#v, want %#v", content, expected)
+		}
+	})
+
+	t.Run("trailing data rejected", func(t *testing.T) {
+		if _, err := UnmarshalToUnstructuredContent(append(append([]byte(nil), encoded...), encoded...), Limits{}); err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+
+	t.Run("duplicate map keys rejected", func(t *testing.T) {
+		// {"a": 1, "a": 2}
+		data := []byte{0xa2, 0x61, 'a', 0x01, 0x61, 'a', 0x02}
+		if _, err := UnmarshalToUnstructuredContent(data, Limits{}); err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+
+	t.Run("tightened array limit", func(t *testing.T) {
+		big, err := modes.Encode.Marshal(map[string]interface{}{"items": make([]interface{}, 32)})
+		if err != nil {
+			t.Fatalf("unexpected marshal error: %v", err)
+		}
+		if _, err := UnmarshalToUnstructuredContent(big, Limits{MaxArrayElements: 16}); err == nil {
+			t.Error("expected error, got none")
+		}
+		if _, err := UnmarshalToUnstructuredContent(big, Limits{}); err != nil {
+			t.Errorf("unexpected error under default limits: %v", err)
+		}
+	})
+
+	t.Run("tightened nesting limit", func(t *testing.T) {
+		deep := bytes.Repeat([]byte{0xa1, 0x61, 'x'}, 8) // 8 levels of {"x": ...}
+		deep = append(deep, 0x01)
+		if _, err := UnmarshalToUnstructuredContent(deep, Limits{MaxNestedLevels: 4}); err == nil {
+			t.Error("expected error, got none")
+		}
+		if _, err := UnmarshalToUnstructuredContent(deep, Limits{}); err != nil {
+			t.Errorf("unexpected error under default limits: %v", err)
+		}
+	})
+
+	t.Run("unsupported limit value", func(t *testing.T) {
+		if _, err := UnmarshalToUnstructuredContent(encoded, Limits{MaxNestedLevels: 1}); err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+}
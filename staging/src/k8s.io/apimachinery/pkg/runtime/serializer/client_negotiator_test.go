@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serializer
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	cborserializer "k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+)
+
+// TestClientNegotiatorFallbackDecoder exercises each sniffing branch of a fallback chain
+// built from UniversalDeserializer: a proxy that rewrites or strips Content-Type must not
+// keep the client from decoding a payload whose format is evident from its prefix.
+func TestClientNegotiatorFallbackDecoder(t *testing.T) {
+	gv := schema.GroupVersion{Version: "v1"}
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypes(gv, &metav1.Status{})
+	codecs := NewCodecFactory(scheme, WithSerializer(cborserializer.NewSerializerInfo))
+
+	status := &metav1.Status{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Status"}, Message: "hello"}
+
+	encode := func(mediaType string) []byte {
+		info, ok := runtime.SerializerInfoForMediaType(codecs.SupportedMediaTypes(), mediaType)
+		if !ok {
+			t.Fatalf("no serializer for %q", mediaType)
+		}
+		data, err := runtime.Encode(codecs.EncoderForVersion(info.Serializer, gv), status)
+		if err != nil {
+			t.Fatalf("failed to encode as %q: %v", mediaType, err)
+		}
+		return data
+	}
+
+	for _, tc := range []struct {
+		name        string
+		contentType string
+		data        []byte
+	}{
+		{name: "cbor payload recognized by self-describe prefix", contentType: "text/garbled", data: encode("application/cbor")},
+		{name: "protobuf payload recognized by magic prefix", contentType: "text/garbled", data: encode("application/vnd.kubernetes.protobuf")},
+		{name: "unrecognized payload falls back to json", contentType: "text/garbled", data: encode("application/json")},
+		{name: "missing content type", contentType: "", data: encode("application/vnd.kubernetes.protobuf")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			negotiator := runtime.NewClientNegotiatorWithFallbackDecoder(codecs.WithoutConversion(), gv, codecs.UniversalDeserializer())
+			decoder, err := negotiator.Decoder(tc.contentType, nil)
+			if err != nil {
+				t.Fatalf("unexpected negotiation error: %v", err)
+			}
+			obj, err := runtime.Decode(decoder, tc.data)
+			if err != nil {
+				t.Fatalf("unexpected decode error: %v", err)
+			}
+			decoded, ok := obj.(*metav1.Status)
+			if !ok {
+				t.Fatalf("expected *metav1.Status, got %T", obj)
+			}
+			if decoded.Message != status.Message {
+				t.Errorf("expected message %q, got %q", status.Message, decoded.Message)
+			}
+		})
+	}
+
+	t.Run("supported content types negotiate normally", func(t *testing.T) {
+		negotiator := runtime.NewClientNegotiatorWithFallbackDecoder(codecs.WithoutConversion(), gv, codecs.UniversalDeserializer())
+		decoder, err := negotiator.Decoder("application/json", nil)
+		if err != nil {
+			t.Fatalf("unexpected negotiation error: %v", err)
+		}
+		if _, err := runtime.Decode(decoder, encode("application/json")); err != nil {
+			t.Fatalf("unexpected decode error: %v", err)
+		}
+	})
+
+	t.Run("no fallback configured", func(t *testing.T) {
+		negotiator := runtime.NewClientNegotiator(codecs.WithoutConversion(), gv)
+		if _, err := negotiator.Decoder("text/garbled", nil); err == nil {
+			t.Fatal("expected negotiation error, got none")
+		}
+	})
+}
@@ -17,8 +17,11 @@ limitations under the License.
 package serializer
 
 import (
+	"io"
 	"mime"
+	"reflect"
 	"strings"
+	"sync"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -37,14 +40,19 @@ type serializerType struct {
 	FileExtensions     []string
 	// EncodesAsText should be true if this content type can be represented safely in UTF-8
 	EncodesAsText bool
+	// EncodesNestedRaw should be true if nested RawExtension bytes pass through this
+	// content type without transcoding
+	EncodesNestedRaw bool
 
 	Serializer       runtime.Serializer
 	PrettySerializer runtime.Serializer
+	StrictSerializer runtime.Serializer
 
 	AcceptStreamContentTypes []string
 	StreamContentType        string
 
 	Framer           runtime.Framer
+	AlternateFramers map[string]runtime.Framer
 	StreamSerializer runtime.Serializer
 }
 
@@ -58,6 +66,7 @@ func newSerializersForScheme(scheme *runtime.Scheme, mf json.MetaFactory, option
 		ContentType:        runtime.ContentTypeJSON,
 		FileExtensions:     []string{"json"},
 		EncodesAsText:      true,
+		EncodesNestedRaw:   true,
 		Serializer:         jsonSerializer,
 
 		Framer:           json.Framer,
@@ -90,6 +99,7 @@ func newSerializersForScheme(scheme *runtime.Scheme, mf json.MetaFactory, option
 			AcceptContentTypes: []string{runtime.ContentTypeProtobuf},
 			ContentType:        runtime.ContentTypeProtobuf,
 			FileExtensions:     []string{"pb"},
+			EncodesNestedRaw:   true,
 			Serializer:         protoSerializer,
 
 			Framer:           protobuf.LengthDelimitedFramer,
@@ -102,6 +112,33 @@ func newSerializersForScheme(scheme *runtime.Scheme, mf json.MetaFactory, option
 			serializers = append(serializers, serializer)
 		}
 	}
+
+	for _, f := range options.serializers {
+		info := f(scheme, scheme)
+		serializer := serializerType{
+			AcceptContentTypes: []string{info.MediaType},
+			ContentType:        info.MediaType,
+			EncodesAsText:      info.EncodesAsText,
+			EncodesNestedRaw:   info.EncodesNestedRaw,
+			Serializer:         info.Serializer,
+			PrettySerializer:   info.PrettySerializer,
+			StrictSerializer:   info.StrictSerializer,
+		}
+		if options.Strict && info.StrictSerializer != nil {
+			// The built-in serializers are constructed in strict mode above;
+			// additional serializers advertise a strict variant and it is used in
+			// place of the default here for the same effect.
+			serializer.Serializer = info.StrictSerializer
+		}
+		if info.StreamSerializer != nil {
+			serializer.AcceptStreamContentTypes = []string{info.MediaType}
+			serializer.StreamContentType = info.MediaType
+			serializer.Framer = info.StreamSerializer.Framer
+			serializer.AlternateFramers = info.StreamSerializer.AlternateFramers
+			serializer.StreamSerializer = info.StreamSerializer.Serializer
+		}
+		serializers = append(serializers, serializer)
+	}
 	return serializers
 }
 
@@ -114,6 +151,24 @@ type CodecFactory struct {
 	accepts     []runtime.SerializerInfo
 
 	legacySerializer runtime.Serializer
+
+	codecs *codecCache
+}
+
+// codecCacheKey identifies a constructed codec chain by the identifier of the serializer
+// that backs it and the group version it targets.
+type codecCacheKey struct {
+	identifier     runtime.Identifier
+	groupVersioner string
+}
+
+// codecCache memoizes the codec chains constructed by EncoderForVersion and
+// DecoderToVersion, which are re-resolved on hot paths like storage and watch encoding.
+// CodecFactory is copied by value, so the cache is held behind a pointer and shared by
+// all copies of the factory that created it.
+type codecCache struct {
+	encoders sync.Map // codecCacheKey -> runtime.Encoder
+	decoders sync.Map // codecCacheKey -> runtime.Decoder
 }
 
 // CodecFactoryOptions holds the options for configuring CodecFactory behavior
@@ -122,6 +177,8 @@ type CodecFactoryOptions struct {
 	Strict bool
 	// Pretty includes a pretty serializer along with the non-pretty one
 	Pretty bool
+
+	serializers []func(runtime.ObjectCreater, runtime.ObjectTyper) runtime.SerializerInfo
 }
 
 // CodecFactoryOptionsMutator takes a pointer to an options struct and then modifies it.
@@ -148,6 +205,13 @@ func DisableStrict(options *CodecFactoryOptions) {
 	options.Strict = false
 }
 
+// WithSerializer configures a serializer to be used in addition to the default serializers.
+func WithSerializer(f func(runtime.ObjectCreater, runtime.ObjectTyper) runtime.SerializerInfo) CodecFactoryOptionsMutator {
+	return func(options *CodecFactoryOptions) {
+		options.serializers = append(options.serializers, f)
+	}
+}
+
 // NewCodecFactory provides methods for retrieving serializers for the supported wire formats
 // and conversion wrappers to define preferred internal and external versions. In the future,
 // as the internal version is used less, callers may instead use a defaulting serializer and
@@ -186,8 +250,10 @@ func newCodecFactory(scheme *runtime.Scheme, serializers []serializerType) Codec
 			info := runtime.SerializerInfo{
 				MediaType:        d.ContentType,
 				EncodesAsText:    d.EncodesAsText,
+				EncodesNestedRaw: d.EncodesNestedRaw,
 				Serializer:       d.Serializer,
 				PrettySerializer: d.PrettySerializer,
+				StrictSerializer: d.StrictSerializer,
 			}
 
 			mediaType, _, err := mime.ParseMediaType(info.MediaType)
@@ -200,9 +266,10 @@ func newCodecFactory(scheme *runtime.Scheme, serializers []serializerType) Codec
 
 			if d.StreamSerializer != nil {
 				info.StreamSerializer = &runtime.StreamSerializerInfo{
-					Serializer:    d.StreamSerializer,
-					EncodesAsText: d.EncodesAsText,
-					Framer:        d.Framer,
+					Serializer:       d.StreamSerializer,
+					EncodesAsText:    d.EncodesAsText,
+					Framer:           d.Framer,
+					AlternateFramers: d.AlternateFramers,
 				}
 			}
 			accepts = append(accepts, info)
@@ -223,6 +290,8 @@ func newCodecFactory(scheme *runtime.Scheme, serializers []serializerType) Codec
 		accepts: accepts,
 
 		legacySerializer: legacySerializer,
+
+		codecs: &codecCache{},
 	}
 }
 
@@ -245,7 +314,8 @@ func (f CodecFactory) SupportedMediaTypes() []runtime.SerializerInfo {
 // invoke CodecForVersions. Callers that need only to read data should use UniversalDecoder().
 //
 // TODO: make this call exist only in pkg/api, and initialize it with the set of default versions.
-//   All other callers will be forced to request a Codec directly.
+//
+//	All other callers will be forced to request a Codec directly.
 func (f CodecFactory) LegacyCodec(version ...schema.GroupVersion) runtime.Codec {
 	return versioning.NewDefaultingCodecForScheme(f.scheme, f.legacySerializer, f.universal, schema.GroupVersions(version), runtime.InternalGroupVersioner)
 }
@@ -289,14 +359,45 @@ func (f CodecFactory) CodecForVersions(encoder runtime.Encoder, decoder runtime.
 	return versioning.NewDefaultingCodecForScheme(f.scheme, encoder, decoder, encode, decode)
 }
 
-// DecoderToVersion returns a decoder that targets the provided group version.
+// DecoderToVersion returns a decoder that targets the provided group version. Decoders
+// constructed for a serializer that also implements Encoder, and therefore has an
+// identifier, are memoized and shared across calls.
 func (f CodecFactory) DecoderToVersion(decoder runtime.Decoder, gv runtime.GroupVersioner) runtime.Decoder {
-	return f.CodecForVersions(nil, decoder, nil, gv)
+	identifiable, ok := decoder.(runtime.Encoder)
+	if !ok || f.codecs == nil {
+		return f.CodecForVersions(nil, decoder, nil, gv)
+	}
+	key := codecCacheKey{identifier: identifiable.Identifier(), groupVersioner: groupVersionerIdentifier(gv)}
+	if cached, ok := f.codecs.decoders.Load(key); ok {
+		return cached.(runtime.Decoder)
+	}
+	constructed, _ := f.codecs.decoders.LoadOrStore(key, f.CodecForVersions(nil, decoder, nil, gv))
+	return constructed.(runtime.Decoder)
 }
 
-// EncoderForVersion returns an encoder that targets the provided group version.
+// EncoderForVersion returns an encoder that targets the provided group version. Encoders
+// are memoized by the serializer's identifier and the target group version and shared
+// across calls.
 func (f CodecFactory) EncoderForVersion(encoder runtime.Encoder, gv runtime.GroupVersioner) runtime.Encoder {
-	return f.CodecForVersions(encoder, nil, gv, nil)
+	if encoder == nil || f.codecs == nil {
+		return f.CodecForVersions(encoder, nil, gv, nil)
+	}
+	key := codecCacheKey{identifier: encoder.Identifier(), groupVersioner: groupVersionerIdentifier(gv)}
+	if cached, ok := f.codecs.encoders.Load(key); ok {
+		return cached.(runtime.Encoder)
+	}
+	constructed, _ := f.codecs.encoders.LoadOrStore(key, f.CodecForVersions(encoder, nil, gv, nil))
+	return constructed.(runtime.Encoder)
+}
+
+// groupVersionerIdentifier returns a string distinguishing gv from other group
+// versioners for use in a cache key. A nil group versioner is valid (CodecForVersions
+// substitutes a fixed default) and is given its own sentinel identifier.
+func groupVersionerIdentifier(gv runtime.GroupVersioner) string {
+	if gv == nil {
+		return "<nil>"
+	}
+	return gv.Identifier()
 }
 
 // WithoutConversionCodecFactory is a CodecFactory that will explicitly ignore requests to perform conversion.
@@ -309,11 +410,112 @@ type WithoutConversionCodecFactory struct {
 // EncoderForVersion returns an encoder that does not do conversion, but does set the group version kind of the object
 // when serialized.
 func (f WithoutConversionCodecFactory) EncoderForVersion(serializer runtime.Encoder, version runtime.GroupVersioner) runtime.Encoder {
-	return runtime.WithVersionEncoder{
-		Version:     version,
-		Encoder:     serializer,
-		ObjectTyper: f.CodecFactory.scheme,
+	return &cachingVersionEncoder{
+		version: version,
+		encoder: serializer,
+		typer:   f.CodecFactory.scheme,
+	}
+}
+
+// gvkDecision is the outcome of selecting the group version kind to encode a Go type with.
+type gvkDecision struct {
+	gvk schema.GroupVersionKind
+	// set is false if the type is not registered and objects should be encoded without
+	// rewriting their group version kind.
+	set bool
+}
+
+// cachingVersionEncoder behaves like runtime.WithVersionEncoder but memoizes the typer
+// lookup and target version selection per Go type, since both are invariant for a given
+// encoder and repeating them dominates the per-object overhead when encoding many objects
+// of the same type (for example the items of a large list).
+type cachingVersionEncoder struct {
+	version runtime.GroupVersioner
+	encoder runtime.Encoder
+	typer   runtime.ObjectTyper
+
+	decisions sync.Map // reflect.Type -> gvkDecision
+}
+
+func (e *cachingVersionEncoder) Encode(obj runtime.Object, stream io.Writer) error {
+	return e.encode(obj, stream, e.encoder)
+}
+
+// EncodeWithAllocator implements runtime.EncoderWithAllocator. The allocator is forwarded
+// to the wrapped encoder when it accepts one.
+func (e *cachingVersionEncoder) EncodeWithAllocator(obj runtime.Object, stream io.Writer, memAlloc runtime.MemoryAllocator) error {
+	if encoder, ok := e.encoder.(runtime.EncoderWithAllocator); ok {
+		return e.encode(obj, stream, allocatingEncoder{encoder: encoder, memAlloc: memAlloc})
+	}
+	return e.encode(obj, stream, e.encoder)
+}
+
+// allocatingEncoder binds a memory allocator to an encoder that accepts one.
+type allocatingEncoder struct {
+	encoder  runtime.EncoderWithAllocator
+	memAlloc runtime.MemoryAllocator
+}
+
+func (e allocatingEncoder) Encode(obj runtime.Object, w io.Writer) error {
+	return e.encoder.EncodeWithAllocator(obj, w, e.memAlloc)
+}
+
+func (e allocatingEncoder) Identifier() runtime.Identifier {
+	return e.encoder.Identifier()
+}
+
+func (e *cachingVersionEncoder) encode(obj runtime.Object, stream io.Writer, encoder runtime.Encoder) error {
+	if _, ok := obj.(runtime.Unstructured); ok {
+		// The group version kind of an unstructured object is carried in its data, so
+		// decisions can't be cached by Go type.
+		return runtime.WithVersionEncoder{Version: e.version, Encoder: encoder, ObjectTyper: e.typer}.Encode(obj, stream)
+	}
+
+	var decision gvkDecision
+	if cached, ok := e.decisions.Load(reflect.TypeOf(obj)); ok {
+		decision = cached.(gvkDecision)
+	} else {
+		gvks, _, err := e.typer.ObjectKinds(obj)
+		switch {
+		case runtime.IsNotRegisteredError(err):
+			// fall through and cache a decision not to set the group version kind
+		case err != nil:
+			return err
+		default:
+			decision.gvk = gvks[0]
+			decision.set = true
+			if e.version != nil {
+				if preferredGVK, ok := e.version.KindForGroupVersionKinds(gvks); ok {
+					decision.gvk = preferredGVK
+				}
+			}
+		}
+		e.decisions.Store(reflect.TypeOf(obj), decision)
+	}
+
+	if !decision.set {
+		return encoder.Encode(obj, stream)
+	}
+	kind := obj.GetObjectKind()
+	oldGVK := kind.GroupVersionKind()
+	kind.SetGroupVersionKind(decision.gvk)
+	err := encoder.Encode(obj, stream)
+	kind.SetGroupVersionKind(oldGVK)
+	return err
+}
+
+// Identifier implements runtime.Encoder interface.
+func (e *cachingVersionEncoder) Identifier() runtime.Identifier {
+	return e.encoder.Identifier()
+}
+
+// EstimateEncodedSize implements runtime.EncodedSizeEstimator by delegating to the
+// wrapped encoder, if it provides an estimate.
+func (e *cachingVersionEncoder) EstimateEncodedSize(obj runtime.Object) (int, bool) {
+	if estimator, ok := e.encoder.(runtime.EncodedSizeEstimator); ok {
+		return estimator.EstimateEncodedSize(obj)
 	}
+	return 0, false
 }
 
 // DecoderToVersion returns an decoder that does not do conversion.
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serializer
+
+import (
+	"bytes"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
+)
+
+func TestEncoderForVersionMemoization(t *testing.T) {
+	scheme, _ := GetTestScheme()
+	cf := newCodecFactory(scheme, newSerializersForScheme(scheme, testMetaFactory{}, CodecFactoryOptions{}))
+	jsonInfo, _ := runtime.SerializerInfoForMediaType(cf.SupportedMediaTypes(), runtime.ContentTypeJSON)
+	yamlInfo, _ := runtime.SerializerInfoForMediaType(cf.SupportedMediaTypes(), runtime.ContentTypeYAML)
+
+	first := cf.EncoderForVersion(jsonInfo.Serializer, schema.GroupVersion{Version: "v1"})
+	second := cf.EncoderForVersion(jsonInfo.Serializer, schema.GroupVersion{Version: "v1"})
+	if first != second {
+		t.Errorf("expected repeated calls with the same serializer and group version to return the same encoder")
+	}
+	if other := cf.EncoderForVersion(jsonInfo.Serializer, schema.GroupVersion{Group: "other", Version: "v2"}); other == first {
+		t.Errorf("expected a different group version to return a different encoder")
+	}
+	if other := cf.EncoderForVersion(yamlInfo.Serializer, schema.GroupVersion{Version: "v1"}); other == first {
+		t.Errorf("expected a different serializer to return a different encoder")
+	}
+
+	// A memoized encoder must encode the same bytes as a freshly constructed one.
+	obj := &runtimetesting.ExternalTestType1{A: "value"}
+	var fresh, memoized bytes.Buffer
+	if err := cf.CodecForVersions(jsonInfo.Serializer, nil, schema.GroupVersion{Version: "v1"}, nil).Encode(obj, &fresh); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+	if err := second.Encode(obj, &memoized); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+	if !bytes.Equal(fresh.Bytes(), memoized.Bytes()) {
+		t.Errorf("expected %q, got %q", fresh.Bytes(), memoized.Bytes())
+	}
+}
+
+func TestSupportedMediaTypesCapabilities(t *testing.T) {
+	scheme, _ := GetTestScheme()
+	cf := newCodecFactory(scheme, newSerializersForScheme(scheme, testMetaFactory{}, CodecFactoryOptions{Pretty: true}))
+
+	for mediaType, want := range map[string]runtime.SerializerCapabilities{
+		runtime.ContentTypeJSON:     {Streaming: true, Pretty: true, NestedRaw: true},
+		runtime.ContentTypeYAML:     {},
+		runtime.ContentTypeProtobuf: {Streaming: true, NestedRaw: true, Binary: true},
+	} {
+		info, ok := runtime.SerializerInfoForMediaType(cf.SupportedMediaTypes(), mediaType)
+		if !ok {
+			t.Errorf("%s: no serializer info", mediaType)
+			continue
+		}
+		if got := info.Capabilities(); got != want {
+			t.Errorf("%s: capabilities %+v, expected %+v", mediaType, got, want)
+		}
+	}
+}
+
+func TestDecoderToVersionMemoization(t *testing.T) {
+	scheme, _ := GetTestScheme()
+	cf := newCodecFactory(scheme, newSerializersForScheme(scheme, testMetaFactory{}, CodecFactoryOptions{}))
+	info, _ := runtime.SerializerInfoForMediaType(cf.SupportedMediaTypes(), runtime.ContentTypeJSON)
+
+	first := cf.DecoderToVersion(info.Serializer, schema.GroupVersion{Version: "__internal"})
+	second := cf.DecoderToVersion(info.Serializer, schema.GroupVersion{Version: "__internal"})
+	if first != second {
+		t.Errorf("expected repeated calls with the same serializer and group version to return the same decoder")
+	}
+	if other := cf.DecoderToVersion(info.Serializer, schema.GroupVersion{Version: "v1"}); other == first {
+		t.Errorf("expected a different group version to return a different decoder")
+	}
+
+	obj, _, err := second.Decode([]byte(`{"myVersionKey":"v1","myKindKey":"TestType1","A":"value"}`), nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if obj == nil {
+		t.Fatal("expected a decoded object")
+	}
+
+	// The universal deserializer has no identifier, so decoders wrapping it are
+	// constructed per call but must still work.
+	universal := cf.DecoderToVersion(cf.UniversalDeserializer(), schema.GroupVersion{Version: "__internal"})
+	if _, _, err := universal.Decode([]byte(`{"myVersionKey":"v1","myKindKey":"TestType1","A":"value"}`), nil, nil); err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+}
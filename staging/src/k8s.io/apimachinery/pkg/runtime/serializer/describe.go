@@ -0,0 +1,73 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serializer
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SerializerDescription summarizes the capabilities of one supported media type in a
+// form suitable for logging or for serving from a debug endpoint. Whether a given
+// serializer is configured can otherwise only be determined by issuing requests against
+// a running server.
+type SerializerDescription struct {
+	// MediaType is the value that selects this serializer during content negotiation.
+	MediaType string `json:"mediaType"`
+	// EncodesAsText is true if the encoded form is valid UTF-8.
+	EncodesAsText bool `json:"encodesAsText"`
+	// Pretty is true if a variant formatted for human viewing is available.
+	Pretty bool `json:"pretty"`
+	// Strict is true if a variant that errs on unknown or duplicate fields is
+	// available.
+	Strict bool `json:"strict"`
+	// Stream is true if a streaming variant is available for watch responses.
+	Stream bool `json:"stream"`
+	// StreamFramings lists the values accepted for the "framing" media type parameter,
+	// which selects a framing other than the streaming variant's default.
+	StreamFramings []string `json:"streamFramings,omitempty"`
+}
+
+// DescribeMediaTypes summarizes the capabilities of every media type supported by the
+// provided negotiated serializer, in the serializer's preference order.
+func DescribeMediaTypes(ns runtime.NegotiatedSerializer) []SerializerDescription {
+	var descriptions []SerializerDescription
+	for _, info := range ns.SupportedMediaTypes() {
+		description := SerializerDescription{
+			MediaType:     info.MediaType,
+			EncodesAsText: info.EncodesAsText,
+			Pretty:        info.PrettySerializer != nil,
+			Strict:        info.StrictSerializer != nil,
+			Stream:        info.StreamSerializer != nil,
+		}
+		if info.StreamSerializer != nil {
+			for framing := range info.StreamSerializer.AlternateFramers {
+				description.StreamFramings = append(description.StreamFramings, framing)
+			}
+			sort.Strings(description.StreamFramings)
+		}
+		descriptions = append(descriptions, description)
+	}
+	return descriptions
+}
+
+// SerializerDescriptions summarizes the capabilities of every media type this factory
+// has serializers for.
+func (f CodecFactory) SerializerDescriptions() []SerializerDescription {
+	return DescribeMediaTypes(f)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serializer
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+)
+
+func TestSerializerDescriptions(t *testing.T) {
+	scheme, _ := GetTestScheme()
+	cf := NewCodecFactory(scheme, WithSerializer(cbor.NewSerializerInfo))
+
+	expected := []SerializerDescription{
+		{
+			MediaType:     "application/json",
+			EncodesAsText: true,
+			Pretty:        true,
+			Stream:        true,
+		},
+		{
+			MediaType:     "application/yaml",
+			EncodesAsText: true,
+		},
+		{
+			MediaType: "application/vnd.kubernetes.protobuf",
+			Stream:    true,
+		},
+		{
+			MediaType:      "application/cbor",
+			Strict:         true,
+			Stream:         true,
+			StreamFramings: []string{"length-delimited"},
+		},
+	}
+	if descriptions := cf.SerializerDescriptions(); !reflect.DeepEqual(expected, descriptions) {
+		t.Errorf("unexpected descriptions: got %#v, want %#v", descriptions, expected)
+	}
+}
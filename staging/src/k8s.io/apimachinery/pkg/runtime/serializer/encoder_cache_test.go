@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serializer
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
+)
+
+func TestCachingVersionEncoder(t *testing.T) {
+	scheme, _ := GetTestScheme()
+	info, _ := runtime.SerializerInfoForMediaType(newCodecFactory(scheme, newSerializersForScheme(scheme, testMetaFactory{}, CodecFactoryOptions{})).SupportedMediaTypes(), runtime.ContentTypeJSON)
+	factory := WithoutConversionCodecFactory{CodecFactory: newCodecFactory(scheme, newSerializersForScheme(scheme, testMetaFactory{}, CodecFactoryOptions{}))}
+	encoder := factory.EncoderForVersion(info.Serializer, schema.GroupVersion{Version: "v1"})
+
+	reference := runtime.WithVersionEncoder{
+		Version:     schema.GroupVersion{Version: "v1"},
+		Encoder:     info.Serializer,
+		ObjectTyper: scheme,
+	}
+
+	objects := []runtime.Object{
+		&runtimetesting.ExternalTestType1{A: "first"},
+		&runtimetesting.ExternalTestType1{A: "second"},
+		&runtimetesting.ExternalTestType2{},
+		&unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Custom"}},
+	}
+	// Encode each object twice so the second pass exercises the cached decisions.
+	objects = append(objects, objects...)
+	for i, obj := range objects {
+		expected := &bytes.Buffer{}
+		if err := reference.Encode(obj.DeepCopyObject(), expected); err != nil {
+			t.Fatalf("%d: unexpected error from reference encoder: %v", i, err)
+		}
+		actual := &bytes.Buffer{}
+		if err := encoder.Encode(obj, actual); err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if expected.String() != actual.String() {
+			t.Errorf("%d: expected %q, got %q", i, expected.String(), actual.String())
+		}
+		if gvk := obj.GetObjectKind().GroupVersionKind(); !gvk.Empty() && gvk.Kind != "Custom" {
+			t.Errorf("%d: expected group version kind to be restored after encode, got %v", i, gvk)
+		}
+	}
+}
+
+// allocatorRecordingEncoder wraps a serializer and records the memory allocator passed
+// through EncodeWithAllocator, so a test can tell whether the allocator-aware path was
+// actually taken rather than silently degrading to plain Encode.
+type allocatorRecordingEncoder struct {
+	runtime.Serializer
+	memAlloc runtime.MemoryAllocator
+}
+
+func (e *allocatorRecordingEncoder) EncodeWithAllocator(obj runtime.Object, w io.Writer, memAlloc runtime.MemoryAllocator) error {
+	e.memAlloc = memAlloc
+	return e.Serializer.Encode(obj, w)
+}
+
+func TestCachingVersionEncoderWithAllocator(t *testing.T) {
+	scheme, _ := GetTestScheme()
+	info, _ := runtime.SerializerInfoForMediaType(newCodecFactory(scheme, newSerializersForScheme(scheme, testMetaFactory{}, CodecFactoryOptions{})).SupportedMediaTypes(), runtime.ContentTypeJSON)
+	inner := &allocatorRecordingEncoder{Serializer: info.Serializer}
+	factory := WithoutConversionCodecFactory{CodecFactory: newCodecFactory(scheme, newSerializersForScheme(scheme, testMetaFactory{}, CodecFactoryOptions{}))}
+	encoder, ok := factory.EncoderForVersion(inner, schema.GroupVersion{Version: "v1"}).(runtime.EncoderWithAllocator)
+	if !ok {
+		t.Fatal("expected the caching encoder to implement runtime.EncoderWithAllocator")
+	}
+
+	allocator := &runtime.SimpleAllocator{}
+	// Encode a typed object twice so both the uncached and the cached decision paths
+	// are covered, then an unstructured object for the uncacheable branch.
+	for i, obj := range []runtime.Object{
+		&runtimetesting.ExternalTestType1{A: "first"},
+		&runtimetesting.ExternalTestType1{A: "second"},
+		&unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Custom"}},
+	} {
+		inner.memAlloc = nil
+		if err := encoder.EncodeWithAllocator(obj, ioutil.Discard, allocator); err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if inner.memAlloc != allocator {
+			t.Errorf("%d: allocator was not forwarded to the wrapped encoder", i)
+		}
+	}
+}
+
+func BenchmarkWithVersionEncoder(b *testing.B) {
+	scheme, _ := GetTestScheme()
+	info, _ := runtime.SerializerInfoForMediaType(newCodecFactory(scheme, newSerializersForScheme(scheme, testMetaFactory{}, CodecFactoryOptions{})).SupportedMediaTypes(), runtime.ContentTypeJSON)
+	obj := &runtimetesting.ExternalTestType1{A: "benchmark"}
+
+	b.Run("uncached", func(b *testing.B) {
+		encoder := runtime.WithVersionEncoder{
+			Version:     schema.GroupVersion{Version: "v1"},
+			Encoder:     info.Serializer,
+			ObjectTyper: scheme,
+		}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := encoder.Encode(obj, ioutil.Discard); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		encoder := WithoutConversionCodecFactory{CodecFactory: newCodecFactory(scheme, newSerializersForScheme(scheme, testMetaFactory{}, CodecFactoryOptions{}))}.EncoderForVersion(info.Serializer, schema.GroupVersion{Version: "v1"})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := encoder.Encode(obj, ioutil.Discard); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package unknownfields locates the entries of a decoded document that do not correspond
+// to any field of a destination Go type. The strict serializers use it to name the
+// offending fields in their errors, since the underlying decoders either report only the
+// first violation or identify fields by position instead of by path.
+package unknownfields
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Paths returns the paths of the entries of a decoded document, in sorted order, that do
+// not correspond to any field of the destination type. The document uses the untyped
+// conventions shared by the decoders: map[string]interface{} for maps and []interface{}
+// for arrays. Matching is exact, consistent with the strict decoders, so a key differing
+// from a field name only by case is unknown. Types with custom unmarshalers accept forms
+// that a field-by-field walk can't predict and are not descended into.
+func Paths(decoded interface{}, t reflect.Type) []string {
+	var paths []string
+	walk("", decoded, t, &paths)
+	sort.Strings(paths)
+	return paths
+}
+
+func walk(path string, decoded interface{}, t reflect.Type, paths *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface {
+		return
+	}
+	if reflect.PtrTo(t).Implements(jsonUnmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return
+	}
+	switch decoded := decoded.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Map:
+			for key, value := range decoded {
+				walk(join(path, key), value, t.Elem(), paths)
+			}
+		case reflect.Struct:
+			fields := fieldsByName(t)
+			for key, value := range decoded {
+				field, ok := fields[key]
+				if !ok {
+					*paths = append(*paths, join(path, key))
+					continue
+				}
+				walk(join(path, key), value, field.Type, paths)
+			}
+		}
+	case []interface{}:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, item := range decoded {
+				walk(fmt.Sprintf("%s[%d]", path, i), item, t.Elem(), paths)
+			}
+		}
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// fieldsByName maps the document keys accepted by a struct type to the fields they
+// populate, flattening embedded structs without their own key the way the JSON field
+// conventions do.
+func fieldsByName(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	collectFields(t, fields)
+	return fields
+}
+
+func collectFields(t reflect.Type, fields map[string]reflect.StructField) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			if field.Anonymous {
+				embedded := field.Type
+				for embedded.Kind() == reflect.Ptr {
+					embedded = embedded.Elem()
+				}
+				if embedded.Kind() == reflect.Struct {
+					collectFields(embedded, fields)
+					continue
+				}
+			}
+			name = field.Name
+		}
+		fields[name] = field
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unknownfields
+
+import (
+	"reflect"
+	"testing"
+)
+
+type inner struct {
+	Known string `json:"known"`
+}
+
+type withUnmarshaler struct{}
+
+func (*withUnmarshaler) UnmarshalJSON([]byte) error { return nil }
+
+type outer struct {
+	Embedded `json:",inline"`
+	Name     string           `json:"name"`
+	Nested   inner            `json:"nested"`
+	Items    []inner          `json:"items"`
+	ByKey    map[string]inner `json:"byKey"`
+	Ignored  string           `json:"-"`
+	Custom   *withUnmarshaler `json:"custom"`
+	Untagged string
+	Any      interface{}        `json:"any"`
+	Pointers []*inner           `json:"pointers"`
+	Deep     map[string][]inner `json:"deep"`
+}
+
+type Embedded struct {
+	Inlined string `json:"inlined"`
+}
+
+func TestPaths(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		decoded  interface{}
+		expected []string
+	}{
+		{
+			name:     "all fields known",
+			decoded:  map[string]interface{}{"name": "x", "inlined": "y", "Untagged": "z"},
+			expected: nil,
+		},
+		{
+			name:     "unknown at top level",
+			decoded:  map[string]interface{}{"name": "x", "extra": true},
+			expected: []string{"extra"},
+		},
+		{
+			name:     "case mismatch is unknown",
+			decoded:  map[string]interface{}{"Name": "x"},
+			expected: []string{"Name"},
+		},
+		{
+			name:     "ignored tag is unknown",
+			decoded:  map[string]interface{}{"-": "x"},
+			expected: []string{"-"},
+		},
+		{
+			name:     "unknown in nested struct",
+			decoded:  map[string]interface{}{"nested": map[string]interface{}{"known": "x", "extra": 1}},
+			expected: []string{"nested.extra"},
+		},
+		{
+			name: "unknown in list element",
+			decoded: map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"known": "x"},
+				map[string]interface{}{"extra": 1},
+			}},
+			expected: []string{"items[1].extra"},
+		},
+		{
+			name:     "unknown under pointer element",
+			decoded:  map[string]interface{}{"pointers": []interface{}{map[string]interface{}{"extra": 1}}},
+			expected: []string{"pointers[0].extra"},
+		},
+		{
+			name:     "map keys are arbitrary but values are checked",
+			decoded:  map[string]interface{}{"byKey": map[string]interface{}{"anything": map[string]interface{}{"extra": 1}}},
+			expected: []string{"byKey.anything.extra"},
+		},
+		{
+			name:     "nested containers",
+			decoded:  map[string]interface{}{"deep": map[string]interface{}{"k": []interface{}{map[string]interface{}{"extra": 1}}}},
+			expected: []string{"deep.k[0].extra"},
+		},
+		{
+			name:     "custom unmarshaler is not descended into",
+			decoded:  map[string]interface{}{"custom": map[string]interface{}{"anything": 1}},
+			expected: nil,
+		},
+		{
+			name:     "interface field accepts anything",
+			decoded:  map[string]interface{}{"any": map[string]interface{}{"anything": 1}},
+			expected: nil,
+		},
+		{
+			name: "multiple unknowns are sorted",
+			decoded: map[string]interface{}{
+				"zz":     1,
+				"aa":     2,
+				"nested": map[string]interface{}{"extra": 3},
+			},
+			expected: []string{"aa", "nested.extra", "zz"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := Paths(tc.decoded, reflect.TypeOf(&outer{}))
+			if len(actual) == 0 && len(tc.expected) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
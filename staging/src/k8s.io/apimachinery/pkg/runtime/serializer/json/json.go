@@ -19,6 +19,7 @@ package json
 import (
 	"encoding/json"
 	"io"
+	"reflect"
 	"strconv"
 	"unsafe"
 
@@ -28,6 +29,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/internal/unknownfields"
 	"k8s.io/apimachinery/pkg/runtime/serializer/recognizer"
 	"k8s.io/apimachinery/pkg/util/framer"
 	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
@@ -286,12 +288,28 @@ func (s *Serializer) Decode(originalData []byte, gvk *schema.GroupVersionKind, i
 	// the actual error is that the object contains unknown field.
 	strictObj := obj.DeepCopyObject()
 	if err := strictCaseSensitiveJsonIterator.Unmarshal(altered, strictObj); err != nil {
+		// The unmarshal error reports only the first unknown field and only by its leaf
+		// name. Recover the complete set of paths so that callers can report each
+		// offending field individually.
+		if paths := unknownFieldPaths(altered, strictObj); len(paths) > 0 {
+			return nil, actual, runtime.NewStrictDecodingErrorForFields(err.Error(), string(originalData), paths...)
+		}
 		return nil, actual, runtime.NewStrictDecodingError(err.Error(), string(originalData))
 	}
 	// Always return the same object as the non-strict serializer to avoid any deviations.
 	return obj, actual, nil
 }
 
+// unknownFieldPaths returns the paths of the fields of a document that do not correspond
+// to any field of the destination object, or nil if the document can't be examined.
+func unknownFieldPaths(data []byte, into runtime.Object) []string {
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil
+	}
+	return unknownfields.Paths(decoded, reflect.TypeOf(into))
+}
+
 // Encode serializes the provided object to the given writer.
 func (s *Serializer) Encode(obj runtime.Object, w io.Writer) error {
 	if co, ok := obj.(runtime.CacheableObject); ok {
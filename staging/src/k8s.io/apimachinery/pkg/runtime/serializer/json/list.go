@@ -0,0 +1,237 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	goruntime "runtime"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var _ runtime.ListEncoder = &Serializer{}
+var _ runtime.ListDecoder = &Serializer{}
+
+// minParallelEncodeItems is the smallest item count for which list items are encoded
+// concurrently. Shorter lists pay more for worker startup and envelope assembly than the
+// concurrency saves.
+const minParallelEncodeItems = 1000
+
+// EncodeList writes an object to a stream as Encode does. Typed lists of a recognized
+// shape with enough items to benefit have their items encoded concurrently; everything
+// else, including the YAML and pretty variants and unstructured lists, takes the ordinary
+// Encode path.
+func (s *Serializer) EncodeList(list runtime.Object, w io.Writer) error {
+	if co, ok := list.(runtime.CacheableObject); ok {
+		return co.CacheEncode(s.Identifier(), s.doEncodeList, w)
+	}
+	return s.doEncodeList(list, w)
+}
+
+func (s *Serializer) doEncodeList(obj runtime.Object, w io.Writer) error {
+	if !s.options.Yaml && !s.options.Pretty {
+		if handled, err := s.encodeListParallel(obj, w); handled {
+			return err
+		}
+	}
+	return s.doEncode(obj, w)
+}
+
+// encodeListParallel encodes obj with its list items encoded concurrently, if obj is a
+// typed list of a recognized shape with enough items to benefit. It returns false if obj
+// was not handled and should be encoded ordinarily. The bytes written are identical to
+// those the sequential path produces.
+func (s *Serializer) encodeListParallel(obj runtime.Object, w io.Writer) (bool, error) {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return false, nil
+	}
+	v = v.Elem()
+	typeMeta, listMeta, itemsValue, ok := listShape(v)
+	if !ok || itemsValue.Len() < minParallelEncodeItems {
+		return false, nil
+	}
+
+	items, err := encodeItemsParallel(itemsValue.Len(), func(i int) interface{} {
+		return itemsValue.Index(i).Addr().Interface()
+	})
+	if err != nil {
+		return true, err
+	}
+
+	// JSON object members appear in field declaration order, so the envelope is
+	// assembled by hand in the order the sequential path would emit its fields: the
+	// inlined type meta, then metadata, then items. listShape accepts only that
+	// declaration order.
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	typeMetaJSON, err := json.Marshal(typeMeta.Interface())
+	if err != nil {
+		return true, err
+	}
+	// Splice the inlined type meta members into the envelope without their enclosing
+	// braces. Both fields carry omitempty tags, so there may be nothing to splice.
+	if inlined := typeMetaJSON[1 : len(typeMetaJSON)-1]; len(inlined) > 0 {
+		buf.Write(inlined)
+		buf.WriteByte(',')
+	}
+	// Struct values are never omitted by encoding/json's omitempty handling, so the
+	// metadata member is always present, exactly as on the sequential path.
+	metadataJSON, err := json.Marshal(listMeta.Interface())
+	if err != nil {
+		return true, err
+	}
+	buf.WriteString(`"metadata":`)
+	buf.Write(metadataJSON)
+	buf.WriteString(`,"items":[`)
+	for i, item := range items {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(item)
+	}
+	// The sequential path is a json.Encoder, which terminates every document with a
+	// newline.
+	buf.WriteString("]}\n")
+	_, err = w.Write(buf.Bytes())
+	return true, err
+}
+
+// listShape returns the type meta, list meta, and items fields of v if and only if v has
+// exactly the shape of an API list object: an inlined type meta, followed by a list
+// metadata struct under the "metadata" key, followed by a slice of items under the
+// "items" key. Lists with other fields, or with the same fields declared in a different
+// order, are not recognized, since encodeListParallel would not reproduce them.
+func listShape(v reflect.Value) (typeMeta, listMeta, items reflect.Value, ok bool) {
+	t := v.Type()
+	if t.NumField() != 3 {
+		return reflect.Value{}, reflect.Value{}, reflect.Value{}, false
+	}
+	typeMetaField, listMetaField, itemsField := t.Field(0), t.Field(1), t.Field(2)
+	if !typeMetaField.Anonymous || typeMetaField.Tag.Get("json") != ",inline" || !isTypeMetaShape(typeMetaField.Type) {
+		return reflect.Value{}, reflect.Value{}, reflect.Value{}, false
+	}
+	if listMetaField.Tag.Get("json") != "metadata,omitempty" || listMetaField.Type.Kind() != reflect.Struct {
+		return reflect.Value{}, reflect.Value{}, reflect.Value{}, false
+	}
+	if itemsField.Tag.Get("json") != "items" || itemsField.Type.Kind() != reflect.Slice {
+		return reflect.Value{}, reflect.Value{}, reflect.Value{}, false
+	}
+	return v.Field(0), v.Field(1), v.Field(2), true
+}
+
+// isTypeMetaShape returns true if t has exactly the fields of a type meta struct, in
+// either order.
+func isTypeMetaShape(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t.NumField() != 2 {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type.Kind() != reflect.String {
+			return false
+		}
+		switch f.Tag.Get("json") {
+		case "kind,omitempty", "apiVersion,omitempty":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// encodeItemsParallel encodes n items concurrently and returns their encodings in order.
+// Items are partitioned into contiguous ranges, one per worker.
+func encodeItemsParallel(n int, item func(i int) interface{}) ([][]byte, error) {
+	items := make([][]byte, n)
+	workers := goruntime.NumCPU()
+	if workers > n {
+		workers = n
+	}
+	chunk := (n + workers - 1) / workers
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		begin, end := w*chunk, (w+1)*chunk
+		if end > n {
+			end = n
+		}
+		wg.Add(1)
+		go func(w, begin, end int) {
+			defer wg.Done()
+			for i := begin; i < end; i++ {
+				data, err := json.Marshal(item(i))
+				if err != nil {
+					errs[w] = err
+					return
+				}
+				items[i] = data
+			}
+		}(w, begin, end)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// DecodeList decodes a document directly into the provided destination, skipping the
+// scan Decode performs to extract the stored type metadata and the type lookup it
+// performs to resolve a destination. The strict variant retains its checks for duplicate
+// and unknown fields.
+func (s *Serializer) DecodeList(data []byte, into runtime.Object) error {
+	originalData := data
+	if s.options.Yaml {
+		altered, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return err
+		}
+		data = altered
+	}
+	if err := caseSensitiveJsonIterator.Unmarshal(data, into); err != nil {
+		return err
+	}
+	if !s.options.Strict {
+		return nil
+	}
+	if _, ok := into.(runtime.Unstructured); ok {
+		// An unstructured destination has no fields for the document to mismatch.
+		return nil
+	}
+	// See Decode for the rationale behind the double unmarshal.
+	altered, err := yaml.YAMLToJSONStrict(originalData)
+	if err != nil {
+		return runtime.NewStrictDecodingError(err.Error(), string(originalData))
+	}
+	strictObj := into.DeepCopyObject()
+	if err := strictCaseSensitiveJsonIterator.Unmarshal(altered, strictObj); err != nil {
+		if paths := unknownFieldPaths(altered, strictObj); len(paths) > 0 {
+			return runtime.NewStrictDecodingErrorForFields(err.Error(), string(originalData), paths...)
+		}
+		return runtime.NewStrictDecodingError(err.Error(), string(originalData))
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package json_test
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/json"
+	runtimetesting "k8s.io/apimachinery/pkg/runtime/testing"
+)
+
+// aboveParallelThreshold is comfortably more items than the serializer requires before it
+// encodes list items concurrently.
+const aboveParallelThreshold = 2000
+
+type testSimpleList struct {
+	runtime.TypeMeta `json:",inline"`
+	Metadata         testListMeta                    `json:"metadata,omitempty"`
+	Items            []runtimetesting.ExternalSimple `json:"items"`
+}
+
+type testListMeta struct {
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+func (l *testSimpleList) DeepCopyObject() runtime.Object {
+	out := *l
+	out.Items = append([]runtimetesting.ExternalSimple(nil), l.Items...)
+	return &out
+}
+
+// testReorderedList declares the same fields as testSimpleList in a different order, so
+// it must not be encoded through the parallel path, which emits fields in the canonical
+// order only.
+type testReorderedList struct {
+	runtime.TypeMeta `json:",inline"`
+	Items            []runtimetesting.ExternalSimple `json:"items"`
+	Metadata         testListMeta                    `json:"metadata,omitempty"`
+}
+
+func (l *testReorderedList) DeepCopyObject() runtime.Object {
+	out := *l
+	out.Items = append([]runtimetesting.ExternalSimple(nil), l.Items...)
+	return &out
+}
+
+func newTestSimpleList(n int) *testSimpleList {
+	list := &testSimpleList{
+		TypeMeta: runtime.TypeMeta{APIVersion: "test.group/v1", Kind: "SimpleList"},
+		Metadata: testListMeta{ResourceVersion: "42"},
+	}
+	for i := 0; i < n; i++ {
+		// The item strings include characters that encoding/json escapes, to catch any
+		// escaping differences between the sequential and parallel paths.
+		list.Items = append(list.Items, runtimetesting.ExternalSimple{TestString: fmt.Sprintf("<item> & #%d", i)})
+	}
+	return list
+}
+
+func TestEncodeListMatchesEncode(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	objects := []struct {
+		name string
+		obj  runtime.Object
+	}{
+		{
+			name: "typed list above threshold",
+			obj:  newTestSimpleList(aboveParallelThreshold),
+		},
+		{
+			name: "typed list below threshold",
+			obj:  newTestSimpleList(3),
+		},
+		{
+			name: "typed list with empty type and list metadata",
+			obj:  &testSimpleList{Items: newTestSimpleList(aboveParallelThreshold).Items},
+		},
+		{
+			name: "list with reordered fields",
+			obj: &testReorderedList{
+				TypeMeta: runtime.TypeMeta{APIVersion: "test.group/v1", Kind: "ReorderedList"},
+				Items:    newTestSimpleList(aboveParallelThreshold).Items,
+			},
+		},
+		{
+			name: "non-list object",
+			obj:  &runtimetesting.ExternalSimple{TestString: "<value>"},
+		},
+	}
+
+	variants := []struct {
+		name    string
+		options json.SerializerOptions
+	}{
+		{name: "json"},
+		{name: "yaml", options: json.SerializerOptions{Yaml: true}},
+		{name: "pretty", options: json.SerializerOptions{Pretty: true}},
+	}
+
+	for _, variant := range variants {
+		s := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme, scheme, variant.options)
+		for _, tc := range objects {
+			t.Run(variant.name+"/"+tc.name, func(t *testing.T) {
+				var want, got bytes.Buffer
+				if err := s.Encode(tc.obj, &want); err != nil {
+					t.Fatalf("unexpected Encode error: %v", err)
+				}
+				if err := s.EncodeList(tc.obj, &got); err != nil {
+					t.Fatalf("unexpected EncodeList error: %v", err)
+				}
+				if !bytes.Equal(want.Bytes(), got.Bytes()) {
+					t.Errorf("Encode and EncodeList produced different bytes:\nEncode:     %s\nEncodeList: %s", want.Bytes(), got.Bytes())
+				}
+			})
+		}
+	}
+}
+
+func TestDecodeList(t *testing.T) {
+	scheme := runtime.NewScheme()
+	s := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme, scheme, json.SerializerOptions{})
+
+	t.Run("typed", func(t *testing.T) {
+		original := newTestSimpleList(3)
+		data, err := runtime.Encode(s, original)
+		if err != nil {
+			t.Fatalf("unexpected encode error: %v", err)
+		}
+		into := &testSimpleList{}
+		if err := s.DecodeList(data, into); err != nil {
+			t.Fatalf("unexpected decode error: %v", err)
+		}
+		if !reflect.DeepEqual(original, into) {
+			t.Errorf("expected %#v, got %#v", original, into)
+		}
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		yamlSerializer := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme, scheme, json.SerializerOptions{Yaml: true})
+		original := newTestSimpleList(3)
+		data, err := runtime.Encode(yamlSerializer, original)
+		if err != nil {
+			t.Fatalf("unexpected encode error: %v", err)
+		}
+		into := &testSimpleList{}
+		if err := yamlSerializer.DecodeList(data, into); err != nil {
+			t.Fatalf("unexpected decode error: %v", err)
+		}
+		if !reflect.DeepEqual(original, into) {
+			t.Errorf("expected %#v, got %#v", original, into)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		data := []byte(`{"apiVersion":"test.group/v1","kind":"SimpleList","metadata":{},"items":[],"bogus":true}`)
+		if err := s.DecodeList(data, &testSimpleList{}); err != nil {
+			t.Errorf("unexpected non-strict decode error: %v", err)
+		}
+		strict := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme, scheme, json.SerializerOptions{Strict: true})
+		err := strict.DecodeList(data, &testSimpleList{})
+		if !runtime.IsStrictDecodingError(err) {
+			t.Errorf("expected a strict decoding error for an unknown field, got %v", err)
+		}
+	})
+}
+
+func BenchmarkListEncode(b *testing.B) {
+	scheme := runtime.NewScheme()
+	s := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme, scheme, json.SerializerOptions{})
+	for _, n := range []int{1000, 10000} {
+		list := newTestSimpleList(n)
+		b.Run(fmt.Sprintf("items=%d/encode", n), func(b *testing.B) {
+			var buf bytes.Buffer
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				if err := s.Encode(list, &buf); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("items=%d/encodeList", n), func(b *testing.B) {
+			var buf bytes.Buffer
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				if err := s.EncodeList(list, &buf); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkListDecode(b *testing.B) {
+	scheme := runtime.NewScheme()
+	s := json.NewSerializerWithOptions(json.DefaultMetaFactory, scheme, scheme, json.SerializerOptions{})
+	for _, n := range []int{1000, 10000} {
+		data, err := runtime.Encode(s, newTestSimpleList(n))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("items=%d/decode", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, _, err := s.Decode(data, nil, &testSimpleList{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("items=%d/decodeList", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := s.DecodeList(data, &testSimpleList{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -41,3 +41,77 @@ func (n *negotiatedSerializerWrapper) EncoderForVersion(e runtime.Encoder, _ run
 func (n *negotiatedSerializerWrapper) DecoderToVersion(d runtime.Decoder, _gv runtime.GroupVersioner) runtime.Decoder {
 	return d
 }
+
+type negotiatedSerializerWithPreference struct {
+	runtime.NegotiatedSerializer
+	preferred []string
+}
+
+// WithMediaTypePreference returns a NegotiatedSerializer whose supported media types are
+// reordered so that media types named in preferred appear first, in the given order. Media
+// types that do not appear in preferred keep their relative order after the preferred ones,
+// and media types in preferred that the underlying serializer does not support are ignored.
+// Because negotiation selects the first supported media type when a client's Accept header
+// does not express a preference (for example `*/*`), this controls the default wire format
+// offered to such clients. If preferred is empty, ns is returned unchanged.
+func WithMediaTypePreference(ns runtime.NegotiatedSerializer, preferred []string) runtime.NegotiatedSerializer {
+	if len(preferred) == 0 {
+		return ns
+	}
+	return &negotiatedSerializerWithPreference{NegotiatedSerializer: ns, preferred: preferred}
+}
+
+type negotiatedSerializerWithRestriction struct {
+	runtime.NegotiatedSerializer
+	allowed []string
+	extra   []runtime.SerializerInfo
+}
+
+// WithMediaTypeRestriction returns a NegotiatedSerializer whose supported media types are
+// limited to those of ns named in allowed, followed by the provided extra SerializerInfos.
+// An empty allowed list keeps all of ns's media types, and extra serializers are offered
+// regardless of allowed. If allowed and extra are both empty, ns is returned unchanged.
+func WithMediaTypeRestriction(ns runtime.NegotiatedSerializer, allowed []string, extra []runtime.SerializerInfo) runtime.NegotiatedSerializer {
+	if len(allowed) == 0 && len(extra) == 0 {
+		return ns
+	}
+	return &negotiatedSerializerWithRestriction{NegotiatedSerializer: ns, allowed: allowed, extra: extra}
+}
+
+func (n *negotiatedSerializerWithRestriction) SupportedMediaTypes() []runtime.SerializerInfo {
+	supported := n.NegotiatedSerializer.SupportedMediaTypes()
+	restricted := make([]runtime.SerializerInfo, 0, len(supported)+len(n.extra))
+	for i := range supported {
+		if len(n.allowed) == 0 {
+			restricted = append(restricted, supported[i])
+			continue
+		}
+		for _, mediaType := range n.allowed {
+			if supported[i].MediaType == mediaType {
+				restricted = append(restricted, supported[i])
+				break
+			}
+		}
+	}
+	return append(restricted, n.extra...)
+}
+
+func (n *negotiatedSerializerWithPreference) SupportedMediaTypes() []runtime.SerializerInfo {
+	supported := n.NegotiatedSerializer.SupportedMediaTypes()
+	reordered := make([]runtime.SerializerInfo, 0, len(supported))
+	used := make([]bool, len(supported))
+	for _, mediaType := range n.preferred {
+		for i := range supported {
+			if !used[i] && supported[i].MediaType == mediaType {
+				reordered = append(reordered, supported[i])
+				used[i] = true
+			}
+		}
+	}
+	for i := range supported {
+		if !used[i] {
+			reordered = append(reordered, supported[i])
+		}
+	}
+	return reordered
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serializer
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type fakeNegotiatedSerializer struct {
+	runtime.NegotiatedSerializer
+	mediaTypes []string
+}
+
+func (f *fakeNegotiatedSerializer) SupportedMediaTypes() []runtime.SerializerInfo {
+	infos := make([]runtime.SerializerInfo, 0, len(f.mediaTypes))
+	for _, mediaType := range f.mediaTypes {
+		infos = append(infos, runtime.SerializerInfo{MediaType: mediaType})
+	}
+	return infos
+}
+
+func TestWithMediaTypeRestriction(t *testing.T) {
+	supported := []string{"application/json", "application/yaml", "application/vnd.kubernetes.protobuf"}
+	testCases := []struct {
+		name     string
+		allowed  []string
+		extra    []string
+		expected []string
+	}{
+		{
+			name:     "no restriction",
+			expected: supported,
+		},
+		{
+			name:     "restrict to subset",
+			allowed:  []string{"application/json"},
+			expected: []string{"application/json"},
+		},
+		{
+			name:     "unsupported allowed media types are dropped",
+			allowed:  []string{"application/json", "application/unknown"},
+			expected: []string{"application/json"},
+		},
+		{
+			name:     "extra media types extend the supported set",
+			extra:    []string{"application/custom"},
+			expected: []string{"application/json", "application/yaml", "application/vnd.kubernetes.protobuf", "application/custom"},
+		},
+		{
+			name:     "restriction and extension combine",
+			allowed:  []string{"application/yaml"},
+			extra:    []string{"application/custom"},
+			expected: []string{"application/yaml", "application/custom"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var extra []runtime.SerializerInfo
+			for _, mediaType := range tc.extra {
+				extra = append(extra, runtime.SerializerInfo{MediaType: mediaType})
+			}
+			ns := WithMediaTypeRestriction(&fakeNegotiatedSerializer{mediaTypes: supported}, tc.allowed, extra)
+			var actual []string
+			for _, info := range ns.SupportedMediaTypes() {
+				actual = append(actual, info.MediaType)
+			}
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWithMediaTypePreference(t *testing.T) {
+	supported := []string{"application/json", "application/yaml", "application/vnd.kubernetes.protobuf"}
+	testCases := []struct {
+		name      string
+		preferred []string
+		expected  []string
+	}{
+		{
+			name:      "empty preference preserves order",
+			preferred: nil,
+			expected:  supported,
+		},
+		{
+			name:      "single preferred media type moves to front",
+			preferred: []string{"application/vnd.kubernetes.protobuf"},
+			expected:  []string{"application/vnd.kubernetes.protobuf", "application/json", "application/yaml"},
+		},
+		{
+			name:      "full reorder",
+			preferred: []string{"application/yaml", "application/vnd.kubernetes.protobuf", "application/json"},
+			expected:  []string{"application/yaml", "application/vnd.kubernetes.protobuf", "application/json"},
+		},
+		{
+			name:      "unsupported preferred media types are ignored",
+			preferred: []string{"application/unknown", "application/yaml"},
+			expected:  []string{"application/yaml", "application/json", "application/vnd.kubernetes.protobuf"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ns := WithMediaTypePreference(&fakeNegotiatedSerializer{mediaTypes: supported}, tc.preferred)
+			var actual []string
+			for _, info := range ns.SupportedMediaTypes() {
+				actual = append(actual, info.MediaType)
+			}
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package versioning
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"reflect"
@@ -124,6 +125,26 @@ func identifier(encodeGV runtime.GroupVersioner, encoder runtime.Encoder) runtim
 // successful, the returned runtime.Object will be the value passed as into. Note that this may bypass conversion if you pass an
 // into that matches the serialized version.
 func (c *codec) Decode(data []byte, defaultGVK *schema.GroupVersionKind, into runtime.Object) (runtime.Object, *schema.GroupVersionKind, error) {
+	return c.doDecode(data, defaultGVK, into, c.decoder.Decode)
+}
+
+// DecodeContext decodes as Decode does, returning the context's error instead of
+// starting if ctx has been canceled. If the underlying decoder is not context-aware,
+// cancellation is not observed once decoding has begun.
+func (c *codec) DecodeContext(ctx context.Context, data []byte, defaultGVK *schema.GroupVersionKind, into runtime.Object) (runtime.Object, *schema.GroupVersionKind, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, defaultGVK, err
+	}
+	decodeFn := c.decoder.Decode
+	if decoder, supportsContext := c.decoder.(runtime.ContextDecoder); supportsContext {
+		decodeFn = func(data []byte, defaults *schema.GroupVersionKind, into runtime.Object) (runtime.Object, *schema.GroupVersionKind, error) {
+			return decoder.DecodeContext(ctx, data, defaults, into)
+		}
+	}
+	return c.doDecode(data, defaultGVK, into, decodeFn)
+}
+
+func (c *codec) doDecode(data []byte, defaultGVK *schema.GroupVersionKind, into runtime.Object, decodeFn func([]byte, *schema.GroupVersionKind, runtime.Object) (runtime.Object, *schema.GroupVersionKind, error)) (runtime.Object, *schema.GroupVersionKind, error) {
 	// If the into object is unstructured and expresses an opinion about its group/version,
 	// create a new instance of the type so we always exercise the conversion path (skips short-circuiting on `into == obj`)
 	decodeInto := into
@@ -133,7 +154,7 @@ func (c *codec) Decode(data []byte, defaultGVK *schema.GroupVersionKind, into ru
 		}
 	}
 
-	obj, gvk, err := c.decoder.Decode(data, defaultGVK, decodeInto)
+	obj, gvk, err := decodeFn(data, defaultGVK, decodeInto)
 	if err != nil {
 		return nil, gvk, err
 	}
@@ -178,16 +199,77 @@ func (c *codec) Decode(data []byte, defaultGVK *schema.GroupVersionKind, into ru
 // Encode ensures the provided object is output in the appropriate group and version, invoking
 // conversion if necessary. Unversioned objects (according to the ObjectTyper) are output as is.
 func (c *codec) Encode(obj runtime.Object, w io.Writer) error {
+	return c.encode(obj, w, nil)
+}
+
+// EncodeWithAllocator writes an object to a stream as Encode does.
+// In addition, it allows for providing a memory allocator for efficient memory usage during object serialization.
+func (c *codec) EncodeWithAllocator(obj runtime.Object, w io.Writer, memAlloc runtime.MemoryAllocator) error {
+	return c.encode(obj, w, memAlloc)
+}
+
+// EncodeContext writes an object to a stream as Encode does, aborting when ctx is
+// canceled. If the underlying encoder is not context-aware, cancellation is checked only
+// once before encoding begins.
+func (c *codec) EncodeContext(ctx context.Context, obj runtime.Object, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	encodeFn := func(obj runtime.Object, w io.Writer) error {
+		return c.encoder.Encode(obj, w)
+	}
+	if encoder, supportsContext := c.encoder.(runtime.ContextEncoder); supportsContext {
+		encodeFn = func(obj runtime.Object, w io.Writer) error {
+			return encoder.EncodeContext(ctx, obj, w)
+		}
+	}
 	if co, ok := obj.(runtime.CacheableObject); ok {
-		return co.CacheEncode(c.Identifier(), c.doEncode, w)
+		return co.CacheEncode(c.Identifier(), func(obj runtime.Object, w io.Writer) error { return c.doEncode(obj, w, encodeFn) }, w)
 	}
-	return c.doEncode(obj, w)
+	return c.doEncode(obj, w, encodeFn)
 }
 
-func (c *codec) doEncode(obj runtime.Object, w io.Writer) error {
+// EncodeList writes a list to a stream as Encode does, converting it if necessary and
+// then encoding through the underlying serializer's specialized list entry point when it
+// has one.
+func (c *codec) EncodeList(list runtime.Object, w io.Writer) error {
+	encodeFn := func(obj runtime.Object, w io.Writer) error {
+		return c.encoder.Encode(obj, w)
+	}
+	if listEncoder, supportsLists := c.encoder.(runtime.ListEncoder); supportsLists {
+		encodeFn = func(obj runtime.Object, w io.Writer) error {
+			return listEncoder.EncodeList(obj, w)
+		}
+	}
+	if co, ok := list.(runtime.CacheableObject); ok {
+		return co.CacheEncode(c.Identifier(), func(obj runtime.Object, w io.Writer) error { return c.doEncode(obj, w, encodeFn) }, w)
+	}
+	return c.doEncode(list, w, encodeFn)
+}
+
+func (c *codec) encode(obj runtime.Object, w io.Writer, memAlloc runtime.MemoryAllocator) error {
+	encodeFn := func(obj runtime.Object, w io.Writer) error {
+		return c.encoder.Encode(obj, w)
+	}
+	if memAlloc != nil {
+		if encoder, supportsAllocator := c.encoder.(runtime.EncoderWithAllocator); supportsAllocator {
+			encodeFn = func(obj runtime.Object, w io.Writer) error {
+				return encoder.EncodeWithAllocator(obj, w, memAlloc)
+			}
+		} else {
+			klog.V(6).Infof("a memory allocator was provided but the encoder %s doesn't implement the runtime.EncoderWithAllocator, using regular encoder.Encode method", c.encoder.Identifier())
+		}
+	}
+	if co, ok := obj.(runtime.CacheableObject); ok {
+		return co.CacheEncode(c.Identifier(), func(obj runtime.Object, w io.Writer) error { return c.doEncode(obj, w, encodeFn) }, w)
+	}
+	return c.doEncode(obj, w, encodeFn)
+}
+
+func (c *codec) doEncode(obj runtime.Object, w io.Writer, encodeFn func(runtime.Object, io.Writer) error) error {
 	switch obj := obj.(type) {
 	case *runtime.Unknown:
-		return c.encoder.Encode(obj, w)
+		return encodeFn(obj, w)
 	case runtime.Unstructured:
 		// An unstructured list can contain objects of multiple group version kinds. don't short-circuit just
 		// because the top-level type matches our desired destination type. actually send the object to the converter
@@ -196,14 +278,14 @@ func (c *codec) doEncode(obj runtime.Object, w io.Writer) error {
 			// avoid conversion roundtrip if GVK is the right one already or is empty (yes, this is a hack, but the old behaviour we rely on in kubectl)
 			objGVK := obj.GetObjectKind().GroupVersionKind()
 			if len(objGVK.Version) == 0 {
-				return c.encoder.Encode(obj, w)
+				return encodeFn(obj, w)
 			}
 			targetGVK, ok := c.encodeVersion.KindForGroupVersionKinds([]schema.GroupVersionKind{objGVK})
 			if !ok {
 				return runtime.NewNotRegisteredGVKErrForTarget(c.originalSchemeName, objGVK, c.encodeVersion)
 			}
 			if targetGVK == objGVK {
-				return c.encoder.Encode(obj, w)
+				return encodeFn(obj, w)
 			}
 		}
 	}
@@ -225,7 +307,7 @@ func (c *codec) doEncode(obj runtime.Object, w io.Writer) error {
 			}
 		}
 		objectKind.SetGroupVersionKind(gvks[0])
-		return c.encoder.Encode(obj, w)
+		return encodeFn(obj, w)
 	}
 
 	// Perform a conversion if necessary
@@ -241,7 +323,7 @@ func (c *codec) doEncode(obj runtime.Object, w io.Writer) error {
 	}
 
 	// Conversion is responsible for setting the proper group, version, and kind onto the outgoing object
-	return c.encoder.Encode(out, w)
+	return encodeFn(out, w)
 }
 
 // Identifier implements runtime.Encoder interface.
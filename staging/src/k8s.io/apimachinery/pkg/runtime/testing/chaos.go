@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ChaosSerializer decorates a runtime.Serializer and corrupts a fraction of its encoded
+// output, so that tests can verify that decoders and their callers degrade gracefully on
+// malformed documents in every media type. Each corrupted document is either truncated
+// at a random offset, has a single bit flipped, or is rotated about a random pivot.
+// Corruption is driven by a seeded source, so a failure reproduces from its seed.
+// Decoding is delegated unchanged.
+type ChaosSerializer struct {
+	// Serializer produces the output to be corrupted.
+	Serializer runtime.Serializer
+	// Probability is the chance, between 0 and 1, that the output of a given Encode
+	// call is corrupted.
+	Probability float64
+
+	lock      sync.Mutex
+	rand      *rand.Rand
+	corrupted int
+}
+
+var _ runtime.Serializer = &ChaosSerializer{}
+
+// NewChaosSerializer returns a serializer that corrupts encoded output from the delegate
+// with the given probability. Tests should log the seed so that failures reproduce.
+func NewChaosSerializer(delegate runtime.Serializer, seed int64, probability float64) *ChaosSerializer {
+	return &ChaosSerializer{
+		Serializer:  delegate,
+		Probability: probability,
+		rand:        rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (s *ChaosSerializer) Encode(obj runtime.Object, w io.Writer) error {
+	var buf bytes.Buffer
+	if err := s.Serializer.Encode(obj, &buf); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+
+	s.lock.Lock()
+	if s.rand.Float64() < s.Probability && len(data) > 0 {
+		data = s.corruptLocked(data)
+		s.corrupted++
+	}
+	s.lock.Unlock()
+
+	_, err := w.Write(data)
+	return err
+}
+
+func (s *ChaosSerializer) corruptLocked(data []byte) []byte {
+	switch s.rand.Intn(3) {
+	case 0:
+		// Truncate, possibly to nothing.
+		return data[:s.rand.Intn(len(data))]
+	case 1:
+		// Flip a single bit.
+		data[s.rand.Intn(len(data))] ^= 1 << uint(s.rand.Intn(8))
+		return data
+	default:
+		// Rotate about a pivot, reordering the document's content.
+		pivot := s.rand.Intn(len(data))
+		return append(append(make([]byte, 0, len(data)), data[pivot:]...), data[:pivot]...)
+	}
+}
+
+func (s *ChaosSerializer) Decode(data []byte, defaults *schema.GroupVersionKind, into runtime.Object) (runtime.Object, *schema.GroupVersionKind, error) {
+	return s.Serializer.Decode(data, defaults, into)
+}
+
+// Identifier is distinct from the delegate's so that cached encodings are never mistaken
+// for its output.
+func (s *ChaosSerializer) Identifier() runtime.Identifier {
+	return runtime.Identifier("chaos:" + string(s.Serializer.Identifier()))
+}
+
+// Corrupted returns the number of Encode calls whose output was corrupted.
+func (s *ChaosSerializer) Corrupted() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.corrupted
+}
@@ -44,6 +44,10 @@ const (
 	ContentTypeJSON     string = "application/json"
 	ContentTypeYAML     string = "application/yaml"
 	ContentTypeProtobuf string = "application/vnd.kubernetes.protobuf"
+	ContentTypeCBOR     string = "application/cbor"
+	// ContentTypeCBORSequence is the streaming variant of ContentTypeCBOR, framing each
+	// document per RFC 8742 CBOR Sequences.
+	ContentTypeCBORSequence string = "application/cbor-seq"
 )
 
 // RawExtension is used to hold extensions in external versions.
@@ -26,4 +26,7 @@ const (
 	MergePatchType          PatchType = "application/merge-patch+json"
 	StrategicMergePatchType PatchType = "application/strategic-merge-patch+json"
 	ApplyPatchType          PatchType = "application/apply-patch+yaml"
+	// ApplyCBORPatchType is an apply configuration encoded as CBOR instead of YAML or
+	// JSON. Servers without CBOR support reject it with 415 Unsupported Media Type.
+	ApplyCBORPatchType PatchType = "application/apply-patch+cbor"
 )
@@ -26,6 +26,7 @@ import (
 	"strings"
 
 	"github.com/google/gofuzz"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 	"k8s.io/klog/v2"
 )
 
@@ -88,6 +89,20 @@ func (intstr *IntOrString) UnmarshalJSON(value []byte) error {
 	return json.Unmarshal(value, &intstr.IntVal)
 }
 
+// UnmarshalCBOR implements the cbor unmarshaling interface. As in JSON, a text string
+// produces the string variant and an integer produces the int variant.
+func (intstr *IntOrString) UnmarshalCBOR(value []byte) error {
+	if err := direct.Unmarshal(value, &intstr.StrVal); err == nil {
+		intstr.Type = String
+		return nil
+	}
+	if err := direct.Unmarshal(value, &intstr.IntVal); err != nil {
+		return err
+	}
+	intstr.Type = Int
+	return nil
+}
+
 // String returns the string value, or the Itoa of the int value.
 func (intstr *IntOrString) String() string {
 	if intstr.Type == String {
@@ -119,6 +134,19 @@ func (intstr IntOrString) MarshalJSON() ([]byte, error) {
 	}
 }
 
+// MarshalCBOR implements the cbor marshaling interface, producing the inner type just
+// as MarshalJSON does.
+func (intstr IntOrString) MarshalCBOR() ([]byte, error) {
+	switch intstr.Type {
+	case Int:
+		return direct.Marshal(intstr.IntVal)
+	case String:
+		return direct.Marshal(intstr.StrVal)
+	default:
+		return nil, fmt.Errorf("impossible IntOrString.Type")
+	}
+}
+
 // OpenAPISchemaType is used by the kube-openapi generator when constructing
 // the OpenAPI spec of this type.
 //
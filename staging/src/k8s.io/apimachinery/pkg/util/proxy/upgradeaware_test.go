@@ -331,6 +331,35 @@ func TestServeHTTP(t *testing.T) {
 	}
 }
 
+func TestServeHTTPProxyErrorUsesResponder(t *testing.T) {
+	// Allocate a port with no listener so that the proxy's dial fails.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	backendURL, _ := url.Parse("http://" + listener.Addr().String() + "/some/path")
+	require.NoError(t, listener.Close())
+
+	responder := &fakeResponder{t: t}
+	proxyHandler := NewUpgradeAwareHandler(backendURL, nil, false, false, responder)
+	proxyServer := httptest.NewServer(proxyHandler)
+	defer proxyServer.Close()
+
+	res, err := http.Get(proxyServer.URL + "/some/path")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	if !responder.called {
+		t.Fatalf("responder was not invoked for the dial failure (status %d)", res.StatusCode)
+	}
+	if res.StatusCode != fakeStatusCode {
+		t.Errorf("expected the responder to write the response, got status %d", res.StatusCode)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	if !strings.Contains(string(body), "connect") {
+		t.Errorf("expected the dial error in the response body, got %q", string(body))
+	}
+}
+
 type RoundTripperFunc func(req *http.Request) (*http.Response, error)
 
 func (fn RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
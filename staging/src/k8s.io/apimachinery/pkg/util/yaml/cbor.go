@@ -0,0 +1,86 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yaml
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+
+	"sigs.k8s.io/yaml"
+)
+
+// YAMLToCBOR converts a single YAML document to a single CBOR data item by way of JSON,
+// so a YAML document and its JSON conversion always produce semantically identical CBOR.
+// Anchors and aliases are resolved during conversion. Numbers follow the fidelity rules
+// of the JSON interop utilities: integers that fit in an int64 are encoded as CBOR
+// integers, and all other numbers are encoded as floating point and may lose precision.
+// Strings are always encoded as text strings, even when they would parse as numbers.
+func YAMLToCBOR(data []byte) ([]byte, error) {
+	j, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("error converting YAML to JSON: %v", err)
+	}
+	var u interface{}
+	if err := utiljson.Unmarshal(j, &u); err != nil {
+		return nil, err
+	}
+	return direct.Marshal(u)
+}
+
+// CBORToYAML converts a single CBOR data item to a YAML document. Integers remain
+// integers and text strings remain strings. Byte strings have no direct YAML
+// representation and are converted to base64-encoded strings, matching the convention
+// for binary data in JSON. Documents containing values the decode modes reject, such as
+// text strings that are not valid UTF-8, are errors.
+func CBORToYAML(data []byte) ([]byte, error) {
+	var u interface{}
+	if err := direct.Unmarshal(data, &u); err != nil {
+		return nil, err
+	}
+	j, err := json.Marshal(u)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.JSONToYAML(j)
+}
+
+// YAMLStreamToCBOR converts each document in a YAML stream to a CBOR data item, returned
+// in the order the documents appear. Each document is converted independently as
+// YAMLToCBOR would.
+func YAMLStreamToCBOR(r io.Reader) ([][]byte, error) {
+	reader := NewYAMLReader(bufio.NewReader(r))
+	var items [][]byte
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		item, err := YAMLToCBOR(doc)
+		if err != nil {
+			return nil, fmt.Errorf("error converting document %d: %v", len(items)+1, err)
+		}
+		items = append(items, item)
+	}
+}
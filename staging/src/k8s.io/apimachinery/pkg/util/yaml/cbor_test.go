@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package yaml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+)
+
+func TestYAMLToCBOR(t *testing.T) {
+	testCases := []struct {
+		name     string
+		yaml     string
+		expected interface{}
+	}{
+		{
+			name:     "integers stay integers",
+			yaml:     "value: 42",
+			expected: map[string]interface{}{"value": int64(42)},
+		},
+		{
+			name:     "floats stay floats",
+			yaml:     "value: 42.5",
+			expected: map[string]interface{}{"value": 42.5},
+		},
+		{
+			name:     "quoted numbers stay strings",
+			yaml:     `value: "42"`,
+			expected: map[string]interface{}{"value": "42"},
+		},
+		{
+			name:     "integers beyond int64 range lose precision as floats",
+			yaml:     "value: 18446744073709551615",
+			expected: map[string]interface{}{"value": float64(18446744073709551615)},
+		},
+		{
+			name: "anchors and aliases are resolved",
+			yaml: "first: &shared\n  key: value\nsecond: *shared\n",
+			expected: map[string]interface{}{
+				"first":  map[string]interface{}{"key": "value"},
+				"second": map[string]interface{}{"key": "value"},
+			},
+		},
+		{
+			name:     "null document",
+			yaml:     "null",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded, err := YAMLToCBOR([]byte(tc.yaml))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var decoded interface{}
+			if err := direct.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatalf("failed to decode converted document: %v", err)
+			}
+			if !reflect.DeepEqual(tc.expected, decoded) {
+				t.Errorf("expected %#v, got %#v", tc.expected, decoded)
+			}
+		})
+	}
+}
+
+func TestCBORToYAML(t *testing.T) {
+	encoded, err := direct.Marshal(map[string]interface{}{"replicas": int64(3), "name": "test"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	converted, err := CBORToYAML(encoded)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "name: test\nreplicas: 3\n"; string(converted) != expected {
+		t.Errorf("expected %q, got %q", expected, string(converted))
+	}
+}
+
+func TestCBORToYAMLBinaryValues(t *testing.T) {
+	// A byte string has no direct YAML representation and converts to its base64
+	// encoding.
+	converted, err := CBORToYAML([]byte{0xa1, 0x63, 'k', 'e', 'y', 0x43, 0x01, 0x02, 0x03})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "key: AQID\n"; string(converted) != expected {
+		t.Errorf("expected %q, got %q", expected, string(converted))
+	}
+
+	// A text string containing invalid UTF-8 has no faithful representation in either
+	// format and is rejected by the decode modes.
+	if _, err := CBORToYAML([]byte{0x62, 0xff, 0xfe}); err == nil {
+		t.Error("expected error converting invalid UTF-8 text string")
+	}
+}
+
+func TestYAMLStreamToCBOR(t *testing.T) {
+	stream := "a: 1\n---\nb: 2\n---\nc: 3\n"
+	items, err := YAMLStreamToCBOR(strings.NewReader(stream))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("expected 3 documents, got %d", len(items))
+	}
+	expected := []map[string]interface{}{
+		{"a": int64(1)},
+		{"b": int64(2)},
+		{"c": int64(3)},
+	}
+	for i, item := range items {
+		var decoded map[string]interface{}
+		if err := direct.Unmarshal(item, &decoded); err != nil {
+			t.Fatalf("failed to decode document %d: %v", i+1, err)
+		}
+		if !reflect.DeepEqual(expected[i], decoded) {
+			t.Errorf("document %d: expected %#v, got %#v", i+1, expected[i], decoded)
+		}
+	}
+
+	roundTripped, err := CBORToYAML(items[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "a: 1\n"; string(roundTripped) != expected {
+		t.Errorf("expected %q after round trip, got %q", expected, string(roundTripped))
+	}
+}
@@ -26,4 +26,5 @@ import (
 func TestRoundTrip(t *testing.T) {
 	roundtrip.RoundTripTestForAPIGroup(t, Install, fuzzer.Funcs)
 	roundtrip.RoundTripProtobufTestForAPIGroup(t, Install, fuzzer.Funcs)
+	roundtrip.RoundTripCBORTestForAPIGroup(t, Install, fuzzer.Funcs)
 }
@@ -26,4 +26,5 @@ import (
 func TestRoundTrip(t *testing.T) {
 	roundtrip.RoundTripTestForAPIGroup(t, Install, examplefuzzer.Funcs)
 	roundtrip.RoundTripProtobufTestForAPIGroup(t, Install, examplefuzzer.Funcs)
+	roundtrip.RoundTripCBORTestForAPIGroup(t, Install, examplefuzzer.Funcs)
 }
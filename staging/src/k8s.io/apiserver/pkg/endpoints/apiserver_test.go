@@ -53,6 +53,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 	"k8s.io/apimachinery/pkg/runtime/serializer/streaming"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/diff"
@@ -3245,6 +3246,96 @@ func TestDeleteWithOptionsQueryAndBody(t *testing.T) {
 	}
 }
 
+func TestDeleteWithOptionsCBOR(t *testing.T) {
+	storage := map[string]rest.Storage{}
+	simpleStorage := SimpleRESTStorage{}
+	ID := "id"
+	storage["simple"] = &simpleStorage
+	handler := handle(storage)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	grace := int64(300)
+	item := &metav1.DeleteOptions{
+		GracePeriodSeconds: &grace,
+	}
+	// A body without apiVersion and kind is tolerated in every format, defaulting to the
+	// meta group version's DeleteOptions.
+	body, err := direct.Marshal(item)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := http.Client{}
+	request, err := http.NewRequest("DELETE", server.URL+"/"+prefix+"/"+testGroupVersion.Group+"/"+testGroupVersion.Version+"/namespaces/default/simple/"+ID, bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	request.Header.Set("Content-Type", runtime.ContentTypeCBOR)
+	res, err := client.Do(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		s, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// Fatal rather than continuing: a failed delete stores no delete options to
+		// inspect.
+		t.Fatalf("unexpected response: %s %#v: %s", request.URL, res, string(s))
+	}
+	if simpleStorage.deleted != ID {
+		t.Errorf("Unexpected delete: %s, expected %s", simpleStorage.deleted, ID)
+	}
+	simpleStorage.deleteOptions.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{})
+	if !apiequality.Semantic.DeepEqual(simpleStorage.deleteOptions, item) {
+		t.Errorf("unexpected delete options: %s", diff.ObjectDiff(simpleStorage.deleteOptions, item))
+	}
+}
+
+func TestDeleteCollectionWithOptionsCBOR(t *testing.T) {
+	simpleStorage := &SimpleRESTStorageWithDeleteCollection{}
+	handler := handle(map[string]rest.Storage{"simple": simpleStorage})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	grace := int64(300)
+	item := &metav1.DeleteOptions{
+		// Submitting per group DeleteOptions remains allowed for backwards compatibility.
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: testGroupVersion.String(),
+			Kind:       "DeleteOptions",
+		},
+		GracePeriodSeconds: &grace,
+	}
+	body, err := direct.Marshal(item)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := http.Client{}
+	request, err := http.NewRequest("DELETE", server.URL+"/"+prefix+"/"+testGroupVersion.Group+"/"+testGroupVersion.Version+"/namespaces/default/simple", bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	request.Header.Set("Content-Type", runtime.ContentTypeCBOR)
+	res, err := client.Do(request)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		s, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		t.Fatalf("unexpected response: %s %#v: %s", request.URL, res, string(s))
+	}
+	if simpleStorage.deleteOptions == nil || simpleStorage.deleteOptions.GracePeriodSeconds == nil || *simpleStorage.deleteOptions.GracePeriodSeconds != grace {
+		t.Errorf("unexpected delete options: %#v", simpleStorage.deleteOptions)
+	}
+}
+
 func TestDeleteInvokesAdmissionControl(t *testing.T) {
 	// TODO: remove mutating deny when we removed it from the endpoint implementation and ported all plugins
 	for _, admit := range []admission.Interface{alwaysMutatingDeny{}, alwaysValidatingDeny{}} {
@@ -4451,6 +4542,7 @@ type SimpleRESTStorageWithDeleteCollection struct {
 // Delete collection doesn't do much, but let us test this path.
 func (storage *SimpleRESTStorageWithDeleteCollection) DeleteCollection(ctx context.Context, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions, listOptions *metainternalversion.ListOptions) (runtime.Object, error) {
 	storage.checkContext(ctx)
+	storage.deleteOptions = options
 	return nil, nil
 }
 
@@ -0,0 +1,78 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package discovery
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+	"k8s.io/apiserver/pkg/endpoints/handlers/negotiation"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+)
+
+// writeObjectNegotiatedWithETag writes a discovery object like
+// responsewriters.WriteObjectNegotiated, additionally computing an entity tag from the
+// hash of the object's canonical CBOR encoding. The entity tag validates the identity of
+// the object, not of any particular representation of it: the same tag is produced and
+// honored for every negotiated response encoding, so a matching If-None-Match header means
+// only that the object is unchanged, not that the cached body was encoded as the request's
+// Accept header asks. Because the body varies with Accept, every response carries a
+// "Vary: Accept" header so that caches store representations per encoding and revalidate
+// the right one. Requests bearing a matching If-None-Match header are answered with 304
+// Not Modified and no body.
+func writeObjectNegotiatedWithETag(s runtime.NegotiatedSerializer, w http.ResponseWriter, req *http.Request, object runtime.Object) {
+	w.Header().Add("Vary", "Accept")
+
+	hash, err := direct.Hash(object)
+	if err != nil {
+		// Discovery objects are always hashable; don't let a conditional-request
+		// optimization break the response if that ever changes.
+		responsewriters.WriteObjectNegotiated(s, negotiation.DefaultEndpointRestrictions, schema.GroupVersion{}, w, req, http.StatusOK, object)
+		return
+	}
+	etag := fmt.Sprintf("%q", fmt.Sprintf("%016x", hash))
+
+	if etagMatches(req.Header.Get("If-None-Match"), etag) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	responsewriters.WriteObjectNegotiated(s, negotiation.DefaultEndpointRestrictions, schema.GroupVersion{}, w, req, http.StatusOK, object)
+}
+
+// etagMatches reports whether any member of an If-None-Match header field value matches
+// the provided entity tag. Weak comparison is used, as RFC 7232 section 3.2 requires for
+// If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
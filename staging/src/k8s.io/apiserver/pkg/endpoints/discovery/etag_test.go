@@ -0,0 +1,104 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package discovery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEtagMatches(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		ifNoneMatch string
+		etag        string
+		want        bool
+	}{
+		{name: "exact match", ifNoneMatch: `"abc"`, etag: `"abc"`, want: true},
+		{name: "no match", ifNoneMatch: `"abc"`, etag: `"def"`, want: false},
+		{name: "empty header", ifNoneMatch: "", etag: `"abc"`, want: false},
+		{name: "list match", ifNoneMatch: `"abc", "def"`, etag: `"def"`, want: true},
+		{name: "wildcard", ifNoneMatch: "*", etag: `"abc"`, want: true},
+		{name: "weak comparison", ifNoneMatch: `W/"abc"`, etag: `"abc"`, want: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := etagMatches(tc.ifNoneMatch, tc.etag); got != tc.want {
+				t.Errorf("etagMatches(%q, %q) = %v, want %v", tc.ifNoneMatch, tc.etag, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDiscoveryEtag(t *testing.T) {
+	handler := NewRootAPIsHandler(DefaultAddresses{DefaultAddress: "192.168.1.1"}, codecs)
+	handler.AddGroup(metav1.APIGroup{Name: "group"})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag header on discovery response")
+	}
+	// The entity tag is encoding-independent, so caches need Vary to keep separately
+	// encoded representations distinct.
+	if got := resp.Header.Get("Vary"); got != "Accept" {
+		t.Errorf("expected Vary: Accept on 200 response, got %q", got)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req.Header.Set("If-None-Match", etag)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotModified {
+		t.Fatalf("expected 304 for matching If-None-Match, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("ETag"); got != etag {
+		t.Errorf("expected ETag %s on 304 response, got %s", etag, got)
+	}
+	if got := resp.Header.Get("Vary"); got != "Accept" {
+		t.Errorf("expected Vary: Accept on 304 response, got %q", got)
+	}
+
+	// A change to the served object must change the entity tag and cause the stale tag
+	// to stop matching.
+	handler.AddGroup(metav1.APIGroup{Name: "other"})
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 after discovery content changed, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("ETag"); got == etag {
+		t.Errorf("expected ETag to change after discovery content changed")
+	}
+}
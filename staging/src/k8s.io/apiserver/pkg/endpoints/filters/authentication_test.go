@@ -17,8 +17,16 @@ limitations under the License.
 package filters
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	cborserializer "k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 	"k8s.io/apiserver/pkg/authentication/authenticator"
 	"k8s.io/apiserver/pkg/authentication/user"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
@@ -27,6 +35,69 @@ import (
 	"testing"
 )
 
+// statusCodecs returns a codec factory that can serve metav1.Status in both JSON and
+// CBOR, for tests that exercise negotiation of error responses written by filters.
+func statusCodecs() serializer.CodecFactory {
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypes(schema.GroupVersion{Version: "v1"}, &metav1.Status{})
+	return serializer.NewCodecFactory(scheme, serializer.WithSerializer(cborserializer.NewSerializerInfo))
+}
+
+// decodeNegotiatedStatus decodes a response body into a metav1.Status according to the
+// response's Content-Type header.
+func decodeNegotiatedStatus(t *testing.T, contentType string, body []byte) *metav1.Status {
+	t.Helper()
+	status := &metav1.Status{}
+	switch contentType {
+	case "application/json":
+		if err := json.Unmarshal(body, status); err != nil {
+			t.Fatalf("failed to decode response body as JSON: %v", err)
+		}
+	case "application/cbor":
+		if err := direct.Unmarshal(bytes.TrimPrefix(body, []byte{0xd9, 0xd9, 0xf7}), status); err != nil {
+			t.Fatalf("failed to decode response body as CBOR: %v", err)
+		}
+	default:
+		t.Fatalf("unexpected response content type %q", contentType)
+	}
+	return status
+}
+
+func TestUnauthorizedResponseNegotiation(t *testing.T) {
+	codecs := statusCodecs()
+	for _, tc := range []struct {
+		name            string
+		accept          string
+		wantContentType string
+	}{
+		{name: "cbor when requested", accept: "application/cbor", wantContentType: "application/cbor"},
+		{name: "json without accept header", wantContentType: "application/json"},
+		{name: "json when accept is unsupported", accept: "text/garbled", wantContentType: "application/json"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/namespaces/default/pods", nil)
+			if len(tc.accept) > 0 {
+				req.Header.Set("Accept", tc.accept)
+			}
+			req = withTestContext(req, nil, nil)
+			recorder := httptest.NewRecorder()
+			Unauthorized(codecs).ServeHTTP(recorder, req)
+
+			if recorder.Code != http.StatusUnauthorized {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusUnauthorized, recorder.Code, recorder.Body.String())
+			}
+			contentType := recorder.Header().Get("Content-Type")
+			if contentType != tc.wantContentType {
+				t.Fatalf("expected content type %q, got %q", tc.wantContentType, contentType)
+			}
+			status := decodeNegotiatedStatus(t, contentType, recorder.Body.Bytes())
+			if status.Reason != metav1.StatusReasonUnauthorized {
+				t.Errorf("expected reason %q, got %q", metav1.StatusReasonUnauthorized, status.Reason)
+			}
+		})
+	}
+}
+
 func TestAuthenticateRequestWithAud(t *testing.T) {
 	success, failed := 0, 0
 	testcases := []struct {
@@ -26,6 +26,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	batch "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	auditinternal "k8s.io/apiserver/pkg/apis/audit"
@@ -185,3 +186,41 @@ func TestAuditAnnotation(t *testing.T) {
 	}
 
 }
+
+func TestForbiddenResponseNegotiation(t *testing.T) {
+	codecs := statusCodecs()
+	for _, tc := range []struct {
+		name            string
+		accept          string
+		wantContentType string
+	}{
+		{name: "cbor when requested", accept: "application/cbor", wantContentType: "application/cbor"},
+		{name: "json without accept header", wantContentType: "application/json"},
+		{name: "json when accept is unsupported", accept: "text/garbled", wantContentType: "application/json"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := WithAuthorization(&fakeHTTPHandler{}, fakeAuthorizer{decision: authorizer.DecisionDeny, reason: "denied by test"}, codecs)
+
+			req, _ := http.NewRequest("GET", "/api/v1/namespaces/default/pods", nil)
+			if len(tc.accept) > 0 {
+				req.Header.Set("Accept", tc.accept)
+			}
+			req = withTestContext(req, nil, nil)
+			req.RemoteAddr = "127.0.0.1"
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			if recorder.Code != http.StatusForbidden {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusForbidden, recorder.Code, recorder.Body.String())
+			}
+			contentType := recorder.Header().Get("Content-Type")
+			if contentType != tc.wantContentType {
+				t.Fatalf("expected content type %q, got %q", tc.wantContentType, contentType)
+			}
+			status := decodeNegotiatedStatus(t, contentType, recorder.Body.Bytes())
+			if status.Reason != metav1.StatusReasonForbidden {
+				t.Errorf("expected reason %q, got %q", metav1.StatusReasonForbidden, status.Reason)
+			}
+		})
+	}
+}
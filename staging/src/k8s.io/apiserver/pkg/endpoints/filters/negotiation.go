@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/endpoints/handlers/negotiation"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// WithNegotiationCache attaches a per-request cache for output media type negotiation,
+// so that the several bodies a request may write (primary object, status errors, table
+// transformations) negotiate once and all use the same encoding.
+func WithNegotiationCache(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req = req.WithContext(negotiation.WithNegotiationCache(req.Context()))
+		handler.ServeHTTP(w, req)
+	})
+}
+
+const (
+	cborNegotiationAllowed = "allowed"
+	cborNegotiationDenied  = "denied"
+)
+
+var cborNegotiationCounter = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Name:           "apiserver_cbor_negotiations_total",
+		Help:           "Counter of requests offering application/cbor in Accept, partitioned by whether the client was allowed to negotiate it.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"result"},
+)
+
+func init() {
+	legacyregistry.MustRegister(cborNegotiationCounter)
+}
+
+// WithCBORNegotiationRestriction limits which clients may negotiate application/cbor
+// responses during a cautious rollout. Requests whose Accept header offers CBOR pass
+// through unchanged when the authenticated user belongs to one of allowedGroups or the
+// User-Agent begins with one of allowedUserAgentPrefixes; otherwise the CBOR clauses are
+// removed from the Accept header and negotiation proceeds among the remaining offers.
+// Install the filter only when a restriction is configured: with both lists empty no
+// client may negotiate CBOR. Wildcard clauses are left intact, so a denied client can
+// still be served CBOR only if the server itself prefers it over every other encoding.
+func WithCBORNegotiationRestriction(handler http.Handler, allowedGroups, allowedUserAgentPrefixes []string) http.Handler {
+	groups := make(map[string]bool, len(allowedGroups))
+	for _, group := range allowedGroups {
+		groups[group] = true
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		accept := req.Header.Get("Accept")
+		if !offersMediaType(accept, runtime.ContentTypeCBOR) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		if allowedToNegotiateCBOR(req, groups, allowedUserAgentPrefixes) {
+			cborNegotiationCounter.WithLabelValues(cborNegotiationAllowed).Inc()
+			handler.ServeHTTP(w, req)
+			return
+		}
+		cborNegotiationCounter.WithLabelValues(cborNegotiationDenied).Inc()
+		req.Header.Set("Accept", stripMediaType(accept, runtime.ContentTypeCBOR))
+		handler.ServeHTTP(w, req)
+	})
+}
+
+func allowedToNegotiateCBOR(req *http.Request, groups map[string]bool, userAgentPrefixes []string) bool {
+	if user, ok := genericapirequest.UserFrom(req.Context()); ok {
+		for _, group := range user.GetGroups() {
+			if groups[group] {
+				return true
+			}
+		}
+	}
+	userAgent := req.UserAgent()
+	for _, prefix := range userAgentPrefixes {
+		if strings.HasPrefix(userAgent, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// offersMediaType returns true if any clause of the Accept header names the media type
+// exactly. Wildcard clauses are not considered offers of a specific type.
+func offersMediaType(accept, mediaType string) bool {
+	for _, clause := range strings.Split(accept, ",") {
+		if mediaRange(clause) == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// stripMediaType removes every clause of the Accept header naming the media type
+// exactly, preserving the remaining clauses as the client sent them. Stripping every
+// clause yields an empty header, for which negotiation selects the server default.
+func stripMediaType(accept, mediaType string) string {
+	var kept []string
+	for _, clause := range strings.Split(accept, ",") {
+		if mediaRange(clause) == mediaType {
+			continue
+		}
+		kept = append(kept, clause)
+	}
+	return strings.Join(kept, ",")
+}
+
+// mediaRange returns the media range of an Accept header clause, with any parameters
+// removed.
+func mediaRange(clause string) string {
+	if i := strings.Index(clause, ";"); i >= 0 {
+		clause = clause[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(clause))
+}
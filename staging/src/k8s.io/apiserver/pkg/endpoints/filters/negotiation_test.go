@@ -0,0 +1,133 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/handlers/negotiation"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestWithNegotiationCache(t *testing.T) {
+	codecs := serializer.NewCodecFactory(runtime.NewScheme())
+
+	var mediaTypes []string
+	handler := WithNegotiationCache(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// The second negotiation must observe the decision of the first despite the
+		// changed Accept header.
+		_, info, err := negotiation.NegotiateOutputMediaType(req, codecs, negotiation.DefaultEndpointRestrictions)
+		if err != nil {
+			t.Fatalf("unexpected negotiation error: %v", err)
+		}
+		mediaTypes = append(mediaTypes, info.MediaType)
+		req.Header.Set("Accept", "application/yaml")
+		_, info, err = negotiation.NegotiateOutputMediaType(req, codecs, negotiation.DefaultEndpointRestrictions)
+		if err != nil {
+			t.Fatalf("unexpected negotiation error: %v", err)
+		}
+		mediaTypes = append(mediaTypes, info.MediaType)
+	}))
+
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(mediaTypes) != 2 || mediaTypes[0] != "application/json" || mediaTypes[1] != "application/json" {
+		t.Errorf("expected both writes to use application/json, got %v", mediaTypes)
+	}
+}
+
+func TestWithCBORNegotiationRestriction(t *testing.T) {
+	testCases := []struct {
+		name       string
+		accept     string
+		user       user.Info
+		userAgent  string
+		wantAccept string
+	}{
+		{
+			name:       "member of allowed group passes through",
+			accept:     "application/cbor",
+			user:       &user.DefaultInfo{Name: "alice", Groups: []string{"system:canary"}},
+			wantAccept: "application/cbor",
+		},
+		{
+			name:       "allowed user agent prefix passes through",
+			accept:     "application/cbor;q=0.9, application/json",
+			userAgent:  "canary-client/v1.19.0",
+			wantAccept: "application/cbor;q=0.9, application/json",
+		},
+		{
+			name:       "denied client has cbor clauses removed",
+			accept:     "application/cbor;q=0.9, application/json",
+			user:       &user.DefaultInfo{Name: "bob", Groups: []string{"system:authenticated"}},
+			wantAccept: " application/json",
+		},
+		{
+			name:   "denied client offering only cbor falls back to the server default",
+			accept: "application/cbor",
+			user:   &user.DefaultInfo{Name: "bob"},
+		},
+		{
+			name:       "requests not offering cbor are untouched",
+			accept:     "application/json, */*",
+			user:       &user.DefaultInfo{Name: "bob"},
+			wantAccept: "application/json, */*",
+		},
+		{
+			name:       "wildcards are not treated as cbor offers",
+			accept:     "application/*",
+			user:       &user.DefaultInfo{Name: "bob"},
+			wantAccept: "application/*",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotAccept string
+			handler := WithCBORNegotiationRestriction(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				gotAccept = req.Header.Get("Accept")
+			}), []string{"system:canary"}, []string{"canary-client"})
+
+			req, err := http.NewRequest("GET", "/api/v1/pods", nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			req.Header.Set("Accept", tc.accept)
+			if tc.userAgent != "" {
+				req.Header.Set("User-Agent", tc.userAgent)
+			}
+			if tc.user != nil {
+				req = req.WithContext(genericapirequest.WithUser(req.Context(), tc.user))
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if gotAccept != tc.wantAccept {
+				t.Errorf("expected Accept %q, got %q", tc.wantAccept, gotAccept)
+			}
+		})
+	}
+}
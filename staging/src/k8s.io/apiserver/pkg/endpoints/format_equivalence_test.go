@@ -0,0 +1,253 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoints
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	restful "github.com/emicklei/go-restful"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	cborserializer "k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+	genericapifilters "k8s.io/apiserver/pkg/endpoints/filters"
+	"k8s.io/apiserver/pkg/registry/rest"
+)
+
+// cborCodecs negotiates application/cbor in addition to the default media types. The
+// stock kube-apiserver codec factory does not include CBOR, so the differential tests
+// install a group with this factory to exercise both request paths side by side.
+var cborCodecs = serializer.NewCodecFactory(scheme, serializer.WithSerializer(cborserializer.NewSerializerInfo))
+
+func handleWithCBOR(storage map[string]rest.Storage) http.Handler {
+	container := restful.NewContainer()
+	container.Router(restful.CurlyRouter{})
+	group := APIGroupVersion{
+		Storage: storage,
+
+		Root:                   "/" + prefix,
+		GroupVersion:           testGroupVersion,
+		OptionsExternalVersion: &testGroupVersion,
+
+		Creater:         scheme,
+		Convertor:       scheme,
+		UnsafeConvertor: runtime.UnsafeObjectConvertor(scheme),
+		Defaulter:       scheme,
+		Typer:           scheme,
+		Linker:          selfLinker,
+
+		EquivalentResourceRegistry: runtime.NewEquivalentResourceRegistry(),
+
+		ParameterCodec: parameterCodec,
+
+		Serializer: cborCodecs,
+
+		Admit: admissionControl,
+	}
+	if err := (&group).InstallREST(container); err != nil {
+		panic(fmt.Sprintf("unable to install container %s: %v", group.GroupVersion, err))
+	}
+	return genericapifilters.WithRequestInfo(container.ServeMux, testRequestInfoResolver())
+}
+
+// warningTexts extracts the warn-text of each Warning header from a response, in order.
+// The agent field is ignored because it may legitimately differ between responses that
+// carry the same warnings.
+func warningTexts(header http.Header) ([]string, error) {
+	var texts []string
+	for _, value := range header.Values("Warning") {
+		// warning-value = warn-code SP warn-agent SP warn-text [SP warn-date]
+		parts := strings.SplitN(value, " ", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("malformed Warning header %q", value)
+		}
+		// strip the optional warn-date suffix before unquoting the warn-text
+		text := parts[2]
+		if end := strings.Index(text, `" `); end >= 0 {
+			text = text[:end+1]
+		}
+		unquoted, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("malformed warn-text in Warning header %q: %v", value, err)
+		}
+		texts = append(texts, unquoted)
+	}
+	return texts, nil
+}
+
+// requireEquivalentWarnings fails the test unless two responses carry the same warnings.
+func requireEquivalentWarnings(t *testing.T, jsonHeader, cborHeader http.Header) {
+	t.Helper()
+	jsonWarnings, err := warningTexts(jsonHeader)
+	if err != nil {
+		t.Fatalf("unexpected error reading warnings of JSON response: %v", err)
+	}
+	cborWarnings, err := warningTexts(cborHeader)
+	if err != nil {
+		t.Fatalf("unexpected error reading warnings of CBOR response: %v", err)
+	}
+	if !reflect.DeepEqual(jsonWarnings, cborWarnings) {
+		t.Errorf("warnings diverge between formats:\njson: %q\ncbor: %q", jsonWarnings, cborWarnings)
+	}
+}
+
+// mustCBOR encodes a document for use as a request body.
+func mustCBOR(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := direct.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// cborTextMap builds a CBOR map of text-string pairs without deduplicating keys, which
+// no conforming encoder will produce.
+func cborTextMap(pairs ...string) []byte {
+	if len(pairs)%2 != 0 || len(pairs)/2 >= 24 {
+		panic("cborTextMap supports only complete pairs and short maps")
+	}
+	doc := []byte{0xa0 | byte(len(pairs)/2)}
+	for _, s := range pairs {
+		if len(s) >= 24 {
+			panic("cborTextMap supports only short strings")
+		}
+		doc = append(doc, 0x60|byte(len(s)))
+		doc = append(doc, s...)
+	}
+	return doc
+}
+
+// TestRequestBodyFormatEquivalence sends the same semantically-questionable documents as
+// JSON and as CBOR and requires that the outcomes correspond. The fieldValidation
+// dimension is pinned even though per-request field validation levels are not
+// implemented and unknown query parameters are ignored; if a validation level parameter
+// is introduced, any behavior it adds must apply to both formats or this test will
+// catch the drift.
+func TestRequestBodyFormatEquivalence(t *testing.T) {
+	simpleStorage := SimpleRESTStorage{}
+	handler := handleWithCBOR(map[string]rest.Storage{"simple": &simpleStorage})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	apiVersion := testGroupVersion.String()
+	testCases := []struct {
+		name string
+		json string
+		cbor []byte
+		// Statuses are expected per format. Differing values pin an intentional
+		// divergence; anything unintentional is a bug.
+		jsonStatus int
+		cborStatus int
+	}{
+		{
+			name: "valid",
+			json: fmt.Sprintf(`{"apiVersion":%q,"kind":"Simple","other":"value"}`, apiVersion),
+			cbor: mustCBOR(t, map[string]interface{}{"apiVersion": apiVersion, "kind": "Simple", "other": "value"}),
+
+			jsonStatus: http.StatusCreated,
+			cborStatus: http.StatusCreated,
+		},
+		{
+			name: "unknown field",
+			json: fmt.Sprintf(`{"apiVersion":%q,"kind":"Simple","unknownField":"value"}`, apiVersion),
+			cbor: mustCBOR(t, map[string]interface{}{"apiVersion": apiVersion, "kind": "Simple", "unknownField": "value"}),
+
+			// Unknown fields are dropped by the non-strict serializers of both formats.
+			jsonStatus: http.StatusCreated,
+			cborStatus: http.StatusCreated,
+		},
+		{
+			name: "wrong type for field",
+			json: fmt.Sprintf(`{"apiVersion":%q,"kind":"Simple","other":42}`, apiVersion),
+			cbor: mustCBOR(t, map[string]interface{}{"apiVersion": apiVersion, "kind": "Simple", "other": int64(42)}),
+
+			jsonStatus: http.StatusBadRequest,
+			cborStatus: http.StatusBadRequest,
+		},
+		{
+			name: "duplicate field",
+			json: fmt.Sprintf(`{"apiVersion":%q,"kind":"Simple","other":"a","other":"b"}`, apiVersion),
+			cbor: cborTextMap("apiVersion", apiVersion, "kind", "Simple", "other", "a", "other", "b"),
+
+			// The JSON serializer resolves duplicate keys last-one-wins and can't
+			// change without breaking existing clients. CBOR rejects duplicate map
+			// keys outright so that a document has exactly one interpretation; see
+			// the decode options in serializer/cbor/internal/modes.
+			jsonStatus: http.StatusCreated,
+			cborStatus: http.StatusBadRequest,
+		},
+	}
+
+	url := server.URL + "/" + prefix + "/" + testGroupVersion.Group + "/" + testGroupVersion.Version + "/namespaces/default/simple"
+	for _, fieldValidation := range []string{"Strict", "Warn", "Ignore"} {
+		for _, tc := range testCases {
+			t.Run(fmt.Sprintf("fieldValidation=%s/%s", fieldValidation, tc.name), func(t *testing.T) {
+				do := func(contentType string, body []byte) (*http.Response, *metav1.Status) {
+					request, err := http.NewRequest("POST", url+"?fieldValidation="+fieldValidation, bytes.NewReader(body))
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					request.Header.Set("Content-Type", contentType)
+					request.Header.Set("Accept", runtime.ContentTypeJSON)
+					response, err := http.DefaultClient.Do(request)
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					defer response.Body.Close()
+					responseBody, err := ioutil.ReadAll(response.Body)
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if response.StatusCode < http.StatusBadRequest {
+						return response, nil
+					}
+					status := &metav1.Status{}
+					if err := json.Unmarshal(responseBody, status); err != nil {
+						t.Fatalf("unexpected error decoding status from %q: %v", responseBody, err)
+					}
+					return response, status
+				}
+
+				jsonResponse, jsonStatus := do(runtime.ContentTypeJSON, []byte(tc.json))
+				cborResponse, cborStatus := do(runtime.ContentTypeCBOR, tc.cbor)
+
+				if jsonResponse.StatusCode != tc.jsonStatus {
+					t.Errorf("expected status %d for JSON, got %d (%#v)", tc.jsonStatus, jsonResponse.StatusCode, jsonStatus)
+				}
+				if cborResponse.StatusCode != tc.cborStatus {
+					t.Errorf("expected status %d for CBOR, got %d (%#v)", tc.cborStatus, cborResponse.StatusCode, cborStatus)
+				}
+				if jsonStatus != nil && cborStatus != nil && jsonStatus.Reason != cborStatus.Reason {
+					t.Errorf("status reasons diverge between formats: json=%q cbor=%q", jsonStatus.Reason, cborStatus.Reason)
+				}
+				requireEquivalentWarnings(t, jsonResponse.Header, cborResponse.Header)
+			})
+		}
+	}
+}
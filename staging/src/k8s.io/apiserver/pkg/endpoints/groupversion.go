@@ -89,6 +89,9 @@ type APIGroupVersion struct {
 	// The limit on the request body size that would be accepted and decoded in a write request.
 	// 0 means no limit.
 	MaxRequestBodyBytes int64
+	// MaxRequestBodyBytesByMediaType overrides MaxRequestBodyBytes for request bodies
+	// declared with a matching media type.
+	MaxRequestBodyBytesByMediaType map[string]int64
 }
 
 // InstallREST registers the REST handlers (storage, watch, proxy and redirect) into a restful Container.
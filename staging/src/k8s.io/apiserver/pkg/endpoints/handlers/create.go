@@ -92,11 +92,15 @@ func createHandler(r rest.NamedCreater, scope *RequestScope, admit admission.Int
 
 		decoder := scope.Serializer.DecoderToVersion(s.Serializer, scope.HubGroupVersion)
 
-		body, err := limitedReadBody(req, scope.MaxRequestBodyBytes)
+		body, err := limitedReadBody(req, scope.maxRequestBodyBytes(req))
 		if err != nil {
 			scope.err(err, w, req)
 			return
 		}
+		if err := checkBodyMediaType(s.MediaType, body); err != nil {
+			scope.err(err, w, req)
+			return
+		}
 
 		options := &metav1.CreateOptions{}
 		values := req.URL.Query()
@@ -115,8 +119,9 @@ func createHandler(r rest.NamedCreater, scope *RequestScope, admit admission.Int
 		defaultGVK := scope.Kind
 		original := r.New()
 		trace.Step("About to convert to expected version")
-		obj, gvk, err := decoder.Decode(body, &defaultGVK, original)
+		obj, gvk, err := decodeBody(ctx, decoder, body, &defaultGVK, original)
 		if err != nil {
+			recordCBORDecodeFailure(ctx, req, body)
 			err = transformDecodeError(scope.Typer, err, original, gvk, body)
 			scope.err(err, w, req)
 			return
@@ -0,0 +1,192 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	cborserializer "k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+	jsonserializer "k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/apimachinery/pkg/types"
+	examplev1 "k8s.io/apiserver/pkg/apis/example/v1"
+)
+
+func projectionTestPod() *examplev1.Pod {
+	return &examplev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "name",
+			Namespace:         "namespace",
+			UID:               types.UID("3f5bbad5-0d9a-4c45-b998-e8b7e9e404cb"),
+			ResourceVersion:   "42",
+			CreationTimestamp: metav1.NewTime(time.Date(2020, 6, 5, 4, 3, 2, 0, time.UTC)),
+			Labels:            map[string]string{"a": "1", "b": "2"},
+			Annotations:       map[string]string{"note": "metadata-heavy"},
+		},
+	}
+}
+
+func projectionTestUnstructured() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata": map[string]interface{}{
+			"name":              "name",
+			"namespace":         "namespace",
+			"uid":               "3f5bbad5-0d9a-4c45-b998-e8b7e9e404cb",
+			"resourceVersion":   "42",
+			"creationTimestamp": "2020-06-05T04:03:02Z",
+			"labels":            map[string]interface{}{"a": "1", "b": "2"},
+		},
+		"spec": map[string]interface{}{"count": int64(3)},
+	}}
+}
+
+// TestPartialObjectMetadataEncoderParity requires the projection to write the same
+// serialization the conversion approach produces, for typed and unstructured sources and
+// for both JSON and CBOR.
+func TestPartialObjectMetadataEncoderParity(t *testing.T) {
+	gv := metav1.SchemeGroupVersion
+	gvk := gv.WithKind("PartialObjectMetadata")
+
+	for _, serializer := range []struct {
+		mediaType string
+		encoder   runtime.Encoder
+	}{
+		{runtime.ContentTypeJSON, jsonserializer.NewSerializerWithOptions(jsonserializer.DefaultMetaFactory, scheme, scheme, jsonserializer.SerializerOptions{})},
+		{runtime.ContentTypeCBOR, cborserializer.NewSerializer(cborserializer.DefaultMetaFactory, scheme, scheme)},
+	} {
+		for _, source := range []runtime.Object{projectionTestPod(), projectionTestUnstructured()} {
+			t.Run(fmt.Sprintf("%s %T", serializer.mediaType, source), func(t *testing.T) {
+				m, err := meta.Accessor(source)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				converted := meta.AsPartialObjectMetadata(m)
+				converted.GetObjectKind().SetGroupVersionKind(gvk)
+				var want bytes.Buffer
+				if err := serializer.encoder.Encode(converted, &want); err != nil {
+					t.Fatalf("unexpected error encoding conversion: %v", err)
+				}
+
+				projector := newPartialObjectMetadataEncoder(serializer.encoder, serializer.encoder, gv)
+				var got bytes.Buffer
+				if err := projector.Encode(source, &got); err != nil {
+					t.Fatalf("unexpected error encoding projection: %v", err)
+				}
+
+				switch serializer.mediaType {
+				case runtime.ContentTypeCBOR:
+					// The CBOR encoding is deterministic, so agreement is
+					// byte for byte regardless of source type.
+					if !bytes.Equal(want.Bytes(), got.Bytes()) {
+						t.Errorf("projection bytes differ from conversion:\n% x\n% x", want.Bytes(), got.Bytes())
+					}
+				case runtime.ContentTypeJSON:
+					// JSON map key order depends on the source type, so
+					// agreement is semantic.
+					var wantValue, gotValue interface{}
+					if err := json.Unmarshal(want.Bytes(), &wantValue); err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if err := json.Unmarshal(got.Bytes(), &gotValue); err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if !reflect.DeepEqual(wantValue, gotValue) {
+						t.Errorf("projection differs from conversion: got %#v, want %#v", gotValue, wantValue)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestPartialObjectMetadataEncoderStatusFallback requires objects without object metadata
+// to be encoded by the fallback encoder unmodified, as watch error events carry a Status.
+func TestPartialObjectMetadataEncoderStatusFallback(t *testing.T) {
+	encoder := jsonserializer.NewSerializerWithOptions(jsonserializer.DefaultMetaFactory, scheme, scheme, jsonserializer.SerializerOptions{})
+	status := &metav1.Status{Status: metav1.StatusFailure, Code: 500}
+
+	var want bytes.Buffer
+	if err := encoder.Encode(status, &want); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	projector := newPartialObjectMetadataEncoder(encoder, encoder, metav1.SchemeGroupVersion)
+	var got bytes.Buffer
+	if err := projector.Encode(status, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(want.Bytes(), got.Bytes()) {
+		t.Errorf("expected status to pass through unmodified: got %q, want %q", got.Bytes(), want.Bytes())
+	}
+}
+
+// BenchmarkPartialObjectMetadataEncode compares the conversion approach against the
+// encode-time projection for a metadata-heavy object.
+func BenchmarkPartialObjectMetadataEncode(b *testing.B) {
+	gv := metav1.SchemeGroupVersion
+	gvk := gv.WithKind("PartialObjectMetadata")
+
+	pod := projectionTestPod()
+	for i := 0; i < 20; i++ {
+		pod.Labels[fmt.Sprintf("label-%d", i)] = "value"
+		pod.Annotations[fmt.Sprintf("annotation-%d", i)] = "a longer value that a controller might record for its own bookkeeping"
+	}
+
+	for _, serializer := range []struct {
+		mediaType string
+		encoder   runtime.Encoder
+	}{
+		{runtime.ContentTypeJSON, jsonserializer.NewSerializerWithOptions(jsonserializer.DefaultMetaFactory, scheme, scheme, jsonserializer.SerializerOptions{})},
+		{runtime.ContentTypeCBOR, cborserializer.NewSerializer(cborserializer.DefaultMetaFactory, scheme, scheme)},
+	} {
+		b.Run(serializer.mediaType+"/convert", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				m, err := meta.Accessor(pod)
+				if err != nil {
+					b.Fatal(err)
+				}
+				converted := meta.AsPartialObjectMetadata(m)
+				converted.GetObjectKind().SetGroupVersionKind(gvk)
+				if err := serializer.encoder.Encode(converted, ioutil.Discard); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(serializer.mediaType+"/project", func(b *testing.B) {
+			projector := newPartialObjectMetadataEncoder(serializer.encoder, serializer.encoder, gv)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := projector.Encode(pod, ioutil.Discard); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package negotiation
+
+import (
+	"context"
+	"sync"
+)
+
+type resultHolderKeyType int
+
+const resultHolderKey resultHolderKeyType = iota
+
+// resultHolder caches the outcome of output media type negotiation for one request. A
+// single request can write several bodies — the primary object, a status error, a table
+// transformation — and each write negotiates the output media type. The first successful
+// negotiation is recorded here and reused for the rest of the request, so repeated writes
+// don't redo the work and can never disagree about the encoding. A recorded result is
+// only reused by calls it remains valid for; see validateCachedNegotiation.
+type resultHolder struct {
+	lock    sync.Mutex
+	options *MediaTypeOptions
+}
+
+// get returns the recorded negotiation result, or nil if none has been recorded.
+func (h *resultHolder) get() *MediaTypeOptions {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.options
+}
+
+// record stores the first successful negotiation result; later results are discarded.
+func (h *resultHolder) record(options MediaTypeOptions) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.options == nil {
+		h.options = &options
+	}
+}
+
+// WithNegotiationCache returns a copy of parent that can carry the result of output
+// media type negotiation for a single request. Contexts without a cache negotiate on
+// every write.
+func WithNegotiationCache(parent context.Context) context.Context {
+	return context.WithValue(parent, resultHolderKey, &resultHolder{})
+}
+
+// negotiationCacheFrom returns the request-scoped negotiation cache, if one was
+// installed by WithNegotiationCache.
+func negotiationCacheFrom(ctx context.Context) *resultHolder {
+	holder, _ := ctx.Value(resultHolderKey).(*resultHolder)
+	return holder
+}
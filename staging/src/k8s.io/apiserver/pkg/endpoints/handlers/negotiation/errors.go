@@ -75,6 +75,32 @@ func (e errNotAcceptableConversion) Status() metav1.Status {
 	}
 }
 
+// errUnsupportedCharset indicates the Content-Type included a charset parameter that
+// conflicts with the encoding of an otherwise supported media type.
+type errUnsupportedCharset struct {
+	mediaType string
+	charset   string
+}
+
+// NewUnsupportedCharsetError returns an error indicating that the charset parameter of
+// the request's Content-Type conflicts with the given media type.
+func NewUnsupportedCharsetError(mediaType, charset string) error {
+	return errUnsupportedCharset{mediaType, charset}
+}
+
+func (e errUnsupportedCharset) Error() string {
+	return fmt.Sprintf("media type %q does not support the charset %q - omit the charset parameter or use utf-8", e.mediaType, e.charset)
+}
+
+func (e errUnsupportedCharset) Status() metav1.Status {
+	return metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    http.StatusUnsupportedMediaType,
+		Reason:  metav1.StatusReasonUnsupportedMediaType,
+		Message: e.Error(),
+	}
+}
+
 // errUnsupportedMediaType indicates Content-Type is not recognized
 type errUnsupportedMediaType struct {
 	accepted []string
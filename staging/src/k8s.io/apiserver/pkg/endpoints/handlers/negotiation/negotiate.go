@@ -19,6 +19,7 @@ package negotiation
 import (
 	"mime"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -32,7 +33,7 @@ import (
 func MediaTypesForSerializer(ns runtime.NegotiatedSerializer) (mediaTypes, streamMediaTypes []string) {
 	for _, info := range ns.SupportedMediaTypes() {
 		mediaTypes = append(mediaTypes, info.MediaType)
-		if info.StreamSerializer != nil {
+		if info.Capabilities().Streaming {
 			// stream=watch is the existing mime-type parameter for watch
 			streamMediaTypes = append(streamMediaTypes, info.MediaType+";stream=watch")
 		}
@@ -41,8 +42,15 @@ func MediaTypesForSerializer(ns runtime.NegotiatedSerializer) (mediaTypes, strea
 }
 
 // NegotiateOutputMediaType negotiates the output structured media type and a serializer, or
-// returns an error.
+// returns an error. If the request context carries a negotiation cache, the first
+// successful result is reused for every subsequent write in the same request.
 func NegotiateOutputMediaType(req *http.Request, ns runtime.NegotiatedSerializer, restrictions EndpointRestrictions) (MediaTypeOptions, runtime.SerializerInfo, error) {
+	cache := negotiationCacheFrom(req.Context())
+	if cache != nil {
+		if options := cache.get(); options != nil && validateCachedNegotiation(options, ns, restrictions) {
+			return *options, options.Accepted, nil
+		}
+	}
 	mediaType, ok := NegotiateMediaTypeOptions(req.Header.Get("Accept"), ns.SupportedMediaTypes(), restrictions)
 	if !ok {
 		supported, _ := MediaTypesForSerializer(ns)
@@ -50,16 +58,56 @@ func NegotiateOutputMediaType(req *http.Request, ns runtime.NegotiatedSerializer
 	}
 	// TODO: move into resthandler
 	info := mediaType.Accepted
-	if (mediaType.Pretty || isPrettyPrint(req)) && info.PrettySerializer != nil {
+	if (mediaType.Pretty || isPrettyPrint(req)) && info.Capabilities().Pretty {
 		info.Serializer = info.PrettySerializer
 	}
+	if cache != nil {
+		// Record the complete decision, including the pretty serializer selection, so
+		// that later writes observe exactly what the first one did. Failures are not
+		// recorded; a write under laxer restrictions may still succeed.
+		recorded := mediaType
+		recorded.Accepted = info
+		cache.record(recorded)
+	}
 	return mediaType, info, nil
 }
 
+// validateCachedNegotiation reports whether a negotiation result recorded earlier in the
+// request is valid for the serializer and restrictions of the current call. Writes within
+// a request usually share both, but endpoints can differ — an error path may use another
+// serializer, or an endpoint may enforce laxer restrictions — and a result negotiated
+// against one must not be served through another.
+func validateCachedNegotiation(cached *MediaTypeOptions, ns runtime.NegotiatedSerializer, restrictions EndpointRestrictions) bool {
+	if !restrictions.AllowsMediaTypeTransform(cached.Accepted.MediaTypeType, cached.Accepted.MediaTypeSubType, cached.Convert) {
+		return false
+	}
+	if len(cached.Stream) > 0 && !restrictions.AllowsStreamSchema(cached.Stream) {
+		return false
+	}
+	if len(cached.UseServerVersion) > 0 && !restrictions.AllowsServerVersion(cached.UseServerVersion) {
+		return false
+	}
+	for _, info := range ns.SupportedMediaTypes() {
+		if info.MediaType != cached.Accepted.MediaType {
+			continue
+		}
+		// The cached serializer must be one this NegotiatedSerializer offers for the
+		// media type, not merely share its name. The pretty variant is substituted
+		// into Serializer when it is selected.
+		if cached.Accepted.Serializer == info.Serializer {
+			return true
+		}
+		if info.PrettySerializer != nil && cached.Accepted.Serializer == info.PrettySerializer {
+			return true
+		}
+	}
+	return false
+}
+
 // NegotiateOutputMediaTypeStream returns a stream serializer for the given request.
 func NegotiateOutputMediaTypeStream(req *http.Request, ns runtime.NegotiatedSerializer, restrictions EndpointRestrictions) (runtime.SerializerInfo, error) {
 	mediaType, ok := NegotiateMediaTypeOptions(req.Header.Get("Accept"), ns.SupportedMediaTypes(), restrictions)
-	if !ok || mediaType.Accepted.StreamSerializer == nil {
+	if !ok || !mediaType.Accepted.Capabilities().Streaming {
 		_, supported := MediaTypesForSerializer(ns)
 		return runtime.SerializerInfo{}, NewNotAcceptableError(supported)
 	}
@@ -78,8 +126,15 @@ func NegotiateInputSerializerForMediaType(mediaType string, streaming bool, ns r
 	if len(mediaType) == 0 {
 		mediaType = mediaTypes[0].MediaType
 	}
-	if mediaType, _, err := mime.ParseMediaType(mediaType); err == nil {
+	if mediaType, params, err := mime.ParseMediaType(mediaType); err == nil {
 		if info, ok := runtime.SerializerInfoForMediaType(mediaTypes, mediaType); ok {
+			// Clients and proxies commonly append parameters like charset. Parameters
+			// that don't affect decoding are ignored, but a charset other than UTF-8
+			// conflicts with every supported encoding and is rejected outright rather
+			// than being reported as an unknown media type.
+			if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") {
+				return runtime.SerializerInfo{}, NewUnsupportedCharsetError(mediaType, charset)
+			}
 			return info, nil
 		}
 	}
@@ -113,6 +168,34 @@ func isPrettyPrint(req *http.Request) bool {
 	return false
 }
 
+// DowngradedMediaType returns the concrete media type the request preferred over the one
+// that was negotiated, when the server does not serve the preferred type at all. It
+// identifies clients that silently fall back because of server configuration — for
+// example an Accept header preferring application/cbor sent to a server with CBOR
+// serving disabled — as opposed to clients whose preference simply lost negotiation.
+func DowngradedMediaType(req *http.Request, ns runtime.NegotiatedSerializer, accepted runtime.SerializerInfo) (string, bool) {
+	supported := map[string]bool{}
+	for _, info := range ns.SupportedMediaTypes() {
+		supported[info.MediaType] = true
+	}
+	// Clauses are in order of decreasing preference.
+	for _, clause := range goautoneg.ParseAccept(req.Header.Get("Accept")) {
+		mediaType := clause.Type + "/" + clause.SubType
+		if mediaType == accepted.MediaType {
+			// Nothing ranked below the type being served was downgraded.
+			return "", false
+		}
+		if clause.Type == "*" || clause.SubType == "*" {
+			// A wildcard is satisfied by whatever is being served.
+			return "", false
+		}
+		if !supported[mediaType] {
+			return mediaType, true
+		}
+	}
+	return "", false
+}
+
 // EndpointRestrictions is an interface that allows content-type negotiation
 // to verify server support for specific options
 type EndpointRestrictions interface {
@@ -174,6 +257,8 @@ type MediaTypeOptions struct {
 // parameters are unversioned and may not be changed.
 func acceptMediaTypeOptions(params map[string]string, accepts *runtime.SerializerInfo, endpoint EndpointRestrictions) (MediaTypeOptions, bool) {
 	var options MediaTypeOptions
+	var framing string
+	capabilities := accepts.Capabilities()
 
 	// extract all known parameters
 	for k, v := range params {
@@ -196,9 +281,23 @@ func acceptMediaTypeOptions(params map[string]string, accepts *runtime.Serialize
 			}
 			options.Convert.Version = v
 
+		// selects an alternate framing for streaming responses. Encodings advertise
+		// the framings they support; asking for one this encoding doesn't offer
+		// makes the clause unsatisfiable.
+		case "framing":
+			if len(v) > 0 {
+				if !capabilities.Streaming {
+					return MediaTypeOptions{}, false
+				}
+				if _, ok := accepts.StreamSerializer.AlternateFramers[v]; !ok {
+					return MediaTypeOptions{}, false
+				}
+			}
+			framing = v
+
 		// controls the streaming schema
 		case "stream":
-			if len(v) > 0 && (accepts.StreamSerializer == nil || !endpoint.AllowsStreamSchema(v)) {
+			if len(v) > 0 && (!capabilities.Streaming || !endpoint.AllowsStreamSchema(v)) {
 				return MediaTypeOptions{}, false
 			}
 			options.Stream = v
@@ -221,6 +320,14 @@ func acceptMediaTypeOptions(params map[string]string, accepts *runtime.Serialize
 		case "pretty":
 			options.Pretty = v == "1"
 
+		// clients and proxies commonly append a charset parameter. UTF-8 is the
+		// only charset compatible with the supported encodings; anything else
+		// makes the clause unsatisfiable.
+		case "charset":
+			if !strings.EqualFold(v, "utf-8") {
+				return MediaTypeOptions{}, false
+			}
+
 		default:
 			options.Unrecognized = append(options.Unrecognized, k)
 		}
@@ -231,11 +338,24 @@ func acceptMediaTypeOptions(params map[string]string, accepts *runtime.Serialize
 	}
 
 	options.Accepted = *accepts
+	if len(framing) > 0 {
+		// The StreamSerializerInfo is shared by every request that negotiates this
+		// media type, so it is copied before the framing override is applied.
+		stream := *options.Accepted.StreamSerializer
+		stream.Framer = stream.AlternateFramers[framing]
+		options.Accepted.StreamSerializer = &stream
+	}
 	return options, true
 }
 
 // NegotiateMediaTypeOptions returns the most appropriate content type given the accept header and
 // a list of alternatives along with the accepted media type parameters.
+//
+// Clauses are considered in order of decreasing quality value, with ties broken in favor
+// of the more specific clause, so that at equal quality an exact type outranks a subtype
+// wildcard and a subtype wildcard outranks */*. A wildcard clause resolves to the first
+// supported media type in the server's preference order that the client has not refused
+// with a more specific zero-quality clause.
 func NegotiateMediaTypeOptions(header string, accepted []runtime.SerializerInfo, endpoint EndpointRestrictions) (MediaTypeOptions, bool) {
 	if len(header) == 0 && len(accepted) > 0 {
 		return MediaTypeOptions{
@@ -244,20 +364,75 @@ func NegotiateMediaTypeOptions(header string, accepted []runtime.SerializerInfo,
 	}
 
 	clauses := goautoneg.ParseAccept(header)
+	sort.SliceStable(clauses, func(i, j int) bool {
+		if clauses[i].Q != clauses[j].Q {
+			return clauses[i].Q > clauses[j].Q
+		}
+		return specificity(&clauses[i]) > specificity(&clauses[j])
+	})
 	for i := range clauses {
 		clause := &clauses[i]
+		if clause.Q == 0 {
+			// A zero quality value means "not acceptable" and can only rule
+			// out matches by other clauses; it never produces one.
+			continue
+		}
 		for i := range accepted {
 			accepts := &accepted[i]
-			switch {
-			case clause.Type == accepts.MediaTypeType && clause.SubType == accepts.MediaTypeSubType,
-				clause.Type == accepts.MediaTypeType && clause.SubType == "*",
-				clause.Type == "*" && clause.SubType == "*":
-				if retVal, ret := acceptMediaTypeOptions(clause.Params, accepts, endpoint); ret {
-					return retVal, true
-				}
+			if !clauseCovers(clause, accepts) {
+				continue
+			}
+			if mediaTypeRefused(clauses, accepts) {
+				continue
+			}
+			if retVal, ret := acceptMediaTypeOptions(clause.Params, accepts, endpoint); ret {
+				return retVal, true
 			}
 		}
 	}
 
 	return MediaTypeOptions{}, false
 }
+
+// specificity orders clauses covering the same media type: an exact type outranks a
+// subtype wildcard, which outranks a full wildcard.
+func specificity(clause *goautoneg.Accept) int {
+	switch {
+	case clause.SubType != "*":
+		return 2
+	case clause.Type != "*":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// clauseCovers returns true if the accept clause matches the media type exactly or
+// through a wildcard.
+func clauseCovers(clause *goautoneg.Accept, accepts *runtime.SerializerInfo) bool {
+	switch {
+	case clause.Type == accepts.MediaTypeType && clause.SubType == accepts.MediaTypeSubType,
+		clause.Type == accepts.MediaTypeType && clause.SubType == "*",
+		clause.Type == "*" && clause.SubType == "*":
+		return true
+	}
+	return false
+}
+
+// mediaTypeRefused returns true if the most specific clause covering the media type has
+// a zero quality value, which clients use to exclude a type from wildcard matches.
+func mediaTypeRefused(clauses []goautoneg.Accept, accepts *runtime.SerializerInfo) bool {
+	best := -1
+	refused := false
+	for i := range clauses {
+		clause := &clauses[i]
+		if !clauseCovers(clause, accepts) {
+			continue
+		}
+		if s := specificity(clause); s > best {
+			best = s
+			refused = clause.Q == 0
+		}
+	}
+	return refused
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package negotiation
 
 import (
+	"io"
 	"mime"
 	"net/http"
 	"net/url"
@@ -25,6 +26,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // statusError is an object that can be converted into an metav1.Status
@@ -35,6 +37,7 @@ type statusError interface {
 type fakeNegotiater struct {
 	serializer, streamSerializer runtime.Serializer
 	framer                       runtime.Framer
+	alternateFramers             map[string]runtime.Framer
 	types, streamTypes           []string
 }
 
@@ -61,9 +64,10 @@ func (n *fakeNegotiater) SupportedMediaTypes() []runtime.SerializerInfo {
 		for _, t := range n.streamTypes {
 			if t == s {
 				info.StreamSerializer = &runtime.StreamSerializerInfo{
-					EncodesAsText: true,
-					Framer:        n.framer,
-					Serializer:    n.streamSerializer,
+					EncodesAsText:    true,
+					Framer:           n.framer,
+					Serializer:       n.streamSerializer,
+					AlternateFramers: n.alternateFramers,
 				}
 			}
 		}
@@ -150,6 +154,35 @@ func TestNegotiate(t *testing.T) {
 			params:      map[string]string{"pretty": "1"},
 		},
 
+		// a utf-8 charset parameter is tolerated
+		{
+			accept:      "application/cbor; charset=utf-8",
+			contentType: "application/cbor",
+			ns:          &fakeNegotiater{serializer: fakeCodec, types: []string{"application/json", "application/cbor"}},
+			serializer:  fakeCodec,
+		},
+		{
+			accept:      "application/json; charset=UTF-8",
+			contentType: "application/json",
+			ns:          &fakeNegotiater{serializer: fakeCodec, types: []string{"application/json"}},
+			serializer:  fakeCodec,
+		},
+		// a conflicting charset makes the clause unsatisfiable, falling through to the
+		// next acceptable clause
+		{
+			accept:      "application/cbor; charset=utf-16, application/json",
+			contentType: "application/json",
+			ns:          &fakeNegotiater{serializer: fakeCodec, types: []string{"application/json", "application/cbor"}},
+			serializer:  fakeCodec,
+		},
+		{
+			accept: "application/cbor; charset=utf-16",
+			ns:     &fakeNegotiater{serializer: fakeCodec, types: []string{"application/cbor"}},
+			errFn: func(err error) bool {
+				return err.Error() == "only the following media types are accepted: application/cbor"
+			},
+		},
+
 		// query param triggers pretty
 		{
 			req: &http.Request{
@@ -281,6 +314,258 @@ func TestNegotiate(t *testing.T) {
 	}
 }
 
+func TestNegotiateOutputMediaTypeCache(t *testing.T) {
+	ns := &fakeNegotiater{serializer: fakeCodec, types: []string{"application/json", "application/yaml"}}
+
+	// without a cache in the context, every call negotiates independently
+	req := &http.Request{Header: http.Header{"Accept": []string{"application/json"}}}
+	_, info, err := NegotiateOutputMediaType(req, ns, DefaultEndpointRestrictions)
+	if err != nil || info.MediaType != "application/json" {
+		t.Fatalf("expected application/json, got %q, %v", info.MediaType, err)
+	}
+	req.Header.Set("Accept", "application/yaml")
+	if _, info, err = NegotiateOutputMediaType(req, ns, DefaultEndpointRestrictions); err != nil || info.MediaType != "application/yaml" {
+		t.Errorf("expected application/yaml, got %q, %v", info.MediaType, err)
+	}
+
+	// with a cache, the first successful negotiation is reused even if the header
+	// changes between writes
+	req = &http.Request{Header: http.Header{"Accept": []string{"application/json"}}}
+	req = req.WithContext(WithNegotiationCache(req.Context()))
+	if _, info, err = NegotiateOutputMediaType(req, ns, DefaultEndpointRestrictions); err != nil || info.MediaType != "application/json" {
+		t.Fatalf("expected application/json, got %q, %v", info.MediaType, err)
+	}
+	req.Header.Set("Accept", "application/yaml")
+	if _, info, err = NegotiateOutputMediaType(req, ns, DefaultEndpointRestrictions); err != nil || info.MediaType != "application/json" {
+		t.Errorf("expected cached application/json, got %q, %v", info.MediaType, err)
+	}
+
+	// failures are not cached; a later write can still negotiate successfully
+	req = &http.Request{Header: http.Header{"Accept": []string{"text/plain"}}}
+	req = req.WithContext(WithNegotiationCache(req.Context()))
+	if _, _, err = NegotiateOutputMediaType(req, ns, DefaultEndpointRestrictions); err == nil {
+		t.Fatal("expected negotiation error, got none")
+	}
+	req.Header.Set("Accept", "application/yaml")
+	if _, info, err = NegotiateOutputMediaType(req, ns, DefaultEndpointRestrictions); err != nil || info.MediaType != "application/yaml" {
+		t.Errorf("expected application/yaml, got %q, %v", info.MediaType, err)
+	}
+
+	// a result negotiated against one serializer is not reused for another, even when
+	// both offer the same media type name
+	otherCodec := runtime.NewBase64Serializer(nil, nil)
+	other := &fakeNegotiater{serializer: otherCodec, types: []string{"application/yaml"}}
+	req = &http.Request{Header: http.Header{"Accept": []string{"application/yaml"}}}
+	req = req.WithContext(WithNegotiationCache(req.Context()))
+	if _, info, err = NegotiateOutputMediaType(req, ns, DefaultEndpointRestrictions); err != nil || info.Serializer != fakeCodec {
+		t.Fatalf("expected the first negotiater's serializer, got %#v, %v", info.Serializer, err)
+	}
+	if _, info, err = NegotiateOutputMediaType(req, other, DefaultEndpointRestrictions); err != nil || info.Serializer != otherCodec {
+		t.Errorf("expected the second negotiater's serializer, got %#v, %v", info.Serializer, err)
+	}
+
+	// a result negotiated under one endpoint's restrictions is not reused by an
+	// endpoint whose restrictions forbid it
+	req = &http.Request{Header: http.Header{"Accept": []string{"application/json;as=Table;g=meta.k8s.io;v=v1"}}}
+	req = req.WithContext(WithNegotiationCache(req.Context()))
+	if _, _, err = NegotiateOutputMediaType(req, ns, permissiveRestrictions{}); err != nil {
+		t.Fatalf("unexpected negotiation error: %v", err)
+	}
+	if _, _, err = NegotiateOutputMediaType(req, ns, DefaultEndpointRestrictions); err == nil {
+		t.Error("expected negotiation error for an endpoint that does not allow the cached transform, got none")
+	}
+}
+
+// permissiveRestrictions allows every media type transform, unlike
+// DefaultEndpointRestrictions, which rejects any transform target.
+type permissiveRestrictions struct{}
+
+func (permissiveRestrictions) AllowsMediaTypeTransform(string, string, *schema.GroupVersionKind) bool {
+	return true
+}
+func (permissiveRestrictions) AllowsServerVersion(string) bool  { return false }
+func (permissiveRestrictions) AllowsStreamSchema(s string) bool { return s == "watch" }
+
+func TestDowngradedMediaType(t *testing.T) {
+	ns := &fakeNegotiater{serializer: fakeCodec, types: []string{"application/json", "application/yaml"}}
+
+	testCases := []struct {
+		accept     string
+		negotiated string
+		requested  string
+		downgraded bool
+	}{
+		// the preferred type is served
+		{accept: "application/json, application/yaml", negotiated: "application/json"},
+		// the preferred type is supported but lost negotiation
+		{accept: "application/yaml;q=0.5, application/json", negotiated: "application/json"},
+		// the preferred type is not served at all
+		{accept: "application/cbor, application/json", negotiated: "application/json", requested: "application/cbor", downgraded: true},
+		{accept: "application/cbor;q=0.9, application/json;q=0.8", negotiated: "application/json", requested: "application/cbor", downgraded: true},
+		// a specific type outranks a wildcard at equal preference
+		{accept: "*/*, application/cbor", negotiated: "application/json", requested: "application/cbor", downgraded: true},
+		// a preferred wildcard is satisfied by whatever is served
+		{accept: "application/cbor;q=0.5, */*", negotiated: "application/json"},
+		// an unserved type ranked below the served one is not a downgrade
+		{accept: "application/json, application/cbor;q=0.5", negotiated: "application/json"},
+		// no accept header
+		{accept: "", negotiated: "application/json"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.accept, func(t *testing.T) {
+			req := &http.Request{Header: http.Header{"Accept": []string{tc.accept}}}
+			accepted, ok := runtime.SerializerInfoForMediaType(ns.SupportedMediaTypes(), tc.negotiated)
+			if !ok {
+				t.Fatalf("no serializer for %q", tc.negotiated)
+			}
+			requested, downgraded := DowngradedMediaType(req, ns, accepted)
+			if downgraded != tc.downgraded || requested != tc.requested {
+				t.Errorf("expected (%q, %t), got (%q, %t)", tc.requested, tc.downgraded, requested, downgraded)
+			}
+		})
+	}
+}
+
+type fakeFramer string
+
+func (fakeFramer) NewFrameWriter(w io.Writer) io.Writer         { return w }
+func (fakeFramer) NewFrameReader(r io.ReadCloser) io.ReadCloser { return r }
+
+func TestNegotiateFramingParameter(t *testing.T) {
+	defaultFramer := fakeFramer("default")
+	alternateFramer := fakeFramer("alternate")
+	ns := &fakeNegotiater{
+		serializer:       fakeCodec,
+		streamSerializer: fakeCodec,
+		framer:           defaultFramer,
+		alternateFramers: map[string]runtime.Framer{"length-delimited": alternateFramer},
+		types:            []string{"application/json"},
+		streamTypes:      []string{"application/json"},
+	}
+
+	testCases := []struct {
+		accept string
+		framer runtime.Framer
+		errFn  func(error) bool
+	}{
+		// the default framer is used when the parameter is absent
+		{
+			accept: "application/json;stream=watch",
+			framer: defaultFramer,
+		},
+		// an empty value is treated like an absent parameter
+		{
+			accept: "application/json;stream=watch;framing=",
+			framer: defaultFramer,
+		},
+		// a supported framing selects the alternate framer
+		{
+			accept: "application/json;stream=watch;framing=length-delimited",
+			framer: alternateFramer,
+		},
+		// an unsupported framing is not acceptable
+		{
+			accept: "application/json;stream=watch;framing=unsupported",
+			errFn: func(err error) bool {
+				return err.Error() == "only the following media types are accepted: application/json;stream=watch"
+			},
+		},
+	}
+	for i, test := range testCases {
+		req := &http.Request{Header: http.Header{"Accept": []string{test.accept}}}
+		info, err := NegotiateOutputMediaTypeStream(req, ns, DefaultEndpointRestrictions)
+		switch {
+		case err == nil && test.errFn != nil:
+			t.Errorf("%d: failed: expected error", i)
+			continue
+		case err != nil && test.errFn == nil:
+			t.Errorf("%d: failed: %v", i, err)
+			continue
+		case err != nil:
+			if !test.errFn(err) {
+				t.Errorf("%d: failed: %v", i, err)
+			}
+			continue
+		}
+		if info.StreamSerializer.Framer != test.framer {
+			t.Errorf("%d: unexpected framer %v", i, info.StreamSerializer.Framer)
+		}
+	}
+
+	// the StreamSerializerInfo advertised by the negotiater is shared between requests,
+	// so selecting an alternate framing must not mutate it
+	infos := ns.SupportedMediaTypes()
+	options, ok := acceptMediaTypeOptions(map[string]string{"stream": "watch", "framing": "length-delimited"}, &infos[0], DefaultEndpointRestrictions)
+	if !ok {
+		t.Fatalf("unexpected negotiation failure")
+	}
+	if options.Accepted.StreamSerializer.Framer != alternateFramer {
+		t.Errorf("unexpected framer %v", options.Accepted.StreamSerializer.Framer)
+	}
+	if infos[0].StreamSerializer.Framer != defaultFramer {
+		t.Errorf("negotiation mutated the shared stream serializer info: %v", infos[0].StreamSerializer.Framer)
+	}
+}
+
+func TestNegotiateInputSerializerForMediaType(t *testing.T) {
+	ns := &fakeNegotiater{serializer: fakeCodec, types: []string{"application/json", "application/cbor"}}
+	testCases := []struct {
+		contentType string
+		mediaType   string
+		errFn       func(error) bool
+	}{
+		// bare media types and irrelevant parameters are accepted
+		{contentType: "application/cbor", mediaType: "application/cbor"},
+		{contentType: "application/cbor; charset=utf-8", mediaType: "application/cbor"},
+		{contentType: "application/json; charset=UTF-8", mediaType: "application/json"},
+		{contentType: "application/cbor; unknown=1", mediaType: "application/cbor"},
+		// a conflicting charset is rejected with an error naming the parameter rather
+		// than reporting an unknown media type
+		{
+			contentType: "application/cbor; charset=utf-16",
+			errFn: func(err error) bool {
+				return strings.Contains(err.Error(), `does not support the charset "utf-16"`)
+			},
+		},
+		// an unsupported media type is rejected regardless of parameters
+		{
+			contentType: "application/yaml; charset=utf-8",
+			errFn: func(err error) bool {
+				return strings.Contains(err.Error(), "unknown format")
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.contentType, func(t *testing.T) {
+			info, err := NegotiateInputSerializerForMediaType(test.contentType, false, ns)
+			if test.errFn != nil {
+				if err == nil {
+					t.Fatalf("expected error, got serializer for %s", info.MediaType)
+				}
+				if !test.errFn(err) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				status, ok := err.(statusError)
+				if !ok {
+					t.Fatalf("error should be statusError: %v", err)
+				}
+				if status.Status().Code != http.StatusUnsupportedMediaType {
+					t.Fatalf("expected status %d, got %d", http.StatusUnsupportedMediaType, status.Status().Code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if info.MediaType != test.mediaType {
+				t.Errorf("expected media type %s, got %s", test.mediaType, info.MediaType)
+			}
+		})
+	}
+}
+
 func fakeSerializerInfoSlice() []runtime.SerializerInfo {
 	result := make([]runtime.SerializerInfo, 2)
 	result[0] = runtime.SerializerInfo{
@@ -307,3 +592,121 @@ func BenchmarkNegotiateMediaTypeOptions(b *testing.B) {
 		}
 	}
 }
+
+// TestNegotiateWildcardAndQualityMatrix locks in the decision matrix for quality values
+// and wildcard clauses so that the outcome of any combination cannot silently change.
+func TestNegotiateWildcardAndQualityMatrix(t *testing.T) {
+	// In the server's order of preference.
+	ns := &fakeNegotiater{serializer: fakeCodec, types: []string{
+		"application/json",
+		"application/yaml",
+		"application/vnd.kubernetes.protobuf",
+		"application/cbor",
+	}}
+
+	testCases := []struct {
+		name   string
+		accept string
+		want   string
+		reject bool
+	}{
+		{
+			name:   "full wildcard resolves to the server-preferred type",
+			accept: "*/*",
+			want:   "application/json",
+		},
+		{
+			name:   "subtype wildcard resolves to the server-preferred type",
+			accept: "application/*",
+			want:   "application/json",
+		},
+		{
+			name:   "exact type is honored",
+			accept: "application/cbor",
+			want:   "application/cbor",
+		},
+		{
+			name:   "first clause wins among exact types at equal quality",
+			accept: "application/cbor, application/json",
+			want:   "application/cbor",
+		},
+		{
+			name:   "exact type outranks subtype wildcard at equal quality",
+			accept: "application/*, application/cbor",
+			want:   "application/cbor",
+		},
+		{
+			name:   "exact type outranks full wildcard at equal quality",
+			accept: "*/*, application/vnd.kubernetes.protobuf",
+			want:   "application/vnd.kubernetes.protobuf",
+		},
+		{
+			name:   "higher quality exact type outranks lower quality exact type",
+			accept: "application/cbor;q=0.5, application/json",
+			want:   "application/json",
+		},
+		{
+			name:   "higher quality wildcard outranks lower quality exact type",
+			accept: "application/*;q=0.2, application/cbor;q=0.1",
+			want:   "application/json",
+		},
+		{
+			name:   "higher quality exact type outranks lower quality wildcard",
+			accept: "*/*;q=0.1, application/cbor;q=0.5",
+			want:   "application/cbor",
+		},
+		{
+			name:   "zero quality excludes a type from a full wildcard",
+			accept: "application/json;q=0, */*",
+			want:   "application/yaml",
+		},
+		{
+			name:   "zero quality excludes a type from a subtype wildcard",
+			accept: "application/cbor;q=0, application/*",
+			want:   "application/json",
+		},
+		{
+			name:   "multiple zero quality exclusions apply together",
+			accept: "application/json;q=0, application/yaml;q=0, */*",
+			want:   "application/vnd.kubernetes.protobuf",
+		},
+		{
+			name:   "zero quality subtype wildcard excludes every subtype",
+			accept: "application/*;q=0, */*",
+			reject: true,
+		},
+		{
+			name:   "zero quality alone matches nothing",
+			accept: "application/json;q=0",
+			reject: true,
+		},
+		{
+			name:   "unsupported exact type matches nothing",
+			accept: "text/html",
+			reject: true,
+		},
+		{
+			name:   "unsupported subtype wildcard is skipped",
+			accept: "text/*, application/cbor;q=0.8",
+			want:   "application/cbor",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			options, ok := NegotiateMediaTypeOptions(tc.accept, ns.SupportedMediaTypes(), DefaultEndpointRestrictions)
+			if tc.reject {
+				if ok {
+					t.Fatalf("expected no acceptable media type, negotiated %s", options.Accepted.MediaType)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("expected negotiation to succeed")
+			}
+			if options.Accepted.MediaType != tc.want {
+				t.Errorf("expected %s, negotiated %s", tc.want, options.Accepted.MediaType)
+			}
+		})
+	}
+}
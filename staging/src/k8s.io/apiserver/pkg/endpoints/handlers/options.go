@@ -0,0 +1,53 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	metainternalversionscheme "k8s.io/apimachinery/pkg/apis/meta/internalversion/scheme"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/endpoints/handlers/negotiation"
+)
+
+// DecodeOptionsFromBody decodes a request body into an options object of the named meta
+// kind, honoring the declared content type of the body. The meta codecs accept every
+// format options can be submitted in, including application/cbor, which is not registered
+// in the per-group codec factories, so handlers that accept options in request bodies
+// should decode them here rather than with the codecs of the resource's own group.
+func DecodeOptionsFromBody(req *http.Request, body []byte, kind string, scope *RequestScope, into runtime.Object) error {
+	s, err := negotiation.NegotiateInputSerializer(req, false, metainternalversionscheme.Codecs)
+	if err != nil {
+		return err
+	}
+	if err := checkBodyMediaType(s.MediaType, body); err != nil {
+		return err
+	}
+	// For backwards compatibility, we need to allow existing clients to submit options in
+	// the group version of the resource. It is also allowed to pass a body with the
+	// meta.k8s.io/v1 options kind.
+	defaultGVK := scope.MetaGroupVersion.WithKind(kind)
+	obj, _, err := metainternalversionscheme.Codecs.DecoderToVersion(s.Serializer, defaultGVK.GroupVersion()).Decode(body, &defaultGVK, into)
+	if err != nil {
+		return err
+	}
+	if obj != into {
+		return fmt.Errorf("decoded object cannot be converted to %s", kind)
+	}
+	return nil
+}
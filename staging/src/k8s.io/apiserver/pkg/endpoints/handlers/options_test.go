@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"testing"
+
+	metainternalversionscheme "k8s.io/apimachinery/pkg/apis/meta/internalversion/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDecodeOptionsFromBody(t *testing.T) {
+	scope := &RequestScope{
+		MetaGroupVersion: metav1.SchemeGroupVersion,
+	}
+
+	testCases := []struct {
+		kind     string
+		options  runtime.Object
+		into     runtime.Object
+		expected runtime.Object
+	}{
+		{
+			kind:     "CreateOptions",
+			options:  &metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}, FieldManager: "test"},
+			into:     &metav1.CreateOptions{},
+			expected: &metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}, FieldManager: "test"},
+		},
+		{
+			kind:     "UpdateOptions",
+			options:  &metav1.UpdateOptions{DryRun: []string{metav1.DryRunAll}, FieldManager: "test"},
+			into:     &metav1.UpdateOptions{},
+			expected: &metav1.UpdateOptions{DryRun: []string{metav1.DryRunAll}, FieldManager: "test"},
+		},
+		{
+			kind:     "GetOptions",
+			options:  &metav1.GetOptions{ResourceVersion: "42"},
+			into:     &metav1.GetOptions{},
+			expected: &metav1.GetOptions{ResourceVersion: "42"},
+		},
+		{
+			kind:     "DeleteOptions",
+			options:  &metav1.DeleteOptions{DryRun: []string{metav1.DryRunAll}},
+			into:     &metav1.DeleteOptions{},
+			expected: &metav1.DeleteOptions{DryRun: []string{metav1.DryRunAll}},
+		},
+	}
+
+	for _, mediaType := range []string{runtime.ContentTypeJSON, runtime.ContentTypeCBOR} {
+		info, ok := runtime.SerializerInfoForMediaType(metainternalversionscheme.Codecs.SupportedMediaTypes(), mediaType)
+		if !ok {
+			t.Fatalf("no serializer registered for %q", mediaType)
+		}
+		encoder := metainternalversionscheme.Codecs.EncoderForVersion(info.Serializer, metav1.SchemeGroupVersion)
+
+		for _, tc := range testCases {
+			t.Run(mediaType+"/"+tc.kind, func(t *testing.T) {
+				var body bytes.Buffer
+				if err := encoder.Encode(tc.options, &body); err != nil {
+					t.Fatalf("unexpected encode error: %v", err)
+				}
+
+				req, err := http.NewRequest(http.MethodPost, "/", nil)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				req.Header.Set("Content-Type", mediaType)
+
+				if err := DecodeOptionsFromBody(req, body.Bytes(), tc.kind, scope, tc.into); err != nil {
+					t.Fatalf("unexpected decode error: %v", err)
+				}
+				// Clear the TypeMeta populated by decoding before comparing.
+				tc.into.GetObjectKind().SetGroupVersionKind(tc.expected.GetObjectKind().GroupVersionKind())
+				if !reflect.DeepEqual(tc.expected, tc.into) {
+					t.Errorf("expected %#v, got %#v", tc.expected, tc.into)
+				}
+			})
+		}
+
+		t.Run(mediaType+"/mismatched body", func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/", nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			req.Header.Set("Content-Type", mediaType)
+
+			// A body in the other format must be rejected rather than misinterpreted.
+			other := []byte(`{}`)
+			if mediaType == runtime.ContentTypeJSON {
+				other = []byte{0xd9, 0xd9, 0xf7, 0xa0}
+			}
+			if err := DecodeOptionsFromBody(req, other, "GetOptions", scope, &metav1.GetOptions{}); err == nil {
+				t.Errorf("expected an error for a %s body declared as %s", string(other), mediaType)
+			}
+		})
+	}
+}
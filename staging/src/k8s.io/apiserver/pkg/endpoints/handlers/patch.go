@@ -32,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/apimachinery/pkg/util/mergepatch"
@@ -80,6 +81,9 @@ func PatchResource(r rest.Patcher, scope *RequestScope, admit admission.Interfac
 
 		// Ensure the patchType is one we support
 		if !sets.NewString(patchTypes...).Has(contentType) {
+			// Advertise the accepted patch types on the rejection (RFC 5789,
+			// section 3.1) so that clients can feature-detect without retrying.
+			w.Header().Set("Accept-Patch", strings.Join(patchTypes, ", "))
 			scope.err(negotiation.NewUnsupportedMediaTypeError(patchTypes), w, req)
 			return
 		}
@@ -105,7 +109,7 @@ func PatchResource(r rest.Patcher, scope *RequestScope, admit admission.Interfac
 			return
 		}
 
-		patchBytes, err := limitedReadBody(req, scope.MaxRequestBodyBytes)
+		patchBytes, err := limitedReadBody(req, scope.maxRequestBodyBytes(req))
 		if err != nil {
 			scope.err(err, w, req)
 			return
@@ -131,8 +135,11 @@ func PatchResource(r rest.Patcher, scope *RequestScope, admit admission.Interfac
 		trace.Step("Recorded the audit event")
 
 		baseContentType := runtime.ContentTypeJSON
-		if patchType == types.ApplyPatchType {
+		switch patchType {
+		case types.ApplyPatchType:
 			baseContentType = runtime.ContentTypeYAML
+		case types.ApplyCBORPatchType:
+			baseContentType = runtime.ContentTypeCBOR
 		}
 		s, ok := runtime.SerializerInfoForMediaType(scope.Serializer.SupportedMediaTypes(), baseContentType)
 		if !ok {
@@ -421,6 +428,7 @@ func (p *smpPatcher) createNewObject() (runtime.Object, error) {
 
 type applyPatcher struct {
 	patch        []byte
+	patchType    types.PatchType
 	options      *metav1.PatchOptions
 	creater      runtime.ObjectCreater
 	kind         schema.GroupVersionKind
@@ -437,7 +445,11 @@ func (p *applyPatcher) applyPatchToCurrentObject(obj runtime.Object) (runtime.Ob
 	}
 
 	patchObj := &unstructured.Unstructured{Object: map[string]interface{}{}}
-	if err := yaml.Unmarshal(p.patch, &patchObj.Object); err != nil {
+	if p.patchType == types.ApplyCBORPatchType {
+		if err := direct.Unmarshal(p.patch, &patchObj.Object); err != nil {
+			return nil, errors.NewBadRequest(fmt.Sprintf("error decoding CBOR: %v", err))
+		}
+	} else if err := yaml.Unmarshal(p.patch, &patchObj.Object); err != nil {
 		return nil, errors.NewBadRequest(fmt.Sprintf("error decoding YAML: %v", err))
 	}
 
@@ -565,11 +577,12 @@ func (p *patcher) patchResource(ctx context.Context, scope *RequestScope) (runti
 			schemaReferenceObj: schemaReferenceObj,
 			fieldManager:       scope.FieldManager,
 		}
-	// this case is unreachable if ServerSideApply is not enabled because we will have already rejected the content type
-	case types.ApplyPatchType:
+	// these cases are unreachable if ServerSideApply is not enabled because we will have already rejected the content type
+	case types.ApplyPatchType, types.ApplyCBORPatchType:
 		p.mechanism = &applyPatcher{
 			fieldManager: scope.FieldManager,
 			patch:        p.patchBytes,
+			patchType:    p.patchType,
 			options:      p.options,
 			creater:      p.creater,
 			kind:         p.kind,
@@ -592,7 +605,7 @@ func (p *patcher) patchResource(ctx context.Context, scope *RequestScope) (runti
 		result, err := requestFunc()
 		// If the object wasn't committed to storage because it's serialized size was too large,
 		// it is safe to remove managedFields (which can be large) and try again.
-		if isTooLargeError(err) && p.patchType != types.ApplyPatchType {
+		if isTooLargeError(err) && p.patchType != types.ApplyPatchType && p.patchType != types.ApplyCBORPatchType {
 			if _, accessorErr := meta.Accessor(p.restPatcher.New()); accessorErr == nil {
 				p.updatedObjectInfo = rest.DefaultUpdatedObjectInfo(nil, p.applyPatch, p.applyAdmission, func(_ context.Context, obj, _ runtime.Object) (runtime.Object, error) {
 					accessor, _ := meta.Accessor(obj)
@@ -17,7 +17,9 @@ limitations under the License.
 package responsewriters
 
 import (
+	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -29,6 +31,7 @@ import (
 
 	"k8s.io/apiserver/pkg/features"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -93,7 +96,7 @@ func SerializeObject(mediaType string, encoder runtime.Encoder, hw http.Response
 		hw:              hw,
 	}
 
-	err := encoder.Encode(object, w)
+	err := encodePreSized(req.Context(), encoder, object, w)
 	if err == nil {
 		err = w.Close()
 		if err == nil {
@@ -120,6 +123,45 @@ func SerializeObject(mediaType string, encoder runtime.Encoder, hw http.Response
 	w.Close()
 }
 
+// encodePreSized encodes object to w. Lists are written through the encoder's
+// specialized list entry point when it has one. Otherwise, encoders that observe context
+// cancellation are preferred, so that a request that is canceled or timed out mid-encode
+// stops consuming CPU on serialization. Otherwise, encoders that accept a caller-managed memory
+// allocator are given a pooled allocator so that encode buffers are reused across
+// requests, and if the encoder can estimate the encoded size of the object up front, the
+// encoding is staged through a buffer pre-allocated to that size, so that encoders that
+// write incrementally neither grow the buffer repeatedly nor make many small writes to
+// the response. The full payload also reaches the response writer as a single write,
+// which is what the gzip threshold is compared against.
+func encodePreSized(ctx context.Context, encoder runtime.Encoder, object runtime.Object, w io.Writer) error {
+	if listEncoder, ok := encoder.(runtime.ListEncoder); ok && meta.IsListType(object) {
+		// Lists dominate large responses, so prefer the specialized list entry point,
+		// which encodes the items of large lists concurrently. That forgoes the
+		// context-aware path's mid-encode cancellation, but a concurrent encode
+		// finishes quickly enough that abandoning it partway would buy little.
+		return listEncoder.EncodeList(object, w)
+	}
+	if contextEncoder, ok := encoder.(runtime.ContextEncoder); ok {
+		return contextEncoder.EncodeContext(ctx, object, w)
+	}
+	if encoderWithAllocator, ok := encoder.(runtime.EncoderWithAllocator); ok {
+		memAlloc := runtime.AllocatorPool.Get().(*runtime.Allocator)
+		defer runtime.AllocatorPool.Put(memAlloc)
+		return encoderWithAllocator.EncodeWithAllocator(object, w, memAlloc)
+	}
+	if estimator, ok := encoder.(runtime.EncodedSizeEstimator); ok {
+		if size, ok := estimator.EstimateEncodedSize(object); ok {
+			buf := bytes.NewBuffer(make([]byte, 0, size))
+			if err := encoder.Encode(object, buf); err != nil {
+				return err
+			}
+			_, err := w.Write(buf.Bytes())
+			return err
+		}
+	}
+	return encoder.Encode(object, w)
+}
+
 var gzipPool = &sync.Pool{
 	New: func() interface{} {
 		gw, err := gzip.NewWriterLevel(nil, defaultGzipContentEncodingLevel)
@@ -243,6 +285,14 @@ func WriteObjectNegotiated(s runtime.NegotiatedSerializer, restrictions negotiat
 		return
 	}
 
+	if requested, ok := negotiation.DowngradedMediaType(req, s, serializer); ok && requested == runtime.ContentTypeCBOR {
+		// The Warning header lets owners of affected clients be identified from request
+		// logs rather than discovering the silent fallback on their own. Only CBOR is
+		// called out so that the metric labels stay bounded.
+		w.Header().Add("Warning", fmt.Sprintf("299 - %q", fmt.Sprintf("this server does not serve %s; responding with %s", requested, serializer.MediaType)))
+		metrics.RecordMediaTypeDowngrade(requested, serializer.MediaType)
+	}
+
 	if ae := request.AuditEventFrom(req.Context()); ae != nil {
 		audit.LogResponseObject(ae, object, gv, s)
 	}
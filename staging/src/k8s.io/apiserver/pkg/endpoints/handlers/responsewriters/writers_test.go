@@ -19,6 +19,7 @@ package responsewriters
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/hex"
 	"fmt"
 	"io"
@@ -29,9 +30,12 @@ import (
 	"testing"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apiserver/pkg/endpoints/handlers/negotiation"
 	"k8s.io/apiserver/pkg/features"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	featuregatetesting "k8s.io/component-base/featuregate/testing"
@@ -291,6 +295,98 @@ func (e *fakeEncoder) Identifier() runtime.Identifier {
 	return runtime.Identifier("fake")
 }
 
+type fakeSizeEstimatingEncoder struct {
+	fakeEncoder
+	size int
+}
+
+func (e *fakeSizeEstimatingEncoder) Encode(obj runtime.Object, w io.Writer) error {
+	// write one byte at a time to exercise buffering by the caller
+	for i := range e.fakeEncoder.buf {
+		if _, err := w.Write(e.fakeEncoder.buf[i : i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *fakeSizeEstimatingEncoder) EstimateEncodedSize(obj runtime.Object) (int, bool) {
+	return e.size, e.size >= 0
+}
+
+type writeCountingWriter struct {
+	writes *[]int
+}
+
+func (w writeCountingWriter) Write(p []byte) (int, error) {
+	*w.writes = append(*w.writes, len(p))
+	return len(p), nil
+}
+
+func TestWriteObjectNegotiatedCBORDowngradeWarning(t *testing.T) {
+	gv := schema.GroupVersion{Version: "v1"}
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypes(gv, &metav1.Status{})
+	codecs := serializer.NewCodecFactory(scheme)
+
+	for _, tc := range []struct {
+		accept      string
+		wantWarning bool
+	}{
+		{accept: "application/cbor, application/json", wantWarning: true},
+		{accept: "application/json", wantWarning: false},
+	} {
+		t.Run(tc.accept, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept", tc.accept)
+			recorder := httptest.NewRecorder()
+			WriteObjectNegotiated(codecs, negotiation.DefaultEndpointRestrictions, gv, recorder, req, http.StatusOK, &metav1.Status{})
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+			}
+			warning := recorder.Header().Get("Warning")
+			if tc.wantWarning && warning != `299 - "this server does not serve application/cbor; responding with application/json"` {
+				t.Errorf("unexpected warning: %q", warning)
+			}
+			if !tc.wantWarning && len(warning) > 0 {
+				t.Errorf("unexpected warning: %q", warning)
+			}
+		})
+	}
+}
+
+func TestEncodePreSized(t *testing.T) {
+	testCases := []struct {
+		name       string
+		size       int
+		wantWrites []int
+	}{
+		{
+			name:       "estimate available",
+			size:       5,
+			wantWrites: []int{5},
+		},
+		{
+			name:       "no estimate",
+			size:       -1,
+			wantWrites: []int{1, 1, 1, 1, 1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var writes []int
+			encoder := &fakeSizeEstimatingEncoder{fakeEncoder: fakeEncoder{buf: []byte("hello")}, size: tc.size}
+			if err := encodePreSized(context.Background(), encoder, nil, writeCountingWriter{&writes}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tc.wantWrites, writes) {
+				t.Errorf("expected writes %v, got %v", tc.wantWrites, writes)
+			}
+		})
+	}
+}
+
 func gzipContent(data []byte, level int) []byte {
 	buf := &bytes.Buffer{}
 	gw, err := gzip.NewWriterLevel(buf, level)
@@ -17,11 +17,13 @@ limitations under the License.
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
 	goruntime "runtime"
@@ -37,7 +39,9 @@ import (
 	metav1beta1 "k8s.io/apimachinery/pkg/apis/meta/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/audit"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	"k8s.io/apiserver/pkg/endpoints/handlers/fieldmanager"
 	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
@@ -83,6 +87,22 @@ type RequestScope struct {
 	HubGroupVersion schema.GroupVersion
 
 	MaxRequestBodyBytes int64
+	// MaxRequestBodyBytesByMediaType overrides MaxRequestBodyBytes for request bodies
+	// declared with a matching media type. Encodings differ in how large an object a
+	// byte can carry, so a single byte limit does not bound decoded object sizes
+	// uniformly across media types.
+	MaxRequestBodyBytesByMediaType map[string]int64
+}
+
+// maxRequestBodyBytes returns the request body size limit to apply to the provided
+// request, taking its declared content type into account.
+func (scope *RequestScope) maxRequestBodyBytes(req *http.Request) int64 {
+	if mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err == nil {
+		if limit, ok := scope.MaxRequestBodyBytesByMediaType[mediaType]; ok {
+			return limit
+		}
+	}
+	return scope.MaxRequestBodyBytes
 }
 
 func (scope *RequestScope) err(err error, w http.ResponseWriter, req *http.Request) {
@@ -262,6 +282,80 @@ func finishRequest(timeout time.Duration, fn resultFunc) (result runtime.Object,
 	}
 }
 
+const (
+	// cborDecodeFailureVerbosity is the verbosity at which the bodies of CBOR requests
+	// that fail to decode are logged in diagnostic notation.
+	cborDecodeFailureVerbosity = 7
+	// maxCBORDiagnosticBytes bounds the size of a request body that will be rendered in
+	// diagnostic notation with full fidelity. Larger bodies are truncated to a fixed
+	// number of data items before rendering.
+	maxCBORDiagnosticBytes = 4096
+	// maxCBORDiagnosticItems bounds the number of data items retained when summarizing a
+	// body too large to render in full. Truncating by decoded items instead of bytes
+	// keeps the summary well-formed and its rendering bounded regardless of how the
+	// document's size is distributed between few large items and many small ones.
+	maxCBORDiagnosticItems = 64
+	// cborDecodeFailureAnnotationKey is the audit annotation under which the diagnostic
+	// notation of a CBOR request body that failed to decode is recorded.
+	cborDecodeFailureAnnotationKey = "apiserver.k8s.io/cbor-decode-failure"
+)
+
+// selfDescribedCBOR is the encoding of the self-describe tag that prefixes every
+// document produced by the CBOR serializer.
+var selfDescribedCBOR = []byte{0xd9, 0xd9, 0xf7}
+
+// recordCBORDecodeFailure records the diagnostic notation (RFC 8949 section 8) of a CBOR
+// request body that failed to decode, so that operators diagnosing client encoding bugs
+// can see what was actually sent without a packet capture. It is a no-op unless logging
+// verbosity is high and the declared content type of the request is CBOR, and bodies
+// larger than a fixed limit are summarized rather than rendered.
+func recordCBORDecodeFailure(ctx context.Context, req *http.Request, body []byte) {
+	if !klog.V(cborDecodeFailureVerbosity).Enabled() {
+		return
+	}
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mediaType != runtime.ContentTypeCBOR {
+		return
+	}
+	var diagnostic string
+	if len(body) > maxCBORDiagnosticBytes {
+		diagnostic, err = direct.DiagnosticLimited(body, maxCBORDiagnosticItems)
+	} else {
+		diagnostic, err = direct.Diagnostic(body)
+	}
+	if err != nil {
+		diagnostic = fmt.Sprintf("malformed cbor: %v (%s)", err, summarizeData(body, 30))
+	}
+	audit.AddAuditAnnotation(ctx, cborDecodeFailureAnnotationKey, diagnostic)
+	klog.V(cborDecodeFailureVerbosity).Infof("undecodable cbor request body: %s", diagnostic)
+}
+
+// checkBodyMediaType rejects a request body that begins with the CBOR self-describe tag
+// when its declared media type is not CBOR, before the body reaches a decoder. The tag's
+// encoding can never begin a document in any of the text media types, so such a body
+// indicates a client or intermediary confusing content types rather than a body that
+// might still decode successfully. The reverse direction is deliberately not checked:
+// untagged CBOR is well-formed and some encoders do not add the tag, so a CBOR-declared
+// body is left to the decoder. Only the first bytes of the body are considered: the same
+// byte sequence inside a document, such as within a JSON string, is not evidence of
+// anything.
+func checkBodyMediaType(mediaType string, body []byte) error {
+	if mediaType != runtime.ContentTypeCBOR && bytes.HasPrefix(body, selfDescribedCBOR) {
+		return errors.NewBadRequest(fmt.Sprintf("the body begins with the CBOR self-describe tag and cannot have been encoded with Content-Type %q", mediaType))
+	}
+	return nil
+}
+
+// decodeBody decodes a request body through the decoder's context-aware entry point when
+// it has one, so that a request that is canceled or timed out while its body is being
+// decoded stops consuming CPU on deserialization.
+func decodeBody(ctx context.Context, decoder runtime.Decoder, body []byte, defaultGVK *schema.GroupVersionKind, into runtime.Object) (runtime.Object, *schema.GroupVersionKind, error) {
+	if contextDecoder, ok := decoder.(runtime.ContextDecoder); ok {
+		return contextDecoder.DecodeContext(ctx, body, defaultGVK, into)
+	}
+	return decoder.Decode(body, defaultGVK, into)
+}
+
 // transformDecodeError adds additional information into a bad-request api error when a decode fails.
 func transformDecodeError(typer runtime.ObjectTyper, baseErr error, into runtime.Object, gvk *schema.GroupVersionKind, body []byte) error {
 	objGVKs, _, err := typer.ObjectKinds(into)
@@ -270,10 +364,31 @@ func transformDecodeError(typer runtime.ObjectTyper, baseErr error, into runtime
 	}
 	objGVK := objGVKs[0]
 	if gvk != nil && len(gvk.Kind) > 0 {
-		return errors.NewBadRequest(fmt.Sprintf("%s in version %q cannot be handled as a %s: %v", gvk.Kind, gvk.Version, objGVK.Kind, baseErr))
+		return badRequestWithCauses(fmt.Sprintf("%s in version %q cannot be handled as a %s: %v", gvk.Kind, gvk.Version, objGVK.Kind, baseErr), baseErr)
 	}
 	summary := summarizeData(body, 30)
-	return errors.NewBadRequest(fmt.Sprintf("the object provided is unrecognized (must be of type %s): %v (%s)", objGVK.Kind, baseErr, summary))
+	return badRequestWithCauses(fmt.Sprintf("the object provided is unrecognized (must be of type %s): %v (%s)", objGVK.Kind, baseErr, summary), baseErr)
+}
+
+// badRequestWithCauses returns a BadRequest error with a cause for each field the
+// underlying decode error identifies. All strict decoders record field paths the same
+// way, so a request body with unknown fields produces the same causes in every encoding.
+func badRequestWithCauses(message string, baseErr error) error {
+	statusErr := errors.NewBadRequest(message)
+	fieldPaths := runtime.StrictDecodingFieldPaths(baseErr)
+	if len(fieldPaths) == 0 {
+		return statusErr
+	}
+	causes := make([]metav1.StatusCause, 0, len(fieldPaths))
+	for _, path := range fieldPaths {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   path,
+			Message: fmt.Sprintf("unknown field %q", path),
+		})
+	}
+	statusErr.ErrStatus.Details = &metav1.StatusDetails{Causes: causes}
+	return statusErr
 }
 
 // setSelfLink sets the self link of an object (or the child items in a list) to the base URL of the request
@@ -325,7 +440,8 @@ func checkName(obj runtime.Object, name, namespace string, namer ScopeNamer) err
 
 // setObjectSelfLink sets the self link of an object as needed.
 // TODO: remove the need for the namer LinkSetters by requiring objects implement either Object or List
-//   interfaces
+//
+//	interfaces
 func setObjectSelfLink(ctx context.Context, obj runtime.Object, req *http.Request, namer ScopeNamer) error {
 	if utilfeature.DefaultFeatureGate.Enabled(features.RemoveSelfLink) {
 		return nil
@@ -378,6 +494,13 @@ func summarizeData(data []byte, maxLength int) string {
 			return string(data[:maxLength]) + " ..."
 		}
 		return string(data)
+	case bytes.HasPrefix(data, selfDescribedCBOR):
+		// Truncating a CBOR document at a byte offset would leave it unrenderable, so
+		// the summary is bounded by a count of decoded data items instead.
+		if diagnostic, err := direct.DiagnosticLimited(data, 8); err == nil {
+			return diagnostic
+		}
+		fallthrough
 	default:
 		if len(data) > maxLength {
 			return hex.EncodeToString(data[:maxLength]) + " ..."
@@ -400,7 +523,11 @@ func limitedReadBody(req *http.Request, limit int64) ([]byte, error) {
 		return nil, err
 	}
 	if lr.N <= 0 {
-		return nil, errors.NewRequestEntityTooLargeError(fmt.Sprintf("limit is %d", limit))
+		message := fmt.Sprintf("limit is %d", limit)
+		if contentType := req.Header.Get("Content-Type"); len(contentType) > 0 {
+			message = fmt.Sprintf("limit is %d for content type %s", limit, contentType)
+		}
+		return nil, errors.NewRequestEntityTooLargeError(message)
 	}
 	return data, nil
 }
@@ -17,13 +17,16 @@ limitations under the License.
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
@@ -36,6 +39,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	cborserializer "k8s.io/apimachinery/pkg/runtime/serializer/cbor"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/diff"
 	"k8s.io/apimachinery/pkg/util/json"
@@ -973,6 +977,10 @@ func (f mutateObjectUpdateFunc) Admit(ctx context.Context, a admission.Attribute
 }
 
 func TestTransformDecodeErrorEnsuresBadRequestError(t *testing.T) {
+	carpScheme := runtime.NewScheme()
+	if err := testapigroupv1.AddToScheme(carpScheme); err != nil {
+		t.Fatal(err)
+	}
 	testCases := []struct {
 		name             string
 		typer            runtime.ObjectTyper
@@ -980,6 +988,7 @@ func TestTransformDecodeErrorEnsuresBadRequestError(t *testing.T) {
 		decodeIntoObject runtime.Object
 		baseErr          error
 		expectedErr      error
+		expectedCauses   []metav1.StatusCause
 	}{
 		{
 			name:  "decoding normal objects fails and returns a bad-request error",
@@ -999,11 +1008,35 @@ func TestTransformDecodeErrorEnsuresBadRequestError(t *testing.T) {
 			decodeIntoObject: &testapigroupv1.Carp{}, // which client-go's scheme doesn't recognize
 			baseErr:          nil,
 		},
+		{
+			name:  "strict decoding errors with field paths produce a cause per field",
+			typer: carpScheme,
+			decodedGVK: &schema.GroupVersionKind{
+				Group:   testapigroupv1.GroupName,
+				Version: "v1",
+				Kind:    "Carp",
+			},
+			decodeIntoObject: &testapigroupv1.Carp{},
+			baseErr:          runtime.NewStrictDecodingErrorForFields(`unknown field(s): "spec.unknown", "unknown"`, `{}`, "spec.unknown", "unknown"),
+			expectedCauses: []metav1.StatusCause{
+				{Type: metav1.CauseTypeFieldValueInvalid, Field: "spec.unknown", Message: `unknown field "spec.unknown"`},
+				{Type: metav1.CauseTypeFieldValueInvalid, Field: "unknown", Message: `unknown field "unknown"`},
+			},
+		},
 	}
 	for _, testCase := range testCases {
 		err := transformDecodeError(testCase.typer, testCase.baseErr, testCase.decodeIntoObject, testCase.decodedGVK, []byte(``))
-		if apiStatus, ok := err.(apierrors.APIStatus); !ok || apiStatus.Status().Code != http.StatusBadRequest {
+		apiStatus, ok := err.(apierrors.APIStatus)
+		if !ok || apiStatus.Status().Code != http.StatusBadRequest {
 			t.Errorf("expected bad request error but got: %v", err)
+			continue
+		}
+		var causes []metav1.StatusCause
+		if details := apiStatus.Status().Details; details != nil {
+			causes = details.Causes
+		}
+		if !reflect.DeepEqual(causes, testCase.expectedCauses) {
+			t.Errorf("%s: expected causes %#v, got %#v", testCase.name, testCase.expectedCauses, causes)
 		}
 	}
 }
@@ -1020,6 +1053,67 @@ func (alwaysErrorTyper) Recognizes(gvk schema.GroupVersionKind) bool {
 	return false
 }
 
+func TestMaxRequestBodyBytesByMediaType(t *testing.T) {
+	scope := &RequestScope{
+		MaxRequestBodyBytes: 1024,
+		MaxRequestBodyBytesByMediaType: map[string]int64{
+			"application/cbor": 512,
+		},
+	}
+
+	testCases := []struct {
+		contentType string
+		limit       int64
+	}{
+		// the general limit applies when no content type is declared
+		{contentType: "", limit: 1024},
+		// and to content types without an override
+		{contentType: "application/json", limit: 1024},
+		{contentType: "application/cbor", limit: 512},
+		// parameters don't affect the limit for the media type
+		{contentType: "application/cbor; charset=utf-8", limit: 512},
+		// an unparseable content type gets the general limit; the request will be
+		// rejected for its media type after its body is read
+		{contentType: "not a media type", limit: 1024},
+	}
+	for _, tc := range testCases {
+		req := &http.Request{Header: http.Header{}}
+		if len(tc.contentType) > 0 {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+		if limit := scope.maxRequestBodyBytes(req); limit != tc.limit {
+			t.Errorf("%q: expected limit %d, got %d", tc.contentType, tc.limit, limit)
+		}
+	}
+}
+
+func TestLimitedReadBody(t *testing.T) {
+	req, err := http.NewRequest("POST", "/", strings.NewReader("0123456789"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/cbor")
+	_, err = limitedReadBody(req, 5)
+	if !apierrors.IsRequestEntityTooLargeError(err) {
+		t.Fatalf("expected too large error, got %v", err)
+	}
+	if want := "limit is 5 for content type application/cbor"; !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error to contain %q, got %q", want, err.Error())
+	}
+
+	req, err = http.NewRequest("POST", "/", strings.NewReader("0123456789"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, err := limitedReadBody(req, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(body) != "0123456789" {
+		t.Errorf("unexpected body: %q", string(body))
+	}
+}
+
 func TestUpdateToCreateOptions(t *testing.T) {
 	f := fuzz.New()
 	for i := 0; i < 100; i++ {
@@ -1105,3 +1199,122 @@ want: %#+v`, got, converted)
 		})
 	}
 }
+
+func TestCheckBodyMediaType(t *testing.T) {
+	prefix := []byte{0xd9, 0xd9, 0xf7}
+	cborBody := append(append([]byte{}, prefix...), 0xa0) // {}
+	jsonBody := []byte(`{"apiVersion":"v1","kind":"Pod"}`)
+	// The prefix bytes appearing inside a document are not evidence of smuggling; only
+	// the leading bytes of the body are significant.
+	jsonBodyContainingPrefix := append([]byte(`{"data":"`), append(append([]byte{}, prefix...), []byte(`"}`)...)...)
+
+	for _, tc := range []struct {
+		name        string
+		mediaType   string
+		body        []byte
+		expectError bool
+	}{
+		{name: "cbor declared and prefixed", mediaType: runtime.ContentTypeCBOR, body: cborBody, expectError: false},
+		// Untagged CBOR is well-formed; a CBOR-declared body that isn't CBOR at all is
+		// left to the decoder to reject.
+		{name: "cbor declared without prefix", mediaType: runtime.ContentTypeCBOR, body: []byte{0xa0}, expectError: false},
+		{name: "cbor declared with json body", mediaType: runtime.ContentTypeCBOR, body: jsonBody, expectError: false},
+		{name: "json declared with json body", mediaType: runtime.ContentTypeJSON, body: jsonBody, expectError: false},
+		{name: "json declared with cbor body", mediaType: runtime.ContentTypeJSON, body: cborBody, expectError: true},
+		{name: "json declared with prefix inside document", mediaType: runtime.ContentTypeJSON, body: jsonBodyContainingPrefix, expectError: false},
+		{name: "yaml declared with cbor body", mediaType: "application/yaml", body: cborBody, expectError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkBodyMediaType(tc.mediaType, tc.body)
+			if tc.expectError {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				status, ok := err.(apierrors.APIStatus)
+				if !ok {
+					t.Fatalf("expected an api status error, got %T: %v", err, err)
+				}
+				if code := status.Status().Code; code != http.StatusBadRequest {
+					t.Errorf("expected status code %d, got %d", http.StatusBadRequest, code)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestCheckBodyMediaTypeAfterChunkedRead verifies that the declared media type is checked
+// against the fully-assembled body, so a body delivered in chunks that split the
+// self-describe tag is still recognized.
+func TestCheckBodyMediaTypeAfterChunkedRead(t *testing.T) {
+	raw := []byte{0xd9, 0xd9, 0xf7, 0xa0}
+	req, err := http.NewRequest(http.MethodPost, "/", iotest.OneByteReader(bytes.NewReader(raw)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, err := limitedReadBody(req, int64(len(raw)))
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if err := checkBodyMediaType(runtime.ContentTypeJSON, body); err == nil {
+		t.Error("expected error for a cbor body declared as json")
+	}
+	if err := checkBodyMediaType(runtime.ContentTypeCBOR, body); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestConnectResponderErrorNegotiated(t *testing.T) {
+	// Proxy subresource handlers report dial failures through rest.Responder, so the
+	// synthesized Status body must honor the Accept header of the original request.
+	codecs := serializer.NewCodecFactory(scheme, serializer.WithSerializer(cborserializer.NewSerializerInfo))
+	scope := &RequestScope{
+		Serializer: codecs,
+		Kind:       examplev1.SchemeGroupVersion.WithKind("Pod"),
+	}
+
+	for _, tc := range []struct {
+		accept    string
+		mediaType string
+	}{
+		{accept: "application/json", mediaType: "application/json"},
+		{accept: "application/cbor", mediaType: "application/cbor"},
+	} {
+		t.Run(tc.accept, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/api/v1/namespaces/ns/pods/missing/proxy", nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			req.Header.Set("Accept", tc.accept)
+			w := httptest.NewRecorder()
+
+			r := &responder{scope: scope, req: req, w: w}
+			r.Error(apierrors.NewServiceUnavailable("connection refused"))
+
+			if w.Code != http.StatusServiceUnavailable {
+				t.Fatalf("expected %d, got %d", http.StatusServiceUnavailable, w.Code)
+			}
+			if got := w.Header().Get("Content-Type"); got != tc.mediaType {
+				t.Fatalf("expected Content-Type %q, got %q", tc.mediaType, got)
+			}
+			info, ok := runtime.SerializerInfoForMediaType(codecs.SupportedMediaTypes(), tc.mediaType)
+			if !ok {
+				t.Fatalf("no serializer for %q", tc.mediaType)
+			}
+			obj, err := runtime.Decode(codecs.UniversalDeserializer(), w.Body.Bytes())
+			if err != nil {
+				t.Fatalf("error decoding %s response body: %v", info.MediaType, err)
+			}
+			status, ok := obj.(*metav1.Status)
+			if !ok {
+				t.Fatalf("expected a Status, got %T", obj)
+			}
+			if status.Code != http.StatusServiceUnavailable || !strings.Contains(status.Message, "connection refused") {
+				t.Errorf("unexpected status: %#v", status)
+			}
+		})
+	}
+}
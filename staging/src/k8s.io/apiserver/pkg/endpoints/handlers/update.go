@@ -72,7 +72,7 @@ func UpdateResource(r rest.Updater, scope *RequestScope, admit admission.Interfa
 			return
 		}
 
-		body, err := limitedReadBody(req, scope.MaxRequestBodyBytes)
+		body, err := limitedReadBody(req, scope.maxRequestBodyBytes(req))
 		if err != nil {
 			scope.err(err, w, req)
 			return
@@ -96,13 +96,18 @@ func UpdateResource(r rest.Updater, scope *RequestScope, admit admission.Interfa
 			scope.err(err, w, req)
 			return
 		}
+		if err := checkBodyMediaType(s.MediaType, body); err != nil {
+			scope.err(err, w, req)
+			return
+		}
 		defaultGVK := scope.Kind
 		original := r.New()
 
 		trace.Step("About to convert to expected version")
 		decoder := scope.Serializer.DecoderToVersion(s.Serializer, scope.HubGroupVersion)
-		obj, gvk, err := decoder.Decode(body, &defaultGVK, original)
+		obj, gvk, err := decodeBody(ctx, decoder, body, &defaultGVK, original)
 		if err != nil {
+			recordCBORDecodeFailure(ctx, req, body)
 			err = transformDecodeError(scope.Typer, err, original, gvk, body)
 			scope.err(err, w, req)
 			return
@@ -101,6 +101,14 @@ func serveWatch(watcher watch.Interface, scope *RequestScope, mediaTypeOptions n
 			return
 		}
 		embeddedEncoder = contentSerializer.EncoderForVersion(info.Serializer, contentKind.GroupVersion())
+		if contentKind.Kind == "PartialObjectMetadata" && (info.MediaType == runtime.ContentTypeJSON || info.MediaType == runtime.ContentTypeCBOR) {
+			// Metadata-only watch objects are projected at encode time rather
+			// than converted to PartialObjectMetadata one event at a time. The
+			// projection writes what the conversion would have produced, so
+			// the per-event transform below reduces to self-link fixup.
+			embeddedEncoder = newPartialObjectMetadataEncoder(info.Serializer, embeddedEncoder, contentKind.GroupVersion())
+			mediaTypeOptions.Convert = nil
+		}
 	} else {
 		embeddedEncoder = scope.Serializer.EncoderForVersion(serializer.Serializer, contentKind.GroupVersion())
 	}
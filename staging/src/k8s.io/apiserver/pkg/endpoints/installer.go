@@ -31,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/conversion"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/admission"
@@ -529,9 +530,22 @@ func (a *APIInstaller) registerResourceHandlers(path string, storage rest.Storag
 	allMediaTypes := append(mediaTypes, streamMediaTypes...)
 	ws.Produces(allMediaTypes...)
 
+	requestScopeSerializer := a.group.Serializer
+	if provider, ok := storage.(rest.SupportedMediaTypesProvider); ok {
+		requestScopeSerializer = runtimeserializer.WithMediaTypeRestriction(requestScopeSerializer, provider.AllowedMediaTypes(), provider.ExtraMediaTypes())
+		for _, s := range requestScopeSerializer.SupportedMediaTypes() {
+			if len(s.MediaTypeSubType) == 0 || len(s.MediaTypeType) == 0 {
+				return nil, fmt.Errorf("all serializers for resource %q must have MediaTypeType and MediaTypeSubType set: %s", resource, s.MediaType)
+			}
+		}
+		// Restrict the media types advertised and negotiable for this resource's routes.
+		mediaTypes, streamMediaTypes = negotiation.MediaTypesForSerializer(requestScopeSerializer)
+		allMediaTypes = append(mediaTypes, streamMediaTypes...)
+	}
+
 	kubeVerbs := map[string]struct{}{}
 	reqScope := handlers.RequestScope{
-		Serializer:      a.group.Serializer,
+		Serializer:      requestScopeSerializer,
 		ParameterCodec:  a.group.ParameterCodec,
 		Creater:         a.group.Creater,
 		Convertor:       a.group.Convertor,
@@ -554,7 +568,8 @@ func (a *APIInstaller) registerResourceHandlers(path string, storage rest.Storag
 
 		MetaGroupVersion: metav1.SchemeGroupVersion,
 
-		MaxRequestBodyBytes: a.group.MaxRequestBodyBytes,
+		MaxRequestBodyBytes:            a.group.MaxRequestBodyBytes,
+		MaxRequestBodyBytesByMediaType: a.group.MaxRequestBodyBytesByMediaType,
 	}
 	if a.group.MetaGroupVersion != nil {
 		reqScope.MetaGroupVersion = *a.group.MetaGroupVersion
@@ -572,6 +587,23 @@ func (a *APIInstaller) registerResourceHandlers(path string, storage rest.Storag
 			return nil, fmt.Errorf("failed to create field manager: %v", err)
 		}
 	}
+	// supportedPatchTypes lists the patch content types accepted for this resource. It is
+	// advertised in discovery and in the Accept-Patch response header so that clients can
+	// feature-detect patch support without trial requests.
+	supportedPatchTypes := []string{
+		string(types.JSONPatchType),
+		string(types.MergePatchType),
+		string(types.StrategicMergePatchType),
+	}
+	if utilfeature.DefaultFeatureGate.Enabled(features.ServerSideApply) {
+		supportedPatchTypes = append(supportedPatchTypes, string(types.ApplyPatchType))
+		// CBOR apply configurations are decoded with the resource's CBOR serializer, so
+		// the CBOR apply patch type is only accepted where that serializer is negotiable.
+		if _, ok := runtime.SerializerInfoForMediaType(requestScopeSerializer.SupportedMediaTypes(), runtime.ContentTypeCBOR); ok {
+			supportedPatchTypes = append(supportedPatchTypes, string(types.ApplyCBORPatchType))
+		}
+	}
+
 	for _, action := range actions {
 		producedObject := storageMeta.ProducesObject(action.Verb)
 		if producedObject == nil {
@@ -720,19 +752,11 @@ func (a *APIInstaller) registerResourceHandlers(path string, storage rest.Storag
 			if isSubresource {
 				doc = "partially update " + subresource + " of the specified " + kind
 			}
-			supportedTypes := []string{
-				string(types.JSONPatchType),
-				string(types.MergePatchType),
-				string(types.StrategicMergePatchType),
-			}
-			if utilfeature.DefaultFeatureGate.Enabled(features.ServerSideApply) {
-				supportedTypes = append(supportedTypes, string(types.ApplyPatchType))
-			}
-			handler := metrics.InstrumentRouteFunc(action.Verb, group, version, resource, subresource, requestScope, metrics.APIServerComponent, restfulPatchResource(patcher, reqScope, admit, supportedTypes))
+			handler := metrics.InstrumentRouteFunc(action.Verb, group, version, resource, subresource, requestScope, metrics.APIServerComponent, restfulPatchResource(patcher, reqScope, admit, supportedPatchTypes))
 			route := ws.PATCH(action.Path).To(handler).
 				Doc(doc).
 				Param(ws.QueryParameter("pretty", "If 'true', then the output is pretty printed.")).
-				Consumes(supportedTypes...).
+				Consumes(supportedPatchTypes...).
 				Operation("patch"+namespaced+kind+strings.Title(subresource)+operationSuffix).
 				Produces(append(storageMeta.ProducesMIMETypes(action.Verb), mediaTypes...)...).
 				Returns(http.StatusOK, "OK", producedObject).
@@ -920,6 +944,14 @@ func (a *APIInstaller) registerResourceHandlers(path string, storage rest.Storag
 	}
 	sort.Strings(apiResource.Verbs)
 
+	// Advertise the media types negotiable for this resource so that clients can choose a
+	// wire format without relying on trial and error.
+	apiResource.ResponseMediaTypes = allMediaTypes
+	apiResource.RequestMediaTypes = mediaTypes
+	if isPatcher {
+		apiResource.PatchMediaTypes = supportedPatchTypes
+	}
+
 	if shortNamesProvider, ok := storage.(rest.ShortNamesProvider); ok {
 		apiResource.ShortNames = shortNamesProvider.ShortNames()
 	}
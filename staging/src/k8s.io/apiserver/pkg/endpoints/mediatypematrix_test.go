@@ -0,0 +1,235 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoints
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	restful "github.com/emicklei/go-restful"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	genericapifilters "k8s.io/apiserver/pkg/endpoints/filters"
+	genericapitesting "k8s.io/apiserver/pkg/endpoints/testing"
+	"k8s.io/apiserver/pkg/registry/rest"
+)
+
+// newMatrixServer installs a group that negotiates every media type the CBOR-enabled
+// codec factory supports and returns both the handler and the container, so that the
+// installed routes can be enumerated.
+func newMatrixServer(storage map[string]rest.Storage) (http.Handler, *restful.Container) {
+	container := restful.NewContainer()
+	container.Router(restful.CurlyRouter{})
+	group := APIGroupVersion{
+		Storage: storage,
+
+		Root:                   "/" + prefix,
+		GroupVersion:           testGroupVersion,
+		OptionsExternalVersion: &testGroupVersion,
+
+		Creater:         scheme,
+		Convertor:       scheme,
+		UnsafeConvertor: runtime.UnsafeObjectConvertor(scheme),
+		Defaulter:       scheme,
+		Typer:           scheme,
+		Linker:          selfLinker,
+
+		EquivalentResourceRegistry: runtime.NewEquivalentResourceRegistry(),
+
+		ParameterCodec: parameterCodec,
+
+		Serializer: cborCodecs,
+
+		Admit: admissionControl,
+	}
+	if err := (&group).InstallREST(container); err != nil {
+		panic(fmt.Sprintf("unable to install container %s: %v", group.GroupVersion, err))
+	}
+	return genericapifilters.WithRequestInfo(container.ServeMux, testRequestInfoResolver()), container
+}
+
+var routeParameterPattern = regexp.MustCompile(`\{[^}]+\}`)
+
+// fillRouteParameters substitutes fixed values for the path parameters of an installed
+// route so that the route can be executed.
+func fillRouteParameters(path string) string {
+	return routeParameterPattern.ReplaceAllStringFunc(path, func(parameter string) string {
+		switch strings.TrimSuffix(strings.TrimPrefix(parameter, "{"), "}") {
+		case "namespace":
+			return "ns"
+		case "name":
+			return "id"
+		default:
+			return "x"
+		}
+	})
+}
+
+// TestMediaTypeMatrix enumerates every route installed for a test group and executes
+// each with every negotiable media type as the Accept (and, for verbs with bodies, the
+// Content-Type), recording a matrix of response status codes. A route responding 406,
+// 415, or 500 to a media type its group's serializer negotiates has incomplete encoding
+// support, which is exactly the regression this matrix is meant to gate: an endpoint
+// added with handwritten negotiation that forgets one of the supported encodings. The
+// matrix is written to the ARTIFACTS directory, when one is configured, for audits that
+// want the full verb-by-encoding picture rather than a pass/fail signal.
+func TestMediaTypeMatrix(t *testing.T) {
+	handler, container := newMatrixServer(map[string]rest.Storage{
+		"simples":     &SimpleRESTStorage{},
+		"simples/sub": &SimpleRESTStorage{},
+	})
+
+	var mediaTypes []string
+	for _, info := range cborCodecs.SupportedMediaTypes() {
+		// The test types have no generated protobuf marshalling. Protobuf support
+		// comes from code generation rather than negotiation, so its absence here says
+		// nothing about installed endpoints.
+		if info.MediaType == runtime.ContentTypeProtobuf {
+			continue
+		}
+		mediaTypes = append(mediaTypes, info.MediaType)
+	}
+	sort.Strings(mediaTypes)
+
+	// bodyFor returns a request body appropriate for the verb, encoded in the given
+	// media type, along with the Content-Type to declare. A name and namespace matching
+	// the route's parameter substitutions keep update requests past the mismatch checks.
+	bodyFor := func(t *testing.T, method, mediaType string) ([]byte, string) {
+		switch method {
+		case http.MethodPost, http.MethodPut:
+			info, ok := runtime.SerializerInfoForMediaType(cborCodecs.SupportedMediaTypes(), mediaType)
+			if !ok {
+				t.Fatalf("no serializer for media type %q", mediaType)
+			}
+			obj := &genericapitesting.Simple{
+				ObjectMeta: metav1.ObjectMeta{Name: "id", Namespace: "ns"},
+				Other:      "matrix",
+			}
+			body, err := runtime.Encode(cborCodecs.EncoderForVersion(info.Serializer, testGroupVersion), obj)
+			if err != nil {
+				t.Fatalf("unexpected encode error: %v", err)
+			}
+			return body, mediaType
+		case http.MethodPatch:
+			// Patch requests negotiate their bodies among the patch media types, not
+			// the object encodings, so the matrix varies only the response encoding.
+			return []byte(`{"other":"patched"}`), string(types.MergePatchType)
+		default:
+			return nil, ""
+		}
+	}
+
+	var matrix []mediaTypeMatrixCell
+	for _, ws := range container.RegisteredWebServices() {
+		for _, route := range ws.Routes() {
+			if strings.Contains(route.Path, "/watch/") {
+				// Watch responses hold the connection open, so they cannot be
+				// executed to completion here. Their encodings are negotiated from
+				// the same stream serializer table as everything else.
+				continue
+			}
+			for _, mediaType := range mediaTypes {
+				body, contentType := bodyFor(t, route.Method, mediaType)
+				// The body reader is always non-nil, as it is for requests
+				// arriving over a network connection.
+				req, err := http.NewRequest(route.Method, fillRouteParameters(route.Path), bytes.NewReader(body))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if contentType != "" {
+					req.Header.Set("Content-Type", contentType)
+				}
+				req.Header.Set("Accept", mediaType)
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+				matrix = append(matrix, mediaTypeMatrixCell{method: route.Method, path: route.Path, mediaType: mediaType, status: w.Code})
+
+				switch w.Code {
+				case http.StatusNotAcceptable, http.StatusUnsupportedMediaType, http.StatusInternalServerError:
+					t.Errorf("%s %s does not support negotiable media type %s: HTTP %d: %s", route.Method, route.Path, mediaType, w.Code, w.Body.String())
+				}
+				if w.Code < 300 {
+					if got := w.Header().Get("Content-Type"); got != mediaType {
+						t.Errorf("%s %s: requested %s but response has Content-Type %s", route.Method, route.Path, mediaType, got)
+					}
+				}
+			}
+		}
+	}
+
+	writeMediaTypeMatrix(t, mediaTypes, matrix)
+}
+
+type mediaTypeMatrixCell struct {
+	method, path, mediaType string
+	status                  int
+}
+
+// writeMediaTypeMatrix renders the status matrix as a fixed-width table and stores it
+// alongside the other test artifacts, or logs it when no artifacts directory is
+// configured.
+func writeMediaTypeMatrix(t *testing.T, mediaTypes []string, matrix []mediaTypeMatrixCell) {
+	statuses := make(map[string]map[string]int)
+	var endpoints []string
+	width := 0
+	for _, c := range matrix {
+		endpoint := c.method + " " + c.path
+		if _, seen := statuses[endpoint]; !seen {
+			statuses[endpoint] = make(map[string]int)
+			endpoints = append(endpoints, endpoint)
+			if len(endpoint) > width {
+				width = len(endpoint)
+			}
+		}
+		statuses[endpoint][c.mediaType] = c.status
+	}
+
+	var table bytes.Buffer
+	fmt.Fprintf(&table, "%-*s", width, "ENDPOINT")
+	for _, mediaType := range mediaTypes {
+		fmt.Fprintf(&table, " %s", mediaType)
+	}
+	fmt.Fprintln(&table)
+	for _, endpoint := range endpoints {
+		fmt.Fprintf(&table, "%-*s", width, endpoint)
+		for _, mediaType := range mediaTypes {
+			fmt.Fprintf(&table, " %-*d", len(mediaType), statuses[endpoint][mediaType])
+		}
+		fmt.Fprintln(&table)
+	}
+
+	if dir := os.Getenv("ARTIFACTS"); dir != "" {
+		name := filepath.Join(dir, "media-type-matrix.txt")
+		if err := ioutil.WriteFile(name, table.Bytes(), 0644); err != nil {
+			t.Fatalf("unexpected error writing matrix artifact: %v", err)
+		}
+		t.Logf("media type matrix written to %s", name)
+		return
+	}
+	t.Logf("media type matrix:\n%s", table.String())
+}
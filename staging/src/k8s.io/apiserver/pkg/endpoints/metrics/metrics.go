@@ -158,6 +158,14 @@ var (
 		},
 		[]string{"verb", "group", "version", "resource", "subresource", "scope", "component", "code"},
 	)
+	mediaTypeDowngrades = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Name:           "apiserver_response_media_type_downgrades_total",
+			Help:           "Number of responses served with a media type other than the one the request preferred because this server does not serve the preferred type, partitioned by requested and served media type.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"requested", "served"},
+	)
 	kubectlExeRegexp = regexp.MustCompile(`^.*((?i:kubectl\.exe))`)
 
 	metrics = []resettableCollector{
@@ -171,6 +179,7 @@ var (
 		WatchEventsSizes,
 		currentInflightRequests,
 		requestTerminationsTotal,
+		mediaTypeDowngrades,
 	}
 )
 
@@ -204,6 +213,13 @@ func UpdateInflightRequestMetrics(nonmutating, mutating int) {
 	currentInflightRequests.WithLabelValues(MutatingKind).Set(float64(mutating))
 }
 
+// RecordMediaTypeDowngrade counts a response that could not be served with the media
+// type the request preferred because the server does not serve that type. Callers must
+// pass only media types from a bounded set, never arbitrary client input.
+func RecordMediaTypeDowngrade(requested, served string) {
+	mediaTypeDowngrades.WithLabelValues(requested, served).Inc()
+}
+
 // RecordRequestTermination records that the request was terminated early as part of a resource
 // preservation or apiserver self-defense mechanism (e.g. timeouts, maxinflight throttling,
 // proxyHandler errors). RecordRequestTermination should only be called zero or one times
@@ -150,6 +150,23 @@ const (
 	//
 	// Allows label and field based indexes in apiserver watch cache to accelerate list operations.
 	SelectorIndex featuregate.Feature = "SelectorIndex"
+
+	// owner: @benluddy
+	// alpha: v1.19
+	//
+	// Allows the storage watcher to drop events by evaluating label selection against
+	// partially-decoded object metadata, skipping the full decode of objects that
+	// would be filtered out anyway.
+	WatchPartialMetadataDecode featuregate.Feature = "WatchPartialMetadataDecode"
+
+	// owner: @benluddy
+	// alpha: v1.19
+	//
+	// Allows the watch cache to dispatch objects decoded by a codec that retains
+	// the stored document (such as the CBOR passthrough codec) without wrapping or
+	// re-encoding them, so that watch responses in the storage encoding are served
+	// from the stored bytes after targeted metadata fix-ups.
+	WatchCacheEncodingPassthrough featuregate.Feature = "WatchCacheEncodingPassthrough"
 )
 
 func init() {
@@ -160,20 +177,22 @@ func init() {
 // To add a new feature, define a key for it above and add it here. The features will be
 // available throughout Kubernetes binaries.
 var defaultKubernetesFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
-	StreamingProxyRedirects: {Default: true, PreRelease: featuregate.Deprecated},
-	ValidateProxyRedirects:  {Default: true, PreRelease: featuregate.Beta},
-	AdvancedAuditing:        {Default: true, PreRelease: featuregate.GA},
-	DynamicAuditing:         {Default: false, PreRelease: featuregate.Alpha},
-	APIResponseCompression:  {Default: true, PreRelease: featuregate.Beta},
-	APIListChunking:         {Default: true, PreRelease: featuregate.Beta},
-	DryRun:                  {Default: true, PreRelease: featuregate.GA},
-	RemainingItemCount:      {Default: true, PreRelease: featuregate.Beta},
-	ServerSideApply:         {Default: true, PreRelease: featuregate.Beta},
-	StorageVersionHash:      {Default: true, PreRelease: featuregate.Beta},
-	WinOverlay:              {Default: false, PreRelease: featuregate.Alpha},
-	WinDSR:                  {Default: false, PreRelease: featuregate.Alpha},
-	WatchBookmark:           {Default: true, PreRelease: featuregate.GA, LockToDefault: true},
-	APIPriorityAndFairness:  {Default: false, PreRelease: featuregate.Alpha},
-	RemoveSelfLink:          {Default: false, PreRelease: featuregate.Alpha},
-	SelectorIndex:           {Default: false, PreRelease: featuregate.Alpha},
+	StreamingProxyRedirects:       {Default: true, PreRelease: featuregate.Deprecated},
+	ValidateProxyRedirects:        {Default: true, PreRelease: featuregate.Beta},
+	AdvancedAuditing:              {Default: true, PreRelease: featuregate.GA},
+	DynamicAuditing:               {Default: false, PreRelease: featuregate.Alpha},
+	APIResponseCompression:        {Default: true, PreRelease: featuregate.Beta},
+	APIListChunking:               {Default: true, PreRelease: featuregate.Beta},
+	DryRun:                        {Default: true, PreRelease: featuregate.GA},
+	RemainingItemCount:            {Default: true, PreRelease: featuregate.Beta},
+	ServerSideApply:               {Default: true, PreRelease: featuregate.Beta},
+	StorageVersionHash:            {Default: true, PreRelease: featuregate.Beta},
+	WinOverlay:                    {Default: false, PreRelease: featuregate.Alpha},
+	WinDSR:                        {Default: false, PreRelease: featuregate.Alpha},
+	WatchBookmark:                 {Default: true, PreRelease: featuregate.GA, LockToDefault: true},
+	APIPriorityAndFairness:        {Default: false, PreRelease: featuregate.Alpha},
+	RemoveSelfLink:                {Default: false, PreRelease: featuregate.Alpha},
+	SelectorIndex:                 {Default: false, PreRelease: featuregate.Alpha},
+	WatchPartialMetadataDecode:    {Default: false, PreRelease: featuregate.Alpha},
+	WatchCacheEncodingPassthrough: {Default: false, PreRelease: featuregate.Alpha},
 }
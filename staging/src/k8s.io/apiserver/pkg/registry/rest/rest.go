@@ -341,6 +341,21 @@ type StorageMetadata interface {
 	ProducesObject(verb string) interface{}
 }
 
+// SupportedMediaTypesProvider is an optional interface that Storage objects can implement
+// to restrict or extend the media types negotiable for the resource in place of the
+// group-wide set. Resources whose objects cannot be encoded in every group media type
+// (for example protobuf-incompatible kinds) can exclude those media types, and resources
+// with dedicated serializers can offer additional ones.
+type SupportedMediaTypesProvider interface {
+	// AllowedMediaTypes returns the group media types that may be negotiated for this
+	// resource. An empty list allows all group media types.
+	AllowedMediaTypes() []string
+
+	// ExtraMediaTypes returns serializers negotiable for this resource in addition to
+	// the group media types.
+	ExtraMediaTypes() []runtime.SerializerInfo
+}
+
 // StorageVersionProvider is an optional interface that a storage object can
 // implement if it wishes to disclose its storage version.
 type StorageVersionProvider interface {
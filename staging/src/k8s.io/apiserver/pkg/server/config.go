@@ -55,9 +55,11 @@ import (
 	apirequest "k8s.io/apiserver/pkg/endpoints/request"
 	genericregistry "k8s.io/apiserver/pkg/registry/generic"
 	"k8s.io/apiserver/pkg/server/dynamiccertificates"
+	"k8s.io/apiserver/pkg/server/dynamicmediatypes"
 	"k8s.io/apiserver/pkg/server/egressselector"
 	genericfilters "k8s.io/apiserver/pkg/server/filters"
 	"k8s.io/apiserver/pkg/server/healthz"
+	"k8s.io/apiserver/pkg/server/objecttrace"
 	"k8s.io/apiserver/pkg/server/routes"
 	serverstore "k8s.io/apiserver/pkg/server/storage"
 	utilflowcontrol "k8s.io/apiserver/pkg/util/flowcontrol"
@@ -97,6 +99,13 @@ type Config struct {
 	// TODO: move into SecureServing(WithLoopback) as soon as insecure serving is gone
 	LoopbackClientConfig *restclient.Config
 
+	// LoopbackClientContentType, if set, is the content type that clients built from
+	// LoopbackClientConfig use for their requests. Internal clients all derive their rest
+	// configs from the loopback config, so the preferred internal content type is applied
+	// here once instead of at each call site. If empty, clients use their default,
+	// application/json.
+	LoopbackClientContentType string
+
 	// EgressSelector provides a lookup mechanism for dialing outbound connections.
 	// It does so based on a EgressSelectorConfiguration which was read at startup.
 	EgressSelector *egressselector.EgressSelector
@@ -159,6 +168,33 @@ type Config struct {
 	// Serializer is required and provides the interface for serializing and converting objects to and from the wire
 	// The default (api.Codecs) usually works fine.
 	Serializer runtime.NegotiatedSerializer
+	// MediaTypePreference, if non-empty, lists media types in the order they should be
+	// preferred during content negotiation when a client's Accept header does not express
+	// a preference among the supported media types (for example `*/*`). It applies to
+	// Serializer and to API groups that do not set their own preference. Media types not
+	// in the list remain supported, after those listed.
+	MediaTypePreference []string
+	// MediaTypeToggle, if non-nil, tracks serving media types that have been disabled at
+	// runtime through the /debug/mediatypes/disabled endpoint. It applies to Serializer
+	// and to every installed API group. Disabling a media type takes effect on subsequent
+	// requests without a restart.
+	MediaTypeToggle *dynamicmediatypes.Toggle
+	// SerializationTrace, if enabled, records the raw bytes and timing of encodes and
+	// decodes of objects matching its configured kind/name patterns, exposed through the
+	// /debug/serialization/trace endpoint. It is a debug facility for investigating an
+	// object that serializes differently in one format than another.
+	SerializationTrace *objecttrace.Recorder
+	// CBORNegotiationAllowedGroups and CBORNegotiationAllowedUserAgentPrefixes, when
+	// either is non-empty, restrict negotiation of application/cbor responses to clients
+	// that are members of one of the listed groups or whose User-Agent begins with one of
+	// the listed prefixes, for cautious rollouts of CBOR serving. When both are empty no
+	// restriction applies.
+	CBORNegotiationAllowedGroups            []string
+	CBORNegotiationAllowedUserAgentPrefixes []string
+	// StorageMediaType, if set, is the default media type used to persist objects. It is
+	// recorded here so debug endpoints can report it; it has no effect on how objects
+	// are actually persisted.
+	StorageMediaType string
 	// OpenAPIConfig will be used in generating OpenAPI spec. This is nil by default. Use DefaultOpenAPIConfig for "working" defaults.
 	OpenAPIConfig *openapicommon.Config
 
@@ -189,6 +225,11 @@ type Config struct {
 	// The limit on the request size that would be accepted and decoded in a write request
 	// 0 means no limit.
 	MaxRequestBodyBytes int64
+	// MaxRequestBodyBytesByMediaType overrides MaxRequestBodyBytes for request bodies
+	// declared with a matching media type. The general limit is tuned for JSON bodies;
+	// a denser encoding can carry a larger object in the same number of bytes, so it is
+	// assigned a lower limit to keep decoded object sizes comparable across media types.
+	MaxRequestBodyBytesByMediaType map[string]int64
 	// MaxRequestsInFlight is the maximum number of parallel non-long-running requests. Every further
 	// request has to wait. Applies only to non-mutating requests.
 	MaxRequestsInFlight int
@@ -294,6 +335,7 @@ func NewConfig(codecs serializer.CodecFactory) *Config {
 		HealthzChecks:               append([]healthz.HealthChecker{}, defaultHealthChecks...),
 		ReadyzChecks:                append([]healthz.HealthChecker{}, defaultHealthChecks...),
 		LivezChecks:                 append([]healthz.HealthChecker{}, defaultHealthChecks...),
+		MediaTypeToggle:             dynamicmediatypes.NewToggle(),
 		EnableIndex:                 true,
 		EnableDiscovery:             true,
 		EnableProfiling:             true,
@@ -318,6 +360,13 @@ func NewConfig(codecs serializer.CodecFactory) *Config {
 		// proto when persisted in etcd, so we allow 2x as the largest request
 		// body size to be accepted and decoded in a write request.
 		MaxRequestBodyBytes: int64(3 * 1024 * 1024),
+		// CBOR needs no quoting or escaping and spends at most one byte per
+		// container element on structure, so in the worst case it can encode
+		// roughly twice as much object per byte as JSON. Hold CBOR bodies to
+		// half the general limit so both encodings admit comparable objects.
+		MaxRequestBodyBytesByMediaType: map[string]int64{
+			runtime.ContentTypeCBOR: int64(3 * 1024 * 1024 / 2),
+		},
 
 		// Default to treating watch as a long-running operation
 		// Generic API servers have no inherent long-running subresources
@@ -485,11 +534,22 @@ func (c *Config) Complete(informers informers.SharedInformerFactory) CompletedCo
 	}
 
 	AuthorizeClientBearerToken(c.LoopbackClientConfig, &c.Authentication, &c.Authorization)
+	ApplyLoopbackContentType(c.LoopbackClientConfig, c.LoopbackClientContentType)
 
 	if c.RequestInfoResolver == nil {
 		c.RequestInfoResolver = NewRequestInfoResolver(c)
 	}
 
+	if c.Serializer != nil {
+		c.Serializer = serializer.WithMediaTypePreference(c.Serializer, c.MediaTypePreference)
+		if c.MediaTypeToggle != nil {
+			c.Serializer = c.MediaTypeToggle.WrapSerializer(c.Serializer)
+		}
+		if c.SerializationTrace.Enabled() {
+			c.Serializer = c.SerializationTrace.WrapSerializer(c.Serializer)
+		}
+	}
+
 	if c.EquivalentResourceRegistry == nil {
 		if c.RESTOptionsGetter == nil {
 			c.EquivalentResourceRegistry = runtime.NewEquivalentResourceRegistry()
@@ -528,6 +588,14 @@ func (c completedConfig) New(name string, delegationTarget DelegationTarget) (*G
 		return nil, fmt.Errorf("Genericapiserver.New() called with config.EquivalentResourceRegistry == nil")
 	}
 
+	if klog.V(2).Enabled() {
+		mediaTypes := make([]string, 0, len(c.Serializer.SupportedMediaTypes()))
+		for _, info := range c.Serializer.SupportedMediaTypes() {
+			mediaTypes = append(mediaTypes, info.MediaType)
+		}
+		klog.V(2).Infof("%q supports response media types: %s", name, strings.Join(mediaTypes, ", "))
+	}
+
 	handlerChainBuilder := func(handler http.Handler) http.Handler {
 		return c.BuildHandlerChainFunc(handler, c.Config)
 	}
@@ -539,6 +607,8 @@ func (c completedConfig) New(name string, delegationTarget DelegationTarget) (*G
 		legacyAPIGroupPrefixes:     c.LegacyAPIGroupPrefixes,
 		admissionControl:           c.AdmissionControl,
 		Serializer:                 c.Serializer,
+		mediaTypePreference:        c.MediaTypePreference,
+		mediaTypeToggle:            c.MediaTypeToggle,
 		AuditBackend:               c.AuditBackend,
 		Authorizer:                 c.Authorization.Authorizer,
 		delegationTarget:           delegationTarget,
@@ -569,8 +639,9 @@ func (c completedConfig) New(name string, delegationTarget DelegationTarget) (*G
 
 		DiscoveryGroupManager: discovery.NewRootAPIsHandler(c.DiscoveryAddresses, c.Serializer),
 
-		maxRequestBodyBytes: c.MaxRequestBodyBytes,
-		livezClock:          clock.RealClock{},
+		maxRequestBodyBytes:            c.MaxRequestBodyBytes,
+		maxRequestBodyBytesByMediaType: c.MaxRequestBodyBytesByMediaType,
+		livezClock:                     clock.RealClock{},
 	}
 
 	for {
@@ -659,7 +730,12 @@ func (c completedConfig) New(name string, delegationTarget DelegationTarget) (*G
 }
 
 func DefaultBuildHandlerChain(apiHandler http.Handler, c *Config) http.Handler {
-	handler := genericapifilters.WithAuthorization(apiHandler, c.Authorization.Authorizer, c.Serializer)
+	handler := apiHandler
+	if len(c.CBORNegotiationAllowedGroups) > 0 || len(c.CBORNegotiationAllowedUserAgentPrefixes) > 0 {
+		// Inside authentication so that the authenticated user's groups are known.
+		handler = genericapifilters.WithCBORNegotiationRestriction(handler, c.CBORNegotiationAllowedGroups, c.CBORNegotiationAllowedUserAgentPrefixes)
+	}
+	handler = genericapifilters.WithAuthorization(handler, c.Authorization.Authorizer, c.Serializer)
 	if c.FlowControl != nil {
 		handler = genericfilters.WithPriorityAndFairness(handler, c.LongRunningFunc, c.FlowControl)
 	} else {
@@ -679,6 +755,7 @@ func DefaultBuildHandlerChain(apiHandler http.Handler, c *Config) http.Handler {
 	}
 	handler = genericapifilters.WithAuditAnnotations(handler, c.AuditBackend, c.AuditPolicyChecker)
 	handler = genericapifilters.WithCacheControl(handler)
+	handler = genericapifilters.WithNegotiationCache(handler)
 	handler = genericfilters.WithPanicRecovery(handler)
 	return handler
 }
@@ -694,6 +771,12 @@ func installAPI(s *GenericAPIServer, c *Config) {
 		}
 		// so far, only logging related endpoints are considered valid to add for these debug flags.
 		routes.DebugFlags{}.Install(s.Handler.NonGoRestfulMux, "v", routes.StringFlagPutHandler(logs.GlogSetter))
+		routes.DebugSerializers{Serializer: c.Serializer, StorageMediaType: c.StorageMediaType}.Install(s.Handler.NonGoRestfulMux)
+		routes.DebugMediaTypes{Toggle: c.MediaTypeToggle}.Install(s.Handler.NonGoRestfulMux)
+		routes.DebugObjectTrace{Recorder: c.SerializationTrace}.Install(s.Handler.NonGoRestfulMux)
+	}
+	if c.FlowControl != nil {
+		routes.DebugFlowControl{FlowControl: c.FlowControl}.Install(s.Handler.NonGoRestfulMux)
 	}
 	if c.EnableMetrics {
 		if c.EnableProfiling {
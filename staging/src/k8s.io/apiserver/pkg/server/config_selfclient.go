@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net"
 
+	"k8s.io/apimachinery/pkg/runtime"
 	restclient "k8s.io/client-go/rest"
 	netutils "k8s.io/utils/net"
 )
@@ -62,6 +63,25 @@ func (s *SecureServingInfo) NewLoopbackClientConfig(token string, loopbackCert [
 	return c, nil
 }
 
+// ApplyLoopbackContentType sets the request and accepted content types on a loopback
+// client config. The server's internal clients (its own controllers, lease controllers,
+// post-start hooks, and anything else built from copies of the loopback config) inherit
+// whatever is set here, so the cluster's preferred internal content type reaches all of
+// them without changes at individual call sites. An empty content type leaves the config
+// untouched.
+func ApplyLoopbackContentType(config *restclient.Config, contentType string) {
+	if config == nil || len(contentType) == 0 {
+		return
+	}
+	config.ContentType = contentType
+	// Accept JSON as a fallback so that responses stay decodable for any resource that
+	// cannot be served in the preferred type.
+	config.AcceptContentTypes = contentType
+	if contentType != runtime.ContentTypeJSON {
+		config.AcceptContentTypes = contentType + "," + runtime.ContentTypeJSON
+	}
+}
+
 // LoopbackHostPort returns the host and port loopback REST clients should use
 // to contact the server.
 func LoopbackHostPort(bindAddress string) (string, string, error) {
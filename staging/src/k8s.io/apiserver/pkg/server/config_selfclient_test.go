@@ -20,6 +20,7 @@ import (
 	"net"
 	"testing"
 
+	restclient "k8s.io/client-go/rest"
 	netutils "k8s.io/utils/net"
 )
 
@@ -86,6 +87,53 @@ func TestLoopbackHostPortIPv6(t *testing.T) {
 	}
 }
 
+func TestApplyLoopbackContentType(t *testing.T) {
+	testCases := []struct {
+		name             string
+		contentType      string
+		wantContentType  string
+		wantAcceptstring string
+	}{
+		{
+			name: "empty leaves defaults",
+		},
+		{
+			name:             "json",
+			contentType:      "application/json",
+			wantContentType:  "application/json",
+			wantAcceptstring: "application/json",
+		},
+		{
+			name:             "cbor with json fallback",
+			contentType:      "application/cbor",
+			wantContentType:  "application/cbor",
+			wantAcceptstring: "application/cbor,application/json",
+		},
+		{
+			name:             "protobuf with json fallback",
+			contentType:      "application/vnd.kubernetes.protobuf",
+			wantContentType:  "application/vnd.kubernetes.protobuf",
+			wantAcceptstring: "application/vnd.kubernetes.protobuf,application/json",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &restclient.Config{}
+			ApplyLoopbackContentType(config, tc.contentType)
+			if config.ContentType != tc.wantContentType {
+				t.Errorf("expected content type %q, got %q", tc.wantContentType, config.ContentType)
+			}
+			if config.AcceptContentTypes != tc.wantAcceptstring {
+				t.Errorf("expected accept content types %q, got %q", tc.wantAcceptstring, config.AcceptContentTypes)
+			}
+		})
+	}
+
+	// A nil config must be tolerated; servers without a loopback connection complete
+	// their configs too.
+	ApplyLoopbackContentType(nil, "application/cbor")
+}
+
 func isIPv6LoopbackSupported() (ipv6 bool, ipv6only bool, err error) {
 	addrs, err := net.InterfaceAddrs()
 	if err != nil {
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package dynamicmediatypes allows serving media types to be disabled at runtime, so
+// that a misbehaving encoding can be rolled back in an emergency without restarting the
+// server or changing its flags.
+package dynamicmediatypes
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Toggle tracks the set of serving media types that are dynamically disabled. Readers
+// take an atomic snapshot of the set, so an update takes effect on requests that begin
+// after it completes, and a request in flight observes one consistent configuration for
+// its duration.
+type Toggle struct {
+	disabled atomic.Value // holds a sets.String that is never mutated after it is stored
+}
+
+// NewToggle returns a Toggle with no media types disabled.
+func NewToggle() *Toggle {
+	t := &Toggle{}
+	t.disabled.Store(sets.NewString())
+	return t
+}
+
+func (t *Toggle) snapshot() sets.String {
+	return t.disabled.Load().(sets.String)
+}
+
+// Disabled returns the media types that are currently disabled, sorted.
+func (t *Toggle) Disabled() []string {
+	return t.snapshot().List()
+}
+
+// SetDisabled replaces the set of disabled media types. Disabling application/json is
+// refused: it is the format of last resort, and a toggle intended for emergency rollback
+// must not be able to take every encoding out of service.
+func (t *Toggle) SetDisabled(mediaTypes []string) error {
+	disabled := sets.NewString(mediaTypes...)
+	if disabled.Has(runtime.ContentTypeJSON) {
+		return fmt.Errorf("media type %s cannot be disabled", runtime.ContentTypeJSON)
+	}
+	t.disabled.Store(disabled)
+	return nil
+}
+
+// WrapSerializer returns a NegotiatedSerializer that omits the media types disabled by
+// the toggle. The disabled set is consulted on each call to SupportedMediaTypes, which
+// negotiation performs once per request, so changes to the toggle take effect without
+// reinstalling any handlers.
+func (t *Toggle) WrapSerializer(ns runtime.NegotiatedSerializer) runtime.NegotiatedSerializer {
+	return &toggledSerializer{NegotiatedSerializer: ns, toggle: t}
+}
+
+type toggledSerializer struct {
+	runtime.NegotiatedSerializer
+	toggle *Toggle
+}
+
+func (s *toggledSerializer) SupportedMediaTypes() []runtime.SerializerInfo {
+	supported := s.NegotiatedSerializer.SupportedMediaTypes()
+	disabled := s.toggle.snapshot()
+	if disabled.Len() == 0 {
+		return supported
+	}
+	remaining := make([]runtime.SerializerInfo, 0, len(supported))
+	for i := range supported {
+		if !disabled.Has(supported[i].MediaType) {
+			remaining = append(remaining, supported[i])
+		}
+	}
+	return remaining
+}
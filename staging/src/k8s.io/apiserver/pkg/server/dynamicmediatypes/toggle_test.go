@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicmediatypes
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type fakeNegotiatedSerializer struct {
+	runtime.NegotiatedSerializer
+	mediaTypes []string
+}
+
+func (f fakeNegotiatedSerializer) SupportedMediaTypes() []runtime.SerializerInfo {
+	infos := make([]runtime.SerializerInfo, 0, len(f.mediaTypes))
+	for _, mediaType := range f.mediaTypes {
+		infos = append(infos, runtime.SerializerInfo{MediaType: mediaType})
+	}
+	return infos
+}
+
+func supportedMediaTypes(ns runtime.NegotiatedSerializer) []string {
+	var mediaTypes []string
+	for _, info := range ns.SupportedMediaTypes() {
+		mediaTypes = append(mediaTypes, info.MediaType)
+	}
+	return mediaTypes
+}
+
+func TestToggle(t *testing.T) {
+	toggle := NewToggle()
+	wrapped := toggle.WrapSerializer(fakeNegotiatedSerializer{mediaTypes: []string{
+		"application/json",
+		"application/yaml",
+		"application/cbor",
+	}})
+
+	if got := supportedMediaTypes(wrapped); !reflect.DeepEqual(got, []string{"application/json", "application/yaml", "application/cbor"}) {
+		t.Errorf("expected all media types before any are disabled, got %v", got)
+	}
+
+	if err := toggle.SetDisabled([]string{"application/cbor"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The disabled set is consulted per call, so the change is observed without
+	// rewrapping the serializer.
+	if got := supportedMediaTypes(wrapped); !reflect.DeepEqual(got, []string{"application/json", "application/yaml"}) {
+		t.Errorf("expected cbor to be omitted while disabled, got %v", got)
+	}
+	if got := toggle.Disabled(); !reflect.DeepEqual(got, []string{"application/cbor"}) {
+		t.Errorf("expected disabled list [application/cbor], got %v", got)
+	}
+
+	if err := toggle.SetDisabled(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := supportedMediaTypes(wrapped); !reflect.DeepEqual(got, []string{"application/json", "application/yaml", "application/cbor"}) {
+		t.Errorf("expected all media types after re-enabling, got %v", got)
+	}
+}
+
+func TestToggleRefusesToDisableJSON(t *testing.T) {
+	toggle := NewToggle()
+	if err := toggle.SetDisabled([]string{"application/cbor", "application/json"}); err == nil {
+		t.Fatal("expected error disabling application/json")
+	}
+	if got := toggle.Disabled(); len(got) != 0 {
+		t.Errorf("rejected update must not take partial effect, got %v", got)
+	}
+}
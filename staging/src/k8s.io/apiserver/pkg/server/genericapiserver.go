@@ -41,6 +41,7 @@ import (
 	genericapi "k8s.io/apiserver/pkg/endpoints"
 	"k8s.io/apiserver/pkg/endpoints/discovery"
 	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/apiserver/pkg/server/dynamicmediatypes"
 	"k8s.io/apiserver/pkg/server/healthz"
 	"k8s.io/apiserver/pkg/server/routes"
 	utilopenapi "k8s.io/apiserver/pkg/util/openapi"
@@ -75,6 +76,9 @@ type APIGroupInfo struct {
 	Scheme *runtime.Scheme
 	// NegotiatedSerializer controls how this group encodes and decodes data
 	NegotiatedSerializer runtime.NegotiatedSerializer
+	// MediaTypePreference, if non-empty, overrides the server-wide preference order of
+	// NegotiatedSerializer's media types used when a client's Accept header is ambiguous.
+	MediaTypePreference []string
 	// ParameterCodec performs conversions for query parameters passed to API calls
 	ParameterCodec runtime.ParameterCodec
 
@@ -116,6 +120,14 @@ type GenericAPIServer struct {
 	// Individual APIGroups may define their own serializers.
 	Serializer runtime.NegotiatedSerializer
 
+	// mediaTypePreference is the server-wide media type preference order applied to API
+	// groups that do not specify their own.
+	mediaTypePreference []string
+
+	// mediaTypeToggle, if non-nil, omits dynamically disabled media types from
+	// negotiation for every installed API group.
+	mediaTypeToggle *dynamicmediatypes.Toggle
+
 	// "Outputs"
 	// Handler holds the handlers being used by this API server
 	Handler *APIServerHandler
@@ -197,6 +209,9 @@ type GenericAPIServer struct {
 	// The limit on the request body size that would be accepted and decoded in a write request.
 	// 0 means no limit.
 	maxRequestBodyBytes int64
+	// maxRequestBodyBytesByMediaType overrides maxRequestBodyBytes for request bodies
+	// declared with a matching media type.
+	maxRequestBodyBytesByMediaType map[string]int64
 }
 
 // DelegationTarget is an interface which allows for composition of API servers with top level handling that works
@@ -416,6 +431,7 @@ func (s *GenericAPIServer) installAPIResources(apiPrefix string, apiGroupInfo *A
 		}
 		apiGroupVersion.OpenAPIModels = openAPIModels
 		apiGroupVersion.MaxRequestBodyBytes = s.maxRequestBodyBytes
+		apiGroupVersion.MaxRequestBodyBytesByMediaType = s.maxRequestBodyBytesByMediaType
 
 		if err := apiGroupVersion.InstallREST(s.Handler.GoRestfulContainer); err != nil {
 			return fmt.Errorf("unable to setup API %v: %v", apiGroupInfo, err)
@@ -516,12 +532,20 @@ func (s *GenericAPIServer) getAPIGroupVersion(apiGroupInfo *APIGroupInfo, groupV
 }
 
 func (s *GenericAPIServer) newAPIGroupVersion(apiGroupInfo *APIGroupInfo, groupVersion schema.GroupVersion) *genericapi.APIGroupVersion {
+	preference := apiGroupInfo.MediaTypePreference
+	if len(preference) == 0 {
+		preference = s.mediaTypePreference
+	}
+	groupSerializer := serializer.WithMediaTypePreference(apiGroupInfo.NegotiatedSerializer, preference)
+	if s.mediaTypeToggle != nil {
+		groupSerializer = s.mediaTypeToggle.WrapSerializer(groupSerializer)
+	}
 	return &genericapi.APIGroupVersion{
 		GroupVersion:     groupVersion,
 		MetaGroupVersion: apiGroupInfo.MetaGroupVersion,
 
 		ParameterCodec:  apiGroupInfo.ParameterCodec,
-		Serializer:      apiGroupInfo.NegotiatedSerializer,
+		Serializer:      groupSerializer,
 		Creater:         apiGroupInfo.Scheme,
 		Convertor:       apiGroupInfo.Scheme,
 		UnsafeConvertor: runtime.UnsafeObjectConvertor(apiGroupInfo.Scheme),
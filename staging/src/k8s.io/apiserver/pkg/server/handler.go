@@ -83,6 +83,14 @@ func NewAPIServerHandler(name string, s runtime.NegotiatedSerializer, handlerCha
 		logStackOnRecover(s, panicReason, httpWriter)
 	})
 	gorestfulContainer.ServiceErrorHandler(func(serviceErr restful.ServiceError, request *restful.Request, response *restful.Response) {
+		if serviceErr.Code == http.StatusMethodNotAllowed || serviceErr.Code == http.StatusUnsupportedMediaType {
+			// Advertise the patch content types accepted at this path (RFC 5789,
+			// section 3.1) so that an OPTIONS probe, or a request refused with 405
+			// or 415, tells the client what a PATCH could contain.
+			if acceptPatch := acceptPatchValue(gorestfulContainer, request.Request.URL.Path); len(acceptPatch) > 0 {
+				response.Header().Set("Accept-Patch", acceptPatch)
+			}
+		}
 		serviceErrorHandler(s, serviceErr, request, response)
 	})
 
@@ -174,6 +182,42 @@ func logStackOnRecover(s runtime.NegotiatedSerializer, panicReason interface{},
 	responsewriters.ErrorNegotiated(apierrors.NewGenericServerResponse(http.StatusInternalServerError, "", schema.GroupResource{}, "", "", 0, false), s, schema.GroupVersion{}, w, &http.Request{Header: headers})
 }
 
+// acceptPatchValue returns the Accept-Patch header value for the resource at the given
+// path, or empty if no registered route accepts a PATCH of the path.
+func acceptPatchValue(c *restful.Container, path string) string {
+	for _, ws := range c.RegisteredWebServices() {
+		for _, route := range ws.Routes() {
+			if route.Method == http.MethodPatch && routePathMatches(route.Path, path) {
+				return strings.Join(route.Consumes, ", ")
+			}
+		}
+	}
+	return ""
+}
+
+// routePathMatches reports whether a request path matches a route path template, treating
+// template variables as single-segment wildcards. go-restful does not export its route
+// matchers.
+func routePathMatches(template, path string) bool {
+	templateSegments := strings.Split(strings.Trim(template, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(templateSegments) != len(pathSegments) {
+		return false
+	}
+	for i, templateSegment := range templateSegments {
+		if strings.HasPrefix(templateSegment, "{") && strings.HasSuffix(templateSegment, "}") {
+			if len(pathSegments[i]) == 0 {
+				return false
+			}
+			continue
+		}
+		if templateSegment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func serviceErrorHandler(s runtime.NegotiatedSerializer, serviceErr restful.ServiceError, request *restful.Request, resp *restful.Response) {
 	responsewriters.ErrorNegotiated(
 		apierrors.NewGenericServerResponse(serviceErr.Code, "", schema.GroupResource{}, "", serviceErr.Message, 0, false),
@@ -0,0 +1,105 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+)
+
+func TestServiceErrorsAdvertiseAcceptPatch(t *testing.T) {
+	handler := NewAPIServerHandler("test", codecs, func(apiHandler http.Handler) http.Handler { return apiHandler }, nil)
+
+	ws := new(restful.WebService)
+	ws.Path("/apis/example.com/v1")
+	ws.Route(ws.PATCH("/namespaces/{namespace}/widgets/{name}").
+		Consumes("application/merge-patch+json", "application/apply-patch+cbor").
+		To(func(*restful.Request, *restful.Response) {}))
+	handler.GoRestfulContainer.Add(ws)
+
+	server := httptest.NewServer(handler.Director)
+	defer server.Close()
+
+	for _, tc := range []struct {
+		name            string
+		method          string
+		path            string
+		wantStatus      int
+		wantAcceptPatch string
+	}{
+		{
+			name:            "options on patchable resource",
+			method:          "OPTIONS",
+			path:            "/apis/example.com/v1/namespaces/ns/widgets/w",
+			wantStatus:      http.StatusMethodNotAllowed,
+			wantAcceptPatch: "application/merge-patch+json, application/apply-patch+cbor",
+		},
+		{
+			name:            "unsupported method on patchable resource",
+			method:          "POST",
+			path:            "/apis/example.com/v1/namespaces/ns/widgets/w",
+			wantStatus:      http.StatusMethodNotAllowed,
+			wantAcceptPatch: "application/merge-patch+json, application/apply-patch+cbor",
+		},
+		{
+			name:       "options on path without patch route",
+			method:     "OPTIONS",
+			path:       "/apis/example.com/v1/namespaces/ns/widgets",
+			wantStatus: http.StatusNotFound,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, server.URL+tc.path, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tc.wantStatus {
+				t.Errorf("expected status %d, got %d", tc.wantStatus, resp.StatusCode)
+			}
+			if got := resp.Header.Get("Accept-Patch"); got != tc.wantAcceptPatch {
+				t.Errorf("expected Accept-Patch %q, got %q", tc.wantAcceptPatch, got)
+			}
+		})
+	}
+}
+
+func TestRoutePathMatches(t *testing.T) {
+	for _, tc := range []struct {
+		template string
+		path     string
+		want     bool
+	}{
+		{"/api/v1/namespaces/{namespace}/pods/{name}", "/api/v1/namespaces/ns/pods/p", true},
+		{"/api/v1/namespaces/{namespace}/pods/{name}", "/api/v1/namespaces/ns/pods", false},
+		{"/api/v1/namespaces/{namespace}/pods/{name}", "/api/v1/namespaces/ns/services/s", false},
+		{"/api/v1/namespaces/{namespace}/pods/{name}", "/api/v1/namespaces/ns/pods/p/status", false},
+		{"/api/v1/nodes/{name}", "/api/v1/nodes/n", true},
+		{"/api/v1/nodes/{name}", "/api/v1/nodes/", false},
+	} {
+		if got := routePathMatches(tc.template, tc.path); got != tc.want {
+			t.Errorf("routePathMatches(%q, %q) = %t, expected %t", tc.template, tc.path, got, tc.want)
+		}
+	}
+}
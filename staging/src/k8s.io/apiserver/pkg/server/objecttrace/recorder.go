@@ -0,0 +1,224 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package objecttrace records the raw serialized bytes and timing of encodes and decodes
+// of individually named objects, for chasing down an object that serializes differently
+// in one format than another in a running cluster. It is a debug facility: recording is
+// off unless patterns are configured, and records are kept in a bounded ring buffer.
+package objecttrace
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// capacity bounds the number of retained records. Old records are overwritten by new
+// ones, so the buffer always holds the most recent serializations of matched objects.
+const capacity = 128
+
+// Record captures one encode or decode of a matched object.
+type Record struct {
+	// Time is when the serialization completed.
+	Time time.Time `json:"time"`
+	// Operation is "encode" or "decode".
+	Operation string `json:"operation"`
+	// Identifier is the identifier of the encoder that produced the bytes. It is empty
+	// for decodes, where the format is evident from the recorded bytes.
+	Identifier string `json:"identifier,omitempty"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	// Duration is how long the underlying serializer took, in nanoseconds.
+	Duration time.Duration `json:"durationNanos"`
+	// Data is the raw serialized bytes, base64-encoded in the JSON representation.
+	Data []byte `json:"data"`
+}
+
+type pattern struct {
+	kind string
+	name string
+}
+
+// Recorder matches objects against configured kind/name patterns and retains the raw
+// bytes and timing of their serializations.
+type Recorder struct {
+	patterns []pattern // immutable after construction
+
+	lock    sync.Mutex
+	records []Record
+	next    int
+}
+
+// NewRecorder returns a Recorder for the given patterns. Each pattern has the form
+// kind/name; either component may be * to match anything.
+func NewRecorder(patterns []string) (*Recorder, error) {
+	r := &Recorder{}
+	for _, p := range patterns {
+		parts := strings.Split(p, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid object trace pattern %q: must have the form kind/name, with * as a wildcard for either component", p)
+		}
+		r.patterns = append(r.patterns, pattern{kind: parts[0], name: parts[1]})
+	}
+	return r, nil
+}
+
+// Enabled returns true if the recorder has at least one pattern. A nil or patternless
+// recorder records nothing.
+func (r *Recorder) Enabled() bool {
+	return r != nil && len(r.patterns) > 0
+}
+
+func (r *Recorder) matches(kind, name string) bool {
+	for _, p := range r.patterns {
+		if (p.kind == "*" || p.kind == kind) && (p.name == "*" || p.name == name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recorder) add(record Record) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.records) < capacity {
+		r.records = append(r.records, record)
+		return
+	}
+	r.records[r.next] = record
+	r.next = (r.next + 1) % capacity
+}
+
+// Records returns the retained records in chronological order.
+func (r *Recorder) Records() []Record {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	out := make([]Record, 0, len(r.records))
+	out = append(out, r.records[r.next:]...)
+	out = append(out, r.records[:r.next]...)
+	return out
+}
+
+// WrapSerializer returns a NegotiatedSerializer whose encoders and decoders record the
+// serializations of matched objects. A recorder with no patterns returns the serializer
+// unchanged, so the request path pays nothing when tracing is not configured.
+func (r *Recorder) WrapSerializer(ns runtime.NegotiatedSerializer) runtime.NegotiatedSerializer {
+	if !r.Enabled() {
+		return ns
+	}
+	return &tracedSerializer{NegotiatedSerializer: ns, recorder: r}
+}
+
+type tracedSerializer struct {
+	runtime.NegotiatedSerializer
+	recorder *Recorder
+}
+
+func (s *tracedSerializer) EncoderForVersion(e runtime.Encoder, gv runtime.GroupVersioner) runtime.Encoder {
+	return &tracedEncoder{delegate: s.NegotiatedSerializer.EncoderForVersion(e, gv), recorder: s.recorder}
+}
+
+func (s *tracedSerializer) DecoderToVersion(d runtime.Decoder, gv runtime.GroupVersioner) runtime.Decoder {
+	return &tracedDecoder{delegate: s.NegotiatedSerializer.DecoderToVersion(d, gv), recorder: s.recorder}
+}
+
+type tracedEncoder struct {
+	delegate runtime.Encoder
+	recorder *Recorder
+}
+
+func (e *tracedEncoder) Encode(obj runtime.Object, w io.Writer) error {
+	kind, namespace, name, ok := identify(obj)
+	if !ok || !e.recorder.matches(kind, name) {
+		return e.delegate.Encode(obj, w)
+	}
+	var buf bytes.Buffer
+	start := time.Now()
+	if err := e.delegate.Encode(obj, &buf); err != nil {
+		return err
+	}
+	e.recorder.add(Record{
+		Time:       time.Now(),
+		Operation:  "encode",
+		Identifier: string(e.delegate.Identifier()),
+		Kind:       kind,
+		Namespace:  namespace,
+		Name:       name,
+		Duration:   time.Since(start),
+		Data:       append([]byte(nil), buf.Bytes()...),
+	})
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (e *tracedEncoder) Identifier() runtime.Identifier {
+	return e.delegate.Identifier()
+}
+
+type tracedDecoder struct {
+	delegate runtime.Decoder
+	recorder *Recorder
+}
+
+func (d *tracedDecoder) Decode(data []byte, defaults *schema.GroupVersionKind, into runtime.Object) (runtime.Object, *schema.GroupVersionKind, error) {
+	start := time.Now()
+	obj, gvk, err := d.delegate.Decode(data, defaults, into)
+	duration := time.Since(start)
+	if err != nil || obj == nil {
+		return obj, gvk, err
+	}
+	if kind, namespace, name, ok := identify(obj); ok && d.recorder.matches(kind, name) {
+		d.recorder.add(Record{
+			Time:      time.Now(),
+			Operation: "decode",
+			Kind:      kind,
+			Namespace: namespace,
+			Name:      name,
+			Duration:  duration,
+			Data:      append([]byte(nil), data...),
+		})
+	}
+	return obj, gvk, err
+}
+
+// identify extracts the kind, namespace, and name an object is matched on. Typed objects
+// usually carry an empty kind outside of serialization, so the Go type name stands in for
+// the kind when it is unset. Objects without object metadata (lists, status) are not
+// matched.
+func identify(obj runtime.Object) (kind, namespace, name string, ok bool) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", "", "", false
+	}
+	kind = obj.GetObjectKind().GroupVersionKind().Kind
+	if kind == "" {
+		t := reflect.TypeOf(obj)
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		kind = t.Name()
+	}
+	return kind, accessor.GetNamespace(), accessor.GetName(), true
+}
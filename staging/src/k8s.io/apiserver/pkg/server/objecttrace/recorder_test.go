@@ -0,0 +1,161 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objecttrace
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apiserver/pkg/apis/example"
+	examplev1 "k8s.io/apiserver/pkg/apis/example/v1"
+)
+
+func TestNewRecorderValidation(t *testing.T) {
+	for _, invalid := range []string{"", "Pod", "/name", "Pod/", "a/b/c"} {
+		if _, err := NewRecorder([]string{invalid}); err == nil {
+			t.Errorf("expected an error for pattern %q, got none", invalid)
+		}
+	}
+	if _, err := NewRecorder([]string{"Pod/example", "Node/*", "*/foo"}); err != nil {
+		t.Errorf("unexpected error for valid patterns: %v", err)
+	}
+}
+
+func TestRecorderMatches(t *testing.T) {
+	r, err := NewRecorder([]string{"Pod/example", "Node/*"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tc := range []struct {
+		kind, name string
+		want       bool
+	}{
+		{"Pod", "example", true},
+		{"Pod", "other", false},
+		{"Node", "anything", true},
+		{"Service", "example", false},
+	} {
+		if got := r.matches(tc.kind, tc.name); got != tc.want {
+			t.Errorf("matches(%q, %q) = %t, want %t", tc.kind, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestRecorderRingBuffer(t *testing.T) {
+	r, err := NewRecorder([]string{"Pod/*"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < capacity+10; i++ {
+		r.add(Record{Name: fmt.Sprintf("%d", i)})
+	}
+	records := r.Records()
+	if len(records) != capacity {
+		t.Fatalf("expected %d retained records, got %d", capacity, len(records))
+	}
+	for i, record := range records {
+		if want := fmt.Sprintf("%d", i+10); record.Name != want {
+			t.Fatalf("expected record %d to be %q, got %q", i, want, record.Name)
+		}
+	}
+}
+
+func TestWrapSerializerRecordsMatchedObjects(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(example.AddToScheme(scheme))
+	utilruntime.Must(examplev1.AddToScheme(scheme))
+	codecs := serializer.NewCodecFactory(scheme)
+
+	recorder, err := NewRecorder([]string{"Pod/traced"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wrapped := recorder.WrapSerializer(codecs)
+
+	info, ok := runtime.SerializerInfoForMediaType(wrapped.SupportedMediaTypes(), runtime.ContentTypeJSON)
+	if !ok {
+		t.Fatal("no json serializer")
+	}
+	encoder := wrapped.EncoderForVersion(info.Serializer, examplev1.SchemeGroupVersion)
+	decoder := wrapped.DecoderToVersion(info.Serializer, examplev1.SchemeGroupVersion)
+
+	makePod := func(name string) *examplev1.Pod {
+		return &examplev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"}}
+	}
+
+	var buf bytes.Buffer
+	if err := encoder.Encode(makePod("ignored"), &buf); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+	if records := recorder.Records(); len(records) != 0 {
+		t.Fatalf("expected no records for unmatched object, got %d", len(records))
+	}
+
+	buf.Reset()
+	if err := encoder.Encode(makePod("traced"), &buf); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+	records := recorder.Records()
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	if records[0].Operation != "encode" || records[0].Kind != "Pod" || records[0].Name != "traced" || records[0].Namespace != "ns" {
+		t.Errorf("unexpected record: %#v", records[0])
+	}
+	if !bytes.Equal(records[0].Data, buf.Bytes()) {
+		t.Errorf("expected recorded bytes to equal encoder output")
+	}
+	if records[0].Identifier == "" {
+		t.Errorf("expected encode record to name the encoder")
+	}
+
+	if _, _, err := decoder.Decode(buf.Bytes(), nil, &examplev1.Pod{}); err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	records = recorder.Records()
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[1].Operation != "decode" || records[1].Kind != "Pod" || records[1].Name != "traced" {
+		t.Errorf("unexpected record: %#v", records[1])
+	}
+	if !bytes.Equal(records[1].Data, buf.Bytes()) {
+		t.Errorf("expected recorded bytes to equal decoder input")
+	}
+}
+
+func TestWrapSerializerDisabled(t *testing.T) {
+	scheme := runtime.NewScheme()
+	codecs := serializer.NewCodecFactory(scheme)
+
+	var r *Recorder
+	if _, ok := r.WrapSerializer(codecs).(*tracedSerializer); ok {
+		t.Error("expected a nil recorder to return the serializer unchanged")
+	}
+	r, err := NewRecorder(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := r.WrapSerializer(codecs).(*tracedSerializer); ok {
+		t.Error("expected a patternless recorder to return the serializer unchanged")
+	}
+}
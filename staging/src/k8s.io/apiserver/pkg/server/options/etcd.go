@@ -25,6 +25,7 @@ import (
 
 	"github.com/spf13/pflag"
 
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/registry/generic"
@@ -86,6 +87,10 @@ func (s *EtcdOptions) Validate() []error {
 		allErrors = append(allErrors, fmt.Errorf("--etcd-servers must be specified"))
 	}
 
+	if s.DefaultStorageMediaType == runtime.ContentTypeCBOR {
+		allErrors = append(allErrors, fmt.Errorf("--storage-media-type %s is not supported: CBOR may be enabled for serving, but stored objects must remain readable by servers and tooling without CBOR support; persist objects as application/json or application/vnd.kubernetes.protobuf", runtime.ContentTypeCBOR))
+	}
+
 	if s.StorageConfig.Type != storagebackend.StorageTypeUnset && !storageTypes.Has(s.StorageConfig.Type) {
 		allErrors = append(allErrors, fmt.Errorf("--storage-backend invalid, allowed values: %s. If not specified, it will default to 'etcd3'", strings.Join(storageTypes.List(), ", ")))
 	}
@@ -189,6 +194,7 @@ func (s *EtcdOptions) ApplyTo(c *server.Config) error {
 		return err
 	}
 	c.RESTOptionsGetter = &SimpleRestOptionsFactory{Options: *s}
+	c.StorageMediaType = s.DefaultStorageMediaType
 	return nil
 }
 
@@ -197,6 +203,7 @@ func (s *EtcdOptions) ApplyWithStorageFactoryTo(factory serverstorage.StorageFac
 		return err
 	}
 	c.RESTOptionsGetter = &StorageFactoryRestOptionsFactory{Options: *s, StorageFactory: factory}
+	c.StorageMediaType = s.DefaultStorageMediaType
 	return nil
 }
 
@@ -18,12 +18,16 @@ package options
 
 import (
 	"fmt"
+	"mime"
 	"net"
+	"strings"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apiserver/pkg/server"
+	"k8s.io/apiserver/pkg/server/objecttrace"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
 
 	"github.com/spf13/pflag"
@@ -52,6 +56,20 @@ type ServerRunOptions struct {
 	MaxRequestBodyBytes       int64
 	TargetRAMMB               int
 	EnablePriorityAndFairness bool
+	// MediaTypePreference lists media types in the order the server should prefer them
+	// when a client's Accept header does not express a preference (for example `*/*`).
+	MediaTypePreference []string
+	// CBORNegotiationAllowedGroups and CBORNegotiationAllowedUserAgentPrefixes, when
+	// either is non-empty, restrict which clients may negotiate application/cbor
+	// responses.
+	CBORNegotiationAllowedGroups            []string
+	CBORNegotiationAllowedUserAgentPrefixes []string
+	// LoopbackClientContentType, if non-empty, is the content type the server's own
+	// loopback clients use for requests to the server.
+	LoopbackClientContentType string
+	// SerializationTracePatterns lists kind/name patterns of objects whose encodes and
+	// decodes should be recorded for debugging.
+	SerializationTracePatterns []string
 }
 
 func NewServerRunOptions() *ServerRunOptions {
@@ -83,6 +101,18 @@ func (s *ServerRunOptions) ApplyTo(c *server.Config) error {
 	c.JSONPatchMaxCopyBytes = s.JSONPatchMaxCopyBytes
 	c.MaxRequestBodyBytes = s.MaxRequestBodyBytes
 	c.PublicAddress = s.AdvertiseAddress
+	c.MediaTypePreference = s.MediaTypePreference
+	c.CBORNegotiationAllowedGroups = s.CBORNegotiationAllowedGroups
+	c.CBORNegotiationAllowedUserAgentPrefixes = s.CBORNegotiationAllowedUserAgentPrefixes
+	c.LoopbackClientContentType = s.LoopbackClientContentType
+
+	if len(s.SerializationTracePatterns) > 0 {
+		recorder, err := objecttrace.NewRecorder(s.SerializationTracePatterns)
+		if err != nil {
+			return err
+		}
+		c.SerializationTrace = recorder
+	}
 
 	return nil
 }
@@ -147,6 +177,29 @@ func (s *ServerRunOptions) Validate() []error {
 		errors = append(errors, fmt.Errorf("--max-resource-write-bytes can not be negative value"))
 	}
 
+	seenMediaTypes := map[string]bool{}
+	for _, mediaType := range s.MediaTypePreference {
+		if parsed, _, err := mime.ParseMediaType(mediaType); err != nil || parsed != mediaType || !strings.Contains(parsed, "/") {
+			errors = append(errors, fmt.Errorf("--media-type-preference contains invalid media type %q", mediaType))
+			continue
+		}
+		if seenMediaTypes[mediaType] {
+			errors = append(errors, fmt.Errorf("--media-type-preference contains duplicate media type %q", mediaType))
+		}
+		seenMediaTypes[mediaType] = true
+	}
+
+	switch s.LoopbackClientContentType {
+	case "", runtime.ContentTypeJSON, runtime.ContentTypeProtobuf, runtime.ContentTypeCBOR:
+	default:
+		errors = append(errors, fmt.Errorf("--loopback-client-content-type %s is not supported; use %s, %s, or %s",
+			s.LoopbackClientContentType, runtime.ContentTypeJSON, runtime.ContentTypeProtobuf, runtime.ContentTypeCBOR))
+	}
+
+	if _, err := objecttrace.NewRecorder(s.SerializationTracePatterns); err != nil {
+		errors = append(errors, fmt.Errorf("--serialization-trace-patterns: %v", err))
+	}
+
 	return errors
 }
 
@@ -208,6 +261,34 @@ func (s *ServerRunOptions) AddUniversalFlags(fs *pflag.FlagSet) {
 	fs.BoolVar(&s.EnablePriorityAndFairness, "enable-priority-and-fairness", s.EnablePriorityAndFairness, ""+
 		"If true and the APIPriorityAndFairness feature gate is enabled, replace the max-in-flight handler with an enhanced one that queues and dispatches with priority and fairness")
 
+	fs.StringSliceVar(&s.MediaTypePreference, "media-type-preference", s.MediaTypePreference, ""+
+		"Comma-separated list of media types, in order of preference, that the server should "+
+		"use to respond to clients whose Accept header does not express a preference among the "+
+		"supported media types (for example '*/*'). Supported media types not in the list remain "+
+		"available, after those listed. If empty, the server's built-in order is used.")
+
+	fs.StringSliceVar(&s.CBORNegotiationAllowedGroups, "cbor-negotiation-allowed-groups", s.CBORNegotiationAllowedGroups, ""+
+		"Comma-separated list of groups whose members may negotiate application/cbor responses. "+
+		"If this and --cbor-negotiation-allowed-user-agent-prefixes are both empty, any client "+
+		"may negotiate application/cbor.")
+
+	fs.StringSliceVar(&s.CBORNegotiationAllowedUserAgentPrefixes, "cbor-negotiation-allowed-user-agent-prefixes", s.CBORNegotiationAllowedUserAgentPrefixes, ""+
+		"Comma-separated list of User-Agent prefixes identifying clients that may negotiate "+
+		"application/cbor responses. If this and --cbor-negotiation-allowed-groups are both "+
+		"empty, any client may negotiate application/cbor.")
+
+	fs.StringSliceVar(&s.SerializationTracePatterns, "serialization-trace-patterns", s.SerializationTracePatterns, ""+
+		"Comma-separated list of kind/name patterns, for example 'Pod/example' or 'Node/*', "+
+		"identifying objects whose encodes and decodes are recorded (raw bytes and timing) "+
+		"and exposed through the /debug/serialization/trace endpoint. For debugging only; "+
+		"every serialization is matched against the patterns.")
+
+	fs.StringVar(&s.LoopbackClientContentType, "loopback-client-content-type", s.LoopbackClientContentType, ""+
+		"Content type used by the server's own loopback clients for requests to the server, "+
+		"for example 'application/vnd.kubernetes.protobuf' or 'application/cbor'. Internal "+
+		"traffic from controllers that use the loopback connection is encoded in this type. "+
+		"If empty, loopback clients use application/json.")
+
 	fs.DurationVar(&s.ShutdownDelayDuration, "shutdown-delay-duration", s.ShutdownDelayDuration, ""+
 		"Time to delay the termination. During that time the server keeps serving requests normally and /healthz "+
 		"returns success, but /readyz immediately returns failure. Graceful termination starts after this delay "+
@@ -168,6 +168,22 @@ func TestServerRunOptionsValidate(t *testing.T) {
 			},
 			expectErr: "--shutdown-delay-duration can not be negative value",
 		},
+		{
+			name: "Test when LoopbackClientContentType is unsupported",
+			testOptions: &ServerRunOptions{
+				AdvertiseAddress:            net.ParseIP("192.168.10.10"),
+				CorsAllowedOriginList:       []string{"10.10.10.100", "10.10.10.200"},
+				MaxRequestsInFlight:         400,
+				MaxMutatingRequestsInFlight: 200,
+				RequestTimeout:              time.Duration(2) * time.Minute,
+				MinRequestTimeout:           1800,
+				JSONPatchMaxCopyBytes:       10 * 1024 * 1024,
+				MaxRequestBodyBytes:         10 * 1024 * 1024,
+				TargetRAMMB:                 65536,
+				LoopbackClientContentType:   "application/yaml",
+			},
+			expectErr: "--loopback-client-content-type application/yaml is not supported",
+		},
 		{
 			name: "Test when ServerRunOptions is valid",
 			testOptions: &ServerRunOptions{
@@ -180,6 +196,7 @@ func TestServerRunOptionsValidate(t *testing.T) {
 				JSONPatchMaxCopyBytes:       10 * 1024 * 1024,
 				MaxRequestBodyBytes:         10 * 1024 * 1024,
 				TargetRAMMB:                 65536,
+				LoopbackClientContentType:   "application/cbor",
 			},
 		},
 	}
@@ -0,0 +1,48 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"net/http"
+
+	"k8s.io/apiserver/pkg/endpoints/handlers/negotiation"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	"k8s.io/apiserver/pkg/server/mux"
+	utilflowcontrol "k8s.io/apiserver/pkg/util/flowcontrol"
+	"k8s.io/apiserver/pkg/util/flowcontrol/debug"
+)
+
+// DebugFlowControl adds a handler under /debug/api_priority_and_fairness dumping the
+// current state of the API priority and fairness filter. The response encoding is
+// negotiated from the Accept header, so tooling can request JSON or CBOR instead of
+// scraping text.
+type DebugFlowControl struct {
+	// FlowControl is the filter whose state is dumped.
+	FlowControl utilflowcontrol.Interface
+}
+
+// Install registers the flow control debug handler.
+func (d DebugFlowControl) Install(c *mux.PathRecorderMux) {
+	if d.FlowControl == nil {
+		return
+	}
+	c.UnlistedHandleFunc("/debug/api_priority_and_fairness", d.handle)
+}
+
+func (d DebugFlowControl) handle(w http.ResponseWriter, req *http.Request) {
+	responsewriters.WriteObjectNegotiated(debug.Codecs, negotiation.DefaultEndpointRestrictions, debug.SchemeGroupVersion, w, req, http.StatusOK, d.FlowControl.DumpState())
+}
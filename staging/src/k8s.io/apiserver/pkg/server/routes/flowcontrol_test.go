@@ -0,0 +1,96 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	fctypesv1a1 "k8s.io/api/flowcontrol/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+	"k8s.io/apiserver/pkg/server/mux"
+	utilflowcontrol "k8s.io/apiserver/pkg/util/flowcontrol"
+	"k8s.io/apiserver/pkg/util/flowcontrol/debug"
+)
+
+type fakeFlowControl struct {
+	state *debug.FlowControlState
+}
+
+func (f fakeFlowControl) Handle(ctx context.Context, requestDigest utilflowcontrol.RequestDigest, noteFn func(fs *fctypesv1a1.FlowSchema, pl *fctypesv1a1.PriorityLevelConfiguration), execFn func()) {
+}
+
+func (f fakeFlowControl) Run(stopCh <-chan struct{}) error {
+	return nil
+}
+
+func (f fakeFlowControl) DumpState() *debug.FlowControlState {
+	return f.state
+}
+
+func TestDebugFlowControl(t *testing.T) {
+	state := &debug.FlowControlState{
+		PriorityLevels: []debug.PriorityLevelState{{Name: "exempt"}},
+		FlowSchemas:    []debug.FlowSchemaState{{Name: "system-leader-election"}},
+	}
+
+	m := mux.NewPathRecorderMux("test")
+	DebugFlowControl{FlowControl: fakeFlowControl{state: state}}.Install(m)
+
+	for _, tc := range []struct {
+		accept    string
+		mediaType string
+		unmarshal func(data []byte, v interface{}) error
+	}{
+		{accept: "application/json", mediaType: "application/json", unmarshal: json.Unmarshal},
+		{accept: "application/cbor", mediaType: "application/cbor", unmarshal: func(data []byte, v interface{}) error {
+			// The serializer prepends the self-described CBOR tag.
+			return direct.Unmarshal(bytes.TrimPrefix(data, []byte{0xd9, 0xd9, 0xf7}), v)
+		}},
+	} {
+		t.Run(tc.accept, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/debug/api_priority_and_fairness", nil)
+			req.Header.Set("Accept", tc.accept)
+			w := httptest.NewRecorder()
+			m.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if contentType := w.Header().Get("Content-Type"); contentType != tc.mediaType {
+				t.Fatalf("expected content type %q, got %q", tc.mediaType, contentType)
+			}
+
+			got := &debug.FlowControlState{}
+			if err := tc.unmarshal(w.Body.Bytes(), got); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			got.TypeMeta = metav1.TypeMeta{}
+			if len(got.PriorityLevels) != 1 || got.PriorityLevels[0].Name != "exempt" {
+				t.Errorf("unexpected priority levels: %#v", got.PriorityLevels)
+			}
+			if len(got.FlowSchemas) != 1 || got.FlowSchemas[0].Name != "system-leader-election" {
+				t.Errorf("unexpected flow schemas: %#v", got.FlowSchemas)
+			}
+		})
+	}
+}
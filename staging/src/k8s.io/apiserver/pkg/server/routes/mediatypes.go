@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/server/dynamicmediatypes"
+	"k8s.io/apiserver/pkg/server/mux"
+)
+
+// DebugMediaTypes adds a handler under /debug/mediatypes/disabled through which serving
+// media types can be disabled and re-enabled at runtime, for emergency rollback of a
+// misbehaving encoding without a restart. Like the other debug handlers it is protected
+// by the server's authorizer; because a write takes an encoding out of service for every
+// client, the handler additionally requires the caller to be in the system:masters
+// group.
+type DebugMediaTypes struct {
+	Toggle *dynamicmediatypes.Toggle
+}
+
+// Install registers the media type toggle handler.
+func (d DebugMediaTypes) Install(c *mux.PathRecorderMux) {
+	if d.Toggle == nil {
+		return
+	}
+	c.UnlistedHandleFunc("/debug/mediatypes/disabled", d.handle)
+}
+
+func (d DebugMediaTypes) handle(w http.ResponseWriter, req *http.Request) {
+	requestor, exists := request.UserFrom(req.Context())
+	if !exists || !hasGroup(requestor, user.SystemPrivilegedGroup) {
+		writePlainText(http.StatusForbidden, "forbidden: media type toggles require membership in "+user.SystemPrivilegedGroup, w)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		writePlainText(http.StatusOK, strings.Join(d.Toggle.Disabled(), ","), w)
+	case http.MethodPost, http.MethodPut:
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			writePlainText(http.StatusBadRequest, "error reading request body: "+err.Error(), w)
+			return
+		}
+		defer req.Body.Close()
+		// The body replaces the disabled set wholesale: a comma-separated list of
+		// media types, or empty to re-enable everything.
+		var mediaTypes []string
+		for _, mediaType := range strings.Split(string(body), ",") {
+			if mediaType = strings.TrimSpace(mediaType); mediaType != "" {
+				mediaTypes = append(mediaTypes, mediaType)
+			}
+		}
+		if err := d.Toggle.SetDisabled(mediaTypes); err != nil {
+			writePlainText(http.StatusBadRequest, err.Error(), w)
+			return
+		}
+		writePlainText(http.StatusOK, strings.Join(d.Toggle.Disabled(), ","), w)
+	default:
+		writePlainText(http.StatusMethodNotAllowed, "unsupported http method", w)
+	}
+}
+
+func hasGroup(requestor user.Info, group string) bool {
+	for _, g := range requestor.GetGroups() {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
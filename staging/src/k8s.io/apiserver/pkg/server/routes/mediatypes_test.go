@@ -0,0 +1,102 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/server/dynamicmediatypes"
+)
+
+func mediaTypesRequest(t *testing.T, method, body string, requestor user.Info) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(method, "/debug/mediatypes/disabled", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requestor != nil {
+		req = req.WithContext(request.WithUser(req.Context(), requestor))
+	}
+	return req
+}
+
+func TestDebugMediaTypes(t *testing.T) {
+	toggle := dynamicmediatypes.NewToggle()
+	handler := DebugMediaTypes{Toggle: toggle}
+	master := &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}}
+
+	w := httptest.NewRecorder()
+	handler.handle(w, mediaTypesRequest(t, http.MethodPost, "application/cbor, application/yaml", master))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := toggle.Disabled(); len(got) != 2 || got[0] != "application/cbor" || got[1] != "application/yaml" {
+		t.Errorf("unexpected disabled set: %v", got)
+	}
+
+	w = httptest.NewRecorder()
+	handler.handle(w, mediaTypesRequest(t, http.MethodGet, "", master))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := strings.TrimSpace(w.Body.String()); got != "application/cbor,application/yaml" {
+		t.Errorf("unexpected response body: %q", got)
+	}
+
+	// An empty body re-enables everything.
+	w = httptest.NewRecorder()
+	handler.handle(w, mediaTypesRequest(t, http.MethodPut, "", master))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := toggle.Disabled(); len(got) != 0 {
+		t.Errorf("expected empty disabled set, got %v", got)
+	}
+
+	w = httptest.NewRecorder()
+	handler.handle(w, mediaTypesRequest(t, http.MethodPost, "application/json", master))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 disabling application/json, got %d", w.Code)
+	}
+}
+
+func TestDebugMediaTypesRequiresPrivilegedGroup(t *testing.T) {
+	toggle := dynamicmediatypes.NewToggle()
+	handler := DebugMediaTypes{Toggle: toggle}
+
+	for name, requestor := range map[string]user.Info{
+		"no user":            nil,
+		"unprivileged group": &user.DefaultInfo{Name: "bob", Groups: []string{"system:authenticated"}},
+		"no groups":          &user.DefaultInfo{Name: "bob"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.handle(w, mediaTypesRequest(t, http.MethodPost, "application/cbor", requestor))
+			if w.Code != http.StatusForbidden {
+				t.Errorf("expected 403, got %d", w.Code)
+			}
+			if got := toggle.Disabled(); len(got) != 0 {
+				t.Errorf("forbidden request must not change the disabled set, got %v", got)
+			}
+		})
+	}
+}
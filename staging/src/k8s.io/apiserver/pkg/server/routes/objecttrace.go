@@ -0,0 +1,65 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/server/mux"
+	"k8s.io/apiserver/pkg/server/objecttrace"
+)
+
+// DebugObjectTrace adds a handler under /debug/serialization/trace that reports the raw
+// bytes and timing recorded for objects matching the configured trace patterns. The
+// records include complete object serializations, so like the media type toggle the
+// handler requires the caller to be in the system:masters group on top of the server's
+// authorizer.
+type DebugObjectTrace struct {
+	Recorder *objecttrace.Recorder
+}
+
+// Install registers the object trace handler if tracing is configured.
+func (d DebugObjectTrace) Install(c *mux.PathRecorderMux) {
+	if !d.Recorder.Enabled() {
+		return
+	}
+	c.UnlistedHandleFunc("/debug/serialization/trace", d.handle)
+}
+
+func (d DebugObjectTrace) handle(w http.ResponseWriter, req *http.Request) {
+	requestor, exists := request.UserFrom(req.Context())
+	if !exists || !hasGroup(requestor, user.SystemPrivilegedGroup) {
+		writePlainText(http.StatusForbidden, "forbidden: object traces require membership in "+user.SystemPrivilegedGroup, w)
+		return
+	}
+	if req.Method != http.MethodGet {
+		writePlainText(http.StatusMethodNotAllowed, "unsupported http method", w)
+		return
+	}
+
+	body, err := json.Marshal(d.Recorder.Records())
+	if err != nil {
+		writePlainText(http.StatusInternalServerError, "error encoding records: "+err.Error(), w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
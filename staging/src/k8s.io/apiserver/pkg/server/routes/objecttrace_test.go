@@ -0,0 +1,74 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/server/objecttrace"
+)
+
+func objectTraceRequest(t *testing.T, requestor user.Info) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "/debug/serialization/trace", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requestor != nil {
+		req = req.WithContext(request.WithUser(req.Context(), requestor))
+	}
+	return req
+}
+
+func TestDebugObjectTrace(t *testing.T) {
+	recorder, err := objecttrace.NewRecorder([]string{"Pod/example"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	handler := DebugObjectTrace{Recorder: recorder}
+	master := &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}}
+
+	w := httptest.NewRecorder()
+	handler.handle(w, objectTraceRequest(t, master))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var records []objecttrace.Record
+	if err := json.Unmarshal(w.Body.Bytes(), &records); err != nil {
+		t.Fatalf("expected a json list of records, got %q: %v", w.Body.String(), err)
+	}
+	if len(records) != 0 {
+		t.Errorf("expected no records, got %d", len(records))
+	}
+
+	w = httptest.NewRecorder()
+	handler.handle(w, objectTraceRequest(t, &user.DefaultInfo{Name: "other"}))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for non-privileged requestor, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.handle(w, objectTraceRequest(t, nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for anonymous request, got %d", w.Code)
+	}
+}
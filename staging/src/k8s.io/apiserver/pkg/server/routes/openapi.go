@@ -44,7 +44,7 @@ func (oa OpenAPI) Install(c *restful.Container, mux *mux.PathRecorderMux) (*hand
 		klog.Fatalf("Failed to create OpenAPIService: %v", err)
 	}
 
-	err = openAPIVersionedService.RegisterOpenAPIVersionedService("/openapi/v2", mux)
+	err = openAPIVersionedService.RegisterOpenAPIVersionedService("/openapi/v2", cborPathHandler{delegate: mux})
 	if err != nil {
 		klog.Fatalf("Failed to register versioned open api spec for root: %v", err)
 	}
@@ -0,0 +1,117 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+	"k8s.io/apimachinery/pkg/util/json"
+)
+
+// cborPathHandler wraps the handlers registered by the OpenAPI service so that clients
+// may negotiate application/cbor in addition to the JSON and protobuf representations
+// that the service produces itself. CBOR responses are transcoded from the JSON
+// representation on the fly, so CBOR-first clients do not need a JSON parser just for
+// schema discovery.
+type cborPathHandler struct {
+	delegate interface {
+		Handle(path string, handler http.Handler)
+	}
+}
+
+func (h cborPathHandler) Handle(path string, handler http.Handler) {
+	h.delegate.Handle(path, negotiateOpenAPICBOR(handler))
+}
+
+// negotiateOpenAPICBOR serves requests that accept application/cbor by obtaining the
+// JSON representation from the delegate handler and transcoding it. All other requests
+// are passed through. Entity tags are propagated unmodified, so conditional requests
+// revalidate the same way in every representation.
+func negotiateOpenAPICBOR(delegate http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !acceptsCBOR(req.Header.Get("Accept")) {
+			delegate.ServeHTTP(w, req)
+			return
+		}
+
+		jsonReq := req.Clone(req.Context())
+		jsonReq.Header.Set("Accept", runtime.ContentTypeJSON)
+		// The body is transcoded before being written, so it must not be compressed.
+		jsonReq.Header.Del("Accept-Encoding")
+
+		recorder := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+		delegate.ServeHTTP(recorder, jsonReq)
+		if recorder.status != http.StatusOK {
+			// Pass non-OK responses (including 304 Not Modified) through untouched.
+			copyHeader(w.Header(), recorder.header)
+			w.WriteHeader(recorder.status)
+			w.Write(recorder.body.Bytes())
+			return
+		}
+
+		var u interface{}
+		if err := json.Unmarshal(recorder.body.Bytes(), &u); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		out, err := direct.Marshal(u)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		copyHeader(w.Header(), recorder.header)
+		w.Header().Set("Content-Type", runtime.ContentTypeCBOR)
+		w.Header().Del("Content-Length")
+		w.Write(out)
+	})
+}
+
+// acceptsCBOR reports whether any member of an Accept header field value names the CBOR
+// media type.
+func acceptsCBOR(accept string) bool {
+	for _, clause := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(clause, ";", 2)[0])
+		if mediaType == runtime.ContentTypeCBOR {
+			return true
+		}
+	}
+	return false
+}
+
+func copyHeader(dst, src http.Header) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
+
+// bufferedResponse is an http.ResponseWriter that records the response for transcoding.
+type bufferedResponse struct {
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+func (r *bufferedResponse) Header() http.Header { return r.header }
+
+func (r *bufferedResponse) Write(p []byte) (int, error) { return r.body.Write(p) }
+
+func (r *bufferedResponse) WriteHeader(status int) { r.status = status }
@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+)
+
+func openAPITestDelegate(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if accept := req.Header.Get("Accept"); accept != "" && accept != runtime.ContentTypeJSON {
+			t.Errorf("delegate received unexpected accept header %q", accept)
+		}
+		if req.Header.Get("If-None-Match") == `"match"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", runtime.ContentTypeJSON)
+		w.Header().Set("ETag", `"match"`)
+		w.Write([]byte(`{"swagger":"2.0","paths":{}}`))
+	})
+}
+
+func TestOpenAPICBORNegotiation(t *testing.T) {
+	handler := negotiateOpenAPICBOR(openAPITestDelegate(t))
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi/v2", nil)
+	req.Header.Set("Accept", runtime.ContentTypeCBOR)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", resp.Code)
+	}
+	if got := resp.Header().Get("Content-Type"); got != runtime.ContentTypeCBOR {
+		t.Errorf("unexpected content type: %q", got)
+	}
+	if got := resp.Header().Get("ETag"); got != `"match"` {
+		t.Errorf("expected the delegate's entity tag to be preserved, got %q", got)
+	}
+	var decoded map[string]interface{}
+	if err := direct.Unmarshal(resp.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	expected := map[string]interface{}{"swagger": "2.0", "paths": map[string]interface{}{}}
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("unexpected decoded spec: got %#v, want %#v", decoded, expected)
+	}
+}
+
+func TestOpenAPICBORNotModified(t *testing.T) {
+	handler := negotiateOpenAPICBOR(openAPITestDelegate(t))
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi/v2", nil)
+	req.Header.Set("Accept", runtime.ContentTypeCBOR)
+	req.Header.Set("If-None-Match", `"match"`)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 to pass through, got %d", resp.Code)
+	}
+}
+
+func TestOpenAPICBORPassthrough(t *testing.T) {
+	handler := negotiateOpenAPICBOR(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Accept") != "application/json" {
+			t.Errorf("expected original accept header to reach the delegate, got %q", req.Header.Get("Accept"))
+		}
+		w.Write([]byte(`{}`))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi/v2", nil)
+	req.Header.Set("Accept", "application/json")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", resp.Code)
+	}
+	if got := resp.Body.String(); got != `{}` {
+		t.Errorf("expected the delegate response to pass through, got %q", got)
+	}
+}
+
+func TestAcceptsCBOR(t *testing.T) {
+	for accept, want := range map[string]bool{
+		"":                                   false,
+		"application/json":                   false,
+		"application/cbor":                   true,
+		"application/cbor;q=.5":              true,
+		"application/json, application/cbor": true,
+	} {
+		if got := acceptsCBOR(accept); got != want {
+			t.Errorf("acceptsCBOR(%q) = %v, want %v", accept, got, want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	"k8s.io/apiserver/pkg/server/mux"
+)
+
+// DebugSerializers adds a handler under /debug/serializers reporting the media types the
+// server is configured to serve and their capabilities. Which serializers an apiserver
+// actually has, after feature gates and options are applied, can otherwise only be
+// determined by issuing requests against it.
+type DebugSerializers struct {
+	// Serializer is the negotiated serializer the server serves requests with.
+	Serializer runtime.NegotiatedSerializer
+	// StorageMediaType, if set, is the default media type used to persist objects.
+	StorageMediaType string
+}
+
+// debugSerializersPayload is the response body of the /debug/serializers handler.
+type debugSerializersPayload struct {
+	StorageMediaType string                             `json:"storageMediaType,omitempty"`
+	Serializers      []serializer.SerializerDescription `json:"serializers"`
+}
+
+// Install registers the serializers debug handler.
+func (d DebugSerializers) Install(c *mux.PathRecorderMux) {
+	if d.Serializer == nil {
+		return
+	}
+	c.UnlistedHandleFunc("/debug/serializers", d.handle)
+}
+
+func (d DebugSerializers) handle(w http.ResponseWriter, req *http.Request) {
+	payload := debugSerializersPayload{
+		StorageMediaType: d.StorageMediaType,
+		Serializers:      serializer.DescribeMediaTypes(d.Serializer),
+	}
+	responsewriters.WriteRawJSON(http.StatusOK, payload, w)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+	"k8s.io/apiserver/pkg/server/mux"
+)
+
+func TestDebugSerializers(t *testing.T) {
+	codecs := serializer.NewCodecFactory(runtime.NewScheme(), serializer.WithSerializer(cbor.NewSerializerInfo))
+
+	m := mux.NewPathRecorderMux("test")
+	DebugSerializers{Serializer: codecs, StorageMediaType: "application/vnd.kubernetes.protobuf"}.Install(m)
+
+	req, err := http.NewRequest("GET", "/debug/serializers", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var payload debugSerializersPayload
+	if err := json.Unmarshal(w.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("response did not decode as JSON: %v", err)
+	}
+	if payload.StorageMediaType != "application/vnd.kubernetes.protobuf" {
+		t.Errorf("unexpected storage media type %q", payload.StorageMediaType)
+	}
+
+	described := map[string]serializer.SerializerDescription{}
+	for _, description := range payload.Serializers {
+		described[description.MediaType] = description
+	}
+	for _, mediaType := range []string{"application/json", "application/yaml", "application/vnd.kubernetes.protobuf", "application/cbor"} {
+		if _, ok := described[mediaType]; !ok {
+			t.Errorf("expected %s to be described, got %v", mediaType, payload.Serializers)
+		}
+	}
+	if description := described["application/cbor"]; !description.Strict || !description.Stream {
+		t.Errorf("unexpected description for application/cbor: %+v", description)
+	}
+}
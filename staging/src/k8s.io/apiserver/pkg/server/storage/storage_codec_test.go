@@ -0,0 +1,159 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bytes"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	cborserializer "k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+	"k8s.io/apimachinery/pkg/runtime/serializer/recognizer"
+	"k8s.io/apiserver/pkg/apis/example"
+	exampleinstall "k8s.io/apiserver/pkg/apis/example/install"
+	examplev1 "k8s.io/apiserver/pkg/apis/example/v1"
+)
+
+func newCBORCodecFactory() serializer.CodecFactory {
+	s := runtime.NewScheme()
+	exampleinstall.Install(s)
+	return serializer.NewCodecFactory(s, serializer.WithSerializer(cborserializer.NewSerializerInfo))
+}
+
+func TestNewStorageCodecCBORRoundTrip(t *testing.T) {
+	codec, _, err := NewStorageCodec(StorageCodecConfig{
+		StorageMediaType:  runtime.ContentTypeCBOR,
+		StorageSerializer: newCBORCodecFactory(),
+		StorageVersion:    examplev1.SchemeGroupVersion,
+		MemoryVersion:     example.SchemeGroupVersion,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage codec: %v", err)
+	}
+
+	pod := &example.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"}}
+	encoded, err := runtime.Encode(codec, pod)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	if !bytes.HasPrefix(encoded, []byte{0xd9, 0xd9, 0xf7}) {
+		t.Errorf("expected stored bytes to begin with the CBOR self-describe tag, got %x", encoded[:3])
+	}
+
+	decoded, err := runtime.Decode(codec, encoded)
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	decodedPod, ok := decoded.(*example.Pod)
+	if !ok {
+		t.Fatalf("expected to decode into the memory version, got %T", decoded)
+	}
+	if decodedPod.Name != pod.Name || decodedPod.Namespace != pod.Namespace {
+		t.Errorf("expected %s/%s after round trip, got %s/%s", pod.Namespace, pod.Name, decodedPod.Namespace, decodedPod.Name)
+	}
+}
+
+func TestNewStorageCodecCBORDecodesStoredJSON(t *testing.T) {
+	factory := newCBORCodecFactory()
+	jsonCodec, _, err := NewStorageCodec(StorageCodecConfig{
+		StorageMediaType:  runtime.ContentTypeJSON,
+		StorageSerializer: factory,
+		StorageVersion:    examplev1.SchemeGroupVersion,
+		MemoryVersion:     example.SchemeGroupVersion,
+	})
+	if err != nil {
+		t.Fatalf("failed to create json storage codec: %v", err)
+	}
+	cborCodec, _, err := NewStorageCodec(StorageCodecConfig{
+		StorageMediaType:  runtime.ContentTypeCBOR,
+		StorageSerializer: factory,
+		StorageVersion:    examplev1.SchemeGroupVersion,
+		MemoryVersion:     example.SchemeGroupVersion,
+	})
+	if err != nil {
+		t.Fatalf("failed to create cbor storage codec: %v", err)
+	}
+
+	// Data written before a storage media type migration must remain readable. The
+	// recognizer falls back to the universal deserializer for documents that do not
+	// carry the self-describe tag.
+	encoded, err := runtime.Encode(jsonCodec, &example.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo"}})
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	decoded, err := runtime.Decode(cborCodec, encoded)
+	if err != nil {
+		t.Fatalf("failed to decode stored json with the cbor storage codec: %v", err)
+	}
+	decodedPod, ok := decoded.(*example.Pod)
+	if !ok {
+		t.Fatalf("expected to decode into the memory version, got %T", decoded)
+	}
+	if decodedPod.Name != "foo" {
+		t.Errorf("expected pod name foo, got %q", decodedPod.Name)
+	}
+}
+
+func TestNewStorageCodecCBORSerializerCapabilities(t *testing.T) {
+	var encoder runtime.Encoder
+	var primaryDecoder runtime.Decoder
+	_, _, err := NewStorageCodec(StorageCodecConfig{
+		StorageMediaType:  runtime.ContentTypeCBOR,
+		StorageSerializer: newCBORCodecFactory(),
+		StorageVersion:    examplev1.SchemeGroupVersion,
+		MemoryVersion:     example.SchemeGroupVersion,
+		EncoderDecoratorFn: func(e runtime.Encoder) runtime.Encoder {
+			encoder = e
+			return e
+		},
+		DecoderDecoratorFn: func(decoders []runtime.Decoder) []runtime.Decoder {
+			primaryDecoder = decoders[0]
+			return decoders
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage codec: %v", err)
+	}
+
+	if encoder.Identifier() == "" {
+		t.Error("expected the selected serializer to have a non-empty identifier")
+	}
+	allocatingEncoder, ok := encoder.(runtime.EncoderWithAllocator)
+	if !ok {
+		t.Fatalf("expected the selected serializer to implement runtime.EncoderWithAllocator, got %T", encoder)
+	}
+	if _, ok := primaryDecoder.(recognizer.RecognizingDecoder); !ok {
+		t.Errorf("expected the primary decoder to implement recognizer.RecognizingDecoder, got %T", primaryDecoder)
+	}
+
+	pod := &examplev1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: examplev1.SchemeGroupVersion.String(), Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+	}
+	var plain, allocated bytes.Buffer
+	if err := encoder.Encode(pod, &plain); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	if err := allocatingEncoder.EncodeWithAllocator(pod, &allocated, &runtime.Allocator{}); err != nil {
+		t.Fatalf("failed to encode with allocator: %v", err)
+	}
+	if !bytes.Equal(plain.Bytes(), allocated.Bytes()) {
+		t.Errorf("expected identical output from Encode and EncodeWithAllocator, got %x and %x", plain.Bytes(), allocated.Bytes())
+	}
+}
@@ -95,6 +95,14 @@ type Config struct {
 	NewListFunc func() runtime.Object
 
 	Codec runtime.Codec
+
+	// DispatchTimeoutBudget caps the total time that dispatching a single watch
+	// event may spend blocked on watchers that cannot accept it immediately,
+	// after which the unresponsive watchers are terminated. While an event is
+	// being dispatched, any cached serializations of its object are pinned in
+	// memory, so one slow consumer (e.g. a CBOR client on a congested link) could
+	// otherwise hold them indefinitely. Zero selects the default budget.
+	DispatchTimeoutBudget time.Duration
 }
 
 type watchersMap map[int]*cacheWatcher
@@ -337,7 +345,7 @@ func NewCacherFromConfig(config Config) (*Cacher, error) {
 		},
 		// TODO: Figure out the correct value for the buffer size.
 		incoming:              make(chan watchCacheEvent, 100),
-		dispatchTimeoutBudget: newTimeBudget(stopCh),
+		dispatchTimeoutBudget: newTimeBudget(stopCh, config.DispatchTimeoutBudget),
 		// We need to (potentially) stop both:
 		// - wait.Until go-routine
 		// - reflector.ListAndWatch
@@ -814,8 +822,16 @@ func (c *Cacher) dispatchEvents() {
 }
 
 func setCachingObjects(event *watchCacheEvent, versioner storage.Versioner) {
+	passthrough := utilfeature.DefaultFeatureGate.Enabled(features.WatchCacheEncodingPassthrough)
 	switch event.Type {
 	case watch.Added, watch.Modified:
+		if _, ok := event.Object.(runtime.CacheableObject); ok && passthrough {
+			// The object was decoded by a codec that retained the stored
+			// document (e.g. the CBOR passthrough codec) and can already
+			// serve per-encoder serializations, so wrapping it in a
+			// cachingObject would only add a deep copy.
+			return
+		}
 		if object, err := newCachingObject(event.Object); err == nil {
 			event.Object = object
 		} else {
@@ -829,6 +845,15 @@ func setCachingObjects(event *watchCacheEvent, versioner storage.Versioner) {
 		// This is rare enough that it doesn't justify making deep-copy of the
 		// object (done by newCachingObject) every time.
 	case watch.Deleted:
+		if _, ok := event.PrevObject.(runtime.CacheableObject); ok && passthrough {
+			// Copy before the resourceVersion fix-up below so that the
+			// object held by the watch cache is not mutated. The copy
+			// retains the stored document.
+			object := event.PrevObject.DeepCopyObject()
+			updateResourceVersionIfNeeded(object, versioner, event.ResourceVersion)
+			event.PrevObject = object
+			return
+		}
 		// Don't wrap Object for delete events - these are not to deliver any
 		// events. Only wrap PrevObject.
 		if object, err := newCachingObject(event.PrevObject); err == nil {
@@ -905,7 +930,9 @@ func (c *Cacher) dispatchEvent(event *watchCacheEvent) {
 				<-timer.C
 			}
 
-			c.dispatchTimeoutBudget.returnUnused(timeout - time.Since(startTime))
+			blocked := time.Since(startTime)
+			c.dispatchTimeoutBudget.returnUnused(timeout - blocked)
+			dispatchBlockedSeconds.WithLabelValues(c.objectType.String()).Observe(blocked.Seconds())
 		}
 	}
 }
@@ -1207,6 +1234,7 @@ func (c *cacheWatcher) add(event *watchCacheEvent, timer *time.Timer) bool {
 		// Since we don't want to block on it infinitely,
 		// we simply terminate it.
 		klog.V(1).Infof("Forcing watcher close due to unresponsiveness: %v", c.objectType.String())
+		terminatedWatchersCounter.WithLabelValues(c.objectType.String()).Inc()
 		c.forget()
 	}
 
@@ -17,8 +17,10 @@ limitations under the License.
 package cacher
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"reflect"
 	goruntime "runtime"
 	"strconv"
@@ -34,6 +36,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	cborserializer "k8s.io/apimachinery/pkg/runtime/serializer/cbor"
 	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/diff"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -469,6 +472,133 @@ func TestWatcherNotGoingBackInTime(t *testing.T) {
 	}
 }
 
+// TestDispatchTimeoutBudgetTerminatesSlowWatchers stresses event dispatch with a
+// heterogeneous population of watchers: consumers that stop reading entirely (e.g.
+// clients that negotiated an expensive encoding like CBOR over a congested
+// connection) mixed with consumers that drain promptly. The unresponsive watchers
+// must be terminated once the configured dispatch timeout budget is exhausted,
+// without disturbing delivery to the responsive ones.
+func TestDispatchTimeoutBudgetTerminatesSlowWatchers(t *testing.T) {
+	backingStorage := &dummyStorage{}
+	prefix := "pods"
+	config := Config{
+		CacheCapacity:  1000,
+		Storage:        backingStorage,
+		Versioner:      testVersioner{},
+		ResourcePrefix: prefix,
+		KeyFunc:        func(obj runtime.Object) (string, error) { return storage.NamespaceKeyFunc(prefix, obj) },
+		GetAttrsFunc:   storage.DefaultNamespaceScopedAttr,
+		NewFunc:        func() runtime.Object { return &example.Pod{} },
+		NewListFunc:    func() runtime.Object { return &example.PodList{} },
+		Codec:          codecs.LegacyCodec(examplev1.SchemeGroupVersion),
+		// Keep the budget small so unresponsive watchers are terminated quickly
+		// instead of pinning dispatched events for the default cap.
+		DispatchTimeoutBudget: 20 * time.Millisecond,
+	}
+	cacher, err := NewCacherFromConfig(config)
+	if err != nil {
+		t.Fatalf("Couldn't create cacher: %v", err)
+	}
+	defer cacher.Stop()
+
+	// Wait until cacher is initialized.
+	cacher.ready.wait()
+
+	// Start with a full budget so responsive watchers can't be terminated by a
+	// transient burst before the first refresh.
+	cacher.dispatchTimeoutBudget.returnUnused(20 * time.Millisecond)
+
+	makePod := func(i int) *examplev1.Pod {
+		return &examplev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            fmt.Sprintf("pod-%d", 1000+i),
+				Namespace:       "ns",
+				ResourceVersion: fmt.Sprintf("%d", 1000+i),
+			},
+		}
+	}
+	if err := cacher.watchCache.Add(makePod(0)); err != nil {
+		t.Errorf("error: %v", err)
+	}
+
+	totalPods := 100
+
+	// Watchers whose consumers never read.
+	stuck := make([]watch.Interface, 3)
+	for i := range stuck {
+		w, err := cacher.Watch(context.TODO(), "pods/ns", "999", storage.Everything)
+		if err != nil {
+			t.Fatalf("Failed to create watch: %v", err)
+		}
+		defer w.Stop()
+		stuck[i] = w
+	}
+
+	// Watchers whose consumers drain every event as it arrives.
+	fastResults := make(chan int, 3)
+	for i := 0; i < cap(fastResults); i++ {
+		w, err := cacher.Watch(context.TODO(), "pods/ns", "999", storage.Everything)
+		if err != nil {
+			t.Fatalf("Failed to create watch: %v", err)
+		}
+		defer w.Stop()
+		go func() {
+			received := 0
+			timeout := time.After(10 * time.Second)
+			for received < totalPods {
+				select {
+				case _, ok := <-w.ResultChan():
+					if !ok {
+						fastResults <- received
+						return
+					}
+					received++
+				case <-timeout:
+					fastResults <- received
+					return
+				}
+			}
+			fastResults <- received
+		}()
+	}
+
+	// Now push a ton of object to cache. Pace the producer so that the backlog
+	// accumulated while one dispatch blocks for the full budget still fits in a
+	// responsive watcher's buffer; otherwise the post-block burst can find the
+	// responsive watchers momentarily full with the budget already spent.
+	for i := 1; i < totalPods; i++ {
+		if err := cacher.watchCache.Add(makePod(i)); err != nil {
+			t.Errorf("error: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Every stuck watcher should have been terminated once its buffers filled
+	// and the budget ran out, observable as a closed result channel.
+	for i, w := range stuck {
+		timeout := time.After(10 * time.Second)
+		for terminated := false; !terminated; {
+			select {
+			case _, ok := <-w.ResultChan():
+				// Drain anything buffered before termination.
+				if !ok {
+					terminated = true
+				}
+			case <-timeout:
+				t.Fatalf("stuck watcher %d was not terminated", i)
+			}
+		}
+	}
+
+	// The responsive watchers should have received every event despite sharing
+	// dispatch with the stuck ones.
+	for i := 0; i < cap(fastResults); i++ {
+		if received := <-fastResults; received != totalPods {
+			t.Errorf("fast watcher received %d events, want %d", received, totalPods)
+		}
+	}
+}
+
 func TestCacheWatcherStoppedInAnotherGoroutine(t *testing.T) {
 	var w *cacheWatcher
 	done := make(chan struct{})
@@ -1170,3 +1300,113 @@ func TestCachingObjects(t *testing.T) {
 	t.Run("single watcher", func(t *testing.T) { testCachingObjects(t, 1) })
 	t.Run("many watcher", func(t *testing.T) { testCachingObjects(t, 3) })
 }
+
+func TestSetCachingObjectsEncodingPassthrough(t *testing.T) {
+	defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.WatchCacheEncodingPassthrough, true)()
+
+	cborSerializer := cborserializer.NewSerializer(cborserializer.DefaultMetaFactory, scheme, scheme)
+	storageCodec := codecs.CodecForVersions(cborSerializer, cborSerializer, examplev1.SchemeGroupVersion, examplev1.SchemeGroupVersion)
+	passthroughCodec := cborserializer.NewPassthroughCodec(storageCodec)
+
+	pod := &examplev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns", ResourceVersion: "1001"}}
+	stored, err := runtime.Encode(storageCodec, pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decode := func() runtime.Object {
+		decoded, _, err := passthroughCodec.Decode(stored, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := decoded.(runtime.CacheableObject); !ok {
+			t.Fatalf("expected decoded object to support caching: %#v", decoded)
+		}
+		return decoded
+	}
+
+	// fullEncode is what serving the object without any passthrough would produce.
+	fullEncode := func(resourceVersion string) []byte {
+		expected := pod.DeepCopy()
+		expected.ResourceVersion = resourceVersion
+		data, err := runtime.Encode(storageCodec, expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	t.Run("add and update events dispatch the decoded object directly", func(t *testing.T) {
+		decoded := decode()
+		event := &watchCacheEvent{Type: watch.Modified, Object: decoded, ResourceVersion: 1001}
+		setCachingObjects(event, testVersioner{})
+		if event.Object != decoded {
+			t.Fatalf("expected event object to be dispatched as-is, got: %#v", event.Object)
+		}
+
+		buffer := &bytes.Buffer{}
+		err := event.Object.(runtime.CacheableObject).CacheEncode(storageCodec.Identifier(), func(obj runtime.Object, w io.Writer) error {
+			t.Error("expected the stored document to be served without re-encoding")
+			return storageCodec.Encode(obj, w)
+		}, buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e, a := fullEncode("1001"), buffer.Bytes(); !bytes.Equal(e, a) {
+			t.Errorf("served document does not match full encode:\nexpected: %x\ngot:      %x", e, a)
+		}
+	})
+
+	t.Run("delete events rewrite resourceVersion without mutating the cached object", func(t *testing.T) {
+		decoded := decode()
+		event := &watchCacheEvent{Type: watch.Deleted, PrevObject: decoded, ResourceVersion: 1002}
+		setCachingObjects(event, testVersioner{})
+		if event.PrevObject == decoded {
+			t.Fatal("expected event prev object to be copied before the resourceVersion fix-up")
+		}
+		if rv := decoded.(metav1.Object).GetResourceVersion(); rv != "1001" {
+			t.Fatalf("cached object mutated, resourceVersion: %s", rv)
+		}
+
+		buffer := &bytes.Buffer{}
+		err := event.PrevObject.(runtime.CacheableObject).CacheEncode(storageCodec.Identifier(), func(obj runtime.Object, w io.Writer) error {
+			t.Error("expected the stored document to be served without re-encoding")
+			return storageCodec.Encode(obj, w)
+		}, buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e, a := fullEncode("1002"), buffer.Bytes(); !bytes.Equal(e, a) {
+			t.Errorf("served document does not match full encode:\nexpected: %x\ngot:      %x", e, a)
+		}
+	})
+
+	t.Run("other encodings fall back to a full encode", func(t *testing.T) {
+		decoded := decode()
+		event := &watchCacheEvent{Type: watch.Modified, Object: decoded, ResourceVersion: 1001}
+		setCachingObjects(event, testVersioner{})
+
+		jsonCodec := codecs.LegacyCodec(examplev1.SchemeGroupVersion)
+		encoded := false
+		buffer := &bytes.Buffer{}
+		err := event.Object.(runtime.CacheableObject).CacheEncode(jsonCodec.Identifier(), func(obj runtime.Object, w io.Writer) error {
+			encoded = true
+			return jsonCodec.Encode(obj, w)
+		}, buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !encoded {
+			t.Error("expected a full encode for an encoding other than the stored one")
+		}
+	})
+
+	t.Run("feature disabled wraps in cachingObject", func(t *testing.T) {
+		defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.WatchCacheEncodingPassthrough, false)()
+		decoded := decode()
+		event := &watchCacheEvent{Type: watch.Modified, Object: decoded, ResourceVersion: 1001}
+		setCachingObjects(event, testVersioner{})
+		if _, ok := event.Object.(*cachingObject); !ok {
+			t.Fatalf("expected event object to be wrapped in a cachingObject, got: %#v", event.Object)
+		}
+	})
+}
@@ -56,12 +56,33 @@ var (
 		},
 		[]string{"resource"},
 	)
+
+	terminatedWatchersCounter = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Name:           "apiserver_terminated_watchers_total",
+			Help:           "Counter of watchers closed due to unresponsiveness broken by resource type.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource"},
+	)
+
+	dispatchBlockedSeconds = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Name:           "watch_cache_dispatch_blocked_seconds",
+			Help:           "Histogram of time spent blocked on unresponsive watchers while dispatching a single watch event, broken by resource type.",
+			Buckets:        []float64{0.001, 0.005, 0.025, 0.1, 0.5, 1.0},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource"},
+	)
 )
 
 func init() {
 	legacyregistry.MustRegister(initCounter)
 	legacyregistry.MustRegister(watchCacheCapacityIncreaseTotal)
 	legacyregistry.MustRegister(watchCacheCapacityDecreaseTotal)
+	legacyregistry.MustRegister(terminatedWatchersCounter)
+	legacyregistry.MustRegister(dispatchBlockedSeconds)
 }
 
 // recordsWatchCacheCapacityChange record watchCache capacity resize(increase or decrease) operations.
@@ -23,7 +23,7 @@ import (
 
 const (
 	refreshPerSecond = 50 * time.Millisecond
-	maxBudget        = 100 * time.Millisecond
+	defaultMaxBudget = 100 * time.Millisecond
 )
 
 // timeBudget implements a budget of time that you can use and is
@@ -47,7 +47,12 @@ type timeBudget struct {
 	maxBudget time.Duration
 }
 
-func newTimeBudget(stopCh <-chan struct{}) *timeBudget {
+// newTimeBudget returns a timeBudget that accumulates up to maxBudget. A zero or
+// negative maxBudget selects the default cap.
+func newTimeBudget(stopCh <-chan struct{}, maxBudget time.Duration) *timeBudget {
+	if maxBudget <= 0 {
+		maxBudget = defaultMaxBudget
+	}
 	result := &timeBudget{
 		budget:    time.Duration(0),
 		refresh:   refreshPerSecond,
@@ -0,0 +1,133 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd3
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/gogo/protobuf/proto"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+)
+
+// partialMetadata holds the object metadata fields that can be extracted from a stored
+// value without decoding the rest of the object.
+type partialMetadata struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels"`
+}
+
+var (
+	// protoEncodingPrefix is the magic number prefixing a runtime.Unknown envelope
+	// written by the protobuf serializer.
+	protoEncodingPrefix = []byte{0x6b, 0x38, 0x73, 0x00}
+	// cborSelfDescribed is the encoded form of the self-described CBOR tag that
+	// prefixes every document written by the CBOR serializer.
+	cborSelfDescribed = []byte{0xd9, 0xd9, 0xf7}
+)
+
+// decodePartialMetadata extracts the name, namespace, and labels of a stored object
+// without decoding the rest of it. It recognizes the media types used for storage: JSON,
+// CBOR, and protobuf in a runtime.Unknown envelope. The second return value is false if
+// the format is unrecognized or the metadata could not be extracted, in which case the
+// caller must fall back to a full decode.
+func decodePartialMetadata(data []byte) (partialMetadata, bool) {
+	switch {
+	case bytes.HasPrefix(data, protoEncodingPrefix):
+		return protoPartialMetadata(data[len(protoEncodingPrefix):])
+	case bytes.HasPrefix(data, cborSelfDescribed):
+		envelope := struct {
+			Metadata partialMetadata `json:"metadata"`
+		}{}
+		if err := direct.Unmarshal(bytes.TrimPrefix(data, cborSelfDescribed), &envelope); err != nil {
+			return partialMetadata{}, false
+		}
+		return envelope.Metadata, true
+	case len(data) > 0 && data[0] == '{':
+		envelope := struct {
+			Metadata partialMetadata `json:"metadata"`
+		}{}
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return partialMetadata{}, false
+		}
+		return envelope.Metadata, true
+	}
+	return partialMetadata{}, false
+}
+
+// protoPartialMetadata decodes the runtime.Unknown envelope and then, within the object
+// encoding it carries, decodes only the first embedded message, which is the object's
+// metadata by convention throughout Kubernetes API types. Remaining fields are skipped
+// without being decoded.
+func protoPartialMetadata(data []byte) (partialMetadata, bool) {
+	unknown := &runtime.Unknown{}
+	if err := unknown.Unmarshal(data); err != nil {
+		return partialMetadata{}, false
+	}
+	buf := proto.NewBuffer(unknown.Raw)
+	for {
+		key, err := buf.DecodeVarint()
+		if err != nil {
+			// Reached the end of the encoding (or malformed input) without
+			// finding a metadata field.
+			return partialMetadata{}, false
+		}
+		wireType := key & 0x7
+		if key>>3 == 1 {
+			if wireType != proto.WireBytes {
+				return partialMetadata{}, false
+			}
+			sub, err := buf.DecodeRawBytes(false)
+			if err != nil {
+				return partialMetadata{}, false
+			}
+			objectMeta := &metav1.ObjectMeta{}
+			if err := objectMeta.Unmarshal(sub); err != nil {
+				return partialMetadata{}, false
+			}
+			return partialMetadata{
+				Name:      objectMeta.Name,
+				Namespace: objectMeta.Namespace,
+				Labels:    objectMeta.Labels,
+			}, true
+		}
+		switch wireType {
+		case proto.WireVarint:
+			if _, err := buf.DecodeVarint(); err != nil {
+				return partialMetadata{}, false
+			}
+		case proto.WireFixed64:
+			if _, err := buf.DecodeFixed64(); err != nil {
+				return partialMetadata{}, false
+			}
+		case proto.WireBytes:
+			if _, err := buf.DecodeRawBytes(false); err != nil {
+				return partialMetadata{}, false
+			}
+		case proto.WireFixed32:
+			if _, err := buf.DecodeFixed32(); err != nil {
+				return partialMetadata{}, false
+			}
+		default:
+			return partialMetadata{}, false
+		}
+	}
+}
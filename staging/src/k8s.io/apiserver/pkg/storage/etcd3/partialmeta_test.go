@@ -0,0 +1,246 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package etcd3
+
+import (
+	"reflect"
+	"testing"
+
+	apitesting "k8s.io/apimachinery/pkg/api/apitesting"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	"k8s.io/apiserver/pkg/apis/example"
+	examplev1 "k8s.io/apiserver/pkg/apis/example/v1"
+	"k8s.io/apiserver/pkg/features"
+	"k8s.io/apiserver/pkg/storage"
+	"k8s.io/apiserver/pkg/storage/value"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
+)
+
+func testPod() *example.Pod {
+	return &example.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "ns",
+			Labels:    map[string]string{"app": "foo"},
+		},
+		Spec: example.PodSpec{NodeName: "node1"},
+	}
+}
+
+func jsonCodec() runtime.Codec {
+	return apitesting.TestCodec(codecs, examplev1.SchemeGroupVersion)
+}
+
+func protoCodec() runtime.Codec {
+	s := protobuf.NewSerializer(scheme, scheme)
+	return codecs.CodecForVersions(s, s, examplev1.SchemeGroupVersion, examplev1.SchemeGroupVersion)
+}
+
+func TestDecodePartialMetadata(t *testing.T) {
+	pod := testPod()
+	want := partialMetadata{Name: "foo", Namespace: "ns", Labels: map[string]string{"app": "foo"}}
+
+	jsonData, err := runtime.Encode(jsonCodec(), pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	protoData, err := runtime.Encode(protoCodec(), pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cborDocument, err := direct.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      "foo",
+			"namespace": "ns",
+			"labels":    map[string]interface{}{"app": "foo"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cborData := append([]byte{0xd9, 0xd9, 0xf7}, cborDocument...)
+
+	for name, data := range map[string][]byte{
+		"json":     jsonData,
+		"protobuf": protoData,
+		"cbor":     cborData,
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, ok := decodePartialMetadata(data)
+			if !ok {
+				t.Fatal("expected metadata to be extracted")
+			}
+			if !reflect.DeepEqual(want, got) {
+				t.Errorf("expected %#v, got %#v", want, got)
+			}
+		})
+	}
+
+	for name, data := range map[string][]byte{
+		"empty":              {},
+		"unrecognized":       []byte("---\nnot: supported\n"),
+		"truncated protobuf": protoData[:6],
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, ok := decodePartialMetadata(data); ok {
+				t.Error("expected extraction to fail")
+			}
+		})
+	}
+}
+
+func TestFiltersWholeEventByMetadata(t *testing.T) {
+	codec := protoCodec()
+	matching, err := runtime.Encode(codec, testPod())
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := testPod()
+	other.Labels = map[string]string{"app": "other"}
+	nonMatching, err := runtime.Encode(codec, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	labelPred := storage.SelectionPredicate{
+		Label:    labels.SelectorFromSet(labels.Set{"app": "foo"}),
+		Field:    fields.Everything(),
+		GetAttrs: storage.DefaultNamespaceScopedAttr,
+	}
+	fieldPred := labelPred
+	fieldPred.Field = fields.OneTermEqualSelector("metadata.name", "foo")
+
+	testcases := []struct {
+		name         string
+		enabled      bool
+		pred         storage.SelectionPredicate
+		event        *event
+		wantFiltered bool
+	}{
+		{
+			name:         "feature disabled",
+			pred:         labelPred,
+			event:        &event{key: "/k", value: nonMatching},
+			wantFiltered: false,
+		},
+		{
+			name:         "non-matching create",
+			enabled:      true,
+			pred:         labelPred,
+			event:        &event{key: "/k", value: nonMatching, isCreated: true},
+			wantFiltered: true,
+		},
+		{
+			name:         "matching create",
+			enabled:      true,
+			pred:         labelPred,
+			event:        &event{key: "/k", value: matching, isCreated: true},
+			wantFiltered: false,
+		},
+		{
+			name:         "non-matching update of non-matching object",
+			enabled:      true,
+			pred:         labelPred,
+			event:        &event{key: "/k", value: nonMatching, prevValue: nonMatching},
+			wantFiltered: true,
+		},
+		{
+			name:         "non-matching update of matching object",
+			enabled:      true,
+			pred:         labelPred,
+			event:        &event{key: "/k", value: nonMatching, prevValue: matching},
+			wantFiltered: false,
+		},
+		{
+			name:         "non-matching delete",
+			enabled:      true,
+			pred:         labelPred,
+			event:        &event{key: "/k", prevValue: nonMatching, isDeleted: true},
+			wantFiltered: true,
+		},
+		{
+			name:         "delete without previous value",
+			enabled:      true,
+			pred:         labelPred,
+			event:        &event{key: "/k", isDeleted: true},
+			wantFiltered: false,
+		},
+		{
+			name:         "field selector disqualifies fast path",
+			enabled:      true,
+			pred:         fieldPred,
+			event:        &event{key: "/k", value: nonMatching, isCreated: true},
+			wantFiltered: false,
+		},
+		{
+			name:         "empty predicate",
+			enabled:      true,
+			pred:         storage.Everything,
+			event:        &event{key: "/k", value: nonMatching, isCreated: true},
+			wantFiltered: false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.WatchPartialMetadataDecode, tc.enabled)()
+
+			wc := &watchChan{
+				watcher:      newWatcher(nil, codec, APIObjectVersioner{}, value.IdentityTransformer),
+				internalPred: tc.pred,
+			}
+			if got := wc.filtersWholeEventByMetadata(tc.event); got != tc.wantFiltered {
+				t.Errorf("expected filtered=%t, got %t", tc.wantFiltered, got)
+			}
+		})
+	}
+}
+
+func BenchmarkDecodePartialMetadata(b *testing.B) {
+	pod := testPod()
+	for name, codec := range map[string]runtime.Codec{
+		"json":     jsonCodec(),
+		"protobuf": protoCodec(),
+	} {
+		data, err := runtime.Encode(codec, pod)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(name+"/partial", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, ok := decodePartialMetadata(data); !ok {
+					b.Fatal("failed to extract metadata")
+				}
+			}
+		})
+		b.Run(name+"/full", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := runtime.Decode(codec, data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
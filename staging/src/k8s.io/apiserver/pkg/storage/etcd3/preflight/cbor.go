@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"go.etcd.io/etcd/clientv3"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+	"k8s.io/apiserver/pkg/storage/value"
+)
+
+// cborCheckPageSize limits how many keys are read from etcd per range request during a
+// compatibility check. It is a variable so that tests can exercise pagination.
+var cborCheckPageSize int64 = 500
+
+// CBORIncompatibility describes a stored object that did not survive a round trip through
+// the CBOR encoding.
+type CBORIncompatibility struct {
+	// Key is the etcd key the object is stored under.
+	Key string
+	// GroupVersionKind identifies the stored object, as far as it could be determined.
+	GroupVersionKind schema.GroupVersionKind
+	// Namespace and Name identify the object, when its metadata could be read.
+	Namespace string
+	Name      string
+	// Reason describes how the round trip failed.
+	Reason string
+}
+
+func (i CBORIncompatibility) String() string {
+	return fmt.Sprintf("%s %s/%s (key %q): %s", i.GroupVersionKind, i.Namespace, i.Name, i.Key, i.Reason)
+}
+
+// CheckCBORStorageCompatibility reads every value stored under prefix, decodes it with the
+// provided decoder, re-encodes it to CBOR, decodes the re-encoded document, and verifies
+// that the two documents agree semantically. It is a dry run operators can perform before
+// switching a server's storage media type to application/cbor: stored values are never
+// modified, and every object that cannot round-trip is reported. The decoder must
+// recognize the formats currently in storage, typically a codec factory's
+// UniversalDeserializer, and the scheme provides the types for re-decoding. If transformer
+// is not nil it is applied to each raw value before decoding, as the storage layer would.
+func CheckCBORStorageCompatibility(ctx context.Context, client *clientv3.Client, prefix string, decoder runtime.Decoder, scheme *runtime.Scheme, transformer value.Transformer) ([]CBORIncompatibility, error) {
+	serializer := cbor.NewSerializer(cbor.DefaultMetaFactory, scheme, scheme)
+
+	var incompatibilities []CBORIncompatibility
+	key := prefix
+	rangeEnd := clientv3.GetPrefixRangeEnd(prefix)
+	for {
+		resp, err := client.Get(ctx, key,
+			clientv3.WithRange(rangeEnd),
+			clientv3.WithLimit(cborCheckPageSize),
+			clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend),
+		)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range resp.Kvs {
+			if incompatibility, ok := checkCBORRoundTrip(string(kv.Key), kv.Value, decoder, serializer, transformer); !ok {
+				incompatibilities = append(incompatibilities, incompatibility)
+			}
+		}
+		if !resp.More || len(resp.Kvs) == 0 {
+			break
+		}
+		// Continue immediately after the last returned key.
+		key = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+	return incompatibilities, nil
+}
+
+// checkCBORRoundTrip verifies that a single stored value round-trips through CBOR. It
+// reports ok when the value is compatible, and an incompatibility describing the failure
+// otherwise.
+func checkCBORRoundTrip(key string, data []byte, decoder runtime.Decoder, serializer cbor.Serializer, transformer value.Transformer) (CBORIncompatibility, bool) {
+	incompatibility := CBORIncompatibility{Key: key}
+
+	if transformer != nil {
+		transformed, _, err := transformer.TransformFromStorage(data, value.DefaultContext(key))
+		if err != nil {
+			incompatibility.Reason = fmt.Sprintf("failed to transform stored value: %v", err)
+			return incompatibility, false
+		}
+		data = transformed
+	}
+
+	obj, gvk, err := decoder.Decode(data, nil, nil)
+	if gvk != nil {
+		incompatibility.GroupVersionKind = *gvk
+	}
+	if err != nil {
+		incompatibility.Reason = fmt.Sprintf("failed to decode stored value: %v", err)
+		return incompatibility, false
+	}
+	if accessor, err := meta.Accessor(obj); err == nil {
+		incompatibility.Namespace = accessor.GetNamespace()
+		incompatibility.Name = accessor.GetName()
+	}
+
+	var encoded bytes.Buffer
+	if err := serializer.Encode(obj, &encoded); err != nil {
+		incompatibility.Reason = fmt.Sprintf("failed to encode to CBOR: %v", err)
+		return incompatibility, false
+	}
+
+	reencoded, _, err := serializer.Decode(encoded.Bytes(), nil, nil)
+	if err != nil {
+		incompatibility.Reason = fmt.Sprintf("failed to decode CBOR encoding: %v", err)
+		return incompatibility, false
+	}
+	var second bytes.Buffer
+	if err := serializer.Encode(reencoded, &second); err != nil {
+		incompatibility.Reason = fmt.Sprintf("failed to re-encode to CBOR: %v", err)
+		return incompatibility, false
+	}
+
+	equal, err := direct.Equal(encoded.Bytes(), second.Bytes())
+	if err != nil {
+		incompatibility.Reason = fmt.Sprintf("failed to compare CBOR encodings: %v", err)
+		return incompatibility, false
+	}
+	if !equal {
+		incompatibility.Reason = "object does not round-trip through CBOR losslessly"
+		return incompatibility, false
+	}
+	return CBORIncompatibility{}, true
+}
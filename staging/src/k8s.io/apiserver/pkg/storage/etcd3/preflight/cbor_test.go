@@ -0,0 +1,112 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"testing"
+
+	"go.etcd.io/etcd/integration"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apiserver/pkg/apis/example"
+	examplev1 "k8s.io/apiserver/pkg/apis/example/v1"
+)
+
+func TestCheckCBORStorageCompatibility(t *testing.T) {
+	scheme := runtime.NewScheme()
+	metav1.AddToGroupVersion(scheme, metav1.SchemeGroupVersion)
+	utilruntime.Must(example.AddToScheme(scheme))
+	utilruntime.Must(examplev1.AddToScheme(scheme))
+	codecs := serializer.NewCodecFactory(scheme)
+
+	cluster := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 1})
+	defer cluster.Terminate(t)
+	client := cluster.RandClient()
+	ctx := context.Background()
+
+	pod := &examplev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "good"}}
+	data, err := runtime.Encode(codecs.LegacyCodec(examplev1.SchemeGroupVersion), pod)
+	if err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+	if _, err := client.Put(ctx, "/prefix/pods/ns/good", string(data)); err != nil {
+		t.Fatalf("unexpected put error: %v", err)
+	}
+	if _, err := client.Put(ctx, "/prefix/pods/ns/mangled", `{"apiVersion":`); err != nil {
+		t.Fatalf("unexpected put error: %v", err)
+	}
+	// Keys outside the prefix are not examined.
+	if _, err := client.Put(ctx, "/other/mangled", `{"apiVersion":`); err != nil {
+		t.Fatalf("unexpected put error: %v", err)
+	}
+
+	incompatibilities, err := CheckCBORStorageCompatibility(ctx, client, "/prefix/", codecs.UniversalDeserializer(), scheme, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(incompatibilities) != 1 {
+		t.Fatalf("expected exactly one incompatibility, got %v", incompatibilities)
+	}
+	if incompatibilities[0].Key != "/prefix/pods/ns/mangled" {
+		t.Errorf("unexpected key: %s", incompatibilities[0].Key)
+	}
+	if incompatibilities[0].Reason == "" {
+		t.Error("expected a reason for the incompatibility")
+	}
+}
+
+func TestCheckCBORStorageCompatibilityPagination(t *testing.T) {
+	scheme := runtime.NewScheme()
+	metav1.AddToGroupVersion(scheme, metav1.SchemeGroupVersion)
+	utilruntime.Must(example.AddToScheme(scheme))
+	utilruntime.Must(examplev1.AddToScheme(scheme))
+	codecs := serializer.NewCodecFactory(scheme)
+
+	cluster := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 1})
+	defer cluster.Terminate(t)
+	client := cluster.RandClient()
+	ctx := context.Background()
+
+	// More keys than one page, each holding an undecodable value, verifies that every
+	// page is visited.
+	keys := []string{"/prefix/a", "/prefix/b", "/prefix/c"}
+	for _, key := range keys {
+		if _, err := client.Put(ctx, key, "not an object"); err != nil {
+			t.Fatalf("unexpected put error: %v", err)
+		}
+	}
+
+	originalPageSize := cborCheckPageSize
+	cborCheckPageSize = 1
+	defer func() { cborCheckPageSize = originalPageSize }()
+
+	incompatibilities, err := CheckCBORStorageCompatibility(ctx, client, "/prefix/", codecs.UniversalDeserializer(), scheme, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(incompatibilities) != len(keys) {
+		t.Fatalf("expected %d incompatibilities, got %v", len(keys), incompatibilities)
+	}
+	for i, key := range keys {
+		if incompatibilities[i].Key != key {
+			t.Errorf("expected key %s at index %d, got %s", key, i, incompatibilities[i].Key)
+		}
+	}
+}
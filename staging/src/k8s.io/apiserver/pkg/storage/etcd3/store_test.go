@@ -41,6 +41,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	cborserializer "k8s.io/apimachinery/pkg/runtime/serializer/cbor"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/apiserver/pkg/apis/example"
@@ -661,6 +662,45 @@ func TestGuaranteedUpdateChecksStoredData(t *testing.T) {
 	}
 }
 
+func TestGuaranteedUpdateSkipsNoOpWritesWithCBOR(t *testing.T) {
+	cborSerializer := cborserializer.NewSerializer(cborserializer.DefaultMetaFactory, scheme, scheme)
+	codec := codecs.CodecForVersions(cborSerializer, cborSerializer, examplev1.SchemeGroupVersion, examplev1.SchemeGroupVersion)
+	cluster := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 1})
+	defer cluster.Terminate(t)
+	store := newStore(cluster.RandClient(), true, codec, "", &prefixTransformer{prefix: []byte(defaultTestPrefix)})
+	ctx := context.Background()
+
+	key, storeObj := testPropogateStore(ctx, t, store, &example.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "A"}})
+
+	// An update that passes the object through unchanged must not write to etcd.
+	out := &example.Pod{}
+	err := store.GuaranteedUpdate(ctx, key, out, false, nil,
+		storage.SimpleUpdate(func(obj runtime.Object) (runtime.Object, error) {
+			return obj, nil
+		}))
+	if err != nil {
+		t.Fatalf("GuaranteedUpdate failed: %v", err)
+	}
+	if out.ResourceVersion != storeObj.ResourceVersion {
+		t.Errorf("no-op update should not have changed the resource version, before=%s, after=%s", storeObj.ResourceVersion, out.ResourceVersion)
+	}
+
+	// A change to the object must still write.
+	out = &example.Pod{}
+	err = store.GuaranteedUpdate(ctx, key, out, false, nil,
+		storage.SimpleUpdate(func(obj runtime.Object) (runtime.Object, error) {
+			pod := obj.(*example.Pod)
+			pod.Labels = map[string]string{"changed": "true"}
+			return pod, nil
+		}))
+	if err != nil {
+		t.Fatalf("GuaranteedUpdate failed: %v", err)
+	}
+	if out.ResourceVersion == storeObj.ResourceVersion {
+		t.Errorf("update with changes should have changed the resource version, but it remained %s", out.ResourceVersion)
+	}
+}
+
 func TestGuaranteedUpdateWithConflict(t *testing.T) {
 	ctx, store, cluster := testSetup(t)
 	defer cluster.Terminate(t)
@@ -26,10 +26,13 @@ import (
 	"sync"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/features"
 	"k8s.io/apiserver/pkg/storage"
 	"k8s.io/apiserver/pkg/storage/value"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 
 	"go.etcd.io/etcd/clientv3"
 	"k8s.io/klog/v2"
@@ -290,8 +293,64 @@ func (wc *watchChan) acceptAll() bool {
 	return wc.internalPred.Empty()
 }
 
+// filtersWholeEventByMetadata returns true when partially-decoded metadata shows that
+// every object referenced by the event fails the predicate, so the event would be
+// filtered out after a full decode anyway. It only applies to predicates using label
+// selection alone, because arbitrary field selectors can reference fields that are not
+// part of the partially-decoded metadata. Any value whose metadata cannot be cheaply
+// extracted disqualifies the whole event from this fast path.
+func (wc *watchChan) filtersWholeEventByMetadata(e *event) bool {
+	if !utilfeature.DefaultFeatureGate.Enabled(features.WatchPartialMetadataDecode) {
+		return false
+	}
+	if wc.internalPred.Label == nil || wc.internalPred.Label.Empty() {
+		return false
+	}
+	if wc.internalPred.Field != nil && !wc.internalPred.Field.Empty() {
+		return false
+	}
+	if !e.isDeleted {
+		matches, ok := wc.matchesByMetadata(e.value, e.key)
+		if !ok || matches {
+			return false
+		}
+	}
+	if len(e.prevValue) > 0 {
+		matches, ok := wc.matchesByMetadata(e.prevValue, e.key)
+		if !ok || matches {
+			return false
+		}
+	} else if e.isDeleted {
+		// A deletion without a previous value has no metadata to evaluate.
+		return false
+	}
+	return true
+}
+
+// matchesByMetadata evaluates the predicate's label selector against the labels of a
+// stored value without fully decoding it. The second return value is false when the
+// metadata could not be extracted, in which case the caller must fall back to a full
+// decode. Values that reach this method are transformed a second time if the event is
+// not dropped; the fast path only pays off when the label selector excludes most of the
+// watched objects.
+func (wc *watchChan) matchesByMetadata(value []byte, key string) (matches, ok bool) {
+	data, _, err := wc.watcher.transformer.TransformFromStorage(value, authenticatedDataString(key))
+	if err != nil {
+		return false, false
+	}
+	meta, ok := decodePartialMetadata(data)
+	if !ok {
+		return false, false
+	}
+	return wc.internalPred.Label.Matches(labels.Set(meta.Labels)), true
+}
+
 // transform transforms an event into a result for user if not filtered.
 func (wc *watchChan) transform(e *event) (res *watch.Event) {
+	if wc.filtersWholeEventByMetadata(e) {
+		return nil
+	}
+
 	curObj, oldObj, err := wc.prepareObjs(e)
 	if err != nil {
 		klog.Errorf("failed to prepare current and previous objects: %v", err)
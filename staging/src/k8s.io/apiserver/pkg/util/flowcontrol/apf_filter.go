@@ -23,6 +23,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apiserver/pkg/util/flowcontrol/counter"
+	"k8s.io/apiserver/pkg/util/flowcontrol/debug"
 	fq "k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing"
 	fqs "k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing/queueset"
 	"k8s.io/apiserver/pkg/util/flowcontrol/metrics"
@@ -51,6 +52,10 @@ type Interface interface {
 	// any needed changes to local behavior.  This method ceases
 	// activity and returns after the given channel is closed.
 	Run(stopCh <-chan struct{}) error
+
+	// DumpState returns a snapshot of the filter's current state for
+	// the debug endpoints.
+	DumpState() *debug.FlowControlState
 }
 
 // This request filter implements https://github.com/kubernetes/enhancements/blob/master/keps/sig-api-machinery/20190228-priority-and-fairness.md
@@ -0,0 +1,45 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	cborserializer "k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+// SchemeGroupVersion is the group and version used to register the debug kinds.  The
+// kinds are never persisted and have no other versions.
+var SchemeGroupVersion = schema.GroupVersion{Group: "debug.flowcontrol.apiserver.k8s.io", Version: "v1"}
+
+// Scheme contains only the debug kinds.
+var Scheme = runtime.NewScheme()
+
+// Codecs serializes the debug kinds, including as CBOR.
+var Codecs = serializer.NewCodecFactory(Scheme, serializer.WithSerializer(cborserializer.NewSerializerInfo))
+
+func init() {
+	utilruntime.Must(AddToScheme(Scheme))
+}
+
+// AddToScheme registers the debug kinds with the provided scheme.
+func AddToScheme(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(SchemeGroupVersion, &FlowControlState{})
+	return nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package debug defines the kinds served by the API priority and fairness debug
+// endpoints.  They are snapshots of filter state, not configuration, and are registered
+// in their own scheme so that the standard serializers can negotiate their encoding.
+package debug
+
+import (
+	flowcontrolv1alpha1 "k8s.io/api/flowcontrol/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// FlowControlState is a point-in-time snapshot of the state of the API priority and
+// fairness filter.
+type FlowControlState struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// PriorityLevels reports each priority level known to the filter, including the
+	// mandatory levels.
+	PriorityLevels []PriorityLevelState `json:"priorityLevels"`
+
+	// FlowSchemas reports the flow schemas in effect, in decreasing order of matching
+	// precedence.
+	FlowSchemas []FlowSchemaState `json:"flowSchemas"`
+}
+
+// PriorityLevelState is the state of one priority level.
+type PriorityLevelState struct {
+	// Name of the PriorityLevelConfiguration object or prototype prescribing this level.
+	Name string `json:"name"`
+
+	// Spec in effect for this level.
+	Spec flowcontrolv1alpha1.PriorityLevelConfigurationSpec `json:"spec"`
+
+	// Quiescing indicates that this priority level is undesired and will be removed
+	// once its queues have drained.
+	Quiescing bool `json:"quiescing"`
+
+	// NumPending is the number of requests that have been matched to this level but
+	// have not yet started waiting or executing.
+	NumPending int `json:"numPending"`
+}
+
+// FlowSchemaState is the state of one flow schema.
+type FlowSchemaState struct {
+	// Name of the FlowSchema object or prototype.
+	Name string `json:"name"`
+
+	// Spec in effect for this schema.
+	Spec flowcontrolv1alpha1.FlowSchemaSpec `json:"spec"`
+}
+
+// DeepCopyObject implements runtime.Object.
+func (in *FlowControlState) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowControlState)
+	out.TypeMeta = in.TypeMeta
+	if in.PriorityLevels != nil {
+		out.PriorityLevels = make([]PriorityLevelState, len(in.PriorityLevels))
+		for i := range in.PriorityLevels {
+			out.PriorityLevels[i].Name = in.PriorityLevels[i].Name
+			in.PriorityLevels[i].Spec.DeepCopyInto(&out.PriorityLevels[i].Spec)
+			out.PriorityLevels[i].Quiescing = in.PriorityLevels[i].Quiescing
+			out.PriorityLevels[i].NumPending = in.PriorityLevels[i].NumPending
+		}
+	}
+	if in.FlowSchemas != nil {
+		out.FlowSchemas = make([]FlowSchemaState, len(in.FlowSchemas))
+		for i := range in.FlowSchemas {
+			out.FlowSchemas[i].Name = in.FlowSchemas[i].Name
+			in.FlowSchemas[i].Spec.DeepCopyInto(&out.FlowSchemas[i].Spec)
+		}
+	}
+	return out
+}
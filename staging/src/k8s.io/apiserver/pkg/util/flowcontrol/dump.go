@@ -0,0 +1,56 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"sort"
+
+	"k8s.io/apiserver/pkg/util/flowcontrol/debug"
+)
+
+// DumpState returns a snapshot of the filter's current state for the debug endpoints.
+func (cfgCtl *configController) DumpState() *debug.FlowControlState {
+	cfgCtl.lock.Lock()
+	defer cfgCtl.lock.Unlock()
+
+	dump := &debug.FlowControlState{}
+
+	plNames := make([]string, 0, len(cfgCtl.priorityLevelStates))
+	for plName := range cfgCtl.priorityLevelStates {
+		plNames = append(plNames, plName)
+	}
+	sort.Strings(plNames)
+	for _, plName := range plNames {
+		plState := cfgCtl.priorityLevelStates[plName]
+		dump.PriorityLevels = append(dump.PriorityLevels, debug.PriorityLevelState{
+			Name:       plState.pl.Name,
+			Spec:       plState.pl.Spec,
+			Quiescing:  plState.quiescing,
+			NumPending: plState.numPending,
+		})
+	}
+
+	// flowSchemas is already sorted by decreasing matching precedence.
+	for _, fs := range cfgCtl.flowSchemas {
+		dump.FlowSchemas = append(dump.FlowSchemas, debug.FlowSchemaState{
+			Name: fs.Name,
+			Spec: fs.Spec,
+		})
+	}
+
+	return dump
+}
@@ -18,7 +18,6 @@ package webhook
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
@@ -29,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	cbordirect "k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/rest"
 )
@@ -122,11 +122,14 @@ func (cm *ClientManager) Validate() error {
 func (cm *ClientManager) HookClient(cc ClientConfig) (*rest.RESTClient, error) {
 	ccWithNoName := cc
 	ccWithNoName.Name = ""
-	cacheKey, err := json.Marshal(ccWithNoName)
+	// The key is a collision-resistant digest of the canonical encoding of the
+	// configuration, so equivalent configurations always share a cache entry and
+	// distinct configurations never do.
+	cacheKey, err := cbordirect.Fingerprint(ccWithNoName)
 	if err != nil {
 		return nil, err
 	}
-	if client, ok := cm.cache.Get(string(cacheKey)); ok {
+	if client, ok := cm.cache.Get(cacheKey); ok {
 		return client.(*rest.RESTClient), nil
 	}
 
@@ -150,7 +153,7 @@ func (cm *ClientManager) HookClient(cc ClientConfig) (*rest.RESTClient, error) {
 		cfg.ContentConfig.ContentType = runtime.ContentTypeJSON
 		client, err := rest.UnversionedRESTClientFor(cfg)
 		if err == nil {
-			cm.cache.Add(string(cacheKey), client)
+			cm.cache.Add(cacheKey, client)
 		}
 		return client, err
 	}
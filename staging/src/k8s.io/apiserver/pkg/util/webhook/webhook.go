@@ -26,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor"
 	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/rest"
@@ -62,10 +63,18 @@ func DefaultShouldRetry(err error) bool {
 
 // NewGenericWebhook creates a new GenericWebhook from the provided kubeconfig file.
 func NewGenericWebhook(scheme *runtime.Scheme, codecFactory serializer.CodecFactory, kubeConfigFile string, groupVersions []schema.GroupVersion, initialBackoff time.Duration, customDial utilnet.DialFunc) (*GenericWebhook, error) {
-	return newGenericWebhook(scheme, codecFactory, kubeConfigFile, groupVersions, initialBackoff, defaultRequestTimeout, customDial)
+	return newGenericWebhook(scheme, codecFactory, kubeConfigFile, groupVersions, initialBackoff, defaultRequestTimeout, customDial, false)
 }
 
-func newGenericWebhook(scheme *runtime.Scheme, codecFactory serializer.CodecFactory, kubeConfigFile string, groupVersions []schema.GroupVersion, initialBackoff, requestTimeout time.Duration, customDial utilnet.DialFunc) (*GenericWebhook, error) {
+// NewCBORNegotiatingGenericWebhook creates a GenericWebhook like NewGenericWebhook, with
+// a client that additionally advertises acceptance of CBOR-encoded responses and decodes
+// them when the backend sends one. Requests are encoded as JSON either way, so a backend
+// that ignores the Accept header observes no difference from NewGenericWebhook.
+func NewCBORNegotiatingGenericWebhook(scheme *runtime.Scheme, codecFactory serializer.CodecFactory, kubeConfigFile string, groupVersions []schema.GroupVersion, initialBackoff time.Duration, customDial utilnet.DialFunc) (*GenericWebhook, error) {
+	return newGenericWebhook(scheme, codecFactory, kubeConfigFile, groupVersions, initialBackoff, defaultRequestTimeout, customDial, true)
+}
+
+func newGenericWebhook(scheme *runtime.Scheme, codecFactory serializer.CodecFactory, kubeConfigFile string, groupVersions []schema.GroupVersion, initialBackoff, requestTimeout time.Duration, customDial utilnet.DialFunc, negotiateCBOR bool) (*GenericWebhook, error) {
 	for _, groupVersion := range groupVersions {
 		if !scheme.IsVersionRegistered(groupVersion) {
 			return nil, fmt.Errorf("webhook plugin requires enabling extension resource: %s", groupVersion)
@@ -93,7 +102,23 @@ func newGenericWebhook(scheme *runtime.Scheme, codecFactory serializer.CodecFact
 	clientConfig.QPS = -1
 
 	codec := codecFactory.LegacyCodec(groupVersions...)
-	clientConfig.ContentConfig.NegotiatedSerializer = serializer.NegotiatedSerializerWrapper(runtime.SerializerInfo{Serializer: codec})
+	negotiatedSerializer := serializer.NegotiatedSerializerWrapper(runtime.SerializerInfo{Serializer: codec})
+	if negotiateCBOR {
+		// The wrapped legacy codec is registered without a media type and acts as a
+		// wildcard, so a codec that can actually decode CBOR must be offered under
+		// application/cbor explicitly for negotiation to select it. Requests still
+		// use the default content type, JSON.
+		cborSerializer := cbor.NewSerializer(cbor.DefaultMetaFactory, scheme, scheme)
+		cborCodec := codecFactory.CodecForVersions(cborSerializer, cborSerializer, schema.GroupVersions(groupVersions), runtime.InternalGroupVersioner)
+		negotiatedSerializer = serializer.WithMediaTypeRestriction(negotiatedSerializer, nil, []runtime.SerializerInfo{{
+			MediaType:        runtime.ContentTypeCBOR,
+			MediaTypeType:    "application",
+			MediaTypeSubType: "cbor",
+			Serializer:       cborCodec,
+		}})
+		clientConfig.ContentConfig.AcceptContentTypes = runtime.ContentTypeCBOR + "," + runtime.ContentTypeJSON
+	}
+	clientConfig.ContentConfig.NegotiatedSerializer = negotiatedSerializer
 
 	clientConfig.Dial = customDial
 
@@ -28,11 +28,13 @@ import (
 	"net/url"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	authenticationv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cbordirect "k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 	"k8s.io/apiserver/pkg/authentication/authenticator"
 	"k8s.io/apiserver/pkg/authentication/token/cache"
 	"k8s.io/apiserver/pkg/authentication/user"
@@ -193,7 +195,7 @@ func newV1TokenAuthenticator(serverURL string, clientCert, clientKey, ca []byte,
 		return nil, err
 	}
 
-	c, err := tokenReviewInterfaceFromKubeconfig(p, "v1", nil)
+	c, err := tokenReviewInterfaceFromKubeconfig(p, "v1", nil, false)
 	if err != nil {
 		return nil, err
 	}
@@ -682,3 +684,101 @@ func TestV1WebhookCacheAndRetry(t *testing.T) {
 		})
 	}
 }
+
+// TestV1CBORNegotiation verifies that a CBOR-negotiating authenticator advertises CBOR
+// to the backend and decodes whichever of the two supported encodings the backend picks,
+// and that the default authenticator does not offer CBOR.
+func TestV1CBORNegotiation(t *testing.T) {
+	tests := []struct {
+		description string
+		negotiate   bool
+		respondCBOR bool
+	}{
+		{description: "default client does not offer cbor", negotiate: false},
+		{description: "negotiating client decodes a json response", negotiate: true},
+		{description: "negotiating client decodes a cbor response", negotiate: true, respondCBOR: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			var lastAccept string
+			serveHTTP := func(w http.ResponseWriter, r *http.Request) {
+				lastAccept = r.Header.Get("Accept")
+				var review authenticationv1.TokenReview
+				bodyData, _ := ioutil.ReadAll(r.Body)
+				// The request encoding must remain JSON whether or not the client
+				// negotiates the response encoding.
+				if err := json.Unmarshal(bodyData, &review); err != nil {
+					http.Error(w, fmt.Sprintf("failed to decode body: %v", err), http.StatusBadRequest)
+					return
+				}
+				review.Status = authenticationv1.TokenReviewStatus{
+					Authenticated: true,
+					User:          authenticationv1.UserInfo{Username: "somebody"},
+				}
+				if tt.respondCBOR {
+					if !strings.Contains(lastAccept, "application/cbor") {
+						http.Error(w, "client does not accept application/cbor", http.StatusNotAcceptable)
+						return
+					}
+					data, err := cbordirect.Marshal(&review)
+					if err != nil {
+						http.Error(w, err.Error(), http.StatusInternalServerError)
+						return
+					}
+					w.Header().Set("Content-Type", "application/cbor")
+					w.Write(data)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(&review)
+			}
+
+			cert, err := tls.X509KeyPair(serverCert, serverKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := httptest.NewUnstartedServer(http.HandlerFunc(serveHTTP))
+			server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+			server.StartTLS()
+			defer server.Close()
+
+			tempfile, err := ioutil.TempFile("", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := tempfile.Name()
+			defer os.Remove(p)
+			config := v1.Config{
+				Clusters: []v1.NamedCluster{
+					{Cluster: v1.Cluster{Server: server.URL, CertificateAuthorityData: caCert}},
+				},
+			}
+			if err := json.NewEncoder(tempfile).Encode(config); err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := tokenReviewInterfaceFromKubeconfig(p, "v1", nil, tt.negotiate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wh, err := newWithBackoff(c, 0, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, authenticated, err := wh.AuthenticateToken(context.Background(), "t0k3n")
+			if err != nil {
+				t.Fatalf("authentication failed: %v", err)
+			}
+			if !authenticated {
+				t.Error("failed to authenticate token")
+			}
+			if resp.User.GetName() != "somebody" {
+				t.Errorf("got user %q, expected %q", resp.User.GetName(), "somebody")
+			}
+			if offered := strings.Contains(lastAccept, "application/cbor"); offered != tt.negotiate {
+				t.Errorf("Accept header %q offers application/cbor: %v, expected %v", lastAccept, offered, tt.negotiate)
+			}
+		})
+	}
+}
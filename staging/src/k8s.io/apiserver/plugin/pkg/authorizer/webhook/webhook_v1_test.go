@@ -36,6 +36,7 @@ import (
 
 	authorizationv1 "k8s.io/api/authorization/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cbordirect "k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 	"k8s.io/apimachinery/pkg/util/diff"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
@@ -186,7 +187,7 @@ current-context: default
 				return fmt.Errorf("failed to execute test template: %v", err)
 			}
 			// Create a new authorizer
-			sarClient, err := subjectAccessReviewInterfaceFromKubeconfig(p, "v1", nil)
+			sarClient, err := subjectAccessReviewInterfaceFromKubeconfig(p, "v1", nil, false)
 			if err != nil {
 				return fmt.Errorf("error building sar client: %v", err)
 			}
@@ -325,7 +326,7 @@ func newV1Authorizer(callbackURL string, clientCert, clientKey, ca []byte, cache
 	if err := json.NewEncoder(tempfile).Encode(config); err != nil {
 		return nil, err
 	}
-	sarClient, err := subjectAccessReviewInterfaceFromKubeconfig(p, "v1", nil)
+	sarClient, err := subjectAccessReviewInterfaceFromKubeconfig(p, "v1", nil, false)
 	if err != nil {
 		return nil, fmt.Errorf("error building sar client: %v", err)
 	}
@@ -645,3 +646,102 @@ func TestV1WebhookCache(t *testing.T) {
 		})
 	}
 }
+
+// TestV1CBORNegotiation verifies that a CBOR-negotiating authorizer advertises CBOR to
+// the backend and decodes whichever of the two supported encodings the backend picks,
+// and that the default authorizer does not offer CBOR.
+func TestV1CBORNegotiation(t *testing.T) {
+	tests := []struct {
+		description string
+		negotiate   bool
+		respondCBOR bool
+	}{
+		{description: "default client does not offer cbor", negotiate: false},
+		{description: "negotiating client decodes a json response", negotiate: true},
+		{description: "negotiating client decodes a cbor response", negotiate: true, respondCBOR: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			var lastAccept string
+			serveHTTP := func(w http.ResponseWriter, r *http.Request) {
+				lastAccept = r.Header.Get("Accept")
+				var review authorizationv1.SubjectAccessReview
+				bodyData, _ := ioutil.ReadAll(r.Body)
+				// The request encoding must remain JSON whether or not the client
+				// negotiates the response encoding.
+				if err := json.Unmarshal(bodyData, &review); err != nil {
+					http.Error(w, fmt.Sprintf("failed to decode body: %v", err), http.StatusBadRequest)
+					return
+				}
+				review.Status = authorizationv1.SubjectAccessReviewStatus{Allowed: true}
+				if tt.respondCBOR {
+					if !strings.Contains(lastAccept, "application/cbor") {
+						http.Error(w, "client does not accept application/cbor", http.StatusNotAcceptable)
+						return
+					}
+					data, err := cbordirect.Marshal(&review)
+					if err != nil {
+						http.Error(w, err.Error(), http.StatusInternalServerError)
+						return
+					}
+					w.Header().Set("Content-Type", "application/cbor")
+					w.Write(data)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(&review)
+			}
+
+			cert, err := tls.X509KeyPair(serverCert, serverKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := httptest.NewUnstartedServer(http.HandlerFunc(serveHTTP))
+			server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+			server.StartTLS()
+			defer server.Close()
+
+			tempfile, err := ioutil.TempFile("", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			p := tempfile.Name()
+			defer os.Remove(p)
+			config := v1.Config{
+				Clusters: []v1.NamedCluster{
+					{Cluster: v1.Cluster{Server: server.URL, CertificateAuthorityData: caCert}},
+				},
+			}
+			if err := json.NewEncoder(tempfile).Encode(config); err != nil {
+				t.Fatal(err)
+			}
+
+			sarClient, err := subjectAccessReviewInterfaceFromKubeconfig(p, "v1", nil, tt.negotiate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wh, err := newWithBackoff(sarClient, 0, 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			attr := authorizer.AttributesRecord{
+				User:            &user.DefaultInfo{Name: "alice"},
+				ResourceRequest: true,
+				Verb:            "get",
+				Resource:        "pods",
+				Namespace:       "kittensandponies",
+			}
+			decision, _, err := wh.Authorize(context.Background(), attr)
+			if err != nil {
+				t.Fatalf("authorization failed: %v", err)
+			}
+			if decision != authorizer.DecisionAllow {
+				t.Errorf("expected decision allow, got %v", decision)
+			}
+			if offered := strings.Contains(lastAccept, "application/cbor"); offered != tt.negotiate {
+				t.Errorf("Accept header %q offers application/cbor: %v, expected %v", lastAccept, offered, tt.negotiate)
+			}
+		})
+	}
+}
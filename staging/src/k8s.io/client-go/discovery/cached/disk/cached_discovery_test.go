@@ -33,6 +33,7 @@ import (
 	"k8s.io/client-go/discovery"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/rest/fake"
+	"k8s.io/client-go/util/mediatype"
 )
 
 func TestCachedDiscoveryClient_Fresh(t *testing.T) {
@@ -123,12 +124,41 @@ func TestNewCachedDiscoveryClient_PathPerm(t *testing.T) {
 	assert.NoError(err)
 }
 
+func TestCachedDiscoveryClient_Capabilities(t *testing.T) {
+	assert := assert.New(t)
+
+	d, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(d)
+
+	c := fakeDiscoveryClient{serverVersion: version.Info{GitVersion: "v1.0.0"}}
+	cdc := newCachedDiscoveryClient(&c, d, 60*time.Second)
+
+	gvr := schema.GroupVersionResource{Group: "a", Version: "v1", Resource: "widgets"}
+	capabilities, err := cdc.LoadCapabilities()
+	assert.NoError(err)
+	assert.Equal(mediatype.SupportUnknown, capabilities.Supports(gvr, "application/cbor"), "expected nothing recorded before the first store")
+
+	capabilities.RecordSupported(gvr, "application/cbor")
+	assert.NoError(cdc.StoreCapabilities(capabilities))
+
+	reloaded, err := newCachedDiscoveryClient(&c, d, 60*time.Second).LoadCapabilities()
+	assert.NoError(err)
+	assert.Equal(mediatype.Supported, reloaded.Supports(gvr, "application/cbor"), "expected the stored record to be visible to a new client")
+
+	c.serverVersion = version.Info{GitVersion: "v1.1.0"}
+	reloaded, err = newCachedDiscoveryClient(&c, d, 60*time.Second).LoadCapabilities()
+	assert.NoError(err)
+	assert.Equal(mediatype.SupportUnknown, reloaded.Supports(gvr, "application/cbor"), "expected the record to be discarded after a server version change")
+}
+
 type fakeDiscoveryClient struct {
 	groupCalls    int
 	resourceCalls int
 	versionCalls  int
 	openAPICalls  int
 
+	serverVersion          version.Info
 	serverResourcesHandler func() ([]*metav1.APIResourceList, error)
 }
 
@@ -206,7 +236,7 @@ func (c *fakeDiscoveryClient) ServerPreferredNamespacedResources() ([]*metav1.AP
 
 func (c *fakeDiscoveryClient) ServerVersion() (*version.Info, error) {
 	c.versionCalls = c.versionCalls + 1
-	return &version.Info{}, nil
+	return &c.serverVersion, nil
 }
 
 func (c *fakeDiscoveryClient) OpenAPISchema() (*openapi_v2.Document, error) {
@@ -0,0 +1,72 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disk
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"k8s.io/client-go/util/mediatype"
+)
+
+// capabilitiesFileName is the name of the media type capabilities file within the
+// discovery cache directory.
+const capabilitiesFileName = "mediatypecapabilities.json"
+
+// persistedCapabilities pairs a capability record with the server version it was learned
+// from, so that records are not trusted across server upgrades or downgrades.
+type persistedCapabilities struct {
+	ServerVersion string                  `json:"serverVersion"`
+	Capabilities  *mediatype.Capabilities `json:"capabilities"`
+}
+
+// LoadCapabilities returns the media type capability record persisted in the cache
+// directory. A record that is missing, expired, unreadable, or learned from a different
+// server version is replaced by an empty one, letting clients re-learn support instead
+// of failing.
+func (d *CachedDiscoveryClient) LoadCapabilities() (*mediatype.Capabilities, error) {
+	serverVersion, err := d.ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+	cachedBytes, err := d.getCachedFile(filepath.Join(d.cacheDirectory, capabilitiesFileName))
+	if err != nil {
+		return mediatype.NewCapabilities(), nil
+	}
+	persisted := persistedCapabilities{Capabilities: mediatype.NewCapabilities()}
+	if err := json.Unmarshal(cachedBytes, &persisted); err != nil || persisted.ServerVersion != serverVersion.GitVersion {
+		return mediatype.NewCapabilities(), nil
+	}
+	return persisted.Capabilities, nil
+}
+
+// StoreCapabilities persists the media type capability record in the cache directory,
+// tagged with the current server version.
+func (d *CachedDiscoveryClient) StoreCapabilities(capabilities *mediatype.Capabilities) error {
+	serverVersion, err := d.ServerVersion()
+	if err != nil {
+		return err
+	}
+	bytes, err := json.Marshal(persistedCapabilities{
+		ServerVersion: serverVersion.GitVersion,
+		Capabilities:  capabilities,
+	})
+	if err != nil {
+		return err
+	}
+	return d.writeCachedBytes(filepath.Join(d.cacheDirectory, capabilitiesFileName), bytes)
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net"
 	"net/url"
+	"strings"
 	"sync"
 	"syscall"
 
@@ -28,10 +29,12 @@ import (
 
 	errorsutil "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/discovery"
 	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/util/mediatype"
 )
 
 type cacheEntry struct {
@@ -51,6 +54,9 @@ type memCacheClient struct {
 	groupToServerResources map[string]*cacheEntry
 	groupList              *metav1.APIGroupList
 	cacheValid             bool
+	// capabilities is primed from the media types advertised in discovery documents
+	// as they are fetched, and shared with clients that learn support from responses.
+	capabilities *mediatype.Capabilities
 }
 
 // Error Constants
@@ -176,6 +182,14 @@ func (d *memCacheClient) Invalidate() {
 	d.cacheValid = false
 	d.groupToServerResources = nil
 	d.groupList = nil
+	d.capabilities.Reset()
+}
+
+// Capabilities returns the media type capability record maintained by this cache. The
+// same record is returned across refreshes; it is emptied by Invalidate and re-primed
+// from discovery documents as they are fetched.
+func (d *memCacheClient) Capabilities() *mediatype.Capabilities {
+	return d.capabilities
 }
 
 // refreshLocked refreshes the state of cache. The caller must hold d.lock for
@@ -216,9 +230,32 @@ func (d *memCacheClient) refreshLocked() error {
 
 	d.groupToServerResources, d.groupList = rl, gl
 	d.cacheValid = true
+	d.primeCapabilitiesLocked()
 	return nil
 }
 
+// primeCapabilitiesLocked records the media types advertised by each cached resource.
+// The caller must hold d.lock for writing.
+func (d *memCacheClient) primeCapabilitiesLocked() {
+	for groupVersion, entry := range d.groupToServerResources {
+		if entry.err != nil || entry.resourceList == nil {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(groupVersion)
+		if err != nil {
+			continue
+		}
+		for i := range entry.resourceList.APIResources {
+			resource := &entry.resourceList.APIResources[i]
+			if strings.Contains(resource.Name, "/") {
+				// Subresources share the parent resource's endpoint capabilities.
+				continue
+			}
+			d.capabilities.RecordDiscovery(gv.WithResource(resource.Name), resource)
+		}
+	}
+}
+
 func (d *memCacheClient) serverResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
 	r, err := d.delegate.ServerResourcesForGroupVersion(groupVersion)
 	if err != nil {
@@ -239,5 +276,6 @@ func NewMemCacheClient(delegate discovery.DiscoveryInterface) discovery.CachedDi
 	return &memCacheClient{
 		delegate:               delegate,
 		groupToServerResources: map[string]*cacheEntry{},
+		capabilities:           mediatype.NewCapabilities(),
 	}
 }
@@ -25,7 +25,9 @@ import (
 
 	errorsutil "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/util/mediatype"
 )
 
 type resourceMapEntry struct {
@@ -387,3 +389,51 @@ func TestPartialRetryableFailure(t *testing.T) {
 		t.Errorf("Expected %#v, got %#v", e, a)
 	}
 }
+
+func TestCapabilities(t *testing.T) {
+	fake := &fakeDiscovery{
+		groupList: &metav1.APIGroupList{
+			Groups: []metav1.APIGroup{{
+				Name: "astronomy",
+				Versions: []metav1.GroupVersionForDiscovery{{
+					GroupVersion: "astronomy/v8beta1",
+					Version:      "v8beta1",
+				}},
+			}},
+		},
+		resourceMap: map[string]*resourceMapEntry{
+			"astronomy/v8beta1": {
+				list: &metav1.APIResourceList{
+					GroupVersion: "astronomy/v8beta1",
+					APIResources: []metav1.APIResource{{
+						Name:               "dwarfplanets",
+						SingularName:       "dwarfplanet",
+						Namespaced:         true,
+						Kind:               "DwarfPlanet",
+						ResponseMediaTypes: []string{"application/cbor"},
+					}},
+				},
+			},
+		},
+	}
+
+	c := NewMemCacheClient(fake)
+	capabilities := c.(*memCacheClient).Capabilities()
+	gvr := schema.GroupVersionResource{Group: "astronomy", Version: "v8beta1", Resource: "dwarfplanets"}
+
+	if got := capabilities.Supports(gvr, "application/cbor"); got != mediatype.SupportUnknown {
+		t.Fatalf("expected nothing recorded before the cache is populated, got %v", got)
+	}
+
+	if _, err := c.ServerResourcesForGroupVersion("astronomy/v8beta1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := capabilities.Supports(gvr, "application/cbor"); got != mediatype.Supported {
+		t.Fatalf("expected advertised media type to be recorded after refresh, got %v", got)
+	}
+
+	c.Invalidate()
+	if got := capabilities.Supports(gvr, "application/cbor"); got != mediatype.SupportUnknown {
+		t.Fatalf("expected record to be emptied by invalidation, got %v", got)
+	}
+}
@@ -19,6 +19,7 @@ package dynamic
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -30,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 	"k8s.io/apimachinery/pkg/runtime/serializer/streaming"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
@@ -71,6 +73,96 @@ func getClientServer(h func(http.ResponseWriter, *http.Request)) (Interface, *ht
 	return cl, srv, nil
 }
 
+func TestEachListItem(t *testing.T) {
+	tcs := []struct {
+		name      string
+		namespace string
+		path      string
+		resp      []byte
+		stopAfter int
+		want      []*unstructured.Unstructured
+	}{
+		{
+			name: "normal_list",
+			path: "/apis/gtest/vtest/rtest",
+			resp: []byte(`{"apiVersion": "vTest", "kind": "rTestList", "metadata": {"resourceVersion": "10"}, "items": [` +
+				string(getJSON("vTest", "rTest", "item1")) + `,` + string(getJSON("vTest", "rTest", "item2")) + `]}`),
+			want: []*unstructured.Unstructured{
+				getObject("vTest", "rTest", "item1"),
+				getObject("vTest", "rTest", "item2"),
+			},
+		},
+		{
+			name:      "namespaced_list",
+			namespace: "nstest",
+			path:      "/apis/gtest/vtest/namespaces/nstest/rtest",
+			resp: getListJSON("vTest", "rTestList",
+				getJSON("vTest", "rTest", "item1")),
+			want: []*unstructured.Unstructured{
+				getObject("vTest", "rTest", "item1"),
+			},
+		},
+		{
+			name: "empty_items",
+			path: "/apis/gtest/vtest/rtest",
+			resp: []byte(`{"apiVersion": "vTest", "kind": "rTestList", "items": null}`),
+			want: nil,
+		},
+		{
+			name: "callback_error_stops_iteration",
+			path: "/apis/gtest/vtest/rtest",
+			resp: getListJSON("vTest", "rTestList",
+				getJSON("vTest", "rTest", "item1"),
+				getJSON("vTest", "rTest", "item2")),
+			stopAfter: 1,
+			want: []*unstructured.Unstructured{
+				getObject("vTest", "rTest", "item1"),
+			},
+		},
+	}
+	for _, tc := range tcs {
+		resource := schema.GroupVersionResource{Group: "gtest", Version: "vtest", Resource: "rtest"}
+		cl, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "GET" {
+				t.Errorf("EachListItem(%q) got HTTP method %s. wanted GET", tc.name, r.Method)
+			}
+
+			if r.URL.Path != tc.path {
+				t.Errorf("EachListItem(%q) got path %s. wanted %s", tc.name, r.URL.Path, tc.path)
+			}
+
+			w.Header().Set("Content-Type", runtime.ContentTypeJSON)
+			w.Write(tc.resp)
+		})
+		if err != nil {
+			t.Errorf("unexpected error when creating client: %v", err)
+			continue
+		}
+
+		stopErr := fmt.Errorf("stop iteration")
+		var got []*unstructured.Unstructured
+		err = cl.Resource(resource).Namespace(tc.namespace).(ListItemIterator).EachListItem(context.TODO(), metav1.ListOptions{}, func(obj *unstructured.Unstructured) error {
+			got = append(got, obj)
+			if tc.stopAfter > 0 && len(got) == tc.stopAfter {
+				return stopErr
+			}
+			return nil
+		})
+		if tc.stopAfter > 0 {
+			if err != stopErr {
+				t.Errorf("EachListItem(%q) expected callback error, got %v", tc.name, err)
+			}
+		} else if err != nil {
+			t.Errorf("unexpected error when iterating list: %v", err)
+		}
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("EachListItem(%q) want: %v\ngot: %v", tc.name, tc.want, got)
+		}
+		srv.Close()
+	}
+}
+
 func TestList(t *testing.T) {
 	tcs := []struct {
 		name      string
@@ -652,3 +744,140 @@ func TestPatch(t *testing.T) {
 		}
 	}
 }
+
+func TestApply(t *testing.T) {
+	tcs := []struct {
+		name             string
+		rejectCBOR       bool
+		wantContentTypes []string
+	}{
+		{
+			name: "cbor_accepted",
+			wantContentTypes: []string{
+				string(types.ApplyCBORPatchType),
+				string(types.ApplyCBORPatchType),
+			},
+		},
+		{
+			// The first apply is downgraded after a 415 response and the
+			// rejection is remembered, so the second apply skips CBOR entirely.
+			name:       "cbor_rejected",
+			rejectCBOR: true,
+			wantContentTypes: []string{
+				string(types.ApplyCBORPatchType),
+				string(types.ApplyPatchType),
+				string(types.ApplyPatchType),
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			// The server implements just enough server-side apply to exercise the
+			// client: it decodes the apply configuration in whichever encoding was
+			// sent, merges its top-level fields over the stored object, and tracks
+			// one managedFields entry per field manager.
+			var contentTypes []string
+			stored := map[string]interface{}{}
+			var managers []string
+			cl, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "PATCH" {
+					t.Errorf("Apply got HTTP method %s. wanted PATCH", r.Method)
+				}
+				if want := "/apis/gtest/vtest/namespaces/nstest/rtest/obj"; r.URL.Path != want {
+					t.Errorf("Apply got path %s. wanted %s", r.URL.Path, want)
+				}
+				contentType := r.Header.Get("Content-Type")
+				contentTypes = append(contentTypes, contentType)
+				if tc.rejectCBOR && contentType == string(types.ApplyCBORPatchType) {
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+					return
+				}
+				data, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					t.Errorf("unexpected error reading body: %v", err)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				applied := map[string]interface{}{}
+				switch types.PatchType(contentType) {
+				case types.ApplyCBORPatchType:
+					err = direct.Unmarshal(data, &applied)
+				case types.ApplyPatchType:
+					err = json.Unmarshal(data, &applied)
+				default:
+					t.Errorf("unexpected patch content type %q", contentType)
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+					return
+				}
+				if err != nil {
+					t.Errorf("unexpected error decoding %q body: %v", contentType, err)
+					w.WriteHeader(http.StatusUnprocessableEntity)
+					return
+				}
+				for k, v := range applied {
+					stored[k] = v
+				}
+				manager := r.URL.Query().Get("fieldManager")
+				seen := false
+				for _, m := range managers {
+					seen = seen || m == manager
+				}
+				if !seen {
+					managers = append(managers, manager)
+				}
+				entries := make([]interface{}, 0, len(managers))
+				for _, m := range managers {
+					entries = append(entries, map[string]interface{}{"manager": m, "operation": "Apply"})
+				}
+				stored["metadata"].(map[string]interface{})["managedFields"] = entries
+				resp, err := json.Marshal(stored)
+				if err != nil {
+					t.Errorf("unexpected error encoding response: %v", err)
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(resp)
+			})
+			if err != nil {
+				t.Fatalf("unexpected error when creating client: %v", err)
+			}
+			defer srv.Close()
+
+			resource := schema.GroupVersionResource{Group: "gtest", Version: "vtest", Resource: "rtest"}
+			applier := cl.Resource(resource).Namespace("nstest").(Applier)
+
+			first := getObject("gtest/vTest", "rTest", "obj")
+			first.Object["spec"] = map[string]interface{}{"replicas": int64(1)}
+			got, err := applier.Apply(context.TODO(), "obj", first, metav1.PatchOptions{FieldManager: "manager-one"})
+			if err != nil {
+				t.Fatalf("unexpected error from first apply: %v", err)
+			}
+			if replicas, _, err := unstructured.NestedInt64(got.Object, "spec", "replicas"); err != nil || replicas != 1 {
+				t.Errorf("unexpected spec.replicas after first apply: %v, %v", replicas, err)
+			}
+
+			second := getObject("gtest/vTest", "rTest", "obj")
+			second.Object["data"] = map[string]interface{}{"key": "value"}
+			got, err = applier.Apply(context.TODO(), "obj", second, metav1.PatchOptions{FieldManager: "manager-two"})
+			if err != nil {
+				t.Fatalf("unexpected error from second apply: %v", err)
+			}
+			// Fields applied by the first manager must survive the second apply.
+			if replicas, _, err := unstructured.NestedInt64(got.Object, "spec", "replicas"); err != nil || replicas != 1 {
+				t.Errorf("unexpected spec.replicas after second apply: %v, %v", replicas, err)
+			}
+			if value, _, err := unstructured.NestedString(got.Object, "data", "key"); err != nil || value != "value" {
+				t.Errorf("unexpected data.key after second apply: %q, %v", value, err)
+			}
+			managedFields, _, err := unstructured.NestedSlice(got.Object, "metadata", "managedFields")
+			if err != nil || len(managedFields) != 2 {
+				t.Errorf("expected two managedFields entries, got %v (%v)", managedFields, err)
+			}
+
+			if !reflect.DeepEqual(contentTypes, tc.wantContentTypes) {
+				t.Errorf("unexpected request content types: got %v, want %v", contentTypes, tc.wantContentTypes)
+			}
+		})
+	}
+}
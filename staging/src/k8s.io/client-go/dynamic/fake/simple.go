@@ -318,6 +318,21 @@ func (c *dynamicResourceClient) List(ctx context.Context, opts metav1.ListOption
 	return list, nil
 }
 
+// EachListItem implements dynamic.ListItemIterator. The fake tracker always materializes
+// the entire list, so this provides only API parity with the real client.
+func (c *dynamicResourceClient) EachListItem(ctx context.Context, opts metav1.ListOptions, fn func(*unstructured.Unstructured) error) error {
+	list, err := c.List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for i := range list.Items {
+		if err := fn(&list.Items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (c *dynamicResourceClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
 	switch {
 	case len(c.namespace) == 0:
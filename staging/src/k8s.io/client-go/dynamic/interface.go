@@ -42,6 +42,27 @@ type ResourceInterface interface {
 	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error)
 }
 
+// Applier is implemented by resource clients that can submit server-side apply
+// configurations. The apply configuration is sent encoded as CBOR unless the resource is
+// known not to accept it, in which case the client falls back to the YAML/JSON apply
+// patch type and remembers the rejection for subsequent calls.
+type Applier interface {
+	// Apply performs a server-side apply of obj. The FieldManager field of options is
+	// required. The returned object reflects the applied state, including the field
+	// management metadata maintained by the server.
+	Apply(ctx context.Context, name string, obj *unstructured.Unstructured, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error)
+}
+
+// ListItemIterator is implemented by resource clients that can visit the items of a list
+// response one at a time as they are decoded, without materializing the entire list in
+// memory. Callers processing very large lists should prefer it over List.
+type ListItemIterator interface {
+	// EachListItem retrieves the collection of objects selected by opts and calls fn once
+	// for each item as it is decoded from the response stream. If fn returns an error,
+	// iteration stops and that error is returned.
+	EachListItem(ctx context.Context, opts metav1.ListOptions, fn func(*unstructured.Unstructured) error) error
+}
+
 type NamespaceableResourceInterface interface {
 	Namespace(string) ResourceInterface
 	ResourceInterface
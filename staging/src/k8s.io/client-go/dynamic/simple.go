@@ -18,20 +18,30 @@ package dynamic
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
 	"k8s.io/apimachinery/pkg/types"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/mediatype"
 )
 
 type dynamicClient struct {
 	client *rest.RESTClient
+	// capabilities records which apply patch types each resource has accepted or
+	// rejected, so that the CBOR encoding is not retried against resources that
+	// returned 415 Unsupported Media Type for it.
+	capabilities *mediatype.Capabilities
 }
 
 var _ Interface = &dynamicClient{}
@@ -71,7 +81,7 @@ func NewForConfig(inConfig *rest.Config) (Interface, error) {
 		return nil, err
 	}
 
-	return &dynamicClient{client: restClient}, nil
+	return &dynamicClient{client: restClient, capabilities: mediatype.NewCapabilities()}, nil
 }
 
 type dynamicResourceClient struct {
@@ -274,6 +284,76 @@ func (c *dynamicResourceClient) List(ctx context.Context, opts metav1.ListOption
 	return list, nil
 }
 
+// EachListItem retrieves the collection of objects selected by opts as a stream and calls
+// fn once per item as it is decoded, so that no more than one item is held in memory at a
+// time. List metadata other than the items is discarded.
+func (c *dynamicResourceClient) EachListItem(ctx context.Context, opts metav1.ListOptions, fn func(*unstructured.Unstructured) error) error {
+	stream, err := c.client.client.Get().AbsPath(c.makeURLSegments("")...).SpecificallyVersionedParams(&opts, dynamicParameterCodec, versionV1).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	return streamListItems(stream, fn)
+}
+
+// streamListItems incrementally decodes the items of a JSON list document from r, calling
+// fn once per decoded item. Fields of the list other than items are skipped.
+func streamListItems(r io.Reader, fn func(*unstructured.Unstructured) error) error {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("expected list to be a JSON object, got %v", tok)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("expected JSON object key, got %v", tok)
+		}
+		if key != "items" {
+			// buffer and discard the values of all other list fields
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+		tok, err = dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok == nil {
+			// "items": null
+			continue
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return fmt.Errorf("expected items to be a JSON array, got %v", tok)
+		}
+		for dec.More() {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return err
+			}
+			item := make(map[string]interface{})
+			if err := utiljson.Unmarshal(raw, &item); err != nil {
+				return err
+			}
+			if err := fn(&unstructured.Unstructured{Object: item}); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
 func (c *dynamicResourceClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
 	opts.Watch = true
 	return c.client.client.Get().AbsPath(c.makeURLSegments("")...).
@@ -305,6 +385,54 @@ func (c *dynamicResourceClient) Patch(ctx context.Context, name string, pt types
 	return uncastObj.(*unstructured.Unstructured), nil
 }
 
+func (c *dynamicResourceClient) Apply(ctx context.Context, name string, obj *unstructured.Unstructured, opts metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if len(name) == 0 {
+		return nil, fmt.Errorf("name is required")
+	}
+	patchType, ok := c.client.capabilities.PreferredMediaType(c.resource, string(types.ApplyCBORPatchType), string(types.ApplyPatchType))
+	if !ok {
+		patchType = string(types.ApplyPatchType)
+	}
+	for {
+		var outBytes []byte
+		var err error
+		if types.PatchType(patchType) == types.ApplyCBORPatchType {
+			outBytes, err = direct.Marshal(obj.Object)
+		} else {
+			outBytes, err = runtime.Encode(unstructured.UnstructuredJSONScheme, obj)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result := c.client.client.
+			Patch(types.PatchType(patchType)).
+			AbsPath(append(c.makeURLSegments(name), subresources...)...).
+			Body(outBytes).
+			SpecificallyVersionedParams(&opts, dynamicParameterCodec, versionV1).
+			Do(ctx)
+		if err := result.Error(); err != nil {
+			var statusCode int
+			result.StatusCode(&statusCode)
+			if statusCode == http.StatusUnsupportedMediaType && types.PatchType(patchType) == types.ApplyCBORPatchType {
+				c.client.capabilities.RecordUnsupported(c.resource, patchType)
+				patchType = string(types.ApplyPatchType)
+				continue
+			}
+			return nil, err
+		}
+		c.client.capabilities.RecordSupported(c.resource, patchType)
+		retBytes, err := result.Raw()
+		if err != nil {
+			return nil, err
+		}
+		uncastObj, err := runtime.Decode(unstructured.UnstructuredJSONScheme, retBytes)
+		if err != nil {
+			return nil, err
+		}
+		return uncastObj.(*unstructured.Unstructured), nil
+	}
+}
+
 func (c *dynamicResourceClient) makeURLSegments(name string) []string {
 	url := []string{}
 	if len(c.resource.Group) == 0 {
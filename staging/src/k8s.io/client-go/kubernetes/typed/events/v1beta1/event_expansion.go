@@ -36,6 +36,9 @@ type EventExpansion interface {
 	// PatchWithEventNamespace is the same as an Update
 	// except that it sends the request to the event.Namespace.
 	PatchWithEventNamespace(event *v1beta1.Event, data []byte) (*v1beta1.Event, error)
+	// CreateBatchWithEventNamespace creates every event in the list with a single
+	// request sent to the namespace shared by all of the events.
+	CreateBatchWithEventNamespace(list *v1beta1.EventList) (*v1beta1.EventList, error)
 }
 
 // CreateWithEventNamespace makes a new event.
@@ -57,6 +60,35 @@ func (e *events) CreateWithEventNamespace(event *v1beta1.Event) (*v1beta1.Event,
 	return result, err
 }
 
+// CreateBatchWithEventNamespace creates every event in the list with a single request.
+// Returns the copy of the list the server returns, or an error. Every event in the list
+// must have the same namespace, which is deduced from the events and must either match
+// this event client's namespace or this event client must have been created with the ""
+// namespace. Batches are not transactional: when an error is returned, events appearing
+// earlier in the list than the rejected one may have been created.
+func (e *events) CreateBatchWithEventNamespace(list *v1beta1.EventList) (*v1beta1.EventList, error) {
+	if len(list.Items) == 0 {
+		return nil, fmt.Errorf("can't create an empty batch of events")
+	}
+	namespace := list.Items[0].Namespace
+	for i := range list.Items {
+		if list.Items[i].Namespace != namespace {
+			return nil, fmt.Errorf("can't create a batch of events spanning namespaces '%v' and '%v'", namespace, list.Items[i].Namespace)
+		}
+	}
+	if e.ns != "" && namespace != e.ns {
+		return nil, fmt.Errorf("can't create events with namespace '%v' in namespace '%v'", namespace, e.ns)
+	}
+	result := &v1beta1.EventList{}
+	err := e.client.Post().
+		NamespaceIfScoped(namespace, len(namespace) > 0).
+		Resource("events").
+		Body(list).
+		Do(context.TODO()).
+		Into(result)
+	return result, err
+}
+
 // UpdateWithEventNamespace modifies an existing event.
 // It returns the copy of the event that the server returns, or an error.
 // The namespace and key to update the event within is deduced from the event.
@@ -50,6 +50,20 @@ func (c *FakeEvents) UpdateWithEventNamespace(event *v1beta1.Event) (*v1beta1.Ev
 	return obj.(*v1beta1.Event), err
 }
 
+// CreateBatchWithEventNamespace records a create action for each event in the list.
+// Returns the list of events the reactions return, or an error.
+func (c *FakeEvents) CreateBatchWithEventNamespace(list *v1beta1.EventList) (*v1beta1.EventList, error) {
+	result := &v1beta1.EventList{}
+	for i := range list.Items {
+		event, err := c.CreateWithEventNamespace(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, *event)
+	}
+	return result, nil
+}
+
 // PatchWithEventNamespace patches an existing event. Returns the copy of the event the server returns, or an error.
 func (c *FakeEvents) PatchWithEventNamespace(event *v1beta1.Event, data []byte) (*v1beta1.Event, error) {
 	pt := types.StrategicMergePatchType
@@ -28,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/mediatype"
 )
 
 const (
@@ -37,6 +38,20 @@ const (
 	envBackoffDuration = "KUBE_CLIENT_BACKOFF_DURATION"
 )
 
+// contentTypeFallbackTTL bounds how long a client avoids a request media type after the
+// server rejects it, so that a server that begins accepting the media type is retried.
+const contentTypeFallbackTTL = 5 * time.Minute
+
+// sharedContentTypeFallback is shared by every RESTClient in the process, so a downgrade
+// learned through one client, typed or dynamic, applies to all clients talking to the same
+// endpoints.
+var sharedContentTypeFallback = mediatype.NewFallbackCache(contentTypeFallbackTTL)
+
+// sharedDecodePreference is shared by every RESTClient in the process that enables
+// AdaptiveAcceptContentTypes, so decode costs observed through one client inform the
+// accept order used by all of them.
+var sharedDecodePreference = mediatype.NewDecodePreference()
+
 // Interface captures the set of operations for generically interacting with Kubernetes REST apis.
 type Interface interface {
 	GetRateLimiter() flowcontrol.RateLimiter
@@ -69,6 +84,27 @@ type ClientContentConfig struct {
 	// Negotiator is used for obtaining encoders and decoders for multiple
 	// supported media types.
 	Negotiator runtime.ClientNegotiator
+	// DisableCompressionForContentTypes lists response media types for which the client
+	// asks the server not to compress the response body, overriding the transport's
+	// compression behavior for requests that accept one of them. Dense binary encodings
+	// like protobuf or CBOR often cost more CPU to gzip than the transfer saves.
+	DisableCompressionForContentTypes []string
+	// AdaptiveAcceptContentTypes reorders the media types of AcceptContentTypes per
+	// resource, preferring those that have been observed to decode fastest for that
+	// resource. Observations are shared by every client in the process that opts in.
+	// The set of acceptable media types never changes, only the preference order.
+	AdaptiveAcceptContentTypes bool
+}
+
+// disablesCompressionFor reports whether the compression override applies to the given
+// media type.
+func (c ClientContentConfig) disablesCompressionFor(mediaType string) bool {
+	for _, t := range c.DisableCompressionForContentTypes {
+		if t == mediaType {
+			return true
+		}
+	}
+	return false
 }
 
 // RESTClient imposes common Kubernetes API conventions on a set of resource paths.
@@ -94,6 +130,14 @@ type RESTClient struct {
 	// overridden.
 	rateLimiter flowcontrol.RateLimiter
 
+	// contentTypeFallback records request media types rejected by the server so that
+	// later requests downgrade instead of repeating the rejection.
+	contentTypeFallback *mediatype.FallbackCache
+
+	// decodePreference, when non-nil, reorders the accepted media types of each request
+	// by the decode cost observed for its resource.
+	decodePreference *mediatype.DecodePreference
+
 	// Set specific behavior of the client.  If not set http.DefaultClient will be used.
 	Client *http.Client
 }
@@ -112,12 +156,19 @@ func NewRESTClient(baseURL *url.URL, versionedAPIPath string, config ClientConte
 	base.RawQuery = ""
 	base.Fragment = ""
 
+	var decodePreference *mediatype.DecodePreference
+	if config.AdaptiveAcceptContentTypes {
+		decodePreference = sharedDecodePreference
+	}
+
 	return &RESTClient{
-		base:             &base,
-		versionedAPIPath: versionedAPIPath,
-		content:          config,
-		createBackoffMgr: readExpBackoffConfig,
-		rateLimiter:      rateLimiter,
+		base:                &base,
+		versionedAPIPath:    versionedAPIPath,
+		content:             config,
+		createBackoffMgr:    readExpBackoffConfig,
+		rateLimiter:         rateLimiter,
+		contentTypeFallback: sharedContentTypeFallback,
+		decodePreference:    decodePreference,
 
 		Client: client,
 	}, nil
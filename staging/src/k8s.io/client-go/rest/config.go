@@ -38,6 +38,7 @@ import (
 	"k8s.io/client-go/transport"
 	certutil "k8s.io/client-go/util/cert"
 	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/metricadapters"
 	"k8s.io/klog/v2"
 )
 
@@ -281,6 +282,23 @@ type ContentConfig struct {
 	// TODO: NegotiatedSerializer will be phased out as internal clients are removed
 	//   from Kubernetes.
 	NegotiatedSerializer runtime.NegotiatedSerializer
+	// DisableCompressionForContentTypes lists response media types for which the client
+	// asks the server not to compress the response body, regardless of the transport's
+	// compression behavior. This is useful for encodings that are already dense, such
+	// as protobuf or CBOR, where gzip typically costs more than it saves.
+	DisableCompressionForContentTypes []string
+	// AllowUnrecognizedResponseContentTypes enables a decode fallback for responses
+	// whose Content-Type header is missing or names an unsupported media type, as can
+	// happen when an intermediary proxy rewrites or strips headers. The payload is
+	// sniffed for a known encoding prefix and treated as JSON otherwise. Has no effect
+	// unless NegotiatedSerializer can produce a universal deserializer.
+	AllowUnrecognizedResponseContentTypes bool
+	// AdaptiveAcceptContentTypes reorders the media types of AcceptContentTypes per
+	// resource, preferring those that have been observed to decode fastest for that
+	// resource. The set of acceptable media types never changes, only the preference
+	// order. Observations are shared by every client in the process that opts in and
+	// are exported through the registered client metrics.
+	AdaptiveAcceptContentTypes bool
 }
 
 // RESTClientFor returns a RESTClient that satisfies the requested attributes on a client Config
@@ -333,15 +351,33 @@ func RESTClientFor(config *Config) (*RESTClient, error) {
 		gv = *config.GroupVersion
 	}
 	clientContent := ClientContentConfig{
-		AcceptContentTypes: config.AcceptContentTypes,
-		ContentType:        config.ContentType,
-		GroupVersion:       gv,
-		Negotiator:         runtime.NewClientNegotiator(config.NegotiatedSerializer, gv),
+		AcceptContentTypes:                config.AcceptContentTypes,
+		ContentType:                       config.ContentType,
+		GroupVersion:                      gv,
+		Negotiator:                        negotiatorFor(config, gv),
+		DisableCompressionForContentTypes: config.DisableCompressionForContentTypes,
+		AdaptiveAcceptContentTypes:        config.AdaptiveAcceptContentTypes,
 	}
 
 	return NewRESTClient(baseURL, versionedAPIPath, clientContent, rateLimiter, httpClient)
 }
 
+// negotiatorFor returns the client negotiator for the config's serializer, with a
+// payload-sniffing decode fallback when the config enables one and the serializer can
+// provide it. Encoders are instrumented to report payload sizes to the registered
+// request payload metric.
+func negotiatorFor(config *Config, gv schema.GroupVersion) runtime.ClientNegotiator {
+	var negotiator runtime.ClientNegotiator
+	if u, ok := config.NegotiatedSerializer.(interface {
+		UniversalDeserializer() runtime.Decoder
+	}); ok && config.AllowUnrecognizedResponseContentTypes {
+		negotiator = runtime.NewClientNegotiatorWithFallbackDecoder(config.NegotiatedSerializer, gv, u.UniversalDeserializer())
+	} else {
+		negotiator = runtime.NewClientNegotiator(config.NegotiatedSerializer, gv)
+	}
+	return metricadapters.NewObservingNegotiator(negotiator)
+}
+
 // UnversionedRESTClientFor is the same as RESTClientFor, except that it allows
 // the config.Version to be empty.
 func UnversionedRESTClientFor(config *Config) (*RESTClient, error) {
@@ -387,10 +423,12 @@ func UnversionedRESTClientFor(config *Config) (*RESTClient, error) {
 		gv = *config.GroupVersion
 	}
 	clientContent := ClientContentConfig{
-		AcceptContentTypes: config.AcceptContentTypes,
-		ContentType:        config.ContentType,
-		GroupVersion:       gv,
-		Negotiator:         runtime.NewClientNegotiator(config.NegotiatedSerializer, gv),
+		AcceptContentTypes:                config.AcceptContentTypes,
+		ContentType:                       config.ContentType,
+		GroupVersion:                      gv,
+		Negotiator:                        negotiatorFor(config, gv),
+		DisableCompressionForContentTypes: config.DisableCompressionForContentTypes,
+		AdaptiveAcceptContentTypes:        config.AdaptiveAcceptContentTypes,
 	}
 
 	return NewRESTClient(baseURL, versionedAPIPath, clientContent, rateLimiter, httpClient)
@@ -110,6 +110,13 @@ type Request struct {
 	// output
 	err  error
 	body io.Reader
+
+	// bodyMediaType is the media type the body was encoded as, when the client chose the
+	// encoding itself. It is left empty for caller-provided bodies. bodyHost and bodyPath
+	// identify the resource collection the choice was made for.
+	bodyMediaType string
+	bodyHost      string
+	bodyPath      string
 }
 
 // NewRequest creates a new request helper object for accessing runtime.Objects on a server.
@@ -149,9 +156,31 @@ func NewRequest(c *RESTClient) *Request {
 	case len(c.content.ContentType) > 0:
 		r.SetHeader("Accept", c.content.ContentType+", */*")
 	}
+	if c.content.disablesCompressionFor(preferredAcceptType(c.content)) {
+		// An explicit Accept-Encoding keeps the transport from requesting gzip on this
+		// request's behalf, and identity tells the server not to compress.
+		r.SetHeader("Accept-Encoding", "identity")
+	}
 	return r
 }
 
+// preferredAcceptType returns the media type the client most prefers to receive, which
+// is the first entry of AcceptContentTypes if set and the request content type otherwise.
+func preferredAcceptType(content ClientContentConfig) string {
+	accept := content.AcceptContentTypes
+	if len(accept) == 0 {
+		accept = content.ContentType
+	}
+	if i := strings.Index(accept, ","); i >= 0 {
+		accept = accept[:i]
+	}
+	mediaType, _, err := mime.ParseMediaType(accept)
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
 // NewRequestWithClient creates a Request with an embedded RESTClient for use in test scenarios.
 func NewRequestWithClient(base *url.URL, versionedAPIPath string, content ClientContentConfig, client *http.Client) *Request {
 	return NewRequest(&RESTClient{
@@ -435,7 +464,19 @@ func (r *Request) Body(obj interface{}) *Request {
 		if reflect.ValueOf(t).IsNil() {
 			return r
 		}
-		encoder, err := r.c.content.Negotiator.Encoder(r.c.content.ContentType, nil)
+		contentType := r.c.content.ContentType
+		if r.c.contentTypeFallback != nil && len(contentType) > 0 && contentType != runtime.ContentTypeJSON {
+			// If the server recently rejected the configured media type for this
+			// endpoint, downgrade to JSON instead of repeating the rejection. The
+			// resource collection, not the full request path, identifies the
+			// endpoint: whether a media type is accepted does not vary between
+			// objects of the same resource, and keying by collection lets a
+			// rejection learned on one object apply to the rest while keeping
+			// the cache's size proportional to the number of resources.
+			r.bodyHost, r.bodyPath = r.URL().Host, path.Join(r.pathPrefix, r.resource, r.subresource)
+			contentType = r.c.contentTypeFallback.ContentType(r.bodyHost, r.bodyPath, contentType, runtime.ContentTypeJSON)
+		}
+		encoder, err := r.c.content.Negotiator.Encoder(contentType, nil)
 		if err != nil {
 			r.err = err
 			return r
@@ -447,7 +488,8 @@ func (r *Request) Body(obj interface{}) *Request {
 		}
 		glogBody("Request Body", data)
 		r.body = bytes.NewReader(data)
-		r.SetHeader("Content-Type", r.c.content.ContentType)
+		r.SetHeader("Content-Type", contentType)
+		r.bodyMediaType = contentType
 	default:
 		r.err = fmt.Errorf("unknown type used for body: %+v", obj)
 	}
@@ -718,6 +760,18 @@ func updateURLMetrics(req *Request, resp *http.Response, err error) {
 	} else {
 		//Metrics for failure codes
 		metrics.RequestResult.Increment(strconv.Itoa(resp.StatusCode), req.verb, url)
+
+		// The transport strips Content-Encoding from responses it decompressed itself,
+		// so that case is recovered from the Uncompressed flag.
+		encoding := resp.Header.Get("Content-Encoding")
+		if len(encoding) == 0 && resp.Uncompressed {
+			encoding = "gzip"
+		}
+		contentType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if err != nil {
+			contentType = "<unknown>"
+		}
+		metrics.ResponseCompression.Observe(contentType, encoding)
 	}
 }
 
@@ -826,6 +880,14 @@ func (r *Request) request(ctx context.Context, fn func(*http.Request, *http.Resp
 		return err
 	}
 
+	if r.c.decodePreference != nil && len(r.resource) > 0 && len(r.c.content.AcceptContentTypes) > 0 &&
+		r.headers.Get("Accept") == r.c.content.AcceptContentTypes {
+		// Reorder the configured accepted media types by the decode cost observed for
+		// this resource. An Accept header replaced by the caller is left alone.
+		gvr := r.c.content.GroupVersion.WithResource(r.resource)
+		r.SetHeader("Accept", r.c.decodePreference.OrderAcceptContentTypes(gvr, r.c.content.AcceptContentTypes))
+	}
+
 	client := r.c.Client
 	if client == nil {
 		client = http.DefaultClient
@@ -965,6 +1027,13 @@ func (r *Request) DoRaw(ctx context.Context) ([]byte, error) {
 
 // transformResponse converts an API response into a structured API object
 func (r *Request) transformResponse(resp *http.Response, req *http.Request) Result {
+	if resp.StatusCode == http.StatusUnsupportedMediaType && r.c.contentTypeFallback != nil &&
+		len(r.bodyMediaType) > 0 && r.bodyMediaType != runtime.ContentTypeJSON {
+		// The server refused the request body encoding. Remember the rejection so that
+		// subsequent requests to this endpoint downgrade instead of failing the same way.
+		r.c.contentTypeFallback.RecordRejection(r.bodyHost, r.bodyPath, r.bodyMediaType)
+	}
+
 	var body []byte
 	if resp.Body != nil {
 		data, err := ioutil.ReadAll(resp.Body)
@@ -997,6 +1066,7 @@ func (r *Request) transformResponse(resp *http.Response, req *http.Request) Resu
 
 	// verify the content type is accurate
 	var decoder runtime.Decoder
+	var decodeObserver func(sizeBytes int, latency time.Duration)
 	contentType := resp.Header.Get("Content-Type")
 	if len(contentType) == 0 {
 		contentType = r.c.content.ContentType
@@ -1022,6 +1092,19 @@ func (r *Request) transformResponse(resp *http.Response, req *http.Request) Resu
 				statusCode:  resp.StatusCode,
 			}
 		}
+		if len(r.resource) > 0 {
+			// Report what each decode costs, per resource and wire format, to the
+			// registered metrics and to the accept order preference when one is
+			// in use.
+			gvr := r.c.content.GroupVersion.WithResource(r.resource)
+			preference := r.c.decodePreference
+			decodeObserver = func(sizeBytes int, latency time.Duration) {
+				metrics.ResponseDecode.Observe(gvr.GroupVersion().String(), gvr.Resource, mediaType, sizeBytes, latency)
+				if preference != nil {
+					preference.Observe(gvr, mediaType, sizeBytes, latency)
+				}
+			}
+		}
 	}
 
 	switch {
@@ -1033,19 +1116,21 @@ func (r *Request) transformResponse(resp *http.Response, req *http.Request) Resu
 		retryAfter, _ := retryAfterSeconds(resp)
 		err := r.newUnstructuredResponseError(body, isTextResponse(resp), resp.StatusCode, req.Method, retryAfter)
 		return Result{
-			body:        body,
-			contentType: contentType,
-			statusCode:  resp.StatusCode,
-			decoder:     decoder,
-			err:         err,
+			body:           body,
+			contentType:    contentType,
+			statusCode:     resp.StatusCode,
+			decoder:        decoder,
+			decodeObserver: decodeObserver,
+			err:            err,
 		}
 	}
 
 	return Result{
-		body:        body,
-		contentType: contentType,
-		statusCode:  resp.StatusCode,
-		decoder:     decoder,
+		body:           body,
+		contentType:    contentType,
+		statusCode:     resp.StatusCode,
+		decoder:        decoder,
+		decodeObserver: decodeObserver,
 	}
 }
 
@@ -1186,6 +1271,9 @@ type Result struct {
 	statusCode  int
 
 	decoder runtime.Decoder
+	// decodeObserver, when set, is called with the size of the body and the time it
+	// took to decode whenever Get or Into decodes the body.
+	decodeObserver func(sizeBytes int, latency time.Duration)
 }
 
 // Raw returns the raw result.
@@ -1206,7 +1294,11 @@ func (r Result) Get() (runtime.Object, error) {
 	}
 
 	// decode, but if the result is Status return that as an error instead.
+	start := time.Now()
 	out, _, err := r.decoder.Decode(r.body, nil, nil)
+	if r.decodeObserver != nil {
+		r.decodeObserver(len(r.body), time.Since(start))
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -1243,7 +1335,11 @@ func (r Result) Into(obj runtime.Object) error {
 			r.statusCode, r.contentType)
 	}
 
+	start := time.Now()
 	out, _, err := r.decoder.Decode(r.body, nil, obj)
+	if r.decodeObserver != nil {
+		r.decodeObserver(len(r.body), time.Since(start))
+	}
 	if err != nil || out == obj {
 		return err
 	}
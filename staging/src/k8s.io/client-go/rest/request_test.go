@@ -44,6 +44,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	cborserializer "k8s.io/apimachinery/pkg/runtime/serializer/cbor"
 	"k8s.io/apimachinery/pkg/runtime/serializer/streaming"
 	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/diff"
@@ -53,6 +55,7 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	restclientwatch "k8s.io/client-go/rest/watch"
 	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/mediatype"
 	utiltesting "k8s.io/client-go/util/testing"
 )
 
@@ -67,6 +70,46 @@ func TestNewRequestSetsAccept(t *testing.T) {
 	}
 }
 
+func TestNewRequestSetsAcceptEncoding(t *testing.T) {
+	testCases := []struct {
+		content  ClientContentConfig
+		expected string
+	}{
+		{
+			content:  ClientContentConfig{ContentType: "application/vnd.kubernetes.protobuf"},
+			expected: "",
+		},
+		{
+			content: ClientContentConfig{
+				ContentType:                       "application/vnd.kubernetes.protobuf",
+				DisableCompressionForContentTypes: []string{"application/vnd.kubernetes.protobuf", "application/cbor"},
+			},
+			expected: "identity",
+		},
+		{
+			content: ClientContentConfig{
+				AcceptContentTypes:                "application/cbor;q=0.9, application/json",
+				ContentType:                       "application/json",
+				DisableCompressionForContentTypes: []string{"application/cbor"},
+			},
+			expected: "identity",
+		},
+		{
+			content: ClientContentConfig{
+				ContentType:                       "application/json",
+				DisableCompressionForContentTypes: []string{"application/cbor"},
+			},
+			expected: "",
+		},
+	}
+	for _, tc := range testCases {
+		r := NewRequestWithClient(&url.URL{Path: "/path/"}, "", tc.content, nil).Verb("get")
+		if actual := r.headers.Get("Accept-Encoding"); actual != tc.expected {
+			t.Errorf("%#v: expected Accept-Encoding %q, got %q", tc.content, tc.expected, actual)
+		}
+	}
+}
+
 func clientForFunc(fn clientFunc) *http.Client {
 	return &http.Client{
 		Transport: fn,
@@ -1567,6 +1610,83 @@ func TestDoRequestNewWayObj(t *testing.T) {
 	fakeHandler.ValidateRequest(t, requestURL, "POST", &tmpStr)
 }
 
+func TestRequestAdaptiveAcceptContentTypes(t *testing.T) {
+	var accepts []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		accepts = append(accepts, req.Header.Get("Accept"))
+		w.Header().Set("Content-Type", runtime.ContentTypeJSON)
+		w.Write([]byte(`{"kind":"Pod","apiVersion":"v1","metadata":{"name":"foo"}}`))
+	}))
+	defer testServer.Close()
+
+	configured := "application/cbor, application/json"
+	gv := v1.SchemeGroupVersion
+	c := testRESTClientWithConfig(t, testServer, ClientContentConfig{
+		AcceptContentTypes: configured,
+		ContentType:        runtime.ContentTypeJSON,
+		GroupVersion:       gv,
+		Negotiator:         runtime.NewClientNegotiator(scheme.Codecs.WithoutConversion(), gv),
+	})
+	// Use a preference private to the test instead of the process-wide one.
+	c.decodePreference = mediatype.NewDecodePreference()
+
+	// The server only ever answers with JSON, so every decode observation is for
+	// JSON, and enough of them move it ahead of the configured first choice.
+	for i := 0; i < 20; i++ {
+		if _, err := c.Verb("GET").Resource("pods").Do(context.Background()).Get(); err != nil {
+			t.Fatalf("unexpected error on request %d: %v", i, err)
+		}
+	}
+
+	if accepts[0] != configured {
+		t.Errorf("expected the first request to use the configured accept order %q, got %q", configured, accepts[0])
+	}
+	if expected := "application/json, application/cbor"; accepts[len(accepts)-1] != expected {
+		t.Errorf("expected the last request to use the observed accept order %q, got %q", expected, accepts[len(accepts)-1])
+	}
+}
+
+func TestRequestBodyContentTypeFallback(t *testing.T) {
+	var contentTypes []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		contentTypes = append(contentTypes, req.Header.Get("Content-Type"))
+		if req.Header.Get("Content-Type") == runtime.ContentTypeCBOR {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		w.Header().Set("Content-Type", runtime.ContentTypeJSON)
+		w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Success"}`))
+	}))
+	defer testServer.Close()
+
+	codecs := serializer.NewCodecFactory(scheme.Scheme, serializer.WithSerializer(cborserializer.NewSerializerInfo))
+	gv := v1.SchemeGroupVersion
+	c := testRESTClientWithConfig(t, testServer, ClientContentConfig{
+		ContentType:  runtime.ContentTypeCBOR,
+		GroupVersion: gv,
+		Negotiator:   runtime.NewClientNegotiator(codecs.WithoutConversion(), gv),
+	})
+	// Use a cache private to the test instead of the process-wide one.
+	c.contentTypeFallback = mediatype.NewFallbackCache(time.Minute)
+
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+	if err := c.Verb("POST").Resource("foo").Body(pod).Do(context.Background()).Error(); err == nil {
+		t.Fatal("expected the first request to be rejected")
+	}
+	if err := c.Verb("POST").Resource("foo").Body(pod).Do(context.Background()).Error(); err != nil {
+		t.Fatalf("unexpected error from downgraded request: %v", err)
+	}
+	// The rejection is remembered for the resource collection, so a request for a named
+	// object of the same resource downgrades without repeating the rejected encoding.
+	if err := c.Verb("PUT").Resource("foo").Name("bar").Body(pod).Do(context.Background()).Error(); err != nil {
+		t.Fatalf("unexpected error from downgraded named-object request: %v", err)
+	}
+	expected := []string{runtime.ContentTypeCBOR, runtime.ContentTypeJSON, runtime.ContentTypeJSON}
+	if !reflect.DeepEqual(contentTypes, expected) {
+		t.Errorf("unexpected request content types: got %v, want %v", contentTypes, expected)
+	}
+}
+
 func TestDoRequestNewWayFile(t *testing.T) {
 	reqObj := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
 	reqBodyExpected, err := runtime.Encode(scheme.Codecs.LegacyCodec(v1.SchemeGroupVersion), reqObj)
@@ -32,6 +32,7 @@ import (
 type Decoder struct {
 	decoder         streaming.Decoder
 	embeddedDecoder runtime.Decoder
+	newInto         func() runtime.Object
 }
 
 // NewDecoder creates an Decoder for the given writer and codec.
@@ -42,6 +43,21 @@ func NewDecoder(decoder streaming.Decoder, embeddedDecoder runtime.Decoder) *Dec
 	}
 }
 
+// NewDecoderWithReusableObjects creates a Decoder that decodes the object embedded in
+// each event into a destination obtained from newInto instead of allocating a fresh
+// object per event. Callers that recycle destinations, for example through a sync.Pool,
+// must not offer an object for reuse until they have finished with the event that
+// delivered it. The embedded decoder is responsible for clearing a reused destination
+// between decodes; the CBOR serializer does this, but most serializers leave fields
+// absent from the incoming document at their previous values.
+func NewDecoderWithReusableObjects(decoder streaming.Decoder, embeddedDecoder runtime.Decoder, newInto func() runtime.Object) *Decoder {
+	return &Decoder{
+		decoder:         decoder,
+		embeddedDecoder: embeddedDecoder,
+		newInto:         newInto,
+	}
+}
+
 // Decode blocks until it can return the next object in the reader. Returns an error
 // if the reader is closed or an object can't be decoded.
 func (d *Decoder) Decode() (watch.EventType, runtime.Object, error) {
@@ -59,7 +75,11 @@ func (d *Decoder) Decode() (watch.EventType, runtime.Object, error) {
 		return "", nil, fmt.Errorf("got invalid watch event type: %v", got.Type)
 	}
 
-	obj, err := runtime.Decode(d.embeddedDecoder, got.Object.Raw)
+	var into runtime.Object
+	if d.newInto != nil {
+		into = d.newInto()
+	}
+	obj, _, err := d.embeddedDecoder.Decode(got.Object.Raw, nil, into)
 	if err != nil {
 		return "", nil, fmt.Errorf("unable to decode watch event: %v", err)
 	}
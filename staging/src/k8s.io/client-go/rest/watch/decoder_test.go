@@ -17,6 +17,7 @@ limitations under the License.
 package versioned_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
 	"testing"
@@ -26,6 +27,7 @@ import (
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	cborserializer "k8s.io/apimachinery/pkg/runtime/serializer/cbor"
 	runtimejson "k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/apimachinery/pkg/runtime/serializer/streaming"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -97,6 +99,74 @@ func TestDecoder(t *testing.T) {
 	}
 }
 
+func TestDecoderReusableObjects(t *testing.T) {
+	cborSerializer := cborserializer.NewSerializer(cborserializer.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+	embeddedDecoder := scheme.Codecs.WithoutConversion().DecoderToVersion(cborSerializer, v1.SchemeGroupVersion)
+
+	encodePod := func(pod *v1.Pod) []byte {
+		pod.TypeMeta = metav1.TypeMeta{APIVersion: v1.SchemeGroupVersion.String(), Kind: "Pod"}
+		var buf bytes.Buffer
+		if err := cborSerializer.Encode(pod, &buf); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	events := []metav1.WatchEvent{
+		{
+			Type:   string(watch.Added),
+			Object: runtime.RawExtension{Raw: encodePod(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"stale": "true"}}})},
+		},
+		{
+			Type:   string(watch.Added),
+			Object: runtime.RawExtension{Raw: encodePod(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "bar"}})},
+		},
+	}
+
+	out, in := io.Pipe()
+	reused := &v1.Pod{}
+	decoder := restclientwatch.NewDecoderWithReusableObjects(
+		streaming.NewDecoder(cborserializer.Framer.NewFrameReader(out), cborSerializer),
+		embeddedDecoder,
+		func() runtime.Object { return reused },
+	)
+	defer decoder.Close()
+
+	go func() {
+		encoder := streaming.NewEncoder(cborserializer.Framer.NewFrameWriter(in), cborSerializer)
+		for i := range events {
+			if err := encoder.Encode(&events[i]); err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+		}
+		in.Close()
+	}()
+
+	_, got, err := decoder.Decode()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if got != runtime.Object(reused) {
+		t.Fatalf("Expected the decoded object to be the reusable destination, got %#v", got)
+	}
+	if e, a := "foo", reused.Name; e != a {
+		t.Errorf("Expected %v, got %v", e, a)
+	}
+	if e, a := map[string]string{"stale": "true"}, reused.Labels; !apiequality.Semantic.DeepEqual(e, a) {
+		t.Errorf("Expected %v, got %v", e, a)
+	}
+
+	if _, _, err := decoder.Decode(); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if e, a := "bar", reused.Name; e != a {
+		t.Errorf("Expected %v, got %v", e, a)
+	}
+	if reused.Labels != nil {
+		t.Errorf("Expected labels absent from the second event to be cleared, got %v", reused.Labels)
+	}
+}
+
 func TestDecoder_SourceClose(t *testing.T) {
 	out, in := io.Pipe()
 	decoder := restclientwatch.NewDecoder(streaming.NewDecoder(out, getDecoder()), getDecoder())
@@ -0,0 +1,47 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package serializer provides negotiated serializers preconfigured for the media types
+// understood by Kubernetes API servers, for clients that assemble their own rest.Config
+// rather than using a generated clientset.
+package serializer
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	runtimeserializer "k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+)
+
+// NewCodecFactory returns a codec factory for the given scheme that supports
+// application/cbor in addition to the default media types.
+func NewCodecFactory(scheme *runtime.Scheme) runtimeserializer.CodecFactory {
+	return runtimeserializer.NewCodecFactory(scheme, runtimeserializer.WithSerializer(cbor.NewSerializerInfo))
+}
+
+// NewNegotiatedSerializer returns a serializer for the given scheme that supports
+// application/cbor in addition to the default media types and does not perform
+// conversion, suitable for use as the NegotiatedSerializer of a rest.Config.
+func NewNegotiatedSerializer(scheme *runtime.Scheme) runtime.NegotiatedSerializer {
+	return NewCodecFactory(scheme).WithoutConversion()
+}
+
+// NewClientNegotiator returns a client content negotiator for the given scheme and group
+// version that supports application/cbor in addition to the default media types. Objects
+// are encoded to gv without other conversion.
+func NewClientNegotiator(scheme *runtime.Scheme, gv schema.GroupVersion) runtime.ClientNegotiator {
+	return runtime.NewClientNegotiator(NewNegotiatedSerializer(scheme), gv)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serializer
+
+import (
+	"bytes"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestNewClientNegotiator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	negotiator := NewClientNegotiator(scheme, corev1.SchemeGroupVersion)
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+	for _, mediaType := range []string{runtime.ContentTypeJSON, runtime.ContentTypeCBOR} {
+		t.Run(mediaType, func(t *testing.T) {
+			encoder, err := negotiator.Encoder(mediaType, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := encoder.Encode(pod, &buf); err != nil {
+				t.Fatalf("unexpected encode error: %v", err)
+			}
+
+			decoder, err := negotiator.Decoder(mediaType, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			obj, gvk, err := decoder.Decode(buf.Bytes(), nil, nil)
+			if err != nil {
+				t.Fatalf("unexpected decode error: %v", err)
+			}
+			if expected := corev1.SchemeGroupVersion.WithKind("Pod"); *gvk != expected {
+				t.Errorf("expected gvk %v, got %v", expected, gvk)
+			}
+			decoded, ok := obj.(*corev1.Pod)
+			if !ok {
+				t.Fatalf("expected *corev1.Pod, got %T", obj)
+			}
+			if decoded.Name != pod.Name {
+				t.Errorf("expected name %q, got %q", pod.Name, decoded.Name)
+			}
+		})
+	}
+
+	if _, err := negotiator.Decoder("application/vnd.unsupported", nil); err == nil {
+		t.Error("expected an error negotiating a decoder for an unsupported media type")
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/events/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	ref "k8s.io/client-go/tools/reference"
+)
+
+type testEventBatchSink struct {
+	testEventSeriesSink
+	OnCreateBatch func(list *v1beta1.EventList) (*v1beta1.EventList, error)
+}
+
+// CreateBatch records the event list for testing.
+func (t *testEventBatchSink) CreateBatch(list *v1beta1.EventList) (*v1beta1.EventList, error) {
+	if t.OnCreateBatch != nil {
+		return t.OnCreateBatch(list)
+	}
+	return list, nil
+}
+
+func TestStartRecordingToSinkBatchesNewEvents(t *testing.T) {
+	testPod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			SelfLink:  "/api/v1/namespaces/baz/pods/foo",
+			Name:      "foo",
+			Namespace: "baz",
+			UID:       "bar",
+		},
+	}
+	regarding, err := ref.GetPartialReference(scheme.Scheme, testPod, ".spec.containers[1]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	related, err := ref.GetPartialReference(scheme.Scheme, testPod, ".spec.containers[0]")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createEvent := make(chan *v1beta1.Event, 10)
+	patchEvent := make(chan *v1beta1.Event, 10)
+	batchEvent := make(chan *v1beta1.EventList, 10)
+	testEvents := testEventBatchSink{
+		testEventSeriesSink: testEventSeriesSink{
+			OnCreate: func(event *v1beta1.Event) (*v1beta1.Event, error) {
+				createEvent <- event
+				return event, nil
+			},
+			OnPatch: func(event *v1beta1.Event, patch []byte) (*v1beta1.Event, error) {
+				patchEvent <- event
+				return event, nil
+			},
+		},
+		OnCreateBatch: func(list *v1beta1.EventList) (*v1beta1.EventList, error) {
+			batchEvent <- list
+			return list, nil
+		},
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	eventBroadcaster := newBroadcaster(&testEvents, 0, map[eventKey]*v1beta1.Event{})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, "eventTest")
+	eventBroadcaster.StartRecordingToSink(stopCh)
+	recorder.Eventf(regarding, related, v1.EventTypeNormal, "test", "started", "some verbose message: %v", 1)
+	recorder.Eventf(regarding, related, v1.EventTypeNormal, "test", "stopped", "some verbose message: %v", 1)
+
+	// Depending on when the flush period elapses, the two events may arrive in one
+	// batch or two.
+	received := 0
+	for received < 2 {
+		select {
+		case list := <-batchEvent:
+			received += len(list.Items)
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatalf("timeout after %v, received %v of 2 events", wait.ForeverTestTimeout, received)
+		}
+	}
+	if len(createEvent) != 0 {
+		t.Errorf("expected no individual creates when the sink supports batching, but got %v", len(createEvent))
+	}
+
+	// An isomorphic event starts a series, which is recorded immediately by patch
+	// instead of being batched.
+	recorder.Eventf(regarding, related, v1.EventTypeNormal, "test", "started", "some verbose message: %v", 1)
+	select {
+	case actualEvent := <-patchEvent:
+		if actualEvent.Series == nil {
+			t.Errorf("expected event with a series, got: %#v", actualEvent)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatalf("timeout after %v", wait.ForeverTestTimeout)
+	}
+}
+
+func TestFlushBatchGroupsEventsByNamespace(t *testing.T) {
+	hostname, _ := os.Hostname()
+	batchEvent := make(chan *v1beta1.EventList, 10)
+	testEvents := testEventBatchSink{
+		OnCreateBatch: func(list *v1beta1.EventList) (*v1beta1.EventList, error) {
+			batchEvent <- list
+			return list, nil
+		},
+	}
+	eventBroadcaster := newBroadcaster(&testEvents, 0, map[eventKey]*v1beta1.Event{}).(*eventBroadcasterImpl)
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, "k8s.io/kube-foo").(*recorderImpl)
+	for _, namespace := range []string{"foo", "bar"} {
+		testPod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				SelfLink:  "/api/v1/namespaces/" + namespace + "/pods/foo",
+				Name:      "foo",
+				Namespace: namespace,
+				UID:       "bar",
+			},
+		}
+		regarding, err := ref.GetReference(scheme.Scheme, testPod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		event := recorder.makeEvent(regarding, nil, metav1.MicroTime{Time: time.Now()}, v1.EventTypeNormal, "test", "some verbose message: 1", "eventTest", "eventTest-"+hostname, "started")
+		eventBroadcaster.bufferEventForBatch(event, &testEvents)
+	}
+
+	eventBroadcaster.flushBatch(&testEvents)
+	for i := 0; i < 2; i++ {
+		select {
+		case list := <-batchEvent:
+			if len(list.Items) != 1 {
+				t.Errorf("expected one event per namespace, got %v", len(list.Items))
+			}
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatalf("timeout after %v, expected one batch per namespace", wait.ForeverTestTimeout)
+		}
+	}
+}
+
+func TestFlushBatchFallsBackToIndividualCreates(t *testing.T) {
+	hostname, _ := os.Hostname()
+	testPod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			SelfLink:  "/api/v1/namespaces/baz/pods/foo",
+			Name:      "foo",
+			Namespace: "baz",
+			UID:       "bar",
+		},
+	}
+	regarding, err := ref.GetReference(scheme.Scheme, testPod)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createEvent := make(chan *v1beta1.Event, 10)
+	testEvents := testEventBatchSink{
+		testEventSeriesSink: testEventSeriesSink{
+			OnCreate: func(event *v1beta1.Event) (*v1beta1.Event, error) {
+				createEvent <- event
+				return event, nil
+			},
+		},
+		OnCreateBatch: func(list *v1beta1.EventList) (*v1beta1.EventList, error) {
+			return nil, fmt.Errorf("batch rejected")
+		},
+	}
+	eventBroadcaster := newBroadcaster(&testEvents, 0, map[eventKey]*v1beta1.Event{}).(*eventBroadcasterImpl)
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, "k8s.io/kube-foo").(*recorderImpl)
+	for _, action := range []string{"started", "stopped"} {
+		event := recorder.makeEvent(regarding, nil, metav1.MicroTime{Time: time.Now()}, v1.EventTypeNormal, "test", "some verbose message: 1", "eventTest", "eventTest-"+hostname, action)
+		eventBroadcaster.bufferEventForBatch(event, &testEvents)
+	}
+
+	eventBroadcaster.flushBatch(&testEvents)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-createEvent:
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatalf("timeout after %v, expected each event of the failed batch to be created individually", wait.ForeverTestTimeout)
+		}
+	}
+}
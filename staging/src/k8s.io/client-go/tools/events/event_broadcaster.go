@@ -44,6 +44,13 @@ const (
 	finishTime       = 6 * time.Minute
 	refreshTime      = 30 * time.Minute
 	maxQueuedEvents  = 1000
+
+	// When the sink supports batch creation, new events are buffered for up to
+	// batchFlushPeriod or until maxEventBatchSize are pending, whichever comes first.
+	// Event traffic is tolerant of latency, and coalescing a burst of events into one
+	// request cuts per-request overhead.
+	batchFlushPeriod  = time.Second
+	maxEventBatchSize = 100
 )
 
 var defaultSleepDuration = 10 * time.Second
@@ -63,6 +70,11 @@ type eventBroadcasterImpl struct {
 	eventCache    map[eventKey]*v1beta1.Event
 	sleepDuration time.Duration
 	sink          EventSink
+
+	// batchMu guards batch, the events buffered for the next batch creation. Only
+	// used when sink implements EventBatchSink.
+	batchMu sync.Mutex
+	batch   []*v1beta1.Event
 }
 
 // EventSinkImpl wraps EventInterface to implement EventSink.
@@ -87,6 +99,11 @@ func (e *EventSinkImpl) Patch(event *v1beta1.Event, data []byte) (*v1beta1.Event
 	return e.Interface.PatchWithEventNamespace(event, data)
 }
 
+// CreateBatch is the same as CreateBatchWithEventNamespace of the EventExpansion
+func (e *EventSinkImpl) CreateBatch(list *v1beta1.EventList) (*v1beta1.EventList, error) {
+	return e.Interface.CreateBatchWithEventNamespace(list)
+}
+
 // NewBroadcaster Creates a new event broadcaster.
 func NewBroadcaster(sink EventSink) EventBroadcaster {
 	return newBroadcaster(sink, defaultSleepDuration, map[eventKey]*v1beta1.Event{})
@@ -175,6 +192,14 @@ func (e *eventBroadcasterImpl) recordToSink(event *v1beta1.Event, clock clock.Cl
 			return eventCopy
 		}()
 		if evToRecord != nil {
+			// A new event (no series yet) can be buffered and created together with
+			// other new events when the sink supports it. Series counts are always
+			// recorded immediately; they are flushed by patch and dropping one loses
+			// information.
+			if batchSink, ok := e.sink.(EventBatchSink); ok && evToRecord.Series == nil {
+				e.bufferEventForBatch(evToRecord, batchSink)
+				return
+			}
 			recordedEvent := e.attemptRecording(evToRecord)
 			if recordedEvent != nil {
 				recordedEventKey := getKey(recordedEvent)
@@ -186,6 +211,59 @@ func (e *eventBroadcasterImpl) recordToSink(event *v1beta1.Event, clock clock.Cl
 	}()
 }
 
+func (e *eventBroadcasterImpl) bufferEventForBatch(event *v1beta1.Event, sink EventBatchSink) {
+	e.batchMu.Lock()
+	e.batch = append(e.batch, event)
+	full := len(e.batch) >= maxEventBatchSize
+	e.batchMu.Unlock()
+	if full {
+		e.flushBatch(sink)
+	}
+}
+
+// flushBatch creates every buffered event with one request per namespace. If a batch
+// fails, each of its events falls back to being recorded individually, with the same
+// retry behavior it would have had if the sink did not support batching.
+func (e *eventBroadcasterImpl) flushBatch(sink EventBatchSink) {
+	e.batchMu.Lock()
+	batch := e.batch
+	e.batch = nil
+	e.batchMu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+	// A batch creation is addressed to a single namespace.
+	byNamespace := map[string][]v1beta1.Event{}
+	for _, event := range batch {
+		// Making sure that ResourceVersion is empty on creation
+		event.ResourceVersion = ""
+		byNamespace[event.Namespace] = append(byNamespace[event.Namespace], *event)
+	}
+	for _, events := range byNamespace {
+		created, err := sink.CreateBatch(&v1beta1.EventList{Items: events})
+		if err != nil {
+			klog.Errorf("Unable to write event batch: '%v' (will record events individually)", err)
+			for i := range events {
+				event := &events[i]
+				go func() {
+					if recordedEvent := e.attemptRecording(event); recordedEvent != nil {
+						e.mu.Lock()
+						defer e.mu.Unlock()
+						e.eventCache[getKey(recordedEvent)] = recordedEvent
+					}
+				}()
+			}
+			continue
+		}
+		e.mu.Lock()
+		for i := range created.Items {
+			recordedEvent := &created.Items[i]
+			e.eventCache[getKey(recordedEvent)] = recordedEvent
+		}
+		e.mu.Unlock()
+	}
+}
+
 func (e *eventBroadcasterImpl) attemptRecording(event *v1beta1.Event) *v1beta1.Event {
 	tries := 0
 	for {
@@ -300,6 +378,11 @@ func (e *eventBroadcasterImpl) StartRecordingToSink(stopCh <-chan struct{}) {
 	go wait.Until(func() {
 		e.finishSeries()
 	}, finishTime, stopCh)
+	if batchSink, ok := e.sink.(EventBatchSink); ok {
+		go wait.Until(func() {
+			e.flushBatch(batchSink)
+		}, batchFlushPeriod, stopCh)
+	}
 	eventHandler := func(obj runtime.Object) {
 		event, ok := obj.(*v1beta1.Event)
 		if !ok {
@@ -68,3 +68,17 @@ type EventSink interface {
 	Update(event *v1beta1.Event) (*v1beta1.Event, error)
 	Patch(oldEvent *v1beta1.Event, data []byte) (*v1beta1.Event, error)
 }
+
+// EventBatchSink is implemented by sinks that can store several events with a single
+// request. Event traffic is high in volume and tolerant of latency, so a broadcaster
+// recording to a sink that implements EventBatchSink buffers new events briefly and
+// submits them in batches to cut per-request overhead. Sinks that do not implement
+// EventBatchSink receive every event individually.
+type EventBatchSink interface {
+	EventSink
+	// CreateBatch stores every event in the list. All of the events in one batch must
+	// have the same namespace. Batches are not transactional: when an error is
+	// returned, events appearing earlier in the list than the rejected one may have
+	// been stored.
+	CreateBatch(list *v1beta1.EventList) (*v1beta1.EventList, error)
+}
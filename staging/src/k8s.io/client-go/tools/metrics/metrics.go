@@ -46,6 +46,29 @@ type ResultMetric interface {
 	Increment(code string, method string, host string)
 }
 
+// CompressionMetric counts responses partitioned by content type and content encoding,
+// for observing how often each wire format is actually served compressed.
+type CompressionMetric interface {
+	Observe(contentType string, encoding string)
+}
+
+// DecodeMetric observes the time a client spends decoding each response body, along with
+// the body's size in bytes, partitioned by the resource's group-version, the resource,
+// and the wire content type. Decode cost by content type informs which wire format a
+// client should prefer for a resource.
+type DecodeMetric interface {
+	Observe(groupVersion, resource, contentType string, sizeBytes int, latency time.Duration)
+}
+
+// PayloadMetric observes the encoded size in bytes of each object a client sends,
+// partitioned by the object's group-version-kind and the wire content type. For list
+// objects, items is the number of items in the list; for everything else it is zero.
+// Size and shape by kind show which resources would benefit most from a more compact
+// encoding or from paginated lists.
+type PayloadMetric interface {
+	Observe(apiVersion, kind, contentType string, sizeBytes, items int)
+}
+
 var (
 	// ClientCertExpiry is the expiry time of a client certificate
 	ClientCertExpiry ExpiryMetric = noopExpiry{}
@@ -57,6 +80,12 @@ var (
 	RateLimiterLatency LatencyMetric = noopLatency{}
 	// RequestResult is the result metric that rest clients will update.
 	RequestResult ResultMetric = noopResult{}
+	// ResponseCompression is the response compression metric that rest clients will update.
+	ResponseCompression CompressionMetric = noopCompression{}
+	// RequestPayload is the request payload size metric that rest clients will update.
+	RequestPayload PayloadMetric = noopPayload{}
+	// ResponseDecode is the response decode cost metric that rest clients will update.
+	ResponseDecode DecodeMetric = noopDecode{}
 )
 
 // RegisterOpts contains all the metrics to register. Metrics may be nil.
@@ -66,6 +95,9 @@ type RegisterOpts struct {
 	RequestLatency        LatencyMetric
 	RateLimiterLatency    LatencyMetric
 	RequestResult         ResultMetric
+	ResponseCompression   CompressionMetric
+	RequestPayload        PayloadMetric
+	ResponseDecode        DecodeMetric
 }
 
 // Register registers metrics for the rest client to use. This can
@@ -87,6 +119,15 @@ func Register(opts RegisterOpts) {
 		if opts.RequestResult != nil {
 			RequestResult = opts.RequestResult
 		}
+		if opts.ResponseCompression != nil {
+			ResponseCompression = opts.ResponseCompression
+		}
+		if opts.RequestPayload != nil {
+			RequestPayload = opts.RequestPayload
+		}
+		if opts.ResponseDecode != nil {
+			ResponseDecode = opts.ResponseDecode
+		}
 	})
 }
 
@@ -105,3 +146,15 @@ func (noopLatency) Observe(string, url.URL, time.Duration) {}
 type noopResult struct{}
 
 func (noopResult) Increment(string, string, string) {}
+
+type noopCompression struct{}
+
+func (noopCompression) Observe(string, string) {}
+
+type noopPayload struct{}
+
+func (noopPayload) Observe(string, string, string, int, int) {}
+
+type noopDecode struct{}
+
+func (noopDecode) Observe(string, string, string, int, time.Duration) {}
@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package streaminglist assembles list responses that are delivered as a stream of
+// framed documents, such as a CBOR Sequence or newline-delimited JSON, instead of as one
+// list document. The first frame of a streaming list response is the list object itself,
+// carrying the type and list metadata that apply to the whole response, with its items
+// omitted; each subsequent frame is one item. Callers can consume items one at a time
+// without the whole list ever being resident, or assemble a complete typed list for
+// consumers that require one, such as a reflector.
+package streaminglist
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/streaming"
+	"k8s.io/client-go/tools/cache"
+)
+
+// OpenFunc opens a streaming list request for the given options, returning a decoder
+// over the framed response body. A client streaming CBOR, for example, would negotiate a
+// CBOR sequence response and construct a streaming decoder from the CBOR framer and
+// codec over the response body.
+type OpenFunc func(ctx context.Context, options metav1.ListOptions) (streaming.Decoder, error)
+
+// ItemFunc is called with each item of a streaming list response in the order the items
+// appear on the stream.
+type ItemFunc func(obj runtime.Object) error
+
+// DecodeEach reads a streaming list response from d until the stream is exhausted,
+// calling fn once per item. The returned list object is the first frame of the response,
+// with no items, so callers can observe the list metadata without the items being
+// accumulated. If newItem is non-nil, it is called to provide the decode destination for
+// each item; otherwise the decoder chooses. If fn returns an error, no further items are
+// decoded and the error is returned.
+func DecodeEach(d streaming.Decoder, newItem func() runtime.Object, fn ItemFunc) (runtime.Object, error) {
+	list, _, err := d.Decode(nil, nil)
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("streaming list response contained no frames")
+		}
+		return nil, err
+	}
+	if _, err := meta.ListAccessor(list); err != nil {
+		return nil, fmt.Errorf("first frame of a streaming list response must be a list: %v", err)
+	}
+	for {
+		var into runtime.Object
+		if newItem != nil {
+			into = newItem()
+		}
+		item, _, err := d.Decode(nil, into)
+		if err == io.EOF {
+			return list, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := fn(item); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// DecodeList assembles a complete list from a streaming list response: the first frame
+// of the response with the remaining frames appended to it as items. To produce a typed
+// list, provide a newItem that returns the item type, for example
+// func() runtime.Object { return &v1.Pod{} } to assemble a *v1.PodList.
+func DecodeList(d streaming.Decoder, newItem func() runtime.Object) (runtime.Object, error) {
+	var items []runtime.Object
+	list, err := DecodeEach(d, newItem, func(obj runtime.Object) error {
+		items = append(items, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := meta.SetList(list, items); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// NewListWatch returns a ListWatch, suitable for constructing informers, that performs
+// each list by assembling a streaming response. Chunking is disabled on the returned
+// ListWatch: the streamed response already bounds client memory during transfer the way
+// paging otherwise would, and a reflector needs the complete list.
+func NewListWatch(open OpenFunc, newItem func() runtime.Object, watchFunc cache.WatchFunc) *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			d, err := open(context.TODO(), options)
+			if err != nil {
+				return nil, err
+			}
+			defer d.Close()
+			return DecodeList(d, newItem)
+		},
+		WatchFunc:       watchFunc,
+		DisableChunking: true,
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package streaminglist_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	cborserializer "k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+	runtimejson "k8s.io/apimachinery/pkg/runtime/serializer/json"
+	"k8s.io/apimachinery/pkg/runtime/serializer/streaming"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/streaminglist"
+)
+
+// streamResponse encodes a streaming list response: a PodList frame with the given
+// resource version and no items, followed by one frame per pod name.
+func streamResponse(t *testing.T, s runtime.Serializer, framer runtime.Framer, resourceVersion string, names ...string) streaming.Decoder {
+	t.Helper()
+	var buf bytes.Buffer
+	encoder := streaming.NewEncoder(framer.NewFrameWriter(&buf), s)
+	list := &v1.PodList{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PodList"},
+		ListMeta: metav1.ListMeta{ResourceVersion: resourceVersion},
+	}
+	if err := encoder.Encode(list); err != nil {
+		t.Fatalf("unexpected list encode error: %v", err)
+	}
+	for _, name := range names {
+		pod := &v1.Pod{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+		}
+		if err := encoder.Encode(pod); err != nil {
+			t.Fatalf("unexpected item encode error: %v", err)
+		}
+	}
+	return streaming.NewDecoder(framer.NewFrameReader(ioutil.NopCloser(&buf)), s)
+}
+
+func cborStreamSerializer() runtime.Serializer {
+	return cborserializer.NewSerializer(cborserializer.DefaultMetaFactory, scheme.Scheme, scheme.Scheme)
+}
+
+func TestDecodeEach(t *testing.T) {
+	d := streamResponse(t, cborStreamSerializer(), cborserializer.Framer, "10", "foo", "bar")
+
+	var names []string
+	list, err := streaminglist.DecodeEach(d, func() runtime.Object { return &v1.Pod{} }, func(obj runtime.Object) error {
+		names = append(names, obj.(*v1.Pod).Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	podList, ok := list.(*v1.PodList)
+	if !ok {
+		t.Fatalf("expected *v1.PodList, got %T", list)
+	}
+	if podList.ResourceVersion != "10" {
+		t.Errorf("expected resource version 10, got %q", podList.ResourceVersion)
+	}
+	if len(podList.Items) != 0 {
+		t.Errorf("expected no items accumulated on the list, got %d", len(podList.Items))
+	}
+	if len(names) != 2 || names[0] != "foo" || names[1] != "bar" {
+		t.Errorf("expected items foo and bar in order, got %v", names)
+	}
+}
+
+func TestDecodeList(t *testing.T) {
+	jsonSerializer := runtimejson.NewSerializer(runtimejson.DefaultMetaFactory, scheme.Scheme, scheme.Scheme, false)
+	d := streamResponse(t, jsonSerializer, runtimejson.Framer, "10", "foo", "bar")
+
+	list, err := streaminglist.DecodeList(d, func() runtime.Object { return &v1.Pod{} })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	podList, ok := list.(*v1.PodList)
+	if !ok {
+		t.Fatalf("expected *v1.PodList, got %T", list)
+	}
+	if podList.ResourceVersion != "10" {
+		t.Errorf("expected resource version 10, got %q", podList.ResourceVersion)
+	}
+	if len(podList.Items) != 2 || podList.Items[0].Name != "foo" || podList.Items[1].Name != "bar" {
+		t.Errorf("unexpected assembled items: %#v", podList.Items)
+	}
+}
+
+func TestDecodeEachRejectsNonList(t *testing.T) {
+	s := cborStreamSerializer()
+	var buf bytes.Buffer
+	encoder := streaming.NewEncoder(cborserializer.Framer.NewFrameWriter(&buf), s)
+	pod := &v1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+	}
+	if err := encoder.Encode(pod); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+	d := streaming.NewDecoder(cborserializer.Framer.NewFrameReader(ioutil.NopCloser(&buf)), s)
+
+	if _, err := streaminglist.DecodeEach(d, nil, func(runtime.Object) error { return nil }); err == nil {
+		t.Error("expected error when the first frame is not a list")
+	}
+}
+
+func TestDecodeEachRejectsEmptyStream(t *testing.T) {
+	s := cborStreamSerializer()
+	d := streaming.NewDecoder(cborserializer.Framer.NewFrameReader(ioutil.NopCloser(&bytes.Buffer{})), s)
+	if _, err := streaminglist.DecodeEach(d, nil, func(runtime.Object) error { return nil }); err == nil {
+		t.Error("expected error for a response with no frames")
+	}
+}
+
+func TestNewListWatch(t *testing.T) {
+	open := func(ctx context.Context, options metav1.ListOptions) (streaming.Decoder, error) {
+		return streamResponse(t, cborStreamSerializer(), cborserializer.Framer, options.ResourceVersion, "foo"), nil
+	}
+	lw := streaminglist.NewListWatch(open, func() runtime.Object { return &v1.Pod{} }, nil)
+	if !lw.DisableChunking {
+		t.Error("expected chunking to be disabled for streaming lists")
+	}
+	list, err := lw.List(metav1.ListOptions{ResourceVersion: "10"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	podList, ok := list.(*v1.PodList)
+	if !ok {
+		t.Fatalf("expected *v1.PodList, got %T", list)
+	}
+	if podList.ResourceVersion != "10" || len(podList.Items) != 1 || podList.Items[0].Name != "foo" {
+		t.Errorf("unexpected assembled list: %#v", podList)
+	}
+}
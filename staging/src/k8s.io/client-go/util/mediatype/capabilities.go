@@ -0,0 +1,208 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mediatype tracks which media types may be used with specific resources. Not all
+// resources support every media type a server offers (custom resources, for example, have
+// no protobuf encoding), so clients attempting an efficient encoding need a shared record
+// of which resources it is usable with. The record can be primed from discovery documents
+// and updated as requests are rejected with 415 Unsupported Media Type, and is intended to
+// be shared between typed and dynamic clients talking to the same server.
+package mediatype
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Support describes what is known about a resource's support for a media type.
+type Support int
+
+const (
+	// SupportUnknown means no information has been recorded. Clients should usually
+	// attempt their preferred media type and learn from the response.
+	SupportUnknown Support = iota
+	// Supported means the media type has been advertised or successfully used.
+	Supported
+	// Unsupported means the server rejected or did not advertise the media type.
+	Unsupported
+)
+
+// Capabilities is a concurrency-safe record of per-resource media type support. The zero
+// value is not usable; use NewCapabilities.
+type Capabilities struct {
+	lock      sync.RWMutex
+	resources map[schema.GroupVersionResource]map[string]bool
+}
+
+// NewCapabilities returns an empty Capabilities.
+func NewCapabilities() *Capabilities {
+	return &Capabilities{resources: map[schema.GroupVersionResource]map[string]bool{}}
+}
+
+// Supports reports what is known about the given resource's support for mediaType.
+func (c *Capabilities) Supports(gvr schema.GroupVersionResource, mediaType string) Support {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	mediaTypes, ok := c.resources[gvr]
+	if !ok {
+		return SupportUnknown
+	}
+	supported, ok := mediaTypes[mediaType]
+	switch {
+	case !ok:
+		return SupportUnknown
+	case supported:
+		return Supported
+	default:
+		return Unsupported
+	}
+}
+
+// PreferredMediaType returns the first of the given media types that is not known to be
+// unsupported by the resource, or false if all are known to be unsupported. Unknown media
+// types are attempted optimistically.
+func (c *Capabilities) PreferredMediaType(gvr schema.GroupVersionResource, mediaTypes ...string) (string, bool) {
+	for _, mediaType := range mediaTypes {
+		if c.Supports(gvr, mediaType) != Unsupported {
+			return mediaType, true
+		}
+	}
+	return "", false
+}
+
+// RecordSupported records that the resource supports mediaType, for example after a
+// request using it succeeded.
+func (c *Capabilities) RecordSupported(gvr schema.GroupVersionResource, mediaType string) {
+	c.record(gvr, mediaType, true)
+}
+
+// RecordUnsupported records that the resource does not support mediaType, for example
+// after a request was rejected with 415 Unsupported Media Type.
+func (c *Capabilities) RecordUnsupported(gvr schema.GroupVersionResource, mediaType string) {
+	c.record(gvr, mediaType, false)
+}
+
+func (c *Capabilities) record(gvr schema.GroupVersionResource, mediaType string, supported bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	mediaTypes, ok := c.resources[gvr]
+	if !ok {
+		mediaTypes = map[string]bool{}
+		c.resources[gvr] = mediaTypes
+	}
+	mediaTypes[mediaType] = supported
+}
+
+// RecordDiscovery primes the record for a resource from its discovery document. Resources
+// that advertise no media types are left unknown rather than marked unsupported, since
+// servers predating media type advertisement omit the fields entirely.
+func (c *Capabilities) RecordDiscovery(gvr schema.GroupVersionResource, resource *metav1.APIResource) {
+	advertised := make([]string, 0, len(resource.RequestMediaTypes)+len(resource.ResponseMediaTypes)+len(resource.PatchMediaTypes))
+	advertised = append(advertised, resource.RequestMediaTypes...)
+	advertised = append(advertised, resource.ResponseMediaTypes...)
+	advertised = append(advertised, resource.PatchMediaTypes...)
+	for _, mediaType := range advertised {
+		c.RecordSupported(gvr, mediaType)
+	}
+}
+
+// RecordAcceptPatch primes the record for a resource from the value of an Accept-Patch
+// response header (RFC 5789, section 3.1), a comma-separated list of the patch content
+// types the resource accepts. An empty value records nothing, since servers predating
+// patch type advertisement omit the header entirely.
+func (c *Capabilities) RecordAcceptPatch(gvr schema.GroupVersionResource, header string) {
+	for _, part := range strings.Split(header, ",") {
+		if mediaType := strings.TrimSpace(part); len(mediaType) > 0 {
+			c.RecordSupported(gvr, mediaType)
+		}
+	}
+}
+
+// Forget discards everything recorded about the resource, for example after discovery
+// indicates the resource has changed.
+func (c *Capabilities) Forget(gvr schema.GroupVersionResource) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.resources, gvr)
+}
+
+// Reset discards everything recorded while preserving the identity of the record, for
+// the benefit of clients that share it.
+func (c *Capabilities) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.resources = map[schema.GroupVersionResource]map[string]bool{}
+}
+
+// resourceCapabilities is the serialized form of a single resource's record.
+type resourceCapabilities struct {
+	Group      string          `json:"group,omitempty"`
+	Version    string          `json:"version"`
+	Resource   string          `json:"resource"`
+	MediaTypes map[string]bool `json:"mediaTypes"`
+}
+
+// MarshalJSON serializes the record with its resources in a stable order, so that caches
+// persisting it produce deterministic files.
+func (c *Capabilities) MarshalJSON() ([]byte, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	resources := make([]resourceCapabilities, 0, len(c.resources))
+	for gvr, mediaTypes := range c.resources {
+		copied := make(map[string]bool, len(mediaTypes))
+		for mediaType, supported := range mediaTypes {
+			copied[mediaType] = supported
+		}
+		resources = append(resources, resourceCapabilities{
+			Group:      gvr.Group,
+			Version:    gvr.Version,
+			Resource:   gvr.Resource,
+			MediaTypes: copied,
+		})
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].Group != resources[j].Group {
+			return resources[i].Group < resources[j].Group
+		}
+		if resources[i].Version != resources[j].Version {
+			return resources[i].Version < resources[j].Version
+		}
+		return resources[i].Resource < resources[j].Resource
+	})
+	return json.Marshal(resources)
+}
+
+// UnmarshalJSON replaces the record with the deserialized one.
+func (c *Capabilities) UnmarshalJSON(data []byte) error {
+	var resources []resourceCapabilities
+	if err := json.Unmarshal(data, &resources); err != nil {
+		return err
+	}
+	replacement := make(map[schema.GroupVersionResource]map[string]bool, len(resources))
+	for _, resource := range resources {
+		gvr := schema.GroupVersionResource{Group: resource.Group, Version: resource.Version, Resource: resource.Resource}
+		replacement[gvr] = resource.MediaTypes
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.resources = replacement
+	return nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mediatype
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestCapabilities(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	other := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+	c := NewCapabilities()
+	if got := c.Supports(gvr, runtime.ContentTypeCBOR); got != SupportUnknown {
+		t.Errorf("expected SupportUnknown for empty capabilities, got %v", got)
+	}
+
+	c.RecordUnsupported(gvr, runtime.ContentTypeCBOR)
+	if got := c.Supports(gvr, runtime.ContentTypeCBOR); got != Unsupported {
+		t.Errorf("expected Unsupported after RecordUnsupported, got %v", got)
+	}
+	if got := c.Supports(other, runtime.ContentTypeCBOR); got != SupportUnknown {
+		t.Errorf("expected recording to be scoped per resource, got %v", got)
+	}
+
+	c.RecordSupported(gvr, runtime.ContentTypeCBOR)
+	if got := c.Supports(gvr, runtime.ContentTypeCBOR); got != Supported {
+		t.Errorf("expected Supported after RecordSupported, got %v", got)
+	}
+
+	c.Forget(gvr)
+	if got := c.Supports(gvr, runtime.ContentTypeCBOR); got != SupportUnknown {
+		t.Errorf("expected SupportUnknown after Forget, got %v", got)
+	}
+}
+
+func TestPreferredMediaType(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+	c := NewCapabilities()
+	if got, ok := c.PreferredMediaType(gvr, runtime.ContentTypeCBOR, runtime.ContentTypeJSON); !ok || got != runtime.ContentTypeCBOR {
+		t.Errorf("expected unknown media types to be attempted optimistically, got %q (ok=%t)", got, ok)
+	}
+
+	c.RecordUnsupported(gvr, runtime.ContentTypeCBOR)
+	if got, ok := c.PreferredMediaType(gvr, runtime.ContentTypeCBOR, runtime.ContentTypeJSON); !ok || got != runtime.ContentTypeJSON {
+		t.Errorf("expected fallback past unsupported media type, got %q (ok=%t)", got, ok)
+	}
+
+	c.RecordUnsupported(gvr, runtime.ContentTypeJSON)
+	if _, ok := c.PreferredMediaType(gvr, runtime.ContentTypeCBOR, runtime.ContentTypeJSON); ok {
+		t.Error("expected no preferred media type when all are unsupported")
+	}
+}
+
+func TestRecordDiscovery(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+	c := NewCapabilities()
+	c.RecordDiscovery(gvr, &metav1.APIResource{
+		Name:               "widgets",
+		RequestMediaTypes:  []string{runtime.ContentTypeJSON},
+		ResponseMediaTypes: []string{runtime.ContentTypeJSON, runtime.ContentTypeCBOR},
+	})
+	if got := c.Supports(gvr, runtime.ContentTypeCBOR); got != Supported {
+		t.Errorf("expected Supported from discovery, got %v", got)
+	}
+	if got := c.Supports(gvr, runtime.ContentTypeProtobuf); got != SupportUnknown {
+		t.Errorf("expected unadvertised media type to remain unknown, got %v", got)
+	}
+
+	// A resource served by an older apiserver advertises nothing.
+	c.RecordDiscovery(gvr, &metav1.APIResource{Name: "widgets"})
+	if got := c.Supports(gvr, runtime.ContentTypeCBOR); got != Supported {
+		t.Errorf("expected empty advertisement to leave existing records in place, got %v", got)
+	}
+
+	c.RecordDiscovery(gvr, &metav1.APIResource{
+		Name:            "widgets",
+		PatchMediaTypes: []string{string(types.ApplyPatchType), string(types.ApplyCBORPatchType)},
+	})
+	if got := c.Supports(gvr, string(types.ApplyCBORPatchType)); got != Supported {
+		t.Errorf("expected patch media types to be recorded from discovery, got %v", got)
+	}
+}
+
+func TestRecordAcceptPatch(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+	c := NewCapabilities()
+	c.RecordAcceptPatch(gvr, "application/merge-patch+json, application/apply-patch+cbor")
+	if got := c.Supports(gvr, string(types.MergePatchType)); got != Supported {
+		t.Errorf("expected Supported from Accept-Patch header, got %v", got)
+	}
+	if got := c.Supports(gvr, string(types.ApplyCBORPatchType)); got != Supported {
+		t.Errorf("expected Supported from Accept-Patch header, got %v", got)
+	}
+	if got := c.Supports(gvr, string(types.JSONPatchType)); got != SupportUnknown {
+		t.Errorf("expected unlisted patch type to remain unknown, got %v", got)
+	}
+
+	c.RecordAcceptPatch(gvr, "")
+	if got := c.Supports(gvr, string(types.MergePatchType)); got != Supported {
+		t.Errorf("expected empty header to record nothing, got %v", got)
+	}
+}
+
+func TestCapabilitiesJSONRoundTrip(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	other := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+	c := NewCapabilities()
+	c.RecordSupported(gvr, "application/cbor")
+	c.RecordUnsupported(other, "application/vnd.kubernetes.protobuf")
+
+	serialized, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	reserialized, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %v", err)
+	}
+	if !bytes.Equal(serialized, reserialized) {
+		t.Errorf("expected deterministic serialization, got %s and %s", serialized, reserialized)
+	}
+
+	decoded := NewCapabilities()
+	if err := json.Unmarshal(serialized, decoded); err != nil {
+		t.Fatalf("unexpected unmarshal error: %v", err)
+	}
+	if got := decoded.Supports(gvr, "application/cbor"); got != Supported {
+		t.Errorf("expected Supported to survive a round trip, got %v", got)
+	}
+	if got := decoded.Supports(other, "application/vnd.kubernetes.protobuf"); got != Unsupported {
+		t.Errorf("expected Unsupported to survive a round trip, got %v", got)
+	}
+	if got := decoded.Supports(gvr, "application/json"); got != SupportUnknown {
+		t.Errorf("expected unrecorded media type to stay unknown, got %v", got)
+	}
+}
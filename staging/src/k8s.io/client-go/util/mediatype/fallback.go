@@ -0,0 +1,135 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mediatype
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+// FallbackCache remembers which request media types an endpoint has recently rejected so
+// that clients can downgrade to another encoding instead of repeating a request the server
+// has already refused. Entries expire after a TTL so that an endpoint that begins
+// accepting a media type, for example after a server upgrade, is eventually retried.
+// Expired entries are dropped whenever a rejection is recorded, so the cache never holds
+// more than the endpoints rejected within the last TTL. A FallbackCache is safe for
+// concurrent use and is intended to be shared by every client in a process that talks to
+// the same endpoints.
+type FallbackCache struct {
+	ttl   time.Duration
+	clock clock.Clock
+
+	lock     sync.Mutex
+	rejected map[endpoint]map[string]time.Time
+}
+
+// endpoint identifies a request target for content type learning. Rejections are recorded
+// per path because a server may accept a media type for some resources and not others.
+// Callers should pass a path that identifies the resource collection rather than an
+// individual object, both because acceptance does not vary between objects of the same
+// resource and to keep the number of distinct keys small.
+type endpoint struct {
+	host string
+	path string
+}
+
+// NewFallbackCache returns an empty FallbackCache whose entries expire after ttl.
+func NewFallbackCache(ttl time.Duration) *FallbackCache {
+	return newFallbackCache(ttl, clock.RealClock{})
+}
+
+// newFallbackCache allows tests to substitute a fake clock.
+func newFallbackCache(ttl time.Duration, clock clock.Clock) *FallbackCache {
+	return &FallbackCache{
+		ttl:      ttl,
+		clock:    clock,
+		rejected: map[endpoint]map[string]time.Time{},
+	}
+}
+
+// RecordRejection notes that the endpoint rejected a request body encoded as mediaType,
+// for example with 415 Unsupported Media Type. The rejection is forgotten after the TTL.
+func (c *FallbackCache) RecordRejection(host, path, mediaType string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	// Rejections are rare -- at most one per endpoint per TTL -- so this is a cheap
+	// place to drop expired entries that would otherwise linger until their exact key
+	// was queried again.
+	c.expireLocked()
+	key := endpoint{host: host, path: path}
+	mediaTypes, ok := c.rejected[key]
+	if !ok {
+		mediaTypes = map[string]time.Time{}
+		c.rejected[key] = mediaTypes
+	}
+	mediaTypes[mediaType] = c.clock.Now().Add(c.ttl)
+}
+
+// expireLocked removes every expired entry. The caller must hold the lock.
+func (c *FallbackCache) expireLocked() {
+	now := c.clock.Now()
+	for key, mediaTypes := range c.rejected {
+		for mediaType, expiry := range mediaTypes {
+			if now.After(expiry) {
+				delete(mediaTypes, mediaType)
+			}
+		}
+		if len(mediaTypes) == 0 {
+			delete(c.rejected, key)
+		}
+	}
+}
+
+// Rejected reports whether the endpoint rejected mediaType within the last TTL.
+func (c *FallbackCache) Rejected(host, path, mediaType string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	key := endpoint{host: host, path: path}
+	mediaTypes, ok := c.rejected[key]
+	if !ok {
+		return false
+	}
+	expiry, ok := mediaTypes[mediaType]
+	if !ok {
+		return false
+	}
+	if c.clock.Now().After(expiry) {
+		delete(mediaTypes, mediaType)
+		if len(mediaTypes) == 0 {
+			delete(c.rejected, key)
+		}
+		return false
+	}
+	return true
+}
+
+// ContentType returns the first of the given media types that the endpoint has not
+// recently rejected. If every candidate has been rejected, the last one is returned so
+// that the request is still attempted with something.
+func (c *FallbackCache) ContentType(host, path string, mediaTypes ...string) string {
+	for _, mediaType := range mediaTypes {
+		if !c.Rejected(host, path, mediaType) {
+			return mediaType
+		}
+	}
+	if len(mediaTypes) == 0 {
+		return ""
+	}
+	return mediaTypes[len(mediaTypes)-1]
+}
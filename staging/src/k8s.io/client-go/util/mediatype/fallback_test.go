@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mediatype
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+func TestFallbackCacheRejection(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	c := newFallbackCache(time.Minute, fakeClock)
+
+	if c.Rejected("host", "/path", "application/cbor") {
+		t.Error("expected nothing to be rejected initially")
+	}
+	if got := c.ContentType("host", "/path", "application/cbor", "application/json"); got != "application/cbor" {
+		t.Errorf("expected preferred media type before any rejection, got %q", got)
+	}
+
+	c.RecordRejection("host", "/path", "application/cbor")
+	if !c.Rejected("host", "/path", "application/cbor") {
+		t.Error("expected rejection to be recorded")
+	}
+	if got := c.ContentType("host", "/path", "application/cbor", "application/json"); got != "application/json" {
+		t.Errorf("expected downgrade after rejection, got %q", got)
+	}
+
+	// Rejections are scoped to the endpoint that produced them.
+	if c.Rejected("host", "/other", "application/cbor") {
+		t.Error("expected rejection to be scoped to its path")
+	}
+	if c.Rejected("otherhost", "/path", "application/cbor") {
+		t.Error("expected rejection to be scoped to its host")
+	}
+}
+
+func TestFallbackCacheTTL(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	c := newFallbackCache(time.Minute, fakeClock)
+
+	c.RecordRejection("host", "/path", "application/cbor")
+	fakeClock.Step(time.Minute + time.Second)
+	if c.Rejected("host", "/path", "application/cbor") {
+		t.Error("expected rejection to expire after the TTL")
+	}
+	if got := c.ContentType("host", "/path", "application/cbor", "application/json"); got != "application/cbor" {
+		t.Errorf("expected preferred media type to be retried after expiry, got %q", got)
+	}
+}
+
+func TestFallbackCacheExpiredEntriesSwept(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	c := newFallbackCache(time.Minute, fakeClock)
+
+	c.RecordRejection("host", "/stale", "application/cbor")
+	fakeClock.Step(time.Minute + time.Second)
+	// Recording a rejection for an unrelated endpoint should drop the expired entry
+	// even though its key is never queried again.
+	c.RecordRejection("host", "/fresh", "application/cbor")
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.rejected) != 1 {
+		t.Errorf("expected only the fresh entry to remain, got %d entries", len(c.rejected))
+	}
+	if _, ok := c.rejected[endpoint{host: "host", path: "/stale"}]; ok {
+		t.Error("expected the expired entry to have been swept")
+	}
+}
+
+func TestFallbackCacheAllRejected(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	c := newFallbackCache(time.Minute, fakeClock)
+
+	c.RecordRejection("host", "/path", "application/cbor")
+	c.RecordRejection("host", "/path", "application/json")
+	if got := c.ContentType("host", "/path", "application/cbor", "application/json"); got != "application/json" {
+		t.Errorf("expected the last candidate when everything is rejected, got %q", got)
+	}
+}
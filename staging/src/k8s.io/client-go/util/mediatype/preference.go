@@ -0,0 +1,128 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mediatype
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// minDecodeObservations is the number of decodes of a media type that must be observed
+// for a resource before the media type is ranked. Single observations are noisy; an
+// unranked media type keeps its configured position.
+const minDecodeObservations = 16
+
+// DecodePreference accumulates the observed cost of decoding response bodies, per
+// resource and media type, and reorders a configured list of accepted media types so that
+// the encodings that have decoded fastest for a resource are requested first. Responses
+// for the same resource carry the same objects whatever their encoding, so mean decode
+// latency is comparable across media types. The set of acceptable media types is never
+// changed, only their order of preference. A DecodePreference is safe for concurrent use
+// and is intended to be shared by every client in a process that opts in.
+type DecodePreference struct {
+	lock  sync.Mutex
+	stats map[schema.GroupVersionResource]map[string]*decodeStats
+}
+
+type decodeStats struct {
+	observations int64
+	bytes        int64
+	duration     time.Duration
+}
+
+// NewDecodePreference returns an empty DecodePreference.
+func NewDecodePreference() *DecodePreference {
+	return &DecodePreference{stats: map[schema.GroupVersionResource]map[string]*decodeStats{}}
+}
+
+// Observe records that a response body of sizeBytes encoded as mediaType took duration to
+// decode into an object of the given resource.
+func (p *DecodePreference) Observe(gvr schema.GroupVersionResource, mediaType string, sizeBytes int, duration time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	mediaTypes, ok := p.stats[gvr]
+	if !ok {
+		mediaTypes = map[string]*decodeStats{}
+		p.stats[gvr] = mediaTypes
+	}
+	stats, ok := mediaTypes[mediaType]
+	if !ok {
+		stats = &decodeStats{}
+		mediaTypes[mediaType] = stats
+	}
+	stats.observations++
+	stats.bytes += int64(sizeBytes)
+	stats.duration += duration
+}
+
+// OrderAcceptContentTypes returns the entries of an Accept header value reordered so that
+// the media types observed to decode fastest for the resource come first. Media types
+// that have been ranked precede those that have not, and unranked media types keep their
+// configured relative order, so the configured preference stands until there is evidence
+// against it. Entries are preserved verbatim, including any parameters.
+func (p *DecodePreference) OrderAcceptContentTypes(gvr schema.GroupVersionResource, accept string) string {
+	entries := strings.Split(accept, ",")
+	if len(entries) < 2 {
+		return accept
+	}
+
+	p.lock.Lock()
+	mediaTypes := p.stats[gvr]
+	means := make([]time.Duration, len(entries))
+	ranked := make([]bool, len(entries))
+	for i, entry := range entries {
+		mediaType := strings.TrimSpace(entry)
+		if j := strings.Index(mediaType, ";"); j >= 0 {
+			mediaType = strings.TrimSpace(mediaType[:j])
+		}
+		if stats, ok := mediaTypes[mediaType]; ok && stats.observations >= minDecodeObservations {
+			means[i] = stats.duration / time.Duration(stats.observations)
+			ranked[i] = true
+		}
+	}
+	p.lock.Unlock()
+
+	anyRanked := false
+	for _, r := range ranked {
+		anyRanked = anyRanked || r
+	}
+	if !anyRanked {
+		return accept
+	}
+
+	indices := make([]int, len(entries))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(a, b int) bool {
+		a, b = indices[a], indices[b]
+		if ranked[a] != ranked[b] {
+			return ranked[a]
+		}
+		return ranked[a] && means[a] < means[b]
+	})
+
+	ordered := make([]string, len(entries))
+	for i, j := range indices {
+		ordered[i] = strings.TrimSpace(entries[j])
+	}
+	return strings.Join(ordered, ", ")
+}
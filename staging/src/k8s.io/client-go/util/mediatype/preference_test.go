@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mediatype
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestDecodePreferenceOrderAcceptContentTypes(t *testing.T) {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	crs := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+	p := NewDecodePreference()
+	for i := 0; i < minDecodeObservations; i++ {
+		p.Observe(pods, "application/json", 3000, 3*time.Millisecond)
+		p.Observe(pods, "application/vnd.kubernetes.protobuf", 1000, time.Millisecond)
+		p.Observe(crs, "application/cbor", 1000, time.Millisecond)
+		p.Observe(crs, "application/json", 3000, 3*time.Millisecond)
+	}
+
+	accept := "application/json, application/vnd.kubernetes.protobuf, application/cbor"
+	for _, tc := range []struct {
+		name     string
+		gvr      schema.GroupVersionResource
+		accept   string
+		expected string
+	}{
+		{
+			name:     "ranked media types ordered by mean decode latency",
+			gvr:      pods,
+			accept:   accept,
+			expected: "application/vnd.kubernetes.protobuf, application/json, application/cbor",
+		},
+		{
+			name:     "per-resource ordering",
+			gvr:      crs,
+			accept:   accept,
+			expected: "application/cbor, application/json, application/vnd.kubernetes.protobuf",
+		},
+		{
+			name:     "parameters preserved",
+			gvr:      pods,
+			accept:   "application/json;q=0.9, application/vnd.kubernetes.protobuf",
+			expected: "application/vnd.kubernetes.protobuf, application/json;q=0.9",
+		},
+		{
+			name:     "unobserved resource unchanged",
+			gvr:      schema.GroupVersionResource{Version: "v1", Resource: "nodes"},
+			accept:   accept,
+			expected: accept,
+		},
+		{
+			name:     "single entry unchanged",
+			gvr:      pods,
+			accept:   "application/json",
+			expected: "application/json",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := p.OrderAcceptContentTypes(tc.gvr, tc.accept); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDecodePreferenceMinimumObservations(t *testing.T) {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	accept := "application/json, application/cbor"
+
+	p := NewDecodePreference()
+	for i := 0; i < minDecodeObservations-1; i++ {
+		p.Observe(pods, "application/cbor", 1000, time.Millisecond)
+	}
+	if got := p.OrderAcceptContentTypes(pods, accept); got != accept {
+		t.Errorf("expected configured order below the observation minimum, got %q", got)
+	}
+
+	p.Observe(pods, "application/cbor", 1000, time.Millisecond)
+	if got, expected := p.OrderAcceptContentTypes(pods, accept), "application/cbor, application/json"; got != expected {
+		t.Errorf("expected %q once the observation minimum is reached, got %q", expected, got)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metricadapters_test
+
+import (
+	"bytes"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/metrics"
+	"k8s.io/client-go/util/metricadapters"
+)
+
+type payloadObservation struct {
+	apiVersion  string
+	kind        string
+	contentType string
+	sizeBytes   int
+	items       int
+}
+
+func TestObservingNegotiator(t *testing.T) {
+	var observations []payloadObservation
+	original := metrics.RequestPayload
+	metrics.RequestPayload = metricadapters.PayloadFunc(func(apiVersion, kind, contentType string, sizeBytes, items int) {
+		observations = append(observations, payloadObservation{apiVersion, kind, contentType, sizeBytes, items})
+	})
+	defer func() { metrics.RequestPayload = original }()
+
+	negotiator := metricadapters.NewObservingNegotiator(runtime.NewClientNegotiator(scheme.Codecs.WithoutConversion(), v1.SchemeGroupVersion))
+	encoder, err := negotiator.Encoder("application/json", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	list := &v1.PodList{Items: []v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "a"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b"}},
+	}}
+	if err := encoder.Encode(list, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	listSize := buf.Len()
+
+	buf.Reset()
+	if err := encoder.Encode(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "a"}}, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	podSize := buf.Len()
+
+	expected := []payloadObservation{
+		{kind: "PodList", contentType: "application/json", sizeBytes: listSize, items: 2},
+		{kind: "Pod", contentType: "application/json", sizeBytes: podSize, items: 0},
+	}
+	if len(observations) != len(expected) {
+		t.Fatalf("expected %d observations, got %#v", len(expected), observations)
+	}
+	for i := range expected {
+		if observations[i] != expected[i] {
+			t.Errorf("expected observation %#v, got %#v", expected[i], observations[i])
+		}
+	}
+}
+
+func TestObservingEncoderPreservesIdentifier(t *testing.T) {
+	negotiator := metricadapters.NewObservingNegotiator(runtime.NewClientNegotiator(scheme.Codecs.WithoutConversion(), v1.SchemeGroupVersion))
+	observing, err := negotiator.Encoder("application/json", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	delegate, err := runtime.NewClientNegotiator(scheme.Codecs.WithoutConversion(), v1.SchemeGroupVersion).Encoder("application/json", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if observing.Identifier() != delegate.Identifier() {
+		t.Errorf("expected identifier %q, got %q", delegate.Identifier(), observing.Identifier())
+	}
+}
@@ -27,4 +27,5 @@ func TestRoundTripTypes(t *testing.T) {
 	roundtrip.RoundTripTestForAPIGroup(t, Install, wardlefuzzer.Funcs)
 	// TODO: enable protobuf generation for the sample-apiserver
 	// roundtrip.RoundTripProtobufTestForAPIGroup(t, Install, wardlefuzzer.Funcs)
+	roundtrip.RoundTripCBORTestForAPIGroup(t, Install, wardlefuzzer.Funcs)
 }
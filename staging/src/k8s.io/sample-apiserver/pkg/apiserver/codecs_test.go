@@ -0,0 +1,62 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/sample-apiserver/pkg/apis/wardle/v1alpha1"
+)
+
+// TestCBORCodecs pins the media types offered when CBOR serving is enabled: CBORCodecs
+// must negotiate application/cbor and round-trip wardle types through it, and the default
+// Codecs must not offer it so that the option remains an explicit opt-in.
+func TestCBORCodecs(t *testing.T) {
+	if info, ok := runtime.SerializerInfoForMediaType(Codecs.SupportedMediaTypes(), runtime.ContentTypeCBOR); ok {
+		t.Errorf("expected the default codecs not to support %s, got %#v", runtime.ContentTypeCBOR, info)
+	}
+
+	info, ok := runtime.SerializerInfoForMediaType(CBORCodecs.SupportedMediaTypes(), runtime.ContentTypeCBOR)
+	if !ok {
+		t.Fatalf("expected the CBOR codecs to support %s", runtime.ContentTypeCBOR)
+	}
+
+	codec := CBORCodecs.CodecForVersions(info.Serializer, info.Serializer, v1alpha1.SchemeGroupVersion, v1alpha1.SchemeGroupVersion)
+	referenceType := v1alpha1.FlunderReferenceType
+	original := &v1alpha1.Flunder{
+		ObjectMeta: metav1.ObjectMeta{Name: "example"},
+		Spec: v1alpha1.FlunderSpec{
+			Reference:     "other",
+			ReferenceType: &referenceType,
+		},
+	}
+	data, err := runtime.Encode(codec, original)
+	if err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+	decoded, err := runtime.Decode(codec, data)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	original.TypeMeta = metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: "Flunder"}
+	if !reflect.DeepEqual(original, decoded) {
+		t.Errorf("expected %#v, got %#v", original, decoded)
+	}
+}
@@ -46,6 +46,7 @@ const defaultEtcdPathPrefix = "/registry/wardle.example.com"
 // WardleServerOptions contains state for master/api server
 type WardleServerOptions struct {
 	RecommendedOptions *genericoptions.RecommendedOptions
+	EnableCBORServing  bool
 
 	SharedInformerFactory informers.SharedInformerFactory
 	StdOut                io.Writer
@@ -91,6 +92,8 @@ func NewCommandStartWardleServer(defaults *WardleServerOptions, stopCh <-chan st
 
 	flags := cmd.Flags()
 	o.RecommendedOptions.AddFlags(flags)
+	flags.BoolVar(&o.EnableCBORServing, "enable-cbor-serving", o.EnableCBORServing, ""+
+		"If true, serve the wardle API group with application/cbor as an additional media type.")
 	utilfeature.DefaultMutableFeatureGate.AddFlag(flags)
 
 	return cmd
@@ -145,7 +148,9 @@ func (o *WardleServerOptions) Config() (*apiserver.Config, error) {
 
 	config := &apiserver.Config{
 		GenericConfig: serverConfig,
-		ExtraConfig:   apiserver.ExtraConfig{},
+		ExtraConfig: apiserver.ExtraConfig{
+			EnableCBORServing: o.EnableCBORServing,
+		},
 	}
 	return config, nil
 }
@@ -20,14 +20,19 @@ import (
 	"flag"
 	"time"
 
+	"k8s.io/apimachinery/pkg/runtime"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/serializer"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
 	// Uncomment the following line to load the gcp plugin (only required to authenticate against GKE clusters).
 	// _ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 
+	samplev1alpha1 "k8s.io/sample-controller/pkg/apis/samplecontroller/v1alpha1"
 	clientset "k8s.io/sample-controller/pkg/generated/clientset/versioned"
+	"k8s.io/sample-controller/pkg/generated/clientset/versioned/scheme"
 	informers "k8s.io/sample-controller/pkg/generated/informers/externalversions"
 	"k8s.io/sample-controller/pkg/signals"
 )
@@ -35,6 +40,7 @@ import (
 var (
 	masterURL  string
 	kubeconfig string
+	preferCBOR bool
 )
 
 func main() {
@@ -54,7 +60,7 @@ func main() {
 		klog.Fatalf("Error building kubernetes clientset: %s", err.Error())
 	}
 
-	exampleClient, err := clientset.NewForConfig(cfg)
+	exampleClient, err := newExampleClient(cfg, preferCBOR)
 	if err != nil {
 		klog.Fatalf("Error building example clientset: %s", err.Error())
 	}
@@ -76,7 +82,33 @@ func main() {
 	}
 }
 
+// newExampleClient returns a clientset for the samplecontroller API group. When
+// preferCBOR is set, requests carry application/cbor bodies and accept CBOR responses,
+// and the shared REST client fallback downgrades to JSON for any endpoint that rejects
+// the encoding, so the flag is safe to enable against servers that do not serve CBOR.
+func newExampleClient(cfg *rest.Config, preferCBOR bool) (clientset.Interface, error) {
+	if !preferCBOR {
+		return clientset.NewForConfig(cfg)
+	}
+	config := *cfg
+	gv := samplev1alpha1.SchemeGroupVersion
+	config.GroupVersion = &gv
+	config.APIPath = "/apis"
+	config.ContentType = runtime.ContentTypeCBOR
+	config.AcceptContentTypes = runtime.ContentTypeCBOR + "," + runtime.ContentTypeJSON
+	config.NegotiatedSerializer = serializer.NewNegotiatedSerializer(scheme.Scheme)
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+	restClient, err := rest.RESTClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+	return clientset.New(restClient), nil
+}
+
 func init() {
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
 	flag.StringVar(&masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
+	flag.BoolVar(&preferCBOR, "prefer-cbor", false, "If true, communicate with the API server using application/cbor where supported, falling back to application/json.")
 }
@@ -0,0 +1,125 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor/direct"
+	"k8s.io/client-go/rest"
+	samplev1alpha1 "k8s.io/sample-controller/pkg/apis/samplecontroller/v1alpha1"
+)
+
+func TestNewExampleClientPrefersCBOR(t *testing.T) {
+	var contentTypes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("unexpected error reading request body: %v", err)
+		}
+		// The serializer prefixes each document with the self-described CBOR tag,
+		// which direct.Unmarshal does not accept.
+		body = bytes.TrimPrefix(body, []byte{0xd9, 0xd9, 0xf7})
+		var decoded map[string]interface{}
+		if err := direct.Unmarshal(body, &decoded); err != nil {
+			t.Errorf("request body did not decode as CBOR: %v", err)
+		} else if kind := decoded["kind"]; kind != "Foo" {
+			t.Errorf("expected request body kind Foo, got %v", kind)
+		}
+		w.Header().Set("Content-Type", runtime.ContentTypeJSON)
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(&samplev1alpha1.Foo{
+			TypeMeta:   metav1.TypeMeta{APIVersion: samplev1alpha1.SchemeGroupVersion.String(), Kind: "Foo"},
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		}); err != nil {
+			t.Errorf("unexpected error writing response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client, err := newExampleClient(&rest.Config{Host: server.URL}, true)
+	if err != nil {
+		t.Fatalf("unexpected error building clientset: %v", err)
+	}
+	foo := &samplev1alpha1.Foo{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"}}
+	created, err := client.SamplecontrollerV1alpha1().Foos("default").Create(context.TODO(), foo, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error creating foo: %v", err)
+	}
+	if created.Name != "example" {
+		t.Errorf("expected created name %q, got %q", "example", created.Name)
+	}
+	if expected := []string{runtime.ContentTypeCBOR}; len(contentTypes) != 1 || contentTypes[0] != expected[0] {
+		t.Errorf("expected request content types %v, got %v", expected, contentTypes)
+	}
+}
+
+func TestNewExampleClientFallsBackToJSON(t *testing.T) {
+	var contentTypes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType := r.Header.Get("Content-Type")
+		contentTypes = append(contentTypes, contentType)
+		w.Header().Set("Content-Type", runtime.ContentTypeJSON)
+		if contentType == runtime.ContentTypeCBOR {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			if err := json.NewEncoder(w).Encode(&metav1.Status{
+				Status:  metav1.StatusFailure,
+				Code:    http.StatusUnsupportedMediaType,
+				Reason:  metav1.StatusReason("UnsupportedMediaType"),
+				Message: "the server does not accept application/cbor",
+			}); err != nil {
+				t.Errorf("unexpected error writing response: %v", err)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(&samplev1alpha1.Foo{
+			TypeMeta:   metav1.TypeMeta{APIVersion: samplev1alpha1.SchemeGroupVersion.String(), Kind: "Foo"},
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		}); err != nil {
+			t.Errorf("unexpected error writing response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client, err := newExampleClient(&rest.Config{Host: server.URL}, true)
+	if err != nil {
+		t.Fatalf("unexpected error building clientset: %v", err)
+	}
+	foos := client.SamplecontrollerV1alpha1().Foos("default")
+	foo := &samplev1alpha1.Foo{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"}}
+	if _, err := foos.Create(context.TODO(), foo, metav1.CreateOptions{}); !errors.IsUnsupportedMediaType(err) {
+		t.Fatalf("expected an unsupported media type error from the first create, got %v", err)
+	}
+	// The rejection is remembered, so the next request to the same endpoint downgrades.
+	if _, err := foos.Create(context.TODO(), foo, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("unexpected error from the second create: %v", err)
+	}
+	if expected := []string{runtime.ContentTypeCBOR, runtime.ContentTypeJSON}; len(contentTypes) != 2 || contentTypes[0] != expected[0] || contentTypes[1] != expected[1] {
+		t.Errorf("expected request content types %v, got %v", expected, contentTypes)
+	}
+}
@@ -53,6 +53,7 @@ import (
 
 	// TODO: Remove the following imports (ref: https://github.com/kubernetes/kubernetes/issues/81245)
 	e2emetrics "k8s.io/kubernetes/test/e2e/framework/metrics"
+	testutils "k8s.io/kubernetes/test/utils"
 )
 
 const (
@@ -518,6 +519,18 @@ func (f *Framework) ClientConfig() *rest.Config {
 	return ret
 }
 
+// RunListWatchLoadComparison issues pod LIST/WATCH load against the cluster apiserver in
+// each of the given content types and returns the apiserver resource usage deltas per
+// format, so scalability runs can compare CBOR against protobuf and JSON.
+func (f *Framework) RunListWatchLoadComparison(contentTypes []string, lists, watches int) ([]testutils.ListWatchLoadResult, error) {
+	return testutils.RunListWatchLoadComparison(testutils.ListWatchLoadConfig{
+		Config:    rest.CopyConfig(f.clientConfig),
+		Namespace: f.Namespace.Name,
+		Lists:     lists,
+		Watches:   watches,
+	}, contentTypes)
+}
+
 // TestContainerOutput runs the given pod in the given namespace and waits
 // for all of the containers in the podSpec to move into the 'Success' status, and tests
 // the specified container log against the given expected output using a substring matcher.
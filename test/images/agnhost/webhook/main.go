@@ -17,7 +17,6 @@ limitations under the License.
 package webhook
 
 import (
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -98,17 +97,9 @@ func serve(w http.ResponseWriter, r *http.Request, admit admitHandler) {
 		}
 	}
 
-	// verify the content type is accurate
-	contentType := r.Header.Get("Content-Type")
-	if contentType != "application/json" {
-		klog.Errorf("contentType=%s, expect application/json", contentType)
-		return
-	}
-
 	klog.V(2).Info(fmt.Sprintf("handling request: %s", body))
 
-	deserializer := codecs.UniversalDeserializer()
-	obj, gvk, err := deserializer.Decode(body, nil, nil)
+	obj, gvk, err := reviewCodec.DecodeRequest(r, body)
 	if err != nil {
 		msg := fmt.Sprintf("Request could not be decoded: %v", err)
 		klog.Error(msg)
@@ -148,15 +139,9 @@ func serve(w http.ResponseWriter, r *http.Request, admit admitHandler) {
 	}
 
 	klog.V(2).Info(fmt.Sprintf("sending response: %v", responseObj))
-	respBytes, err := json.Marshal(responseObj)
-	if err != nil {
+	if err := reviewCodec.EncodeResponse(w, r, responseObj); err != nil {
 		klog.Error(err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	w.Header().Set("Content-Type", "application/json")
-	if _, err := w.Write(respBytes); err != nil {
-		klog.Error(err)
 	}
 }
 
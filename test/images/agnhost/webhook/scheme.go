@@ -25,11 +25,16 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	testwebhook "k8s.io/kubernetes/test/utils/webhook"
 )
 
 var scheme = runtime.NewScheme()
 var codecs = serializer.NewCodecFactory(scheme)
 
+// reviewCodec decodes admission review requests and encodes responses in the format the
+// request was made in, so that the same handlers serve JSON and CBOR clients.
+var reviewCodec = testwebhook.NewCodec(scheme)
+
 func init() {
 	addToScheme(scheme)
 }
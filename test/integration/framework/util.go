@@ -31,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 	v1helper "k8s.io/kubernetes/pkg/apis/core/v1/helper"
 	nodectlr "k8s.io/kubernetes/pkg/controller/nodelifecycle"
@@ -64,6 +65,18 @@ func DeleteTestingNamespace(ns *v1.Namespace, apiserver *httptest.Server, t *tes
 	// TODO: Remove all resources from a given namespace once we implement CreateTestingNamespace.
 }
 
+// RunListWatchLoadComparison drives pod LIST/WATCH load against the given test apiserver
+// once per content type, with client-side throttling disabled, and returns the resource
+// usage deltas observed for each format.
+func RunListWatchLoadComparison(s *httptest.Server, namespace string, contentTypes []string, lists, watches int) ([]testutils.ListWatchLoadResult, error) {
+	return testutils.RunListWatchLoadComparison(testutils.ListWatchLoadConfig{
+		Config:    &restclient.Config{Host: s.URL, QPS: -1},
+		Namespace: namespace,
+		Lists:     lists,
+		Watches:   watches,
+	}, contentTypes)
+}
+
 // GetReadySchedulableNodes addresses the common use case of getting nodes you can do work on.
 // 1) Needs to be schedulable.
 // 2) Needs to be ready.
@@ -0,0 +1,211 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+// ListWatchLoadConfig drives a burst of pod LIST and WATCH requests against an apiserver
+// in a single content type, so that the server-side cost of serving collections can be
+// compared across serialization formats (JSON, protobuf, CBOR).
+type ListWatchLoadConfig struct {
+	// Config is the base client configuration. The content type fields of a copy are
+	// overwritten with ContentType for the duration of the run.
+	Config *restclient.Config
+	// ContentType is the media type negotiated for request and response bodies, for
+	// example runtime.ContentTypeCBOR or runtime.ContentTypeProtobuf.
+	ContentType string
+	// Namespace scopes the LIST and WATCH requests. Empty means all namespaces.
+	Namespace string
+	// Lists is the total number of LIST requests to issue.
+	Lists int
+	// Parallelism bounds the number of LIST requests in flight at once. Zero means 1.
+	Parallelism int
+	// Watches is the number of watches held open while the lists are running.
+	Watches int
+	// WatchDuration is how long each watch is kept open and drained after the last
+	// list completes. Zero means 10 seconds.
+	WatchDuration time.Duration
+}
+
+// ListWatchLoadResult records the wall time of the run together with the apiserver's
+// process accounting deltas over it, as scraped from its /metrics endpoint.
+type ListWatchLoadResult struct {
+	ContentType string
+	Lists       int
+	Watches     int
+	// Duration is the wall time from the first request until the watches were drained.
+	Duration time.Duration
+	// CPUSeconds is the increase in process_cpu_seconds_total over the run.
+	CPUSeconds float64
+	// ResidentMemoryBytes is the change in process_resident_memory_bytes over the run.
+	// It can be negative if the run straddled a garbage collection.
+	ResidentMemoryBytes float64
+}
+
+func (r ListWatchLoadResult) String() string {
+	return fmt.Sprintf("%s: %d lists, %d watches in %v (%.2f cpu-seconds, %+.0f resident bytes)",
+		r.ContentType, r.Lists, r.Watches, r.Duration, r.CPUSeconds, r.ResidentMemoryBytes)
+}
+
+// RunListWatchLoad issues the configured LIST and WATCH requests and returns the
+// apiserver resource usage deltas observed while serving them. Errors from individual
+// requests abort the run.
+func RunListWatchLoad(config ListWatchLoadConfig) (*ListWatchLoadResult, error) {
+	parallelism := config.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	watchDuration := config.WatchDuration
+	if watchDuration == 0 {
+		watchDuration = 10 * time.Second
+	}
+
+	cfg := restclient.CopyConfig(config.Config)
+	cfg.ContentType = config.ContentType
+	cfg.AcceptContentTypes = config.ContentType
+	client, err := clientset.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	startCPU, startMemory, err := apiserverProcessMetrics(client)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, config.Lists+config.Watches)
+	stopWatches := make(chan struct{})
+	for i := 0; i < config.Watches; i++ {
+		w, err := client.CoreV1().Pods(config.Namespace).Watch(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			close(stopWatches)
+			return nil, fmt.Errorf("starting watch %d: %v", i, err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer w.Stop()
+			for {
+				select {
+				case _, ok := <-w.ResultChan():
+					if !ok {
+						return
+					}
+				case <-stopWatches:
+					return
+				}
+			}
+		}()
+	}
+
+	lists := make(chan struct{}, config.Lists)
+	for i := 0; i < config.Lists; i++ {
+		lists <- struct{}{}
+	}
+	close(lists)
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range lists {
+				if _, err := client.CoreV1().Pods(config.Namespace).List(context.TODO(), metav1.ListOptions{}); err != nil {
+					errCh <- err
+					return
+				}
+			}
+		}()
+	}
+
+	time.AfterFunc(watchDuration, func() { close(stopWatches) })
+	wg.Wait()
+	duration := time.Since(start)
+
+	select {
+	case err := <-errCh:
+		return nil, err
+	default:
+	}
+
+	endCPU, endMemory, err := apiserverProcessMetrics(client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListWatchLoadResult{
+		ContentType:         config.ContentType,
+		Lists:               config.Lists,
+		Watches:             config.Watches,
+		Duration:            duration,
+		CPUSeconds:          endCPU - startCPU,
+		ResidentMemoryBytes: endMemory - startMemory,
+	}, nil
+}
+
+// RunListWatchLoadComparison runs the same load once per content type, sequentially, and
+// returns the results in the same order. The base config's ContentType is ignored.
+func RunListWatchLoadComparison(base ListWatchLoadConfig, contentTypes []string) ([]ListWatchLoadResult, error) {
+	results := make([]ListWatchLoadResult, 0, len(contentTypes))
+	for _, contentType := range contentTypes {
+		config := base
+		config.ContentType = contentType
+		result, err := RunListWatchLoad(config)
+		if err != nil {
+			return nil, fmt.Errorf("load with content type %q: %v", contentType, err)
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// apiserverProcessMetrics scrapes the process CPU and resident memory gauges from the
+// apiserver's /metrics endpoint.
+func apiserverProcessMetrics(c clientset.Interface) (cpuSeconds, residentMemoryBytes float64, err error) {
+	body, err := c.CoreV1().RESTClient().Get().AbsPath("/metrics").DoRaw(context.TODO())
+	if err != nil {
+		return 0, 0, fmt.Errorf("scraping metrics: %v", err)
+	}
+	metrics := testutil.NewMetrics()
+	if err := testutil.ParseMetrics(string(body), &metrics); err != nil {
+		return 0, 0, fmt.Errorf("parsing metrics: %v", err)
+	}
+	for _, metric := range []struct {
+		name string
+		into *float64
+	}{
+		{"process_cpu_seconds_total", &cpuSeconds},
+		{"process_resident_memory_bytes", &residentMemoryBytes},
+	} {
+		samples := metrics[metric.name]
+		if len(samples) == 0 {
+			return 0, 0, fmt.Errorf("metric %q not found", metric.name)
+		}
+		*metric.into = float64(samples[0].Value)
+	}
+	return cpuSeconds, residentMemoryBytes, nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhook provides helpers for implementing admission webhook servers in tests,
+// so that test webhooks don't each decode review requests by hand.
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/runtime/serializer/cbor"
+)
+
+// Codec decodes admission review requests and encodes responses, honoring the request's
+// Content-Type header so that the same handler can serve JSON and CBOR clients.
+type Codec struct {
+	codecs serializer.CodecFactory
+}
+
+// NewCodec returns a Codec decoding into the types registered in scheme. JSON and CBOR
+// requests are supported; requests in any other format are rejected.
+func NewCodec(scheme *runtime.Scheme) Codec {
+	return Codec{codecs: serializer.NewCodecFactory(scheme, serializer.WithSerializer(cbor.NewSerializerInfo))}
+}
+
+// DecodeRequest decodes the body of an admission review request into a typed object
+// according to the request's Content-Type header. An empty Content-Type is treated as
+// JSON for the benefit of hand-written clients.
+func (c Codec) DecodeRequest(req *http.Request, body []byte) (runtime.Object, *schema.GroupVersionKind, error) {
+	info, err := c.serializerForContentType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return info.Serializer.Decode(body, nil, nil)
+}
+
+// EncodeResponse writes obj to w in the format the request was made in, setting the
+// response Content-Type to match.
+func (c Codec) EncodeResponse(w http.ResponseWriter, req *http.Request, obj runtime.Object) error {
+	info, err := c.serializerForContentType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", info.MediaType)
+	return info.Serializer.Encode(obj, w)
+}
+
+// serializerForContentType resolves a Content-Type header value, which may carry
+// parameters such as a charset, to one of the supported serializers.
+func (c Codec) serializerForContentType(contentType string) (runtime.SerializerInfo, error) {
+	if idx := strings.Index(contentType, ";"); idx >= 0 {
+		contentType = contentType[:idx]
+	}
+	contentType = strings.TrimSpace(contentType)
+	if len(contentType) == 0 {
+		contentType = runtime.ContentTypeJSON
+	}
+	if contentType != runtime.ContentTypeJSON && contentType != runtime.ContentTypeCBOR {
+		return runtime.SerializerInfo{}, fmt.Errorf("unsupported content type %q, expected %q or %q", contentType, runtime.ContentTypeJSON, runtime.ContentTypeCBOR)
+	}
+	info, ok := runtime.SerializerInfoForMediaType(c.codecs.SupportedMediaTypes(), contentType)
+	if !ok {
+		return runtime.SerializerInfo{}, fmt.Errorf("no serializer registered for %q", contentType)
+	}
+	return info, nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+func testCodec(t *testing.T) Codec {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(admissionv1.AddToScheme(scheme))
+	return NewCodec(scheme)
+}
+
+func testReview() *admissionv1.AdmissionReview {
+	review := &admissionv1.AdmissionReview{
+		Request: &admissionv1.AdmissionRequest{
+			UID:  types.UID("705ab4f5-6393-11e8-b7cc-42010a800002"),
+			Kind: metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		},
+	}
+	review.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("AdmissionReview"))
+	return review
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	codec := testCodec(t)
+	review := testReview()
+
+	for _, contentType := range []string{
+		"application/json",
+		"application/json; charset=UTF-8",
+		"application/cbor",
+		"", // hand-written clients may omit the header; JSON is assumed
+	} {
+		t.Run(contentType, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/validate", nil)
+			if len(contentType) > 0 {
+				req.Header.Set("Content-Type", contentType)
+			}
+			if err := codec.EncodeResponse(recorder, req, review); err != nil {
+				t.Fatalf("unexpected encode error: %v", err)
+			}
+
+			req = httptest.NewRequest("POST", "/validate", bytes.NewReader(recorder.Body.Bytes()))
+			req.Header.Set("Content-Type", recorder.Header().Get("Content-Type"))
+			obj, gvk, err := codec.DecodeRequest(req, recorder.Body.Bytes())
+			if err != nil {
+				t.Fatalf("unexpected decode error: %v", err)
+			}
+			if *gvk != admissionv1.SchemeGroupVersion.WithKind("AdmissionReview") {
+				t.Errorf("unexpected gvk: %v", gvk)
+			}
+			decoded, ok := obj.(*admissionv1.AdmissionReview)
+			if !ok {
+				t.Fatalf("expected *admissionv1.AdmissionReview, got %T", obj)
+			}
+			if decoded.Request == nil || decoded.Request.UID != review.Request.UID {
+				t.Errorf("request did not survive the round trip: %#v", decoded.Request)
+			}
+		})
+	}
+}
+
+func TestCodecResponseContentTypeMatchesRequest(t *testing.T) {
+	codec := testCodec(t)
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/validate", nil)
+	req.Header.Set("Content-Type", "application/cbor")
+	if err := codec.EncodeResponse(recorder, req, testReview()); err != nil {
+		t.Fatalf("unexpected encode error: %v", err)
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "application/cbor" {
+		t.Errorf("expected response Content-Type to match the request, got %q", got)
+	}
+}
+
+func TestCodecRejectsUnsupportedContentType(t *testing.T) {
+	codec := testCodec(t)
+
+	req := httptest.NewRequest("POST", "/validate", nil)
+	req.Header.Set("Content-Type", "application/yaml")
+	if _, _, err := codec.DecodeRequest(req, []byte(`{}`)); err == nil {
+		t.Error("expected decode of unsupported content type to fail")
+	}
+	if err := codec.EncodeResponse(httptest.NewRecorder(), req, testReview()); err == nil {
+		t.Error("expected encode for unsupported content type to fail")
+	}
+}